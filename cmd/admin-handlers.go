@@ -24,6 +24,7 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -93,6 +94,89 @@ func (adminAPI adminAPIHandlers) ServiceStatusHandler(w http.ResponseWriter, r *
 	writeSuccessResponseJSON(w, jsonBytes)
 }
 
+// ServerProperties - holds the version, uptime and region of a server.
+type ServerProperties struct {
+	Uptime        time.Duration `json:"uptime"`
+	Version       string        `json:"version"`
+	CommitID      string        `json:"commitID"`
+	Region        string        `json:"region"`
+	ReadOnly      bool          `json:"readOnly"`
+	HealBandwidth uint64        `json:"healBandwidth"`
+}
+
+// ServerInfoData - holds the storage, requests and properties of a server.
+type ServerInfoData struct {
+	StorageInfo StorageInfo      `json:"storage"`
+	MuxStats    ServerMuxStats   `json:"mux"`
+	Properties  ServerProperties `json:"server"`
+	Quorum      QuorumStatus     `json:"quorum"`
+	RPCRetry    RPCRetryStats    `json:"rpcRetry"`
+	NodeLatency []NodeLatency    `json:"nodeLatency"`
+}
+
+// ServerInfo - holds the server info of a node, Error is set when the
+// node could not be reached.
+type ServerInfo struct {
+	Error string          `json:"error"`
+	Addr  string          `json:"addr"`
+	Data  *ServerInfoData `json:"data"`
+}
+
+// getLocalServerInfoData - returns the server info of this server.
+func getLocalServerInfoData() (ServerInfoData, error) {
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		return ServerInfoData{}, errServerNotInitialized
+	}
+
+	var muxStats ServerMuxStats
+	if globalServerMux != nil {
+		muxStats = globalServerMux.Stats()
+	}
+
+	return ServerInfoData{
+		StorageInfo: objLayer.StorageInfo(),
+		MuxStats:    muxStats,
+		Properties: ServerProperties{
+			Uptime:        time.Now().UTC().Sub(globalBootTime),
+			Version:       Version,
+			CommitID:      CommitID,
+			Region:        serverConfig.GetRegion(),
+			ReadOnly:      globalReadOnlyMode.IsEnabled(),
+			HealBandwidth: globalHealBandwidth.Rate(),
+		},
+		Quorum:      getQuorumStatus(objLayer),
+		RPCRetry:    globalRPCRetryStats.Stats(),
+		NodeLatency: globalNodeLatency.Stats(time.Now().UTC()),
+	}, nil
+}
+
+// ServerInfoHandler - GET /?info
+// HTTP header x-minio-operation: server
+// ----------
+// Fetches server information like version, uptime, region, online
+// and offline disks and total and free capacity. In a distributed
+// setup the information of all the servers is returned.
+func (adminAPI adminAPIHandlers) ServerInfoHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Fetch server information from all the servers.
+	serversInfo := getPeerServersInfo(globalAdminPeers)
+
+	// Marshal API response
+	jsonBytes, err := json.Marshal(serversInfo)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		errorIf(err, "Failed to marshal server info into json.")
+		return
+	}
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
 // ServiceRestartHandler - POST /?service
 // HTTP header x-minio-operation: restart
 // ----------
@@ -181,6 +265,50 @@ func (adminAPI adminAPIHandlers) ServiceCredentialsHandler(w http.ResponseWriter
 	w.WriteHeader(http.StatusOK)
 }
 
+// setReadOnlyReq request
+type setReadOnlyReq struct {
+	ReadOnly bool `xml:"readOnly"`
+}
+
+// ServiceReadOnlyHandler - POST /?service
+// HTTP header x-minio-operation: set-read-only
+// ----------
+// Enables or disables read-only mode of minio server, all the S3 write
+// requests are rejected in read-only mode while reads continue to be
+// served. In a distributed setup, updates all the servers in the cluster.
+func (adminAPI adminAPIHandlers) ServiceReadOnlyHandler(w http.ResponseWriter, r *http.Request) {
+	// Authenticate request
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Load request body
+	inputData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+
+	// Unmarshal request body
+	var req setReadOnlyReq
+	if err = xml.Unmarshal(inputData, &req); err != nil {
+		errorIf(err, "Cannot unmarshal read-only mode request")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+
+	// Update read-only mode on all the servers including this one.
+	peerErrs := setPeersReadOnly(globalAdminPeers, req.ReadOnly)
+	for peer, err := range peerErrs {
+		errorIf(err, "Unable to update read-only mode on peer %s.", peer)
+	}
+
+	// At this stage, the operation is successful, return 200 OK
+	w.WriteHeader(http.StatusOK)
+}
+
 // validateLockQueryParams - Validates query params for list/clear locks management APIs.
 func validateLockQueryParams(vars url.Values) (string, string, time.Duration, APIErrorCode) {
 	bucket := vars.Get(string(mgmtBucket))
@@ -323,11 +451,16 @@ func validateHealQueryParams(vars url.Values) (string, string, string, string, i
 	}
 
 	// Validate prefix, marker, delimiter and maxKey.
-	apiErr := validateListObjectsArgs(prefix, marker, delimiter, maxKey)
+	apiErr := validateListObjectsArgs(prefix, marker, delimiter, "", maxKey)
 	if apiErr != ErrNone {
 		return "", "", "", "", 0, apiErr
 	}
 
+	// Listing objects to heal is only delimited by '/'.
+	if delimiter != "" && delimiter != slashSeparator {
+		return "", "", "", "", 0, ErrNotImplemented
+	}
+
 	return bucket, prefix, marker, delimiter, maxKey, ErrNone
 }
 
@@ -365,7 +498,7 @@ func (adminAPI adminAPIHandlers) ListObjectsHealHandler(w http.ResponseWriter, r
 		return
 	}
 
-	listResponse := generateListObjectsV1Response(bucket, prefix, marker, delimiter, maxKey, objectInfos)
+	listResponse := generateListObjectsV1Response(bucket, prefix, marker, delimiter, "", maxKey, objectInfos)
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(listResponse))
 }
@@ -503,6 +636,116 @@ func (adminAPI adminAPIHandlers) HealObjectHandler(w http.ResponseWriter, r *htt
 	writeSuccessResponseHeadersOnly(w)
 }
 
+// HealObjectsHandler - POST /?heal&bucket=mybucket&prefix=myprefix&dry-run
+// - x-minio-operation = objects
+// - bucket is mandatory query parameter, prefix is optional
+// Heals all the objects needing heal in a given bucket matching the
+// given prefix, several objects at a time. Replies with the number of
+// objects healed and the objects which could not be healed as json.
+func (adminAPI adminAPIHandlers) HealObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	// Get object layer instance.
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Validate request signature.
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Healing objects is only applicable to single node XL and
+	// distributed XL setup.
+	if !globalIsXL {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	// Validate bucket name and check if it exists.
+	vars := r.URL.Query()
+	bucket := vars.Get(string(mgmtBucket))
+	prefix := vars.Get(string(mgmtPrefix))
+	if err := checkBucketExist(bucket, objLayer); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if !IsValidObjectPrefix(prefix) {
+		writeErrorResponse(w, ErrInvalidObjectName, r.URL)
+		return
+	}
+
+	// if dry-run is set in query params then perform validations
+	// and return success.
+	if isDryRun(vars) {
+		writeSuccessResponseHeadersOnly(w)
+		return
+	}
+
+	result, err := healObjects(objLayer, bucket, prefix, globalHealConcurrency)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		errorIf(err, "Failed to marshal heal objects result.")
+		return
+	}
+
+	// Reply with the result of the heal, as json.
+	writeSuccessResponseJSON(w, jsonBytes)
+}
+
+// setHealBandwidthReq request
+type setHealBandwidthReq struct {
+	Bandwidth uint64 `xml:"bandwidth"`
+}
+
+// HealSetBandwidthHandler - POST /?heal
+// HTTP header x-minio-operation: set-bandwidth
+// ----------
+// Sets the rate in bytes per second at which the healed data is written
+// to the drives, 0 is unlimited. The heals in progress are slowed down
+// or sped up right away. In a distributed setup, updates all the
+// servers in the cluster.
+func (adminAPI adminAPIHandlers) HealSetBandwidthHandler(w http.ResponseWriter, r *http.Request) {
+	// Authenticate request
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	// Load request body
+	inputData, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeErrorResponse(w, ErrInternalError, r.URL)
+		return
+	}
+
+	// Unmarshal request body
+	var req setHealBandwidthReq
+	if err = xml.Unmarshal(inputData, &req); err != nil {
+		errorIf(err, "Cannot unmarshal heal bandwidth request")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+
+	// Update heal bandwidth on all the servers including this one.
+	peerErrs := setPeersHealBandwidth(globalAdminPeers, req.Bandwidth)
+	for peer, err := range peerErrs {
+		errorIf(err, "Unable to update heal bandwidth on peer %s.", peer)
+	}
+
+	// At this stage, the operation is successful, return 200 OK
+	w.WriteHeader(http.StatusOK)
+}
+
 // HealFormatHandler - POST /?heal&dry-run
 // - x-minio-operation = format
 // - bucket and object are both mandatory query parameters
@@ -562,6 +805,14 @@ func (adminAPI adminAPIHandlers) HealFormatHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Keep the caches and the indexes of the previous object layer.
+	newObjectAPI, err = wrapObjectLayer(newObjectAPI)
+	if err != nil {
+		fmt.Println(traceError(err))
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Set object layer with newly formatted storage to globalObjectAPI.
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObjectAPI
@@ -576,3 +827,58 @@ func (adminAPI adminAPIHandlers) HealFormatHandler(w http.ResponseWriter, r *htt
 	// Return 200 on success.
 	writeSuccessResponseHeadersOnly(w)
 }
+
+// TraceHandler - GET /?trace&api={api}&status={status}
+// HTTP header x-minio-operation: trace
+// ----------
+// Streams the traces of the requests served by the server as JSON
+// lines until the client disconnects. Traces can be filtered by API
+// like REST.GET.OBJECT and by status like 404 or 5xx. Traces are
+// dropped when the client does not keep up, the number of traces
+// dropped is reported with the next trace sent.
+func (adminAPI adminAPIHandlers) TraceHandler(w http.ResponseWriter, r *http.Request) {
+	adminAPIErr := checkRequestAuthType(r, "", "", "")
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	filter, adminAPIErr := getTraceFilter(r.URL.Query())
+	if adminAPIErr != ErrNone {
+		writeErrorResponse(w, adminAPIErr, r.URL)
+		return
+	}
+
+	subscriber := globalTrace.Subscribe(filter)
+	defer globalTrace.Unsubscribe(subscriber)
+
+	var closeCh <-chan bool
+	if notifier, ok := w.(http.CloseNotifier); ok {
+		closeCh = notifier.CloseNotify()
+	}
+
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", string(mimeJSON))
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	encoder := json.NewEncoder(w)
+	keepAliveTicker := time.NewTicker(traceKeepAliveInterval)
+	defer keepAliveTicker.Stop()
+	for {
+		var err error
+		select {
+		case info := <-subscriber.traceCh:
+			info.Dropped = atomic.SwapUint64(&subscriber.dropped, 0)
+			err = encoder.Encode(info)
+		case <-keepAliveTicker.C:
+			_, err = w.Write([]byte("\n"))
+		case <-closeCh:
+			return
+		}
+		if err != nil {
+			return
+		}
+		w.(http.Flusher).Flush()
+	}
+}
@@ -110,6 +110,7 @@ const (
 	statusCmd cmdType = iota
 	restartCmd
 	setCreds
+	setReadOnlyCmd
 )
 
 // String - String representation for cmdType
@@ -121,6 +122,8 @@ func (c cmdType) String() string {
 		return "restart"
 	case setCreds:
 		return "set-credentials"
+	case setReadOnlyCmd:
+		return "set-read-only"
 	}
 	return ""
 }
@@ -135,6 +138,8 @@ func (c cmdType) apiMethod() string {
 		return "POST"
 	case setCreds:
 		return "POST"
+	case setReadOnlyCmd:
+		return "POST"
 	}
 	return "GET"
 }
@@ -253,6 +258,111 @@ func TestServiceRestartHandler(t *testing.T) {
 	testServicesCmdHandler(restartCmd, t)
 }
 
+// getServerInfoRequest - Constructs a server info management REST API request.
+func getServerInfoRequest(cred credential) (*http.Request, error) {
+	req, err := newTestRequest("GET", "/?info", 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(minioAdminOpHeader, "server")
+
+	// management REST API uses signature V4 for authentication.
+	err = signRequestV4(req, cred.AccessKey, cred.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// Test for server info management REST API.
+func TestServerInfoHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	// Initialize admin peers to make admin RPC calls. Note: In a
+	// single node setup, this degenerates to a simple function
+	// call under the hood.
+	eps, err := parseStorageEndpoints([]string{"http://localhost"})
+	if err != nil {
+		t.Fatalf("Failed to parse storage end point - %v", err)
+	}
+
+	// Set globalMinioAddr to be able to distinguish local endpoints from remote.
+	globalMinioAddr = eps[0].Host
+	initGlobalAdminPeers(eps)
+
+	// Take two disks offline.
+	xl := adminTestBed.objLayer.(*xlObjects)
+	xl.storageDisks[0] = nil
+	xl.storageDisks[1] = nil
+
+	req, err := getServerInfoRequest(serverConfig.GetCredential())
+	if err != nil {
+		t.Fatalf("Failed to build server info request %v", err)
+	}
+	rec := httptest.NewRecorder()
+	adminTestBed.mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected to receive %d status code but received %d. Body (%s)",
+			http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	// Verify the JSON shape of the response.
+	var rawInfo []map[string]json.RawMessage
+	if err = json.Unmarshal(rec.Body.Bytes(), &rawInfo); err != nil {
+		t.Fatalf("Failed to unmarshal server info - %v", err)
+	}
+	if len(rawInfo) != 1 {
+		t.Fatalf("Expected server info of 1 server, but received %d", len(rawInfo))
+	}
+	for _, key := range []string{"addr", "error", "data"} {
+		if _, ok := rawInfo[0][key]; !ok {
+			t.Errorf("Expected %s in server info", key)
+		}
+	}
+
+	var serversInfo []ServerInfo
+	if err = json.Unmarshal(rec.Body.Bytes(), &serversInfo); err != nil {
+		t.Fatalf("Failed to unmarshal server info - %v", err)
+	}
+	serverInfo := serversInfo[0]
+	if serverInfo.Error != "" {
+		t.Fatalf("Expected no error, but received %s", serverInfo.Error)
+	}
+	if serverInfo.Addr != globalMinioAddr {
+		t.Errorf("Expected server address %s, but received %s", globalMinioAddr, serverInfo.Addr)
+	}
+	if serverInfo.Data == nil {
+		t.Fatal("Expected server info data, but received none")
+	}
+	properties := serverInfo.Data.Properties
+	if properties.Version != Version || properties.CommitID != CommitID {
+		t.Errorf("Expected version %s and commit %s, but received %s and %s",
+			Version, CommitID, properties.Version, properties.CommitID)
+	}
+	if properties.Region != serverConfig.GetRegion() {
+		t.Errorf("Expected region %s, but received %s", serverConfig.GetRegion(), properties.Region)
+	}
+	if properties.Uptime <= 0 {
+		t.Errorf("Expected a positive uptime, but received %s", properties.Uptime)
+	}
+	backend := serverInfo.Data.StorageInfo.Backend
+	if backend.Type != XL {
+		t.Errorf("Expected XL backend, but received %v", backend.Type)
+	}
+	if backend.OnlineDisks != len(adminTestBed.xlDirs)-2 || backend.OfflineDisks != 2 {
+		t.Errorf("Expected %d online and 2 offline disks, but received %d and %d",
+			len(adminTestBed.xlDirs)-2, backend.OnlineDisks, backend.OfflineDisks)
+	}
+	if serverInfo.Data.StorageInfo.Total <= 0 || serverInfo.Data.StorageInfo.Free <= 0 {
+		t.Errorf("Expected positive total and free capacity, but received %d and %d",
+			serverInfo.Data.StorageInfo.Total, serverInfo.Data.StorageInfo.Free)
+	}
+}
+
 // Test for service set creds management REST API.
 func TestServiceSetCreds(t *testing.T) {
 	adminTestBed, err := prepareAdminXLTestBed()
@@ -333,6 +443,57 @@ func TestServiceSetCreds(t *testing.T) {
 	}
 }
 
+// Test for service set read-only management REST API.
+func TestServiceSetReadOnly(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+	defer globalReadOnlyMode.Set(false)
+
+	// Initialize admin peers to make admin RPC calls. Note: In a
+	// single node setup, this degenerates to a simple function
+	// call under the hood.
+	eps, err := parseStorageEndpoints([]string{"http://localhost"})
+	if err != nil {
+		t.Fatalf("Failed to parse storage end point - %v", err)
+	}
+
+	// Set globalMinioAddr to be able to distinguish local endpoints from remote.
+	globalMinioAddr = eps[0].Host
+	initGlobalAdminPeers(eps)
+
+	credentials := serverConfig.GetCredential()
+	testCases := []struct {
+		body               []byte
+		expectedStatusCode int
+		expectedReadOnly   bool
+	}{
+		// Enable read-only mode.
+		{[]byte("<setReadOnlyReq><readOnly>true</readOnly></setReadOnlyReq>"), http.StatusOK, true},
+		// Malformed request, mode stays unchanged.
+		{[]byte("<setReadOnlyReq><readOnly>"), http.StatusBadRequest, true},
+		// Disable read-only mode.
+		{[]byte("<setReadOnlyReq><readOnly>false</readOnly></setReadOnlyReq>"), http.StatusOK, false},
+	}
+	for i, testCase := range testCases {
+		req, err := getServiceCmdRequest(setReadOnlyCmd, credentials, testCase.body)
+		if err != nil {
+			t.Fatalf("Failed to build service set read-only request %v", err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected status code %d, found %d. Body (%s)",
+				i+1, testCase.expectedStatusCode, rec.Code, rec.Body.String())
+		}
+		if globalReadOnlyMode.IsEnabled() != testCase.expectedReadOnly {
+			t.Errorf("Test %d: Expected read-only mode to be %v", i+1, testCase.expectedReadOnly)
+		}
+	}
+}
+
 // mkLockQueryVal - helper function to build lock query param.
 func mkLockQueryVal(bucket, prefix, durationStr string) url.Values {
 	qVal := url.Values{}
@@ -952,6 +1113,179 @@ func TestHealObjectHandler(t *testing.T) {
 	}
 }
 
+// TestHealObjectsHandler - test for HealObjectsHandler.
+func TestHealObjectsHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+
+	// Create objects under bucket mybucket missing on the first disk.
+	bucketName := "mybucket"
+	err = adminTestBed.objLayer.MakeBucket(bucketName)
+	if err != nil {
+		t.Fatalf("Failed to make bucket %s - %v", bucketName, err)
+	}
+	objNames := []string{"myprefix/myobject1", "myprefix/myobject2", "myprefix/myobject3", "myobject"}
+	for _, objName := range objNames {
+		_, err = adminTestBed.objLayer.PutObject(bucketName, objName,
+			int64(len("hello")), bytes.NewReader([]byte("hello")), nil, "")
+		if err != nil {
+			t.Fatalf("Failed to create %s - %v", objName, err)
+		}
+		err = removeAll(pathJoin(adminTestBed.xlDirs[0], bucketName, objName))
+		if err != nil {
+			t.Fatalf("Failed to remove %s from the first disk - %v", objName, err)
+		}
+	}
+
+	testCases := []struct {
+		bucket     string
+		prefix     string
+		dryrun     string
+		statusCode int
+		healed     int
+	}{
+		// 1. Invalid bucket name.
+		{
+			bucket:     `invalid\\Bucket`,
+			statusCode: http.StatusBadRequest,
+		},
+		// 2. Bucket not found.
+		{
+			bucket:     "bucketnotfound",
+			statusCode: http.StatusNotFound,
+		},
+		// 3. Valid test case with dry-run, nothing is healed.
+		{
+			bucket:     bucketName,
+			prefix:     "myprefix/",
+			dryrun:     "yes",
+			statusCode: http.StatusOK,
+		},
+		// 4. Valid test case, heals the objects under the prefix.
+		{
+			bucket:     bucketName,
+			prefix:     "myprefix/",
+			statusCode: http.StatusOK,
+			healed:     3,
+		},
+		// 5. Valid test case, heals the remaining objects.
+		{
+			bucket:     bucketName,
+			statusCode: http.StatusOK,
+			healed:     1,
+		},
+	}
+	for i, test := range testCases {
+		// Prepare query params.
+		queryVal := url.Values{}
+		queryVal.Set(string(mgmtBucket), test.bucket)
+		queryVal.Set(string(mgmtPrefix), test.prefix)
+		queryVal.Set("heal", "")
+		if test.dryrun != "" {
+			queryVal.Set(string(mgmtDryRun), test.dryrun)
+		}
+
+		req, err := newTestRequest("POST", "/?"+queryVal.Encode(), 0, nil)
+		if err != nil {
+			t.Fatalf("Test %d - Failed to construct heal objects request - %v", i+1, err)
+		}
+
+		req.Header.Set(minioAdminOpHeader, "objects")
+
+		cred := serverConfig.GetCredential()
+		err = signRequestV4(req, cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d - Failed to sign heal objects request - %v", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if test.statusCode != rec.Code {
+			t.Fatalf("Test %d - Expected HTTP status code %d but received %d", i+1, test.statusCode, rec.Code)
+		}
+		if test.statusCode != http.StatusOK || test.dryrun != "" {
+			continue
+		}
+		var result HealObjectsResult
+		if err = json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+			t.Fatalf("Test %d - Failed to unmarshal heal objects result - %v", i+1, err)
+		}
+		if result.Healed != test.healed || len(result.Failed) != 0 {
+			t.Fatalf("Test %d - Expected %d objects healed, got %#v", i+1, test.healed, result)
+		}
+	}
+
+	// All the objects are healthy.
+	listInfo, err := adminTestBed.objLayer.ListObjectsHeal(bucketName, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("Failed to list objects needing heal - %v", err)
+	}
+	if len(listInfo.Objects) != 0 {
+		t.Fatalf("Expected no object to need heal, got %v", listInfo.Objects)
+	}
+}
+
+// TestHealSetBandwidthHandler - test for HealSetBandwidthHandler.
+func TestHealSetBandwidthHandler(t *testing.T) {
+	adminTestBed, err := prepareAdminXLTestBed()
+	if err != nil {
+		t.Fatal("Failed to initialize a single node XL backend for admin handler tests.")
+	}
+	defer adminTestBed.TearDown()
+	defer globalHealBandwidth.SetRate(0)
+
+	// Initialize admin peers to make admin RPC calls. Note: In a
+	// single node setup, this degenerates to a simple function
+	// call under the hood.
+	eps, err := parseStorageEndpoints([]string{"http://localhost"})
+	if err != nil {
+		t.Fatalf("Failed to parse storage end point - %v", err)
+	}
+
+	// Set globalMinioAddr to be able to distinguish local endpoints from remote.
+	globalMinioAddr = eps[0].Host
+	initGlobalAdminPeers(eps)
+
+	testCases := []struct {
+		body               []byte
+		expectedStatusCode int
+		expectedBandwidth  uint64
+	}{
+		// Limit the heals.
+		{[]byte("<setHealBandwidthReq><bandwidth>1048576</bandwidth></setHealBandwidthReq>"), http.StatusOK, 1048576},
+		// Malformed request, bandwidth stays unchanged.
+		{[]byte("<setHealBandwidthReq><bandwidth>fast</bandwidth></setHealBandwidthReq>"), http.StatusBadRequest, 1048576},
+		// Remove the limit.
+		{[]byte("<setHealBandwidthReq><bandwidth>0</bandwidth></setHealBandwidthReq>"), http.StatusOK, 0},
+	}
+	for i, testCase := range testCases {
+		queryVal := url.Values{}
+		queryVal.Set("heal", "")
+		req, err := newTestRequest("POST", "/?"+queryVal.Encode(), int64(len(testCase.body)), bytes.NewReader(testCase.body))
+		if err != nil {
+			t.Fatalf("Test %d - Failed to construct heal bandwidth request - %v", i+1, err)
+		}
+		req.Header.Set(minioAdminOpHeader, "set-bandwidth")
+
+		cred := serverConfig.GetCredential()
+		err = signRequestV4(req, cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d - Failed to sign heal bandwidth request - %v", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		adminTestBed.mux.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected status code %d, found %d. Body (%s)",
+				i+1, testCase.expectedStatusCode, rec.Code, rec.Body.String())
+		}
+		if rate := globalHealBandwidth.Rate(); rate != testCase.expectedBandwidth {
+			t.Errorf("Test %d: Expected heal bandwidth %d, found %d", i+1, testCase.expectedBandwidth, rate)
+		}
+	}
+}
+
 // TestHealFormatHandler - test for HealFormatHandler.
 func TestHealFormatHandler(t *testing.T) {
 	adminTestBed, err := prepareAdminXLTestBed()
@@ -39,6 +39,19 @@ func registerAdminRouter(mux *router.Router) {
 	// Service update credentials
 	adminRouter.Methods("POST").Queries("service", "").Headers(minioAdminOpHeader, "set-credentials").HandlerFunc(adminAPI.ServiceCredentialsHandler)
 
+	// Service set read-only mode
+	adminRouter.Methods("POST").Queries("service", "").Headers(minioAdminOpHeader, "set-read-only").HandlerFunc(adminAPI.ServiceReadOnlyHandler)
+
+	/// Server information
+
+	// Server info
+	adminRouter.Methods("GET").Queries("info", "").Headers(minioAdminOpHeader, "server").HandlerFunc(adminAPI.ServerInfoHandler)
+
+	/// Trace operations
+
+	// Stream request traces
+	adminRouter.Methods("GET").Queries("trace", "").Headers(minioAdminOpHeader, traceAdminOp).HandlerFunc(adminAPI.TraceHandler)
+
 	/// Lock operations
 
 	// List Locks
@@ -57,6 +70,10 @@ func registerAdminRouter(mux *router.Router) {
 	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "bucket").HandlerFunc(adminAPI.HealBucketHandler)
 	// Heal Objects.
 	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "object").HandlerFunc(adminAPI.HealObjectHandler)
+	// Heal Objects of a bucket.
+	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "objects").HandlerFunc(adminAPI.HealObjectsHandler)
+	// Set Heal Bandwidth.
+	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "set-bandwidth").HandlerFunc(adminAPI.HealSetBandwidthHandler)
 	// Heal Format.
 	adminRouter.Methods("POST").Queries("heal", "").Headers(minioAdminOpHeader, "format").HandlerFunc(adminAPI.HealFormatHandler)
 }
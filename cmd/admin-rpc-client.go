@@ -39,6 +39,9 @@ type adminCmdRunner interface {
 	Restart() error
 	ListLocks(bucket, prefix string, duration time.Duration) ([]VolumeLockInfo, error)
 	ReInitDisks() error
+	ServerInfoData() (ServerInfoData, error)
+	SetReadOnly(readOnly bool) error
+	SetHealBandwidth(bandwidth uint64) error
 }
 
 // Restart - Sends a message over channel to the go-routine
@@ -88,6 +91,49 @@ func (rc remoteAdminClient) ReInitDisks() error {
 	return rc.Call("Admin.ReInitDisks", &args, &reply)
 }
 
+// ServerInfoData - Returns the server info of this server.
+func (lc localAdminClient) ServerInfoData() (ServerInfoData, error) {
+	return getLocalServerInfoData()
+}
+
+// ServerInfoData - Returns the server info of the remote server via RPC.
+func (rc remoteAdminClient) ServerInfoData() (ServerInfoData, error) {
+	args := AuthRPCArgs{}
+	reply := ServerInfoDataReply{}
+	if err := rc.Call("Admin.ServerInfoData", &args, &reply); err != nil {
+		return ServerInfoData{}, err
+	}
+	return reply.ServerInfoData, nil
+}
+
+// SetReadOnly - Enables or disables read-only mode of this server.
+func (lc localAdminClient) SetReadOnly(readOnly bool) error {
+	globalReadOnlyMode.Set(readOnly)
+	return nil
+}
+
+// SetReadOnly - Enables or disables read-only mode of the remote
+// server via RPC.
+func (rc remoteAdminClient) SetReadOnly(readOnly bool) error {
+	args := SetReadOnlyArgs{ReadOnly: readOnly}
+	reply := AuthRPCReply{}
+	return rc.Call("Admin.SetReadOnly", &args, &reply)
+}
+
+// SetHealBandwidth - Sets the heal bandwidth of this server.
+func (lc localAdminClient) SetHealBandwidth(bandwidth uint64) error {
+	globalHealBandwidth.SetRate(bandwidth)
+	return nil
+}
+
+// SetHealBandwidth - Sets the heal bandwidth of the remote server via
+// RPC.
+func (rc remoteAdminClient) SetHealBandwidth(bandwidth uint64) error {
+	args := SetHealBandwidthArgs{Bandwidth: bandwidth}
+	reply := AuthRPCReply{}
+	return rc.Call("Admin.SetHealBandwidth", &args, &reply)
+}
+
 // adminPeer - represents an entity that implements Restart methods.
 type adminPeer struct {
 	addr      string
@@ -241,3 +287,74 @@ func reInitPeerDisks(peers adminPeers) error {
 	wg.Wait()
 	return nil
 }
+
+// getPeerServersInfo - fetches the server info of all the peer servers,
+// error of unreachable peers is reported in their entries.
+func getPeerServersInfo(peers adminPeers) []ServerInfo {
+	serversInfo := make([]ServerInfo, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			serversInfo[idx].Addr = peer.addr
+			serverInfoData, err := peer.cmdRunner.ServerInfoData()
+			if err != nil {
+				errorIf(err, "Unable to get server info from %s.", peer.addr)
+				serversInfo[idx].Error = err.Error()
+				return
+			}
+			serversInfo[idx].Data = &serverInfoData
+		}(i, peer)
+	}
+	wg.Wait()
+	return serversInfo
+}
+
+// setPeersReadOnly - enables or disables read-only mode on all the peer
+// servers, returns the errors of the peers which could not be updated
+// indexed by their address.
+func setPeersReadOnly(peers adminPeers, readOnly bool) map[string]error {
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			errs[idx] = peer.cmdRunner.SetReadOnly(readOnly)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	peerErrs := make(map[string]error)
+	for i, err := range errs {
+		if err != nil {
+			peerErrs[peers[i].addr] = err
+		}
+	}
+	return peerErrs
+}
+
+// setPeersHealBandwidth - sets the heal bandwidth on all the peer
+// servers, returns the errors of the peers which could not be updated
+// indexed by their address.
+func setPeersHealBandwidth(peers adminPeers, bandwidth uint64) map[string]error {
+	errs := make([]error, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(idx int, peer adminPeer) {
+			defer wg.Done()
+			errs[idx] = peer.cmdRunner.SetHealBandwidth(bandwidth)
+		}(i, peer)
+	}
+	wg.Wait()
+
+	peerErrs := make(map[string]error)
+	for i, err := range errs {
+		if err != nil {
+			peerErrs[peers[i].addr] = err
+		}
+	}
+	return peerErrs
+}
@@ -48,6 +48,24 @@ type ListLocksReply struct {
 	volLocks []VolumeLockInfo
 }
 
+// ServerInfoDataReply - wraps the server info response over RPC.
+type ServerInfoDataReply struct {
+	AuthRPCReply
+	ServerInfoData ServerInfoData
+}
+
+// SetReadOnlyArgs - wraps the read-only mode to set over RPC.
+type SetReadOnlyArgs struct {
+	AuthRPCArgs
+	ReadOnly bool
+}
+
+// SetHealBandwidthArgs - wraps the heal bandwidth to set over RPC.
+type SetHealBandwidthArgs struct {
+	AuthRPCArgs
+	Bandwidth uint64
+}
+
 // Restart - Restart this instance of minio server.
 func (s *adminCmd) Restart(args *AuthRPCArgs, reply *AuthRPCReply) error {
 	if err := args.IsAuthenticated(); err != nil {
@@ -68,6 +86,40 @@ func (s *adminCmd) ListLocks(query *ListLocksQuery, reply *ListLocksReply) error
 	return nil
 }
 
+// ServerInfoData - returns the server info of this server.
+func (s *adminCmd) ServerInfoData(args *AuthRPCArgs, reply *ServerInfoDataReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	serverInfoData, err := getLocalServerInfoData()
+	if err != nil {
+		return err
+	}
+	reply.ServerInfoData = serverInfoData
+	return nil
+}
+
+// SetReadOnly - enables or disables read-only mode of this server.
+func (s *adminCmd) SetReadOnly(args *SetReadOnlyArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	globalReadOnlyMode.Set(args.ReadOnly)
+	return nil
+}
+
+// SetHealBandwidth - sets the heal bandwidth of this server.
+func (s *adminCmd) SetHealBandwidth(args *SetHealBandwidthArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	globalHealBandwidth.SetRate(args.Bandwidth)
+	return nil
+}
+
 // ReInitDisk - reinitialize storage disks and object layer to use the
 // new format.
 func (s *adminCmd) ReInitDisks(args *AuthRPCArgs, reply *AuthRPCReply) error {
@@ -94,6 +146,12 @@ func (s *adminCmd) ReInitDisks(args *AuthRPCArgs, reply *AuthRPCReply) error {
 		return err
 	}
 
+	// Keep the caches and the indexes of the previous object layer.
+	newObjectAPI, err = wrapObjectLayer(newObjectAPI)
+	if err != nil {
+		return err
+	}
+
 	// Replace object layer with newly formatted storage.
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObjectAPI
@@ -112,10 +112,19 @@ func TestReInitDisks(t *testing.T) {
 	}
 	authReply := AuthRPCReply{}
 
+	// The new object layer is wrapped as at startup.
+	defer func(isGetCoalescing bool) {
+		globalIsGetCoalescing = isGetCoalescing
+	}(globalIsGetCoalescing)
+	globalIsGetCoalescing = true
+
 	err = adminServer.ReInitDisks(&authArgs, &authReply)
 	if err != nil {
 		t.Errorf("Expected to pass, but failed with %v", err)
 	}
+	if _, ok := newObjectLayerFn().(*coalescingObjects); !ok {
+		t.Errorf("Expected the object layer to coalesce the reads, got %T", newObjectLayerFn())
+	}
 
 	// Negative test case with admin rpc server setup for FS.
 	globalIsXL = false
@@ -144,3 +153,59 @@ func TestReInitDisks(t *testing.T) {
 			errUnsupportedBackend, err)
 	}
 }
+
+// TestAdminServerInfoData - test for Admin.ServerInfoData RPC service.
+func TestAdminServerInfoData(t *testing.T) {
+	// Reset global variables to start afresh.
+	resetTestGlobals()
+
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Unable to initialize server config. %s", err)
+	}
+	defer removeAll(rootPath)
+
+	adminServer := adminCmd{}
+	creds := serverConfig.GetCredential()
+	args := LoginRPCArgs{
+		Username:    creds.AccessKey,
+		Password:    creds.SecretKey,
+		Version:     Version,
+		RequestTime: time.Now().UTC(),
+	}
+	reply := LoginRPCReply{}
+	err = adminServer.Login(&args, &reply)
+	if err != nil {
+		t.Fatalf("Failed to login to admin server - %v", err)
+	}
+	authArgs := AuthRPCArgs{
+		AuthToken:   reply.AuthToken,
+		RequestTime: time.Now().UTC(),
+	}
+
+	// Object layer is not initialized yet.
+	infoReply := ServerInfoDataReply{}
+	if err = adminServer.ServerInfoData(&authArgs, &infoReply); err != errServerNotInitialized {
+		t.Errorf("Expected %v, but received %v", errServerNotInitialized, err)
+	}
+
+	objLayer, fsDir, err := prepareFS()
+	if err != nil {
+		t.Fatalf("Unable to initialize FS backend. %s", err)
+	}
+	defer removeAll(fsDir)
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = objLayer
+	globalObjLayerMutex.Unlock()
+
+	infoReply = ServerInfoDataReply{}
+	if err = adminServer.ServerInfoData(&authArgs, &infoReply); err != nil {
+		t.Fatalf("Expected to pass, but failed with %v", err)
+	}
+	if infoReply.ServerInfoData.StorageInfo.Backend.Type != FS {
+		t.Errorf("Expected FS backend, but received %v", infoReply.ServerInfoData.StorageInfo.Backend.Type)
+	}
+	if infoReply.ServerInfoData.Properties.Version != Version {
+		t.Errorf("Expected version %s, but received %s", Version, infoReply.ServerInfoData.Properties.Version)
+	}
+}
@@ -0,0 +1,250 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Maximum number of traces queued for a subscriber, the traces
+	// are dropped for the subscriber once its queue is full.
+	traceQueueSize = 1000
+
+	// Interval after which an empty line is written to the idle
+	// subscribers to keep the connection active.
+	traceKeepAliveInterval = 5 * time.Second
+
+	// Admin operation of the trace requests.
+	traceAdminOp = "trace"
+)
+
+// Traces the requests for the subscribers of the admin trace API.
+var globalTrace = newTracePublisher()
+
+// TraceInfo - trace of a request served, sent to the subscribers as a
+// JSON line.
+type TraceInfo struct {
+	Time          time.Time     `json:"time"`
+	API           string        `json:"api"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+	StatusCode    int           `json:"statusCode"`
+	ErrorCode     string        `json:"errorCode,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	BytesReceived int64         `json:"bytesReceived"`
+	BytesSent     int64         `json:"bytesSent"`
+	RemoteAddr    string        `json:"remoteAddr"`
+	RequestID     string        `json:"requestID,omitempty"`
+	// Number of traces dropped before this one as the subscriber
+	// did not keep up.
+	Dropped uint64 `json:"dropped,omitempty"`
+}
+
+// traceFilter - APIs and statuses of the traces a subscriber receives,
+// all the traces are received if empty.
+type traceFilter struct {
+	apis     []string
+	statuses []string
+}
+
+// getTraceFilter - parses the `api` and `status` query params of a
+// trace request. A status is either a status code like 404 or a class
+// of status codes like 5xx.
+func getTraceFilter(query url.Values) (traceFilter, APIErrorCode) {
+	filter := traceFilter{
+		apis:     query["api"],
+		statuses: query["status"],
+	}
+	for _, status := range filter.statuses {
+		code := strings.TrimSuffix(strings.ToLower(status), "xx")
+		if len(code) != 1 && len(code) != 3 {
+			return traceFilter{}, ErrInvalidTraceFilter
+		}
+		if _, err := strconv.Atoi(code); err != nil {
+			return traceFilter{}, ErrInvalidTraceFilter
+		}
+	}
+	return filter, ErrNone
+}
+
+// match - returns true if the trace passes the filter.
+func (f traceFilter) match(info TraceInfo) bool {
+	if len(f.apis) > 0 && !contains(f.apis, info.API) {
+		return false
+	}
+	if len(f.statuses) == 0 {
+		return true
+	}
+	statusCode := strconv.Itoa(info.StatusCode)
+	for _, status := range f.statuses {
+		if code := strings.TrimSuffix(strings.ToLower(status), "xx"); strings.HasPrefix(statusCode, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// traceSubscriber - queue of the traces of a subscriber.
+type traceSubscriber struct {
+	// Number of traces dropped since the last trace received,
+	// accessed atomically.
+	dropped uint64
+
+	filter  traceFilter
+	traceCh chan TraceInfo
+}
+
+// tracePublisher - sends the traces to all the subscribers. Publishing
+// never blocks the request path, traces are dropped for the subscribers
+// not keeping up.
+type tracePublisher struct {
+	// Number of subscribers, accessed atomically.
+	count int32
+
+	mu          sync.RWMutex
+	subscribers map[*traceSubscriber]struct{}
+}
+
+func newTracePublisher() *tracePublisher {
+	return &tracePublisher{
+		subscribers: make(map[*traceSubscriber]struct{}),
+	}
+}
+
+// HasSubscribers - returns true if any subscriber is receiving traces.
+func (p *tracePublisher) HasSubscribers() bool {
+	return atomic.LoadInt32(&p.count) > 0
+}
+
+// Subscribe - adds a subscriber receiving the traces passing the filter.
+func (p *tracePublisher) Subscribe(filter traceFilter) *traceSubscriber {
+	subscriber := &traceSubscriber{
+		filter:  filter,
+		traceCh: make(chan TraceInfo, traceQueueSize),
+	}
+	p.mu.Lock()
+	p.subscribers[subscriber] = struct{}{}
+	p.mu.Unlock()
+	atomic.AddInt32(&p.count, 1)
+	return subscriber
+}
+
+// Unsubscribe - removes the subscriber.
+func (p *tracePublisher) Unsubscribe(subscriber *traceSubscriber) {
+	p.mu.Lock()
+	delete(p.subscribers, subscriber)
+	p.mu.Unlock()
+	atomic.AddInt32(&p.count, -1)
+}
+
+// Publish - queues the trace for the subscribers it passes the filter
+// of, the trace is dropped for the subscribers with a full queue.
+func (p *tracePublisher) Publish(info TraceInfo) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for subscriber := range p.subscribers {
+		if !subscriber.filter.match(info) {
+			continue
+		}
+		select {
+		case subscriber.traceCh <- info:
+		default:
+			atomic.AddUint64(&subscriber.dropped, 1)
+		}
+	}
+}
+
+// isTraceRequest - returns true for the admin trace requests, which
+// are not traced themselves.
+func isTraceRequest(r *http.Request) bool {
+	_, ok := r.URL.Query()["trace"]
+	return ok && r.Header.Get(minioAdminOpHeader) == traceAdminOp
+}
+
+// getTraceAPI - returns the API of the request, ADMIN.<operation> for
+// the admin requests and REST.<method>.<resource> otherwise.
+func getTraceAPI(r *http.Request, object string) string {
+	if op := r.Header.Get(minioAdminOpHeader); op != "" {
+		return "ADMIN." + strings.ToUpper(op)
+	}
+	return getAccessLogOperation(r, object)
+}
+
+// traceBodyReader - counts the bytes of the request body read.
+type traceBodyReader struct {
+	io.ReadCloser
+	bytesRead int64
+}
+
+func (r *traceBodyReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.bytesRead += int64(n)
+	return n, err
+}
+
+type traceHandler struct {
+	handler http.Handler
+}
+
+// setTraceHandler - traces the S3 and admin requests while the admin
+// trace API has subscribers. Internal requests of the server are not
+// traced.
+func setTraceHandler(h http.Handler) http.Handler {
+	return traceHandler{h}
+}
+
+func (h traceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !globalTrace.HasSubscribers() || isTraceRequest(r) ||
+		strings.HasPrefix(r.URL.Path, reservedBucket+slashSeparator) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	_, object := urlPath2BucketObjectName(r.URL)
+	api := getTraceAPI(r, object)
+	startTime := time.Now().UTC()
+	body := &traceBodyReader{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = body
+	}
+	// Status, error code and bytes sent are recorded like for the
+	// access logs.
+	tw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	h.handler.ServeHTTP(tw, r)
+
+	globalTrace.Publish(TraceInfo{
+		Time:          startTime,
+		API:           api,
+		Method:        r.Method,
+		Path:          r.URL.RequestURI(),
+		StatusCode:    tw.status,
+		ErrorCode:     tw.errorCode(),
+		Duration:      time.Since(startTime),
+		BytesReceived: body.bytesRead,
+		BytesSent:     tw.bytesSent,
+		RemoteAddr:    r.RemoteAddr,
+		RequestID:     tw.Header().Get(responseRequestIDKey),
+	})
+}
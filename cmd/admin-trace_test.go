@@ -0,0 +1,272 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests parsing and matching of the trace filters.
+func TestTraceFilter(t *testing.T) {
+	testCases := []struct {
+		query       url.Values
+		info        TraceInfo
+		expectedErr APIErrorCode
+		match       bool
+	}{
+		// Test case - 1.
+		// Empty filter matches all the traces.
+		{url.Values{}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 200}, ErrNone, true},
+		// Test case - 2.
+		{url.Values{"api": {"REST.GET.OBJECT"}}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 200}, ErrNone, true},
+		// Test case - 3.
+		{url.Values{"api": {"REST.PUT.OBJECT", "REST.GET.OBJECT"}}, TraceInfo{API: "REST.HEAD.OBJECT", StatusCode: 200}, ErrNone, false},
+		// Test case - 4.
+		{url.Values{"status": {"404"}}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 404}, ErrNone, true},
+		// Test case - 5.
+		{url.Values{"status": {"404"}}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 403}, ErrNone, false},
+		// Test case - 6.
+		{url.Values{"status": {"5xx", "4XX"}}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 403}, ErrNone, true},
+		// Test case - 7.
+		{url.Values{"api": {"REST.GET.OBJECT"}, "status": {"5xx"}}, TraceInfo{API: "REST.GET.OBJECT", StatusCode: 200}, ErrNone, false},
+		// Test case - 8.
+		// Invalid statuses.
+		{url.Values{"status": {"40"}}, TraceInfo{}, ErrInvalidTraceFilter, false},
+		// Test case - 9.
+		{url.Values{"status": {"error"}}, TraceInfo{}, ErrInvalidTraceFilter, false},
+	}
+	for i, testCase := range testCases {
+		filter, err := getTraceFilter(testCase.query)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected error %d, got %d", i+1, testCase.expectedErr, err)
+		}
+		if err != ErrNone {
+			continue
+		}
+		if match := filter.match(testCase.info); match != testCase.match {
+			t.Errorf("Test %d: Expected match %t, got %t", i+1, testCase.match, match)
+		}
+	}
+}
+
+// Tests that the traces are dropped for a subscriber not keeping up
+// without blocking the publisher.
+func TestTracePublisherDrops(t *testing.T) {
+	publisher := newTracePublisher()
+	if publisher.HasSubscribers() {
+		t.Fatal("Expected no subscribers")
+	}
+	slow := publisher.Subscribe(traceFilter{})
+	filtered := publisher.Subscribe(traceFilter{statuses: []string{"5xx"}})
+	if !publisher.HasSubscribers() {
+		t.Fatal("Expected subscribers")
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		for i := 0; i < traceQueueSize+10; i++ {
+			publisher.Publish(TraceInfo{StatusCode: http.StatusOK})
+		}
+		publisher.Publish(TraceInfo{StatusCode: http.StatusInternalServerError})
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected publishing not to block")
+	}
+
+	if len(slow.traceCh) != traceQueueSize || slow.dropped != 11 {
+		t.Fatalf("Expected %d queued and 11 dropped traces, got %d and %d", traceQueueSize, len(slow.traceCh), slow.dropped)
+	}
+	if len(filtered.traceCh) != 1 || filtered.dropped != 0 {
+		t.Fatalf("Expected only the filtered trace, got %d queued and %d dropped traces", len(filtered.traceCh), filtered.dropped)
+	}
+
+	publisher.Unsubscribe(slow)
+	publisher.Unsubscribe(filtered)
+	if publisher.HasSubscribers() {
+		t.Fatal("Expected no subscribers")
+	}
+}
+
+// Tests the admin trace API streaming the traces of concurrent
+// requests to the subscribers.
+func TestAdminTraceHandler(t *testing.T) {
+	initNSLock(false)
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	newRequest := func(method, urlStr string, body []byte, header http.Header) *http.Request {
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("Failed to create HTTP request: <ERROR> %v", err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, testServer.AccessKey, testServer.SecretKey); err != nil {
+			t.Fatalf("Failed to sign HTTP request: <ERROR> %v", err)
+		}
+		return req
+	}
+	client := http.Client{}
+
+	// Subscribes to the traces, the traces are sent on the returned
+	// channel until the subscription is closed.
+	subscribe := func(query url.Values) (<-chan TraceInfo, func()) {
+		query.Set("trace", "")
+		header := http.Header{}
+		header.Set(minioAdminOpHeader, traceAdminOp)
+		resp, err := client.Do(newRequest("GET", testServer.Server.URL+"/?"+query.Encode(), nil, header))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+		}
+		traceCh := make(chan TraceInfo, 100)
+		go func() {
+			defer close(traceCh)
+			scanner := bufio.NewScanner(resp.Body)
+			for scanner.Scan() {
+				var info TraceInfo
+				if len(scanner.Bytes()) == 0 || json.Unmarshal(scanner.Bytes(), &info) != nil {
+					continue
+				}
+				traceCh <- info
+			}
+		}()
+		return traceCh, func() { resp.Body.Close() }
+	}
+
+	// Invalid filter is rejected.
+	header := http.Header{}
+	header.Set(minioAdminOpHeader, traceAdminOp)
+	resp, err := client.Do(newRequest("GET", testServer.Server.URL+"/?trace&status=bad", nil, header))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, got %d", http.StatusBadRequest, resp.StatusCode)
+	}
+
+	allCh, closeAll := subscribe(url.Values{})
+	defer closeAll()
+	errorCh, closeErrors := subscribe(url.Values{"status": {"4xx"}})
+	defer closeErrors()
+
+	bucket := getRandomBucketName()
+	resp, err = client.Do(newRequest("PUT", testServer.Server.URL+"/"+bucket, nil, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	const requests = 10
+	data := []byte("trace data")
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			object := fmt.Sprintf("object-%d", i)
+			resp, err := client.Do(newRequest("PUT", testServer.Server.URL+"/"+bucket+"/"+object, data, nil))
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		resp, err := client.Do(newRequest("GET", testServer.Server.URL+"/"+bucket+"/missing", nil, nil))
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		resp.Body.Close()
+	}()
+	wg.Wait()
+
+	// All the requests are traced.
+	expected := map[string]bool{"/" + bucket: true, "/" + bucket + "/missing": true}
+	for i := 0; i < requests; i++ {
+		expected[fmt.Sprintf("/%s/object-%d", bucket, i)] = true
+	}
+	timeout := time.After(10 * time.Second)
+	for len(expected) > 0 {
+		select {
+		case info := <-allCh:
+			if !expected[info.Path] {
+				t.Fatalf("Unexpected trace %#v", info)
+			}
+			delete(expected, info.Path)
+			switch {
+			case info.Path == "/"+bucket+"/missing":
+				if info.API != "REST.GET.OBJECT" || info.StatusCode != http.StatusNotFound || info.ErrorCode != "NoSuchKey" {
+					t.Fatalf("Unexpected trace %#v", info)
+				}
+			case info.Path != "/"+bucket:
+				if info.API != "REST.PUT.OBJECT" || info.StatusCode != http.StatusOK ||
+					info.BytesReceived != int64(len(data)) || info.Method != "PUT" {
+					t.Fatalf("Unexpected trace %#v", info)
+				}
+			}
+		case <-timeout:
+			t.Fatalf("Expected traces of %v", expected)
+		}
+	}
+
+	// Only the failed request is traced for the filtered subscriber.
+	select {
+	case info := <-errorCh:
+		if info.Path != "/"+bucket+"/missing" {
+			t.Fatalf("Unexpected trace %#v", info)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected the trace of the failed request")
+	}
+	select {
+	case info, ok := <-errorCh:
+		if ok {
+			t.Fatalf("Unexpected trace %#v", info)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Subscribers are removed once disconnected.
+	closeAll()
+	closeErrors()
+	for i := 0; globalTrace.HasSubscribers(); i++ {
+		if i == 100 {
+			t.Fatal("Expected the subscribers to be removed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
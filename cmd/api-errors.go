@@ -57,6 +57,7 @@ const (
 	ErrInvalidDigest
 	ErrInvalidRange
 	ErrInvalidMaxKeys
+	ErrInvalidEncodingMethod
 	ErrInvalidMaxUploads
 	ErrInvalidMaxParts
 	ErrInvalidPartNumberMarker
@@ -111,6 +112,64 @@ const (
 	ErrInvalidQueryParams
 	ErrBucketAlreadyOwnedByYou
 	ErrInvalidDuration
+	ErrInvalidContentRange
+	ErrInvalidTargetBucketForLogging
+	ErrRequestHeaderFieldsTooLarge
+	ErrInvalidChecksumAlgorithm
+	ErrInvalidTrailer
+	ErrChecksumMismatch
+	ErrInvalidListOrder
+	ErrInvalidContinuationToken
+	ErrNoSuchLifecycleConfiguration
+	ErrReplicationConfigurationNotFound
+	ErrNoSuchContentSniffingConfiguration
+	ErrNoSuchWebsiteConfiguration
+	ErrInvalidRedirectLocation
+	ErrInvalidFanOutKeys
+	ErrInvalidTraceFilter
+	ErrInvalidPartNumber
+	ErrUnsupportedACLGrant
+	ErrObjectLockConfigurationNotFound
+	ErrObjectLocked
+	ErrInvalidObjectRetention
+	ErrObjectLockNotEnabled
+	ErrObjectAlreadyExists
+	ErrNoSuchObjectSizeLimitsConfiguration
+	ErrTooManyBuckets
+	ErrInvalidTruncateLength
+	ErrNoSuchPublicAccessBlockConfiguration
+	ErrInvalidMetadataSearch
+	ErrNoSuchClientCertConfiguration
+	ErrClientCertRequired
+	ErrClientCAsNotConfigured
+	ErrInvalidMetadataFilter
+	ErrObjectContentRejected
+	ErrTorrentObjectTooLarge
+	ErrInvalidObjectResponseHeader
+	ErrInsecureSSECustomerRequest
+	ErrInvalidSSECustomerAlgorithm
+	ErrInvalidSSECustomerKey
+	ErrSSECustomerKeyMD5Mismatch
+	ErrSSEEncryptedObject
+	ErrSSECustomerKeyMismatch
+	ErrEncryptedObjectNotSupported
+	ErrTooManyMultipartUploads
+	ErrNoSuchInventoryConfiguration
+	ErrInvalidInventoryID
+	ErrTooManyInventoryConfigurations
+	ErrInventoryFormatNotSupported
+	ErrInvalidListToken
+	ErrNoSuchContentAddressingConfiguration
+	ErrContentAddressingBucketNotEmpty
+	ErrInvalidContentAddress
+	ErrContentAddressMismatch
+	ErrContentAddressingNotSupported
+	ErrInvalidObjectTTL
+	ErrNoSuchOwnershipControls
+	ErrACLNotSupported
+	ErrInvalidMoveSource
+	ErrMoveDestinationExists
+	ErrTooEarly
 	// Add new error codes here.
 
 	// Bucket notification related errors.
@@ -137,6 +196,9 @@ const (
 	ErrPolicyNesting
 	ErrInvalidObjectName
 	ErrServerNotInitialized
+	ErrServerReadOnly
+	ErrMetadataIndexDisabled
+	ErrMetadataIndexNotReady
 	// Add new extended error codes here.
 	// Please open a https://github.com/minio/minio/issues before adding
 	// new error codes here.
@@ -178,6 +240,11 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Argument maxKeys must be an integer between 0 and 2147483647",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidEncodingMethod: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid Encoding Method specified in Request",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	ErrInvalidMaxParts: {
 		Code:           "InvalidArgument",
 		Description:    "Argument max-parts must be an integer between 0 and 2147483647",
@@ -486,6 +553,296 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Duration provided in the request is invalid.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	ErrInvalidContentRange: {
+		Code:           "InvalidRequest",
+		Description:    "Content-Range must cover the whole object set by x-amz-decoded-content-length for aws-chunked uploads.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTargetBucketForLogging: {
+		Code:           "InvalidTargetBucketForLogging",
+		Description:    "The target bucket for logging does not exist.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrRequestHeaderFieldsTooLarge: {
+		Code:           "RequestHeaderSectionTooLarge",
+		Description:    "Your request header section exceeds the maximum allowed size or number of headers.",
+		HTTPStatusCode: http.StatusRequestHeaderFieldsTooLarge,
+	},
+	ErrInvalidChecksumAlgorithm: {
+		Code:           "InvalidRequest",
+		Description:    "Value for x-amz-checksum-algorithm header is invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTrailer: {
+		Code:           "InvalidRequest",
+		Description:    "The value specified in the x-amz-trailer header is not supported.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrChecksumMismatch: {
+		Code:           "BadDigest",
+		Description:    "The checksum you specified did not match the calculated checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidListOrder: {
+		Code:           "InvalidArgument",
+		Description:    "The x-minio-order value is not supported, or cannot be used along with delimiter and start-after.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidContinuationToken: {
+		Code:           "InvalidArgument",
+		Description:    "The continuation token provided is incorrect.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchLifecycleConfiguration: {
+		Code:           "NoSuchLifecycleConfiguration",
+		Description:    "The lifecycle configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrReplicationConfigurationNotFound: {
+		Code:           "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchContentSniffingConfiguration: {
+		Code:           "NoSuchContentSniffingConfiguration",
+		Description:    "The content sniffing configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrNoSuchWebsiteConfiguration: {
+		Code:           "NoSuchWebsiteConfiguration",
+		Description:    "The specified bucket does not have a website configuration.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidRedirectLocation: {
+		Code:           "InvalidRedirectLocation",
+		Description:    "The website redirect location must have a prefix of 'http://' or 'https://' or '/'.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidFanOutKeys: {
+		Code:           "InvalidArgument",
+		Description:    "The number of keys of the x-minio-fan-out request is zero or exceeds the maximum allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTraceFilter: {
+		Code:           "InvalidArgument",
+		Description:    "The trace status filter must be a status code like 404 or a class of status codes like 5xx.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidPartNumber: {
+		Code:           "InvalidPart",
+		Description:    "Part number must be an integer between 1 and the maximum number of parts, inclusive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrUnsupportedACLGrant: {
+		Code:           "NotImplemented",
+		Description:    "Only FULL_CONTROL, READ and WRITE grants to the bucket owner and the AllUsers group, or the private, public-read and public-read-write canned ACLs are supported.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrObjectLockConfigurationNotFound: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Object is WORM protected and cannot be overwritten or deleted until its retention period expires.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidObjectRetention: {
+		Code:           "InvalidRequest",
+		Description:    "The object lock mode must be GOVERNANCE or COMPLIANCE and the retain until date must be a date in the future, both must be specified together.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLockNotEnabled: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing Object Lock Configuration",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectAlreadyExists: {
+		Code:           "ObjectAlreadyExists",
+		Description:    "An object with the same name already exists, it must be deleted before restoring the object from the trash.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrNoSuchObjectSizeLimitsConfiguration: {
+		Code:           "NoSuchObjectSizeLimitsConfiguration",
+		Description:    "The object size limits configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrTooManyBuckets: {
+		Code:           "TooManyBuckets",
+		Description:    "You have attempted to create more buckets than allowed",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTruncateLength: {
+		Code:           "InvalidArgument",
+		Description:    "The length of the x-minio-truncate request must be an integer between 0 and the size of the object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchPublicAccessBlockConfiguration: {
+		Code:           "NoSuchPublicAccessBlockConfiguration",
+		Description:    "The public access block configuration was not found",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidMetadataSearch: {
+		Code:           "InvalidArgument",
+		Description:    "The x-minio-metadata-search request must have at least one meta predicate, each of them on an indexed metadata key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchClientCertConfiguration: {
+		Code:           "NoSuchClientCertConfiguration",
+		Description:    "The client certificate configuration does not exist",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrClientCertRequired: {
+		Code:           "AccessDenied",
+		Description:    "A verified client certificate is required to write to this bucket.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrClientCAsNotConfigured: {
+		Code:           "InvalidRequest",
+		Description:    "Client certificates can not be required, the server has no client CAs to verify them.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMetadataFilter: {
+		Code:           "InvalidArgument",
+		Description:    "The x-minio-meta-filter value must be a single key=value user metadata match, and cannot be used along with the newest-first order.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectContentRejected: {
+		Code:           "XMinioObjectContentRejected",
+		Description:    "The content of the object was rejected by the server.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrTorrentObjectTooLarge: {
+		Code:           "InvalidRequest",
+		Description:    "Torrents are only generated for the objects up to 5GiB.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectResponseHeader: {
+		Code:           "InvalidArgument",
+		Description:    "The response header requested with x-minio-response-header- is not allowed on the objects.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInsecureSSECustomerRequest: {
+		Code:           "InvalidRequest",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must be made over a secure connection.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerAlgorithm: {
+		Code:           "InvalidArgument",
+		Description:    "Requests specifying Server Side Encryption with Customer provided keys must provide a valid encryption algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidSSECustomerKey: {
+		Code:           "InvalidArgument",
+		Description:    "The secret key was invalid for the specified algorithm.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyMD5Mismatch: {
+		Code:           "InvalidArgument",
+		Description:    "The calculated MD5 hash of the key did not match the hash that was provided.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSEEncryptedObject: {
+		Code:           "InvalidRequest",
+		Description:    "The object was stored using a form of Server Side Encryption. The correct parameters must be provided to retrieve the object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSSECustomerKeyMismatch: {
+		Code:           "AccessDenied",
+		Description:    "The provided customer key does not match the key the object was encrypted with.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrEncryptedObjectNotSupported: {
+		Code:           "NotImplemented",
+		Description:    "Server Side Encryption with Customer provided keys is not supported by this operation.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrTooManyMultipartUploads: {
+		Code:           "TooManyMultipartUploads",
+		Description:    "You have attempted to initiate more multipart uploads in progress in the bucket than allowed, complete or abort some of them first.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchInventoryConfiguration: {
+		Code:           "NoSuchConfiguration",
+		Description:    "The specified configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidInventoryID: {
+		Code:           "InvalidArgument",
+		Description:    "The inventory configuration ID is missing or does not match the ID of the configuration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyInventoryConfigurations: {
+		Code:           "TooManyConfigurations",
+		Description:    "You are attempting to create a new configuration but have already reached the 1,000-configuration limit.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInventoryFormatNotSupported: {
+		Code:           "NotImplemented",
+		Description:    "The inventory report format is not supported, only CSV reports are written.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrInvalidListToken: {
+		Code:           "InvalidToken",
+		Description:    "The continuation token provided is malformed or otherwise invalid.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchContentAddressingConfiguration: {
+		Code:           "NoSuchContentAddressingConfiguration",
+		Description:    "The content addressing configuration does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrContentAddressingBucketNotEmpty: {
+		Code:           "InvalidBucketState",
+		Description:    "Content addressing can only be enabled on an empty bucket.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrInvalidContentAddress: {
+		Code:           "InvalidArgument",
+		Description:    "The object key must be the lowercase hex encoded SHA256 of the object content in a content-addressed bucket.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrContentAddressMismatch: {
+		Code:           "BadDigest",
+		Description:    "The SHA256 of the object content does not match the object key.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrContentAddressingNotSupported: {
+		Code:           "NotImplemented",
+		Description:    "The operation is not supported by content-addressed buckets.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrInvalidObjectTTL: {
+		Code:           "InvalidArgument",
+		Description:    "The object TTL must be a positive number of seconds.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchOwnershipControls: {
+		Code:           "OwnershipControlsNotFoundError",
+		Description:    "The bucket ownership controls were not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrACLNotSupported: {
+		Code:           "AccessControlListNotSupported",
+		Description:    "The bucket does not allow ACLs.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMoveSource: {
+		Code:           "InvalidArgument",
+		Description:    "The x-minio-move-source header must name another object, as /bucket/object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMoveDestinationExists: {
+		Code:           "MoveDestinationExists",
+		Description:    "The destination object of the move already exists.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrTooEarly: {
+		Code:           "TooEarly",
+		Description:    "The request was sent in TLS early data and may be replayed, retry it after the handshake.",
+		HTTPStatusCode: http.StatusTooEarly,
+	},
 
 	/// Bucket notification related errors.
 	ErrEventNotification: {
@@ -545,7 +902,7 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 	ErrStorageFull: {
 		Code:           "XMinioStorageFull",
 		Description:    "Storage backend has reached its minimum free disk threshold. Please delete few objects to proceed.",
-		HTTPStatusCode: http.StatusInternalServerError,
+		HTTPStatusCode: http.StatusInsufficientStorage,
 	},
 	ErrObjectExistsAsDirectory: {
 		Code:           "XMinioObjectExistsAsDirectory",
@@ -577,6 +934,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Server not initialized, please try again.",
 		HTTPStatusCode: http.StatusServiceUnavailable,
 	},
+	ErrServerReadOnly: {
+		Code:           "XMinioServerReadOnly",
+		Description:    "Server is in read-only mode, write operations are not allowed.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrMetadataIndexDisabled: {
+		Code:           "XMinioMetadataIndexDisabled",
+		Description:    "Metadata index is not enabled on this server.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrMetadataIndexNotReady: {
+		Code:           "XMinioMetadataIndexNotReady",
+		Description:    "Metadata index of the bucket is being built, please try again.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 	ErrAdminInvalidAccessKey: {
 		Code:           "XMinioAdminInvalidAccessKey",
 		Description:    "The access key is invalid.",
@@ -606,10 +978,106 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrSignatureDoesNotMatch
 	case errContentSHA256Mismatch:
 		apiErr = ErrContentSHA256Mismatch
+	case errChecksumMismatch:
+		apiErr = ErrChecksumMismatch
 	case errDataTooLarge:
 		apiErr = ErrEntityTooLarge
 	case errDataTooSmall:
 		apiErr = ErrEntityTooSmall
+	case errInvalidListOrderToken:
+		apiErr = ErrInvalidContinuationToken
+	case errNoSuchLifecycleConfig:
+		apiErr = ErrNoSuchLifecycleConfiguration
+	case errInvalidLifecycleConfig:
+		apiErr = ErrMalformedXML
+	case errNoSuchReplicationConfig:
+		apiErr = ErrReplicationConfigurationNotFound
+	case errInvalidReplicationConfig:
+		apiErr = ErrMalformedXML
+	case errNoSuchContentSniffingConfig:
+		apiErr = ErrNoSuchContentSniffingConfiguration
+	case errInvalidContentSniffingConfig:
+		apiErr = ErrMalformedXML
+	case errNoSuchWebsiteConfig:
+		apiErr = ErrNoSuchWebsiteConfiguration
+	case errInvalidWebsiteConfig:
+		apiErr = ErrMalformedXML
+	case errInvalidRequestPaymentConfig:
+		apiErr = ErrMalformedXML
+	case errInvalidAccelerateConfig:
+		apiErr = ErrMalformedXML
+	case errUnsupportedACLGrant:
+		apiErr = ErrUnsupportedACLGrant
+	case errNoSuchObjectLockConfig:
+		apiErr = ErrObjectLockConfigurationNotFound
+	case errInvalidObjectLockConfig:
+		apiErr = ErrMalformedXML
+	case errObjectLocked:
+		apiErr = ErrObjectLocked
+	case errInvalidObjectRetention:
+		apiErr = ErrInvalidObjectRetention
+	case errObjectLockNotEnabled:
+		apiErr = ErrObjectLockNotEnabled
+	case errInvalidTrashConfig:
+		apiErr = ErrMalformedXML
+	case errNoSuchTrashEntry:
+		apiErr = ErrNoSuchKey
+	case errTrashRestoreConflict:
+		apiErr = ErrObjectAlreadyExists
+	case errNoSuchObjectSizeLimitsConfig:
+		apiErr = ErrNoSuchObjectSizeLimitsConfiguration
+	case errInvalidObjectSizeLimitsConfig:
+		apiErr = ErrMalformedXML
+	case errTooManyBuckets:
+		apiErr = ErrTooManyBuckets
+	case errTooManyMultipartUploads:
+		apiErr = ErrTooManyMultipartUploads
+	case errInvalidTruncateLength:
+		apiErr = ErrInvalidTruncateLength
+	case errNoSuchPublicAccessBlockConfig:
+		apiErr = ErrNoSuchPublicAccessBlockConfiguration
+	case errPublicAccessBlocked:
+		apiErr = ErrAccessDenied
+	case errMetadataIndexNotReady:
+		apiErr = ErrMetadataIndexNotReady
+	case errNoSuchClientCertConfig:
+		apiErr = ErrNoSuchClientCertConfiguration
+	case errEncryptedObjectUnsupported:
+		apiErr = ErrEncryptedObjectNotSupported
+	case errNoSuchInventoryConfig:
+		apiErr = ErrNoSuchInventoryConfiguration
+	case errInvalidInventoryConfig:
+		apiErr = ErrMalformedXML
+	case errInvalidInventoryID:
+		apiErr = ErrInvalidInventoryID
+	case errTooManyInventoryConfigs:
+		apiErr = ErrTooManyInventoryConfigurations
+	case errInventoryFormatUnsupported:
+		apiErr = ErrInventoryFormatNotSupported
+	case errInvalidListToken:
+		apiErr = ErrInvalidListToken
+	case errNoSuchContentAddressingConfig:
+		apiErr = ErrNoSuchContentAddressingConfiguration
+	case errInvalidContentAddressingConfig:
+		apiErr = ErrMalformedXML
+	case errContentAddressingBucketNotEmpty:
+		apiErr = ErrContentAddressingBucketNotEmpty
+	case errInvalidContentAddress:
+		apiErr = ErrInvalidContentAddress
+	case errContentAddressMismatch:
+		apiErr = ErrContentAddressMismatch
+	case errContentAddressingUnsupported:
+		apiErr = ErrContentAddressingNotSupported
+	case errInvalidObjectTTL:
+		apiErr = ErrInvalidObjectTTL
+	case errNoSuchOwnershipControlsConfig:
+		apiErr = ErrNoSuchOwnershipControls
+	case errInvalidOwnershipControlsConfig:
+		apiErr = ErrMalformedXML
+	case errACLNotSupported:
+		apiErr = ErrACLNotSupported
+	case errMoveDestinationExists:
+		apiErr = ErrMoveDestinationExists
 
 	}
 
@@ -661,10 +1129,14 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrEntityTooSmall
 	case SHA256Mismatch:
 		apiErr = ErrContentSHA256Mismatch
+	case ObjectContentRejected:
+		apiErr = ErrObjectContentRejected
 	case ObjectTooLarge:
 		apiErr = ErrEntityTooLarge
 	case ObjectTooSmall:
 		apiErr = ErrEntityTooSmall
+	case NotImplemented:
+		apiErr = ErrNotImplemented
 	default:
 		apiErr = ErrInternalError
 	}
@@ -21,7 +21,9 @@ import (
 	"encoding/xml"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -57,24 +59,51 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *h
 	w.Header().Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
 
 	// Set last modified time.
-	lastModified := objInfo.ModTime.UTC().Format(http.TimeFormat)
-	w.Header().Set("Last-Modified", lastModified)
+	w.Header().Set("Last-Modified", formatLastModifiedHeader(objInfo.ModTime))
 
 	// Set Etag if available.
 	if objInfo.MD5Sum != "" {
 		w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
 	}
 
-	// Set all other user defined metadata.
+	// Set the number of parts of multipart objects.
+	if partsCount := getMultipartPartsCount(objInfo.MD5Sum); partsCount > 0 {
+		w.Header().Set("x-amz-mp-parts-count", strconv.Itoa(partsCount))
+	}
+
+	// Set all other user defined metadata, the tags are only counted.
 	for k, v := range objInfo.UserDefined {
+		if k == objectTaggingKey {
+			if tags, err := url.ParseQuery(v); err == nil && len(tags) > 0 {
+				w.Header().Set("x-amz-tagging-count", strconv.Itoa(len(tags)))
+			}
+			continue
+		}
+		// Internal metadata of the server is never sent.
+		if strings.HasPrefix(k, minioInternalMetaPrefix) {
+			continue
+		}
+		// Response headers of the object are sent under their name,
+		// unless no longer allowed.
+		if name, ok := getObjectResponseHeader(k); ok {
+			w.Header().Set(name, v)
+			continue
+		} else if strings.HasPrefix(k, objectResponseHeaderPrefix) {
+			continue
+		}
 		w.Header().Set(k, v)
 	}
 
-	// for providing ranged content
+	// Set expiration of the object if a lifecycle rule applies to it.
+	if expiration := getObjectExpiration(objInfo); expiration != "" {
+		w.Header().Set("x-amz-expiration", expiration)
+	}
+
+	// for providing ranged content, the caller writes the partial
+	// content status.
 	if contentRange != nil && contentRange.offsetBegin > -1 {
 		// Override content-length
 		w.Header().Set("Content-Length", strconv.FormatInt(contentRange.getLength(), 10))
 		w.Header().Set("Content-Range", contentRange.String())
-		w.WriteHeader(http.StatusPartialContent)
 	}
 }
@@ -36,7 +36,7 @@ func getListObjectsV1Args(values url.Values) (prefix, marker, delimiter string,
 }
 
 // Parse bucket url queries for ListObjects V2.
-func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, fetchOwner bool, maxkeys int, encodingType string) {
+func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, fetchOwner, fetchChecksum bool, maxkeys int, encodingType string) {
 	prefix = values.Get("prefix")
 	token = values.Get("continuation-token")
 	startAfter = values.Get("start-after")
@@ -47,6 +47,9 @@ func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimit
 		maxkeys = maxObjectList
 	}
 	fetchOwner = values.Get("fetch-owner") == "true"
+	// Minio extension, the checksums stored with the objects are
+	// listed if requested.
+	fetchChecksum = values.Get("fetch-checksum") == "true"
 	encodingType = values.Get("encoding-type")
 	return
 }
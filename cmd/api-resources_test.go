@@ -27,7 +27,7 @@ func TestListObjectsV2Resources(t *testing.T) {
 	testCases := []struct {
 		values                               url.Values
 		prefix, token, startAfter, delimiter string
-		fetchOwner                           bool
+		fetchOwner, fetchChecksum            bool
 		maxKeys                              int
 		encodingType                         string
 	}{
@@ -38,16 +38,18 @@ func TestListObjectsV2Resources(t *testing.T) {
 				"start-after":        []string{"start-after"},
 				"delimiter":          []string{"/"},
 				"fetch-owner":        []string{"true"},
+				"fetch-checksum":     []string{"true"},
 				"max-keys":           []string{"100"},
 				"encoding-type":      []string{"gzip"},
 			},
-			prefix:       "photos/",
-			token:        "token",
-			startAfter:   "start-after",
-			delimiter:    "/",
-			fetchOwner:   true,
-			maxKeys:      100,
-			encodingType: "gzip",
+			prefix:        "photos/",
+			token:         "token",
+			startAfter:    "start-after",
+			delimiter:     "/",
+			fetchOwner:    true,
+			fetchChecksum: true,
+			maxKeys:       100,
+			encodingType:  "gzip",
 		},
 		{
 			values: url.Values{
@@ -69,7 +71,7 @@ func TestListObjectsV2Resources(t *testing.T) {
 	}
 
 	for i, testCase := range testCases {
-		prefix, token, startAfter, delimiter, fetchOwner, maxKeys, encodingType := getListObjectsV2Args(testCase.values)
+		prefix, token, startAfter, delimiter, fetchOwner, fetchChecksum, maxKeys, encodingType := getListObjectsV2Args(testCase.values)
 		if prefix != testCase.prefix {
 			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.prefix, prefix)
 		}
@@ -85,6 +87,9 @@ func TestListObjectsV2Resources(t *testing.T) {
 		if fetchOwner != testCase.fetchOwner {
 			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.fetchOwner, fetchOwner)
 		}
+		if fetchChecksum != testCase.fetchChecksum {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.fetchChecksum, fetchChecksum)
+		}
 		if maxKeys != testCase.maxKeys {
 			t.Errorf("Test %d: Expected %d, got %d", i+1, testCase.maxKeys, maxKeys)
 		}
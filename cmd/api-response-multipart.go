@@ -16,7 +16,10 @@
 
 package cmd
 
-import "net/http"
+import (
+	"net/http"
+	"net/url"
+)
 
 // Represents additional fields necessary for ErrPartTooSmall S3 error.
 type completeMultipartAPIError struct {
@@ -54,3 +57,22 @@ func writePartSmallErrorResponse(w http.ResponseWriter, r *http.Request, err Par
 	w.Write(encodedErrorResponse)
 	w.(http.Flusher).Flush()
 }
+
+// Represents the additional field of the S3 errors of an invalid part
+// number.
+type partNumberAPIError struct {
+	// Part number which is invalid.
+	PartNumber int
+	// Other default XML error responses.
+	APIErrorResponse
+}
+
+// writePartNumberErrorResponse - writes the error response of an
+// invalid part number along with the part number, so that the clients
+// know which of their parts is rejected.
+func writePartNumberErrorResponse(w http.ResponseWriter, errorCode APIErrorCode, partNumber int, reqURL *url.URL) {
+	apiError := getAPIError(errorCode)
+	errorResponse := getAPIErrorResponse(apiError, reqURL.Path)
+	encodedErrorResponse := encodeResponse(partNumberAPIError{partNumber, errorResponse})
+	writeResponse(w, apiError.HTTPStatusCode, encodedErrorResponse, mimeXML)
+}
@@ -32,12 +32,33 @@ const (
 	maxPartsList      = 1000                       // Limit number of parts in a listPartsResponse.
 )
 
+// formatLastModifiedHeader - formats the last modified time for the
+// Last-Modified header, in RFC1123 format. Last modified times are
+// truncated to seconds on all the surfaces since the HTTP dates have no
+// sub-second precision, so that the headers, the listings and the copy
+// responses agree for the same object.
+func formatLastModifiedHeader(lastModified time.Time) string {
+	return lastModified.UTC().Truncate(time.Second).Format(http.TimeFormat)
+}
+
+// formatLastModified - formats the last modified time for the XML
+// responses, in ISO8601 format.
+func formatLastModified(lastModified time.Time) string {
+	return lastModified.UTC().Truncate(time.Second).Format(timeFormatAMZLong)
+}
+
 // LocationResponse - format for location response.
 type LocationResponse struct {
 	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ LocationConstraint" json:"-"`
 	Location string   `xml:",chardata"`
 }
 
+// PolicyStatus - format for bucket policy status response.
+type PolicyStatus struct {
+	XMLName  xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ PolicyStatus" json:"-"`
+	IsPublic bool     `xml:"IsPublic"`
+}
+
 // ListObjectsResponse - format for list objects response.
 type ListObjectsResponse struct {
 	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult" json:"-"`
@@ -199,6 +220,13 @@ type Object struct {
 	// The class of storage used to store the object.
 	StorageClass   string
 	HealObjectInfo *HealObjectInfo `xml:"HealObjectInfo,omitempty"`
+
+	// Checksum stored with the object when it was written, only set
+	// when requested by fetch-checksum.
+	ChecksumCRC32  string `xml:",omitempty"`
+	ChecksumCRC32C string `xml:",omitempty"`
+	ChecksumSHA1   string `xml:",omitempty"`
+	ChecksumSHA256 string `xml:",omitempty"`
 }
 
 // CopyObjectResponse container returns ETag and LastModified of the successfully copied object
@@ -206,6 +234,13 @@ type CopyObjectResponse struct {
 	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult" json:"-"`
 	LastModified string   // time string of format "2006-01-02T15:04:05.000Z"
 	ETag         string   // md5sum of the copied object.
+
+	// Checksum of the copied object, only one of them is set when
+	// x-amz-checksum-algorithm is requested.
+	ChecksumCRC32  string `xml:",omitempty"`
+	ChecksumCRC32C string `xml:",omitempty"`
+	ChecksumSHA1   string `xml:",omitempty"`
+	ChecksumSHA256 string `xml:",omitempty"`
 }
 
 // CopyObjectPartResponse container returns ETag and LastModified of the successfully copied object
@@ -261,6 +296,27 @@ type DeleteObjectsResponse struct {
 	Errors []DeleteError `xml:"Error,omitempty"`
 }
 
+// FanOutError - error of a key of the fan-out PUT.
+type FanOutError struct {
+	Code    string
+	Message string
+}
+
+// FanOutResult - result of a key of the fan-out PUT, holds the ETag of
+// the stored object or the error.
+type FanOutResult struct {
+	Key   string
+	ETag  string       `xml:",omitempty"`
+	Error *FanOutError `xml:",omitempty"`
+}
+
+// FanOutResponse container for the results of the keys of a fan-out PUT.
+type FanOutResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ FanOutResult" json:"-"`
+
+	Results []FanOutResult `xml:"Result"`
+}
+
 // PostResponse container for POST object request when success_action_status is set to 201
 type PostResponse struct {
 	Bucket   string
@@ -303,8 +359,20 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	return data
 }
 
+// Encoding type of the listings percent-encoding the object names.
+const listEncodingTypeURL = "url"
+
+// s3EncodeName - encodes the object name or prefix of a listing with
+// the requested encoding type, returned unchanged if not requested.
+func s3EncodeName(name, encodingType string) string {
+	if encodingType == listEncodingTypeURL {
+		return getURLEncodedName(name)
+	}
+	return name
+}
+
 // generates an ListObjectsV1 response for the said bucket with other enumerated options.
-func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
+func generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
@@ -318,8 +386,8 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
-		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZLong)
+		content.Key = s3EncodeName(object.Name, encodingType)
+		content.LastModified = formatLastModified(object.ModTime)
 		if object.MD5Sum != "" {
 			content.ETag = "\"" + object.MD5Sum + "\""
 		}
@@ -330,20 +398,20 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 		content.HealObjectInfo = object.HealObjectInfo
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.Prefix = prefix
-	data.Marker = marker
-	data.Delimiter = delimiter
+	data.EncodingType = encodingType
+	data.Prefix = s3EncodeName(prefix, encodingType)
+	data.Marker = s3EncodeName(marker, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
 	data.MaxKeys = maxKeys
 
-	data.NextMarker = resp.NextMarker
+	data.NextMarker = s3EncodeName(resp.NextMarker, encodingType)
 	data.IsTruncated = resp.IsTruncated
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
@@ -351,7 +419,7 @@ func generateListObjectsV1Response(bucket, prefix, marker, delimiter string, max
 }
 
 // generates an ListObjectsV2 response for the said bucket with other enumerated options.
-func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter string, fetchOwner bool, maxKeys int, resp ListObjectsInfo) ListObjectsV2Response {
+func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, encodingType string, fetchOwner, fetchChecksum bool, maxKeys int, resp ListObjectsInfo) ListObjectsV2Response {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
@@ -367,30 +435,33 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 		if object.Name == "" {
 			continue
 		}
-		content.Key = object.Name
-		content.LastModified = object.ModTime.UTC().Format(timeFormatAMZLong)
+		content.Key = s3EncodeName(object.Name, encodingType)
+		content.LastModified = formatLastModified(object.ModTime)
 		if object.MD5Sum != "" {
 			content.ETag = "\"" + object.MD5Sum + "\""
 		}
 		content.Size = object.Size
 		content.StorageClass = globalMinioDefaultStorageClass
 		content.Owner = owner
+		if fetchChecksum {
+			setListObjectChecksum(&content, object.UserDefined)
+		}
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 
-	data.StartAfter = startAfter
-	data.Delimiter = delimiter
-	data.Prefix = prefix
+	data.EncodingType = encodingType
+	data.StartAfter = s3EncodeName(startAfter, encodingType)
+	data.Delimiter = s3EncodeName(delimiter, encodingType)
+	data.Prefix = s3EncodeName(prefix, encodingType)
 	data.MaxKeys = maxKeys
 	data.ContinuationToken = token
 	data.NextContinuationToken = resp.NextMarker
 	data.IsTruncated = resp.IsTruncated
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = s3EncodeName(prefix, encodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
@@ -402,7 +473,7 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectResponse {
 	return CopyObjectResponse{
 		ETag:         "\"" + etag + "\"",
-		LastModified: lastModified.UTC().Format(timeFormatAMZLong),
+		LastModified: formatLastModified(lastModified),
 	}
 }
 
@@ -410,7 +481,7 @@ func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectR
 func generateCopyObjectPartResponse(etag string, lastModified time.Time) CopyObjectPartResponse {
 	return CopyObjectPartResponse{
 		ETag:         "\"" + etag + "\"",
-		LastModified: lastModified.UTC().Format(timeFormatAMZLong),
+		LastModified: formatLastModified(lastModified),
 	}
 }
 
@@ -525,6 +596,8 @@ const (
 	mimeJSON mimeType = "application/json"
 	// Means response type is XML.
 	mimeXML mimeType = "application/xml"
+	// Means response type is a torrent file.
+	mimeTorrent mimeType = "application/x-bittorrent"
 )
 
 // writeSuccessResponseJSON writes success headers and response if any,
@@ -560,10 +633,12 @@ func writeErrorResponse(w http.ResponseWriter, errorCode APIErrorCode, reqURL *u
 	// Generate error response.
 	errorResponse := getAPIErrorResponse(apiError, reqURL.Path)
 	encodedErrorResponse := encodeResponse(errorResponse)
+	setRetryAfterHeader(w, errorCode)
 	writeResponse(w, apiError.HTTPStatusCode, encodedErrorResponse, mimeXML)
 }
 
 func writeErrorResponseHeadersOnly(w http.ResponseWriter, errorCode APIErrorCode) {
 	apiError := getAPIError(errorCode)
+	setRetryAfterHeader(w, errorCode)
 	writeResponse(w, apiError.HTTPStatusCode, nil, mimeNone)
 }
@@ -16,7 +16,11 @@
 
 package cmd
 
-import router "github.com/gorilla/mux"
+import (
+	"net/http"
+
+	router "github.com/gorilla/mux"
+)
 
 // objectAPIHandler implements and provides http handlers for S3 API.
 type objectAPIHandlers struct {
@@ -38,10 +42,8 @@ func registerAPIRouter(mux *router.Router) {
 
 	/// Object operations
 
-	// HeadObject
-	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
 	// CopyObjectPart
-	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F).*?").HandlerFunc(api.CopyObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F|%2f).*?").HandlerFunc(api.CopyObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	// PutObjectPart
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	// ListObjectPxarts
@@ -50,12 +52,27 @@ func registerAPIRouter(mux *router.Router) {
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
 	// NewMultipartUpload
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.NewMultipartUploadHandler).Queries("uploads", "")
+	// RestoreObjectFromTrash
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.RestoreObjectFromTrashHandler).Queries("trashRestore", "")
+	// TruncateObject
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.TruncateObjectHandler).Queries(truncateQueryParam, "")
+	// MoveObject
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.MoveObjectHandler).Queries(moveQueryParam, "")
 	// AbortMultipartUpload
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.AbortMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	// GetObjectTorrent
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectTorrentHandler).Queries("torrent", "")
+
+	/// Not implemented object operations, registered after the
+	/// implemented ones and ahead of the generic object routes.
+	registerNotImplementedObjectAPIs(bucket)
+
+	// HeadObject
+	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
 	// GetObject
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectHandler)
 	// CopyObject
-	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F).*?").HandlerFunc(api.CopyObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/|%2F|%2f).*?").HandlerFunc(api.CopyObjectHandler)
 	// PutObject
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectHandler)
 	// DeleteObject
@@ -67,20 +84,117 @@ func registerAPIRouter(mux *router.Router) {
 	bucket.Methods("GET").HandlerFunc(api.GetBucketLocationHandler).Queries("location", "")
 	// GetBucketPolicy
 	bucket.Methods("GET").HandlerFunc(api.GetBucketPolicyHandler).Queries("policy", "")
+	// GetBucketPolicyStatus
+	bucket.Methods("GET").HandlerFunc(api.GetBucketPolicyStatusHandler).Queries("policyStatus", "")
 	// GetBucketNotification
 	bucket.Methods("GET").HandlerFunc(api.GetBucketNotificationHandler).Queries("notification", "")
+	// GetBucketLogging
+	bucket.Methods("GET").HandlerFunc(api.GetBucketLoggingHandler).Queries("logging", "")
+	// GetBucketLifecycle
+	bucket.Methods("GET").HandlerFunc(api.GetBucketLifecycleHandler).Queries("lifecycle", "")
+	// GetBucketInventory
+	bucket.Methods("GET").HandlerFunc(api.GetBucketInventoryHandler).Queries("inventory", "")
+	// GetBucketReplication
+	bucket.Methods("GET").HandlerFunc(api.GetBucketReplicationHandler).Queries("replication", "")
+	// GetBucketContentSniffing
+	bucket.Methods("GET").HandlerFunc(api.GetBucketContentSniffingHandler).Queries("contentSniffing", "")
+	// GetBucketContentAddressing
+	bucket.Methods("GET").HandlerFunc(api.GetBucketContentAddressingHandler).Queries("contentAddressing", "")
+	// GetBucketOwnershipControls
+	bucket.Methods("GET").HandlerFunc(api.GetBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+	// GetBucketWebsite
+	bucket.Methods("GET").HandlerFunc(api.GetBucketWebsiteHandler).Queries("website", "")
+	// GetBucketACL
+	bucket.Methods("GET").HandlerFunc(api.GetBucketACLHandler).Queries("acl", "")
+	// GetBucketRequestPayment
+	bucket.Methods("GET").HandlerFunc(api.GetBucketRequestPaymentHandler).Queries("requestPayment", "")
+	// GetBucketObjectLockConfig
+	bucket.Methods("GET").HandlerFunc(api.GetBucketObjectLockConfigHandler).Queries("object-lock", "")
+	// GetBucketTrash
+	bucket.Methods("GET").HandlerFunc(api.GetBucketTrashHandler).Queries("trash", "")
+	// GetBucketObjectSizeLimits
+	bucket.Methods("GET").HandlerFunc(api.GetBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+	// GetBucketPublicAccessBlock
+	bucket.Methods("GET").HandlerFunc(api.GetBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+	// GetBucketClientCert
+	bucket.Methods("GET").HandlerFunc(api.GetBucketClientCertHandler).Queries("clientCert", "")
+	// GetBucketAccelerate
+	bucket.Methods("GET").HandlerFunc(api.GetBucketAccelerateHandler).Queries("accelerate", "")
 	// ListenBucketNotification
 	bucket.Methods("GET").HandlerFunc(api.ListenBucketNotificationHandler).Queries("events", "{events:.*}")
 	// ListMultipartUploads
 	bucket.Methods("GET").HandlerFunc(api.ListMultipartUploadsHandler).Queries("uploads", "")
+	// MetadataSearch
+	bucket.Methods("GET").HandlerFunc(api.MetadataSearchHandler).Queries(metadataSearchQueryParam, "")
 	// ListObjectsV2
 	bucket.Methods("GET").HandlerFunc(api.ListObjectsV2Handler).Queries("list-type", "2")
-	// ListObjectsV1 (Legacy)
-	bucket.Methods("GET").HandlerFunc(api.ListObjectsV1Handler)
 	// PutBucketPolicy
 	bucket.Methods("PUT").HandlerFunc(api.PutBucketPolicyHandler).Queries("policy", "")
 	// PutBucketNotification
 	bucket.Methods("PUT").HandlerFunc(api.PutBucketNotificationHandler).Queries("notification", "")
+	// PutBucketLogging
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketLoggingHandler).Queries("logging", "")
+	// PutBucketLifecycle
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketLifecycleHandler).Queries("lifecycle", "")
+	// PutBucketInventory
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketInventoryHandler).Queries("inventory", "")
+	// PutBucketReplication
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketReplicationHandler).Queries("replication", "")
+	// PutBucketContentSniffing
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketContentSniffingHandler).Queries("contentSniffing", "")
+	// PutBucketContentAddressing
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketContentAddressingHandler).Queries("contentAddressing", "")
+	// PutBucketOwnershipControls
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+	// PutBucketWebsite
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketWebsiteHandler).Queries("website", "")
+	// PutBucketACL
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketACLHandler).Queries("acl", "")
+	// PutBucketRequestPayment
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketRequestPaymentHandler).Queries("requestPayment", "")
+	// PutBucketObjectLockConfig
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectLockConfigHandler).Queries("object-lock", "")
+	// PutBucketTrash
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketTrashHandler).Queries("trash", "")
+	// PutBucketObjectSizeLimits
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+	// PutBucketPublicAccessBlock
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+	// PutBucketClientCert
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketClientCertHandler).Queries("clientCert", "")
+	// PutBucketAccelerate
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketAccelerateHandler).Queries("accelerate", "")
+	// FanOutPutObject
+	bucket.Methods("POST").HandlerFunc(api.FanOutPutObjectHandler).Queries(fanOutQueryParam, "")
+	// DeleteBucketPolicy
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPolicyHandler).Queries("policy", "")
+	// DeleteBucketLifecycle
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketLifecycleHandler).Queries("lifecycle", "")
+	// DeleteBucketInventory
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketInventoryHandler).Queries("inventory", "")
+	// DeleteBucketReplication
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketReplicationHandler).Queries("replication", "")
+	// DeleteBucketContentSniffing
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketContentSniffingHandler).Queries("contentSniffing", "")
+	// DeleteBucketContentAddressing
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketContentAddressingHandler).Queries("contentAddressing", "")
+	// DeleteBucketOwnershipControls
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+	// DeleteBucketWebsite
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketWebsiteHandler).Queries("website", "")
+	// DeleteBucketObjectSizeLimits
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+	// DeleteBucketPublicAccessBlock
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+	// DeleteBucketClientCert
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketClientCertHandler).Queries("clientCert", "")
+
+	/// Not implemented bucket operations, registered after the
+	/// implemented ones and ahead of the generic bucket routes.
+	registerNotImplementedBucketAPIs(bucket)
+
+	// ListObjectsV1 (Legacy)
+	bucket.Methods("GET").HandlerFunc(api.ListObjectsV1Handler)
 	// PutBucket
 	bucket.Methods("PUT").HandlerFunc(api.PutBucketHandler)
 	// HeadBucket
@@ -89,8 +203,6 @@ func registerAPIRouter(mux *router.Router) {
 	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(api.PostPolicyBucketHandler)
 	// DeleteMultipleObjects
 	bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler)
-	// DeleteBucketPolicy
-	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPolicyHandler).Queries("policy", "")
 	// DeleteBucket
 	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketHandler)
 
@@ -99,3 +211,50 @@ func registerAPIRouter(mux *router.Router) {
 	// ListBuckets
 	apiRouter.Methods("GET").HandlerFunc(api.ListBucketsHandler)
 }
+
+// Sub-resources of the buckets not implemented by the server, any
+// request naming one of them which is not routed to an implemented API
+// replies with 'NotImplemented' whatever its method.
+var notImplementedBucketResources = []string{
+	"acl",
+	"cors",
+	"lifecycle",
+	"logging",
+	"replication",
+	"tagging",
+	"versions",
+	"requestPayment",
+	"versioning",
+	"website",
+}
+
+// Sub-resources of the objects not implemented by the server.
+var notImplementedObjectResources = []string{
+	"torrent",
+	"acl",
+	"policy",
+}
+
+// registerNotImplementedObjectAPIs - registers the not implemented
+// object sub-resources for all the methods on the bucket router, so
+// that the requests naming them never reach the generic object APIs.
+func registerNotImplementedObjectAPIs(bucket *router.Router) {
+	for _, resource := range notImplementedObjectResources {
+		bucket.Path("/{object:.+}").HandlerFunc(notImplementedHandler).Queries(resource, "")
+	}
+}
+
+// registerNotImplementedBucketAPIs - registers the not implemented
+// bucket sub-resources for all the methods on the bucket router, so
+// that the requests naming them never reach the generic bucket APIs.
+func registerNotImplementedBucketAPIs(bucket *router.Router) {
+	for _, resource := range notImplementedBucketResources {
+		bucket.NewRoute().HandlerFunc(notImplementedHandler).Queries(resource, "")
+	}
+}
+
+// notImplementedHandler - replies back 'NotImplemented' error for all
+// the S3 APIs which are not supported by the server.
+func notImplementedHandler(w http.ResponseWriter, r *http.Request) {
+	writeErrorResponse(w, ErrNotImplemented, r.URL)
+}
@@ -0,0 +1,93 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the requests naming a not implemented sub-resource reply with
+// 'NotImplemented' whatever their method, they never reach the generic
+// bucket and object APIs.
+func TestNotImplementedSubResources(t *testing.T) {
+	ExecObjectLayerAPITest(t, testNotImplementedSubResources, nil)
+}
+
+func testNotImplementedSubResources(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	objectName := "object"
+	data := []byte("not implemented")
+	if _, err := obj.PutObject(bucketName, objectName, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		method string
+		url    string
+	}{
+		// Bucket sub-resources.
+		{"DELETE", "/" + bucketName + "?versioning"},
+		{"POST", "/" + bucketName + "?versioning"},
+		{"DELETE", "/" + bucketName + "?acl"},
+		{"POST", "/" + bucketName + "?acl"},
+		{"DELETE", "/" + bucketName + "?cors"},
+		{"DELETE", "/" + bucketName + "?tagging"},
+		{"DELETE", "/" + bucketName + "?logging"},
+		{"DELETE", "/" + bucketName + "?requestPayment"},
+		{"POST", "/" + bucketName + "?website"},
+		{"HEAD", "/" + bucketName + "?versions"},
+		// Object sub-resources.
+		{"DELETE", "/" + bucketName + "/" + objectName + "?acl"},
+		{"POST", "/" + bucketName + "/" + objectName + "?acl"},
+		{"DELETE", "/" + bucketName + "/" + objectName + "?policy"},
+		{"POST", "/" + bucketName + "/" + objectName + "?policy"},
+		{"DELETE", "/" + bucketName + "/" + objectName + "?torrent"},
+		{"PUT", "/" + bucketName + "/" + objectName + "?torrent"},
+	}
+	for i, testCase := range testCases {
+		req, err := newTestSignedRequestV4(testCase.method, testCase.url, 0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Test %d: Failed to create HTTP request: <ERROR> %v", instanceType, i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: Test %d: %s %s: Expected the response status to be `%d`, but instead found `%d`",
+				instanceType, i+1, testCase.method, testCase.url, http.StatusNotImplemented, rec.Code)
+		}
+	}
+
+	// Neither the bucket nor the object were deleted.
+	if _, err := obj.GetBucketInfo(bucketName); err != nil {
+		t.Fatalf("%s: Expected the bucket to be kept, got %v", instanceType, err)
+	}
+	if _, err := obj.GetObjectInfo(bucketName, objectName); err != nil {
+		t.Fatalf("%s: Expected the object to be kept, got %v", instanceType, err)
+	}
+}
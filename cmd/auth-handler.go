@@ -63,7 +63,7 @@ func isRequestPostPolicySignatureV4(r *http.Request) bool {
 
 // Verify if the request has AWS Streaming Signature Version '4'. This is only valid for 'PUT' operation.
 func isRequestSignStreamingV4(r *http.Request) bool {
-	return r.Header.Get("x-amz-content-sha256") == streamingContentSHA256 && r.Method == httpPUT
+	return isStreamingContentSHA256(r.Header.Get("x-amz-content-sha256")) && r.Method == httpPUT
 }
 
 // Authorization type.
@@ -80,6 +80,7 @@ const (
 	authTypeSigned
 	authTypeSignedV2
 	authTypeJWT
+	authTypeClientCert
 )
 
 // Get request authentication type.
@@ -99,6 +100,9 @@ func getRequestAuthType(r *http.Request) authType {
 	} else if isRequestPostPolicySignatureV4(r) {
 		return authTypePostPolicy
 	} else if _, ok := r.Header["Authorization"]; !ok {
+		if isRequestClientCert(r) {
+			return authTypeClientCert
+		}
 		return authTypeAnonymous
 	}
 	return authTypeUnknown
@@ -121,6 +125,8 @@ func checkRequestAuthType(r *http.Request, bucket, policyAction, region string)
 			errorIf(errSignatureMismatch, dumpRequest(r))
 		}
 		return s3Error
+	case authTypeClientCert:
+		return isReqAuthenticatedByCert(r, policyAction)
 	}
 
 	if reqAuthType == authTypeAnonymous && policyAction != "" {
@@ -208,6 +214,7 @@ var supportedS3AuthTypes = map[authType]struct{}{
 	authTypeSignedV2:        {},
 	authTypePostPolicy:      {},
 	authTypeStreamingSigned: {},
+	authTypeClientCert:      {},
 }
 
 // Validate if the authType is valid and supported.
@@ -171,9 +171,14 @@ func TestS3SupportedAuthType(t *testing.T) {
 			authT: authTypeUnknown,
 			pass:  false,
 		},
-		// Test 10 - some new auth type is not supported s3 type.
+		// Test 10 - supported s3 type with client certificate.
 		{
-			authT: authType(9),
+			authT: authTypeClientCert,
+			pass:  true,
+		},
+		// Test 11 - some new auth type is not supported s3 type.
+		{
+			authT: authType(10),
 			pass:  false,
 		},
 	}
@@ -101,10 +101,21 @@ func (authClient *AuthRPCClient) call(serviceMethod string, args interface {
 }
 
 // Call executes RPC call till success or globalAuthRPCRetryThreshold on ErrShutdown.
+// Idempotent calls are retried on network errors as per globalRPCRetryPolicy,
+// unless reconnect is disabled so that the calls to an offline node fail fast.
 func (authClient *AuthRPCClient) Call(serviceMethod string, args interface {
 	SetAuthToken(authToken string)
 	SetRequestTime(requestTime time.Time)
 }, reply interface{}) (err error) {
+	if idempotentRPCMethods[serviceMethod] && !authClient.config.disableReconnect {
+		return callWithRetry(globalRPCRetryPolicy, globalRPCRetryStats, func() error {
+			return authClient.call(serviceMethod, args, reply)
+		}, func() {
+			// Close the broken connection, next attempt reconnects.
+			authClient.Close()
+		})
+	}
+
 	doneCh := make(chan struct{})
 	defer close(doneCh)
 	for i := range newRetryTimer(time.Second, 30*time.Second, MaxJitter, doneCh) {
@@ -0,0 +1,245 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Maximum number of bytes transferred between two waits of a
+// throttled transfer.
+const throttleChunkSize = 32 * humanize.KiByte
+
+// bandwidthLimits - maximum number of bytes per second transferred by
+// a connection for the object data, 0 means unlimited.
+type bandwidthLimits struct {
+	// Limit of all the connections.
+	global uint64
+	// Limits overriding the global limit for the requests signed by
+	// the access key.
+	keys map[string]uint64
+}
+
+// Limit - returns the limit of the requests signed by the access key.
+func (b bandwidthLimits) Limit(accessKey string) uint64 {
+	if limit, ok := b.keys[accessKey]; ok && accessKey != "" {
+		return limit
+	}
+	return b.global
+}
+
+// throttle - delays the transfers of a connection so that their
+// combined average rate since the first byte does not exceed the limit.
+// A throttle only sleeps in the goroutine doing the transfer, its own
+// lock is never held while sleeping and every wait is bounded by the
+// time needed for a single chunk. The handlers however hold the
+// namespace lock of the object for the whole transfer, a throttled
+// download delays the writers of the object and a throttled upload
+// delays its readers and writers until the transfer completes.
+type throttle struct {
+	limit uint64
+
+	mutex sync.Mutex
+	start time.Time
+	total uint64
+	// Number of transfers sharing the throttle.
+	refs int
+}
+
+func newThrottle(limit uint64) *throttle {
+	return &throttle{limit: limit}
+}
+
+// chunkSize - returns the maximum number of bytes to transfer before
+// the next wait, a chunk never takes more than a second.
+func (t *throttle) chunkSize(n int) int {
+	size := uint64(throttleChunkSize)
+	if t.limit < size {
+		size = t.limit
+	}
+	if uint64(n) > size {
+		return int(size)
+	}
+	return n
+}
+
+// wait - accounts n transferred bytes and sleeps until the transfer is
+// back within the limit.
+func (t *throttle) wait(n int) {
+	t.mutex.Lock()
+	if t.start.IsZero() {
+		t.start = time.Now()
+	}
+	t.total += uint64(n)
+	expected := time.Duration(float64(t.total) / float64(t.limit) * float64(time.Second))
+	delay := expected - time.Since(t.start)
+	t.mutex.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// connThrottleKey - identifies the throttle shared by the transfers of
+// a connection within the same limit.
+type connThrottleKey struct {
+	remoteAddr string
+	limit      uint64
+}
+
+// Throttles of the connections transferring object data.
+var connThrottles = struct {
+	mutex     sync.Mutex
+	throttles map[connThrottleKey]*throttle
+}{throttles: make(map[connThrottleKey]*throttle)}
+
+// getConnThrottle - returns the throttle of the connection of the
+// request, shared by the concurrent transfers of the connection such
+// as the HTTP/2 streams multiplexed on it, and the function releasing
+// it once the transfer is done. The throttle of a connection is
+// dropped once released by all its transfers.
+func getConnThrottle(r *http.Request, limit uint64) (*throttle, func()) {
+	// Requests not received from a connection are throttled alone.
+	if r.RemoteAddr == "" {
+		return newThrottle(limit), func() {}
+	}
+
+	key := connThrottleKey{r.RemoteAddr, limit}
+	connThrottles.mutex.Lock()
+	t, ok := connThrottles.throttles[key]
+	if !ok {
+		t = newThrottle(limit)
+		connThrottles.throttles[key] = t
+	}
+	t.refs++
+	connThrottles.mutex.Unlock()
+
+	var once sync.Once
+	return t, func() {
+		once.Do(func() {
+			connThrottles.mutex.Lock()
+			t.refs--
+			if t.refs == 0 {
+				delete(connThrottles.throttles, key)
+			}
+			connThrottles.mutex.Unlock()
+		})
+	}
+}
+
+// throttledReader - reads from the underlying reader within the limit.
+type throttledReader struct {
+	io.ReadCloser
+	throttle *throttle
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p[:r.throttle.chunkSize(len(p))])
+	if n > 0 {
+		r.throttle.wait(n)
+	}
+	return n, err
+}
+
+// throttledWriter - writes to the underlying writer within the limit.
+type throttledWriter struct {
+	io.Writer
+	throttle *throttle
+}
+
+func (w *throttledWriter) Write(p []byte) (written int, err error) {
+	// Empty writes are passed as is, they may be meaningful to the
+	// underlying writer.
+	if len(p) == 0 {
+		return w.Writer.Write(p)
+	}
+	for len(p) > 0 {
+		var n int
+		n, err = w.Writer.Write(p[:w.throttle.chunkSize(len(p))])
+		written += n
+		if err != nil {
+			return written, err
+		}
+		w.throttle.wait(n)
+		p = p[n:]
+	}
+	return written, nil
+}
+
+// getBandwidthLimit - returns the limit applicable to the object data
+// of the request.
+func getBandwidthLimit(r *http.Request) uint64 {
+	limits := globalBandwidthLimits
+	if limits.global == 0 && len(limits.keys) == 0 {
+		return 0
+	}
+	return limits.Limit(getRequestAccessKey(r))
+}
+
+// throttleRequestBody - limits the rate at which the object data is
+// read from the request body, returns the function to call once the
+// body is read.
+func throttleRequestBody(r *http.Request) func() {
+	if limit := getBandwidthLimit(r); limit > 0 && r.Body != nil {
+		t, release := getConnThrottle(r, limit)
+		r.Body = &throttledReader{ReadCloser: r.Body, throttle: t}
+		return release
+	}
+	return func() {}
+}
+
+// throttleResponseWriter - returns a writer limiting the rate at which
+// the object data is written to w, and the function to call once the
+// object data is written.
+func throttleResponseWriter(w io.Writer, r *http.Request) (io.Writer, func()) {
+	if limit := getBandwidthLimit(r); limit > 0 {
+		t, release := getConnThrottle(r, limit)
+		return &throttledWriter{Writer: w, throttle: t}, release
+	}
+	return w, func() {}
+}
+
+// setBandwidthLimits - sets the bandwidth limits from
+// MINIO_BANDWIDTH_LIMIT and MINIO_BANDWIDTH_LIMIT_KEYS env, the latter
+// is a comma separated list of "accesskey=limit" overrides.
+func setBandwidthLimits() {
+	if limit := os.Getenv("MINIO_BANDWIDTH_LIMIT"); limit != "" {
+		rate, err := humanize.ParseBytes(limit)
+		fatalIf(err, "Invalid MINIO_BANDWIDTH_LIMIT value %s.", limit)
+		globalBandwidthLimits.global = rate
+	}
+	if keyLimits := os.Getenv("MINIO_BANDWIDTH_LIMIT_KEYS"); keyLimits != "" {
+		keys := make(map[string]uint64)
+		for _, keyLimit := range strings.Split(keyLimits, ",") {
+			i := strings.LastIndex(keyLimit, "=")
+			if i <= 0 {
+				fatalIf(errInvalidArgument, "Invalid MINIO_BANDWIDTH_LIMIT_KEYS value %s.", keyLimits)
+			}
+			rate, err := humanize.ParseBytes(keyLimit[i+1:])
+			fatalIf(err, "Invalid MINIO_BANDWIDTH_LIMIT_KEYS value %s.", keyLimits)
+			keys[strings.TrimSpace(keyLimit[:i])] = rate
+		}
+		globalBandwidthLimits.keys = keys
+	}
+}
@@ -0,0 +1,239 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// checkTransferTime - fails if the transfer of size bytes did not take
+// the time expected at the limit, within tolerance.
+func checkTransferTime(t *testing.T, name string, elapsed time.Duration, size, limit uint64) {
+	expected := time.Duration(float64(size) / float64(limit) * float64(time.Second))
+	if elapsed < expected*9/10 || elapsed > expected*2+100*time.Millisecond {
+		t.Errorf("%s: Expected the transfer to take about %s, took %s", name, expected, elapsed)
+	}
+}
+
+// Tests that the throttled reader and writer respect the limit.
+func TestThrottledReaderWriter(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 64*humanize.KiByte)
+
+	testCases := []struct {
+		limit uint64
+	}{
+		{256 * humanize.KiByte},
+		// Limit smaller than a chunk.
+		{8 * humanize.KiByte},
+	}
+	for i, testCase := range testCases {
+		size := uint64(len(data))
+		if testCase.limit < throttleChunkSize {
+			size = 2 * testCase.limit
+		}
+
+		start := time.Now()
+		reader := &throttledReader{ReadCloser: ioutil.NopCloser(bytes.NewReader(data[:size])), throttle: newThrottle(testCase.limit)}
+		n, err := io.Copy(ioutil.Discard, reader)
+		if err != nil || uint64(n) != size {
+			t.Fatalf("Test %d: Unexpected read of %d bytes: %v", i+1, n, err)
+		}
+		checkTransferTime(t, "read", time.Since(start), size, testCase.limit)
+
+		var buf bytes.Buffer
+		start = time.Now()
+		writer := &throttledWriter{Writer: &buf, throttle: newThrottle(testCase.limit)}
+		if n, err := writer.Write(data[:size]); err != nil || uint64(n) != size {
+			t.Fatalf("Test %d: Unexpected write of %d bytes: %v", i+1, n, err)
+		}
+		checkTransferTime(t, "write", time.Since(start), size, testCase.limit)
+		if !bytes.Equal(buf.Bytes(), data[:size]) {
+			t.Fatalf("Test %d: Written data does not match", i+1)
+		}
+	}
+}
+
+// Tests that the concurrent transfers of a connection share its limit
+// while the transfers of other connections do not.
+func TestConnThrottle(t *testing.T) {
+	savedLimits := globalBandwidthLimits
+	defer func() {
+		globalBandwidthLimits = savedLimits
+	}()
+	limit := uint64(256 * humanize.KiByte)
+	globalBandwidthLimits = bandwidthLimits{global: limit}
+	data := bytes.Repeat([]byte("a"), 64*humanize.KiByte)
+
+	testCases := []struct {
+		remoteAddrs []string
+		shared      bool
+	}{
+		// Transfers of a single connection.
+		{[]string{"127.0.0.1:10000", "127.0.0.1:10000"}, true},
+		// Transfers of two connections.
+		{[]string{"127.0.0.1:10000", "127.0.0.1:10001"}, false},
+	}
+	for i, testCase := range testCases {
+		var wg sync.WaitGroup
+		start := time.Now()
+		for _, remoteAddr := range testCase.remoteAddrs {
+			req, err := http.NewRequest("GET", "http://127.0.0.1:9000/bucket/object", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.RemoteAddr = remoteAddr
+			writer, release := throttleResponseWriter(ioutil.Discard, req)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer release()
+				if _, err := writer.Write(data); err != nil {
+					t.Errorf("Test %d: %s", i+1, err)
+				}
+			}()
+		}
+		wg.Wait()
+		size := uint64(len(data))
+		if testCase.shared {
+			size *= uint64(len(testCase.remoteAddrs))
+		}
+		checkTransferTime(t, "write", time.Since(start), size, limit)
+	}
+
+	// Throttles are dropped once released.
+	connThrottles.mutex.Lock()
+	defer connThrottles.mutex.Unlock()
+	if len(connThrottles.throttles) != 0 {
+		t.Fatalf("Expected no throttles left, got %d", len(connThrottles.throttles))
+	}
+}
+
+// Tests the limit applicable to the access keys.
+func TestBandwidthLimits(t *testing.T) {
+	limits := bandwidthLimits{
+		global: humanize.MiByte,
+		keys: map[string]uint64{
+			"slow":      humanize.KiByte,
+			"unlimited": 0,
+		},
+	}
+	testCases := []struct {
+		accessKey string
+		limit     uint64
+	}{
+		{"", humanize.MiByte},
+		{"other", humanize.MiByte},
+		{"slow", humanize.KiByte},
+		{"unlimited", 0},
+	}
+	for i, testCase := range testCases {
+		if limit := limits.Limit(testCase.accessKey); limit != testCase.limit {
+			t.Errorf("Test %d: Expected limit %d, got %d", i+1, testCase.limit, limit)
+		}
+	}
+}
+
+// Tests that uploads and downloads of the object data are throttled
+// while the metadata operations are not.
+func TestBandwidthThrottleHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBandwidthThrottleHandlers, []string{"PutObject", "GetObject", "HeadObject"})
+}
+
+func testBandwidthThrottleHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	savedLimits := globalBandwidthLimits
+	defer func() {
+		globalBandwidthLimits = savedLimits
+	}()
+
+	serveRequest := func(method, urlStr string, body []byte) (*httptest.ResponseRecorder, time.Duration) {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		start := time.Now()
+		apiRouter.ServeHTTP(rec, req)
+		return rec, time.Since(start)
+	}
+
+	limit := uint64(128 * humanize.KiByte)
+	data := bytes.Repeat([]byte("a"), 32*humanize.KiByte)
+	// Unthrottled transfers and metadata operations are well below
+	// the time of a throttled transfer.
+	unthrottledTime := 150 * time.Millisecond
+	testCases := []struct {
+		limits    bandwidthLimits
+		throttled bool
+	}{
+		// Global limit.
+		{bandwidthLimits{global: limit}, true},
+		// Limit of the access key.
+		{bandwidthLimits{keys: map[string]uint64{credentials.AccessKey: limit}}, true},
+		// Access key is not limited.
+		{bandwidthLimits{global: limit, keys: map[string]uint64{credentials.AccessKey: 0}}, false},
+		// Limit of other access keys.
+		{bandwidthLimits{keys: map[string]uint64{"otheraccesskey": limit}}, false},
+	}
+	for i, testCase := range testCases {
+		globalBandwidthLimits = testCase.limits
+		objectName := "object"
+
+		rec, elapsed := serveRequest("PUT", getPutObjectURL("", bucketName, objectName), data)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		if testCase.throttled {
+			checkTransferTime(t, instanceType+" PUT", elapsed, uint64(len(data)), limit)
+		} else if elapsed > unthrottledTime {
+			t.Errorf("%s: Test %d: Expected PUT not to be throttled, took %s", instanceType, i+1, elapsed)
+		}
+
+		rec, elapsed = serveRequest("GET", getGetObjectURL("", bucketName, objectName), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), data) {
+			t.Fatalf("%s: Test %d: Downloaded data does not match", instanceType, i+1)
+		}
+		if testCase.throttled {
+			checkTransferTime(t, instanceType+" GET", elapsed, uint64(len(data)), limit)
+		} else if elapsed > unthrottledTime {
+			t.Errorf("%s: Test %d: Expected GET not to be throttled, took %s", instanceType, i+1, elapsed)
+		}
+
+		rec, elapsed = serveRequest("HEAD", getHeadObjectURL("", bucketName, objectName), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		if elapsed > unthrottledTime {
+			t.Errorf("%s: Test %d: Expected HEAD not to be throttled, took %s", instanceType, i+1, elapsed)
+		}
+	}
+}
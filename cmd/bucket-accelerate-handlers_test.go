@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT and GET bucket accelerate round-trip the valid states and
+// reject the invalid ones.
+func TestBucketAccelerateHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketAccelerateHandlers, []string{
+		"GetBucketAccelerate",
+		"PutBucketAccelerate",
+	})
+}
+
+func testBucketAccelerateHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Accelerate configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+	defer globalBucketAccelerate.Set(bucketName, nil)
+
+	serveRequest := func(method, bucket string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, getBucketAccelerateURL("", bucket), int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	getStatus := func() string {
+		rec := serveRequest("GET", bucketName, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var config AccelerateConfiguration
+		if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		return config.Status
+	}
+
+	// No status until one is set.
+	if status := getStatus(); status != "" {
+		t.Fatalf("%s: Expected no status, got %s", instanceType, status)
+	}
+
+	testCases := []struct {
+		config         string
+		expectedStatus int
+		expectedCode   string
+		status         string
+	}{
+		// Test case - 1.
+		// Valid states are stored and echoed.
+		{`<AccelerateConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></AccelerateConfiguration>`,
+			http.StatusOK, "", accelerateStatusEnabled},
+		// Test case - 2.
+		{`<AccelerateConfiguration><Status>Suspended</Status></AccelerateConfiguration>`,
+			http.StatusOK, "", accelerateStatusSuspended},
+		// Test case - 3.
+		// Invalid states are rejected, the stored state is kept.
+		{`<AccelerateConfiguration><Status>Disabled</Status></AccelerateConfiguration>`,
+			http.StatusBadRequest, "MalformedXML", accelerateStatusSuspended},
+		// Test case - 4.
+		{`<AccelerateConfiguration><Status>enabled</Status></AccelerateConfiguration>`,
+			http.StatusBadRequest, "MalformedXML", accelerateStatusSuspended},
+		// Test case - 5.
+		{`<AccelerateConfiguration></AccelerateConfiguration>`,
+			http.StatusBadRequest, "MalformedXML", accelerateStatusSuspended},
+		// Test case - 6.
+		{`<AccelerateConfiguration><Status>`,
+			http.StatusBadRequest, "MalformedXML", accelerateStatusSuspended},
+	}
+	for i, testCase := range testCases {
+		rec := serveRequest("PUT", bucketName, []byte(testCase.config))
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+		if testCase.expectedCode != "" {
+			var errResp APIErrorResponse
+			if err := xml.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Code != testCase.expectedCode {
+				t.Fatalf("%s: Test %d: Expected %s, got %s", instanceType, i+1, testCase.expectedCode, rec.Body.String())
+			}
+		}
+		if status := getStatus(); status != testCase.status {
+			t.Fatalf("%s: Test %d: Expected status %s, got %s", instanceType, i+1, testCase.status, status)
+		}
+		if config, _ := globalBucketAccelerate.Get(bucketName); config.Status != testCase.status {
+			t.Fatalf("%s: Test %d: Expected in-memory status %s, got %s", instanceType, i+1, testCase.status, config.Status)
+		}
+	}
+
+	// Missing buckets are reported.
+	if rec := serveRequest("GET", "missing-bucket", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
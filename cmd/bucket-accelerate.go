@@ -0,0 +1,208 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"path"
+	"sync"
+)
+
+const (
+	// Bucket accelerate config name.
+	bucketAccelerateConfig = "accelerate.xml"
+
+	// Status values of the accelerate config.
+	accelerateStatusEnabled   = "Enabled"
+	accelerateStatusSuspended = "Suspended"
+)
+
+// errInvalidAccelerateConfig - accelerate config is not valid.
+var errInvalidAccelerateConfig = errors.New("Invalid accelerate configuration")
+
+// errNoSuchAccelerateConfig - accelerate config is not set on the bucket.
+var errNoSuchAccelerateConfig = errors.New("The accelerate configuration does not exist")
+
+// AccelerateConfiguration - transfer acceleration state of a bucket.
+// The state is only stored and returned, the transfers of the buckets
+// are never accelerated.
+type AccelerateConfiguration struct {
+	XMLName xml.Name `xml:"AccelerateConfiguration"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status,omitempty"`
+}
+
+// validateAccelerateConfig - validates the status.
+func validateAccelerateConfig(config AccelerateConfiguration) error {
+	if config.Status != accelerateStatusEnabled && config.Status != accelerateStatusSuspended {
+		return errInvalidAccelerateConfig
+	}
+	return nil
+}
+
+// Variable represents bucket accelerate configs in memory.
+var globalBucketAccelerate = newBucketAccelerateConfigs(nil)
+
+// bucketAccelerateConfigs - accelerate configs of all the buckets.
+type bucketAccelerateConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of accelerate configs indexed by 'bucket'.
+	configs map[string]AccelerateConfiguration
+}
+
+// newBucketAccelerateConfigs - initializes bucket accelerate configs.
+func newBucketAccelerateConfigs(configs map[string]AccelerateConfiguration) *bucketAccelerateConfigs {
+	if configs == nil {
+		configs = make(map[string]AccelerateConfiguration)
+	}
+	return &bucketAccelerateConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the accelerate config of the bucket, false if not set.
+func (bc *bucketAccelerateConfigs) Get(bucket string) (AccelerateConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the accelerate config of the bucket, nil config removes it.
+func (bc *bucketAccelerateConfigs) Set(bucket string, config *AccelerateConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// Loads all bucket accelerate configs from persistent layer.
+func loadAllBucketAccelerateConfigs(objAPI ObjectLayer) (map[string]AccelerateConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]AccelerateConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketAccelerateConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchAccelerateConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket accelerate configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket accelerate configs.
+func initBucketAccelerate(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketAccelerateConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket accelerate configs.
+	globalBucketAccelerate = newBucketAccelerateConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketAccelerateConfig - reads the accelerate config of the bucket.
+func readBucketAccelerateConfig(bucket string, objAPI ObjectLayer) (AccelerateConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketAccelerateConfig)
+
+	// Acquire a read lock on accelerate config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return AccelerateConfiguration{}, errNoSuchAccelerateConfig
+		}
+		errorIf(err, "Unable to load accelerate config for the bucket %s.", bucket)
+		return AccelerateConfiguration{}, errorCause(err)
+	}
+
+	var config AccelerateConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return AccelerateConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketAccelerateConfig - saves the accelerate config of the
+// bucket, nil config removes any previously saved config.
+func writeBucketAccelerateConfig(bucket string, config *AccelerateConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketAccelerateConfig)
+
+	// Acquire a write lock on accelerate config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove accelerate config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set accelerate config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketAccelerateChange - persists the accelerate
+// config of the bucket and notifies all the nodes in the cluster to
+// update their in-memory state.
+func persistAndNotifyBucketAccelerateChange(bucket string, config *AccelerateConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketAccelerateConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketAccelerate(bucket, config)
+	return nil
+}
@@ -0,0 +1,355 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Maximum number of access log records queued for writing,
+	// records are dropped once the queue is full.
+	accessLogQueueSize = 10000
+
+	// Number of records after which the queued records are written.
+	accessLogBatchSize = 1000
+
+	// Interval after which the queued records are written.
+	accessLogFlushInterval = 5 * time.Minute
+
+	// Time format of access log records.
+	accessLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+
+	// Time format of access log object names.
+	accessLogObjectTimeFormat = "2006-01-02-15-04-05"
+)
+
+var (
+	// Writes access logs of all the buckets, initialized along with
+	// the bucket logging configs.
+	globalAccessLogger     *accessLogger
+	globalAccessLoggerOnce sync.Once
+)
+
+// accessLogRecord - single access log line along with the log target
+// it has to be written to.
+type accessLogRecord struct {
+	target LoggingEnabled
+	line   string
+}
+
+// accessLogger - batches access log records in memory and writes them
+// as objects into their target buckets. Logging never blocks the
+// request path, records are dropped when the writer falls behind.
+type accessLogger struct {
+	// Number of dropped records, accessed atomically.
+	dropped uint64
+
+	objAPI        func() ObjectLayer
+	batchSize     int
+	flushInterval time.Duration
+
+	recordCh chan accessLogRecord
+	flushCh  chan chan struct{}
+}
+
+// newAccessLogger - initializes an access logger and starts writing
+// the records in background.
+func newAccessLogger(objAPI func() ObjectLayer, batchSize int, flushInterval time.Duration) *accessLogger {
+	logger := &accessLogger{
+		objAPI:        objAPI,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		recordCh:      make(chan accessLogRecord, accessLogQueueSize),
+		flushCh:       make(chan chan struct{}),
+	}
+	go logger.run()
+	return logger
+}
+
+// Log - queues the record for writing, drops the record if the queue
+// is full.
+func (l *accessLogger) Log(record accessLogRecord) {
+	select {
+	case l.recordCh <- record:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+}
+
+// Flush - writes all the queued records, returns after they are
+// written.
+func (l *accessLogger) Flush() {
+	doneCh := make(chan struct{})
+	l.flushCh <- doneCh
+	<-doneCh
+}
+
+// run - batches the records per target, writes them when the batch is
+// full, on every flush interval and on flush requests.
+func (l *accessLogger) run() {
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	batches := make(map[LoggingEnabled][]string)
+	count := 0
+	writeBatches := func() {
+		for target, lines := range batches {
+			l.writeBatch(target, lines)
+		}
+		batches = make(map[LoggingEnabled][]string)
+		count = 0
+	}
+	for {
+		select {
+		case record := <-l.recordCh:
+			batches[record.target] = append(batches[record.target], record.line)
+			count++
+			if count >= l.batchSize {
+				writeBatches()
+			}
+		case <-ticker.C:
+			writeBatches()
+		case doneCh := <-l.flushCh:
+			// Drain records queued before the flush request.
+			for drained := false; !drained; {
+				select {
+				case record := <-l.recordCh:
+					batches[record.target] = append(batches[record.target], record.line)
+				default:
+					drained = true
+				}
+			}
+			writeBatches()
+			close(doneCh)
+		}
+	}
+}
+
+// writeBatch - writes the lines as a new object under the target
+// prefix of the target bucket.
+func (l *accessLogger) writeBatch(target LoggingEnabled, lines []string) {
+	objAPI := l.objAPI()
+	if objAPI == nil {
+		return
+	}
+
+	data := []byte(strings.Join(lines, "\n") + "\n")
+	object := target.TargetPrefix + time.Now().UTC().Format(accessLogObjectTimeFormat) + "-" +
+		strings.ToUpper(strings.Replace(mustGetUUID(), "-", "", -1)[:16])
+
+	objectLock := globalNSMutex.NewNSLock(target.TargetBucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	_, err := objAPI.PutObject(target.TargetBucket, object, int64(len(data)), bytes.NewReader(data), map[string]string{
+		"content-type": "text/plain",
+	}, "")
+	errorIf(err, "Unable to write access log %s to bucket %s.", object, target.TargetBucket)
+}
+
+// accessLogResponseWriter - records the status, error code and the
+// number of bytes of a response for the access log.
+type accessLogResponseWriter struct {
+	http.ResponseWriter
+	status    int
+	bytesSent int64
+	// First bytes of error responses to extract the error code.
+	errorBody []byte
+}
+
+// Maximum bytes of an error response looked at for the error code.
+const accessLogErrorBodyLimit = 512
+
+func (w *accessLogResponseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogResponseWriter) Write(p []byte) (int, error) {
+	if w.status >= http.StatusBadRequest && len(w.errorBody) < accessLogErrorBodyLimit {
+		n := accessLogErrorBodyLimit - len(w.errorBody)
+		if n > len(p) {
+			n = len(p)
+		}
+		w.errorBody = append(w.errorBody, p[:n]...)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytesSent += int64(n)
+	return n, err
+}
+
+// Flush - some handlers stream their responses, flush whenever the
+// underlying writer supports it.
+func (w *accessLogResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+var accessLogErrorCodeRegex = regexp.MustCompile("<Code>([^<]+)</Code>")
+
+// errorCode - returns the S3 error code of the response, empty if the
+// request was successful.
+func (w *accessLogResponseWriter) errorCode() string {
+	if matches := accessLogErrorCodeRegex.FindSubmatch(w.errorBody); matches != nil {
+		return string(matches[1])
+	}
+	return ""
+}
+
+// getRequestAccessKey - returns the access key the request is signed
+// with, empty for anonymous or unrecognized requests.
+func getRequestAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned, authTypeStreamingSigned:
+		if signV4Values, s3Error := parseSignV4(r.Header.Get("Authorization")); s3Error == ErrNone {
+			return signV4Values.Credential.accessKey
+		}
+	case authTypePresigned:
+		if preSignValues, s3Error := parsePreSignV4(r.URL.Query()); s3Error == ErrNone {
+			return preSignValues.Credential.accessKey
+		}
+	case authTypeSignedV2:
+		auth := strings.TrimPrefix(r.Header.Get("Authorization"), signV2Algorithm+" ")
+		if i := strings.LastIndex(auth, ":"); i > 0 {
+			return auth[:i]
+		}
+	case authTypePresignedV2:
+		return r.URL.Query().Get("AWSAccessKeyId")
+	}
+	return ""
+}
+
+// getAccessLogOperation - returns the operation of the request in
+// REST.<method>.<resource> form, e.g. REST.GET.OBJECT or
+// REST.PUT.LOGGING.
+func getAccessLogOperation(r *http.Request, object string) string {
+	query := r.URL.Query()
+	resource := "BUCKET"
+	if object != "" {
+		resource = "OBJECT"
+	}
+	switch {
+	case object != "" && query.Get("uploadId") != "" && query.Get("partNumber") != "":
+		resource = "PART"
+	case object != "" && query.Get("uploadId") != "":
+		resource = "UPLOAD"
+	case object != "" && hasQueryKey(query, "uploads"):
+		resource = "UPLOADS"
+	default:
+		for _, subResource := range resourceList {
+			if hasQueryKey(query, subResource) {
+				resource = strings.ToUpper(subResource)
+				break
+			}
+		}
+	}
+	return "REST." + r.Method + "." + resource
+}
+
+// hasQueryKey - returns true if the query has the key, with or
+// without a value.
+func hasQueryKey(query map[string][]string, key string) bool {
+	_, ok := query[key]
+	return ok
+}
+
+// accessLogField - returns '-' for empty access log fields.
+func accessLogField(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// newAccessLogLine - formats the request in the S3 server access log
+// format, fields are bucket owner, bucket, time, remote IP, requester,
+// request ID, operation, key, request URI, HTTP status, error code,
+// bytes sent, object size, total time, turn around time, referrer,
+// user agent and version ID.
+func newAccessLogLine(r *http.Request, w *accessLogResponseWriter, bucket, object string, startTime time.Time) string {
+	remoteIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIP = r.RemoteAddr
+	}
+	objectSize := ""
+	if r.Method == httpPUT && r.ContentLength >= 0 {
+		objectSize = strconv.FormatInt(r.ContentLength, 10)
+	}
+	bytesSent := ""
+	if w.bytesSent > 0 {
+		bytesSent = strconv.FormatInt(w.bytesSent, 10)
+	}
+	return fmt.Sprintf("%s %s [%s] %s %s %s %s %s \"%s %s %s\" %d %s %s %s %d - %q %q -",
+		globalMinioDefaultOwnerID,
+		bucket,
+		startTime.Format(accessLogTimeFormat),
+		accessLogField(remoteIP),
+		accessLogField(getRequestAccessKey(r)),
+		accessLogField(w.Header().Get(responseRequestIDKey)),
+		getAccessLogOperation(r, object),
+		accessLogField(object),
+		r.Method, r.URL.RequestURI(), r.Proto,
+		w.status,
+		accessLogField(w.errorCode()),
+		accessLogField(bytesSent),
+		accessLogField(objectSize),
+		time.Since(startTime)/time.Millisecond,
+		accessLogField(r.Referer()),
+		accessLogField(r.UserAgent()),
+	)
+}
+
+type bucketLoggingHandler struct {
+	handler http.Handler
+}
+
+// setBucketLoggingHandler - writes an access log record for every
+// request against a bucket with logging enabled. Writes to the log
+// target buckets are not logged to avoid an endless chain of log
+// records.
+func setBucketLoggingHandler(h http.Handler) http.Handler {
+	return bucketLoggingHandler{h}
+}
+
+func (h bucketLoggingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	target, ok := globalBucketLogging.Get(bucket)
+	if !ok || globalAccessLogger == nil ||
+		(isWriteRequest(r) && globalBucketLogging.IsTargetBucket(bucket)) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	startTime := time.Now().UTC()
+	lw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	h.handler.ServeHTTP(lw, r)
+	globalAccessLogger.Log(accessLogRecord{
+		target: target,
+		line:   newAccessLogLine(r, lw, bucket, object, startTime),
+	})
+}
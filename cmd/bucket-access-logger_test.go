@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Tests the operation names of access log records.
+func TestGetAccessLogOperation(t *testing.T) {
+	testCases := []struct {
+		method, url, object string
+		expectedOperation   string
+	}{
+		{"GET", "/bucket/object", "object", "REST.GET.OBJECT"},
+		{"PUT", "/bucket/object", "object", "REST.PUT.OBJECT"},
+		{"GET", "/bucket", "", "REST.GET.BUCKET"},
+		{"PUT", "/bucket?logging", "", "REST.PUT.LOGGING"},
+		{"GET", "/bucket?policy", "", "REST.GET.POLICY"},
+		{"POST", "/bucket/object?uploads", "object", "REST.POST.UPLOADS"},
+		{"PUT", "/bucket/object?partNumber=1&uploadId=id", "object", "REST.PUT.PART"},
+		{"POST", "/bucket/object?uploadId=id", "object", "REST.POST.UPLOAD"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if operation := getAccessLogOperation(req, testCase.object); operation != testCase.expectedOperation {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expectedOperation, operation)
+		}
+	}
+}
+
+// Tests that requests against a bucket with logging enabled produce
+// log objects in the target bucket, and writes to the target bucket
+// are not logged.
+func TestBucketAccessLogging(t *testing.T) {
+	initNSLock(false)
+	ts := StartTestServer(t, "FS")
+	defer ts.Stop()
+
+	client := http.Client{}
+	doRequest := func(method, urlStr string, body []byte) *http.Response {
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), ts.AccessKey, ts.SecretKey)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, urlStr, err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, urlStr, err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	bucket, logBucket := "source-bucket", "log-bucket"
+	for _, b := range []string{bucket, logBucket} {
+		if resp := doRequest("PUT", getMakeBucketURL(ts.Server.URL, b), nil); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unable to create bucket %s, status %d", b, resp.StatusCode)
+		}
+	}
+
+	// Log requests of both the buckets into the log bucket.
+	for _, b := range []string{bucket, logBucket} {
+		loggingStatus := []byte(`<BucketLoggingStatus><LoggingEnabled><TargetBucket>log-bucket</TargetBucket><TargetPrefix>logs/` + b + `/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`)
+		if resp := doRequest("PUT", getBucketLoggingURL(ts.Server.URL, b), loggingStatus); resp.StatusCode != http.StatusOK {
+			t.Fatalf("Unable to enable logging on %s, status %d", b, resp.StatusCode)
+		}
+	}
+
+	doRequest("PUT", getPutObjectURL(ts.Server.URL, bucket, "object"), []byte("hello"))
+	doRequest("GET", getGetObjectURL(ts.Server.URL, bucket, "object"), nil)
+	doRequest("GET", getGetObjectURL(ts.Server.URL, bucket, "missing-object"), nil)
+	// Writes to the log bucket are not logged, reads are.
+	doRequest("PUT", getPutObjectURL(ts.Server.URL, logBucket, "object"), []byte("hello"))
+	doRequest("HEAD", getHeadObjectURL(ts.Server.URL, logBucket, "object"), nil)
+
+	globalAccessLogger.Flush()
+
+	readLogs := func(prefix string) string {
+		result, err := ts.Obj.ListObjects(logBucket, prefix, "", "", 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var logs bytes.Buffer
+		for _, objInfo := range result.Objects {
+			if err = ts.Obj.GetObject(logBucket, objInfo.Name, 0, objInfo.Size, &logs); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return logs.String()
+	}
+
+	logs := readLogs("logs/" + bucket + "/")
+	for _, expected := range []string{
+		" source-bucket ",
+		" REST.PUT.OBJECT object \"PUT /source-bucket/object HTTP/1.1\" 200 ",
+		" REST.GET.OBJECT object \"GET /source-bucket/object HTTP/1.1\" 200 - 5 ",
+		" REST.GET.OBJECT missing-object \"GET /source-bucket/missing-object HTTP/1.1\" 404 NoSuchKey ",
+		" " + ts.AccessKey + " ",
+	} {
+		if !strings.Contains(logs, expected) {
+			t.Errorf("Expected %q in access logs, got\n%s", expected, logs)
+		}
+	}
+	if lines := strings.Count(logs, "\n"); lines != 3 {
+		t.Errorf("Expected 3 access log records, got %d", lines)
+	}
+
+	logs = readLogs("logs/" + logBucket + "/")
+	if strings.Contains(logs, "REST.PUT.OBJECT") {
+		t.Errorf("Expected writes to the log bucket not to be logged, got\n%s", logs)
+	}
+	if !strings.Contains(logs, "REST.HEAD.OBJECT") {
+		t.Errorf("Expected reads of the log bucket to be logged, got\n%s", logs)
+	}
+}
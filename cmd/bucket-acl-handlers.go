@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Maximum size of a bucket access control list.
+const maxBucketACLSize = 20 * 1024
+
+// GetBucketACLHandler - GET Bucket ACL
+// -----------------
+// This operation uses the acl subresource to return the access control
+// list of a bucket, translated from the canned policy set on the whole
+// bucket.
+func (api objectAPIHandlers) GetBucketACLHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	// Before proceeding validate if bucket exists.
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	policyInfo, err := readBucketAccessPolicy(objAPI, bucket)
+	if err != nil {
+		errorIf(err, "Unable to read bucket policy.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	acl := generateBucketACL(policy.GetPolicy(policyInfo.Statements, bucket, ""))
+	aclBytes, err := xml.Marshal(acl)
+	if err != nil {
+		errorIf(err, "Unable to marshal access control list into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseXML(w, aclBytes)
+}
+
+// PutBucketACLHandler - PUT Bucket ACL
+// -----------------
+// This operation uses the acl subresource to set the access control
+// list of a bucket, either from the x-amz-acl canned ACL header or
+// from the body. The ACL is translated into a canned policy set on the
+// whole bucket.
+func (api objectAPIHandlers) PutBucketACLHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	// Before proceeding validate if bucket exists.
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var bucketPolicy policy.BucketPolicy
+	var err error
+	if cannedACL := r.Header.Get(amzACL); cannedACL != "" {
+		bucketPolicy, err = getCannedACLPolicy(cannedACL)
+	} else {
+		// If Content-Length is unknown or zero, deny the request.
+		if r.ContentLength == -1 || r.ContentLength == 0 {
+			writeErrorResponse(w, ErrMissingContentLength, r.URL)
+			return
+		}
+		if r.ContentLength > maxBucketACLSize {
+			writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+			return
+		}
+
+		var aclBytes []byte
+		aclBytes, err = ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+		if err != nil {
+			errorIf(err, "Unable to read incoming body.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+
+		var acl AccessControlPolicy
+		if err = xml.Unmarshal(aclBytes, &acl); err != nil {
+			errorIf(err, "Unable to parse access control list XML.")
+			writeErrorResponse(w, ErrMalformedXML, r.URL)
+			return
+		}
+		bucketPolicy, err = getBucketACLPolicy(acl)
+	}
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Public ACLs are rejected if blocked on the bucket.
+	if err = checkPublicACLAllowed(bucket, bucketPolicy); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// ACLs granting others than the bucket owner are rejected if
+	// disabled on the bucket.
+	if err = checkBucketACLAllowed(bucket, bucketPolicy); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if s3Error := setBucketCannedPolicy(bucket, bucketPolicy, objAPI); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseHeadersOnly(w)
+}
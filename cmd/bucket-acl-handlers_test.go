@@ -0,0 +1,141 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT and GET bucket ACL round trip along with the anonymous
+// access granted to the AllUsers group.
+func TestBucketACLHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketACLHandlers, []string{"GetBucketACL", "PutBucketACL", "GetObject"})
+}
+
+func testBucketACLHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Policies are applied in-memory through the local peer.
+	initBucketPolicies(obj)
+	initGlobalS3Peers(nil)
+
+	data := []byte("hello")
+	if _, err := obj.PutObject(bucketName, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header, signed bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if signed {
+			if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+				t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+			}
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	getACL := func() AccessControlPolicy {
+		rec := serveRequest("GET", getBucketACLURL("", bucketName), nil, nil, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var acl AccessControlPolicy
+		if err := xml.Unmarshal(rec.Body.Bytes(), &acl); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		return acl
+	}
+	putACL := func(acl string, header http.Header) int {
+		return serveRequest("PUT", getBucketACLURL("", bucketName), []byte(acl), header, true).Code
+	}
+
+	// Only the owner has access by default.
+	acl := getACL()
+	if len(acl.AccessControlList) != 1 || !isOwnerGrantee(acl.AccessControlList[0].Grantee) ||
+		acl.AccessControlList[0].Permission != permissionFullControl {
+		t.Fatalf("%s: Unexpected ACL %#v", instanceType, acl)
+	}
+	if rec := serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+
+	// Grant READ to AllUsers, the ACL from GET is sent back as is.
+	acl.AccessControlList = append(acl.AccessControlList, Grant{
+		Grantee:    Grantee{Type: granteeGroup, URI: groupAllUsersURI},
+		Permission: permissionRead,
+	})
+	aclBytes, err := xml.Marshal(acl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := putACL(string(aclBytes), nil); code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	acl = getACL()
+	if len(acl.AccessControlList) != 2 || !isAllUsersGrantee(acl.AccessControlList[1].Grantee) ||
+		acl.AccessControlList[1].Permission != permissionRead {
+		t.Fatalf("%s: Unexpected ACL %#v", instanceType, acl)
+	}
+
+	// Anonymous users can read the objects.
+	rec := serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("%s: Expected anonymous read to succeed, got status %d", instanceType, rec.Code)
+	}
+
+	// Unsupported grants are rejected.
+	testCases := []string{
+		`<AccessControlPolicy><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="CanonicalUser"><ID>someone</ID></Grantee><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+		`<AccessControlPolicy><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AuthenticatedUsers</URI></Grantee><Permission>READ</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+		`<AccessControlPolicy><AccessControlList><Grant><Grantee xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="Group"><URI>http://acs.amazonaws.com/groups/global/AllUsers</URI></Grantee><Permission>READ_ACP</Permission></Grant></AccessControlList></AccessControlPolicy>`,
+	}
+	for i, testCase := range testCases {
+		if code := putACL(testCase, nil); code != http.StatusNotImplemented {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusNotImplemented, code)
+		}
+	}
+	cannedHeader := http.Header{}
+	cannedHeader.Set(amzACL, "authenticated-read")
+	if code := putACL("", cannedHeader); code != http.StatusNotImplemented {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotImplemented, code)
+	}
+	if code := putACL("<AccessControlPolicy>", nil); code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, code)
+	}
+
+	// Private canned ACL removes the anonymous access.
+	cannedHeader.Set(amzACL, cannedACLPrivate)
+	if code := putACL("", cannedHeader); code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	if acl = getACL(); len(acl.AccessControlList) != 1 {
+		t.Fatalf("%s: Unexpected ACL %#v", instanceType, acl)
+	}
+	if rec = serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+}
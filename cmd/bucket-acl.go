@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+const (
+	// Header setting a canned ACL.
+	amzACL = "X-Amz-Acl"
+
+	// Canned ACLs supported, mapped to the canned bucket policies.
+	cannedACLPrivate         = "private"
+	cannedACLPublicRead      = "public-read"
+	cannedACLPublicReadWrite = "public-read-write"
+
+	// Grantee types.
+	granteeCanonicalUser = "CanonicalUser"
+	granteeGroup         = "Group"
+
+	// URI of the group of all the users, including the anonymous users.
+	groupAllUsersURI = "http://acs.amazonaws.com/groups/global/AllUsers"
+
+	// Permissions supported.
+	permissionFullControl = "FULL_CONTROL"
+	permissionRead        = "READ"
+	permissionWrite       = "WRITE"
+
+	// Name space of the xsi:type attribute of the grantees.
+	xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+)
+
+// errUnsupportedACLGrant - the ACL grants something the bucket
+// policies can not represent.
+var errUnsupportedACLGrant = errors.New("Unsupported access control list grant")
+
+// Grantee - user or group granted a permission.
+type Grantee struct {
+	// Type is read from the xsi:type attribute.
+	Type        string `xml:"type,attr"`
+	ID          string `xml:"ID,omitempty"`
+	DisplayName string `xml:"DisplayName,omitempty"`
+	URI         string `xml:"URI,omitempty"`
+}
+
+// MarshalXML - encodes the grantee with its type as xsi:type attribute,
+// which encoding/xml can not generate from a struct tag.
+func (g Grantee) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = append(start.Attr,
+		xml.Attr{Name: xml.Name{Local: "xmlns:xsi"}, Value: xsiNamespace},
+		xml.Attr{Name: xml.Name{Local: "xsi:type"}, Value: g.Type},
+	)
+	grantee := struct {
+		ID          string `xml:"ID,omitempty"`
+		DisplayName string `xml:"DisplayName,omitempty"`
+		URI         string `xml:"URI,omitempty"`
+	}{g.ID, g.DisplayName, g.URI}
+	return e.EncodeElement(grantee, start)
+}
+
+// Grant - permission granted to a grantee.
+type Grant struct {
+	Grantee    Grantee `xml:"Grantee"`
+	Permission string  `xml:"Permission"`
+}
+
+// AccessControlPolicy - access control list of a bucket.
+type AccessControlPolicy struct {
+	XMLName           xml.Name `xml:"AccessControlPolicy"`
+	XMLNS             string   `xml:"xmlns,attr,omitempty"`
+	Owner             Owner    `xml:"Owner"`
+	AccessControlList []Grant  `xml:"AccessControlList>Grant"`
+}
+
+// ownerGrantee - the bucket owner as grantee.
+func ownerGrantee() Grantee {
+	return Grantee{
+		Type:        granteeCanonicalUser,
+		ID:          globalMinioDefaultOwnerID,
+		DisplayName: globalMinioDefaultOwnerID,
+	}
+}
+
+// isOwnerGrantee - returns true if the grantee is the bucket owner.
+func isOwnerGrantee(grantee Grantee) bool {
+	return grantee.Type == granteeCanonicalUser && grantee.ID == globalMinioDefaultOwnerID
+}
+
+// isAllUsersGrantee - returns true if the grantee is the group of all
+// the users.
+func isAllUsersGrantee(grantee Grantee) bool {
+	return grantee.Type == granteeGroup && grantee.URI == groupAllUsersURI
+}
+
+// generateBucketACL - returns the access control list of the canned
+// bucket policy, the bucket owner always has full control.
+func generateBucketACL(bucketPolicy policy.BucketPolicy) AccessControlPolicy {
+	acl := AccessControlPolicy{
+		XMLNS: "http://s3.amazonaws.com/doc/2006-03-01/",
+		Owner: Owner{
+			ID:          globalMinioDefaultOwnerID,
+			DisplayName: globalMinioDefaultOwnerID,
+		},
+		AccessControlList: []Grant{{Grantee: ownerGrantee(), Permission: permissionFullControl}},
+	}
+
+	allUsers := Grantee{Type: granteeGroup, URI: groupAllUsersURI}
+	switch bucketPolicy {
+	case policy.BucketPolicyReadOnly:
+		acl.AccessControlList = append(acl.AccessControlList, Grant{Grantee: allUsers, Permission: permissionRead})
+	case policy.BucketPolicyWriteOnly:
+		acl.AccessControlList = append(acl.AccessControlList, Grant{Grantee: allUsers, Permission: permissionWrite})
+	case policy.BucketPolicyReadWrite:
+		acl.AccessControlList = append(acl.AccessControlList,
+			Grant{Grantee: allUsers, Permission: permissionRead},
+			Grant{Grantee: allUsers, Permission: permissionWrite},
+		)
+	}
+	return acl
+}
+
+// getBucketACLPolicy - returns the canned bucket policy granting the
+// access control list. Only the bucket owner and the AllUsers group can
+// be granted FULL_CONTROL, READ or WRITE, the grants of the owner are
+// accepted as the owner always has full control.
+func getBucketACLPolicy(acl AccessControlPolicy) (policy.BucketPolicy, error) {
+	var read, write bool
+	for _, grant := range acl.AccessControlList {
+		switch grant.Permission {
+		case permissionFullControl, permissionRead, permissionWrite:
+		default:
+			return policy.BucketPolicyNone, errUnsupportedACLGrant
+		}
+		if isOwnerGrantee(grant.Grantee) {
+			continue
+		}
+		if !isAllUsersGrantee(grant.Grantee) {
+			return policy.BucketPolicyNone, errUnsupportedACLGrant
+		}
+		read = read || grant.Permission != permissionWrite
+		write = write || grant.Permission != permissionRead
+	}
+
+	switch {
+	case read && write:
+		return policy.BucketPolicyReadWrite, nil
+	case read:
+		return policy.BucketPolicyReadOnly, nil
+	case write:
+		return policy.BucketPolicyWriteOnly, nil
+	}
+	return policy.BucketPolicyNone, nil
+}
+
+// getCannedACLPolicy - returns the canned bucket policy of the canned ACL.
+func getCannedACLPolicy(cannedACL string) (policy.BucketPolicy, error) {
+	switch cannedACL {
+	case cannedACLPrivate:
+		return policy.BucketPolicyNone, nil
+	case cannedACLPublicRead:
+		return policy.BucketPolicyReadOnly, nil
+	case cannedACLPublicReadWrite:
+		return policy.BucketPolicyReadWrite, nil
+	}
+	return policy.BucketPolicyNone, errUnsupportedACLGrant
+}
+
+// setBucketCannedPolicy - sets the canned policy on the whole bucket,
+// the statements of the other prefixes are kept.
+func setBucketCannedPolicy(bucket string, bucketPolicy policy.BucketPolicy, objAPI ObjectLayer) APIErrorCode {
+	policyInfo, err := readBucketAccessPolicy(objAPI, bucket)
+	if err != nil {
+		errorIf(err, "Unable to read bucket policy.")
+		return toAPIErrorCode(err)
+	}
+
+	policyInfo.Statements = policy.SetPolicy(policyInfo.Statements, bucketPolicy, bucket, "")
+	if len(policyInfo.Statements) == 0 {
+		err = persistAndNotifyBucketPolicyChange(bucket, policyChange{true, nil}, objAPI)
+		if err != nil {
+			if _, ok := err.(BucketPolicyNotFound); ok {
+				return ErrNone
+			}
+			errorIf(err, "Unable to remove bucket policy.")
+			return toAPIErrorCode(err)
+		}
+		return ErrNone
+	}
+
+	data, err := json.Marshal(policyInfo)
+	if err != nil {
+		errorIf(err, "Unable to marshal bucket policy.")
+		return toAPIErrorCode(err)
+	}
+	return parseAndPersistBucketPolicy(bucket, data, objAPI)
+}
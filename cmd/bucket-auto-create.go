@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"strings"
+)
+
+// setBucketAutoCreate - enables the creation of the buckets on their
+// first write when MINIO_BUCKET_AUTO_CREATE env is set to "on".
+func setBucketAutoCreate() {
+	if autoCreate := os.Getenv("MINIO_BUCKET_AUTO_CREATE"); autoCreate != "" {
+		switch strings.ToLower(autoCreate) {
+		case "on":
+			globalIsBucketAutoCreate = true
+		case "off":
+			globalIsBucketAutoCreate = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_BUCKET_AUTO_CREATE value %s.", autoCreate)
+		}
+	}
+}
+
+// autoCreateBucket - creates the bucket written by an object write if
+// it does not exist and the auto creation is enabled. Only the requests
+// signed with the credentials, which may create the buckets, do so.
+// The bucket name is validated and the maximum number of buckets is
+// enforced like on PUT Bucket.
+func autoCreateBucket(objAPI ObjectLayer, bucket string, rAuthType authType) error {
+	if !globalIsBucketAutoCreate {
+		return nil
+	}
+	switch rAuthType {
+	case authTypeSigned, authTypePresigned, authTypeStreamingSigned, authTypeSignedV2, authTypePresignedV2:
+	default:
+		return nil
+	}
+
+	if _, err := objAPI.GetBucketInfo(bucket); err == nil {
+		return nil
+	} else if _, ok := errorCause(err).(BucketNotFound); !ok {
+		return err
+	}
+
+	bucketLock := globalNSMutex.NewNSLock(bucket, "")
+	bucketLock.Lock()
+	defer bucketLock.Unlock()
+
+	err := makeBucketWithinLimit(objAPI, bucket)
+	if _, ok := errorCause(err).(BucketExists); ok {
+		// Created by a concurrent write.
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,124 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the writes to missing buckets fail with NoSuchBucket by
+// default, and create the buckets once the auto creation is enabled.
+func TestBucketAutoCreateHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketAutoCreateHandlers, []string{"PutObject", "NewMultipart"})
+}
+
+func testBucketAutoCreateHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(autoCreate bool, maxBuckets int) {
+		globalIsBucketAutoCreate = autoCreate
+		globalMaxBuckets = maxBuckets
+	}(globalIsBucketAutoCreate, globalMaxBuckets)
+
+	data := []byte("hello")
+	putObject := func(bucket, object string, signed bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		var req *http.Request
+		var err error
+		if signed {
+			req, err = newTestSignedRequestV4("PUT", getPutObjectURL("", bucket, object), int64(len(data)), bytes.NewReader(data), credentials.AccessKey, credentials.SecretKey)
+		} else {
+			req, err = newTestRequest("PUT", getPutObjectURL("", bucket, object), int64(len(data)), bytes.NewReader(data))
+		}
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for Put Object: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectBucket := func(testName, bucket string, exists bool) {
+		_, err := obj.GetBucketInfo(bucket)
+		if exists && err != nil {
+			t.Fatalf("%s: %s: Expected bucket %s to exist, got %v", instanceType, testName, bucket, err)
+		}
+		if !exists && toAPIErrorCode(err) != ErrNoSuchBucket {
+			t.Fatalf("%s: %s: Expected bucket %s not to exist, got %v", instanceType, testName, bucket, err)
+		}
+	}
+
+	// Disabled by default.
+	globalIsBucketAutoCreate = false
+	if rec := putObject("auto-bucket", "object", true); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotFound, rec.Code)
+	}
+	expectBucket("disabled", "auto-bucket", false)
+
+	globalIsBucketAutoCreate = true
+
+	// Anonymous writes never create buckets.
+	if rec := putObject("auto-bucket", "object", false); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotFound, rec.Code)
+	}
+	expectBucket("anonymous", "auto-bucket", false)
+
+	// The first signed write creates the bucket, the next ones use it.
+	for _, object := range []string{"object", "dir/object"} {
+		if rec := putObject("auto-bucket", object, true); rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+		}
+		var buffer bytes.Buffer
+		if err := obj.GetObject("auto-bucket", object, 0, int64(len(data)), &buffer); err != nil {
+			t.Fatalf("%s: Failed to read object %s: <ERROR> %v", instanceType, object, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Fatalf("%s: Contents of object %s differ from the uploaded data", instanceType, object)
+		}
+	}
+
+	// Bucket names are validated.
+	if rec := putObject("Invalid_Bucket", "object", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Multipart uploads create the bucket as well.
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("POST", getNewMultipartURL("", "auto-multipart", "object"), 0, nil, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request for NewMultipart: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+	expectBucket("multipart", "auto-multipart", true)
+
+	// The maximum number of buckets is enforced.
+	buckets, err := obj.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalMaxBuckets = len(buckets)
+	if rec = putObject("auto-limited", "object", true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusBadRequest, rec.Code)
+	}
+	expectBucket("limited", "auto-limited", false)
+	if rec = putObject(bucketName, "object", true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusOK, rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT, GET and DELETE bucket client certificate along with the
+// rejection of the writes made without a verified client certificate.
+func TestBucketClientCertHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketClientCertHandlers, []string{
+		"GetBucketClientCert",
+		"PutBucketClientCert",
+		"DeleteBucketClientCert",
+		"PutObject",
+		"GetObject",
+		"DeleteObject",
+	})
+}
+
+func testBucketClientCertHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Client certificate configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+	defer globalBucketClientCert.Set(bucketName, nil)
+	defer func(clientCAs *x509.CertPool) { globalClientCAs = clientCAs }(globalClientCAs)
+
+	otherBucket := getRandomBucketName()
+	if err := obj.MakeBucket(otherBucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+
+	handler := setClientCertRequiredHandler(apiRouter)
+	clientCert := &x509.Certificate{Subject: pkix.Name{CommonName: "uploader"}}
+	serveRequest := func(method, urlStr string, body []byte, withCert bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		req.TLS = &tls.ConnectionState{}
+		if withCert {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{clientCert}}
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	expectStatus := func(testName string, rec *httptest.ResponseRecorder, status int) {
+		if rec.Code != status {
+			t.Fatalf("%s: %s: Expected status %d, got %d: %s", instanceType, testName, status, rec.Code, rec.Body.String())
+		}
+	}
+
+	requireConfig := []byte(`<ClientCertConfiguration><RequireForWrites>true</RequireForWrites></ClientCertConfiguration>`)
+	data := []byte("hello")
+
+	// No config by default.
+	expectStatus("get missing config", serveRequest("GET", getBucketClientCertURL("", bucketName), nil, false), http.StatusNotFound)
+
+	// Client certificates can not be required without client CAs.
+	globalClientCAs = nil
+	expectStatus("put config without client CAs", serveRequest("PUT", getBucketClientCertURL("", bucketName), requireConfig, false), http.StatusBadRequest)
+	globalClientCAs = x509.NewCertPool()
+
+	// Malformed configs are rejected.
+	expectStatus("put malformed config", serveRequest("PUT", getBucketClientCertURL("", bucketName), []byte("<ClientCertConfiguration>"), false), http.StatusBadRequest)
+
+	// Writes do not require a client certificate until configured.
+	expectStatus("put object before config", serveRequest("PUT", getPutObjectURL("", bucketName, "before"), data, false), http.StatusOK)
+	expectStatus("put config", serveRequest("PUT", getBucketClientCertURL("", bucketName), requireConfig, false), http.StatusOK)
+
+	rec := serveRequest("GET", getBucketClientCertURL("", bucketName), nil, false)
+	expectStatus("get config", rec, http.StatusOK)
+	var config ClientCertConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if !config.RequireForWrites {
+		t.Fatalf("%s: Expected the client certificate to be required for writes", instanceType)
+	}
+
+	// Writes of the protected bucket without a client certificate are denied.
+	expectStatus("put object without cert", serveRequest("PUT", getPutObjectURL("", bucketName, "object"), data, false), http.StatusForbidden)
+	if _, err := obj.GetObjectInfo(bucketName, "object"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the object not to be written, got %v", instanceType, err)
+	}
+	expectStatus("delete object without cert", serveRequest("DELETE", getDeleteObjectURL("", bucketName, "before"), nil, false), http.StatusForbidden)
+	expectStatus("delete config without cert", serveRequest("DELETE", getBucketClientCertURL("", bucketName), nil, false), http.StatusForbidden)
+
+	// Writes with a verified client certificate are allowed.
+	expectStatus("put object with cert", serveRequest("PUT", getPutObjectURL("", bucketName, "object"), data, true), http.StatusOK)
+	expectStatus("delete object with cert", serveRequest("DELETE", getDeleteObjectURL("", bucketName, "before"), nil, true), http.StatusNoContent)
+
+	// Reads and the writes of the other buckets are not affected.
+	expectStatus("get object without cert", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, false), http.StatusOK)
+	expectStatus("put other bucket without cert", serveRequest("PUT", getPutObjectURL("", otherBucket, "object"), data, false), http.StatusOK)
+
+	// Once the config is removed, writes are allowed again.
+	expectStatus("delete config with cert", serveRequest("DELETE", getBucketClientCertURL("", bucketName), nil, true), http.StatusNoContent)
+	expectStatus("put object after delete", serveRequest("PUT", getPutObjectURL("", bucketName, "after"), data, false), http.StatusOK)
+}
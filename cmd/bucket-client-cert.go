@@ -0,0 +1,230 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Bucket client certificate config name.
+const bucketClientCertConfig = "client-cert.xml"
+
+// errNoSuchClientCertConfig - client certificate config is not set on the bucket.
+var errNoSuchClientCertConfig = errors.New("The client certificate configuration does not exist")
+
+// ClientCertConfiguration - requires the writes of a bucket to be made
+// over TLS connections presenting a client certificate verified by the
+// client CAs, whichever way the requests are authenticated. The
+// changes of the configuration itself are writes of the bucket.
+type ClientCertConfiguration struct {
+	XMLName          xml.Name `xml:"ClientCertConfiguration"`
+	XMLNS            string   `xml:"xmlns,attr,omitempty"`
+	RequireForWrites bool     `xml:"RequireForWrites"`
+}
+
+// Variable represents bucket client certificate configs in memory.
+var globalBucketClientCert = newBucketClientCertConfigs(nil)
+
+// bucketClientCertConfigs - client certificate configs of all the buckets.
+type bucketClientCertConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of client certificate configs indexed by 'bucket'.
+	configs map[string]ClientCertConfiguration
+}
+
+// newBucketClientCertConfigs - initializes bucket client certificate configs.
+func newBucketClientCertConfigs(configs map[string]ClientCertConfiguration) *bucketClientCertConfigs {
+	if configs == nil {
+		configs = make(map[string]ClientCertConfiguration)
+	}
+	return &bucketClientCertConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the client certificate config of the bucket, false if not set.
+func (bc *bucketClientCertConfigs) Get(bucket string) (ClientCertConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the client certificate config of the bucket, nil config removes it.
+func (bc *bucketClientCertConfigs) Set(bucket string, config *ClientCertConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsRequiredForWrites - returns true if the writes of the bucket
+// require a verified client certificate.
+func (bc *bucketClientCertConfigs) IsRequiredForWrites(bucket string) bool {
+	config, ok := bc.Get(bucket)
+	return ok && config.RequireForWrites
+}
+
+// Loads all bucket client certificate configs from persistent layer.
+func loadAllBucketClientCertConfigs(objAPI ObjectLayer) (map[string]ClientCertConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ClientCertConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketClientCertConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchClientCertConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket client certificate configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket client certificate configs.
+func initBucketClientCert(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketClientCertConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket client certificate configs.
+	globalBucketClientCert = newBucketClientCertConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketClientCertConfig - reads the client certificate config of the bucket.
+func readBucketClientCertConfig(bucket string, objAPI ObjectLayer) (ClientCertConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketClientCertConfig)
+
+	// Acquire a read lock on client certificate config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ClientCertConfiguration{}, errNoSuchClientCertConfig
+		}
+		errorIf(err, "Unable to load client certificate config for the bucket %s.", bucket)
+		return ClientCertConfiguration{}, errorCause(err)
+	}
+
+	var config ClientCertConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ClientCertConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketClientCertConfig - saves the client certificate config of
+// the bucket, nil config removes any previously saved config.
+func writeBucketClientCertConfig(bucket string, config *ClientCertConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketClientCertConfig)
+
+	// Acquire a write lock on client certificate config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove client certificate config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set client certificate config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketClientCertChange - persists the client
+// certificate config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketClientCertChange(bucket string, config *ClientCertConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketClientCertConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketClientCert(bucket, config)
+	return nil
+}
+
+// hasVerifiedClientCert - returns true if the connection of the request
+// presented a client certificate verified by the client CAs.
+func hasVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0
+}
+
+type clientCertRequiredHandler struct {
+	handler http.Handler
+}
+
+// setClientCertRequiredHandler - rejects the writes of the buckets
+// requiring a client certificate for their writes, unless their
+// connection presented a verified client certificate. The certificate
+// is not required to be mapped to an identity, the requests are
+// authenticated afterwards as usual.
+func setClientCertRequiredHandler(h http.Handler) http.Handler {
+	return clientCertRequiredHandler{h}
+}
+
+func (h clientCertRequiredHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := urlPath2BucketObjectName(r.URL)
+	if bucket != "" && !strings.HasPrefix(r.URL.Path, reservedBucket+slashSeparator) &&
+		globalBucketClientCert.IsRequiredForWrites(bucket) && isWriteRequest(r) && !hasVerifiedClientCert(r) {
+		writeErrorResponse(w, ErrClientCertRequired, r.URL)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
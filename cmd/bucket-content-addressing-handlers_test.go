@@ -0,0 +1,262 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT, GET and DELETE bucket content addressing along with the
+// objects accepted and rejected by content-addressed buckets.
+func TestBucketContentAddressingHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketContentAddressingHandlers, []string{
+		"GetBucketContentAddressing",
+		"PutBucketContentAddressing",
+		"DeleteBucketContentAddressing",
+		"CopyObject",
+		"PutObject",
+		"HeadObject",
+		"NewMultipart",
+		"DeleteBucket",
+	})
+}
+
+func testBucketContentAddressingHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Content addressing configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	errorCode := func(rec *httptest.ResponseRecorder) string {
+		var errResponse APIErrorResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		return errResponse.Code
+	}
+	contentAddress := func(data []byte) string {
+		sum := sha256.Sum256(data)
+		return hex.EncodeToString(sum[:])
+	}
+
+	// Content addressing is not configured by default.
+	rec := serveRequest("GET", getBucketContentAddressingURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Invalid content addressing configs.
+	invalidConfigs := []string{
+		`<ContentAddressingConfiguration><Status>`,
+		`<ContentAddressingConfiguration></ContentAddressingConfiguration>`,
+		`<ContentAddressingConfiguration><Status>On</Status></ContentAddressingConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketContentAddressingURL("", bucketName), []byte(config), nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	// Content addressing is only enabled on an empty bucket.
+	config := `<ContentAddressingConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></ContentAddressingConfiguration>`
+	if _, err := obj.PutObject(bucketName, "unverified", 4, bytes.NewReader([]byte("data")), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = serveRequest("PUT", getBucketContentAddressingURL("", bucketName), []byte(config), nil)
+	if rec.Code != http.StatusConflict || errorCode(rec) != "InvalidBucketState" {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusConflict, rec.Code)
+	}
+	if err := obj.DeleteObject(bucketName, "unverified"); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if rec = serveRequest("PUT", getBucketContentAddressingURL("", bucketName), []byte(config), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketContentAddressingURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var contentAddressing ContentAddressingConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &contentAddressing); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if contentAddressing.Status != contentAddressingEnabled {
+		t.Fatalf("%s: Unexpected content addressing config %#v", instanceType, contentAddressing)
+	}
+
+	data := []byte("content-addressed object")
+	otherData := []byte("some other content")
+	unsignedPayloadHeader := http.Header{"X-Amz-Content-Sha256": {unsignedPayload}}
+	testCases := []struct {
+		objectName     string
+		data           []byte
+		header         http.Header
+		expectedStatus int
+		expectedCode   string
+	}{
+		// Content hashing to the key, verified by the signature and
+		// by the server.
+		{contentAddress(data), data, nil, http.StatusOK, ""},
+		{contentAddress(otherData), otherData, unsignedPayloadHeader, http.StatusOK, ""},
+		{contentAddress(nil), nil, unsignedPayloadHeader, http.StatusOK, ""},
+		// Content not hashing to the key.
+		{contentAddress(otherData), data, nil, http.StatusBadRequest, "BadDigest"},
+		{contentAddress(data), otherData, unsignedPayloadHeader, http.StatusBadRequest, "BadDigest"},
+		{contentAddress(data), nil, unsignedPayloadHeader, http.StatusBadRequest, "BadDigest"},
+		// Keys which are not a lowercase hex encoded SHA256.
+		{"object", data, nil, http.StatusBadRequest, "InvalidArgument"},
+		{"A" + contentAddress(data)[1:], data, nil, http.StatusBadRequest, "InvalidArgument"},
+	}
+	for i, testCase := range testCases {
+		// Rejected objects must not exist afterwards.
+		if testCase.expectedStatus != http.StatusOK {
+			obj.DeleteObject(bucketName, testCase.objectName)
+		}
+		rec = serveRequest("PUT", getPutObjectURL("", bucketName, testCase.objectName), testCase.data, testCase.header)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+		if testCase.expectedStatus != http.StatusOK {
+			if code := errorCode(rec); code != testCase.expectedCode {
+				t.Fatalf("%s: Test %d: Expected error %s, got %s", instanceType, i+1, testCase.expectedCode, code)
+			}
+			if _, err := obj.GetObjectInfo(bucketName, testCase.objectName); err == nil {
+				t.Fatalf("%s: Test %d: Expected the rejected object not to be stored", instanceType, i+1)
+			}
+			continue
+		}
+		// SHA256 checksum is returned along with the object.
+		rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, testCase.objectName), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		sum := sha256.Sum256(testCase.data)
+		if checksum := rec.Header().Get("X-Amz-Checksum-Sha256"); checksum != base64.StdEncoding.EncodeToString(sum[:]) {
+			t.Fatalf("%s: Test %d: Unexpected checksum %s", instanceType, i+1, checksum)
+		}
+	}
+
+	// Content of aws-chunked uploads is verified as well.
+	req, err := newTestStreamingSignedRequest("PUT", getPutObjectURL("", bucketName, contentAddress(otherData)),
+		int64(len(data)), 8, bytes.NewReader(data), credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	obj.DeleteObject(bucketName, contentAddress(otherData))
+	rec = httptest.NewRecorder()
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || errorCode(rec) != "BadDigest" {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Copies are named after the SHA256 of their source.
+	srcBucket := "content-source"
+	if err = obj.MakeBucket(srcBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(srcBucket, "source", int64(len(otherData)), bytes.NewReader(otherData), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	copyTestCases := []struct {
+		source         string
+		objectName     string
+		expectedStatus int
+	}{
+		{srcBucket + "/source", contentAddress(otherData), http.StatusOK},
+		{srcBucket + "/source", contentAddress(data), http.StatusBadRequest},
+		// Sources of the content-addressed bucket are not read again.
+		{bucketName + "/" + contentAddress(otherData), contentAddress(otherData), http.StatusOK},
+		{bucketName + "/" + contentAddress(otherData), contentAddress(data), http.StatusBadRequest},
+		{srcBucket + "/source", "copy", http.StatusBadRequest},
+	}
+	for i, testCase := range copyTestCases {
+		header := http.Header{"X-Amz-Copy-Source": {"/" + testCase.source}}
+		if testCase.source == bucketName+"/"+testCase.objectName {
+			header.Set("X-Amz-Metadata-Directive", "REPLACE")
+		}
+		if rec = serveRequest("PUT", getCopyObjectURL("", bucketName, testCase.objectName), nil, header); rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+
+	// Multipart uploads are not verified, they are not supported.
+	if rec = serveRequest("POST", getNewMultipartURL("", bucketName, contentAddress(data)), nil, nil); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotImplemented, rec.Code)
+	}
+
+	// Delete content addressing.
+	if rec = serveRequest("DELETE", getBucketContentAddressingURL("", bucketName), nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = serveRequest("GET", getBucketContentAddressingURL("", bucketName), nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	if rec = serveRequest("PUT", getPutObjectURL("", bucketName, "object"), data, nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+
+	// Content addressing does not survive the bucket, a bucket recreated
+	// with the same name accepts any key.
+	recreatedBucket := "content-recreated"
+	if err = obj.MakeBucket(recreatedBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if rec = serveRequest("PUT", getBucketContentAddressingURL("", recreatedBucket), []byte(config), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec = serveRequest("DELETE", getDeleteBucketURL("", recreatedBucket), nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if err = obj.MakeBucket(recreatedBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if rec = serveRequest("GET", getBucketContentAddressingURL("", recreatedBucket), nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	if rec = serveRequest("PUT", getPutObjectURL("", recreatedBucket, "object"), data, nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+}
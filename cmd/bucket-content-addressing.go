@@ -0,0 +1,365 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"hash"
+	"io"
+	"path"
+	"sync"
+)
+
+const (
+	// Bucket content addressing config name.
+	bucketContentAddressingConfig = "content-addressing.xml"
+
+	// Content addressing status values.
+	contentAddressingEnabled  = "Enabled"
+	contentAddressingDisabled = "Disabled"
+)
+
+// errInvalidContentAddressingConfig - content addressing config is not valid.
+var errInvalidContentAddressingConfig = errors.New("Invalid content addressing configuration")
+
+// errNoSuchContentAddressingConfig - content addressing config is not set on the bucket.
+var errNoSuchContentAddressingConfig = errors.New("The content addressing configuration does not exist")
+
+// errContentAddressingBucketNotEmpty - content addressing is enabled on a
+// bucket holding objects whose keys were never verified.
+var errContentAddressingBucketNotEmpty = errors.New("Content addressing can only be enabled on an empty bucket")
+
+// errInvalidContentAddress - object key is not a SHA256 content address.
+var errInvalidContentAddress = errors.New("Object key is not the lowercase hex encoded SHA256 of the object content")
+
+// errContentAddressMismatch - SHA256 of the object content is not its key.
+var errContentAddressMismatch = errors.New("SHA256 of the object content does not match the object key")
+
+// errContentAddressingUnsupported - operation can not verify the content
+// of the objects of a content-addressed bucket.
+var errContentAddressingUnsupported = errors.New("Operation is not supported by content-addressed buckets")
+
+// ContentAddressingConfiguration - makes the keys of the objects of a
+// bucket the lowercase hex encoded SHA256 of their content. Objects are
+// only written if their content hashes to their key, so that they are
+// immutable and identical contents are stored once.
+type ContentAddressingConfiguration struct {
+	XMLName xml.Name `xml:"ContentAddressingConfiguration"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status"`
+}
+
+// validateContentAddressingConfig - validates the content addressing status.
+func validateContentAddressingConfig(config ContentAddressingConfiguration) error {
+	if config.Status != contentAddressingEnabled && config.Status != contentAddressingDisabled {
+		return errInvalidContentAddressingConfig
+	}
+	return nil
+}
+
+// Variable represents bucket content addressing configs in memory.
+var globalBucketContentAddressing = newBucketContentAddressingConfigs(nil)
+
+// bucketContentAddressingConfigs - content addressing configs of all the buckets.
+type bucketContentAddressingConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of content addressing configs indexed by 'bucket'.
+	configs map[string]ContentAddressingConfiguration
+}
+
+// newBucketContentAddressingConfigs - initializes bucket content addressing configs.
+func newBucketContentAddressingConfigs(configs map[string]ContentAddressingConfiguration) *bucketContentAddressingConfigs {
+	if configs == nil {
+		configs = make(map[string]ContentAddressingConfiguration)
+	}
+	return &bucketContentAddressingConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the content addressing config of the bucket, false if not set.
+func (bc *bucketContentAddressingConfigs) Get(bucket string) (ContentAddressingConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the content addressing config of the bucket, nil config removes it.
+func (bc *bucketContentAddressingConfigs) Set(bucket string, config *ContentAddressingConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsEnabled - returns true if content addressing is enabled on the bucket.
+func (bc *bucketContentAddressingConfigs) IsEnabled(bucket string) bool {
+	config, ok := bc.Get(bucket)
+	return ok && config.Status == contentAddressingEnabled
+}
+
+// checkContentAddressingBucketEmpty - content addressing is only enabled
+// on buckets without objects nor uploads in progress, the objects of a
+// content-addressed bucket are then all verified.
+func checkContentAddressingBucketEmpty(objAPI ObjectLayer, bucket string) error {
+	objects, err := objAPI.ListObjects(bucket, "", "", "", 1)
+	if err != nil {
+		return err
+	}
+	uploads, err := objAPI.ListMultipartUploads(bucket, "", "", "", "", 1)
+	if err != nil {
+		return err
+	}
+	if len(objects.Objects) > 0 || len(objects.Prefixes) > 0 || len(uploads.Uploads) > 0 {
+		return errContentAddressingBucketNotEmpty
+	}
+	return nil
+}
+
+// isValidContentAddress - returns true if the object name is a lowercase
+// hex encoded SHA256.
+func isValidContentAddress(object string) bool {
+	if len(object) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	for i := 0; i < len(object); i++ {
+		if c := object[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// checkContentAddress - the objects of content-addressed buckets must be
+// named after the SHA256 of their content.
+func checkContentAddress(bucket, object string) error {
+	if globalBucketContentAddressing.IsEnabled(bucket) && !isValidContentAddress(object) {
+		return errInvalidContentAddress
+	}
+	return nil
+}
+
+// isContentAddressedObject - returns true if the content of the object
+// is known to hash to its key, it is not read again to be verified.
+func isContentAddressedObject(bucket, object string) bool {
+	return globalBucketContentAddressing.IsEnabled(bucket) && isValidContentAddress(object)
+}
+
+// checkContentAddressedCopy - the copy of the source object must be
+// named after the SHA256 of its content, the source is hashed unless it
+// is verified against its key already.
+func checkContentAddressedCopy(objAPI ObjectLayer, srcBucket, srcObject, dstBucket, dstObject string, size int64) error {
+	if err := checkContentAddress(dstBucket, dstObject); err != nil {
+		return err
+	}
+	if isContentAddressedObject(srcBucket, srcObject) {
+		if srcObject != dstObject {
+			return errContentAddressMismatch
+		}
+		return nil
+	}
+	_, checksum, err := getObjectChecksums(objAPI, srcBucket, srcObject, size, checksumSHA256)
+	if err != nil {
+		return err
+	}
+	sum, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(sum) != dstObject {
+		return errContentAddressMismatch
+	}
+	return nil
+}
+
+// setContentAddressMetadata - saves the SHA256 checksum of the content
+// addressed by the object key, the checksum is returned by reads like
+// the checksums sent by the clients.
+func setContentAddressMetadata(metadata map[string]string, address string) {
+	sum, _ := hex.DecodeString(address)
+	setChecksumMetadata(metadata, checksumSHA256, base64.StdEncoding.EncodeToString(sum))
+}
+
+// newContentAddressReader - returns the reader of the size bytes of the
+// object content, failing with errContentAddressMismatch before the last
+// byte is returned if the content does not hash to the address. Content
+// whose SHA256 is already verified by the object layer is not hashed
+// again.
+func newContentAddressReader(reader io.Reader, size int64, address, sha256sum string) (io.Reader, error) {
+	if sha256sum != "" {
+		if sha256sum != address {
+			return nil, errContentAddressMismatch
+		}
+		return reader, nil
+	}
+	if size == 0 {
+		if address != emptySHA256 {
+			return nil, errContentAddressMismatch
+		}
+		return reader, nil
+	}
+	return &contentAddressReader{
+		reader:     io.LimitReader(reader, size),
+		address:    address,
+		remaining:  size,
+		sha256Hash: sha256.New(),
+	}, nil
+}
+
+// contentAddressReader - hashes the content read and verifies it against
+// the address once fully read.
+type contentAddressReader struct {
+	reader     io.Reader
+	address    string
+	remaining  int64
+	sha256Hash hash.Hash
+}
+
+func (r *contentAddressReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	r.sha256Hash.Write(p[:n])
+	r.remaining -= int64(n)
+	if n > 0 && r.remaining == 0 {
+		// Last bytes are held back on mismatch, so that the object
+		// layer never sees the full content.
+		if hex.EncodeToString(r.sha256Hash.Sum(nil)) != r.address {
+			return 0, errContentAddressMismatch
+		}
+	}
+	return n, err
+}
+
+// Loads all bucket content addressing configs from persistent layer.
+func loadAllBucketContentAddressingConfigs(objAPI ObjectLayer) (map[string]ContentAddressingConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ContentAddressingConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketContentAddressingConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchContentAddressingConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket content addressing configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket content addressing configs.
+func initBucketContentAddressing(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketContentAddressingConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket content addressing configs.
+	globalBucketContentAddressing = newBucketContentAddressingConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketContentAddressingConfig - reads the content addressing config of the bucket.
+func readBucketContentAddressingConfig(bucket string, objAPI ObjectLayer) (ContentAddressingConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketContentAddressingConfig)
+
+	// Acquire a read lock on content addressing config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ContentAddressingConfiguration{}, errNoSuchContentAddressingConfig
+		}
+		errorIf(err, "Unable to load content addressing config for the bucket %s.", bucket)
+		return ContentAddressingConfiguration{}, errorCause(err)
+	}
+
+	var config ContentAddressingConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ContentAddressingConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketContentAddressingConfig - saves the content addressing config of
+// the bucket, nil config removes any previously saved config.
+func writeBucketContentAddressingConfig(bucket string, config *ContentAddressingConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketContentAddressingConfig)
+
+	// Acquire a write lock on content addressing config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove content addressing config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set content addressing config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketContentAddressingChange - persists the content
+// addressing config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketContentAddressingChange(bucket string, config *ContentAddressingConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketContentAddressingConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketContentAddressing(bucket, config)
+	return nil
+}
@@ -0,0 +1,186 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT, GET and DELETE bucket content sniffing along with the
+// content type of the objects uploaded without a Content-Type.
+func TestBucketContentSniffingHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketContentSniffingHandlers, []string{
+		"GetBucketContentSniffing",
+		"PutBucketContentSniffing",
+		"DeleteBucketContentSniffing",
+		"PutObject",
+		"HeadObject",
+		"GetObject",
+	})
+}
+
+func testBucketContentSniffingHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Content sniffing configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Content sniffing is not configured by default.
+	rec := serveRequest("GET", getBucketContentSniffingURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Invalid content sniffing configs.
+	invalidConfigs := []string{
+		`<ContentSniffingConfiguration><Status>`,
+		`<ContentSniffingConfiguration></ContentSniffingConfiguration>`,
+		`<ContentSniffingConfiguration><Status>On</Status></ContentSniffingConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketContentSniffingURL("", bucketName), []byte(config), nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	pngData := append([]byte("\x89PNG\x0D\x0A\x1A\x0A"), bytes.Repeat([]byte{0}, 1024)...)
+	putObject := func(objectName string, data []byte, contentType string) {
+		header := http.Header{}
+		if contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+		if rec = serveRequest("PUT", getPutObjectURL("", bucketName, objectName), data, header); rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+	}
+	getContentType := func(objectName string, data []byte) string {
+		rec = serveRequest("GET", getGetObjectURL("", bucketName, objectName), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), data) {
+			t.Fatalf("%s: Object %s does not match the uploaded data", instanceType, objectName)
+		}
+		// Stored content type, GET responses without it are sniffed
+		// by the recorder.
+		rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, objectName), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		return rec.Header().Get("Content-Type")
+	}
+
+	// Objects without a Content-Type are stored without a type unless
+	// content sniffing is enabled.
+	putObject("disabled", pngData, "")
+	if contentType := getContentType("disabled", pngData); contentType != "" {
+		t.Fatalf("%s: Expected no content type, got %s", instanceType, contentType)
+	}
+
+	config := `<ContentSniffingConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Status>Enabled</Status></ContentSniffingConfiguration>`
+	if rec = serveRequest("PUT", getBucketContentSniffingURL("", bucketName), []byte(config), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketContentSniffingURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var contentSniffing ContentSniffingConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &contentSniffing); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if contentSniffing.Status != contentSniffingEnabled {
+		t.Fatalf("%s: Unexpected content sniffing config %#v", instanceType, contentSniffing)
+	}
+
+	testCases := []struct {
+		objectName          string
+		data                []byte
+		contentType         string
+		expectedContentType string
+	}{
+		// Sniffed from the data.
+		{"image", pngData, "", "image/png"},
+		{"page", []byte("<html><body>hello</body></html>"), "", "text/html; charset=utf-8"},
+		// Client provided type always wins.
+		{"explicit", pngData, "application/x-custom", "application/x-custom"},
+		// Type known from the extension is preferred.
+		{"style.css", []byte("body { color: red; }"), "", "text/css"},
+		// Undetected content is stored without a type.
+		{"binary", []byte{0x01, 0x02, 0x03, 0x04}, "", ""},
+	}
+	for i, testCase := range testCases {
+		putObject(testCase.objectName, testCase.data, testCase.contentType)
+		if contentType := getContentType(testCase.objectName, testCase.data); contentType != testCase.expectedContentType {
+			t.Errorf("%s: Test %d: Expected content type %s, got %s", instanceType, i+1, testCase.expectedContentType, contentType)
+		}
+	}
+
+	// Content type is sniffed from the decoded data of streaming uploads.
+	req, err := newTestStreamingSignedRequest("PUT", getPutObjectURL("", bucketName, "streaming"),
+		int64(len(pngData)), 64, bytes.NewReader(pngData), credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	rec = httptest.NewRecorder()
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if contentType := getContentType("streaming", pngData); contentType != "image/png" {
+		t.Errorf("%s: Expected content type image/png, got %s", instanceType, contentType)
+	}
+
+	// Delete content sniffing.
+	if rec = serveRequest("DELETE", getBucketContentSniffingURL("", bucketName), nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = serveRequest("GET", getBucketContentSniffingURL("", bucketName), nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	putObject("deleted", pngData, "")
+	if contentType := getContentType("deleted", pngData); contentType != "" {
+		t.Fatalf("%s: Expected no content type, got %s", instanceType, contentType)
+	}
+}
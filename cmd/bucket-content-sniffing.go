@@ -0,0 +1,261 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio/pkg/mimedb"
+)
+
+const (
+	// Bucket content sniffing config name.
+	bucketContentSniffingConfig = "content-sniffing.xml"
+
+	// Content sniffing status values.
+	contentSniffingEnabled  = "Enabled"
+	contentSniffingDisabled = "Disabled"
+
+	// Maximum number of bytes considered by http.DetectContentType.
+	contentSniffLen = 512
+)
+
+// errInvalidContentSniffingConfig - content sniffing config is not valid.
+var errInvalidContentSniffingConfig = errors.New("Invalid content sniffing configuration")
+
+// errNoSuchContentSniffingConfig - content sniffing config is not set on the bucket.
+var errNoSuchContentSniffingConfig = errors.New("The content sniffing configuration does not exist")
+
+// ContentSniffingConfiguration - enables detection of the content type
+// of objects uploaded without a Content-Type header.
+type ContentSniffingConfiguration struct {
+	XMLName xml.Name `xml:"ContentSniffingConfiguration"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Status  string   `xml:"Status"`
+}
+
+// validateContentSniffingConfig - validates the content sniffing status.
+func validateContentSniffingConfig(config ContentSniffingConfiguration) error {
+	if config.Status != contentSniffingEnabled && config.Status != contentSniffingDisabled {
+		return errInvalidContentSniffingConfig
+	}
+	return nil
+}
+
+// Variable represents bucket content sniffing configs in memory.
+var globalBucketContentSniffing = newBucketContentSniffingConfigs(nil)
+
+// bucketContentSniffingConfigs - content sniffing configs of all the buckets.
+type bucketContentSniffingConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of content sniffing configs indexed by 'bucket'.
+	configs map[string]ContentSniffingConfiguration
+}
+
+// newBucketContentSniffingConfigs - initializes bucket content sniffing configs.
+func newBucketContentSniffingConfigs(configs map[string]ContentSniffingConfiguration) *bucketContentSniffingConfigs {
+	if configs == nil {
+		configs = make(map[string]ContentSniffingConfiguration)
+	}
+	return &bucketContentSniffingConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the content sniffing config of the bucket, false if not set.
+func (bc *bucketContentSniffingConfigs) Get(bucket string) (ContentSniffingConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the content sniffing config of the bucket, nil config removes it.
+func (bc *bucketContentSniffingConfigs) Set(bucket string, config *ContentSniffingConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsEnabled - returns true if content sniffing is enabled on the bucket.
+func (bc *bucketContentSniffingConfigs) IsEnabled(bucket string) bool {
+	config, ok := bc.Get(bucket)
+	return ok && config.Status == contentSniffingEnabled
+}
+
+// isContentSniffingRequired - returns true if the content type of the
+// uploaded object is to be sniffed from its data. A Content-Type sent
+// by the client always wins and types known from the object extension
+// are preferred over the sniffed ones.
+func isContentSniffingRequired(bucket, object string, header http.Header, size int64) bool {
+	if size <= 0 || header.Get("Content-Type") != "" {
+		return false
+	}
+	if objectExt := path.Ext(object); objectExt != "" {
+		if _, ok := mimedb.DB[strings.ToLower(strings.TrimPrefix(objectExt, "."))]; ok {
+			return false
+		}
+	}
+	return globalBucketContentSniffing.IsEnabled(bucket)
+}
+
+// sniffContentType - detects the content type from the first bytes of
+// the reader and saves it in the metadata, the returned reader replays
+// the sniffed bytes followed by the rest of the data so that the data
+// is still read only once.
+func sniffContentType(reader io.Reader, size int64, metadata map[string]string) (io.Reader, error) {
+	sniffLen := int64(contentSniffLen)
+	if size < sniffLen {
+		sniffLen = size
+	}
+	buf := make([]byte, sniffLen)
+	// Short data is detected by the object layer.
+	n, err := io.ReadFull(reader, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	// Undetected content is left to the default type.
+	if contentType := http.DetectContentType(buf); contentType != "application/octet-stream" {
+		metadata["content-type"] = contentType
+	}
+	return io.MultiReader(bytes.NewReader(buf), reader), nil
+}
+
+// Loads all bucket content sniffing configs from persistent layer.
+func loadAllBucketContentSniffingConfigs(objAPI ObjectLayer) (map[string]ContentSniffingConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ContentSniffingConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketContentSniffingConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchContentSniffingConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket content sniffing configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket content sniffing configs.
+func initBucketContentSniffing(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketContentSniffingConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket content sniffing configs.
+	globalBucketContentSniffing = newBucketContentSniffingConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketContentSniffingConfig - reads the content sniffing config of the bucket.
+func readBucketContentSniffingConfig(bucket string, objAPI ObjectLayer) (ContentSniffingConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketContentSniffingConfig)
+
+	// Acquire a read lock on content sniffing config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ContentSniffingConfiguration{}, errNoSuchContentSniffingConfig
+		}
+		errorIf(err, "Unable to load content sniffing config for the bucket %s.", bucket)
+		return ContentSniffingConfiguration{}, errorCause(err)
+	}
+
+	var config ContentSniffingConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ContentSniffingConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketContentSniffingConfig - saves the content sniffing config of
+// the bucket, nil config removes any previously saved config.
+func writeBucketContentSniffingConfig(bucket string, config *ContentSniffingConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketContentSniffingConfig)
+
+	// Acquire a write lock on content sniffing config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove content sniffing config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set content sniffing config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketContentSniffingChange - persists the content
+// sniffing config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketContentSniffingChange(bucket string, config *ContentSniffingConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketContentSniffingConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketContentSniffing(bucket, config)
+	return nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// Lock path in the meta bucket serializing the creation of the
+// buckets while their maximum number is enforced.
+const maxBucketsLockPath = "max-buckets.lock"
+
+// errTooManyBuckets - the maximum number of buckets is reached.
+var errTooManyBuckets = errors.New("The maximum number of buckets is reached")
+
+// setMaxBuckets - sets the maximum number of buckets from
+// MINIO_MAX_BUCKETS env.
+func setMaxBuckets() {
+	if maxBuckets := os.Getenv("MINIO_MAX_BUCKETS"); maxBuckets != "" {
+		n, err := strconv.Atoi(maxBuckets)
+		if err != nil || n < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_BUCKETS value %s.", maxBuckets)
+		}
+		globalMaxBuckets = n
+	}
+}
+
+// makeBucketWithinLimit - creates the bucket unless the maximum number
+// of buckets is reached. While a maximum is set the creations are
+// serialized, so that concurrent creations never exceed it.
+func makeBucketWithinLimit(objAPI ObjectLayer, bucket string) error {
+	if globalMaxBuckets == 0 {
+		return objAPI.MakeBucket(bucket)
+	}
+
+	maxBucketsLock := globalNSMutex.NewNSLock(minioMetaBucket, maxBucketsLockPath)
+	maxBucketsLock.Lock()
+	defer maxBucketsLock.Unlock()
+
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	if len(buckets) >= globalMaxBuckets {
+		// Creating an existing bucket fails with its own error.
+		for _, bucketInfo := range buckets {
+			if bucketInfo.Name == bucket {
+				return objAPI.MakeBucket(bucket)
+			}
+		}
+		return traceError(errTooManyBuckets)
+	}
+	return objAPI.MakeBucket(bucket)
+}
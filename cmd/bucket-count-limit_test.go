@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Tests concurrent bucket creations never exceed the maximum number
+// of buckets.
+func TestMakeBucketWithinLimit(t *testing.T) {
+	ExecObjectLayerTest(t, testMakeBucketWithinLimit)
+}
+
+func testMakeBucketWithinLimit(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	maxBuckets := 5
+	globalMaxBuckets = maxBuckets
+	defer func() { globalMaxBuckets = 0 }()
+
+	attempts := 3 * maxBuckets
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = makeBucketWithinLimit(obj, fmt.Sprintf("bucket-limit-%d", i))
+		}(i)
+	}
+	wg.Wait()
+
+	created := 0
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case toAPIErrorCode(err) != ErrTooManyBuckets:
+			t.Fatalf("%s: Attempt %d: Expected error %v, got %v", instanceType, i+1, errTooManyBuckets, err)
+		}
+	}
+	if created != maxBuckets {
+		t.Fatalf("%s: Expected %d buckets to be created, got %d", instanceType, maxBuckets, created)
+	}
+	buckets, err := obj.ListBuckets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != maxBuckets {
+		t.Fatalf("%s: Expected %d buckets, got %d", instanceType, maxBuckets, len(buckets))
+	}
+
+	// Existing buckets are reported as such once the limit is reached.
+	if err = makeBucketWithinLimit(obj, buckets[0].Name); toAPIErrorCode(err) != ErrBucketAlreadyOwnedByYou {
+		t.Fatalf("%s: Expected bucket exists error, got %v", instanceType, err)
+	}
+
+	// Buckets can be created again once some are removed.
+	if err = obj.DeleteBucket(buckets[0].Name); err != nil {
+		t.Fatal(err)
+	}
+	if err = makeBucketWithinLimit(obj, "bucket-limit-new"); err != nil {
+		t.Fatalf("%s: Expected bucket to be created, got %v", instanceType, err)
+	}
+}
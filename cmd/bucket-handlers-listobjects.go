@@ -25,21 +25,21 @@ import (
 // Validate all the ListObjects query arguments, returns an APIErrorCode
 // if one of the args do not meet the required conditions.
 // Special conditions required by Minio server are as below
-// - delimiter if set should be equal to '/', otherwise the request is rejected.
 // - marker if set should have a common prefix with 'prefix' param, otherwise
 //   the request is rejected.
-func validateListObjectsArgs(prefix, marker, delimiter string, maxKeys int) APIErrorCode {
+func validateListObjectsArgs(prefix, marker, delimiter, encodingType string, maxKeys int) APIErrorCode {
 	// Max keys cannot be negative.
 	if maxKeys < 0 {
 		return ErrInvalidMaxKeys
 	}
 
+	// Only url encoding type is supported.
+	if encodingType != "" && encodingType != listEncodingTypeURL {
+		return ErrInvalidEncodingMethod
+	}
+
 	/// Minio special conditions for ListObjects.
 
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != "/" {
-		return ErrNotImplemented
-	}
 	// Marker is set validate pre-condition.
 	if marker != "" {
 		// Marker not common with prefix is not implemented.
@@ -75,32 +75,96 @@ func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http
 	}
 
 	// Extract all the listObjectsV2 query params to their native values.
-	prefix, token, startAfter, delimiter, fetchOwner, maxKeys, _ := getListObjectsV2Args(r.URL.Query())
+	prefix, token, startAfter, delimiter, fetchOwner, fetchChecksum, maxKeys, encodingType := getListObjectsV2Args(r.URL.Query())
+
+	// Continuation token is the opaque encoding of the marker, the
+	// response echoes the token as received.
+	tokenMarker, err := decodeListToken(bucket, token)
+	if err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Minio extension, objects are listed in lexical order unless
+	// requested otherwise.
+	order, s3Error := getListObjectsOrder(r.URL.Query().Get(listOrderQueryParam))
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Minio extension, objects are filtered on their user metadata if
+	// requested.
+	filter, s3Error := getMetadataFilter(r.URL.Query())
+	if s3Error == ErrNone && filter != nil && order == listOrderNewestFirst {
+		s3Error = ErrInvalidMetadataFilter
+	}
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+	if order == listOrderNewestFirst {
+		// Newest first order lists all the objects under the prefix,
+		// continuation token is not an object name.
+		if delimiter != "" || startAfter != "" {
+			writeErrorResponse(w, ErrInvalidListOrder, r.URL)
+			return
+		}
+		if s3Error = validateListObjectsArgs(prefix, "", delimiter, encodingType, maxKeys); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+		listObjectsInfo, err := listObjectsNewestFirst(objectAPI, bucket, prefix, tokenMarker, maxKeys)
+		if err != nil {
+			errorIf(err, "Unable to list objects.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		if fetchChecksum {
+			if err = loadListObjectsMetadata(objectAPI, bucket, listObjectsInfo.Objects); err != nil {
+				errorIf(err, "Unable to list objects.")
+				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+				return
+			}
+		}
+		listObjectsInfo.NextMarker = encodeListToken(bucket, listObjectsInfo.NextMarker)
+		response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, encodingType, fetchOwner, fetchChecksum, maxKeys, listObjectsInfo)
+		writeSuccessResponseXML(w, encodeResponse(response))
+		return
+	}
 
 	// In ListObjectsV2 'continuation-token' is the marker.
-	marker := token
+	marker := tokenMarker
 	// Check if 'continuation-token' is empty.
 	if token == "" {
 		// Then we need to use 'start-after' as marker instead.
 		marker = startAfter
 	}
 	// Validate the query params before beginning to serve the request.
-	// fetch-owner is not validated since it is a boolean
-	if s3Error := validateListObjectsArgs(prefix, marker, delimiter, maxKeys); s3Error != ErrNone {
+	// fetch-owner and fetch-checksum are not validated since they are booleans
+	if s3Error := validateListObjectsArgs(prefix, marker, delimiter, encodingType, maxKeys); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 	// Inititate a list objects operation based on the input params.
 	// On success would return back ListObjectsInfo object to be
 	// marshalled into S3 compatible XML header.
-	listObjectsInfo, err := objectAPI.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	listObjectsInfo, err := listObjectsWithMetadataFilter(objectAPI, bucket, prefix, marker, delimiter, maxKeys, filter)
 	if err != nil {
 		errorIf(err, "Unable to list objects.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if fetchChecksum {
+		if err = loadListObjectsMetadata(objectAPI, bucket, listObjectsInfo.Objects); err != nil {
+			errorIf(err, "Unable to list objects.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	listObjectsInfo.NextMarker = encodeListToken(bucket, listObjectsInfo.NextMarker)
 
-	response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, fetchOwner, maxKeys, listObjectsInfo)
+	response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, encodingType, fetchOwner, fetchChecksum, maxKeys, listObjectsInfo)
 
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(response))
@@ -127,11 +191,22 @@ func (api objectAPIHandlers) ListObjectsV1Handler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// ListObjects V1 is deprecated.
+	setDeprecationWarning(w, deprecationListObjectsV1)
+
 	// Extract all the litsObjectsV1 query params to their native values.
-	prefix, marker, delimiter, maxKeys, _ := getListObjectsV1Args(r.URL.Query())
+	prefix, marker, delimiter, maxKeys, encodingType := getListObjectsV1Args(r.URL.Query())
 
 	// Validate all the query params before beginning to serve the request.
-	if s3Error := validateListObjectsArgs(prefix, marker, delimiter, maxKeys); s3Error != ErrNone {
+	if s3Error := validateListObjectsArgs(prefix, marker, delimiter, encodingType, maxKeys); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Minio extension, objects are filtered on their user metadata if
+	// requested.
+	filter, s3Error := getMetadataFilter(r.URL.Query())
+	if s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
@@ -139,13 +214,13 @@ func (api objectAPIHandlers) ListObjectsV1Handler(w http.ResponseWriter, r *http
 	// Inititate a list objects operation based on the input params.
 	// On success would return back ListObjectsInfo object to be
 	// marshalled into S3 compatible XML header.
-	listObjectsInfo, err := objectAPI.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	listObjectsInfo, err := listObjectsWithMetadataFilter(objectAPI, bucket, prefix, marker, delimiter, maxKeys, filter)
 	if err != nil {
 		errorIf(err, "Unable to list objects.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
-	response := generateListObjectsV1Response(bucket, prefix, marker, delimiter, maxKeys, listObjectsInfo)
+	response := generateListObjectsV1Response(bucket, prefix, marker, delimiter, encodingType, maxKeys, listObjectsInfo)
 
 	// Write success response.
 	writeSuccessResponseXML(w, encodeResponse(response))
@@ -26,6 +26,7 @@ import (
 	"path"
 	"strings"
 	"sync"
+	"time"
 
 	mux "github.com/gorilla/mux"
 	"github.com/minio/minio-go/pkg/set"
@@ -56,6 +57,11 @@ func enforceBucketPolicy(bucket, action, resource, referer string, queryParams u
 		return ErrAccessDenied
 	}
 
+	// Public access may be restricted on the bucket.
+	if isPublicAccessRestricted(bucket, policy) {
+		return ErrAccessDenied
+	}
+
 	// Construct resource in 'arn:aws:s3:::examplebucket/object' format.
 	arn := bucketARNPrefix + strings.TrimSuffix(strings.TrimPrefix(resource, "/"), "/")
 
@@ -80,7 +86,7 @@ func enforceBucketPolicy(bucket, action, resource, referer string, queryParams u
 // Check if the action is allowed on the bucket/prefix.
 func isBucketActionAllowed(action, bucket, prefix string) bool {
 	policy := globalBucketPolicies.GetBucketPolicy(bucket)
-	if policy == nil {
+	if policy == nil || isPublicAccessRestricted(bucket, policy) {
 		return false
 	}
 	resource := bucketARNPrefix + path.Join(bucket, prefix)
@@ -278,7 +284,15 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		wg.Add(1)
 		go func(i int, obj ObjectIdentifier) {
 			defer wg.Done()
-			dErr := objectAPI.DeleteObject(bucket, obj.ObjectName)
+			objectLock := globalNSMutex.NewNSLock(bucket, obj.ObjectName)
+			objectLock.Lock()
+			defer objectLock.Unlock()
+
+			// Objects under retention can not be deleted.
+			dErr := enforceObjectRetention(objectAPI, bucket, obj.ObjectName, r)
+			if dErr == nil {
+				dErr = deleteObjectOrTrash(objectAPI, bucket, obj.ObjectName)
+			}
 			if dErr != nil {
 				dErrs[i] = dErr
 			}
@@ -369,7 +383,7 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 	defer bucketLock.Unlock()
 
 	// Proceed to creating a bucket.
-	err := objectAPI.MakeBucket(bucket)
+	err := makeBucketWithinLimit(objectAPI, bucket)
 	if err != nil {
 		errorIf(err, "Unable to create a bucket.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -400,6 +414,9 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Form data is read within the bandwidth limit.
+	defer throttleRequestBody(r)()
+
 	// Here the parameter is the size of the form data that should
 	// be loaded in memory, the remaining being put in temporary files.
 	reader, err := r.MultipartReader()
@@ -441,6 +458,11 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	}
 	object := formValues["Key"]
 
+	// POST policies signed with Signature V2 are deprecated.
+	if formValues["Signature"] != "" {
+		setDeprecationWarning(w, deprecationSigV2)
+	}
+
 	// Verify policy signature.
 	apiErr := doesPolicySignatureMatch(formValues)
 	if apiErr != ErrNone {
@@ -467,7 +489,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	}
 
 	// Ensure that the object size is within expected range, also the file size
-	// should not exceed the maximum single Put size (5 GiB)
+	// should not exceed the maximum single Put size (5 GiB by default)
 	lengthRange := postPolicyForm.Conditions.ContentLengthRange
 	if lengthRange.Valid {
 		if fileSize < lengthRange.Min {
@@ -476,23 +498,67 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 			return
 		}
 
-		if fileSize > lengthRange.Max || fileSize > maxObjectSize {
+		if fileSize > lengthRange.Max {
 			errorIf(err, "Unable to create object.")
 			writeErrorResponse(w, toAPIErrorCode(errDataTooLarge), r.URL)
 			return
 		}
 	}
+	if isMaxObjectSize(fileSize) {
+		writeErrorResponse(w, toAPIErrorCode(errDataTooLarge), r.URL)
+		return
+	}
+
+	// Object size must be within the limits of the bucket, if any.
+	if err = checkObjectSizeLimits(bucket, fileSize); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Object ACLs are rejected if disabled on the bucket.
+	if err = checkObjectACLAllowed(bucket, formValues["Acl"]); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Objects of content-addressed buckets are named after their SHA256.
+	if err = checkContentAddress(bucket, object); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 
 	// Extract metadata to be saved from received Form.
 	metadata := extractMetadataFromForm(formValues)
 
+	// Inherit the default retention of the bucket.
+	if err = setObjectRetentionMetadata(bucket, nil, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	sha256sum := ""
 
+	// Content not hashing to its key is never stored.
+	var objReader io.Reader = fileBody
+	if globalBucketContentAddressing.IsEnabled(bucket) {
+		setContentAddressMetadata(metadata, object)
+		if objReader, err = newContentAddressReader(fileBody, fileSize, object, sha256sum); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
-	objInfo, err := objectAPI.PutObject(bucket, object, fileSize, fileBody, metadata, sha256sum)
+	// Objects under retention can not be overwritten.
+	if err = enforceObjectRetention(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	objInfo, err := objectAPI.PutObject(bucket, object, fileSize, objReader, metadata, sha256sum)
 	if err != nil {
 		errorIf(err, "Unable to create object.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -502,35 +568,32 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	w.Header().Set("Location", getObjectLocation(bucket, object))
 
 	successRedirect := formValues[http.CanonicalHeaderKey("success_action_redirect")]
+	if successRedirect == "" {
+		// Older forms use the redirect field.
+		successRedirect = formValues[http.CanonicalHeaderKey("redirect")]
+	}
 	successStatus := formValues[http.CanonicalHeaderKey("success_action_status")]
 
-	if successStatus == "" && successRedirect == "" {
+	// Redirect URLs which are not valid absolute URLs are ignored and
+	// the response depends on success_action_status instead.
+	redirectURL := getPostSuccessRedirectURL(successRedirect, bucket, object, "\""+objInfo.MD5Sum+"\"")
+
+	// Decide what http response to send depending on success_action_status parameter
+	switch {
+	case redirectURL != "":
+		writeRedirectSeeOther(w, redirectURL)
+	case successStatus == "201":
+		resp := encodeResponse(PostResponse{
+			Bucket:   bucket,
+			Key:      object,
+			ETag:     "\"" + objInfo.MD5Sum + "\"",
+			Location: getObjectLocation(bucket, object),
+		})
+		writeResponse(w, http.StatusCreated, resp, "application/xml")
+	case successStatus == "200":
+		writeSuccessResponseHeadersOnly(w)
+	default:
 		writeSuccessNoContent(w)
-	} else {
-		if successRedirect != "" {
-			redirectURL := successRedirect + "?" + fmt.Sprintf("bucket=%s&key=%s&etag=%s",
-				bucket,
-				getURLEncodedName(object),
-				getURLEncodedName("\""+objInfo.MD5Sum+"\""))
-
-			writeRedirectSeeOther(w, redirectURL)
-		} else {
-			// Decide what http response to send depending on success_action_status parameter
-			switch successStatus {
-			case "201":
-				resp := encodeResponse(PostResponse{
-					Bucket:   bucket,
-					Key:      object,
-					ETag:     "\"" + objInfo.MD5Sum + "\"",
-					Location: getObjectLocation(bucket, object),
-				})
-				writeResponse(w, http.StatusCreated, resp, "application/xml")
-			case "200":
-				writeSuccessResponseHeadersOnly(w)
-			default:
-				writeSuccessNoContent(w)
-			}
-		}
 	}
 
 	// Notify object created event.
@@ -544,6 +607,26 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	})
 }
 
+// getPostSuccessRedirectURL - returns the URL to redirect to after a
+// successful POST upload with the bucket, key and etag of the object
+// added to its query, empty if the URL is not a valid absolute URL.
+func getPostSuccessRedirectURL(redirect, bucket, object, etag string) string {
+	if redirect == "" {
+		return ""
+	}
+	u, err := url.Parse(redirect)
+	if err != nil || !u.IsAbs() {
+		return ""
+	}
+	query := fmt.Sprintf("bucket=%s&key=%s&etag=%s", bucket, getURLEncodedName(object), getURLEncodedName(etag))
+	if u.RawQuery != "" {
+		u.RawQuery += "&" + query
+	} else {
+		u.RawQuery = query
+	}
+	return u.String()
+}
+
 // HeadBucketHandler - HEAD Bucket
 // ----------
 // This operation is useful to determine if a bucket exists.
@@ -615,6 +698,52 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 	// Delete listener config, if present - ignore any errors.
 	_ = removeListenerConfig(bucket, objectAPI)
 
+	// Delete logging config, if present - ignore any errors.
+	_ = persistAndNotifyBucketLoggingChange(bucket, BucketLoggingStatus{}, objectAPI)
+
+	// Delete lifecycle config, if present - ignore any errors.
+	_ = persistAndNotifyBucketLifecycleChange(bucket, nil, objectAPI)
+
+	// Delete replication config, if present - ignore any errors.
+	_ = persistAndNotifyBucketReplicationChange(bucket, nil, objectAPI)
+
+	// Delete content sniffing config, if present - ignore any errors.
+	_ = persistAndNotifyBucketContentSniffingChange(bucket, nil, objectAPI)
+
+	// Delete website config, if present - ignore any errors.
+	_ = persistAndNotifyBucketWebsiteChange(bucket, nil, objectAPI)
+
+	// Delete request payment config, if present - ignore any errors.
+	_ = persistAndNotifyBucketRequestPaymentChange(bucket, nil, objectAPI)
+
+	// Delete object lock config, if present - ignore any errors.
+	_ = persistAndNotifyBucketObjectLockChange(bucket, nil, objectAPI)
+
+	// Delete trash config and trashed objects, if present - ignore any errors.
+	_ = persistAndNotifyBucketTrashChange(bucket, nil, objectAPI)
+	_ = purgeBucketTrash(bucket, objectAPI, false, time.Now().UTC())
+
+	// Delete object size limits config, if present - ignore any errors.
+	_ = persistAndNotifyBucketObjectSizeLimitsChange(bucket, nil, objectAPI)
+
+	// Delete public access block config, if present - ignore any errors.
+	_ = persistAndNotifyBucketPublicAccessBlockChange(bucket, nil, objectAPI)
+
+	// Delete accelerate config, if present - ignore any errors.
+	_ = persistAndNotifyBucketAccelerateChange(bucket, nil, objectAPI)
+
+	// Delete client certificate config, if present - ignore any errors.
+	_ = persistAndNotifyBucketClientCertChange(bucket, nil, objectAPI)
+
+	// Delete ownership controls config, if present - ignore any errors.
+	_ = persistAndNotifyBucketOwnershipControlsChange(bucket, nil, objectAPI)
+
+	// Delete content addressing config, if present - ignore any errors.
+	_ = persistAndNotifyBucketContentAddressingChange(bucket, nil, objectAPI)
+
+	// Delete inventory configs and their last runs, if present - ignore any errors.
+	_ = removeBucketInventory(bucket, objectAPI)
+
 	// Write success response.
 	writeSuccessNoContent(w)
 }
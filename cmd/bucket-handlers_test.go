@@ -22,8 +22,11 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Wrapper for calling GetBucketPolicy HTTP handler tests for both XL multiple disks and single node setup.
@@ -771,3 +774,371 @@ func testAPIDeleteMultipleObjectsHandler(obj ObjectLayer, instanceType, bucketNa
 	// `ExecObjectLayerAPINilTest` manages the operation.
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
+
+// Wrapper for calling ListObjectsV2 HTTP handler tests with x-minio-order
+// for both XL multiple disks and single node setup.
+func TestListObjectsV2OrderHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsV2OrderHandler, []string{"ListObjectsV2"})
+}
+
+func testListObjectsV2OrderHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Upload objects in non lexical order.
+	arrival := []string{"b", "d", "a", "e", "c"}
+	for _, objectName := range arrival {
+		if _, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+			t.Fatalf("Minio %s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+		// Make sure all objects have distinct modification times.
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	listObjects := func(values url.Values) (int, ListObjectsV2Response) {
+		values.Set("list-type", "2")
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request for ListObjectsV2: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		var response ListObjectsV2Response
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Minio %s: Failed to parse ListObjectsV2 response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec.Code, response
+	}
+	keys := func(response ListObjectsV2Response) (names []string) {
+		for _, object := range response.Contents {
+			names = append(names, object.Key)
+		}
+		return names
+	}
+
+	// Default order is lexical.
+	code, response := listObjects(url.Values{})
+	if code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	if got := strings.Join(keys(response), ","); got != "a,b,c,d,e" {
+		t.Fatalf("Minio %s: Expected lexical order a,b,c,d,e, got %s", instanceType, got)
+	}
+
+	// Newest first, paginated by two objects.
+	expectedPages := []string{"c,e", "a,d", "b"}
+	token := ""
+	for i, expectedPage := range expectedPages {
+		values := url.Values{}
+		values.Set(listOrderQueryParam, listOrderNewestFirst)
+		values.Set("max-keys", "2")
+		if token != "" {
+			values.Set("continuation-token", token)
+		}
+		code, response = listObjects(values)
+		if code != http.StatusOK {
+			t.Fatalf("Minio %s: Page %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, code)
+		}
+		if got := strings.Join(keys(response), ","); got != expectedPage {
+			t.Fatalf("Minio %s: Page %d: Expected %s, got %s", instanceType, i+1, expectedPage, got)
+		}
+		isLastPage := i == len(expectedPages)-1
+		if response.IsTruncated == isLastPage || (response.NextContinuationToken == "") != isLastPage {
+			t.Fatalf("Minio %s: Page %d: Unexpected truncation %v with token %q", instanceType, i+1,
+				response.IsTruncated, response.NextContinuationToken)
+		}
+		if response.ContinuationToken != token {
+			t.Fatalf("Minio %s: Page %d: Expected continuation token %q, got %q", instanceType, i+1, token, response.ContinuationToken)
+		}
+		token = response.NextContinuationToken
+	}
+
+	// Invalid requests.
+	testCases := []url.Values{
+		{listOrderQueryParam: {"oldest-first"}},
+		{listOrderQueryParam: {listOrderNewestFirst}, "delimiter": {"/"}},
+		{listOrderQueryParam: {listOrderNewestFirst}, "start-after": {"a"}},
+		{listOrderQueryParam: {listOrderNewestFirst}, "continuation-token": {"a"}},
+	}
+	for i, values := range testCases {
+		if code, _ = listObjects(values); code != http.StatusBadRequest {
+			t.Errorf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, code)
+		}
+	}
+}
+
+// Wrapper for calling ListObjectsV2 HTTP handler tests with fetch-checksum
+// for both XL multiple disks and single node setup.
+func TestListObjectsV2ChecksumHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsV2ChecksumHandler, []string{"ListObjectsV2"})
+}
+
+func testListObjectsV2ChecksumHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Objects written with a CRC32C or a SHA256 checksum and without.
+	checksums := map[string]map[string]string{
+		"crc32c":   {checksumMetadataKey(checksumCRC32C): "mnG7TA=="},
+		"sha256":   {checksumMetadataKey(checksumSHA256): "LPJNul+wow4m6DsqxbninhsWHlwfp0JecwQzYpOLmCQ="},
+		"none":     {},
+		"metadata": {"X-Amz-Meta-Color": "red"},
+	}
+	for objectName, metadata := range checksums {
+		if _, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), metadata, ""); err != nil {
+			t.Fatalf("Minio %s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+	}
+
+	for _, fetchChecksum := range []string{"", "true"} {
+		values := url.Values{}
+		values.Set("list-type", "2")
+		if fetchChecksum != "" {
+			values.Set("fetch-checksum", fetchChecksum)
+		}
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request for ListObjectsV2: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var response ListObjectsV2Response
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Minio %s: Failed to parse ListObjectsV2 response: <ERROR> %v", instanceType, err)
+		}
+		if len(response.Contents) != len(checksums) {
+			t.Fatalf("Minio %s: Expected %d objects, got %d", instanceType, len(checksums), len(response.Contents))
+		}
+		for _, object := range response.Contents {
+			expected := Object{}
+			if fetchChecksum != "" {
+				setListObjectChecksum(&expected, checksums[object.Key])
+			}
+			if object.ChecksumCRC32 != "" || object.ChecksumSHA1 != "" ||
+				object.ChecksumCRC32C != expected.ChecksumCRC32C || object.ChecksumSHA256 != expected.ChecksumSHA256 {
+				t.Errorf("Minio %s: fetch-checksum=%q: Unexpected checksums of %s: %+v", instanceType, fetchChecksum, object.Key, object)
+			}
+		}
+		// Only the stored checksums are listed.
+		if fetchChecksum != "" && bytes.Count(rec.Body.Bytes(), []byte("<Checksum")) != 2 {
+			t.Errorf("Minio %s: Expected 2 checksums listed, got %s", instanceType, rec.Body.String())
+		}
+		if fetchChecksum == "" && bytes.Contains(rec.Body.Bytes(), []byte("<Checksum")) {
+			t.Errorf("Minio %s: Expected no checksum listed, got %s", instanceType, rec.Body.String())
+		}
+	}
+}
+
+// Wrapper for calling ListObjects HTTP handler tests with x-minio-meta-filter
+// for both XL multiple disks and single node setup.
+func TestListObjectsMetadataFilterHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsMetadataFilterHandler, []string{"ListObjectsV2", "ListObjectsV1"})
+}
+
+func testListObjectsMetadataFilterHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	for objectName, color := range map[string]string{"a": "red", "b": "blue", "c": "", "d/1": "red", "d/2": "blue", "e": "red"} {
+		metadata := map[string]string{}
+		if color != "" {
+			metadata["X-Amz-Meta-Color"] = color
+		}
+		if _, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), metadata, ""); err != nil {
+			t.Fatalf("Minio %s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+	}
+
+	listObjects := func(values url.Values) (int, ListObjectsV2Response) {
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request for ListObjects: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		var response ListObjectsV2Response
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+				t.Fatalf("Minio %s: Failed to parse ListObjects response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec.Code, response
+	}
+	entries := func(response ListObjectsV2Response) (names []string) {
+		for _, object := range response.Contents {
+			names = append(names, object.Key)
+		}
+		for _, prefix := range response.CommonPrefixes {
+			names = append(names, prefix.Prefix)
+		}
+		return names
+	}
+
+	testCases := []struct {
+		values   url.Values
+		expected string
+	}{
+		// Without the filter all the objects are listed.
+		{url.Values{}, "a,b,c,d/1,d/2,e"},
+		{url.Values{"list-type": {"2"}}, "a,b,c,d/1,d/2,e"},
+		// Matching objects.
+		{url.Values{metadataFilterQueryParam: {"color=red"}}, "a,d/1,e"},
+		{url.Values{"list-type": {"2"}, metadataFilterQueryParam: {"X-Amz-Meta-Color=blue"}}, "b,d/2"},
+		{url.Values{"list-type": {"2"}, metadataFilterQueryParam: {"color=red"}, "prefix": {"d/"}}, "d/1"},
+		// Common prefixes are not filtered.
+		{url.Values{metadataFilterQueryParam: {"color=blue"}, "delimiter": {"/"}}, "b,d/"},
+		// Non matching objects.
+		{url.Values{metadataFilterQueryParam: {"color=Red"}}, ""},
+		{url.Values{"list-type": {"2"}, metadataFilterQueryParam: {"owner=alice"}}, ""},
+	}
+	for i, testCase := range testCases {
+		code, response := listObjects(testCase.values)
+		if code != http.StatusOK {
+			t.Fatalf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, code)
+		}
+		if got := strings.Join(entries(response), ","); got != testCase.expected {
+			t.Fatalf("Minio %s: Test %d: Expected %s, got %s", instanceType, i+1, testCase.expected, got)
+		}
+	}
+
+	// Filtered listings are paginated.
+	expectedPages := []string{"a,d/1", "e"}
+	token := ""
+	for i, expectedPage := range expectedPages {
+		values := url.Values{"list-type": {"2"}, "max-keys": {"2"}, metadataFilterQueryParam: {"color=red"}}
+		if token != "" {
+			values.Set("continuation-token", token)
+		}
+		code, response := listObjects(values)
+		if code != http.StatusOK {
+			t.Fatalf("Minio %s: Page %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, code)
+		}
+		if got := strings.Join(entries(response), ","); got != expectedPage {
+			t.Fatalf("Minio %s: Page %d: Expected %s, got %s", instanceType, i+1, expectedPage, got)
+		}
+		if response.IsTruncated != (i < len(expectedPages)-1) {
+			t.Fatalf("Minio %s: Page %d: Unexpected truncation %t", instanceType, i+1, response.IsTruncated)
+		}
+		token = response.NextContinuationToken
+	}
+
+	// Invalid requests.
+	invalidCases := []url.Values{
+		{metadataFilterQueryParam: {"color"}},
+		{metadataFilterQueryParam: {"=red"}},
+		{metadataFilterQueryParam: {"color=red", "size=small"}},
+		{"list-type": {"2"}, metadataFilterQueryParam: {"color=red"}, listOrderQueryParam: {listOrderNewestFirst}},
+	}
+	for i, values := range invalidCases {
+		if code, _ := listObjects(values); code != http.StatusBadRequest {
+			t.Errorf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, code)
+		}
+	}
+}
+
+// Wrapper for calling ListObjects HTTP handler tests with encoding-type=url
+// for both XL multiple disks and single node setup.
+func TestListObjectsEncodingTypeHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsEncodingTypeHandler, []string{"ListObjectsV2", "ListObjectsV1"})
+}
+
+func testListObjectsEncodingTypeHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	objectNames := []string{"dir one/a b", "dir one/line\nbreak", "dir one/sub dir/object", "dir one/\u00e9&<"}
+	for _, objectName := range objectNames {
+		if _, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+			t.Fatalf("Minio %s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+	}
+
+	listObjects := func(values url.Values, response interface{}) int {
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request for ListObjects: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code == http.StatusOK {
+			if err = xml.Unmarshal(rec.Body.Bytes(), response); err != nil {
+				t.Fatalf("Minio %s: Failed to parse ListObjects response: <ERROR> %v", instanceType, err)
+			}
+		}
+		return rec.Code
+	}
+	keys := func(contents []Object) (names []string) {
+		for _, object := range contents {
+			names = append(names, object.Key)
+		}
+		return names
+	}
+	prefixes := func(commonPrefixes []CommonPrefix) (names []string) {
+		for _, prefix := range commonPrefixes {
+			names = append(names, prefix.Prefix)
+		}
+		return names
+	}
+
+	// ListObjectsV1 encodes the keys, prefix, delimiter and marker.
+	var v1Response ListObjectsResponse
+	values := url.Values{}
+	values.Set("prefix", "dir one/")
+	values.Set("delimiter", "/")
+	values.Set("marker", "dir one/a b")
+	values.Set("encoding-type", "url")
+	if code := listObjects(values, &v1Response); code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	if v1Response.EncodingType != "url" || v1Response.Prefix != "dir%20one/" ||
+		v1Response.Marker != "dir%20one/a%20b" || v1Response.Delimiter != "/" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV1 response %#v", instanceType, v1Response)
+	}
+	if got := strings.Join(keys(v1Response.Contents), ","); got != "dir%20one/line%0Abreak,dir%20one/%C3%A9%26%3C" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV1 keys %s", instanceType, got)
+	}
+	if got := strings.Join(prefixes(v1Response.CommonPrefixes), ","); got != "dir%20one/sub%20dir/" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV1 prefixes %s", instanceType, got)
+	}
+
+	// ListObjectsV2 encodes the keys, prefix and delimiter.
+	var v2Response ListObjectsV2Response
+	values = url.Values{}
+	values.Set("list-type", "2")
+	values.Set("prefix", "dir one/")
+	values.Set("delimiter", "/")
+	values.Set("encoding-type", "url")
+	if code := listObjects(values, &v2Response); code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	if v2Response.EncodingType != "url" || v2Response.Prefix != "dir%20one/" || v2Response.Delimiter != "/" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV2 response %#v", instanceType, v2Response)
+	}
+	if got := strings.Join(keys(v2Response.Contents), ","); got != "dir%20one/a%20b,dir%20one/line%0Abreak,dir%20one/%C3%A9%26%3C" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV2 keys %s", instanceType, got)
+	}
+	if got := strings.Join(prefixes(v2Response.CommonPrefixes), ","); got != "dir%20one/sub%20dir/" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV2 prefixes %s", instanceType, got)
+	}
+
+	// Keys are not encoded unless requested.
+	v2Response = ListObjectsV2Response{}
+	values.Del("encoding-type")
+	if code := listObjects(values, &v2Response); code != http.StatusOK {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	if v2Response.EncodingType != "" || len(v2Response.Contents) == 0 || v2Response.Contents[0].Key != "dir one/a b" {
+		t.Fatalf("Minio %s: Unexpected ListObjectsV2 response %#v", instanceType, v2Response)
+	}
+
+	// Unsupported encoding types are rejected.
+	values.Set("encoding-type", "gzip")
+	if code := listObjects(values, &v2Response); code != http.StatusBadRequest {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusBadRequest, code)
+	}
+	values.Del("list-type")
+	if code := listObjects(values, &v1Response); code != http.StatusBadRequest {
+		t.Fatalf("Minio %s: Expected status %d, got %d", instanceType, http.StatusBadRequest, code)
+	}
+}
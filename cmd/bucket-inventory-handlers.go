@@ -0,0 +1,227 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Maximum size of a bucket inventory config.
+const maxBucketInventoryConfigSize = 20 * 1024
+
+// GetBucketInventoryHandler - This implementation of the GET operation
+// uses the inventory subresource to return the inventory configuration
+// of a bucket with the ID given, or to list all the inventory
+// configurations of the bucket when no ID is given.
+func (api objectAPIHandlers) GetBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var response interface{}
+	id := r.URL.Query().Get("id")
+	if id != "" {
+		config, err := readBucketInventoryConfig(bucket, id, objAPI)
+		if err != nil {
+			errorIf(err, "Unable to read inventory configuration.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		config.XMLNS = "http://s3.amazonaws.com/doc/2006-03-01/"
+		response = config
+	} else {
+		configs, err := readBucketInventoryConfigs(bucket, objAPI)
+		if err != nil && err != errNoSuchInventoryConfig {
+			errorIf(err, "Unable to read inventory configurations.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+
+		// Configs are listed by ID after the continuation token.
+		token := r.URL.Query().Get("continuation-token")
+		result := ListInventoryConfigurationsResult{
+			XMLNS:             "http://s3.amazonaws.com/doc/2006-03-01/",
+			ContinuationToken: token,
+		}
+		for _, config := range configs {
+			if config.ID <= token {
+				continue
+			}
+			if len(result.Configs) == maxInventoryConfigsList {
+				result.IsTruncated = true
+				result.NextContinuationToken = result.Configs[len(result.Configs)-1].ID
+				break
+			}
+			result.Configs = append(result.Configs, config)
+		}
+		response = result
+	}
+
+	responseBytes, err := xml.Marshal(response)
+	if err != nil {
+		errorIf(err, "Unable to marshal inventory configuration into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseXML(w, responseBytes)
+}
+
+// PutBucketInventoryHandler - Adds the inventory configuration with the
+// ID given to a bucket, replacing any existing configuration with the
+// same ID.
+func (api objectAPIHandlers) PutBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErrorResponse(w, toAPIErrorCode(errInvalidInventoryID), r.URL)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+	if r.ContentLength > maxBucketInventoryConfigSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	configBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIf(err, "Unable to read incoming body.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var config InventoryConfiguration
+	if err = xml.Unmarshal(configBytes, &config); err != nil {
+		errorIf(err, "Unable to parse inventory configuration XML.")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	config.XMLNS = ""
+
+	if config.ID != id {
+		writeErrorResponse(w, toAPIErrorCode(errInvalidInventoryID), r.URL)
+		return
+	}
+	if err = validateInventoryConfig(config); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Acquire a write lock on bucket before modifying its configuration.
+	bucketLock := globalNSMutex.NewNSLock(bucket, "")
+	bucketLock.Lock()
+	defer bucketLock.Unlock()
+
+	if err = writeBucketInventoryConfig(bucket, id, &config, objAPI); err != nil {
+		errorIf(err, "Unable to save inventory configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseHeadersOnly(w)
+}
+
+// DeleteBucketInventoryHandler - Removes the inventory configuration
+// with the ID given from a bucket.
+func (api objectAPIHandlers) DeleteBucketInventoryHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErrorResponse(w, toAPIErrorCode(errInvalidInventoryID), r.URL)
+		return
+	}
+
+	// Acquire a write lock on bucket before modifying its configuration.
+	bucketLock := globalNSMutex.NewNSLock(bucket, "")
+	bucketLock.Lock()
+	defer bucketLock.Unlock()
+
+	if err := writeBucketInventoryConfig(bucket, id, nil, objAPI); err != nil {
+		errorIf(err, "Unable to remove inventory configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessNoContent(w)
+}
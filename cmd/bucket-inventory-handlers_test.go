@@ -0,0 +1,181 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests PUT, GET, list and DELETE bucket inventory configs.
+func TestBucketInventoryHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketInventoryHandlers, []string{
+		"GetBucketInventory",
+		"PutBucketInventory",
+		"DeleteBucketInventory",
+		"DeleteBucket",
+	})
+}
+
+func testBucketInventoryHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	serveRequest := func(method, urlStr, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader([]byte(body)),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	configXML := func(id, format, frequency, fields string) string {
+		return fmt.Sprintf(`<InventoryConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">
+<Id>%s</Id><IsEnabled>true</IsEnabled><Filter><Prefix>logs/</Prefix></Filter>
+<Destination><S3BucketDestination><Format>%s</Format><Bucket>arn:aws:s3:::reports</Bucket><Prefix>inventory</Prefix></S3BucketDestination></Destination>
+<Schedule><Frequency>%s</Frequency></Schedule><IncludedObjectVersions>Current</IncludedObjectVersions>
+<OptionalFields>%s</OptionalFields></InventoryConfiguration>`, id, format, frequency, fields)
+	}
+
+	// Inventory is not configured by default.
+	rec := serveRequest("GET", getBucketInventoryURL("", bucketName, "report1"), "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	testCases := []struct {
+		id             string
+		body           string
+		expectedStatus int
+	}{
+		{"report1", configXML("report1", "CSV", "Daily", "<Field>Size</Field><Field>ETag</Field>"), http.StatusOK},
+		{"report2", configXML("report2", "CSV", "Weekly", "<Field>LastModifiedDate</Field>"), http.StatusOK},
+		// Missing ID.
+		{"", configXML("report3", "CSV", "Daily", ""), http.StatusBadRequest},
+		// ID of the request and of the config differ.
+		{"report3", configXML("report4", "CSV", "Daily", ""), http.StatusBadRequest},
+		// Unsupported format.
+		{"report3", configXML("report3", "Parquet", "Daily", ""), http.StatusNotImplemented},
+		// Invalid frequency.
+		{"report3", configXML("report3", "CSV", "Hourly", ""), http.StatusBadRequest},
+		// Unknown and duplicate fields.
+		{"report3", configXML("report3", "CSV", "Daily", "<Field>Owner</Field>"), http.StatusBadRequest},
+		{"report3", configXML("report3", "CSV", "Daily", "<Field>Size</Field><Field>Size</Field>"), http.StatusBadRequest},
+		// Invalid ID.
+		{"a/b", configXML("a/b", "CSV", "Daily", ""), http.StatusBadRequest},
+		// Malformed XML.
+		{"report3", "<InventoryConfiguration>", http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		rec = serveRequest("PUT", getBucketInventoryURL("", bucketName, testCase.id), testCase.body)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d: %s", instanceType, i+1, testCase.expectedStatus, rec.Code, rec.Body.String())
+		}
+	}
+
+	// Config round-trip.
+	rec = serveRequest("GET", getBucketInventoryURL("", bucketName, "report1"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var config InventoryConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if config.ID != "report1" || !config.IsEnabled || config.prefix() != "logs/" ||
+		config.destinationBucket() != "reports" || config.Destination.S3BucketDestination.Prefix != "inventory" ||
+		config.Schedule.Frequency != "Daily" || !reflect.DeepEqual(config.fields(), []string{"Size", "ETag"}) {
+		t.Fatalf("%s: Unexpected inventory configuration %+v", instanceType, config)
+	}
+
+	// Replacing a config keeps the others.
+	rec = serveRequest("PUT", getBucketInventoryURL("", bucketName, "report1"), configXML("report1", "CSV", "Weekly", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketInventoryURL("", bucketName, ""), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var result ListInventoryConfigurationsResult
+	if err := xml.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Configs) != 2 || result.IsTruncated {
+		t.Fatalf("%s: Expected 2 inventory configurations, got %+v", instanceType, result)
+	}
+	if result.Configs[0].ID != "report1" || result.Configs[0].Schedule.Frequency != "Weekly" || result.Configs[1].ID != "report2" {
+		t.Fatalf("%s: Unexpected inventory configurations %+v", instanceType, result.Configs)
+	}
+
+	// Removed configs are no longer found.
+	rec = serveRequest("DELETE", getBucketInventoryURL("", bucketName, "report1"), "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	rec = serveRequest("DELETE", getBucketInventoryURL("", bucketName, "report1"), "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketInventoryURL("", bucketName, "report1"), "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketInventoryURL("", bucketName, "report2"), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+
+	// Configs and last runs do not survive the bucket.
+	recreatedBucket := "inventory-recreated"
+	if err := obj.MakeBucket(recreatedBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = serveRequest("PUT", getBucketInventoryURL("", recreatedBucket, "report1"), configXML("report1", "CSV", "Daily", ""))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	lastRun := time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC)
+	state := bucketInventoryState{Version: bucketInventoryStateFormat, LastRuns: map[string]time.Time{"report1": lastRun}}
+	if err := writeBucketInventoryState(recreatedBucket, state, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = serveRequest("DELETE", getDeleteBucketURL("", recreatedBucket), "")
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if err := obj.MakeBucket(recreatedBucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	rec = serveRequest("GET", getBucketInventoryURL("", recreatedBucket, "report1"), "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	state, err := readBucketInventoryState(recreatedBucket, obj)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(state.LastRuns) != 0 {
+		t.Fatalf("%s: Expected no last runs, got %v", instanceType, state.LastRuns)
+	}
+}
@@ -0,0 +1,744 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket inventory configs name, all the configs of a bucket are
+	// saved together.
+	bucketInventoryConfig = "inventory.xml"
+
+	// Last runs of the inventory configs of a bucket.
+	bucketInventoryStateFile   = "inventory.json"
+	bucketInventoryStateFormat = "1"
+
+	// Maximum number of inventory configs of a bucket.
+	maxInventoryConfigs = 1000
+
+	// Maximum number of inventory configs listed at once.
+	maxInventoryConfigsList = 100
+
+	// Inventory report formats, frequencies and object versions.
+	inventoryFormatCSV     = "CSV"
+	inventoryFormatORC     = "ORC"
+	inventoryFormatParquet = "Parquet"
+	inventoryDaily         = "Daily"
+	inventoryWeekly        = "Weekly"
+	inventoryVersionsAll   = "All"
+	inventoryVersionsCur   = "Current"
+
+	// Destination buckets are given by their ARN.
+	inventoryBucketARNPrefix = "arn:aws:s3:::"
+
+	// Version of the manifest of the inventory reports.
+	inventoryManifestVersion = "2016-11-30"
+
+	// Reports are saved under '<prefix>/<bucket>/<id>', the manifests
+	// under the time of the run and the data files under 'data'.
+	inventoryReportTimeFormat = "2006-01-02T15-04Z"
+	inventoryDataDir          = "data"
+	inventoryManifestFile     = "manifest.json"
+	inventoryChecksumFile     = "manifest.checksum"
+)
+
+// Inventory optional fields.
+const (
+	inventoryFieldSize                = "Size"
+	inventoryFieldLastModifiedDate    = "LastModifiedDate"
+	inventoryFieldStorageClass        = "StorageClass"
+	inventoryFieldETag                = "ETag"
+	inventoryFieldIsMultipartUploaded = "IsMultipartUploaded"
+	inventoryFieldEncryptionStatus    = "EncryptionStatus"
+)
+
+var (
+	// Interval between the checks of the inventory configs due to run.
+	inventoryRunInterval = time.Hour
+
+	// Maximum number of objects in a data file of a report.
+	inventoryFileObjects = 100000
+
+	// Inventory reports of all the buckets are written by a single
+	// scheduler.
+	globalInventorySchedulerOnce sync.Once
+)
+
+// Inventory config IDs end up in the paths of the reports.
+var validInventoryID = regexp.MustCompile(`^[a-zA-Z0-9._-]{1,64}$`)
+
+// errInvalidInventoryConfig - inventory config is not valid.
+var errInvalidInventoryConfig = errors.New("Invalid inventory configuration")
+
+// errNoSuchInventoryConfig - inventory config is not set on the bucket.
+var errNoSuchInventoryConfig = errors.New("The inventory configuration does not exist")
+
+// errInvalidInventoryID - inventory config ID is missing in the request
+// or is not the ID of the config.
+var errInvalidInventoryID = errors.New("Invalid inventory configuration ID")
+
+// errTooManyInventoryConfigs - bucket has the maximum number of
+// inventory configs.
+var errTooManyInventoryConfigs = errors.New("Too many inventory configurations")
+
+// errInventoryFormatUnsupported - inventory report format is not
+// supported.
+var errInventoryFormatUnsupported = errors.New("Inventory report format is not supported")
+
+// InventoryFilter - selects the objects listed in the reports.
+type InventoryFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// InventoryS3BucketDestination - bucket the reports are written to.
+type InventoryS3BucketDestination struct {
+	Format    string `xml:"Format"`
+	AccountID string `xml:"AccountId,omitempty"`
+	Bucket    string `xml:"Bucket"`
+	Prefix    string `xml:"Prefix,omitempty"`
+}
+
+// InventoryDestination - destination of the reports.
+type InventoryDestination struct {
+	S3BucketDestination InventoryS3BucketDestination `xml:"S3BucketDestination"`
+}
+
+// InventorySchedule - how often the reports are written.
+type InventorySchedule struct {
+	Frequency string `xml:"Frequency"`
+}
+
+// InventoryOptionalFields - fields of the objects listed in the reports
+// along with their bucket and key.
+type InventoryOptionalFields struct {
+	Fields []string `xml:"Field"`
+}
+
+// InventoryConfiguration - inventory config of a bucket.
+type InventoryConfiguration struct {
+	XMLName                xml.Name                 `xml:"InventoryConfiguration"`
+	XMLNS                  string                   `xml:"xmlns,attr,omitempty"`
+	ID                     string                   `xml:"Id"`
+	IsEnabled              bool                     `xml:"IsEnabled"`
+	Filter                 *InventoryFilter         `xml:"Filter,omitempty"`
+	Destination            InventoryDestination     `xml:"Destination"`
+	Schedule               InventorySchedule        `xml:"Schedule"`
+	IncludedObjectVersions string                   `xml:"IncludedObjectVersions"`
+	OptionalFields         *InventoryOptionalFields `xml:"OptionalFields,omitempty"`
+}
+
+// bucketInventoryConfigs - all the inventory configs of a bucket, as
+// saved in the meta bucket.
+type bucketInventoryConfigs struct {
+	XMLName xml.Name                 `xml:"InventoryConfigurations"`
+	Configs []InventoryConfiguration `xml:"InventoryConfiguration"`
+}
+
+// ListInventoryConfigurationsResult - response of the list of the
+// inventory configs of a bucket.
+type ListInventoryConfigurationsResult struct {
+	XMLName               xml.Name                 `xml:"ListInventoryConfigurationsResult"`
+	XMLNS                 string                   `xml:"xmlns,attr,omitempty"`
+	Configs               []InventoryConfiguration `xml:"InventoryConfiguration"`
+	IsTruncated           bool                     `xml:"IsTruncated"`
+	ContinuationToken     string                   `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string                   `xml:"NextContinuationToken,omitempty"`
+}
+
+// prefix - returns the object name prefix of the objects listed.
+func (config InventoryConfiguration) prefix() string {
+	if config.Filter != nil {
+		return config.Filter.Prefix
+	}
+	return ""
+}
+
+// fields - returns the optional fields of the objects listed.
+func (config InventoryConfiguration) fields() []string {
+	if config.OptionalFields != nil {
+		return config.OptionalFields.Fields
+	}
+	return nil
+}
+
+// destinationBucket - returns the name of the bucket the reports are
+// written to.
+func (config InventoryConfiguration) destinationBucket() string {
+	return strings.TrimPrefix(config.Destination.S3BucketDestination.Bucket, inventoryBucketARNPrefix)
+}
+
+// reportPrefix - returns the prefix of the reports of the config of the
+// bucket in the destination bucket.
+func (config InventoryConfiguration) reportPrefix(bucket string) string {
+	return path.Join(config.Destination.S3BucketDestination.Prefix, bucket, config.ID)
+}
+
+// validateInventoryConfig - validates the inventory config.
+func validateInventoryConfig(config InventoryConfiguration) error {
+	if !validInventoryID.MatchString(config.ID) {
+		return errInvalidInventoryConfig
+	}
+	dest := config.Destination.S3BucketDestination
+	switch dest.Format {
+	case inventoryFormatCSV:
+	case inventoryFormatORC, inventoryFormatParquet:
+		return errInventoryFormatUnsupported
+	default:
+		return errInvalidInventoryConfig
+	}
+	if !strings.HasPrefix(dest.Bucket, inventoryBucketARNPrefix) || !IsValidBucketName(config.destinationBucket()) {
+		return errInvalidInventoryConfig
+	}
+	if dest.Prefix != "" && !IsValidObjectPrefix(dest.Prefix) {
+		return errInvalidInventoryConfig
+	}
+	if config.Schedule.Frequency != inventoryDaily && config.Schedule.Frequency != inventoryWeekly {
+		return errInvalidInventoryConfig
+	}
+	if config.IncludedObjectVersions != inventoryVersionsAll && config.IncludedObjectVersions != inventoryVersionsCur {
+		return errInvalidInventoryConfig
+	}
+	fields := make(map[string]struct{})
+	for _, field := range config.fields() {
+		switch field {
+		case inventoryFieldSize, inventoryFieldLastModifiedDate, inventoryFieldStorageClass,
+			inventoryFieldETag, inventoryFieldIsMultipartUploaded, inventoryFieldEncryptionStatus:
+		default:
+			return errInvalidInventoryConfig
+		}
+		if _, ok := fields[field]; ok {
+			return errInvalidInventoryConfig
+		}
+		fields[field] = struct{}{}
+	}
+	return nil
+}
+
+// readBucketInventoryConfigs - reads the inventory configs of the
+// bucket, sorted by their ID.
+func readBucketInventoryConfigs(bucket string, objAPI ObjectLayer) ([]InventoryConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketInventoryConfig)
+
+	// Acquire a read lock on inventory configs before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return nil, errNoSuchInventoryConfig
+		}
+		errorIf(err, "Unable to load inventory configs for the bucket %s.", bucket)
+		return nil, errorCause(err)
+	}
+
+	var configs bucketInventoryConfigs
+	if err = xml.Unmarshal(buffer.Bytes(), &configs); err != nil {
+		return nil, err
+	}
+	return configs.Configs, nil
+}
+
+// readBucketInventoryConfig - reads the inventory config of the bucket
+// with the ID.
+func readBucketInventoryConfig(bucket, id string, objAPI ObjectLayer) (InventoryConfiguration, error) {
+	configs, err := readBucketInventoryConfigs(bucket, objAPI)
+	if err != nil {
+		return InventoryConfiguration{}, err
+	}
+	for _, config := range configs {
+		if config.ID == id {
+			return config, nil
+		}
+	}
+	return InventoryConfiguration{}, errNoSuchInventoryConfig
+}
+
+// writeBucketInventoryConfig - adds or replaces the inventory config of
+// the bucket with the ID, nil config removes it.
+func writeBucketInventoryConfig(bucket, id string, config *InventoryConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketInventoryConfig)
+
+	// Acquire a write lock on inventory configs before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	var configs bucketInventoryConfigs
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err == nil {
+		if err = xml.Unmarshal(buffer.Bytes(), &configs); err != nil {
+			return err
+		}
+	} else if !isErrObjectNotFound(err) && !isErrIncompleteBody(err) {
+		errorIf(err, "Unable to load inventory configs for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+
+	found := false
+	newConfigs := configs.Configs[:0]
+	for _, c := range configs.Configs {
+		if c.ID != id {
+			newConfigs = append(newConfigs, c)
+			continue
+		}
+		found = true
+		if config != nil {
+			newConfigs = append(newConfigs, *config)
+		}
+	}
+	if !found {
+		if config == nil {
+			return errNoSuchInventoryConfig
+		}
+		if len(newConfigs) >= maxInventoryConfigs {
+			return errTooManyInventoryConfigs
+		}
+		newConfigs = append(newConfigs, *config)
+	}
+	sort.Sort(inventoryConfigsByID(newConfigs))
+	configs.Configs = newConfigs
+
+	if len(configs.Configs) == 0 {
+		err = objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove inventory configs of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(configs)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set inventory configs for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeBucketInventory - removes the inventory configs and the last
+// runs of the bucket. The state lock is held on all the nodes, so that
+// no run in progress writes them back.
+func removeBucketInventory(bucket string, objAPI ObjectLayer) error {
+	statePath := path.Join(bucketConfigPrefix, bucket, bucketInventoryStateFile)
+	stateLock := globalNSMutex.NewNSLock(minioMetaBucket, statePath)
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketInventoryConfig)
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	for _, objPath := range []string{configPath, statePath} {
+		err := objAPI.DeleteObject(minioMetaBucket, objPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove inventory of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+	}
+	return nil
+}
+
+// inventoryConfigsByID - sorts the inventory configs by their ID.
+type inventoryConfigsByID []InventoryConfiguration
+
+func (c inventoryConfigsByID) Len() int           { return len(c) }
+func (c inventoryConfigsByID) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c inventoryConfigsByID) Less(i, j int) bool { return c[i].ID < c[j].ID }
+
+// Intialize the bucket inventory, the configs are read by the scheduler
+// on each run, so that no state is kept in memory.
+func initBucketInventory(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	// Inventory reports are written in background.
+	globalInventorySchedulerOnce.Do(func() {
+		startInventoryScheduler(newObjectLayerFn)
+	})
+
+	// Success.
+	return nil
+}
+
+// bucketInventoryState - last runs of the inventory configs of a bucket,
+// indexed by their ID.
+type bucketInventoryState struct {
+	Version  string               `json:"version"`
+	LastRuns map[string]time.Time `json:"lastRuns"`
+}
+
+// readBucketInventoryState - reads the last runs of the inventory
+// configs of the bucket. Must be called with the state lock held.
+func readBucketInventoryState(bucket string, objAPI ObjectLayer) (bucketInventoryState, error) {
+	state := bucketInventoryState{Version: bucketInventoryStateFormat, LastRuns: make(map[string]time.Time)}
+
+	var buffer bytes.Buffer
+	statePath := path.Join(bucketConfigPrefix, bucket, bucketInventoryStateFile)
+	err := objAPI.GetObject(minioMetaBucket, statePath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return state, nil
+		}
+		return state, errorCause(err)
+	}
+	if err = json.Unmarshal(buffer.Bytes(), &state); err != nil {
+		return state, err
+	}
+	if state.LastRuns == nil {
+		state.LastRuns = make(map[string]time.Time)
+	}
+	return state, nil
+}
+
+// writeBucketInventoryState - saves the last runs of the inventory
+// configs of the bucket. Must be called with the state lock held.
+func writeBucketInventoryState(bucket string, state bucketInventoryState, objAPI ObjectLayer) error {
+	buf, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	statePath := path.Join(bucketConfigPrefix, bucket, bucketInventoryStateFile)
+	if _, err = objAPI.PutObject(minioMetaBucket, statePath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// getInventoryPeriod - returns the period of the schedule the time is
+// in, a report is written once per day or per ISO week.
+func getInventoryPeriod(frequency string, t time.Time) string {
+	t = t.UTC()
+	if frequency == inventoryWeekly {
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return t.Format("2006-01-02")
+}
+
+// inventoryManifestDataFile - data file of an inventory report.
+type inventoryManifestDataFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	MD5Checksum string `json:"MD5checksum"`
+}
+
+// inventoryManifest - manifest of an inventory report, listing its data
+// files.
+type inventoryManifest struct {
+	SourceBucket      string                      `json:"sourceBucket"`
+	DestinationBucket string                      `json:"destinationBucket"`
+	Version           string                      `json:"version"`
+	CreationTimestamp string                      `json:"creationTimestamp"`
+	FileFormat        string                      `json:"fileFormat"`
+	FileSchema        string                      `json:"fileSchema"`
+	Files             []inventoryManifestDataFile `json:"files"`
+}
+
+// getInventoryFieldValue - returns the value of the optional field of
+// the object in the reports.
+func getInventoryFieldValue(field string, objInfo ObjectInfo) string {
+	switch field {
+	case inventoryFieldSize:
+		return strconv.FormatInt(objInfo.Size, 10)
+	case inventoryFieldLastModifiedDate:
+		return objInfo.ModTime.UTC().Format(timeFormatAMZLong)
+	case inventoryFieldStorageClass:
+		return globalMinioDefaultStorageClass
+	case inventoryFieldETag:
+		return objInfo.MD5Sum
+	case inventoryFieldIsMultipartUploaded:
+		return strconv.FormatBool(strings.Contains(objInfo.MD5Sum, "-"))
+	case inventoryFieldEncryptionStatus:
+		if isEncryptedObject(objInfo.UserDefined) {
+			return "SSE-C"
+		}
+		return "NOT-SSE"
+	}
+	return ""
+}
+
+// inventoryReportWriter - writes the rows of an inventory report into
+// gzipped CSV data files of at most inventoryFileObjects rows.
+type inventoryReportWriter struct {
+	objAPI     ObjectLayer
+	bucket     string
+	dataPrefix string
+
+	rows       int
+	buffer     bytes.Buffer
+	gzipWriter *gzip.Writer
+	csvWriter  *csv.Writer
+	files      []inventoryManifestDataFile
+}
+
+func newInventoryReportWriter(objAPI ObjectLayer, bucket, dataPrefix string) *inventoryReportWriter {
+	w := &inventoryReportWriter{objAPI: objAPI, bucket: bucket, dataPrefix: dataPrefix}
+	w.gzipWriter = gzip.NewWriter(&w.buffer)
+	w.csvWriter = csv.NewWriter(w.gzipWriter)
+	return w
+}
+
+// Write - writes a row, the data file is saved once full.
+func (w *inventoryReportWriter) Write(record []string) error {
+	if err := w.csvWriter.Write(record); err != nil {
+		return err
+	}
+	w.rows++
+	if w.rows == inventoryFileObjects {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush - saves the rows written so far as a data file.
+func (w *inventoryReportWriter) Flush() error {
+	if w.rows == 0 {
+		return nil
+	}
+	w.csvWriter.Flush()
+	if err := w.csvWriter.Error(); err != nil {
+		return err
+	}
+	if err := w.gzipWriter.Close(); err != nil {
+		return err
+	}
+
+	md5Sum := md5.Sum(w.buffer.Bytes())
+	dataFile := inventoryManifestDataFile{
+		Key:         path.Join(w.dataPrefix, mustGetUUID()+".csv.gz"),
+		Size:        int64(w.buffer.Len()),
+		MD5Checksum: hex.EncodeToString(md5Sum[:]),
+	}
+	metadata := map[string]string{
+		"md5Sum":       dataFile.MD5Checksum,
+		"content-type": "application/x-gzip",
+	}
+	if err := putInventoryObject(w.objAPI, w.bucket, dataFile.Key, w.buffer.Bytes(), metadata); err != nil {
+		return err
+	}
+	w.files = append(w.files, dataFile)
+
+	w.rows = 0
+	w.buffer.Reset()
+	w.gzipWriter.Reset(&w.buffer)
+	return nil
+}
+
+// runBucketInventory - writes an inventory report of the objects of the
+// bucket selected by the config, the manifest is only written once all
+// the data files are.
+func runBucketInventory(objAPI ObjectLayer, bucket string, config InventoryConfiguration, now time.Time) (inventoryManifest, error) {
+	now = now.UTC()
+	destBucket := config.destinationBucket()
+	reportPrefix := config.reportPrefix(bucket)
+	fields := config.fields()
+
+	// The metadata of the objects is not listed.
+	needMetadata := false
+	for _, field := range fields {
+		if field == inventoryFieldEncryptionStatus {
+			needMetadata = true
+		}
+	}
+
+	writer := newInventoryReportWriter(objAPI, destBucket, path.Join(reportPrefix, inventoryDataDir))
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, config.prefix(), marker, "", maxObjectList)
+		if err != nil {
+			return inventoryManifest{}, err
+		}
+		for _, objInfo := range result.Objects {
+			if needMetadata {
+				objInfo, err = objAPI.GetObjectInfo(bucket, objInfo.Name)
+				if err != nil {
+					// Objects deleted since listed are not reported.
+					if isErrObjectNotFound(err) {
+						continue
+					}
+					return inventoryManifest{}, err
+				}
+			}
+			record := []string{bucket, objInfo.Name}
+			for _, field := range fields {
+				record = append(record, getInventoryFieldValue(field, objInfo))
+			}
+			if err = writer.Write(record); err != nil {
+				return inventoryManifest{}, err
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	if err := writer.Flush(); err != nil {
+		return inventoryManifest{}, err
+	}
+
+	manifest := inventoryManifest{
+		SourceBucket:      bucket,
+		DestinationBucket: config.Destination.S3BucketDestination.Bucket,
+		Version:           inventoryManifestVersion,
+		CreationTimestamp: strconv.FormatInt(now.UnixNano()/int64(time.Millisecond), 10),
+		FileFormat:        config.Destination.S3BucketDestination.Format,
+		FileSchema:        strings.Join(append([]string{"Bucket", "Key"}, fields...), ", "),
+		Files:             writer.files,
+	}
+	if manifest.Files == nil {
+		manifest.Files = []inventoryManifestDataFile{}
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return inventoryManifest{}, err
+	}
+	manifestDir := path.Join(reportPrefix, now.Format(inventoryReportTimeFormat))
+	err = putInventoryObject(objAPI, destBucket, path.Join(manifestDir, inventoryManifestFile), manifestBytes,
+		map[string]string{"content-type": "application/json"})
+	if err != nil {
+		return inventoryManifest{}, err
+	}
+	md5Sum := md5.Sum(manifestBytes)
+	checksum := []byte(hex.EncodeToString(md5Sum[:]))
+	if err = putInventoryObject(objAPI, destBucket, path.Join(manifestDir, inventoryChecksumFile), checksum, nil); err != nil {
+		return inventoryManifest{}, err
+	}
+	return manifest, nil
+}
+
+// putInventoryObject - writes a file of a report to the destination
+// bucket under the object lock, the object layer relies on its callers
+// to serialize the writes of an object.
+func putInventoryObject(objAPI ObjectLayer, bucket, object string, data []byte, metadata map[string]string) error {
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	_, err := objAPI.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), metadata, "")
+	return err
+}
+
+// runBucketInventories - writes the reports of the enabled inventory
+// configs of the bucket which were not run in the current period of
+// their schedule.
+func runBucketInventories(objAPI ObjectLayer, bucket string, now time.Time) error {
+	// Acquire a write lock on the inventory state, a report is only
+	// written by one of the nodes. Configs are read with the lock held
+	// so that the state of a deleted bucket is not written back.
+	statePath := path.Join(bucketConfigPrefix, bucket, bucketInventoryStateFile)
+	stateLock := globalNSMutex.NewNSLock(minioMetaBucket, statePath)
+	stateLock.Lock()
+	defer stateLock.Unlock()
+
+	configs, err := readBucketInventoryConfigs(bucket, objAPI)
+	if err != nil {
+		if err == errNoSuchInventoryConfig {
+			return nil
+		}
+		return err
+	}
+
+	state, err := readBucketInventoryState(bucket, objAPI)
+	if err != nil {
+		return err
+	}
+	lastRuns := make(map[string]time.Time)
+	for _, config := range configs {
+		lastRun, ok := state.LastRuns[config.ID]
+		if ok {
+			lastRuns[config.ID] = lastRun
+		}
+		if !config.IsEnabled {
+			continue
+		}
+		if ok && getInventoryPeriod(config.Schedule.Frequency, lastRun) == getInventoryPeriod(config.Schedule.Frequency, now) {
+			continue
+		}
+		if _, err = runBucketInventory(objAPI, bucket, config, now); err != nil {
+			errorIf(err, "Unable to write the inventory report %s of the bucket %s.", config.ID, bucket)
+			continue
+		}
+		lastRuns[config.ID] = now.UTC()
+	}
+
+	// Last runs of the removed configs are dropped.
+	if reflect.DeepEqual(lastRuns, state.LastRuns) {
+		return nil
+	}
+	state.LastRuns = lastRuns
+	return writeBucketInventoryState(bucket, state, objAPI)
+}
+
+// runInventories - writes the reports of the inventory configs of all
+// the buckets due to run.
+func runInventories(objAPI ObjectLayer, now time.Time) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return
+	}
+	for _, bucket := range buckets {
+		err = runBucketInventories(objAPI, bucket.Name, now)
+		errorIf(err, "Unable to write the inventory reports of the bucket %s.", bucket.Name)
+	}
+}
+
+// startInventoryScheduler - writes the inventory reports due to run
+// periodically until the server stops.
+func startInventoryScheduler(objAPI func() ObjectLayer) {
+	go func() {
+		ticker := time.NewTicker(inventoryRunInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if objLayer := objAPI(); objLayer != nil {
+					runInventories(objLayer, time.Now().UTC())
+				}
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,180 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests an inventory run writes a manifest and data files listing the
+// objects selected, once per period of the schedule.
+func TestRunBucketInventories(t *testing.T) {
+	ExecObjectLayerTest(t, testRunBucketInventories)
+}
+
+func testRunBucketInventories(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func(objects int) { inventoryFileObjects = objects }(inventoryFileObjects)
+	inventoryFileObjects = 2
+
+	bucket, destBucket := "source", "reports"
+	for _, b := range []string{bucket, destBucket} {
+		if err := obj.MakeBucket(b); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+	objects := make(map[string]ObjectInfo)
+	for _, object := range []string{"logs/a", "logs/b", "logs/c/d", "data/e"} {
+		objInfo, err := obj.PutObject(bucket, object, int64(len(object)), bytes.NewReader([]byte(object)), nil, "")
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		objects[object] = objInfo
+	}
+
+	config := InventoryConfiguration{
+		ID:        "report1",
+		IsEnabled: true,
+		Filter:    &InventoryFilter{Prefix: "logs/"},
+		Destination: InventoryDestination{S3BucketDestination: InventoryS3BucketDestination{
+			Format: inventoryFormatCSV,
+			Bucket: inventoryBucketARNPrefix + destBucket,
+			Prefix: "inventory",
+		}},
+		Schedule:               InventorySchedule{Frequency: inventoryDaily},
+		IncludedObjectVersions: inventoryVersionsCur,
+		OptionalFields:         &InventoryOptionalFields{Fields: []string{inventoryFieldSize, inventoryFieldETag, inventoryFieldEncryptionStatus}},
+	}
+	if err := validateInventoryConfig(config); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := writeBucketInventoryConfig(bucket, config.ID, &config, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	now := time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC)
+	if err := runBucketInventories(obj, bucket, now); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	var buffer bytes.Buffer
+	manifestPath := "inventory/source/report1/2017-06-01T10-30Z/manifest.json"
+	if err := obj.GetObject(destBucket, manifestPath, 0, -1, &buffer); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	var manifest inventoryManifest
+	if err := json.Unmarshal(buffer.Bytes(), &manifest); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if manifest.SourceBucket != bucket || manifest.DestinationBucket != "arn:aws:s3:::reports" ||
+		manifest.FileSchema != "Bucket, Key, Size, ETag, EncryptionStatus" {
+		t.Fatalf("%s: Unexpected manifest %+v", instanceType, manifest)
+	}
+	if _, err := obj.GetObjectInfo(destBucket, path.Join(path.Dir(manifestPath), inventoryChecksumFile)); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	// Data files hold at most two rows each.
+	if len(manifest.Files) != 2 {
+		t.Fatalf("%s: Expected 2 data files, got %d", instanceType, len(manifest.Files))
+	}
+	var records [][]string
+	for _, file := range manifest.Files {
+		if !strings.HasPrefix(file.Key, "inventory/source/report1/data/") {
+			t.Fatalf("%s: Unexpected data file %s", instanceType, file.Key)
+		}
+		buffer.Reset()
+		if err := obj.GetObject(destBucket, file.Key, 0, -1, &buffer); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if int64(buffer.Len()) != file.Size {
+			t.Fatalf("%s: Expected data file size %d, got %d", instanceType, file.Size, buffer.Len())
+		}
+		gzipReader, err := gzip.NewReader(&buffer)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		fileRecords, err := csv.NewReader(gzipReader).ReadAll()
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		records = append(records, fileRecords...)
+	}
+	sort.Sort(inventoryRecordsByKey(records))
+	var expected [][]string
+	for _, object := range []string{"logs/a", "logs/b", "logs/c/d"} {
+		expected = append(expected, []string{bucket, object, "6", objects[object].MD5Sum, "NOT-SSE"})
+	}
+	expected[2][2] = "8"
+	if !reflect.DeepEqual(records, expected) {
+		t.Fatalf("%s: Expected the records %v, got %v", instanceType, expected, records)
+	}
+
+	// Reports are written once per day.
+	countManifests := func() int {
+		result, err := obj.ListObjects(destBucket, "inventory/source/report1/", "", "", 1000)
+		if err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		count := 0
+		for _, objInfo := range result.Objects {
+			if path.Base(objInfo.Name) == inventoryManifestFile {
+				count++
+			}
+		}
+		return count
+	}
+	if err := runBucketInventories(obj, bucket, now.Add(10*time.Hour)); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if count := countManifests(); count != 1 {
+		t.Fatalf("%s: Expected 1 manifest the same day, got %d", instanceType, count)
+	}
+	if err := runBucketInventories(obj, bucket, now.Add(24*time.Hour)); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if count := countManifests(); count != 2 {
+		t.Fatalf("%s: Expected 2 manifests the next day, got %d", instanceType, count)
+	}
+
+	// Disabled configs are not run.
+	config.IsEnabled = false
+	if err := writeBucketInventoryConfig(bucket, config.ID, &config, obj); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if err := runBucketInventories(obj, bucket, now.Add(48*time.Hour)); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if count := countManifests(); count != 2 {
+		t.Fatalf("%s: Expected no manifest for a disabled config, got %d", instanceType, count)
+	}
+}
+
+// inventoryRecordsByKey - sorts the inventory records by their key.
+type inventoryRecordsByKey [][]string
+
+func (r inventoryRecordsByKey) Len() int           { return len(r) }
+func (r inventoryRecordsByKey) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r inventoryRecordsByKey) Less(i, j int) bool { return r[i][1] < r[j][1] }
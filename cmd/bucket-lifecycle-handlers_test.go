@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests PUT, GET and DELETE bucket lifecycle along with the
+// x-amz-expiration header of the objects.
+func TestBucketLifecycleHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketLifecycleHandlers, []string{
+		"GetBucketLifecycle",
+		"PutBucketLifecycle",
+		"DeleteBucketLifecycle",
+		"HeadObject",
+		"GetObject",
+	})
+}
+
+func testBucketLifecycleHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Lifecycle changes are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader([]byte(body)),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Lifecycle is not configured by default.
+	rec := serveRequest("GET", getBucketLifecycleURL("", bucketName), "")
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	objects := make(map[string]ObjectInfo)
+	for _, objectName := range []string{"logs/a", "data/b", "tmp/c"} {
+		objInfo, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), nil, "")
+		if err != nil {
+			t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+		objects[objectName] = objInfo
+	}
+	if expiration := serveRequest("HEAD", getHeadObjectURL("", bucketName, "logs/a"), "").Header().Get("x-amz-expiration"); expiration != "" {
+		t.Fatalf("%s: Expected no expiration without lifecycle, got %s", instanceType, expiration)
+	}
+
+	// Invalid lifecycle configs.
+	invalidConfigs := []string{
+		`<LifecycleConfiguration><Rule>`,
+		`<LifecycleConfiguration></LifecycleConfiguration>`,
+		`<LifecycleConfiguration><Rule><Status>Unknown</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`,
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>`,
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>1</Days><Date>2030-01-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`,
+		`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Date>2030-01-01T10:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`,
+		`<LifecycleConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>2</Days></Expiration></Rule></LifecycleConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketLifecycleURL("", bucketName), config); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	config := `<LifecycleConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<Rule><ID>expire-logs</ID><Prefix>logs/</Prefix><Status>Enabled</Status><Expiration><Days>3</Days></Expiration></Rule>` +
+		`<Rule><ID>expire-all-logs</ID><Filter><Prefix>logs/</Prefix></Filter><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule>` +
+		`<Rule><ID>expire-data</ID><Filter><Prefix>data/</Prefix></Filter><Status>Enabled</Status><Expiration><Date>2030-01-01T00:00:00Z</Date></Expiration></Rule>` +
+		`<Rule><ID>expire-tmp</ID><Prefix>tmp/</Prefix><Status>Disabled</Status><Expiration><Days>1</Days></Expiration></Rule>` +
+		`</LifecycleConfiguration>`
+	if rec = serveRequest("PUT", getBucketLifecycleURL("", bucketName), config); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketLifecycleURL("", bucketName), "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var lifecycle LifecycleConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &lifecycle); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if len(lifecycle.Rules) != 4 || lifecycle.Rules[2].Expiration.Date != "2030-01-01T00:00:00Z" {
+		t.Fatalf("%s: Unexpected lifecycle config %#v", instanceType, lifecycle)
+	}
+
+	// Expiry date is the midnight UTC following the number of days.
+	expiry := objects["logs/a"].ModTime.UTC().AddDate(0, 0, 3)
+	expiry = time.Date(expiry.Year(), expiry.Month(), expiry.Day()+1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		method             string
+		objectName         string
+		expectedExpiration string
+	}{
+		// Earliest of the matching rules applies.
+		{"HEAD", "logs/a", fmt.Sprintf(`expiry-date="%s", rule-id="expire-logs"`, expiry.Format(http.TimeFormat))},
+		{"GET", "logs/a", fmt.Sprintf(`expiry-date="%s", rule-id="expire-logs"`, expiry.Format(http.TimeFormat))},
+		{"HEAD", "data/b", `expiry-date="Tue, 01 Jan 2030 00:00:00 GMT", rule-id="expire-data"`},
+		// Disabled rules do not apply.
+		{"HEAD", "tmp/c", ""},
+	}
+	for i, testCase := range testCases {
+		urlStr := getHeadObjectURL("", bucketName, testCase.objectName)
+		if testCase.method == "GET" {
+			urlStr = getGetObjectURL("", bucketName, testCase.objectName)
+		}
+		rec = serveRequest(testCase.method, urlStr, "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		if expiration := rec.Header().Get("x-amz-expiration"); expiration != testCase.expectedExpiration {
+			t.Errorf("%s: Test %d: Expected expiration %q, got %q", instanceType, i+1, testCase.expectedExpiration, expiration)
+		}
+	}
+
+	// Delete lifecycle.
+	if rec = serveRequest("DELETE", getBucketLifecycleURL("", bucketName), ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = serveRequest("GET", getBucketLifecycleURL("", bucketName), ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	if expiration := serveRequest("HEAD", getHeadObjectURL("", bucketName, "logs/a"), "").Header().Get("x-amz-expiration"); expiration != "" {
+		t.Fatalf("%s: Expected no expiration after lifecycle is removed, got %s", instanceType, expiration)
+	}
+}
@@ -0,0 +1,324 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket lifecycle config name.
+	bucketLifecycleConfig = "lifecycle.xml"
+
+	// Maximum number of rules in a lifecycle config.
+	maxLifecycleRules = 1000
+
+	// Maximum length of a lifecycle rule ID.
+	maxLifecycleRuleIDLength = 255
+
+	// Lifecycle rule status values.
+	lifecycleStatusEnabled  = "Enabled"
+	lifecycleStatusDisabled = "Disabled"
+)
+
+// errInvalidLifecycleConfig - lifecycle config is not valid.
+var errInvalidLifecycleConfig = errors.New("Invalid lifecycle configuration")
+
+// errNoSuchLifecycleConfig - lifecycle config is not set on the bucket.
+var errNoSuchLifecycleConfig = errors.New("The lifecycle configuration does not exist")
+
+// LifecycleExpiration - expiration of the objects matching a rule,
+// either after a number of days since creation or at a date.
+type LifecycleExpiration struct {
+	Days int    `xml:"Days,omitempty"`
+	Date string `xml:"Date,omitempty"`
+}
+
+// LifecycleFilter - selects the objects a rule applies to.
+type LifecycleFilter struct {
+	Prefix string `xml:"Prefix"`
+}
+
+// LifecycleRule - a single lifecycle rule of a bucket.
+type LifecycleRule struct {
+	ID         string               `xml:"ID,omitempty"`
+	Prefix     string               `xml:"Prefix,omitempty"`
+	Filter     *LifecycleFilter     `xml:"Filter,omitempty"`
+	Status     string               `xml:"Status"`
+	Expiration *LifecycleExpiration `xml:"Expiration"`
+}
+
+// LifecycleConfiguration - lifecycle config of a bucket.
+type LifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	XMLNS   string          `xml:"xmlns,attr,omitempty"`
+	Rules   []LifecycleRule `xml:"Rule"`
+}
+
+// prefix - returns the object name prefix the rule applies to.
+func (rule LifecycleRule) prefix() string {
+	if rule.Filter != nil {
+		return rule.Filter.Prefix
+	}
+	return rule.Prefix
+}
+
+// expiryDate - returns the time at which the object would be expired by
+// the rule. Days are counted from the object creation and rounded up to
+// the next midnight UTC, as S3 does.
+func (rule LifecycleRule) expiryDate(modTime time.Time) time.Time {
+	if rule.Expiration.Date != "" {
+		date, _ := time.Parse(time.RFC3339, rule.Expiration.Date)
+		return date.UTC()
+	}
+	expiry := modTime.UTC().Add(time.Duration(rule.Expiration.Days) * 24 * time.Hour)
+	midnight := expiry.Truncate(24 * time.Hour)
+	if midnight.Before(expiry) {
+		midnight = midnight.Add(24 * time.Hour)
+	}
+	return midnight
+}
+
+// validateLifecycleConfig - validates the lifecycle rules.
+func validateLifecycleConfig(config LifecycleConfiguration) error {
+	if len(config.Rules) == 0 || len(config.Rules) > maxLifecycleRules {
+		return errInvalidLifecycleConfig
+	}
+	ruleIDs := make(map[string]struct{})
+	for _, rule := range config.Rules {
+		if len(rule.ID) > maxLifecycleRuleIDLength {
+			return errInvalidLifecycleConfig
+		}
+		if rule.ID != "" {
+			if _, ok := ruleIDs[rule.ID]; ok {
+				return errInvalidLifecycleConfig
+			}
+			ruleIDs[rule.ID] = struct{}{}
+		}
+		if rule.Status != lifecycleStatusEnabled && rule.Status != lifecycleStatusDisabled {
+			return errInvalidLifecycleConfig
+		}
+		if rule.Filter != nil && rule.Prefix != "" {
+			return errInvalidLifecycleConfig
+		}
+		expiration := rule.Expiration
+		if expiration == nil {
+			return errInvalidLifecycleConfig
+		}
+		// Exactly one of days or date is allowed.
+		if (expiration.Days > 0) == (expiration.Date != "") || expiration.Days < 0 {
+			return errInvalidLifecycleConfig
+		}
+		if expiration.Date != "" {
+			// Date must be midnight UTC in ISO 8601 format.
+			date, err := time.Parse(time.RFC3339, expiration.Date)
+			if err != nil || !date.UTC().Equal(date.UTC().Truncate(24*time.Hour)) {
+				return errInvalidLifecycleConfig
+			}
+		}
+	}
+	return nil
+}
+
+// Variable represents bucket lifecycle configs in memory.
+var globalBucketLifecycle = newBucketLifecycleConfigs(nil)
+
+// bucketLifecycleConfigs - lifecycle configs of all the buckets.
+type bucketLifecycleConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of lifecycle configs indexed by 'bucket'.
+	configs map[string]LifecycleConfiguration
+}
+
+// newBucketLifecycleConfigs - initializes bucket lifecycle configs.
+func newBucketLifecycleConfigs(configs map[string]LifecycleConfiguration) *bucketLifecycleConfigs {
+	if configs == nil {
+		configs = make(map[string]LifecycleConfiguration)
+	}
+	return &bucketLifecycleConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the lifecycle config of the bucket, false if not set.
+func (bl *bucketLifecycleConfigs) Get(bucket string) (LifecycleConfiguration, bool) {
+	bl.rwMutex.RLock()
+	defer bl.rwMutex.RUnlock()
+	config, ok := bl.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the lifecycle config of the bucket, nil config removes it.
+func (bl *bucketLifecycleConfigs) Set(bucket string, config *LifecycleConfiguration) {
+	bl.rwMutex.Lock()
+	defer bl.rwMutex.Unlock()
+	if config == nil {
+		delete(bl.configs, bucket)
+		return
+	}
+	bl.configs[bucket] = *config
+}
+
+// getObjectExpiration - returns the value of x-amz-expiration header of
+// the object, empty if no enabled lifecycle rule applies to it. When
+// multiple rules apply the earliest expiry is reported.
+func getObjectExpiration(objInfo ObjectInfo) string {
+	config, ok := globalBucketLifecycle.Get(objInfo.Bucket)
+	if !ok {
+		return ""
+	}
+
+	var expiry time.Time
+	var ruleID string
+	for _, rule := range config.Rules {
+		if rule.Status != lifecycleStatusEnabled || rule.Expiration == nil {
+			continue
+		}
+		if !strings.HasPrefix(objInfo.Name, rule.prefix()) {
+			continue
+		}
+		date := rule.expiryDate(objInfo.ModTime)
+		if expiry.IsZero() || date.Before(expiry) {
+			expiry = date
+			ruleID = rule.ID
+		}
+	}
+	if expiry.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("expiry-date=\"%s\", rule-id=\"%s\"", expiry.Format(http.TimeFormat), ruleID)
+}
+
+// Loads all bucket lifecycle configs from persistent layer.
+func loadAllBucketLifecycleConfigs(objAPI ObjectLayer) (map[string]LifecycleConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]LifecycleConfiguration)
+	for _, bucket := range buckets {
+		config, lErr := readBucketLifecycleConfig(bucket.Name, objAPI)
+		if lErr != nil {
+			if !isErrIgnored(lErr, errNoSuchLifecycleConfig, errDiskNotFound) {
+				return nil, lErr
+			}
+			// Continue to load other bucket lifecycle configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket lifecycle configs.
+func initBucketLifecycle(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketLifecycleConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket lifecycle configs.
+	globalBucketLifecycle = newBucketLifecycleConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketLifecycleConfig - reads the lifecycle config of the bucket.
+func readBucketLifecycleConfig(bucket string, objAPI ObjectLayer) (LifecycleConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketLifecycleConfig)
+
+	// Acquire a read lock on lifecycle config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return LifecycleConfiguration{}, errNoSuchLifecycleConfig
+		}
+		errorIf(err, "Unable to load lifecycle config for the bucket %s.", bucket)
+		return LifecycleConfiguration{}, errorCause(err)
+	}
+
+	var config LifecycleConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return LifecycleConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketLifecycleConfig - saves the lifecycle config of the bucket,
+// nil config removes any previously saved config.
+func writeBucketLifecycleConfig(bucket string, config *LifecycleConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketLifecycleConfig)
+
+	// Acquire a write lock on lifecycle config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove lifecycle config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set lifecycle config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketLifecycleChange - persists the lifecycle config
+// of the bucket and notifies all the nodes in the cluster to update
+// their in-memory state.
+func persistAndNotifyBucketLifecycleChange(bucket string, config *LifecycleConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketLifecycleConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketLifecycle(bucket, config)
+	return nil
+}
@@ -0,0 +1,154 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// Maximum size of a bucket logging config.
+const maxBucketLoggingConfigSize = 20 * 1024
+
+// GetBucketLoggingHandler - This implementation of the GET operation
+// uses the logging subresource to return the logging status of a
+// bucket. If logging is not enabled on the bucket, the operation
+// returns an empty BucketLoggingStatus element.
+func (api objectAPIHandlers) GetBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	loggingStatus, err := readBucketLoggingConfig(bucket, objAPI)
+	if err != nil {
+		errorIf(err, "Unable to read logging configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	loggingStatus.XMLNS = "http://s3.amazonaws.com/doc/2006-03-01/"
+
+	loggingBytes, err := xml.Marshal(loggingStatus)
+	if err != nil {
+		errorIf(err, "Unable to marshal logging configuration into XML.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseXML(w, loggingBytes)
+}
+
+// PutBucketLoggingHandler - Sets the logging parameters of a bucket,
+// access logs of the bucket are written as objects under the target
+// prefix of the target bucket. An empty BucketLoggingStatus element
+// disables logging.
+func (api objectAPIHandlers) PutBucketLoggingHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// If Content-Length is unknown or zero, deny the request.
+	if r.ContentLength == -1 || r.ContentLength == 0 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+	if r.ContentLength > maxBucketLoggingConfigSize {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	loggingBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, r.ContentLength))
+	if err != nil {
+		errorIf(err, "Unable to read incoming body.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	var loggingStatus BucketLoggingStatus
+	if err = xml.Unmarshal(loggingBytes, &loggingStatus); err != nil {
+		errorIf(err, "Unable to parse logging configuration XML.")
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
+		return
+	}
+	loggingStatus.XMLNS = ""
+
+	if target := loggingStatus.LoggingEnabled; target != nil {
+		if !IsValidBucketName(target.TargetBucket) {
+			writeErrorResponse(w, ErrInvalidTargetBucketForLogging, r.URL)
+			return
+		}
+		if _, err = objAPI.GetBucketInfo(target.TargetBucket); err != nil {
+			writeErrorResponse(w, ErrInvalidTargetBucketForLogging, r.URL)
+			return
+		}
+		if target.TargetPrefix != "" && !IsValidObjectPrefix(target.TargetPrefix) {
+			writeErrorResponse(w, ErrInvalidObjectName, r.URL)
+			return
+		}
+	}
+
+	// Acquire a write lock on bucket before modifying its configuration.
+	bucketLock := globalNSMutex.NewNSLock(bucket, "")
+	bucketLock.Lock()
+	// Release lock after notifying peers
+	defer bucketLock.Unlock()
+
+	if err = persistAndNotifyBucketLoggingChange(bucket, loggingStatus, objAPI); err != nil {
+		errorIf(err, "Unable to save logging configuration.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Success.
+	writeSuccessResponseHeadersOnly(w)
+}
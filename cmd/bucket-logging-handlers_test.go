@@ -0,0 +1,117 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT and GET bucket logging round-trip.
+func TestBucketLoggingHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketLoggingHandlers, []string{
+		"GetBucketLogging",
+		"PutBucketLogging",
+	})
+}
+
+func testBucketLoggingHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	logBucket := "log-bucket"
+	if err := obj.MakeBucket(logBucket); err != nil {
+		t.Fatalf("%s: Unable to create bucket: %s", instanceType, err)
+	}
+
+	getLogging := func() BucketLoggingStatus {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", getBucketLoggingURL("", bucketName),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for GetBucketLogging: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var loggingStatus BucketLoggingStatus
+		if err = xml.Unmarshal(rec.Body.Bytes(), &loggingStatus); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		return loggingStatus
+	}
+
+	// Logging is disabled by default.
+	if loggingStatus := getLogging(); loggingStatus.LoggingEnabled != nil {
+		t.Fatalf("%s: Expected logging to be disabled, got %#v", instanceType, loggingStatus.LoggingEnabled)
+	}
+
+	testCases := []struct {
+		body               string
+		expectedRespStatus int
+		expectedTarget     *LoggingEnabled
+	}{
+		// Enable logging.
+		{
+			`<BucketLoggingStatus xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><LoggingEnabled><TargetBucket>log-bucket</TargetBucket><TargetPrefix>logs/</TargetPrefix></LoggingEnabled></BucketLoggingStatus>`,
+			http.StatusOK, &LoggingEnabled{TargetBucket: logBucket, TargetPrefix: "logs/"},
+		},
+		// Target bucket does not exist, previous config is retained.
+		{
+			`<BucketLoggingStatus><LoggingEnabled><TargetBucket>missing-bucket</TargetBucket></LoggingEnabled></BucketLoggingStatus>`,
+			http.StatusBadRequest, &LoggingEnabled{TargetBucket: logBucket, TargetPrefix: "logs/"},
+		},
+		// Malformed XML.
+		{
+			`<BucketLoggingStatus><LoggingEnabled>`,
+			http.StatusBadRequest, &LoggingEnabled{TargetBucket: logBucket, TargetPrefix: "logs/"},
+		},
+		// Disable logging.
+		{
+			`<BucketLoggingStatus xmlns="http://s3.amazonaws.com/doc/2006-03-01/" />`,
+			http.StatusOK, nil,
+		},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("PUT", getBucketLoggingURL("", bucketName),
+			int64(len(testCase.body)), bytes.NewReader([]byte(testCase.body)), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for PutBucketLogging: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedRespStatus, rec.Code)
+		}
+
+		loggingStatus := getLogging()
+		if testCase.expectedTarget == nil {
+			if loggingStatus.LoggingEnabled != nil {
+				t.Fatalf("%s: Test %d: Expected logging to be disabled", instanceType, i+1)
+			}
+			if _, ok := globalBucketLogging.Get(bucketName); ok {
+				t.Fatalf("%s: Test %d: Expected in-memory logging config to be removed", instanceType, i+1)
+			}
+			continue
+		}
+		if loggingStatus.LoggingEnabled == nil || *loggingStatus.LoggingEnabled != *testCase.expectedTarget {
+			t.Fatalf("%s: Test %d: Expected %#v, got %#v", instanceType, i+1, testCase.expectedTarget, loggingStatus.LoggingEnabled)
+		}
+	}
+}
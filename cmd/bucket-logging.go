@@ -0,0 +1,217 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"path"
+	"sync"
+)
+
+// Bucket logging config name.
+const bucketLoggingConfig = "logging.xml"
+
+// LoggingEnabled - target bucket and key prefix of the access log
+// objects of a bucket.
+type LoggingEnabled struct {
+	TargetBucket string `xml:"TargetBucket"`
+	TargetPrefix string `xml:"TargetPrefix"`
+}
+
+// BucketLoggingStatus - access logging configuration of a bucket,
+// logging is disabled when LoggingEnabled is not set.
+type BucketLoggingStatus struct {
+	XMLName        xml.Name        `xml:"BucketLoggingStatus"`
+	XMLNS          string          `xml:"xmlns,attr,omitempty"`
+	LoggingEnabled *LoggingEnabled `xml:"LoggingEnabled"`
+}
+
+// Variable represents bucket logging configs in memory.
+var globalBucketLogging = newBucketLoggingConfigs(nil)
+
+// bucketLoggingConfigs - access logging configs of all the buckets,
+// looked up for every request.
+type bucketLoggingConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of logging targets indexed by 'bucket'.
+	configs map[string]LoggingEnabled
+}
+
+// newBucketLoggingConfigs - initializes bucket logging configs.
+func newBucketLoggingConfigs(configs map[string]LoggingEnabled) *bucketLoggingConfigs {
+	if configs == nil {
+		configs = make(map[string]LoggingEnabled)
+	}
+	return &bucketLoggingConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the logging target of the bucket, false if logging
+// is disabled.
+func (bl *bucketLoggingConfigs) Get(bucket string) (LoggingEnabled, bool) {
+	bl.rwMutex.RLock()
+	defer bl.rwMutex.RUnlock()
+	target, ok := bl.configs[bucket]
+	return target, ok
+}
+
+// Set - sets the logging target of the bucket, nil target disables
+// logging.
+func (bl *bucketLoggingConfigs) Set(bucket string, target *LoggingEnabled) {
+	bl.rwMutex.Lock()
+	defer bl.rwMutex.Unlock()
+	if target == nil {
+		delete(bl.configs, bucket)
+		return
+	}
+	bl.configs[bucket] = *target
+}
+
+// IsTargetBucket - returns true if access logs of any bucket are
+// written to the bucket.
+func (bl *bucketLoggingConfigs) IsTargetBucket(bucket string) bool {
+	bl.rwMutex.RLock()
+	defer bl.rwMutex.RUnlock()
+	for _, target := range bl.configs {
+		if target.TargetBucket == bucket {
+			return true
+		}
+	}
+	return false
+}
+
+// Loads all bucket logging configs from persistent layer.
+func loadAllBucketLoggingConfigs(objAPI ObjectLayer) (map[string]LoggingEnabled, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]LoggingEnabled)
+	for _, bucket := range buckets {
+		loggingStatus, lErr := readBucketLoggingConfig(bucket.Name, objAPI)
+		if lErr != nil {
+			if !isErrIgnored(lErr, errDiskNotFound) {
+				return nil, lErr
+			}
+			// Continue to load other bucket logging configs if possible.
+			continue
+		}
+		if loggingStatus.LoggingEnabled != nil {
+			configs[bucket.Name] = *loggingStatus.LoggingEnabled
+		}
+	}
+	return configs, nil
+}
+
+// Intialize all bucket logging configs and start the access logger.
+func initBucketLogging(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketLoggingConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket logging configs.
+	globalBucketLogging = newBucketLoggingConfigs(configs)
+
+	// Access logs are written asynchronously by a single logger.
+	globalAccessLoggerOnce.Do(func() {
+		globalAccessLogger = newAccessLogger(newObjectLayerFn, accessLogBatchSize, accessLogFlushInterval)
+	})
+
+	// Success.
+	return nil
+}
+
+// readBucketLoggingConfig - reads the logging config of the bucket,
+// returns an empty config if logging was never configured.
+func readBucketLoggingConfig(bucket string, objAPI ObjectLayer) (BucketLoggingStatus, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketLoggingConfig)
+
+	// Acquire a read lock on logging config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return BucketLoggingStatus{}, nil
+		}
+		errorIf(err, "Unable to load logging config for the bucket %s.", bucket)
+		return BucketLoggingStatus{}, errorCause(err)
+	}
+
+	var loggingStatus BucketLoggingStatus
+	if err = xml.Unmarshal(buffer.Bytes(), &loggingStatus); err != nil {
+		return BucketLoggingStatus{}, err
+	}
+	return loggingStatus, nil
+}
+
+// writeBucketLoggingConfig - saves the logging config of the bucket,
+// logging config without a target removes any previously saved config.
+func writeBucketLoggingConfig(bucket string, loggingStatus BucketLoggingStatus, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketLoggingConfig)
+
+	// Acquire a write lock on logging config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if loggingStatus.LoggingEnabled == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove logging config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(loggingStatus)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set logging config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketLoggingChange - persists the logging config of
+// the bucket and notifies all the nodes in the cluster to update their
+// in-memory state.
+func persistAndNotifyBucketLoggingChange(bucket string, loggingStatus BucketLoggingStatus, objAPI ObjectLayer) error {
+	if err := writeBucketLoggingConfig(bucket, loggingStatus, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketLogging(bucket, loggingStatus.LoggingEnabled)
+	return nil
+}
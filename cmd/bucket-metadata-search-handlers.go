@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/url"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Minio extension to ListObjects, a GET on the bucket with the
+// `x-minio-metadata-search` query param lists the objects whose user
+// metadata match all the `meta` query params, each of them either
+// `key=value` or `key` alone for the objects having the key.
+const (
+	metadataSearchQueryParam     = "x-minio-metadata-search"
+	metadataSearchMetaQueryParam = "meta"
+)
+
+// getMetadataPredicates - returns the predicates of the search, all of
+// them must be on indexed keys.
+func getMetadataPredicates(values url.Values, index *metadataIndexObjects) ([]metadataPredicate, APIErrorCode) {
+	metas := values[metadataSearchMetaQueryParam]
+	if len(metas) == 0 {
+		return nil, ErrInvalidMetadataSearch
+	}
+	predicates := make([]metadataPredicate, 0, len(metas))
+	for _, meta := range metas {
+		predicate, ok := parseMetadataPredicate(meta)
+		if !ok || !index.isIndexed(predicate.key) {
+			return nil, ErrInvalidMetadataSearch
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, ErrNone
+}
+
+// MetadataSearchHandler - GET /{bucket}?x-minio-metadata-search&meta={predicate}...
+// ----------
+// This implementation of the metadata search lists some or all (up to
+// 1000) of the objects of a bucket matching the predicates on their
+// indexed user metadata, in the format of ListObjects V1. The prefix,
+// marker, max-keys and encoding-type query params are those of
+// ListObjects V1, the listing is flat.
+func (api objectAPIHandlers) MetadataSearchHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:ListBucket", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	index, ok := objectAPI.(*metadataIndexObjects)
+	if !ok {
+		writeErrorResponse(w, ErrMetadataIndexDisabled, r.URL)
+		return
+	}
+
+	prefix, marker, _, maxKeys, encodingType := getListObjectsV1Args(r.URL.Query())
+	if s3Error := validateListObjectsArgs(prefix, marker, "", encodingType, maxKeys); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	predicates, s3Error := getMetadataPredicates(r.URL.Query(), index)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if err := checkBucketExist(bucket, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	listObjectsInfo, err := index.Search(bucket, prefix, marker, predicates, maxKeys)
+	if err != nil {
+		errorIf(err, "Unable to search objects.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	response := generateListObjectsV1Response(bucket, prefix, marker, "", encodingType, maxKeys, listObjectsInfo)
+
+	// Write success response.
+	writeSuccessResponseXML(w, encodeResponse(response))
+}
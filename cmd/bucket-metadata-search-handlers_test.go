@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the metadata search lists the matching objects, and rejects
+// the searches of the keys not indexed.
+func TestMetadataSearchHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testMetadataSearchHandler, []string{"MetadataSearch"})
+}
+
+func testMetadataSearchHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	search := func(prefix, marker, maxKeys string, metas ...string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", getMetadataSearchURL("", bucketName, prefix, marker, maxKeys, metas),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for MetadataSearch: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Searches fail while the index is disabled.
+	if rec := search("", "", "", "color=red"); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotImplemented, rec.Code)
+	}
+
+	index := newMetadataIndexObjects(obj, []string{"color"})
+	if err := index.Rebuild(); err != nil {
+		t.Fatalf("%s: Failed to build the index: <ERROR> %v", instanceType, err)
+	}
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = index
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = obj
+		globalObjLayerMutex.Unlock()
+	}()
+
+	data := []byte("data")
+	for object, color := range map[string]string{"a/1": "red", "a/2": "red", "b/1": "red", "b/2": "blue"} {
+		metadata := map[string]string{"X-Amz-Meta-Color": color}
+		if _, err := index.PutObject(bucketName, object, int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+	if err := index.DeleteObject(bucketName, "a/2"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		prefix      string
+		marker      string
+		maxKeys     string
+		metas       []string
+		status      int
+		objects     []string
+		isTruncated bool
+	}{
+		{"", "", "", []string{"color=red"}, http.StatusOK, []string{"a/1", "b/1"}, false},
+		{"", "", "", []string{"x-amz-meta-color"}, http.StatusOK, []string{"a/1", "b/1", "b/2"}, false},
+		{"b/", "", "", []string{"color"}, http.StatusOK, []string{"b/1", "b/2"}, false},
+		{"", "", "1", []string{"color=red"}, http.StatusOK, []string{"a/1"}, true},
+		{"", "a/1", "1", []string{"color=red"}, http.StatusOK, []string{"b/1"}, false},
+		{"", "", "", []string{"color=green"}, http.StatusOK, nil, false},
+		// Keys not indexed and missing predicates are rejected.
+		{"", "", "", []string{"owner=alice"}, http.StatusBadRequest, nil, false},
+		{"", "", "", nil, http.StatusBadRequest, nil, false},
+		{"", "", "-1", []string{"color"}, http.StatusBadRequest, nil, false},
+	}
+	for i, testCase := range testCases {
+		rec := search(testCase.prefix, testCase.marker, testCase.maxKeys, testCase.metas...)
+		if rec.Code != testCase.status {
+			t.Fatalf("%s: Test %d: Expected the response status to be `%d`, but instead found `%d`", instanceType, i+1, testCase.status, rec.Code)
+		}
+		if testCase.status != http.StatusOK {
+			continue
+		}
+		var response ListObjectsResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: Test %d: Failed to parse the response: <ERROR> %v", instanceType, i+1, err)
+		}
+		var objects []string
+		for _, content := range response.Contents {
+			objects = append(objects, content.Key)
+		}
+		if len(objects) != len(testCase.objects) {
+			t.Fatalf("%s: Test %d: Expected objects %v, got %v", instanceType, i+1, testCase.objects, objects)
+		}
+		for j := range objects {
+			if objects[j] != testCase.objects[j] {
+				t.Fatalf("%s: Test %d: Expected objects %v, got %v", instanceType, i+1, testCase.objects, objects)
+			}
+		}
+		if response.IsTruncated != testCase.isTruncated {
+			t.Fatalf("%s: Test %d: Expected truncated %t, got %t", instanceType, i+1, testCase.isTruncated, response.IsTruncated)
+		}
+	}
+
+	// Missing buckets are reported.
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("GET", getMetadataSearchURL("", "missing-bucket", "", "", "", []string{"color"}),
+		0, nil, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
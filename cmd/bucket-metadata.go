@@ -30,6 +30,48 @@ type BucketMetaState interface {
 	// Updates bucket policy
 	UpdateBucketPolicy(args *SetBucketPolicyPeerArgs) error
 
+	// Updates bucket logging
+	UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error
+
+	// Updates bucket lifecycle
+	UpdateBucketLifecycle(args *SetBucketLifecyclePeerArgs) error
+
+	// Updates bucket replication
+	UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error
+
+	// Updates bucket content sniffing
+	UpdateBucketContentSniffing(args *SetBucketContentSniffingPeerArgs) error
+
+	// Updates bucket website
+	UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error
+
+	// Updates bucket request payment
+	UpdateBucketRequestPayment(args *SetBucketRequestPaymentPeerArgs) error
+
+	// Updates bucket object lock
+	UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error
+
+	// Updates bucket trash
+	UpdateBucketTrash(args *SetBucketTrashPeerArgs) error
+
+	// Updates bucket object size limits
+	UpdateBucketObjectSizeLimits(args *SetBucketObjectSizeLimitsPeerArgs) error
+
+	// Updates bucket public access block
+	UpdateBucketPublicAccessBlock(args *SetBucketPublicAccessBlockPeerArgs) error
+
+	// Updates bucket accelerate
+	UpdateBucketAccelerate(args *SetBucketAcceleratePeerArgs) error
+
+	// Updates bucket client certificate
+	UpdateBucketClientCert(args *SetBucketClientCertPeerArgs) error
+
+	// Updates bucket content addressing
+	UpdateBucketContentAddressing(args *SetBucketContentAddressingPeerArgs) error
+
+	// Updates bucket ownership controls
+	UpdateBucketOwnershipControls(args *SetBucketOwnershipControlsPeerArgs) error
+
 	// Sends event
 	SendEvent(args *EventArgs) error
 }
@@ -88,6 +130,188 @@ func (lc *localBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArgs
 	return globalBucketPolicies.SetBucketPolicy(args.Bucket, pCh)
 }
 
+// localBucketMetaState.UpdateBucketLogging - updates in-memory global
+// bucket logging info.
+func (lc *localBucketMetaState) UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketLogging.Set(args.Bucket, args.Target)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketLifecycle - updates in-memory global
+// bucket lifecycle info.
+func (lc *localBucketMetaState) UpdateBucketLifecycle(args *SetBucketLifecyclePeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketLifecycle.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketReplication - updates in-memory global
+// bucket replication info.
+func (lc *localBucketMetaState) UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketReplication.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketContentSniffing - updates in-memory
+// global bucket content sniffing info.
+func (lc *localBucketMetaState) UpdateBucketContentSniffing(args *SetBucketContentSniffingPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketContentSniffing.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketWebsite - updates in-memory global
+// bucket website info.
+func (lc *localBucketMetaState) UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketWebsites.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketRequestPayment - updates in-memory
+// global bucket request payment info.
+func (lc *localBucketMetaState) UpdateBucketRequestPayment(args *SetBucketRequestPaymentPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketRequestPayment.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketObjectLock - updates in-memory
+// global bucket object lock info.
+func (lc *localBucketMetaState) UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketObjectLock.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketTrash - updates in-memory global
+// bucket trash info.
+func (lc *localBucketMetaState) UpdateBucketTrash(args *SetBucketTrashPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketTrash.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketObjectSizeLimits - updates in-memory
+// global bucket object size limits info.
+func (lc *localBucketMetaState) UpdateBucketObjectSizeLimits(args *SetBucketObjectSizeLimitsPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketObjectSizeLimits.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketPublicAccessBlock - updates in-memory
+// global bucket public access block info.
+func (lc *localBucketMetaState) UpdateBucketPublicAccessBlock(args *SetBucketPublicAccessBlockPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketPublicAccessBlock.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketAccelerate - updates in-memory
+// global bucket accelerate info.
+func (lc *localBucketMetaState) UpdateBucketAccelerate(args *SetBucketAcceleratePeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketAccelerate.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketClientCert - updates in-memory
+// global bucket client certificate info.
+func (lc *localBucketMetaState) UpdateBucketClientCert(args *SetBucketClientCertPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketClientCert.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketContentAddressing - updates in-memory
+// global bucket content addressing info.
+func (lc *localBucketMetaState) UpdateBucketContentAddressing(args *SetBucketContentAddressingPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketContentAddressing.Set(args.Bucket, args.Config)
+	return nil
+}
+
+// localBucketMetaState.UpdateBucketOwnershipControls - updates in-memory
+// global bucket ownership controls info.
+func (lc *localBucketMetaState) UpdateBucketOwnershipControls(args *SetBucketOwnershipControlsPeerArgs) error {
+	// check if object layer is available.
+	objAPI := lc.ObjectAPI()
+	if objAPI == nil {
+		return errServerNotInitialized
+	}
+
+	globalBucketOwnershipControls.Set(args.Bucket, args.Config)
+	return nil
+}
+
 // localBucketMetaState.SendEvent - sends event to local event notifier via
 // `globalEventNotifier`
 func (lc *localBucketMetaState) SendEvent(args *EventArgs) error {
@@ -126,6 +350,104 @@ func (rc *remoteBucketMetaState) UpdateBucketPolicy(args *SetBucketPolicyPeerArg
 	return rc.Call("S3.SetBucketPolicyPeer", args, &reply)
 }
 
+// remoteBucketMetaState.UpdateBucketLogging - sends bucket logging
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketLogging(args *SetBucketLoggingPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketLoggingPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketLifecycle - sends bucket lifecycle
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketLifecycle(args *SetBucketLifecyclePeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketLifecyclePeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketReplication - sends bucket replication
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketReplication(args *SetBucketReplicationPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketReplicationPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketContentSniffing - sends bucket content
+// sniffing change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketContentSniffing(args *SetBucketContentSniffingPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketContentSniffingPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketWebsite - sends bucket website
+// change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketWebsite(args *SetBucketWebsitePeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketWebsitePeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketRequestPayment - sends bucket
+// request payment change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketRequestPayment(args *SetBucketRequestPaymentPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketRequestPaymentPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketObjectLock - sends bucket object
+// lock change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketObjectLock(args *SetBucketObjectLockPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketObjectLockPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketTrash - sends bucket trash change
+// to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketTrash(args *SetBucketTrashPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketTrashPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketObjectSizeLimits - sends bucket
+// object size limits change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketObjectSizeLimits(args *SetBucketObjectSizeLimitsPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketObjectSizeLimitsPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketPublicAccessBlock - sends bucket
+// public access block change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketPublicAccessBlock(args *SetBucketPublicAccessBlockPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketPublicAccessBlockPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketAccelerate - sends bucket
+// accelerate change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketAccelerate(args *SetBucketAcceleratePeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketAcceleratePeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketClientCert - sends bucket client
+// certificate change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketClientCert(args *SetBucketClientCertPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketClientCertPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketContentAddressing - sends bucket
+// content addressing change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketContentAddressing(args *SetBucketContentAddressingPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketContentAddressingPeer", args, &reply)
+}
+
+// remoteBucketMetaState.UpdateBucketOwnershipControls - sends bucket
+// ownership controls change to remote peer via RPC call.
+func (rc *remoteBucketMetaState) UpdateBucketOwnershipControls(args *SetBucketOwnershipControlsPeerArgs) error {
+	reply := AuthRPCReply{}
+	return rc.Call("S3.SetBucketOwnershipControlsPeer", args, &reply)
+}
+
 // remoteBucketMetaState.SendEvent - sends event for bucket listener to remote
 // peer via RPC call.
 func (rc *remoteBucketMetaState) SendEvent(args *EventArgs) error {
@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Supported modes of the validation of the bucket names.
+const (
+	// DNS compliant bucket names only, the default.
+	bucketNameValidationStrict = "strict"
+	// Legacy bucket names, allowing uppercase letters, underscores and
+	// names up to 255 characters, for the setups migrating old buckets.
+	bucketNameValidationRelaxed = "relaxed"
+)
+
+// validLegacyBucket regexp.
+var validLegacyBucket = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9\.\-_]{2,254}$`)
+
+// setBucketNameValidation - sets the validation of the bucket names
+// from MINIO_BUCKET_NAME_VALIDATION env.
+func setBucketNameValidation() {
+	if mode := os.Getenv("MINIO_BUCKET_NAME_VALIDATION"); mode != "" {
+		switch strings.ToLower(mode) {
+		case bucketNameValidationStrict:
+			globalBucketNameValidation = bucketNameValidationStrict
+		case bucketNameValidationRelaxed:
+			globalBucketNameValidation = bucketNameValidationRelaxed
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_BUCKET_NAME_VALIDATION value %s.", mode)
+		}
+	}
+}
+
+// isValidStrictBucketName - returns true if the bucket name is DNS
+// compliant: 3-63 lowercase letters, numbers, dashes and periods,
+// beginning and ending with a letter or a number, without adjacent
+// periods or dashes next to a period, not formatted as an IP address
+// and without the prefixes and suffixes reserved by S3.
+func isValidStrictBucketName(bucket string) bool {
+	if len(bucket) < 3 || len(bucket) > 63 {
+		return false
+	}
+	if !validBucket.MatchString(bucket) || isIPAddress.MatchString(bucket) {
+		return false
+	}
+	for _, label := range []string{"..", ".-", "-."} {
+		if strings.Contains(bucket, label) {
+			return false
+		}
+	}
+	return !strings.HasPrefix(bucket, "xn--") &&
+		!strings.HasSuffix(bucket, "-s3alias") &&
+		!strings.HasSuffix(bucket, "--ol-s3")
+}
+
+// isValidRelaxedBucketName - returns true if the bucket name is a
+// legacy bucket name: 3-255 letters, numbers, dashes, periods and
+// underscores, beginning with a letter or a number, without adjacent
+// periods and not formatted as an IP address.
+func isValidRelaxedBucketName(bucket string) bool {
+	return validLegacyBucket.MatchString(bucket) &&
+		!isIPAddress.MatchString(bucket) &&
+		!strings.Contains(bucket, "..")
+}
+
+// bucketNameValidationHandler - rejects the path-style requests of
+// invalid bucket names.
+type bucketNameValidationHandler struct {
+	handler http.Handler
+}
+
+// setBucketNameValidationHandler - rejects the requests whose path
+// names an invalid bucket with InvalidBucketName, before any of them
+// reaches the object layer. The reserved paths of the server are not
+// buckets and are left as is.
+func setBucketNameValidationHandler(h http.Handler) http.Handler {
+	return bucketNameValidationHandler{h}
+}
+
+func (h bucketNameValidationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, _ := urlPath2BucketObjectName(r.URL)
+	if bucket != "" && !strings.HasPrefix(r.URL.Path, reservedBucket+slashSeparator) &&
+		!IsValidBucketName(bucket) {
+		writeErrorResponse(w, ErrInvalidBucketName, r.URL)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests the legacy bucket names are accepted in the relaxed mode only.
+func TestIsValidBucketNameRelaxed(t *testing.T) {
+	defer func() { globalBucketNameValidation = bucketNameValidationStrict }()
+
+	testCases := []struct {
+		bucketName    string
+		strictResult  bool
+		relaxedResult bool
+	}{
+		{"my-bucket", true, true},
+		{"MyBucket", false, true},
+		{"under_score", false, true},
+		{"my.-bucket", false, true},
+		{strings.Repeat("a", 64), false, true},
+		{strings.Repeat("a", 255), false, true},
+		{minioMetaBucket, true, true},
+		// Invalid in both modes.
+		{strings.Repeat("a", 256), false, false},
+		{"ab", false, false},
+		{"_bucket", false, false},
+		{".bucket", false, false},
+		{"my..bucket", false, false},
+		{"192.168.1.1", false, false},
+		{"my/bucket", false, false},
+		{"una ñina", false, false},
+	}
+	for i, testCase := range testCases {
+		globalBucketNameValidation = bucketNameValidationStrict
+		if result := IsValidBucketName(testCase.bucketName); result != testCase.strictResult {
+			t.Errorf("Test %d: strict: Expected %t for %q, got %t", i+1, testCase.strictResult, testCase.bucketName, result)
+		}
+		globalBucketNameValidation = bucketNameValidationRelaxed
+		if result := IsValidBucketName(testCase.bucketName); result != testCase.relaxedResult {
+			t.Errorf("Test %d: relaxed: Expected %t for %q, got %t", i+1, testCase.relaxedResult, testCase.bucketName, result)
+		}
+	}
+}
+
+// Tests the requests of invalid bucket names are rejected before
+// reaching the API handlers.
+func TestBucketNameValidationHandler(t *testing.T) {
+	handler := setBucketNameValidationHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		method       string
+		path         string
+		expectedCode int
+	}{
+		{"GET", "/", http.StatusOK},
+		{"PUT", "/my-bucket", http.StatusOK},
+		{"PUT", "/my-bucket/object", http.StatusOK},
+		{"GET", "/my-bucket/Object_Name", http.StatusOK},
+		{"PUT", "/MyBucket", http.StatusBadRequest},
+		{"GET", "/my..bucket/object", http.StatusBadRequest},
+		{"GET", "/192.168.1.1/object", http.StatusBadRequest},
+		{"GET", "/ab", http.StatusBadRequest},
+		{"GET", "/" + strings.Repeat("a", 64), http.StatusBadRequest},
+		// Reserved paths of the server are not buckets.
+		{"POST", reservedBucket + "/webrpc", http.StatusOK},
+		{"GET", reservedBucket + "/website/my-bucket/", http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(testCase.method, "http://127.0.0.1:9000"+testCase.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("Test %d: %s %s: Expected status %d, got %d", i+1, testCase.method, testCase.path, testCase.expectedCode, rec.Code)
+		}
+		if rec.Code != http.StatusOK && !bytes.Contains(rec.Body.Bytes(), []byte("<Code>InvalidBucketName</Code>")) {
+			t.Fatalf("Test %d: Expected InvalidBucketName, got %s", i+1, rec.Body.String())
+		}
+	}
+}
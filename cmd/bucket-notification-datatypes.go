@@ -19,6 +19,7 @@ package cmd
 import (
 	"encoding/xml"
 	"errors"
+	"strings"
 )
 
 // Represents the criteria for the filter rule.
@@ -114,6 +115,13 @@ func (eventName EventName) String() string {
 	}
 }
 
+// RecordName - returns the name of the event in the notification
+// records, which unlike the configurations has no "s3:" prefix.
+// For example "ObjectCreated:Put".
+func (eventName EventName) RecordName() string {
+	return strings.TrimPrefix(eventName.String(), "s3:")
+}
+
 // Indentity represents the accessKey who caused the event.
 type identity struct {
 	PrincipalID string `json:"principalId"`
@@ -126,10 +134,11 @@ type bucketMeta struct {
 	ARN           string   `json:"arn"`
 }
 
-// Notification event object metadata.
+// Notification event object metadata, size and ETag are only set for
+// the created objects.
 type objectMeta struct {
 	Key       string `json:"key"`
-	Size      int64  `json:"size,omitempty"`
+	Size      *int64 `json:"size,omitempty"`
 	ETag      string `json:"eTag,omitempty"`
 	VersionID string `json:"versionId,omitempty"`
 	Sequencer string `json:"sequencer"`
@@ -148,6 +157,12 @@ const (
 const (
 	// Response element origin endpoint key.
 	responseOriginEndpointKey = "x-minio-origin-endpoint"
+
+	// Response element key of the host which processed the request.
+	responseHostIDKey = "x-amz-id-2"
+
+	// Request parameter key of the IP address of the client.
+	requestSourceIPAddressKey = "sourceIPAddress"
 )
 
 // Notification event server specific metadata.
@@ -165,7 +180,7 @@ const (
 
 	// Event version number defaulting to the value in S3 spec.
 	// ref: http://docs.aws.amazon.com/AmazonS3/latest/dev/notification-content-structure.html
-	eventVersion = "2.0"
+	eventVersion = "2.1"
 )
 
 // NotificationEvent represents an Amazon an S3 bucket notification event.
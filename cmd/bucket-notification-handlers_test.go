@@ -166,7 +166,7 @@ func TestSendBucketNotification(t *testing.T) {
 	}
 	records := notifications["Records"]
 	for i, rec := range records {
-		if rec.EventName == evTypes[i].String() {
+		if rec.EventName == evTypes[i].RecordName() {
 			continue
 		}
 		t.Errorf("Failed to receive %d event %s", i, evTypes[i].String())
@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests objects inherit the default retention of the bucket unless
+// overridden by the request, and the retention prevents overwrites
+// and deletes.
+func TestBucketObjectLockDefaultRetention(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketObjectLockDefaultRetention, []string{
+		"GetBucketObjectLockConfig", "PutBucketObjectLockConfig", "HeadObject", "PutObject", "DeleteObject",
+	})
+}
+
+func testBucketObjectLockDefaultRetention(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Object lock configs are applied in-memory through the local peer.
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	retentionHeader := func(mode string, retainUntil time.Time) http.Header {
+		header := http.Header{}
+		header.Set(amzObjectLockMode, mode)
+		header.Set(amzObjectLockRetainUntilDate, retainUntil.Format(time.RFC3339))
+		return header
+	}
+	overrideRetainUntil := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+
+	// Retention can not be requested before object lock is enabled.
+	rec := serveRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("data"),
+		retentionHeader(retentionModeCompliance, overrideRetainUntil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+	if rec = serveRequest("GET", getBucketObjectLockURL("", bucketName), nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Invalid configs are rejected.
+	invalidConfigs := []string{
+		`<ObjectLockConfiguration><ObjectLockEnabled>Disabled</ObjectLockEnabled></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>NONE</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode></DefaultRetention></Rule></ObjectLockConfiguration>`,
+		`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>1</Days><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketObjectLockURL("", bucketName), []byte(config), nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	config := `<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>1</Days></DefaultRetention></Rule></ObjectLockConfiguration>`
+	if rec = serveRequest("PUT", getBucketObjectLockURL("", bucketName), []byte(config), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketObjectLockURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var lockConfig ObjectLockConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &lockConfig); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if lockConfig.Rule == nil || lockConfig.Rule.DefaultRetention.Mode != retentionModeGovernance ||
+		lockConfig.Rule.DefaultRetention.Days != 1 {
+		t.Fatalf("%s: Unexpected object lock config %#v", instanceType, lockConfig)
+	}
+
+	// Object inheriting the default retention.
+	before := time.Now().UTC().Truncate(time.Second)
+	if rec = serveRequest("PUT", getPutObjectURL("", bucketName, "inherited"), []byte("data"), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, "inherited"), nil, nil)
+	if mode := rec.Header().Get(amzObjectLockMode); mode != retentionModeGovernance {
+		t.Fatalf("%s: Expected mode %s, got %s", instanceType, retentionModeGovernance, mode)
+	}
+	retainUntil, err := time.Parse(time.RFC3339, rec.Header().Get(amzObjectLockRetainUntilDate))
+	if err != nil {
+		t.Fatalf("%s: Unexpected retain until date %s", instanceType, err)
+	}
+	if retainUntil.Before(before.AddDate(0, 0, 1)) || retainUntil.After(time.Now().UTC().AddDate(0, 0, 1)) {
+		t.Fatalf("%s: Unexpected retain until date %s", instanceType, retainUntil)
+	}
+
+	// Object overriding the default retention.
+	rec = serveRequest("PUT", getPutObjectURL("", bucketName, "overridden"), []byte("data"),
+		retentionHeader(retentionModeCompliance, overrideRetainUntil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, "overridden"), nil, nil)
+	if mode := rec.Header().Get(amzObjectLockMode); mode != retentionModeCompliance {
+		t.Fatalf("%s: Expected mode %s, got %s", instanceType, retentionModeCompliance, mode)
+	}
+	if date := rec.Header().Get(amzObjectLockRetainUntilDate); date != overrideRetainUntil.Format(time.RFC3339) {
+		t.Fatalf("%s: Expected retain until date %s, got %s", instanceType, overrideRetainUntil.Format(time.RFC3339), date)
+	}
+
+	// Retain until dates in the past are rejected.
+	rec = serveRequest("PUT", getPutObjectURL("", bucketName, "past"), []byte("data"),
+		retentionHeader(retentionModeCompliance, time.Now().UTC().Add(-time.Hour)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Retained objects can neither be overwritten nor deleted.
+	if rec = serveRequest("PUT", getPutObjectURL("", bucketName, "inherited"), []byte("new"), nil); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+	for _, object := range []string{"inherited", "overridden"} {
+		if rec = serveRequest("DELETE", getDeleteObjectURL("", bucketName, object), nil, nil); rec.Code != http.StatusForbidden {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+		}
+	}
+
+	// Governance retention can be bypassed, compliance retention can not.
+	bypassHeader := http.Header{}
+	bypassHeader.Set(amzBypassGovernanceRetention, "true")
+	if rec = serveRequest("DELETE", getDeleteObjectURL("", bucketName, "overridden"), nil, bypassHeader); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+	if rec = serveRequest("DELETE", getDeleteObjectURL("", bucketName, "inherited"), nil, bypassHeader); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+}
@@ -0,0 +1,357 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket object lock config name.
+	bucketObjectLockConfig = "object-lock.xml"
+
+	// Only valid value of ObjectLockEnabled.
+	objectLockEnabled = "Enabled"
+
+	// Retention modes, objects under governance retention can be
+	// overwritten or deleted by bypassing the retention explicitly
+	// while objects under compliance retention can not.
+	retentionModeGovernance = "GOVERNANCE"
+	retentionModeCompliance = "COMPLIANCE"
+
+	// Headers setting the retention of an object, they are saved as
+	// is in the metadata of the object.
+	amzObjectLockMode            = "X-Amz-Object-Lock-Mode"
+	amzObjectLockRetainUntilDate = "X-Amz-Object-Lock-Retain-Until-Date"
+	// Header bypassing the governance retention, only valid value is "true".
+	amzBypassGovernanceRetention = "X-Amz-Bypass-Governance-Retention"
+)
+
+// errInvalidObjectLockConfig - object lock config is not valid.
+var errInvalidObjectLockConfig = errors.New("Invalid object lock configuration")
+
+// errNoSuchObjectLockConfig - object lock config is not set on the bucket.
+var errNoSuchObjectLockConfig = errors.New("The object lock configuration does not exist")
+
+// errObjectLocked - object is under retention and can not be
+// overwritten or deleted.
+var errObjectLocked = errors.New("Object is under retention")
+
+// errInvalidObjectRetention - retention requested for the object is not valid.
+var errInvalidObjectRetention = errors.New("Invalid object retention")
+
+// errObjectLockNotEnabled - retention requested for an object of a
+// bucket without object lock.
+var errObjectLockNotEnabled = errors.New("Object lock is not enabled on the bucket")
+
+// DefaultRetention - retention applied to the new objects of the bucket
+// which do not set their own, for the given number of days or years.
+type DefaultRetention struct {
+	Mode  string `xml:"Mode"`
+	Days  int    `xml:"Days,omitempty"`
+	Years int    `xml:"Years,omitempty"`
+}
+
+// ObjectLockRule - rule of the object lock config.
+type ObjectLockRule struct {
+	DefaultRetention DefaultRetention `xml:"DefaultRetention"`
+}
+
+// ObjectLockConfiguration - enables object lock on a bucket, with an
+// optional default retention of its new objects.
+type ObjectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration"`
+	XMLNS             string          `xml:"xmlns,attr,omitempty"`
+	ObjectLockEnabled string          `xml:"ObjectLockEnabled"`
+	Rule              *ObjectLockRule `xml:"Rule,omitempty"`
+}
+
+// isValidRetentionMode - returns true if the retention mode is supported.
+func isValidRetentionMode(mode string) bool {
+	return mode == retentionModeGovernance || mode == retentionModeCompliance
+}
+
+// validateObjectLockConfig - validates object lock is enabled and the
+// default retention has a mode and exactly one of days or years.
+func validateObjectLockConfig(config ObjectLockConfiguration) error {
+	if config.ObjectLockEnabled != objectLockEnabled {
+		return errInvalidObjectLockConfig
+	}
+	if config.Rule == nil {
+		return nil
+	}
+	retention := config.Rule.DefaultRetention
+	if !isValidRetentionMode(retention.Mode) {
+		return errInvalidObjectLockConfig
+	}
+	if retention.Days < 0 || retention.Years < 0 || (retention.Days == 0) == (retention.Years == 0) {
+		return errInvalidObjectLockConfig
+	}
+	return nil
+}
+
+// Variable represents bucket object lock configs in memory.
+var globalBucketObjectLock = newBucketObjectLockConfigs(nil)
+
+// bucketObjectLockConfigs - object lock configs of all the buckets.
+type bucketObjectLockConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of object lock configs indexed by 'bucket'.
+	configs map[string]ObjectLockConfiguration
+}
+
+// newBucketObjectLockConfigs - initializes bucket object lock configs.
+func newBucketObjectLockConfigs(configs map[string]ObjectLockConfiguration) *bucketObjectLockConfigs {
+	if configs == nil {
+		configs = make(map[string]ObjectLockConfiguration)
+	}
+	return &bucketObjectLockConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the object lock config of the bucket, false if not set.
+func (bc *bucketObjectLockConfigs) Get(bucket string) (ObjectLockConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the object lock config of the bucket, nil config removes it.
+func (bc *bucketObjectLockConfigs) Set(bucket string, config *ObjectLockConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsEnabled - returns true if object lock is enabled on the bucket.
+func (bc *bucketObjectLockConfigs) IsEnabled(bucket string) bool {
+	_, ok := bc.Get(bucket)
+	return ok
+}
+
+// Loads all bucket object lock configs from persistent layer.
+func loadAllBucketObjectLockConfigs(objAPI ObjectLayer) (map[string]ObjectLockConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ObjectLockConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketObjectLockConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchObjectLockConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket object lock configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket object lock configs.
+func initBucketObjectLock(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketObjectLockConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket object lock configs.
+	globalBucketObjectLock = newBucketObjectLockConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketObjectLockConfig - reads the object lock config of the bucket.
+func readBucketObjectLockConfig(bucket string, objAPI ObjectLayer) (ObjectLockConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketObjectLockConfig)
+
+	// Acquire a read lock on object lock config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ObjectLockConfiguration{}, errNoSuchObjectLockConfig
+		}
+		errorIf(err, "Unable to load object lock config for the bucket %s.", bucket)
+		return ObjectLockConfiguration{}, errorCause(err)
+	}
+
+	var config ObjectLockConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ObjectLockConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketObjectLockConfig - saves the object lock config of the
+// bucket, nil config removes any previously saved config.
+func writeBucketObjectLockConfig(bucket string, config *ObjectLockConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketObjectLockConfig)
+
+	// Acquire a write lock on object lock config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove object lock config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set object lock config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketObjectLockChange - persists the object lock
+// config of the bucket and notifies all the nodes in the cluster to
+// update their in-memory state.
+func persistAndNotifyBucketObjectLockChange(bucket string, config *ObjectLockConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketObjectLockConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketObjectLock(bucket, config)
+	return nil
+}
+
+// getDefaultRetainUntil - returns the retain until date of the default
+// retention starting at the given time.
+func getDefaultRetainUntil(retention DefaultRetention, now time.Time) time.Time {
+	return now.AddDate(retention.Years, 0, retention.Days)
+}
+
+// setObjectRetentionMetadata - saves the retention of a new object of
+// the bucket in its metadata. The retention set by the request headers
+// overrides the default retention of the bucket, whose retain until
+// date is computed from the time of the write.
+func setObjectRetentionMetadata(bucket string, header http.Header, metadata map[string]string) error {
+	mode := header.Get(amzObjectLockMode)
+	retainUntilDate := header.Get(amzObjectLockRetainUntilDate)
+
+	config, ok := globalBucketObjectLock.Get(bucket)
+	if mode == "" && retainUntilDate == "" {
+		if ok && config.Rule != nil {
+			retention := config.Rule.DefaultRetention
+			retainUntil := getDefaultRetainUntil(retention, time.Now().UTC())
+			metadata[amzObjectLockMode] = retention.Mode
+			metadata[amzObjectLockRetainUntilDate] = retainUntil.Format(time.RFC3339)
+		}
+		return nil
+	}
+	if !ok {
+		return errObjectLockNotEnabled
+	}
+	if !isValidRetentionMode(mode) {
+		return errInvalidObjectRetention
+	}
+	retainUntil, err := time.Parse(time.RFC3339, retainUntilDate)
+	if err != nil || !retainUntil.After(time.Now().UTC()) {
+		return errInvalidObjectRetention
+	}
+	metadata[amzObjectLockMode] = mode
+	metadata[amzObjectLockRetainUntilDate] = retainUntil.UTC().Format(time.RFC3339)
+	return nil
+}
+
+// removeObjectRetentionMetadata - removes the retention from the
+// metadata, copies do not inherit the retention of their source.
+func removeObjectRetentionMetadata(metadata map[string]string) {
+	delete(metadata, amzObjectLockMode)
+	delete(metadata, amzObjectLockRetainUntilDate)
+}
+
+// isObjectRetained - returns true if the retention saved in the metadata
+// of the object has not expired. Governance retention is not enforced
+// if bypassed.
+func isObjectRetained(metadata map[string]string, bypassGovernance bool, now time.Time) bool {
+	mode := metadata[amzObjectLockMode]
+	if mode == "" || (mode == retentionModeGovernance && bypassGovernance) {
+		return false
+	}
+	retainUntil, err := time.Parse(time.RFC3339, metadata[amzObjectLockRetainUntilDate])
+	if err != nil {
+		return false
+	}
+	return retainUntil.After(now)
+}
+
+// isGovernanceBypassed - returns true if the request bypasses the
+// governance retention, anonymous requests can not bypass it.
+func isGovernanceBypassed(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get(amzBypassGovernanceRetention), "true") &&
+		getRequestAuthType(r) != authTypeAnonymous
+}
+
+// enforceObjectRetention - returns errObjectLocked if the object exists
+// and is under retention, it can neither be overwritten nor deleted.
+// Must be called with the object lock held.
+func enforceObjectRetention(objAPI ObjectLayer, bucket, object string, r *http.Request) error {
+	if !globalBucketObjectLock.IsEnabled(bucket) {
+		return nil
+	}
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if isObjectRetained(objInfo.UserDefined, isGovernanceBypassed(r), time.Now().UTC()) {
+		return errObjectLocked
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/iotest"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests uploads out of the object size limits of the bucket are
+// rejected, for single and multipart uploads.
+func TestBucketObjectSizeLimitsHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketObjectSizeLimitsHandlers, []string{
+		"GetBucketObjectSizeLimits", "PutBucketObjectSizeLimits", "DeleteBucketObjectSizeLimits",
+		"PutObject", "PutObjectPart", "CompleteMultipart",
+	})
+}
+
+func testBucketObjectSizeLimitsHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Object size limits configs are applied in-memory through the local peer.
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	serveStreamingRequest := func(urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestStreamingSignedRequest("PUT", urlStr, int64(len(body)), 64*humanize.KiByte,
+			bytes.NewReader(body), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	completeUpload := func(object string, partSizes ...int64) *httptest.ResponseRecorder {
+		uploadID, err := obj.NewMultipartUpload(bucketName, object, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to start multipart upload: <ERROR> %v", instanceType, err)
+		}
+		var parts []completePart
+		for i, size := range partSizes {
+			data := bytes.Repeat([]byte("a"), int(size))
+			partInfo, pErr := obj.PutObjectPart(bucketName, object, uploadID, i+1, size, bytes.NewReader(data), "", "")
+			if pErr != nil {
+				t.Fatalf("%s: Failed to upload part: <ERROR> %v", instanceType, pErr)
+			}
+			parts = append(parts, completePart{PartNumber: i + 1, ETag: partInfo.ETag})
+		}
+		completeBytes, err := xml.Marshal(&completeMultipartUpload{Parts: parts})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return serveRequest("POST", getCompleteMultipartUploadURL("", bucketName, object, uploadID), completeBytes)
+	}
+	expectCode := func(testName string, rec *httptest.ResponseRecorder, code int) {
+		if rec.Code != code {
+			t.Fatalf("%s: %s: Expected status %d, got %d: %s", instanceType, testName, code, rec.Code, rec.Body.String())
+		}
+	}
+	expectError := func(testName string, rec *httptest.ResponseRecorder, errCode APIErrorCode) {
+		apiErr := getAPIError(errCode)
+		expectCode(testName, rec, apiErr.HTTPStatusCode)
+		if !bytes.Contains(rec.Body.Bytes(), []byte("<Code>"+apiErr.Code+"</Code>")) {
+			t.Fatalf("%s: %s: Expected error %s, got %s", instanceType, testName, apiErr.Code, rec.Body.String())
+		}
+	}
+
+	// No limits by default.
+	expectCode("get unset", serveRequest("GET", getBucketObjectSizeLimitsURL("", bucketName), nil), http.StatusNotFound)
+
+	// Invalid configs are rejected.
+	invalidConfigs := []string{
+		`<ObjectSizeLimitsConfiguration></ObjectSizeLimitsConfiguration>`,
+		`<ObjectSizeLimitsConfiguration><MinSize>-1</MinSize></ObjectSizeLimitsConfiguration>`,
+		`<ObjectSizeLimitsConfiguration><MinSize>10</MinSize><MaxSize>5</MaxSize></ObjectSizeLimitsConfiguration>`,
+	}
+	for _, config := range invalidConfigs {
+		expectCode(config, serveRequest("PUT", getBucketObjectSizeLimitsURL("", bucketName), []byte(config)), http.StatusBadRequest)
+	}
+
+	maxSize := int64(6 * humanize.MiByte)
+	config := `<ObjectSizeLimitsConfiguration><MinSize>10</MinSize><MaxSize>6291456</MaxSize></ObjectSizeLimitsConfiguration>`
+	expectCode("put config", serveRequest("PUT", getBucketObjectSizeLimitsURL("", bucketName), []byte(config)), http.StatusOK)
+	rec := serveRequest("GET", getBucketObjectSizeLimitsURL("", bucketName), nil)
+	expectCode("get config", rec, http.StatusOK)
+	var limitsConfig ObjectSizeLimitsConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &limitsConfig); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if limitsConfig.MinSize != 10 || limitsConfig.MaxSize != maxSize {
+		t.Fatalf("%s: Unexpected object size limits config %#v", instanceType, limitsConfig)
+	}
+
+	// Single uploads.
+	small := []byte("small")
+	inRange := []byte("within the size limits")
+	large := bytes.Repeat([]byte("a"), int(maxSize)+1)
+	expectError("put under min", serveRequest("PUT", getPutObjectURL("", bucketName, "small"), small), ErrEntityTooSmall)
+	expectError("put over max", serveRequest("PUT", getPutObjectURL("", bucketName, "large"), large), ErrEntityTooLarge)
+	expectCode("put in range", serveRequest("PUT", getPutObjectURL("", bucketName, "in-range"), inRange), http.StatusOK)
+	expectError("streaming put under min", serveStreamingRequest(getPutObjectURL("", bucketName, "small"), small), ErrEntityTooSmall)
+	expectError("streaming put over max", serveStreamingRequest(getPutObjectURL("", bucketName, "large"), large), ErrEntityTooLarge)
+	expectCode("streaming put in range", serveStreamingRequest(getPutObjectURL("", bucketName, "streamed"), inRange), http.StatusOK)
+	for _, object := range []string{"small", "large"} {
+		if _, err := obj.GetObjectInfo(bucketName, object); !isErrObjectNotFound(err) {
+			t.Fatalf("%s: Expected object %s not to be created, got %v", instanceType, object, err)
+		}
+	}
+
+	// A part alone can not exceed the maximum size.
+	uploadID, err := obj.NewMultipartUpload(bucketName, "part", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectError("part over max", serveRequest("PUT", getPutObjectPartURL("", bucketName, "part", uploadID, "1"), large), ErrEntityTooLarge)
+
+	// Multipart uploads are checked on completion.
+	expectError("complete under min", completeUpload("multipart-small", 5), ErrEntityTooSmall)
+	expectError("complete over max", completeUpload("multipart-large", 5*humanize.MiByte, 2*humanize.MiByte), ErrEntityTooLarge)
+	expectCode("complete in range", completeUpload("multipart", 5*humanize.MiByte, humanize.MiByte), http.StatusOK)
+
+	// Any size is accepted once the limits are removed.
+	expectCode("delete config", serveRequest("DELETE", getBucketObjectSizeLimitsURL("", bucketName), nil), http.StatusNoContent)
+	expectCode("put unlimited", serveRequest("PUT", getPutObjectURL("", bucketName, "small"), small), http.StatusOK)
+}
+
+// countingReader - counts the bytes read from the underlying reader.
+type countingReader struct {
+	io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Tests the object size limits are enforced as the data is read, the
+// read is aborted as soon as the maximum size is exceeded.
+func TestObjectSizeLimitsReader(t *testing.T) {
+	bucket := "size-limits-reader"
+	globalBucketObjectSizeLimits.Set(bucket, &ObjectSizeLimitsConfiguration{MinSize: 10, MaxSize: 100})
+	defer globalBucketObjectSizeLimits.Set(bucket, nil)
+
+	testCases := []struct {
+		size        int
+		isPart      bool
+		expectedErr error
+	}{
+		{size: 5, isPart: false, expectedErr: errDataTooSmall},
+		{size: 5, isPart: true, expectedErr: nil},
+		{size: 50, isPart: false, expectedErr: nil},
+		{size: 100, isPart: false, expectedErr: nil},
+		{size: 1000, isPart: false, expectedErr: errDataTooLarge},
+		{size: 1000, isPart: true, expectedErr: errDataTooLarge},
+	}
+	for i, testCase := range testCases {
+		source := &countingReader{Reader: bytes.NewReader(bytes.Repeat([]byte("a"), testCase.size))}
+		reader := newObjectSizeLimitsReader(bucket, iotest.OneByteReader(source), testCase.isPart)
+		if _, err := ioutil.ReadAll(reader); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		// The upload is aborted right after the maximum size.
+		if testCase.expectedErr == errDataTooLarge && source.n != 101 {
+			t.Errorf("Test %d: Expected the read to abort after 101 bytes, read %d", i+1, source.n)
+		}
+	}
+
+	// Buckets without limits are not wrapped.
+	source := bytes.NewReader(nil)
+	if reader := newObjectSizeLimitsReader("no-limits", source, false); reader != source {
+		t.Errorf("Expected the reader of a bucket without limits to be unchanged")
+	}
+}
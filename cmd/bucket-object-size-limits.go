@@ -0,0 +1,290 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path"
+	"sync"
+)
+
+// Bucket object size limits config name.
+const bucketObjectSizeLimitsConfig = "object-size-limits.xml"
+
+// errInvalidObjectSizeLimitsConfig - object size limits config is not valid.
+var errInvalidObjectSizeLimitsConfig = errors.New("Invalid object size limits configuration")
+
+// errNoSuchObjectSizeLimitsConfig - object size limits config is not set on the bucket.
+var errNoSuchObjectSizeLimitsConfig = errors.New("The object size limits configuration does not exist")
+
+// ObjectSizeLimitsConfiguration - minimum and maximum size of the
+// objects written to a bucket, a zero size is not limited.
+type ObjectSizeLimitsConfiguration struct {
+	XMLName xml.Name `xml:"ObjectSizeLimitsConfiguration"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	MinSize int64    `xml:"MinSize,omitempty"`
+	MaxSize int64    `xml:"MaxSize,omitempty"`
+}
+
+// validateObjectSizeLimitsConfig - validates at least one of the sizes
+// is limited and the minimum size does not exceed the maximum size.
+func validateObjectSizeLimitsConfig(config ObjectSizeLimitsConfiguration) error {
+	if config.MinSize < 0 || config.MaxSize < 0 || (config.MinSize == 0 && config.MaxSize == 0) {
+		return errInvalidObjectSizeLimitsConfig
+	}
+	if config.MaxSize > 0 && config.MinSize > config.MaxSize {
+		return errInvalidObjectSizeLimitsConfig
+	}
+	return nil
+}
+
+// Variable represents bucket object size limits configs in memory.
+var globalBucketObjectSizeLimits = newBucketObjectSizeLimitsConfigs(nil)
+
+// bucketObjectSizeLimitsConfigs - object size limits configs of all the buckets.
+type bucketObjectSizeLimitsConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of object size limits configs indexed by 'bucket'.
+	configs map[string]ObjectSizeLimitsConfiguration
+}
+
+// newBucketObjectSizeLimitsConfigs - initializes bucket object size limits configs.
+func newBucketObjectSizeLimitsConfigs(configs map[string]ObjectSizeLimitsConfiguration) *bucketObjectSizeLimitsConfigs {
+	if configs == nil {
+		configs = make(map[string]ObjectSizeLimitsConfiguration)
+	}
+	return &bucketObjectSizeLimitsConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the object size limits config of the bucket, false if not set.
+func (bc *bucketObjectSizeLimitsConfigs) Get(bucket string) (ObjectSizeLimitsConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the object size limits config of the bucket, nil config removes it.
+func (bc *bucketObjectSizeLimitsConfigs) Set(bucket string, config *ObjectSizeLimitsConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// Loads all bucket object size limits configs from persistent layer.
+func loadAllBucketObjectSizeLimitsConfigs(objAPI ObjectLayer) (map[string]ObjectSizeLimitsConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ObjectSizeLimitsConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketObjectSizeLimitsConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchObjectSizeLimitsConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket object size limits configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket object size limits configs.
+func initBucketObjectSizeLimits(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketObjectSizeLimitsConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket object size limits configs.
+	globalBucketObjectSizeLimits = newBucketObjectSizeLimitsConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketObjectSizeLimitsConfig - reads the object size limits config of the bucket.
+func readBucketObjectSizeLimitsConfig(bucket string, objAPI ObjectLayer) (ObjectSizeLimitsConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketObjectSizeLimitsConfig)
+
+	// Acquire a read lock on object size limits config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ObjectSizeLimitsConfiguration{}, errNoSuchObjectSizeLimitsConfig
+		}
+		errorIf(err, "Unable to load object size limits config for the bucket %s.", bucket)
+		return ObjectSizeLimitsConfiguration{}, errorCause(err)
+	}
+
+	var config ObjectSizeLimitsConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ObjectSizeLimitsConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketObjectSizeLimitsConfig - saves the object size limits
+// config of the bucket, nil config removes any previously saved config.
+func writeBucketObjectSizeLimitsConfig(bucket string, config *ObjectSizeLimitsConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketObjectSizeLimitsConfig)
+
+	// Acquire a write lock on object size limits config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove object size limits config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set object size limits config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketObjectSizeLimitsChange - persists the object
+// size limits config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketObjectSizeLimitsChange(bucket string, config *ObjectSizeLimitsConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketObjectSizeLimitsConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketObjectSizeLimits(bucket, config)
+	return nil
+}
+
+// checkObjectSizeLimits - returns errDataTooSmall or errDataTooLarge if
+// the size of an object is out of the limits of the bucket.
+func checkObjectSizeLimits(bucket string, size int64) error {
+	config, ok := globalBucketObjectSizeLimits.Get(bucket)
+	if !ok {
+		return nil
+	}
+	if size < config.MinSize {
+		return errDataTooSmall
+	}
+	if config.MaxSize > 0 && size > config.MaxSize {
+		return errDataTooLarge
+	}
+	return nil
+}
+
+// checkPartSizeLimits - returns errDataTooLarge if a part alone
+// exceeds the maximum object size of the bucket.
+func checkPartSizeLimits(bucket string, size int64) error {
+	config, ok := globalBucketObjectSizeLimits.Get(bucket)
+	if ok && config.MaxSize > 0 && size > config.MaxSize {
+		return errDataTooLarge
+	}
+	return nil
+}
+
+// newObjectSizeLimitsReader - enforces the object size limits of the
+// bucket as the data is read, the read fails as soon as the maximum
+// size is exceeded. The minimum size is only enforced for whole
+// objects, not for parts.
+func newObjectSizeLimitsReader(bucket string, reader io.Reader, isPart bool) io.Reader {
+	config, ok := globalBucketObjectSizeLimits.Get(bucket)
+	if !ok || (isPart && config.MaxSize == 0) {
+		return reader
+	}
+	limitsReader := &rangeReader{Reader: reader, Max: config.MaxSize}
+	if config.MaxSize == 0 {
+		limitsReader.Max = globalMaxObjectSize
+	}
+	if !isPart {
+		limitsReader.Min = config.MinSize
+	}
+	return limitsReader
+}
+
+// checkMultipartSizeLimits - returns errDataTooSmall or errDataTooLarge
+// if the object completed from the parts is larger than the maximum
+// size of the multipart objects, or out of the limits of the bucket.
+func checkMultipartSizeLimits(objAPI ObjectLayer, bucket, object, uploadID string, parts []completePart) error {
+	// Parts are only listed if the object may be out of the limits,
+	// each part is at most the maximum object size.
+	_, ok := globalBucketObjectSizeLimits.Get(bucket)
+	if !ok && int64(len(parts))*globalMaxObjectSize <= globalMaxMultipartObjectSize {
+		return nil
+	}
+
+	partSizes := make(map[int]int64)
+	partNumberMarker := 0
+	for {
+		listPartsInfo, err := objAPI.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxPartsList)
+		if err != nil {
+			return err
+		}
+		for _, part := range listPartsInfo.Parts {
+			partSizes[part.PartNumber] = part.Size
+		}
+		if !listPartsInfo.IsTruncated {
+			break
+		}
+		partNumberMarker = listPartsInfo.NextPartNumberMarker
+	}
+
+	// Parts which were not uploaded are reported by the completion.
+	var size int64
+	for _, part := range parts {
+		size += partSizes[part.PartNumber]
+	}
+	if size > globalMaxMultipartObjectSize {
+		return errDataTooLarge
+	}
+	return checkObjectSizeLimits(bucket, size)
+}
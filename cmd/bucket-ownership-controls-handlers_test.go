@@ -0,0 +1,153 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the ownership controls are saved and the ACLs of the writes are
+// rejected once disabled by BucketOwnerEnforced.
+func TestBucketOwnershipControlsHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketOwnershipControlsHandlers, []string{
+		"GetBucketOwnershipControls", "PutBucketOwnershipControls", "DeleteBucketOwnershipControls",
+		"PutBucketACL", "PutObject", "NewMultipart",
+	})
+}
+
+func testBucketOwnershipControlsHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Configs and policies are applied in-memory through the local peer.
+	initBucketPolicies(obj)
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectCode := func(testName string, rec *httptest.ResponseRecorder, code int) {
+		if rec.Code != code {
+			t.Fatalf("%s: %s: Expected status %d, got %d: %s", instanceType, testName, code, rec.Code, rec.Body.String())
+		}
+	}
+	configXML := func(ownership string) []byte {
+		return []byte(fmt.Sprintf(`<OwnershipControls><Rule><ObjectOwnership>%s</ObjectOwnership></Rule></OwnershipControls>`, ownership))
+	}
+	aclHeader := func(cannedACL string) http.Header {
+		if cannedACL == "" {
+			return nil
+		}
+		return http.Header{"X-Amz-Acl": []string{cannedACL}}
+	}
+
+	// No ownership controls by default.
+	rec := serveRequest("GET", getBucketOwnershipControlsURL("", bucketName), nil, nil)
+	expectCode("get unset", rec, http.StatusNotFound)
+	if !bytes.Contains(rec.Body.Bytes(), []byte("<Code>OwnershipControlsNotFoundError</Code>")) {
+		t.Fatalf("%s: Expected OwnershipControlsNotFoundError, got %s", instanceType, rec.Body.String())
+	}
+
+	// Malformed and invalid configs are rejected.
+	for _, config := range [][]byte{
+		[]byte("<OwnershipControls>"),
+		[]byte("<OwnershipControls></OwnershipControls>"),
+		configXML("Nobody"),
+		[]byte("<OwnershipControls><Rule><ObjectOwnership>ObjectWriter</ObjectOwnership></Rule><Rule><ObjectOwnership>ObjectWriter</ObjectOwnership></Rule></OwnershipControls>"),
+	} {
+		expectCode("invalid config "+string(config), serveRequest("PUT", getBucketOwnershipControlsURL("", bucketName), config, nil), http.StatusBadRequest)
+	}
+
+	testCases := []struct {
+		ownership    string
+		cannedACL    string
+		expectedCode int
+	}{
+		{objectOwnershipObjectWriter, "", http.StatusOK},
+		{objectOwnershipObjectWriter, "public-read", http.StatusOK},
+		{objectOwnershipObjectWriter, cannedACLBucketOwnerFullControl, http.StatusOK},
+		{objectOwnershipBucketOwnerPreferred, "", http.StatusOK},
+		{objectOwnershipBucketOwnerPreferred, "public-read", http.StatusOK},
+		{objectOwnershipBucketOwnerPreferred, cannedACLBucketOwnerFullControl, http.StatusOK},
+		// ACLs are disabled, only the full control of the bucket owner
+		// is accepted.
+		{objectOwnershipBucketOwnerEnforced, "", http.StatusOK},
+		{objectOwnershipBucketOwnerEnforced, "public-read", http.StatusBadRequest},
+		{objectOwnershipBucketOwnerEnforced, "private", http.StatusBadRequest},
+		{objectOwnershipBucketOwnerEnforced, cannedACLBucketOwnerFullControl, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		testName := fmt.Sprintf("Test %d: %s %s", i+1, testCase.ownership, testCase.cannedACL)
+		expectCode(testName+": put config", serveRequest("PUT", getBucketOwnershipControlsURL("", bucketName), configXML(testCase.ownership), nil), http.StatusOK)
+
+		rec = serveRequest("GET", getBucketOwnershipControlsURL("", bucketName), nil, nil)
+		expectCode(testName+": get config", rec, http.StatusOK)
+		var config OwnershipControls
+		if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+			t.Fatalf("%s: %s: Unexpected XML received %s", instanceType, testName, err)
+		}
+		if len(config.Rules) != 1 || config.Rules[0].ObjectOwnership != testCase.ownership {
+			t.Fatalf("%s: %s: Unexpected ownership controls %#v", instanceType, testName, config)
+		}
+
+		object := fmt.Sprintf("object-%d", i+1)
+		rec = serveRequest("PUT", getPutObjectURL("", bucketName, object), []byte("hello"), aclHeader(testCase.cannedACL))
+		expectCode(testName+": put object", rec, testCase.expectedCode)
+		rec = serveRequest("POST", getNewMultipartURL("", bucketName, object), nil, aclHeader(testCase.cannedACL))
+		expectCode(testName+": new multipart", rec, testCase.expectedCode)
+		if testCase.expectedCode != http.StatusOK {
+			if !bytes.Contains(rec.Body.Bytes(), []byte("<Code>AccessControlListNotSupported</Code>")) {
+				t.Fatalf("%s: %s: Expected AccessControlListNotSupported, got %s", instanceType, testName, rec.Body.String())
+			}
+			// Nothing is written.
+			if _, err := obj.GetObjectInfo(bucketName, object); !isErrObjectNotFound(err) {
+				t.Fatalf("%s: %s: Expected the object not to be written, got %v", instanceType, testName, err)
+			}
+			continue
+		}
+		// Objects are always owned by the bucket owner.
+		if _, err := obj.GetObjectInfo(bucketName, object); err != nil {
+			t.Fatalf("%s: %s: Expected the object to be written, got %v", instanceType, testName, err)
+		}
+	}
+
+	// Bucket ACLs granting others are rejected as well, the private ACL
+	// is still accepted.
+	expectCode("enforced public bucket acl", serveRequest("PUT", getBucketACLURL("", bucketName), nil, aclHeader("public-read")), http.StatusBadRequest)
+	expectCode("enforced private bucket acl", serveRequest("PUT", getBucketACLURL("", bucketName), nil, aclHeader("private")), http.StatusOK)
+
+	// ACLs are accepted again once the ownership controls are removed.
+	expectCode("delete config", serveRequest("DELETE", getBucketOwnershipControlsURL("", bucketName), nil, nil), http.StatusNoContent)
+	expectCode("get deleted", serveRequest("GET", getBucketOwnershipControlsURL("", bucketName), nil, nil), http.StatusNotFound)
+	expectCode("public bucket acl", serveRequest("PUT", getBucketACLURL("", bucketName), nil, aclHeader("public-read")), http.StatusOK)
+	expectCode("public object acl", serveRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("hello"), aclHeader("public-read")), http.StatusOK)
+}
@@ -0,0 +1,263 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"path"
+	"sync"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+const (
+	// Bucket ownership controls config name.
+	bucketOwnershipControlsConfig = "ownership-controls.xml"
+
+	// Object ownership values.
+	objectOwnershipBucketOwnerEnforced  = "BucketOwnerEnforced"
+	objectOwnershipBucketOwnerPreferred = "BucketOwnerPreferred"
+	objectOwnershipObjectWriter         = "ObjectWriter"
+
+	// Canned ACL giving the bucket owner full control of an object,
+	// the only ACL accepted on the writes once ACLs are disabled.
+	cannedACLBucketOwnerFullControl = "bucket-owner-full-control"
+)
+
+// errInvalidOwnershipControlsConfig - ownership controls config is not valid.
+var errInvalidOwnershipControlsConfig = errors.New("Invalid ownership controls configuration")
+
+// errNoSuchOwnershipControlsConfig - ownership controls config is not set on the bucket.
+var errNoSuchOwnershipControlsConfig = errors.New("The bucket ownership controls were not found")
+
+// errACLNotSupported - the request sets an ACL while ACLs are disabled
+// by the ownership controls of the bucket.
+var errACLNotSupported = errors.New("The bucket does not allow ACLs")
+
+// OwnershipControlsRule - object ownership of the bucket.
+type OwnershipControlsRule struct {
+	ObjectOwnership string `xml:"ObjectOwnership"`
+}
+
+// OwnershipControls - controls the ownership of the objects written to
+// a bucket and whether ACLs apply to them. The server has a single
+// account owning every bucket and object, so the objects are always
+// owned by the bucket owner and the ownership only decides whether the
+// ACLs are accepted. BucketOwnerEnforced disables the ACLs, the writes
+// setting an ACL other than bucket-owner-full-control are rejected.
+type OwnershipControls struct {
+	XMLName xml.Name                `xml:"OwnershipControls"`
+	XMLNS   string                  `xml:"xmlns,attr,omitempty"`
+	Rules   []OwnershipControlsRule `xml:"Rule"`
+}
+
+// validateOwnershipControlsConfig - validates the config holds a single
+// rule with a known object ownership.
+func validateOwnershipControlsConfig(config OwnershipControls) error {
+	if len(config.Rules) != 1 {
+		return errInvalidOwnershipControlsConfig
+	}
+	switch config.Rules[0].ObjectOwnership {
+	case objectOwnershipBucketOwnerEnforced, objectOwnershipBucketOwnerPreferred, objectOwnershipObjectWriter:
+		return nil
+	}
+	return errInvalidOwnershipControlsConfig
+}
+
+// Variable represents bucket ownership controls configs in memory.
+var globalBucketOwnershipControls = newBucketOwnershipControlsConfigs(nil)
+
+// bucketOwnershipControlsConfigs - ownership controls configs of all the buckets.
+type bucketOwnershipControlsConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of ownership controls configs indexed by 'bucket'.
+	configs map[string]OwnershipControls
+}
+
+// newBucketOwnershipControlsConfigs - initializes bucket ownership controls configs.
+func newBucketOwnershipControlsConfigs(configs map[string]OwnershipControls) *bucketOwnershipControlsConfigs {
+	if configs == nil {
+		configs = make(map[string]OwnershipControls)
+	}
+	return &bucketOwnershipControlsConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the ownership controls config of the bucket, false if not set.
+func (bc *bucketOwnershipControlsConfigs) Get(bucket string) (OwnershipControls, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the ownership controls config of the bucket, nil config removes it.
+func (bc *bucketOwnershipControlsConfigs) Set(bucket string, config *OwnershipControls) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsACLDisabled - returns true if the ACLs are disabled on the bucket,
+// its object ownership being BucketOwnerEnforced.
+func (bc *bucketOwnershipControlsConfigs) IsACLDisabled(bucket string) bool {
+	config, ok := bc.Get(bucket)
+	return ok && len(config.Rules) > 0 && config.Rules[0].ObjectOwnership == objectOwnershipBucketOwnerEnforced
+}
+
+// Loads all bucket ownership controls configs from persistent layer.
+func loadAllBucketOwnershipControlsConfigs(objAPI ObjectLayer) (map[string]OwnershipControls, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]OwnershipControls)
+	for _, bucket := range buckets {
+		config, cErr := readBucketOwnershipControlsConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchOwnershipControlsConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket ownership controls configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket ownership controls configs.
+func initBucketOwnershipControls(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketOwnershipControlsConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket ownership controls configs.
+	globalBucketOwnershipControls = newBucketOwnershipControlsConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketOwnershipControlsConfig - reads the ownership controls config of the bucket.
+func readBucketOwnershipControlsConfig(bucket string, objAPI ObjectLayer) (OwnershipControls, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketOwnershipControlsConfig)
+
+	// Acquire a read lock on ownership controls config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return OwnershipControls{}, errNoSuchOwnershipControlsConfig
+		}
+		errorIf(err, "Unable to load ownership controls config for the bucket %s.", bucket)
+		return OwnershipControls{}, errorCause(err)
+	}
+
+	var config OwnershipControls
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return OwnershipControls{}, err
+	}
+	return config, nil
+}
+
+// writeBucketOwnershipControlsConfig - saves the ownership controls
+// config of the bucket, nil config removes any previously saved config.
+func writeBucketOwnershipControlsConfig(bucket string, config *OwnershipControls, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketOwnershipControlsConfig)
+
+	// Acquire a write lock on ownership controls config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove ownership controls config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set ownership controls config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketOwnershipControlsChange - persists the
+// ownership controls config of the bucket and notifies all the nodes in
+// the cluster to update their in-memory state.
+func persistAndNotifyBucketOwnershipControlsChange(bucket string, config *OwnershipControls, objAPI ObjectLayer) error {
+	if err := writeBucketOwnershipControlsConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketOwnershipControls(bucket, config)
+	return nil
+}
+
+// checkBucketACLAllowed - returns errACLNotSupported if the canned
+// policy of a bucket ACL grants anything but the full control of the
+// bucket owner while ACLs are disabled on the bucket.
+func checkBucketACLAllowed(bucket string, bucketPolicy policy.BucketPolicy) error {
+	if globalBucketOwnershipControls.IsACLDisabled(bucket) && bucketPolicy != policy.BucketPolicyNone {
+		return errACLNotSupported
+	}
+	return nil
+}
+
+// checkObjectACLAllowed - returns errACLNotSupported if a write sets a
+// canned ACL other than bucket-owner-full-control while ACLs are
+// disabled on the bucket. The object ACLs are not stored otherwise,
+// the objects being owned by the bucket owner whatever the ownership.
+func checkObjectACLAllowed(bucket, cannedACL string) error {
+	if cannedACL == "" || cannedACL == cannedACLBucketOwnerFullControl {
+		return nil
+	}
+	if globalBucketOwnershipControls.IsACLDisabled(bucket) {
+		return errACLNotSupported
+	}
+	return nil
+}
@@ -165,6 +165,34 @@ func bucketPolicyConditionMatch(conditions map[string]set.StringSet, statement p
 	return true
 }
 
+// isBucketPolicyPublic - returns true if the policy allows any action
+// to anonymous users. Statements with conditions restrict the access
+// unless the conditions match everything.
+func isBucketPolicyPublic(policy *bucketPolicy) bool {
+	for _, statement := range policy.Statements {
+		if statement.Effect != "Allow" || !parsePrincipals(statement.Principal).Contains("*") {
+			continue
+		}
+		if !isPolicyConditionRestrictive(statement.Conditions) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPolicyConditionRestrictive - returns false if there are no
+// conditions or all the conditions are StringLike matching any value.
+func isPolicyConditionRestrictive(conditions map[string]map[string]set.StringSet) bool {
+	for condition, conditionKeyVal := range conditions {
+		for _, values := range conditionKeyVal {
+			if condition != "StringLike" || !values.Equals(set.CreateStringSet("*")) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // PutBucketPolicyHandler - PUT Bucket policy
 // -----------------
 // This implementation of the PUT operation uses the policy
@@ -214,6 +242,12 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// Public policies are rejected if blocked on the bucket.
+	if err = checkPublicPolicyAllowed(bucket, policyBytes); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Parse validate and save bucket policy.
 	if s3Error := parseAndPersistBucketPolicy(bucket, policyBytes, objAPI); s3Error != ErrNone {
 		writeErrorResponse(w, s3Error, r.URL)
@@ -310,3 +344,47 @@ func (api objectAPIHandlers) GetBucketPolicyHandler(w http.ResponseWriter, r *ht
 	// Write to client.
 	fmt.Fprint(w, policy)
 }
+
+// GetBucketPolicyStatusHandler - GET Bucket policy status
+// -----------------
+// This operation uses the policyStatus subresource to return whether
+// the bucket is public based on its policy.
+func (api objectAPIHandlers) GetBucketPolicyStatusHandler(w http.ResponseWriter, r *http.Request) {
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, "", "", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	// Before proceeding validate if bucket exists.
+	_, err := objAPI.GetBucketInfo(bucket)
+	if err != nil {
+		errorIf(err, "Unable to find bucket info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Buckets without a policy are private.
+	policyStatus := PolicyStatus{}
+	policy, err := readBucketPolicy(bucket, objAPI)
+	if err != nil {
+		if _, ok := err.(BucketPolicyNotFound); !ok {
+			errorIf(err, "Unable to read bucket policy.")
+			writeErrorResponse(w, ErrInternalError, r.URL)
+			return
+		}
+	} else {
+		policyStatus.IsPublic = isBucketPolicyPublic(policy)
+	}
+
+	// Write to client.
+	writeSuccessResponseXML(w, encodeResponse(policyStatus))
+}
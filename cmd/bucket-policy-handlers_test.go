@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/xml"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -636,6 +637,85 @@ func testGetBucketPolicyHandler(obj ObjectLayer, instanceType, bucketName string
 	ExecObjectLayerAPINilTest(t, nilBucket, "", instanceType, apiRouter, nilReq)
 }
 
+// Wrapper for calling Get Bucket Policy Status HTTP handler tests for both XL multiple disks and single node setup.
+func TestGetBucketPolicyStatusHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testGetBucketPolicyStatusHandler, []string{"PutBucketPolicy", "GetBucketPolicyStatus"})
+}
+
+// testGetBucketPolicyStatusHandler - Test for end point which reports whether the bucket is public.
+func testGetBucketPolicyStatusHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// initialize bucket policy.
+	initBucketPolicies(obj)
+
+	testCases := []struct {
+		bucketPolicy string
+		// expected output.
+		expectedIsPublic bool
+	}{
+		// Test case - 1.
+		// Bucket without a policy is private.
+		{"", false},
+		// Test case - 2.
+		// Public read policy.
+		{`{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::%s/*"],"Sid":""}]}`, true},
+		// Test case - 3.
+		// Listing restricted to a prefix by a condition.
+		{`{"Version":"2012-10-17","Statement":[{"Action":["s3:ListBucket"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::%s"],"Condition":{"StringEquals":{"s3:prefix":["public"]}},"Sid":""}]}`, false},
+		// Test case - 4.
+		// Condition matching any referer does not restrict access.
+		{`{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":"*","Resource":["arn:aws:s3:::%s/*"],"Condition":{"StringLike":{"aws:Referer":["*"]}},"Sid":""}]}`, true},
+		// Test case - 5.
+		// Access denied to everyone.
+		{`{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Deny","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::%s/*"],"Sid":""}]}`, false},
+	}
+	for i, testCase := range testCases {
+		if testCase.bucketPolicy != "" {
+			bucketPolicyStr := fmt.Sprintf(testCase.bucketPolicy, bucketName)
+			rec := httptest.NewRecorder()
+			req, err := newTestSignedRequestV4("PUT", getPutPolicyURL("", bucketName),
+				int64(len(bucketPolicyStr)), bytes.NewReader([]byte(bucketPolicyStr)), credentials.AccessKey, credentials.SecretKey)
+			if err != nil {
+				t.Fatalf("Test %d: %s: Failed to create HTTP request for PutBucketPolicyHandler: <ERROR> %v", i+1, instanceType, err)
+			}
+			apiRouter.ServeHTTP(rec, req)
+			if rec.Code != http.StatusNoContent {
+				t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, http.StatusNoContent, rec.Code)
+			}
+		}
+
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("GET", getGetPolicyStatusURL("", bucketName),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for GetBucketPolicyStatusHandler: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, http.StatusOK, rec.Code)
+		}
+		var policyStatus PolicyStatus
+		if err = xml.Unmarshal(rec.Body.Bytes(), &policyStatus); err != nil {
+			t.Fatalf("Test %d: %s: Unexpected XML received %s", i+1, instanceType, err)
+		}
+		if policyStatus.IsPublic != testCase.expectedIsPublic {
+			t.Errorf("Test %d: %s: Expected IsPublic to be %v, but found %v", i+1, instanceType, testCase.expectedIsPublic, policyStatus.IsPublic)
+		}
+	}
+
+	// Non-existent bucket.
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("GET", getGetPolicyStatusURL("", "non-existent-bucket"),
+		0, nil, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request for GetBucketPolicyStatusHandler: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected the response status to be `%d`, but instead found `%d`", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
+
 // Wrapper for calling Delete Bucket Policy HTTP handler tests for both XL multiple disks and single node setup.
 func TestDeleteBucketPolicyHandler(t *testing.T) {
 	ExecObjectLayerAPITest(t, testDeleteBucketPolicyHandler, []string{"PutBucketPolicy", "DeleteBucketPolicy"})
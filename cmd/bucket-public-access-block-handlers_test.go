@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the public access block config is saved and enforced on the
+// bucket policies, the ACLs and the anonymous requests.
+func TestBucketPublicAccessBlockHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketPublicAccessBlockHandlers, []string{
+		"GetBucketPublicAccessBlock", "PutBucketPublicAccessBlock", "DeleteBucketPublicAccessBlock",
+		"PutBucketPolicy", "PutBucketACL", "GetObject",
+	})
+}
+
+func testBucketPublicAccessBlockHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Configs and policies are applied in-memory through the local peer.
+	initBucketPolicies(obj)
+	initGlobalS3Peers(nil)
+
+	data := []byte("hello")
+	if _, err := obj.PutObject(bucketName, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header, signed bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if signed {
+			if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+				t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+			}
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	expectCode := func(testName string, rec *httptest.ResponseRecorder, code int) {
+		if rec.Code != code {
+			t.Fatalf("%s: %s: Expected status %d, got %d: %s", instanceType, testName, code, rec.Code, rec.Body.String())
+		}
+	}
+	putConfig := func(config string) {
+		expectCode("put config "+config, serveRequest("PUT", getBucketPublicAccessBlockURL("", bucketName), []byte(config), nil, true), http.StatusOK)
+	}
+
+	publicPolicy := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/*"]}]}`, bucketName)
+	privatePolicy := fmt.Sprintf(`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"AWS":["*"]},"Action":["s3:GetObject"],"Resource":["arn:aws:s3:::%s/*"],"Condition":{"StringLike":{"aws:Referer":["example.com/*"]}}}]}`, bucketName)
+	publicReadACL := http.Header{"X-Amz-Acl": []string{"public-read"}}
+	privateACL := http.Header{"X-Amz-Acl": []string{"private"}}
+
+	// No config by default, public policies are allowed.
+	rec := serveRequest("GET", getBucketPublicAccessBlockURL("", bucketName), nil, nil, true)
+	expectCode("get unset", rec, http.StatusNotFound)
+	if !bytes.Contains(rec.Body.Bytes(), []byte("<Code>NoSuchPublicAccessBlockConfiguration</Code>")) {
+		t.Fatalf("%s: Expected NoSuchPublicAccessBlockConfiguration, got %s", instanceType, rec.Body.String())
+	}
+	expectCode("public policy unset", serveRequest("PUT", getPutPolicyURL("", bucketName), []byte(publicPolicy), nil, true), http.StatusNoContent)
+	expectCode("anonymous get unset", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false), http.StatusOK)
+
+	// Malformed configs are rejected.
+	expectCode("malformed config", serveRequest("PUT", getBucketPublicAccessBlockURL("", bucketName), []byte("<PublicAccessBlockConfiguration>"), nil, true), http.StatusBadRequest)
+
+	// Public policies are blocked, others are still allowed.
+	putConfig(`<PublicAccessBlockConfiguration><BlockPublicPolicy>true</BlockPublicPolicy></PublicAccessBlockConfiguration>`)
+	rec = serveRequest("GET", getBucketPublicAccessBlockURL("", bucketName), nil, nil, true)
+	expectCode("get config", rec, http.StatusOK)
+	var config PublicAccessBlockConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if !config.BlockPublicPolicy || config.BlockPublicAcls || config.IgnorePublicAcls || config.RestrictPublicBuckets {
+		t.Fatalf("%s: Unexpected public access block config %#v", instanceType, config)
+	}
+	expectCode("public policy blocked", serveRequest("PUT", getPutPolicyURL("", bucketName), []byte(publicPolicy), nil, true), http.StatusForbidden)
+	expectCode("private policy", serveRequest("PUT", getPutPolicyURL("", bucketName), []byte(privatePolicy), nil, true), http.StatusNoContent)
+	expectCode("public acl with policy blocked", serveRequest("PUT", getBucketACLURL("", bucketName), nil, publicReadACL, true), http.StatusOK)
+
+	// Public ACLs are blocked, private ACL is allowed.
+	putConfig(`<PublicAccessBlockConfiguration><BlockPublicAcls>true</BlockPublicAcls></PublicAccessBlockConfiguration>`)
+	expectCode("public acl blocked", serveRequest("PUT", getBucketACLURL("", bucketName), nil, publicReadACL, true), http.StatusForbidden)
+	expectCode("private acl", serveRequest("PUT", getBucketACLURL("", bucketName), nil, privateACL, true), http.StatusOK)
+
+	// Anonymous access through an existing public policy is restricted.
+	expectCode("public policy", serveRequest("PUT", getPutPolicyURL("", bucketName), []byte(publicPolicy), nil, true), http.StatusNoContent)
+	expectCode("anonymous get", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false), http.StatusOK)
+	putConfig(`<PublicAccessBlockConfiguration><RestrictPublicBuckets>true</RestrictPublicBuckets></PublicAccessBlockConfiguration>`)
+	expectCode("anonymous get restricted", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false), http.StatusForbidden)
+	expectCode("signed get restricted", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, true), http.StatusOK)
+
+	// Access is granted again once the config is removed.
+	expectCode("delete config", serveRequest("DELETE", getBucketPublicAccessBlockURL("", bucketName), nil, nil, true), http.StatusNoContent)
+	expectCode("get deleted", serveRequest("GET", getBucketPublicAccessBlockURL("", bucketName), nil, nil, true), http.StatusNotFound)
+	expectCode("anonymous get deleted", serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, false), http.StatusOK)
+}
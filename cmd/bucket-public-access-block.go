@@ -0,0 +1,239 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"path"
+	"sync"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Bucket public access block config name.
+const bucketPublicAccessBlockConfig = "public-access-block.xml"
+
+// errNoSuchPublicAccessBlockConfig - public access block config is not set on the bucket.
+var errNoSuchPublicAccessBlockConfig = errors.New("The public access block configuration does not exist")
+
+// errPublicAccessBlocked - the request would make the bucket public
+// while it is blocked by the public access block config.
+var errPublicAccessBlocked = errors.New("Public access is blocked by the public access block configuration of the bucket")
+
+// PublicAccessBlockConfiguration - guards a bucket against public
+// access. Public ACLs are saved as the bucket policy, so ignoring them
+// ignores the public statements of the policy as well.
+type PublicAccessBlockConfiguration struct {
+	XMLName               xml.Name `xml:"PublicAccessBlockConfiguration"`
+	XMLNS                 string   `xml:"xmlns,attr,omitempty"`
+	BlockPublicAcls       bool     `xml:"BlockPublicAcls"`
+	IgnorePublicAcls      bool     `xml:"IgnorePublicAcls"`
+	BlockPublicPolicy     bool     `xml:"BlockPublicPolicy"`
+	RestrictPublicBuckets bool     `xml:"RestrictPublicBuckets"`
+}
+
+// Variable represents bucket public access block configs in memory.
+var globalBucketPublicAccessBlock = newBucketPublicAccessBlockConfigs(nil)
+
+// bucketPublicAccessBlockConfigs - public access block configs of all the buckets.
+type bucketPublicAccessBlockConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of public access block configs indexed by 'bucket'.
+	configs map[string]PublicAccessBlockConfiguration
+}
+
+// newBucketPublicAccessBlockConfigs - initializes bucket public access block configs.
+func newBucketPublicAccessBlockConfigs(configs map[string]PublicAccessBlockConfiguration) *bucketPublicAccessBlockConfigs {
+	if configs == nil {
+		configs = make(map[string]PublicAccessBlockConfiguration)
+	}
+	return &bucketPublicAccessBlockConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the public access block config of the bucket, false if not set.
+func (bc *bucketPublicAccessBlockConfigs) Get(bucket string) (PublicAccessBlockConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the public access block config of the bucket, nil config removes it.
+func (bc *bucketPublicAccessBlockConfigs) Set(bucket string, config *PublicAccessBlockConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// Loads all bucket public access block configs from persistent layer.
+func loadAllBucketPublicAccessBlockConfigs(objAPI ObjectLayer) (map[string]PublicAccessBlockConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]PublicAccessBlockConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketPublicAccessBlockConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchPublicAccessBlockConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket public access block configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket public access block configs.
+func initBucketPublicAccessBlock(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketPublicAccessBlockConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket public access block configs.
+	globalBucketPublicAccessBlock = newBucketPublicAccessBlockConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketPublicAccessBlockConfig - reads the public access block config of the bucket.
+func readBucketPublicAccessBlockConfig(bucket string, objAPI ObjectLayer) (PublicAccessBlockConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketPublicAccessBlockConfig)
+
+	// Acquire a read lock on public access block config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return PublicAccessBlockConfiguration{}, errNoSuchPublicAccessBlockConfig
+		}
+		errorIf(err, "Unable to load public access block config for the bucket %s.", bucket)
+		return PublicAccessBlockConfiguration{}, errorCause(err)
+	}
+
+	var config PublicAccessBlockConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return PublicAccessBlockConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketPublicAccessBlockConfig - saves the public access block
+// config of the bucket, nil config removes any previously saved config.
+func writeBucketPublicAccessBlockConfig(bucket string, config *PublicAccessBlockConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketPublicAccessBlockConfig)
+
+	// Acquire a write lock on public access block config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove public access block config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set public access block config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketPublicAccessBlockChange - persists the public
+// access block config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketPublicAccessBlockChange(bucket string, config *PublicAccessBlockConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketPublicAccessBlockConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketPublicAccessBlock(bucket, config)
+	return nil
+}
+
+// checkPublicACLAllowed - returns errPublicAccessBlocked if the canned
+// policy of an ACL grants public access while public ACLs are blocked
+// on the bucket.
+func checkPublicACLAllowed(bucket string, bucketPolicy policy.BucketPolicy) error {
+	config, ok := globalBucketPublicAccessBlock.Get(bucket)
+	if ok && config.BlockPublicAcls && bucketPolicy != policy.BucketPolicyNone {
+		return errPublicAccessBlocked
+	}
+	return nil
+}
+
+// checkPublicPolicyAllowed - returns errPublicAccessBlocked if the
+// policy grants public access while public policies are blocked on the
+// bucket. Policies which do not parse are left to the policy validation.
+func checkPublicPolicyAllowed(bucket string, policyBytes []byte) error {
+	config, ok := globalBucketPublicAccessBlock.Get(bucket)
+	if !ok || !config.BlockPublicPolicy {
+		return nil
+	}
+	policyInfo := &bucketPolicy{}
+	if err := parseBucketPolicy(bytes.NewReader(policyBytes), policyInfo); err != nil {
+		return nil
+	}
+	if isBucketPolicyPublic(policyInfo) {
+		return errPublicAccessBlocked
+	}
+	return nil
+}
+
+// isPublicAccessRestricted - returns true if the anonymous access
+// granted by a public policy is restricted on the bucket.
+func isPublicAccessRestricted(bucket string, policyInfo *bucketPolicy) bool {
+	config, ok := globalBucketPublicAccessBlock.Get(bucket)
+	if !ok || !(config.RestrictPublicBuckets || config.IgnorePublicAcls) {
+		return false
+	}
+	return isBucketPolicyPublic(policyInfo)
+}
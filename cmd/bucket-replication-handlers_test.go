@@ -0,0 +1,260 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockReplicationTarget - remote S3 target storing the replicated
+// objects in memory, the first request and all the requests for
+// objects named 'fail' fail.
+type mockReplicationTarget struct {
+	mu       sync.Mutex
+	requests int
+	objects  map[string][]byte
+	headers  map[string]http.Header
+}
+
+func (m *mockReplicationTarget) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if m.requests == 1 || strings.HasSuffix(r.URL.Path, "/fail") || r.Header.Get("Authorization") == "" {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.objects[r.URL.Path] = data
+		m.headers[r.URL.Path] = r.Header
+		w.WriteHeader(http.StatusOK)
+	case "DELETE":
+		delete(m.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (m *mockReplicationTarget) getObject(path string) ([]byte, http.Header, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.objects[path]
+	return data, m.headers[path], ok
+}
+
+// Tests PUT, GET and DELETE bucket replication along with replication
+// of the objects to a remote target.
+func TestBucketReplicationHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketReplicationHandlers, []string{
+		"GetBucketReplication",
+		"PutBucketReplication",
+		"DeleteBucketReplication",
+		"PutObject",
+		"HeadObject",
+		"DeleteObject",
+	})
+}
+
+func testBucketReplicationHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Replication configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+
+	replicationRetryUnit = time.Millisecond
+	defer func() { replicationRetryUnit = time.Second }()
+
+	target := &mockReplicationTarget{
+		objects: make(map[string][]byte),
+		headers: make(map[string]http.Header),
+	}
+	targetServer := httptest.NewServer(target)
+	defer targetServer.Close()
+
+	serveRequest := func(method, urlStr, body string, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader([]byte(body)))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Replication is not configured by default.
+	rec := serveRequest("GET", getBucketReplicationURL("", bucketName), "", nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	destination := fmt.Sprintf(`<Destination><Bucket>arn:aws:s3:::target</Bucket><Endpoint>%s</Endpoint>`+
+		`<AccessKey>access</AccessKey><SecretKey>secret</SecretKey></Destination>`, targetServer.URL)
+
+	// Invalid replication configs.
+	invalidConfigs := []string{
+		`<ReplicationConfiguration><Rule>`,
+		`<ReplicationConfiguration></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Unknown</Status>` + destination + `</Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::target</Bucket></Destination></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status><Destination><Bucket>arn:aws:s3:::a</Bucket><Endpoint>http://localhost</Endpoint></Destination></Rule></ReplicationConfiguration>`,
+		`<ReplicationConfiguration><Rule><Status>Enabled</Status><Filter><Prefix>a</Prefix><Tag><Key>k</Key><Value>v</Value></Tag></Filter>` + destination + `</Rule></ReplicationConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketReplicationURL("", bucketName), config, nil); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	config := `<ReplicationConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/">` +
+		`<Rule><ID>docs</ID><Status>Enabled</Status><Filter><And><Prefix>docs/</Prefix>` +
+		`<Tag><Key>team</Key><Value>dr</Value></Tag></And></Filter>` + destination +
+		`<DeleteMarkerReplication><Status>Enabled</Status></DeleteMarkerReplication></Rule>` +
+		`</ReplicationConfiguration>`
+	if rec = serveRequest("PUT", getBucketReplicationURL("", bucketName), config, nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketReplicationURL("", bucketName), "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var replication ReplicationConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &replication); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if len(replication.Rules) != 1 || replication.Rules[0].Destination.AccessKey != "access" ||
+		replication.Rules[0].Destination.SecretKey != "" {
+		t.Fatalf("%s: Unexpected replication config %#v", instanceType, replication)
+	}
+
+	// Waits for the replication status of the object.
+	waitForStatus := func(object, expectedStatus string) {
+		var status string
+		for i := 0; i < 500; i++ {
+			rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, object), "", nil)
+			if status = rec.Header().Get(replicationStatusKey); status == expectedStatus {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("%s: Expected replication status %s of %s, got %s", instanceType, expectedStatus, object, status)
+	}
+
+	testCases := []struct {
+		objectName string
+		tagging    string
+		replicated bool
+	}{
+		// Prefix and tag match.
+		{"docs/a", "team=dr&env=prod", true},
+		// Tag does not match.
+		{"docs/b", "team=web", false},
+		// Prefix does not match.
+		{"images/c", "team=dr", false},
+	}
+	for i, testCase := range testCases {
+		header := http.Header{}
+		header.Set("X-Amz-Tagging", testCase.tagging)
+		header.Set("X-Amz-Meta-Test", "value")
+		rec = serveRequest("PUT", getPutObjectURL("", bucketName, testCase.objectName), "hello", header)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+	}
+	for i, testCase := range testCases {
+		if !testCase.replicated {
+			continue
+		}
+		waitForStatus(testCase.objectName, replicationCompleted)
+		data, header, ok := target.getObject("/target/" + testCase.objectName)
+		if !ok || string(data) != "hello" {
+			t.Fatalf("%s: Test %d: Expected object to be replicated, got %q", instanceType, i+1, data)
+		}
+		if header.Get("X-Amz-Meta-Test") != "value" || header.Get(replicationStatusKey) != replicationReplica ||
+			header.Get("X-Amz-Tagging") != testCase.tagging {
+			t.Fatalf("%s: Test %d: Unexpected replicated headers %v", instanceType, i+1, header)
+		}
+	}
+	for i, testCase := range testCases {
+		if testCase.replicated {
+			continue
+		}
+		rec = serveRequest("HEAD", getHeadObjectURL("", bucketName, testCase.objectName), "", nil)
+		if status := rec.Header().Get(replicationStatusKey); status != "" {
+			t.Fatalf("%s: Test %d: Expected no replication status, got %s", instanceType, i+1, status)
+		}
+		if _, _, ok := target.getObject("/target/" + testCase.objectName); ok {
+			t.Fatalf("%s: Test %d: Expected object not to be replicated", instanceType, i+1)
+		}
+	}
+
+	// Objects which can not be replicated are marked failed.
+	header := http.Header{}
+	header.Set("X-Amz-Tagging", "team=dr")
+	if rec = serveRequest("PUT", getPutObjectURL("", bucketName, "docs/fail"), "hello", header); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	waitForStatus("docs/fail", replicationFailed)
+
+	// Deletes are replicated.
+	if rec = serveRequest("DELETE", getDeleteObjectURL("", bucketName, "docs/a"), "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	for i := 0; ; i++ {
+		if _, _, ok := target.getObject("/target/docs/a"); !ok {
+			break
+		}
+		if i == 500 {
+			t.Fatalf("%s: Expected delete to be replicated", instanceType)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Delete replication.
+	if rec = serveRequest("DELETE", getBucketReplicationURL("", bucketName), "", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = serveRequest("GET", getBucketReplicationURL("", bucketName), "", nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
@@ -0,0 +1,377 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	// Bucket replication config name.
+	bucketReplicationConfig = "replication.xml"
+
+	// Maximum number of rules in a replication config.
+	maxReplicationRules = 1000
+
+	// Maximum length of a replication rule ID.
+	maxReplicationRuleIDLength = 255
+
+	// Replication rule status values.
+	replicationStatusEnabled  = "Enabled"
+	replicationStatusDisabled = "Disabled"
+
+	// Prefix of the destination bucket ARN.
+	replicationBucketARNPrefix = "arn:aws:s3:::"
+)
+
+// errInvalidReplicationConfig - replication config is not valid.
+var errInvalidReplicationConfig = errors.New("Invalid replication configuration")
+
+// errNoSuchReplicationConfig - replication config is not set on the bucket.
+var errNoSuchReplicationConfig = errors.New("The replication configuration was not found")
+
+// ReplicationTag - object tag matched by a replication rule.
+type ReplicationTag struct {
+	Key   string `xml:"Key"`
+	Value string `xml:"Value"`
+}
+
+// ReplicationFilterAnd - prefix and tags all of which must match.
+type ReplicationFilterAnd struct {
+	Prefix string           `xml:"Prefix,omitempty"`
+	Tags   []ReplicationTag `xml:"Tag"`
+}
+
+// ReplicationFilter - selects the objects a rule applies to, only one
+// of prefix, tag or and is allowed.
+type ReplicationFilter struct {
+	Prefix string                `xml:"Prefix,omitempty"`
+	Tag    *ReplicationTag       `xml:"Tag,omitempty"`
+	And    *ReplicationFilterAnd `xml:"And,omitempty"`
+}
+
+// ReplicationDestination - remote bucket the objects are replicated to.
+// Endpoint, credentials and region of the remote S3 service are Minio
+// extensions, the secret key is never returned by GET replication.
+type ReplicationDestination struct {
+	Bucket       string `xml:"Bucket"`
+	StorageClass string `xml:"StorageClass,omitempty"`
+	Endpoint     string `xml:"Endpoint"`
+	AccessKey    string `xml:"AccessKey,omitempty"`
+	SecretKey    string `xml:"SecretKey,omitempty"`
+	Region       string `xml:"Region,omitempty"`
+}
+
+// DeleteMarkerReplication - deletes are replicated only when enabled.
+type DeleteMarkerReplication struct {
+	Status string `xml:"Status"`
+}
+
+// ReplicationRule - a single replication rule of a bucket.
+type ReplicationRule struct {
+	ID                      string                   `xml:"ID,omitempty"`
+	Priority                int                      `xml:"Priority,omitempty"`
+	Status                  string                   `xml:"Status"`
+	Prefix                  string                   `xml:"Prefix,omitempty"`
+	Filter                  *ReplicationFilter       `xml:"Filter,omitempty"`
+	Destination             ReplicationDestination   `xml:"Destination"`
+	DeleteMarkerReplication *DeleteMarkerReplication `xml:"DeleteMarkerReplication,omitempty"`
+}
+
+// ReplicationConfiguration - replication config of a bucket.
+type ReplicationConfiguration struct {
+	XMLName xml.Name          `xml:"ReplicationConfiguration"`
+	XMLNS   string            `xml:"xmlns,attr,omitempty"`
+	Role    string            `xml:"Role,omitempty"`
+	Rules   []ReplicationRule `xml:"Rule"`
+}
+
+// targetBucket - returns the name of the destination bucket.
+func (dest ReplicationDestination) targetBucket() string {
+	return strings.TrimPrefix(dest.Bucket, replicationBucketARNPrefix)
+}
+
+// replicateDeletes - returns true if deletes are replicated by the rule.
+func (rule ReplicationRule) replicateDeletes() bool {
+	return rule.DeleteMarkerReplication != nil && rule.DeleteMarkerReplication.Status == replicationStatusEnabled
+}
+
+// matchTags - returns true if all the tags are set on the object.
+func matchTags(tags []ReplicationTag, objectTags url.Values) bool {
+	for _, tag := range tags {
+		values, ok := objectTags[tag.Key]
+		if !ok || len(values) == 0 || values[0] != tag.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// Match - returns true if the rule applies to the object, tags are
+// ignored for deletes as the deleted object is gone.
+func (rule ReplicationRule) Match(object string, objectTags url.Values, isDelete bool) bool {
+	if rule.Status != replicationStatusEnabled {
+		return false
+	}
+	prefix := rule.Prefix
+	var tags []ReplicationTag
+	if filter := rule.Filter; filter != nil {
+		prefix = filter.Prefix
+		if filter.Tag != nil {
+			tags = []ReplicationTag{*filter.Tag}
+		}
+		if filter.And != nil {
+			prefix = filter.And.Prefix
+			tags = filter.And.Tags
+		}
+	}
+	if !strings.HasPrefix(object, prefix) {
+		return false
+	}
+	return isDelete || matchTags(tags, objectTags)
+}
+
+// validateReplicationConfig - validates the replication rules.
+func validateReplicationConfig(config ReplicationConfiguration) error {
+	if len(config.Rules) == 0 || len(config.Rules) > maxReplicationRules {
+		return errInvalidReplicationConfig
+	}
+	ruleIDs := make(map[string]struct{})
+	for _, rule := range config.Rules {
+		if len(rule.ID) > maxReplicationRuleIDLength {
+			return errInvalidReplicationConfig
+		}
+		if rule.ID != "" {
+			if _, ok := ruleIDs[rule.ID]; ok {
+				return errInvalidReplicationConfig
+			}
+			ruleIDs[rule.ID] = struct{}{}
+		}
+		if rule.Status != replicationStatusEnabled && rule.Status != replicationStatusDisabled {
+			return errInvalidReplicationConfig
+		}
+		if rule.DeleteMarkerReplication != nil {
+			status := rule.DeleteMarkerReplication.Status
+			if status != replicationStatusEnabled && status != replicationStatusDisabled {
+				return errInvalidReplicationConfig
+			}
+		}
+		if filter := rule.Filter; filter != nil {
+			if rule.Prefix != "" {
+				return errInvalidReplicationConfig
+			}
+			// Only one of prefix, tag or and is allowed.
+			if filter.And != nil && (filter.Prefix != "" || filter.Tag != nil) ||
+				filter.Tag != nil && filter.Prefix != "" {
+				return errInvalidReplicationConfig
+			}
+		}
+		dest := rule.Destination
+		if !IsValidBucketName(dest.targetBucket()) {
+			return errInvalidReplicationConfig
+		}
+		u, err := url.Parse(dest.Endpoint)
+		if err != nil || (u.Scheme != httpScheme && u.Scheme != httpsScheme) || u.Host == "" {
+			return errInvalidReplicationConfig
+		}
+	}
+	return nil
+}
+
+// Variable represents bucket replication configs in memory.
+var globalBucketReplication = newBucketReplicationConfigs(nil)
+
+// bucketReplicationConfigs - replication configs of all the buckets.
+type bucketReplicationConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of replication configs indexed by 'bucket'.
+	configs map[string]ReplicationConfiguration
+}
+
+// newBucketReplicationConfigs - initializes bucket replication configs.
+func newBucketReplicationConfigs(configs map[string]ReplicationConfiguration) *bucketReplicationConfigs {
+	if configs == nil {
+		configs = make(map[string]ReplicationConfiguration)
+	}
+	return &bucketReplicationConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the replication config of the bucket, false if not set.
+func (br *bucketReplicationConfigs) Get(bucket string) (ReplicationConfiguration, bool) {
+	br.rwMutex.RLock()
+	defer br.rwMutex.RUnlock()
+	config, ok := br.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the replication config of the bucket, nil config removes it.
+func (br *bucketReplicationConfigs) Set(bucket string, config *ReplicationConfiguration) {
+	br.rwMutex.Lock()
+	defer br.rwMutex.Unlock()
+	if config == nil {
+		delete(br.configs, bucket)
+		return
+	}
+	br.configs[bucket] = *config
+}
+
+// MatchRule - returns the rule replicating the object, the rule with
+// the highest priority wins when multiple rules apply.
+func (br *bucketReplicationConfigs) MatchRule(bucket, object string, objectTags url.Values, isDelete bool) (ReplicationRule, bool) {
+	config, ok := br.Get(bucket)
+	if !ok {
+		return ReplicationRule{}, false
+	}
+	var matched *ReplicationRule
+	for i, rule := range config.Rules {
+		if !rule.Match(object, objectTags, isDelete) {
+			continue
+		}
+		if matched == nil || rule.Priority > matched.Priority {
+			matched = &config.Rules[i]
+		}
+	}
+	if matched == nil {
+		return ReplicationRule{}, false
+	}
+	return *matched, true
+}
+
+// Loads all bucket replication configs from persistent layer.
+func loadAllBucketReplicationConfigs(objAPI ObjectLayer) (map[string]ReplicationConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]ReplicationConfiguration)
+	for _, bucket := range buckets {
+		config, rErr := readBucketReplicationConfig(bucket.Name, objAPI)
+		if rErr != nil {
+			if !isErrIgnored(rErr, errNoSuchReplicationConfig, errDiskNotFound) {
+				return nil, rErr
+			}
+			// Continue to load other bucket replication configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket replication configs and start the replicator.
+func initBucketReplication(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketReplicationConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket replication configs.
+	globalBucketReplication = newBucketReplicationConfigs(configs)
+
+	// Objects are replicated asynchronously by a single replicator.
+	globalReplicatorOnce.Do(func() {
+		globalReplicator = newReplicator(newObjectLayerFn)
+	})
+
+	// Success.
+	return nil
+}
+
+// readBucketReplicationConfig - reads the replication config of the bucket.
+func readBucketReplicationConfig(bucket string, objAPI ObjectLayer) (ReplicationConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketReplicationConfig)
+
+	// Acquire a read lock on replication config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return ReplicationConfiguration{}, errNoSuchReplicationConfig
+		}
+		errorIf(err, "Unable to load replication config for the bucket %s.", bucket)
+		return ReplicationConfiguration{}, errorCause(err)
+	}
+
+	var config ReplicationConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return ReplicationConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketReplicationConfig - saves the replication config of the
+// bucket, nil config removes any previously saved config.
+func writeBucketReplicationConfig(bucket string, config *ReplicationConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketReplicationConfig)
+
+	// Acquire a write lock on replication config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove replication config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set replication config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketReplicationChange - persists the replication
+// config of the bucket and notifies all the nodes in the cluster to
+// update their in-memory state.
+func persistAndNotifyBucketReplicationChange(bucket string, config *ReplicationConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketReplicationConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketReplication(bucket, config)
+	return nil
+}
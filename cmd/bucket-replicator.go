@@ -0,0 +1,362 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+)
+
+const (
+	// Maximum number of replication tasks queued, objects are marked
+	// as failed once the queue is full.
+	replicationQueueSize = 10000
+
+	// Maximum number of attempts to replicate a change.
+	replicationMaxAttempts = 5
+
+	// Object metadata holding the replication status, returned as
+	// x-amz-replication-status header.
+	replicationStatusKey = "X-Amz-Replication-Status"
+
+	// Replication status values.
+	replicationPending   = "PENDING"
+	replicationCompleted = "COMPLETED"
+	replicationFailed    = "FAILED"
+	replicationReplica   = "REPLICA"
+
+	// Object metadata holding the tags of the object in URL query
+	// format, matched by replication rules.
+	objectTaggingKey = "X-Amz-Tagging"
+)
+
+var (
+	// Backoff between the replication attempts.
+	replicationRetryUnit = time.Second
+	replicationRetryCap  = 30 * time.Second
+
+	// Replicates object changes of all the buckets, initialized along
+	// with the bucket replication configs.
+	globalReplicator     *replicator
+	globalReplicatorOnce sync.Once
+)
+
+// errReplicationSuperseded - object changed after the replication was
+// queued, the newer change is replicated by its own task.
+var errReplicationSuperseded = errors.New("Object changed since replication was queued")
+
+// replicationTask - object change to be replicated to the rule target.
+type replicationTask struct {
+	bucket   string
+	object   string
+	isDelete bool
+	// MD5 sum of the replicated object, empty for deletes.
+	md5Sum string
+	rule   ReplicationRule
+}
+
+// replicator - replicates object changes to the remote targets in the
+// order they were made. Replication never blocks the request path.
+type replicator struct {
+	objAPI func() ObjectLayer
+	client *http.Client
+	taskCh chan replicationTask
+}
+
+// newReplicator - initializes a replicator and starts replicating the
+// queued changes in background.
+func newReplicator(objAPI func() ObjectLayer) *replicator {
+	r := &replicator{
+		objAPI: objAPI,
+		client: &http.Client{},
+		taskCh: make(chan replicationTask, replicationQueueSize),
+	}
+	go r.run()
+	return r
+}
+
+// Queue - queues the task, returns false if the queue is full.
+func (r *replicator) Queue(task replicationTask) bool {
+	select {
+	case r.taskCh <- task:
+		return true
+	default:
+		return false
+	}
+}
+
+func (r *replicator) run() {
+	for task := range r.taskCh {
+		r.replicate(task)
+	}
+}
+
+// replicate - replicates the change with retries, the replication
+// status of created objects is updated with the outcome.
+func (r *replicator) replicate(task replicationTask) {
+	objAPI := r.objAPI()
+	if objAPI == nil {
+		return
+	}
+
+	if task.isDelete {
+		err := r.retry(func() error { return r.removeRemoteObject(task) })
+		errorIf(err, "Unable to replicate delete of %s/%s.", task.bucket, task.object)
+		return
+	}
+
+	err := r.retry(func() error { return r.putRemoteObject(objAPI, task) })
+	if err == errReplicationSuperseded {
+		return
+	}
+	errorIf(err, "Unable to replicate %s/%s.", task.bucket, task.object)
+	status := replicationCompleted
+	if err != nil {
+		status = replicationFailed
+	}
+
+	objectLock := globalNSMutex.NewNSLock(task.bucket, task.object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	err = setReplicationStatus(objAPI, task, status)
+	if err != errReplicationSuperseded {
+		errorIf(err, "Unable to set replication status of %s/%s.", task.bucket, task.object)
+	}
+}
+
+// retry - calls fn with exponential backoff until it succeeds or the
+// maximum attempts are reached.
+func (r *replicator) retry(fn func() error) (err error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	for i := range newRetryTimer(replicationRetryUnit, replicationRetryCap, MaxJitter, doneCh) {
+		if err = fn(); err == nil || err == errReplicationSuperseded || i+1 >= replicationMaxAttempts {
+			break
+		}
+	}
+	return err
+}
+
+// newRemoteRequest - returns a request for the object in the
+// destination bucket, signed once all the headers are set.
+func newRemoteRequest(method string, dest ReplicationDestination, object string, body io.Reader, size int64) (*http.Request, error) {
+	u, err := url.Parse(dest.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	u.Path = "/" + dest.targetBucket() + "/" + object
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	return req, nil
+}
+
+// signRemoteRequest - signs the request with the credentials of the
+// destination.
+func signRemoteRequest(req *http.Request, dest ReplicationDestination) *http.Request {
+	region := dest.Region
+	if region == "" {
+		region = globalMinioDefaultRegion
+	}
+	return s3signer.SignV4(*req, dest.AccessKey, dest.SecretKey, region)
+}
+
+// doRemoteRequest - sends the request, returns an error unless one of
+// the expected status codes is returned.
+func (r *replicator) doRemoteRequest(req *http.Request, expectedStatus ...int) error {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(ioutil.Discard, resp.Body)
+	for _, status := range expectedStatus {
+		if resp.StatusCode == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("Replication target %s returned %s", req.URL.Host, resp.Status)
+}
+
+// getReplicationHeaders - returns the headers to write the object
+// with its metadata on the destination, the copy is marked as replica.
+func getReplicationHeaders(objInfo ObjectInfo) http.Header {
+	header := make(http.Header)
+	for key, value := range objInfo.UserDefined {
+		switch {
+		case contains(supportedHeaders, strings.ToLower(key)),
+			strings.HasPrefix(http.CanonicalHeaderKey(key), "X-Amz-Meta-"),
+			key == objectTaggingKey:
+			header.Set(key, value)
+		}
+	}
+	header.Set(replicationStatusKey, replicationReplica)
+	return header
+}
+
+// putRemoteObject - writes the object to the destination, the object
+// is read locked while it is streamed.
+func (r *replicator) putRemoteObject(objAPI ObjectLayer, task replicationTask) error {
+	objectLock := globalNSMutex.NewNSLock(task.bucket, task.object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := objAPI.GetObjectInfo(task.bucket, task.object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return errReplicationSuperseded
+		}
+		return err
+	}
+	if objInfo.MD5Sum != task.md5Sum {
+		return errReplicationSuperseded
+	}
+	// The server does not hold the customer keys to decrypt the object.
+	if isEncryptedObject(objInfo.UserDefined) {
+		return errEncryptedObjectUnsupported
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(objAPI.GetObject(task.bucket, task.object, 0, objInfo.Size, pw))
+	}()
+
+	req, err := newRemoteRequest(httpPUT, task.rule.Destination, task.object, pr, objInfo.Size)
+	if err != nil {
+		return err
+	}
+	for key, values := range getReplicationHeaders(objInfo) {
+		req.Header[key] = values
+	}
+	if task.rule.Destination.StorageClass != "" {
+		req.Header.Set("X-Amz-Storage-Class", task.rule.Destination.StorageClass)
+	}
+	return r.doRemoteRequest(signRemoteRequest(req, task.rule.Destination), http.StatusOK)
+}
+
+// removeRemoteObject - deletes the object from the destination, it is
+// not an error if the object is already gone.
+func (r *replicator) removeRemoteObject(task replicationTask) error {
+	req, err := newRemoteRequest(httpDELETE, task.rule.Destination, task.object, nil, 0)
+	if err != nil {
+		return err
+	}
+	return r.doRemoteRequest(signRemoteRequest(req, task.rule.Destination),
+		http.StatusNoContent, http.StatusOK, http.StatusNotFound)
+}
+
+// setReplicationStatus - updates the replication status in the object
+// metadata unless the object changed since the task was queued, the
+// caller holds the object write lock.
+func setReplicationStatus(objAPI ObjectLayer, task replicationTask, status string) error {
+	objInfo, err := objAPI.GetObjectInfo(task.bucket, task.object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return errReplicationSuperseded
+		}
+		return err
+	}
+	if objInfo.MD5Sum != task.md5Sum {
+		return errReplicationSuperseded
+	}
+
+	metadata := make(map[string]string)
+	for key, value := range objInfo.UserDefined {
+		metadata[key] = value
+	}
+	metadata["md5Sum"] = objInfo.MD5Sum
+	metadata[replicationStatusKey] = status
+	_, err = objAPI.CopyObject(task.bucket, task.object, task.bucket, task.object, metadata)
+	return err
+}
+
+// queueReplication - queues the object change of the event for
+// replication if a rule of the bucket applies to it. Created objects
+// are marked pending until replicated, callers of eventNotify hold the
+// object write lock for created objects.
+func queueReplication(event eventData) {
+	if globalReplicator == nil {
+		return
+	}
+	if _, ok := globalBucketReplication.Get(event.Bucket); !ok {
+		return
+	}
+	objAPI := newObjectLayerFn()
+	if objAPI == nil {
+		return
+	}
+
+	task := replicationTask{
+		bucket:   event.Bucket,
+		object:   event.ObjInfo.Name,
+		isDelete: event.Type == ObjectRemovedDelete,
+	}
+	if task.isDelete {
+		rule, ok := globalBucketReplication.MatchRule(task.bucket, task.object, nil, true)
+		if !ok || !rule.replicateDeletes() {
+			return
+		}
+		task.rule = rule
+		if !globalReplicator.Queue(task) {
+			errorIf(errors.New("replication queue is full"), "Unable to replicate delete of %s/%s.", task.bucket, task.object)
+		}
+		return
+	}
+
+	objInfo, err := objAPI.GetObjectInfo(task.bucket, task.object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info of %s/%s.", task.bucket, task.object)
+		return
+	}
+	// Replicas are not replicated again, buckets may replicate to
+	// each other.
+	if objInfo.UserDefined[replicationStatusKey] == replicationReplica {
+		return
+	}
+	tags, _ := url.ParseQuery(objInfo.UserDefined[objectTaggingKey])
+	rule, ok := globalBucketReplication.MatchRule(task.bucket, task.object, tags, false)
+	if !ok {
+		return
+	}
+	task.rule = rule
+	task.md5Sum = objInfo.MD5Sum
+
+	// Mark pending before queueing, the replicator waits for the
+	// object lock held by the caller to update the status.
+	if err = setReplicationStatus(objAPI, task, replicationPending); err != nil {
+		errorIf(err, "Unable to set replication status of %s/%s.", task.bucket, task.object)
+		return
+	}
+	if !globalReplicator.Queue(task) {
+		errorIf(setReplicationStatus(objAPI, task, replicationFailed),
+			"Unable to set replication status of %s/%s.", task.bucket, task.object)
+	}
+}
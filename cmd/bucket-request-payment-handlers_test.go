@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests PUT and GET bucket request payment along with the enforcement
+// of the x-amz-request-payer header on the requester pays buckets.
+func TestBucketRequestPaymentHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketRequestPaymentHandlers, []string{
+		"GetBucketRequestPayment",
+		"PutBucketRequestPayment",
+		"PutObject",
+		"GetObject",
+		"ListObjectsV2",
+	})
+}
+
+func testBucketRequestPaymentHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Request payment configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+	defer globalBucketRequestPayment.Set(bucketName, nil)
+
+	handler := setRequesterPaysHandler(apiRouter)
+	serveRequest := func(method, urlStr string, body []byte, header http.Header, signed bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if signed {
+			if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+				t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+			}
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+	getPayer := func() string {
+		rec := serveRequest("GET", getBucketRequestPaymentURL("", bucketName), nil, nil, true)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		var config RequestPaymentConfiguration
+		if err := xml.Unmarshal(rec.Body.Bytes(), &config); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		return config.Payer
+	}
+
+	// Bucket owner pays by default.
+	if payer := getPayer(); payer != requestPaymentBucketOwner {
+		t.Fatalf("%s: Expected payer %s, got %s", instanceType, requestPaymentBucketOwner, payer)
+	}
+
+	// Invalid request payment configs.
+	invalidConfigs := []string{
+		`<RequestPaymentConfiguration><Payer>`,
+		`<RequestPaymentConfiguration></RequestPaymentConfiguration>`,
+		`<RequestPaymentConfiguration><Payer>Anyone</Payer></RequestPaymentConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec := serveRequest("PUT", getBucketRequestPaymentURL("", bucketName), []byte(config), nil, true); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	data := []byte("requester pays data")
+	if rec := serveRequest("PUT", getPutObjectURL("", bucketName, "object"), data, nil, true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+
+	config := `<RequestPaymentConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Payer>Requester</Payer></RequestPaymentConfiguration>`
+	if rec := serveRequest("PUT", getBucketRequestPaymentURL("", bucketName), []byte(config), nil, true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if payer := getPayer(); payer != requestPaymentRequester {
+		t.Fatalf("%s: Expected payer %s, got %s", instanceType, requestPaymentRequester, payer)
+	}
+
+	payerHeader := http.Header{}
+	payerHeader.Set(amzRequestPayer, requestPayerValue)
+	testCases := []struct {
+		method         string
+		urlStr         string
+		body           []byte
+		header         http.Header
+		signed         bool
+		expectedStatus int
+		isCharged      bool
+	}{
+		// Test case - 1.
+		// Requests without the header are rejected.
+		{"GET", getGetObjectURL("", bucketName, "object"), nil, nil, true, http.StatusForbidden, false},
+		// Test case - 2.
+		{"PUT", getPutObjectURL("", bucketName, "new-object"), data, nil, true, http.StatusForbidden, false},
+		// Test case - 3.
+		{"GET", getListObjectsV2URL("", bucketName, "", ""), nil, nil, true, http.StatusForbidden, false},
+		// Test case - 4.
+		// Anonymous requests are rejected even with the header.
+		{"GET", getGetObjectURL("", bucketName, "object"), nil, payerHeader, false, http.StatusForbidden, false},
+		// Test case - 5.
+		// Requests with the header are accepted and charged.
+		{"GET", getGetObjectURL("", bucketName, "object"), nil, payerHeader, true, http.StatusOK, true},
+		// Test case - 6.
+		{"PUT", getPutObjectURL("", bucketName, "new-object"), data, payerHeader, true, http.StatusOK, true},
+		// Test case - 7.
+		{"GET", getListObjectsV2URL("", bucketName, "", ""), nil, payerHeader, true, http.StatusOK, true},
+		// Test case - 8.
+		// Bucket configuration requests are not charged.
+		{"GET", getBucketRequestPaymentURL("", bucketName), nil, nil, true, http.StatusOK, false},
+	}
+	for i, testCase := range testCases {
+		rec := serveRequest(testCase.method, testCase.urlStr, testCase.body, testCase.header, testCase.signed)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+		if testCase.expectedStatus == http.StatusForbidden {
+			var errResp APIErrorResponse
+			if err := xml.Unmarshal(rec.Body.Bytes(), &errResp); err != nil || errResp.Code != "AccessDenied" {
+				t.Fatalf("%s: Test %d: Expected AccessDenied, got %s", instanceType, i+1, rec.Body.String())
+			}
+		}
+		if isCharged := rec.Header().Get(amzRequestCharged) == requestPayerValue; isCharged != testCase.isCharged {
+			t.Fatalf("%s: Test %d: Expected charged %t, got %t", instanceType, i+1, testCase.isCharged, isCharged)
+		}
+	}
+
+	// Accepted requests are accounted to the requester.
+	usage := globalRequesterUsage.Get(bucketName, credentials.AccessKey)
+	if usage.Requests != 3 || usage.BytesReceived != int64(len(data)) || usage.BytesSent < int64(len(data)) {
+		t.Fatalf("%s: Unexpected usage %#v", instanceType, usage)
+	}
+
+	// Bucket owner pays again, the header is not required.
+	config = `<RequestPaymentConfiguration><Payer>BucketOwner</Payer></RequestPaymentConfiguration>`
+	if rec := serveRequest("PUT", getBucketRequestPaymentURL("", bucketName), []byte(config), nil, true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if payer := getPayer(); payer != requestPaymentBucketOwner {
+		t.Fatalf("%s: Expected payer %s, got %s", instanceType, requestPaymentBucketOwner, payer)
+	}
+	if rec := serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, nil, true); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+}
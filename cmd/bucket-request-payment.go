@@ -0,0 +1,380 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket request payment config name.
+	bucketRequestPaymentConfig = "request-payment.xml"
+
+	// Payer values of the request payment config.
+	requestPaymentBucketOwner = "BucketOwner"
+	requestPaymentRequester   = "Requester"
+
+	// Header of the requests acknowledging that the requester pays,
+	// its only valid value is "requester".
+	amzRequestPayer = "X-Amz-Request-Payer"
+	// Header of the responses of the requests charged to the requester.
+	amzRequestCharged = "X-Amz-Request-Charged"
+	requestPayerValue = "requester"
+)
+
+// errInvalidRequestPaymentConfig - request payment config is not valid.
+var errInvalidRequestPaymentConfig = errors.New("Invalid request payment configuration")
+
+// errNoSuchRequestPaymentConfig - request payment config is not set on the bucket.
+var errNoSuchRequestPaymentConfig = errors.New("The request payment configuration does not exist")
+
+// RequestPaymentConfiguration - sets who pays for the requests and the
+// data transferred from a bucket, the bucket owner by default.
+type RequestPaymentConfiguration struct {
+	XMLName xml.Name `xml:"RequestPaymentConfiguration"`
+	XMLNS   string   `xml:"xmlns,attr,omitempty"`
+	Payer   string   `xml:"Payer"`
+}
+
+// validateRequestPaymentConfig - validates the payer.
+func validateRequestPaymentConfig(config RequestPaymentConfiguration) error {
+	if config.Payer != requestPaymentBucketOwner && config.Payer != requestPaymentRequester {
+		return errInvalidRequestPaymentConfig
+	}
+	return nil
+}
+
+// Variable represents bucket request payment configs in memory.
+var globalBucketRequestPayment = newBucketRequestPaymentConfigs(nil)
+
+// bucketRequestPaymentConfigs - request payment configs of all the buckets.
+type bucketRequestPaymentConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of request payment configs indexed by 'bucket'.
+	configs map[string]RequestPaymentConfiguration
+}
+
+// newBucketRequestPaymentConfigs - initializes bucket request payment configs.
+func newBucketRequestPaymentConfigs(configs map[string]RequestPaymentConfiguration) *bucketRequestPaymentConfigs {
+	if configs == nil {
+		configs = make(map[string]RequestPaymentConfiguration)
+	}
+	return &bucketRequestPaymentConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the request payment config of the bucket, false if not set.
+func (bc *bucketRequestPaymentConfigs) Get(bucket string) (RequestPaymentConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the request payment config of the bucket, nil config removes it.
+func (bc *bucketRequestPaymentConfigs) Set(bucket string, config *RequestPaymentConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// IsRequesterPays - returns true if the requester pays for the requests
+// of the bucket.
+func (bc *bucketRequestPaymentConfigs) IsRequesterPays(bucket string) bool {
+	config, ok := bc.Get(bucket)
+	return ok && config.Payer == requestPaymentRequester
+}
+
+// Loads all bucket request payment configs from persistent layer.
+func loadAllBucketRequestPaymentConfigs(objAPI ObjectLayer) (map[string]RequestPaymentConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]RequestPaymentConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketRequestPaymentConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchRequestPaymentConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket request payment configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket request payment configs.
+func initBucketRequestPayment(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketRequestPaymentConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket request payment configs.
+	globalBucketRequestPayment = newBucketRequestPaymentConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketRequestPaymentConfig - reads the request payment config of the bucket.
+func readBucketRequestPaymentConfig(bucket string, objAPI ObjectLayer) (RequestPaymentConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketRequestPaymentConfig)
+
+	// Acquire a read lock on request payment config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return RequestPaymentConfiguration{}, errNoSuchRequestPaymentConfig
+		}
+		errorIf(err, "Unable to load request payment config for the bucket %s.", bucket)
+		return RequestPaymentConfiguration{}, errorCause(err)
+	}
+
+	var config RequestPaymentConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return RequestPaymentConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketRequestPaymentConfig - saves the request payment config of
+// the bucket, nil config removes any previously saved config.
+func writeBucketRequestPaymentConfig(bucket string, config *RequestPaymentConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketRequestPaymentConfig)
+
+	// Acquire a write lock on request payment config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove request payment config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set request payment config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketRequestPaymentChange - persists the request
+// payment config of the bucket and notifies all the nodes in the
+// cluster to update their in-memory state.
+func persistAndNotifyBucketRequestPaymentChange(bucket string, config *RequestPaymentConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketRequestPaymentConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketRequestPayment(bucket, config)
+	return nil
+}
+
+// requesterUsage - usage of a requester pays bucket charged to a
+// requester.
+type requesterUsage struct {
+	Requests      int64
+	BytesReceived int64
+	BytesSent     int64
+}
+
+// requesterPaysRecord - request of a requester pays bucket charged to
+// the requester.
+type requesterPaysRecord struct {
+	Time          time.Time
+	Bucket        string
+	Object        string
+	AccessKey     string
+	API           string
+	StatusCode    int
+	BytesReceived int64
+	BytesSent     int64
+}
+
+// requesterUsageRecorder - usage of the requester pays buckets of each
+// requester, since the server started.
+type requesterUsageRecorder struct {
+	mu sync.Mutex
+
+	// Usage indexed by bucket and access key.
+	usage map[string]map[string]requesterUsage
+}
+
+// Variable represents the usage of the requester pays buckets.
+var globalRequesterUsage = &requesterUsageRecorder{
+	usage: make(map[string]map[string]requesterUsage),
+}
+
+// Record - adds the request to the usage of the requester.
+func (u *requesterUsageRecorder) Record(record requesterPaysRecord) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	bucketUsage, ok := u.usage[record.Bucket]
+	if !ok {
+		bucketUsage = make(map[string]requesterUsage)
+		u.usage[record.Bucket] = bucketUsage
+	}
+	usage := bucketUsage[record.AccessKey]
+	usage.Requests++
+	usage.BytesReceived += record.BytesReceived
+	usage.BytesSent += record.BytesSent
+	bucketUsage[record.AccessKey] = usage
+}
+
+// Get - returns the usage of the bucket by the requester.
+func (u *requesterUsageRecorder) Get(bucket, accessKey string) requesterUsage {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	return u.usage[bucket][accessKey]
+}
+
+// Hook called with every request charged to the requester, records the
+// usage in memory by default. It can be replaced to forward the records
+// to a billing system.
+var recordRequesterPays = globalRequesterUsage.Record
+
+// Bucket configuration subresources, their requests are made by the
+// bucket owner and are never charged to the requester.
+var bucketConfigResources = []string{
+	"accelerate",
+	"acl",
+	"clientCert",
+	"contentSniffing",
+	"cors",
+	"events",
+	"lifecycle",
+	"location",
+	"logging",
+	"notification",
+	"object-lock",
+	"objectSizeLimits",
+	"policy",
+	"policyStatus",
+	"publicAccessBlock",
+	"replication",
+	"requestPayment",
+	"tagging",
+	"trash",
+	"versioning",
+	"website",
+}
+
+// isRequesterPaysRequest - returns true if the request is charged to the
+// requester on a requester pays bucket, i.e all the object requests and
+// the listings and uploads of the bucket.
+func isRequesterPaysRequest(r *http.Request, object string) bool {
+	if object != "" {
+		return true
+	}
+	if r.Method == httpPUT || r.Method == httpDELETE {
+		return false
+	}
+	query := r.URL.Query()
+	for _, resource := range bucketConfigResources {
+		if _, ok := query[resource]; ok {
+			return false
+		}
+	}
+	return true
+}
+
+type requesterPaysHandler struct {
+	handler http.Handler
+}
+
+// setRequesterPaysHandler - rejects the requests of the requester pays
+// buckets not acknowledging that the requester pays, the anonymous
+// requests are rejected as well since there is no requester to charge.
+// The requests accepted are recorded for the requester.
+func setRequesterPaysHandler(h http.Handler) http.Handler {
+	return requesterPaysHandler{h}
+}
+
+func (h requesterPaysHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	if bucket == "" || strings.HasPrefix(r.URL.Path, reservedBucket+slashSeparator) ||
+		!globalBucketRequestPayment.IsRequesterPays(bucket) || !isRequesterPaysRequest(r, object) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+
+	accessKey := getRequestAccessKey(r)
+	if accessKey == "" || !strings.EqualFold(r.Header.Get(amzRequestPayer), requestPayerValue) {
+		writeErrorResponse(w, ErrAccessDenied, r.URL)
+		return
+	}
+
+	startTime := time.Now().UTC()
+	body := &traceBodyReader{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = body
+	}
+	w.Header().Set(amzRequestCharged, requestPayerValue)
+	// Status and bytes sent are recorded like for the access logs.
+	rw := &accessLogResponseWriter{ResponseWriter: w, status: http.StatusOK}
+	h.handler.ServeHTTP(rw, r)
+
+	recordRequesterPays(requesterPaysRecord{
+		Time:          startTime,
+		Bucket:        bucket,
+		Object:        object,
+		AccessKey:     accessKey,
+		API:           getAccessLogOperation(r, object),
+		StatusCode:    rw.status,
+		BytesReceived: body.bytesRead,
+		BytesSent:     rw.bytesSent,
+	})
+}
@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests deleted objects are moved to the trash, hidden from listings,
+// restored and purged once expired.
+func TestBucketTrashHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testBucketTrashHandlers, []string{
+		"GetBucketTrash", "PutBucketTrash", "RestoreObjectFromTrash", "DeleteObject",
+	})
+}
+
+func testBucketTrashHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Trash configs are applied in-memory through the local peer.
+	initGlobalS3Peers(nil)
+
+	serveRequest := func(method, urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	putObject := func(object string, data []byte) {
+		metadata := map[string]string{"X-Amz-Meta-Color": "blue"}
+		if _, err := obj.PutObject(bucketName, object, int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+	}
+	deleteObject := func(object string) {
+		if rec := serveRequest("DELETE", getDeleteObjectURL("", bucketName, object), nil); rec.Code != http.StatusNoContent {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+		}
+		if _, err := obj.GetObjectInfo(bucketName, object); !isErrObjectNotFound(err) {
+			t.Fatalf("%s: Expected object %s to be deleted, got %v", instanceType, object, err)
+		}
+	}
+	restoreObject := func(object string) int {
+		return serveRequest("POST", getRestoreObjectFromTrashURL("", bucketName, object), nil).Code
+	}
+
+	// Invalid configs are rejected.
+	invalidConfigs := []string{
+		`<TrashConfiguration><Status>Enabled</Status></TrashConfiguration>`,
+		`<TrashConfiguration><Status>Enabled</Status><RetentionDays>-1</RetentionDays></TrashConfiguration>`,
+		`<TrashConfiguration><Status>Suspended</Status></TrashConfiguration>`,
+	}
+	for i, config := range invalidConfigs {
+		if rec := serveRequest("PUT", getBucketTrashURL("", bucketName), []byte(config)); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	config := `<TrashConfiguration><Status>Enabled</Status><RetentionDays>7</RetentionDays></TrashConfiguration>`
+	if rec := serveRequest("PUT", getBucketTrashURL("", bucketName), []byte(config)); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec := serveRequest("GET", getBucketTrashURL("", bucketName), nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var trashConfig TrashConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &trashConfig); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if trashConfig.Status != trashStatusEnabled || trashConfig.RetentionDays != 7 {
+		t.Fatalf("%s: Unexpected trash config %#v", instanceType, trashConfig)
+	}
+
+	// Delete then restore.
+	data := []byte("hello trash")
+	putObject("dir/object", data)
+	deleteObject("dir/object")
+	result, err := obj.ListObjects(bucketName, "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 0 || len(result.Prefixes) != 0 {
+		t.Fatalf("%s: Expected trashed objects to be hidden, got %#v", instanceType, result)
+	}
+	if code := restoreObject("dir/object"); code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, code)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucketName, "dir/object", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected restored content %q, got %q", instanceType, data, buffer.Bytes())
+	}
+	objInfo, err := obj.GetObjectInfo(bucketName, "dir/object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.UserDefined["X-Amz-Meta-Color"] != "blue" {
+		t.Fatalf("%s: Expected restored metadata, got %#v", instanceType, objInfo.UserDefined)
+	}
+	// The trash entry is consumed by the restore.
+	if code := restoreObject("dir/object"); code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, code)
+	}
+
+	// Restore does not overwrite a newer object.
+	deleteObject("dir/object")
+	putObject("dir/object", []byte("newer"))
+	if code := restoreObject("dir/object"); code != http.StatusConflict {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusConflict, code)
+	}
+
+	// Delete then expire.
+	putObject("expiring", data)
+	deleteObject("expiring")
+	entry, err := readTrashEntry(obj, getTrashEntryPath(bucketName, "expiring"))
+	if err != nil {
+		t.Fatalf("%s: Expected object in the trash, got %v", instanceType, err)
+	}
+	purgeExpiredTrash(obj, time.Now().UTC().AddDate(0, 0, 6))
+	if _, err = readTrashEntry(obj, getTrashEntryPath(bucketName, "expiring")); err != nil {
+		t.Fatalf("%s: Expected object to be retained in the trash, got %v", instanceType, err)
+	}
+	purgeExpiredTrash(obj, time.Now().UTC().AddDate(0, 0, 8))
+	if code := restoreObject("expiring"); code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, code)
+	}
+	if _, err = obj.GetObjectInfo(minioMetaBucket, getTrashDataPath(bucketName, entry.ID)); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected trash data to be purged, got %v", instanceType, err)
+	}
+
+	// Objects are deleted right away once the trash is disabled.
+	config = `<TrashConfiguration><Status>Disabled</Status></TrashConfiguration>`
+	if rec = serveRequest("PUT", getBucketTrashURL("", bucketName), []byte(config)); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	putObject("removed", data)
+	deleteObject("removed")
+	if code := restoreObject("removed"); code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, code)
+	}
+}
@@ -0,0 +1,494 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"path"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket trash config name.
+	bucketTrashConfig = "trash.xml"
+
+	// Status values of the trash config.
+	trashStatusEnabled  = "Enabled"
+	trashStatusDisabled = "Disabled"
+
+	// Trashed objects are moved under 'trash/<bucket>/<id>' in the
+	// meta bucket, each indexed by an entry under
+	// 'trash/<bucket>/entries/' named after the hash of its name.
+	trashPrefix        = "trash"
+	trashEntriesPrefix = "entries"
+)
+
+var (
+	// Interval between the purges of the expired trash.
+	trashReapInterval = time.Hour
+
+	// Expired trash of all the buckets is purged by a single reaper.
+	globalTrashReaperOnce sync.Once
+)
+
+// errInvalidTrashConfig - trash config is not valid.
+var errInvalidTrashConfig = errors.New("Invalid trash configuration")
+
+// errNoSuchTrashConfig - trash config is not set on the bucket.
+var errNoSuchTrashConfig = errors.New("The trash configuration does not exist")
+
+// errNoSuchTrashEntry - object is not in the trash of the bucket.
+var errNoSuchTrashEntry = errors.New("The object does not exist in the trash")
+
+// errTrashRestoreConflict - object restored from the trash already exists.
+var errTrashRestoreConflict = errors.New("Object to restore already exists")
+
+// TrashConfiguration - enables the trash mode of a bucket, deleted
+// objects are moved to the trash and retained for the given number of
+// days before being purged.
+type TrashConfiguration struct {
+	XMLName       xml.Name `xml:"TrashConfiguration"`
+	XMLNS         string   `xml:"xmlns,attr,omitempty"`
+	Status        string   `xml:"Status"`
+	RetentionDays int      `xml:"RetentionDays,omitempty"`
+}
+
+// validateTrashConfig - validates the status and the retention, which
+// is required when the trash is enabled.
+func validateTrashConfig(config TrashConfiguration) error {
+	switch config.Status {
+	case trashStatusEnabled:
+		if config.RetentionDays <= 0 {
+			return errInvalidTrashConfig
+		}
+	case trashStatusDisabled:
+		if config.RetentionDays != 0 {
+			return errInvalidTrashConfig
+		}
+	default:
+		return errInvalidTrashConfig
+	}
+	return nil
+}
+
+// Variable represents bucket trash configs in memory.
+var globalBucketTrash = newBucketTrashConfigs(nil)
+
+// bucketTrashConfigs - trash configs of all the buckets.
+type bucketTrashConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of trash configs indexed by 'bucket'.
+	configs map[string]TrashConfiguration
+}
+
+// newBucketTrashConfigs - initializes bucket trash configs.
+func newBucketTrashConfigs(configs map[string]TrashConfiguration) *bucketTrashConfigs {
+	if configs == nil {
+		configs = make(map[string]TrashConfiguration)
+	}
+	return &bucketTrashConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the trash config of the bucket, false if not set.
+func (bc *bucketTrashConfigs) Get(bucket string) (TrashConfiguration, bool) {
+	bc.rwMutex.RLock()
+	defer bc.rwMutex.RUnlock()
+	config, ok := bc.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the trash config of the bucket, nil config removes it.
+func (bc *bucketTrashConfigs) Set(bucket string, config *TrashConfiguration) {
+	bc.rwMutex.Lock()
+	defer bc.rwMutex.Unlock()
+	if config == nil {
+		delete(bc.configs, bucket)
+		return
+	}
+	bc.configs[bucket] = *config
+}
+
+// Loads all bucket trash configs from persistent layer.
+func loadAllBucketTrashConfigs(objAPI ObjectLayer) (map[string]TrashConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]TrashConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketTrashConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchTrashConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket trash configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket trash configs.
+func initBucketTrash(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketTrashConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket trash configs.
+	globalBucketTrash = newBucketTrashConfigs(configs)
+
+	// Expired trash is purged in background.
+	globalTrashReaperOnce.Do(func() {
+		startTrashReaper(newObjectLayerFn)
+	})
+
+	// Success.
+	return nil
+}
+
+// readBucketTrashConfig - reads the trash config of the bucket.
+func readBucketTrashConfig(bucket string, objAPI ObjectLayer) (TrashConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketTrashConfig)
+
+	// Acquire a read lock on trash config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return TrashConfiguration{}, errNoSuchTrashConfig
+		}
+		errorIf(err, "Unable to load trash config for the bucket %s.", bucket)
+		return TrashConfiguration{}, errorCause(err)
+	}
+
+	var config TrashConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return TrashConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketTrashConfig - saves the trash config of the bucket, nil
+// config removes any previously saved config.
+func writeBucketTrashConfig(bucket string, config *TrashConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketTrashConfig)
+
+	// Acquire a write lock on trash config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove trash config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set trash config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketTrashChange - persists the trash config of the
+// bucket and notifies all the nodes in the cluster to update their
+// in-memory state.
+func persistAndNotifyBucketTrashChange(bucket string, config *TrashConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketTrashConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketTrash(bucket, config)
+	return nil
+}
+
+// trashEntry - entry of the trash index, the last deleted copy of the
+// object, moved to the trash along with its metadata.
+type trashEntry struct {
+	Object    string    `json:"object"`
+	ID        string    `json:"id"`
+	DeletedAt time.Time `json:"deletedAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// getTrashEntriesPrefix - returns the prefix of the trash index of the
+// bucket.
+func getTrashEntriesPrefix(bucket string) string {
+	return path.Join(trashPrefix, bucket, trashEntriesPrefix) + slashSeparator
+}
+
+// getTrashEntryPath - returns the path of the trash entry of the object,
+// named after the hash of the object name since the names of objects
+// may be prefixes of one another.
+func getTrashEntryPath(bucket, object string) string {
+	return getTrashEntriesPrefix(bucket) + getSHA256Hash([]byte(object)) + ".json"
+}
+
+// getTrashDataPath - returns the path of the data of a trash entry.
+func getTrashDataPath(bucket, id string) string {
+	return path.Join(trashPrefix, bucket, id)
+}
+
+// readTrashEntry - reads an entry of the trash index, errNoSuchTrashEntry
+// if there is none.
+func readTrashEntry(objAPI ObjectLayer, entryPath string) (trashEntry, error) {
+	var entry trashEntry
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, entryPath, 0, -1, &buffer); err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return entry, errNoSuchTrashEntry
+		}
+		return entry, errorCause(err)
+	}
+	err := json.Unmarshal(buffer.Bytes(), &entry)
+	return entry, err
+}
+
+// writeTrashEntry - saves the trash entry of the object, replacing the
+// entry of its previously deleted copy.
+func writeTrashEntry(objAPI ObjectLayer, bucket string, entry trashEntry) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	entryPath := getTrashEntryPath(bucket, entry.Object)
+	if _, err = objAPI.PutObject(minioMetaBucket, entryPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeTrashEntry - removes the trash entry of the object.
+func removeTrashEntry(objAPI ObjectLayer, bucket, object string) error {
+	err := objAPI.DeleteObject(minioMetaBucket, getTrashEntryPath(bucket, object))
+	if err != nil && !isErrObjectNotFound(err) {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// removeTrashData - removes the data of a trash entry, errors are only
+// logged since the entry is already out of the index.
+func removeTrashData(bucket, id string, objAPI ObjectLayer) {
+	err := objAPI.DeleteObject(minioMetaBucket, getTrashDataPath(bucket, id))
+	if err != nil && !isErrObjectNotFound(err) {
+		errorIf(err, "Unable to remove trash data %s of the bucket %s.", id, bucket)
+	}
+}
+
+// isTrashEnabled - returns the retention of the trash of the bucket,
+// false if the trash mode is not enabled.
+func isTrashEnabled(bucket string) (int, bool) {
+	config, ok := globalBucketTrash.Get(bucket)
+	if !ok || config.Status != trashStatusEnabled {
+		return 0, false
+	}
+	return config.RetentionDays, true
+}
+
+// deleteObjectOrTrash - deletes the object, or moves it to the trash if
+// the trash mode is enabled on the bucket. Must be called with the
+// object lock held.
+func deleteObjectOrTrash(objAPI ObjectLayer, bucket, object string) error {
+	retentionDays, ok := isTrashEnabled(bucket)
+	if !ok {
+		return objAPI.DeleteObject(bucket, object)
+	}
+	return moveObjectToTrash(objAPI, bucket, object, retentionDays, time.Now().UTC())
+}
+
+// moveObjectToTrash - moves the object to the trash of the bucket,
+// retained for the given number of days, its data is not copied. Must
+// be called with the object lock held.
+func moveObjectToTrash(objAPI ObjectLayer, bucket, object string, retentionDays int, now time.Time) error {
+	prevEntry, err := readTrashEntry(objAPI, getTrashEntryPath(bucket, object))
+	hasPrevEntry := err == nil
+	if err != nil && err != errNoSuchTrashEntry {
+		return err
+	}
+
+	id := mustGetUUID()
+	dataPath := getTrashDataPath(bucket, id)
+	if _, err = objAPI.RenameObject(bucket, object, minioMetaBucket, dataPath); err != nil {
+		return err
+	}
+
+	err = writeTrashEntry(objAPI, bucket, trashEntry{
+		Object:    object,
+		ID:        id,
+		DeletedAt: now,
+		ExpiresAt: now.AddDate(0, 0, retentionDays),
+	})
+	if err != nil {
+		// Object is put back, as if the delete never happened.
+		if _, rerr := objAPI.RenameObject(minioMetaBucket, dataPath, bucket, object); rerr != nil {
+			errorIf(rerr, "Unable to move %s/%s back from the trash.", bucket, object)
+		}
+		return err
+	}
+
+	// Previously deleted copy of the object is replaced.
+	if hasPrevEntry {
+		removeTrashData(bucket, prevEntry.ID, objAPI)
+	}
+	return nil
+}
+
+// restoreObjectFromTrash - moves the object back from the trash of the
+// bucket, the object must not exist. Must be called with the object
+// lock held.
+func restoreObjectFromTrash(objAPI ObjectLayer, bucket, object string) (ObjectInfo, error) {
+	entry, err := readTrashEntry(objAPI, getTrashEntryPath(bucket, object))
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if _, err = objAPI.GetObjectInfo(bucket, object); err == nil {
+		return ObjectInfo{}, errTrashRestoreConflict
+	} else if !isErrObjectNotFound(err) {
+		return ObjectInfo{}, err
+	}
+
+	objInfo, err := objAPI.RenameObject(minioMetaBucket, getTrashDataPath(bucket, entry.ID), bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+
+	// The data is out of the trash, an entry left behind is dropped
+	// once expired.
+	errorIf(removeTrashEntry(objAPI, bucket, object), "Unable to remove the trash entry of %s/%s.", bucket, object)
+	return objInfo, nil
+}
+
+// purgeTrashEntry - purges the trash entry if expired at the given
+// time, or regardless if expired is false.
+func purgeTrashEntry(objAPI ObjectLayer, bucket, entryPath string, expired bool, now time.Time) error {
+	entry, err := readTrashEntry(objAPI, entryPath)
+	if err != nil {
+		if err == errNoSuchTrashEntry {
+			return nil
+		}
+		return err
+	}
+	if expired && entry.ExpiresAt.After(now) {
+		return nil
+	}
+
+	// Entries are replaced by the deletes and removed by the restores
+	// of their object under its lock, read the entry again under it.
+	objectLock := globalNSMutex.NewNSLock(bucket, entry.Object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	if entry, err = readTrashEntry(objAPI, entryPath); err != nil {
+		if err == errNoSuchTrashEntry {
+			return nil
+		}
+		return err
+	}
+	if expired && entry.ExpiresAt.After(now) {
+		return nil
+	}
+	if err = removeTrashEntry(objAPI, bucket, entry.Object); err != nil {
+		return err
+	}
+	removeTrashData(bucket, entry.ID, objAPI)
+	return nil
+}
+
+// purgeBucketTrash - purges the entries of the trash of the bucket
+// expired at the given time, all the entries if expired is false. The
+// entries failing to be purged are retried by the next purge.
+func purgeBucketTrash(bucket string, objAPI ObjectLayer, expired bool, now time.Time) error {
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, getTrashEntriesPrefix(bucket), marker, "", maxObjectList)
+		if err != nil {
+			return errorCause(err)
+		}
+		for _, entryInfo := range result.Objects {
+			err = purgeTrashEntry(objAPI, bucket, entryInfo.Name, expired, now)
+			errorIf(err, "Unable to purge the trash entry %s.", entryInfo.Name)
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// purgeExpiredTrash - purges the expired trash of all the buckets,
+// including the buckets whose trash mode was disabled since.
+func purgeExpiredTrash(objAPI ObjectLayer, now time.Time) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return
+	}
+	for _, bucket := range buckets {
+		err = purgeBucketTrash(bucket.Name, objAPI, true, now)
+		errorIf(err, "Unable to purge expired trash of the bucket %s.", bucket.Name)
+	}
+}
+
+// startTrashReaper - purges the expired trash periodically until the
+// server stops.
+func startTrashReaper(objAPI func() ObjectLayer) {
+	go func() {
+		ticker := time.NewTicker(trashReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if objLayer := objAPI(); objLayer != nil {
+					purgeExpiredTrash(objLayer, time.Now().UTC())
+				}
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
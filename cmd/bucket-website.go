@@ -0,0 +1,254 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+)
+
+const (
+	// Bucket website config name.
+	bucketWebsiteConfig = "website.xml"
+
+	// Object metadata redirecting the website requests of the object,
+	// returned as x-amz-website-redirect-location header.
+	websiteRedirectLocationKey = "x-amz-website-redirect-location"
+)
+
+// errInvalidWebsiteConfig - website config is not valid.
+var errInvalidWebsiteConfig = errors.New("Invalid website configuration")
+
+// errNoSuchWebsiteConfig - website config is not set on the bucket.
+var errNoSuchWebsiteConfig = errors.New("The specified bucket does not have a website configuration")
+
+// WebsiteIndexDocument - object returned for the requests of the
+// website root and its subdirectories.
+type WebsiteIndexDocument struct {
+	Suffix string `xml:"Suffix"`
+}
+
+// WebsiteErrorDocument - object returned when the requested object
+// does not exist.
+type WebsiteErrorDocument struct {
+	Key string `xml:"Key"`
+}
+
+// WebsiteConfiguration - serves the objects of the bucket as a static
+// website.
+type WebsiteConfiguration struct {
+	XMLName       xml.Name              `xml:"WebsiteConfiguration"`
+	XMLNS         string                `xml:"xmlns,attr,omitempty"`
+	IndexDocument *WebsiteIndexDocument `xml:"IndexDocument"`
+	ErrorDocument *WebsiteErrorDocument `xml:"ErrorDocument,omitempty"`
+	// Redirects and routing rules are not supported, set only to
+	// reject such configs.
+	RedirectAllRequestsTo *websiteUnsupported `xml:"RedirectAllRequestsTo"`
+	RoutingRules          *websiteUnsupported `xml:"RoutingRules"`
+}
+
+// websiteUnsupported - website config element which is not supported.
+type websiteUnsupported struct {
+	InnerXML string `xml:",innerxml"`
+}
+
+// validateWebsiteConfig - validates the index and error documents of
+// the website config.
+func validateWebsiteConfig(config WebsiteConfiguration) error {
+	if config.RedirectAllRequestsTo != nil || config.RoutingRules != nil {
+		return NotImplemented{}
+	}
+	// Index document suffix must be a non empty name without slashes.
+	if config.IndexDocument == nil || config.IndexDocument.Suffix == "" ||
+		strings.Contains(config.IndexDocument.Suffix, slashSeparator) {
+		return errInvalidWebsiteConfig
+	}
+	if config.ErrorDocument != nil && !IsValidObjectName(config.ErrorDocument.Key) {
+		return errInvalidWebsiteConfig
+	}
+	return nil
+}
+
+// isValidWebsiteRedirectLocation - returns true if the redirect
+// location is a path of the website or an absolute http(s) URL.
+func isValidWebsiteRedirectLocation(location string) bool {
+	return strings.HasPrefix(location, slashSeparator) ||
+		strings.HasPrefix(location, "http://") ||
+		strings.HasPrefix(location, "https://")
+}
+
+// isWebsiteRedirectObject - returns true if the object uploaded with
+// the headers is stored purely as a website redirect, without data.
+func isWebsiteRedirectObject(header http.Header, size int64) bool {
+	return size == 0 && header.Get(websiteRedirectLocationKey) != ""
+}
+
+// Variable represents bucket website configs in memory.
+var globalBucketWebsites = newBucketWebsiteConfigs(nil)
+
+// bucketWebsiteConfigs - website configs of all the buckets.
+type bucketWebsiteConfigs struct {
+	rwMutex *sync.RWMutex
+
+	// Collection of website configs indexed by 'bucket'.
+	configs map[string]WebsiteConfiguration
+}
+
+// newBucketWebsiteConfigs - initializes bucket website configs.
+func newBucketWebsiteConfigs(configs map[string]WebsiteConfiguration) *bucketWebsiteConfigs {
+	if configs == nil {
+		configs = make(map[string]WebsiteConfiguration)
+	}
+	return &bucketWebsiteConfigs{
+		rwMutex: &sync.RWMutex{},
+		configs: configs,
+	}
+}
+
+// Get - returns the website config of the bucket, false if not set.
+func (bw *bucketWebsiteConfigs) Get(bucket string) (WebsiteConfiguration, bool) {
+	bw.rwMutex.RLock()
+	defer bw.rwMutex.RUnlock()
+	config, ok := bw.configs[bucket]
+	return config, ok
+}
+
+// Set - sets the website config of the bucket, nil config removes it.
+func (bw *bucketWebsiteConfigs) Set(bucket string, config *WebsiteConfiguration) {
+	bw.rwMutex.Lock()
+	defer bw.rwMutex.Unlock()
+	if config == nil {
+		delete(bw.configs, bucket)
+		return
+	}
+	bw.configs[bucket] = *config
+}
+
+// Loads all bucket website configs from persistent layer.
+func loadAllBucketWebsiteConfigs(objAPI ObjectLayer) (map[string]WebsiteConfiguration, error) {
+	buckets, err := objAPI.ListBuckets()
+	errorIf(err, "Unable to list buckets.")
+	if err != nil {
+		return nil, errorCause(err)
+	}
+
+	configs := make(map[string]WebsiteConfiguration)
+	for _, bucket := range buckets {
+		config, cErr := readBucketWebsiteConfig(bucket.Name, objAPI)
+		if cErr != nil {
+			if !isErrIgnored(cErr, errNoSuchWebsiteConfig, errDiskNotFound) {
+				return nil, cErr
+			}
+			// Continue to load other bucket website configs if possible.
+			continue
+		}
+		configs[bucket.Name] = config
+	}
+	return configs, nil
+}
+
+// Intialize all bucket website configs.
+func initBucketWebsite(objAPI ObjectLayer) error {
+	if objAPI == nil {
+		return errInvalidArgument
+	}
+
+	configs, err := loadAllBucketWebsiteConfigs(objAPI)
+	if err != nil {
+		return err
+	}
+
+	// Populate global bucket website configs.
+	globalBucketWebsites = newBucketWebsiteConfigs(configs)
+
+	// Success.
+	return nil
+}
+
+// readBucketWebsiteConfig - reads the website config of the bucket.
+func readBucketWebsiteConfig(bucket string, objAPI ObjectLayer) (WebsiteConfiguration, error) {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketWebsiteConfig)
+
+	// Acquire a read lock on website config before reading.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.RLock()
+	defer objLock.RUnlock()
+
+	var buffer bytes.Buffer
+	err := objAPI.GetObject(minioMetaBucket, configPath, 0, -1, &buffer)
+	if err != nil {
+		if isErrObjectNotFound(err) || isErrIncompleteBody(err) {
+			return WebsiteConfiguration{}, errNoSuchWebsiteConfig
+		}
+		errorIf(err, "Unable to load website config for the bucket %s.", bucket)
+		return WebsiteConfiguration{}, errorCause(err)
+	}
+
+	var config WebsiteConfiguration
+	if err = xml.Unmarshal(buffer.Bytes(), &config); err != nil {
+		return WebsiteConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketWebsiteConfig - saves the website config of the bucket,
+// nil config removes any previously saved config.
+func writeBucketWebsiteConfig(bucket string, config *WebsiteConfiguration, objAPI ObjectLayer) error {
+	configPath := path.Join(bucketConfigPrefix, bucket, bucketWebsiteConfig)
+
+	// Acquire a write lock on website config before modifying.
+	objLock := globalNSMutex.NewNSLock(minioMetaBucket, configPath)
+	objLock.Lock()
+	defer objLock.Unlock()
+
+	if config == nil {
+		err := objAPI.DeleteObject(minioMetaBucket, configPath)
+		if err != nil && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to remove website config of the bucket %s.", bucket)
+			return errorCause(err)
+		}
+		return nil
+	}
+
+	buf, err := xml.Marshal(config)
+	if err != nil {
+		return err
+	}
+	if _, err = objAPI.PutObject(minioMetaBucket, configPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		errorIf(err, "Unable to set website config for the bucket %s.", bucket)
+		return errorCause(err)
+	}
+	return nil
+}
+
+// persistAndNotifyBucketWebsiteChange - persists the website config of
+// the bucket and notifies all the nodes in the cluster to update their
+// in-memory state.
+func persistAndNotifyBucketWebsiteChange(bucket string, config *WebsiteConfiguration, objAPI ObjectLayer) error {
+	if err := writeBucketWebsiteConfig(bucket, config, objAPI); err != nil {
+		return err
+	}
+
+	// Notify all peers (including self) to update in-memory state
+	S3PeersUpdateBucketWebsite(bucket, config)
+	return nil
+}
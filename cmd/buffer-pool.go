@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// setUploadBufferPool - sets the pooling of the buffers of the data
+// path and the size of the buffers of the FS backend from
+// MINIO_UPLOAD_BUFFER_POOL and MINIO_UPLOAD_BUFFER_SIZE env.
+func setUploadBufferPool() {
+	if bufferPool := os.Getenv("MINIO_UPLOAD_BUFFER_POOL"); bufferPool != "" {
+		switch strings.ToLower(bufferPool) {
+		case "on":
+			globalIsUploadBufferPool = true
+		case "off":
+			globalIsUploadBufferPool = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_UPLOAD_BUFFER_POOL value %s.", bufferPool)
+		}
+	}
+	if bufferSize := os.Getenv("MINIO_UPLOAD_BUFFER_SIZE"); bufferSize != "" {
+		size, err := humanize.ParseBytes(bufferSize)
+		if err != nil || size == 0 || size > maxObjectSize {
+			fatalIf(errInvalidArgument, "Invalid MINIO_UPLOAD_BUFFER_SIZE value %s.", bufferSize)
+		}
+		globalUploadBufferSize = int64(size)
+	}
+}
+
+// bufferPool - pool of the reusable buffers of the data path, so that
+// the buffers of the concurrent uploads are not allocated again and
+// again. The buffers have a fixed length and capacity, the capacity
+// may be larger so that the buffers are extended in place. A buffer
+// must not be referenced anymore once put back into the pool.
+type bufferPool struct {
+	size     int
+	capacity int
+	pool     sync.Pool
+}
+
+// Pools of the buffers indexed by their length and capacity.
+var bufferPools = struct {
+	mutex sync.Mutex
+	pools map[[2]int]*bufferPool
+}{pools: make(map[[2]int]*bufferPool)}
+
+// getBufferPool - returns the pool of the buffers of size bytes with
+// a capacity of at least capacity bytes.
+func getBufferPool(size, capacity int) *bufferPool {
+	if capacity < size {
+		capacity = size
+	}
+	bufferPools.mutex.Lock()
+	defer bufferPools.mutex.Unlock()
+
+	key := [2]int{size, capacity}
+	pool, ok := bufferPools.pools[key]
+	if !ok {
+		pool = &bufferPool{size: size, capacity: capacity}
+		bufferPools.pools[key] = pool
+	}
+	return pool
+}
+
+// Get - returns a buffer from the pool, a new one if the pool is empty
+// or the pooling is disabled.
+func (p *bufferPool) Get() []byte {
+	if globalIsUploadBufferPool {
+		if buf, ok := p.pool.Get().(*[]byte); ok {
+			return (*buf)[:p.size]
+		}
+	}
+	return make([]byte, p.size, p.capacity)
+}
+
+// Put - puts the buffer back into the pool for reuse. Buffers of other
+// pools, or extended beyond their capacity, are left to the GC.
+func (p *bufferPool) Put(buf []byte) {
+	if !globalIsUploadBufferPool || cap(buf) != p.capacity {
+		return
+	}
+	buf = buf[:p.size]
+	p.pool.Put(&buf)
+}
+
+// getUploadBufferPool - returns the pool of the buffers copying the
+// object data of the FS backend.
+func getUploadBufferPool() *bufferPool {
+	return getBufferPool(int(globalUploadBufferSize), 0)
+}
+
+// getErasureBufferPool - returns the pool of the buffers erasure coding
+// blockSize bytes, large enough to hold the parity blocks as well.
+func getErasureBufferPool(blockSize int64, dataBlocks, parityBlocks int) *bufferPool {
+	blockLength := int(blockSize)
+	shardSize := (blockLength + dataBlocks - 1) / dataBlocks
+	return getBufferPool(blockLength, shardSize*(dataBlocks+parityBlocks))
+}
@@ -0,0 +1,197 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/klauspost/reedsolomon"
+)
+
+// Tests the buffers of a pool have its length and capacity, and the
+// buffers of other pools are not put back into it.
+func TestBufferPool(t *testing.T) {
+	defer func(isPool bool) { globalIsUploadBufferPool = isPool }(globalIsUploadBufferPool)
+	globalIsUploadBufferPool = true
+
+	pool := getBufferPool(100, 150)
+	if pool != getBufferPool(100, 150) {
+		t.Fatal("Expected the same pool for the same length and capacity")
+	}
+	if pool == getBufferPool(100, 0) {
+		t.Fatal("Expected a different pool for a different capacity")
+	}
+	buf := pool.Get()
+	if len(buf) != 100 || cap(buf) != 150 {
+		t.Fatalf("Expected a buffer of length 100 and capacity 150, got %d and %d", len(buf), cap(buf))
+	}
+	pool.Put(buf[:10])
+	buf = pool.Get()
+	if len(buf) != 100 || cap(buf) != 150 {
+		t.Fatalf("Expected a buffer of length 100 and capacity 150, got %d and %d", len(buf), cap(buf))
+	}
+
+	// Buffers of other capacities are dropped.
+	pool.Put(make([]byte, 100))
+	if buf = pool.Get(); cap(buf) != 150 {
+		t.Fatalf("Expected a buffer of capacity 150, got %d", cap(buf))
+	}
+
+	// Buffers are always allocated once the pooling is disabled.
+	globalIsUploadBufferPool = false
+	pool.Put(buf)
+	buf[0] = 1
+	if buf = pool.Get(); buf[0] != 0 || len(buf) != 100 {
+		t.Fatal("Expected a new buffer once the pooling is disabled")
+	}
+}
+
+// Tests the blocks split in place are the blocks split by reedsolomon,
+// even if the buffer holds previous data.
+func TestSplitData(t *testing.T) {
+	dataBlocks, parityBlocks := 5, 3
+	rs, err := reedsolomon.New(dataBlocks, parityBlocks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blockSize := int64(1000)
+	pool := getErasureBufferPool(blockSize, dataBlocks, parityBlocks)
+
+	for _, size := range []int{1, 4, 5, 6, 999, 1000} {
+		data := make([]byte, size)
+		if _, err = rand.Read(data); err != nil {
+			t.Fatal(err)
+		}
+		expected, err := rs.Split(append([]byte(nil), data...))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Dirty the buffer beyond its length.
+		buf := pool.Get()
+		full := buf[:cap(buf)]
+		for i := range full {
+			full[i] = 0xff
+		}
+		copy(buf, data)
+		blocks, err := splitData(rs, buf[:size], dataBlocks, parityBlocks)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(blocks) != len(expected) {
+			t.Fatalf("Size %d: Expected %d blocks, got %d", size, len(expected), len(blocks))
+		}
+		for i := range blocks {
+			if !bytes.Equal(blocks[i][:len(expected[i])], expected[i]) || (i < dataBlocks && len(blocks[i]) != len(expected[i])) {
+				t.Fatalf("Size %d: Block %d differs from the reedsolomon block", size, i)
+			}
+		}
+		pool.Put(buf)
+	}
+
+	if _, err = splitData(rs, nil, dataBlocks, parityBlocks); err != reedsolomon.ErrShortData {
+		t.Fatalf("Expected %v, got %v", reedsolomon.ErrShortData, err)
+	}
+}
+
+// Tests the concurrent uploads sharing the pooled buffers store their
+// own data.
+func TestPutObjectPooledBuffers(t *testing.T) {
+	ExecObjectLayerTest(t, testPutObjectPooledBuffers)
+}
+
+func testPutObjectPooledBuffers(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+
+	objects := make([][]byte, 8)
+	for i := range objects {
+		objects[i] = make([]byte, 3*humanize.MiByte+i*1234)
+		if _, err := rand.Read(objects[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for round := 0; round < 2; round++ {
+		errs := make([]error, len(objects))
+		var wg sync.WaitGroup
+		for i := range objects {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				data := objects[i]
+				_, errs[i] = obj.PutObject(bucket, fmt.Sprintf("object-%d", i), int64(len(data)), bytes.NewReader(data), nil, "")
+			}(i)
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("%s: Failed to upload object-%d: <ERROR> %v", instanceType, i, err)
+			}
+		}
+
+		for i, data := range objects {
+			var buffer bytes.Buffer
+			if err := obj.GetObject(bucket, fmt.Sprintf("object-%d", i), 0, int64(len(data)), &buffer); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buffer.Bytes(), data) {
+				t.Fatalf("%s: Contents of object-%d differ from the uploaded data", instanceType, i)
+			}
+		}
+	}
+}
+
+// Benchmarks the allocations of erasure coding an object with and
+// without the pooled buffers.
+func BenchmarkErasureCreateFile(b *testing.B) {
+	for _, isPool := range []bool{true, false} {
+		b.Run(fmt.Sprintf("pool=%t", isPool), func(b *testing.B) {
+			defer func(isPool bool) { globalIsUploadBufferPool = isPool }(globalIsUploadBufferPool)
+			globalIsUploadBufferPool = isPool
+
+			dataBlocks, parityBlocks := 8, 8
+			blockSize := int64(blockSizeV1)
+			setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+			if err != nil {
+				b.Fatal(err)
+			}
+			defer setup.Remove()
+
+			data := make([]byte, 4*humanize.MiByte)
+			if _, err = rand.Read(data); err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(data)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				object := fmt.Sprintf("object-%d", i)
+				if _, _, err = erasureCreateFile(setup.disks, "testbucket", object, bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, bitRotAlgo, dataBlocks+1); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
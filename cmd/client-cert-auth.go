@@ -0,0 +1,165 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio-go/pkg/set"
+)
+
+// Client certificate authentication (mTLS) is enabled when CA
+// certificates are present in the "client-CAs" directory of the certs
+// path. Every TLS client is then required to present a certificate
+// signed by one of these CAs, connections without one are rejected
+// during the handshake. A verified certificate whose identity, i.e its
+// subject common name, DNS or email SAN, is mapped to a canned policy
+// in "client-identities.json" authenticates requests without access
+// keys. In a distributed setup the server certificate is presented to
+// peers as client certificate, hence it must be issued by one of the
+// client CAs and allow client authentication. Identities are mapped
+// as below, for example
+//
+//  {
+//    "backup.example.com": "readonly",
+//    "ingest-agent": "writeonly"
+//  }
+
+var (
+	// Actions allowed for identities with readonly policy.
+	clientCertReadActions = set.CreateStringSet("s3:GetBucketLocation", "s3:ListBucket", "s3:GetObject")
+
+	// Actions allowed for identities with writeonly policy.
+	clientCertWriteActions = set.CreateStringSet("s3:GetBucketLocation", "s3:ListBucketMultipartUploads",
+		"s3:AbortMultipartUpload", "s3:DeleteObject", "s3:ListMultipartUploadParts", "s3:PutObject")
+)
+
+// mustGetClientCAFiles must get the list of the client CA certificates
+// stored in minio config dir.
+func mustGetClientCAFiles() (caCerts []string) {
+	CAsDir := filepath.Join(mustGetCertsPath(), globalMinioClientCertsCADir)
+	caFiles, _ := ioutil.ReadDir(CAsDir)
+	for _, cert := range caFiles {
+		caCerts = append(caCerts, filepath.Join(CAsDir, cert.Name()))
+	}
+	return
+}
+
+// parseClientCertIdentities - parses client certificate identities
+// mapped to canned policies.
+func parseClientCertIdentities(data []byte) (map[string]policy.BucketPolicy, error) {
+	identities := make(map[string]policy.BucketPolicy)
+	if err := json.Unmarshal(data, &identities); err != nil {
+		return nil, err
+	}
+	for identity, bucketPolicy := range identities {
+		if bucketPolicy == policy.BucketPolicyNone || !bucketPolicy.IsValidBucketPolicy() {
+			return nil, fmt.Errorf("Invalid policy %s for client identity %s", bucketPolicy, identity)
+		}
+	}
+	return identities, nil
+}
+
+// loadClientCAs - loads client CA files provided in minio config into
+// globalClientCAs, along with the client certificate identities.
+func loadClientCAs() {
+	caFiles := mustGetClientCAFiles()
+	if len(caFiles) == 0 {
+		return
+	}
+	clientCAs := x509.NewCertPool()
+	for _, caFile := range caFiles {
+		caCert, err := ioutil.ReadFile(caFile)
+		fatalIf(err, "Unable to load a client CA file")
+		if !clientCAs.AppendCertsFromPEM(caCert) {
+			fatalIf(errInvalidArgument, "Unable to parse client CA file %s", caFile)
+		}
+	}
+
+	identities := make(map[string]policy.BucketPolicy)
+	data, err := ioutil.ReadFile(filepath.Join(mustGetCertsPath(), globalMinioClientIdentitiesFile))
+	if err != nil && !os.IsNotExist(err) {
+		fatalIf(err, "Unable to load client identities file")
+	}
+	if err == nil {
+		identities, err = parseClientCertIdentities(data)
+		fatalIf(err, "Unable to parse client identities file")
+	}
+
+	globalClientCAs = clientCAs
+	globalClientCertIdentities = identities
+}
+
+// getClientCertIdentities - returns the identities of a client
+// certificate, subject common name followed by DNS and email SANs.
+func getClientCertIdentities(cert *x509.Certificate) []string {
+	var identities []string
+	if cert.Subject.CommonName != "" {
+		identities = append(identities, cert.Subject.CommonName)
+	}
+	identities = append(identities, cert.DNSNames...)
+	identities = append(identities, cert.EmailAddresses...)
+	return identities
+}
+
+// getClientCertPolicy - returns the policy mapped to the identity of
+// the verified client certificate of the request, if any.
+func getClientCertPolicy(r *http.Request) (policy.BucketPolicy, bool) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return policy.BucketPolicyNone, false
+	}
+	for _, identity := range getClientCertIdentities(r.TLS.VerifiedChains[0][0]) {
+		if bucketPolicy, ok := globalClientCertIdentities[identity]; ok {
+			return bucketPolicy, true
+		}
+	}
+	return policy.BucketPolicyNone, false
+}
+
+// Verify if request is authenticated by a mapped client certificate.
+func isRequestClientCert(r *http.Request) bool {
+	_, ok := getClientCertPolicy(r)
+	return ok
+}
+
+// isReqAuthenticatedByCert - verifies if the policy of the client
+// certificate identity allows the action.
+func isReqAuthenticatedByCert(r *http.Request, action string) APIErrorCode {
+	bucketPolicy, ok := getClientCertPolicy(r)
+	if !ok {
+		return ErrAccessDenied
+	}
+	switch bucketPolicy {
+	case policy.BucketPolicyReadOnly:
+		ok = clientCertReadActions.Contains(action)
+	case policy.BucketPolicyWriteOnly:
+		ok = clientCertWriteActions.Contains(action)
+	case policy.BucketPolicyReadWrite:
+		ok = clientCertReadActions.Contains(action) || clientCertWriteActions.Contains(action)
+	}
+	if !ok {
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
@@ -0,0 +1,273 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/pkg/policy"
+)
+
+// Tests parsing client certificate identities.
+func TestParseClientCertIdentities(t *testing.T) {
+	testCases := []struct {
+		data       string
+		identities map[string]policy.BucketPolicy
+		shouldPass bool
+	}{
+		{`{}`, map[string]policy.BucketPolicy{}, true},
+		{`{"backup.example.com": "readonly", "ingest-agent": "writeonly", "admin@example.com": "readwrite"}`,
+			map[string]policy.BucketPolicy{
+				"backup.example.com": policy.BucketPolicyReadOnly,
+				"ingest-agent":       policy.BucketPolicyWriteOnly,
+				"admin@example.com":  policy.BucketPolicyReadWrite,
+			}, true},
+		{`{"ingest-agent": "none"}`, nil, false},
+		{`{"ingest-agent": "invalid"}`, nil, false},
+		{`["ingest-agent"]`, nil, false},
+	}
+	for i, testCase := range testCases {
+		identities, err := parseClientCertIdentities([]byte(testCase.data))
+		if testCase.shouldPass && err != nil {
+			t.Fatalf("Test %d: Expected to pass, but failed with %s", i+1, err)
+		}
+		if !testCase.shouldPass {
+			if err == nil {
+				t.Fatalf("Test %d: Expected to fail, but passed", i+1)
+			}
+			continue
+		}
+		if len(identities) != len(testCase.identities) {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.identities, identities)
+		}
+		for identity, bucketPolicy := range testCase.identities {
+			if identities[identity] != bucketPolicy {
+				t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.identities, identities)
+			}
+		}
+	}
+}
+
+// Tests authenticating requests with verified client certificates.
+func TestIsReqAuthenticatedByCert(t *testing.T) {
+	defer func() { globalClientCertIdentities = nil }()
+	globalClientCertIdentities = map[string]policy.BucketPolicy{
+		"reader":             policy.BucketPolicyReadOnly,
+		"writer.example.com": policy.BucketPolicyWriteOnly,
+		"admin@example.com":  policy.BucketPolicyReadWrite,
+	}
+
+	newCertRequest := func(cert *x509.Certificate) *http.Request {
+		req, err := newTestRequest("GET", "/bucket/object", 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.TLS = &tls.ConnectionState{}
+		if cert != nil {
+			req.TLS.VerifiedChains = [][]*x509.Certificate{{cert}}
+		}
+		return req
+	}
+
+	reader := &x509.Certificate{Subject: pkix.Name{CommonName: "reader"}}
+	writer := &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}, DNSNames: []string{"writer.example.com"}}
+	admin := &x509.Certificate{EmailAddresses: []string{"admin@example.com"}}
+	unknown := &x509.Certificate{Subject: pkix.Name{CommonName: "unknown"}}
+
+	testCases := []struct {
+		cert     *x509.Certificate
+		action   string
+		authType authType
+		s3Error  APIErrorCode
+	}{
+		{reader, "s3:GetObject", authTypeClientCert, ErrNone},
+		{reader, "s3:ListBucket", authTypeClientCert, ErrNone},
+		{reader, "s3:PutObject", authTypeClientCert, ErrAccessDenied},
+		{writer, "s3:PutObject", authTypeClientCert, ErrNone},
+		{writer, "s3:DeleteObject", authTypeClientCert, ErrNone},
+		{writer, "s3:GetObject", authTypeClientCert, ErrAccessDenied},
+		{admin, "s3:GetObject", authTypeClientCert, ErrNone},
+		{admin, "s3:PutObject", authTypeClientCert, ErrNone},
+		// Bucket and admin operations are not allowed.
+		{admin, "", authTypeClientCert, ErrAccessDenied},
+		// Unmapped identities and requests without certificates are anonymous.
+		{unknown, "s3:GetObject", authTypeAnonymous, ErrAccessDenied},
+		{nil, "s3:GetObject", authTypeAnonymous, ErrAccessDenied},
+	}
+	for i, testCase := range testCases {
+		req := newCertRequest(testCase.cert)
+		if aType := getRequestAuthType(req); aType != testCase.authType {
+			t.Errorf("Test %d: Expected auth type %d, got %d", i+1, testCase.authType, aType)
+		}
+		if s3Error := isReqAuthenticatedByCert(req, testCase.action); s3Error != testCase.s3Error {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, getAPIError(testCase.s3Error), getAPIError(s3Error))
+		}
+	}
+
+	// Signed requests are not authenticated by the client certificate.
+	req := newCertRequest(reader)
+	req.Header.Set("Authorization", signV4Algorithm)
+	if aType := getRequestAuthType(req); aType != authTypeSigned {
+		t.Errorf("Expected auth type %d, got %d", authTypeSigned, aType)
+	}
+}
+
+// newTestClientCert - creates a certificate for client authentication
+// signed by the given CA, a self-signed CA certificate is created when
+// the CA is nil.
+func newTestClientCert(commonName string, ca *tls.Certificate) (tls.Certificate, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().UTC().Add(-time.Minute),
+		NotAfter:              time.Now().UTC().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	parent, signer := &template, priv
+	if ca == nil {
+		template.IsCA = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		if parent, err = x509.ParseCertificate(ca.Certificate[0]); err != nil {
+			return tls.Certificate{}, err
+		}
+		signer = ca.PrivateKey.(*rsa.PrivateKey)
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, parent, &priv.PublicKey, signer)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{derBytes}, PrivateKey: priv}, nil
+}
+
+// Tests that the server requires and verifies client certificates when
+// client CAs are configured.
+func TestServerListenAndServeClientCert(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed: %s", err)
+	}
+	defer removeAll(rootPath)
+
+	ca, err := newTestClientCert("Minio Test CA", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientCert, err := newTestClientCert("ingest-agent", &ca)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrustedCA, err := newTestClientCert("Untrusted Test CA", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	untrustedCert, err := newTestClientCert("ingest-agent", &untrustedCA)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caCert, err := x509.ParseCertificate(ca.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	globalClientCAs = x509.NewCertPool()
+	globalClientCAs.AddCert(caCert)
+	globalClientCertIdentities = map[string]policy.BucketPolicy{"ingest-agent": policy.BucketPolicyWriteOnly}
+	defer func() {
+		globalClientCAs = nil
+		globalClientCertIdentities = nil
+	}()
+
+	// Initialize done channel specifically for each tests.
+	globalServiceDoneCh = make(chan struct{}, 1)
+
+	addr := net.JoinHostPort("127.0.0.1", getFreePort())
+	m := NewServerMux(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if getRequestAuthType(r) != authTypeClientCert {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer m.Close()
+
+	if err = createCertsPath(); err != nil {
+		t.Fatal(err)
+	}
+	certFile := mustGetCertFile()
+	keyFile := mustGetKeyFile()
+	defer os.RemoveAll(certFile)
+	defer os.RemoveAll(keyFile)
+	if err = generateTestCert(addr); err != nil {
+		t.Fatal(err)
+	}
+	go m.ListenAndServe(certFile, keyFile)
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{
+			Timeout: 5 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{InsecureSkipVerify: true, Certificates: certs},
+			},
+		}
+	}
+
+	// Wait for the server to accept connections.
+	client := newClient(clientCert)
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		if resp, err = client.Get("https://" + addr); err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Expected request with a valid client certificate to pass, but failed with %s", err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	// Untrusted client certificates and connections without client
+	// certificates are rejected during the handshake.
+	for i, client := range []*http.Client{newClient(untrustedCert), newClient()} {
+		if resp, err = client.Get("https://" + addr); err == nil {
+			resp.Body.Close()
+			t.Fatalf("Test %d: Expected the request to be rejected, but got status %d", i+1, resp.StatusCode)
+		}
+	}
+}
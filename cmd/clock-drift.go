@@ -0,0 +1,202 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// Default maximum drift of the clock from the NTP reference
+	// before an alert, well within the allowed skew of the signatures.
+	globalDefaultNTPMaxDrift = time.Minute
+
+	// Default interval between two checks of the clock drift.
+	globalDefaultNTPCheckInterval = time.Hour
+
+	// Default port and timeout of the NTP queries.
+	ntpDefaultPort = "123"
+	ntpTimeout     = 5 * time.Second
+
+	// Seconds from the NTP epoch, 1900, to the Unix epoch.
+	ntpEpochOffset = 2208988800
+)
+
+// errClockDrift - the clock drifted from the NTP reference past the
+// maximum drift.
+var errClockDrift = errors.New("Clock drifted from the NTP reference past the maximum drift")
+
+// setClockDriftCheck - sets the NTP reference of the clock, its maximum
+// drift, the interval between two checks and whether the server refuses
+// to start past the maximum drift from MINIO_NTP_SERVER,
+// MINIO_NTP_MAX_DRIFT, MINIO_NTP_CHECK_INTERVAL and MINIO_NTP_STRICT env.
+func setClockDriftCheck() {
+	if server := os.Getenv("MINIO_NTP_SERVER"); server != "" {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			server = net.JoinHostPort(server, ntpDefaultPort)
+		}
+		globalNTPServer = server
+	}
+	getDuration := func(envName string, value time.Duration) time.Duration {
+		if s := os.Getenv(envName); s != "" {
+			duration, err := time.ParseDuration(s)
+			fatalIf(err, "Invalid %s value %s.", envName, s)
+			if duration < 0 {
+				fatalIf(errInvalidArgument, "Invalid %s value %s.", envName, s)
+			}
+			return duration
+		}
+		return value
+	}
+	globalNTPMaxDrift = getDuration("MINIO_NTP_MAX_DRIFT", globalNTPMaxDrift)
+	globalNTPCheckInterval = getDuration("MINIO_NTP_CHECK_INTERVAL", globalNTPCheckInterval)
+	if strict := os.Getenv("MINIO_NTP_STRICT"); strict != "" {
+		switch strings.ToLower(strict) {
+		case "on":
+			globalIsNTPStrict = true
+		case "off":
+			globalIsNTPStrict = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_NTP_STRICT value %s.", strict)
+		}
+	}
+}
+
+// ntpTime - converts a 64-bit NTP timestamp.
+func ntpTime(b []byte) time.Time {
+	secs := int64(binary.BigEndian.Uint32(b[0:4])) - ntpEpochOffset
+	frac := int64(binary.BigEndian.Uint32(b[4:8]))
+	return time.Unix(secs, (frac*int64(time.Second))>>32).UTC()
+}
+
+// queryNTPOffset - returns the offset of the NTP server clock from the
+// clock given by now, a positive offset means that clock is behind.
+// Implements the client side of SNTP (RFC 4330).
+func queryNTPOffset(server string, now func() time.Time) (time.Duration, error) {
+	conn, err := net.DialTimeout("udp", server, ntpTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(ntpTimeout))
+
+	// Version 4, client mode.
+	req := make([]byte, 48)
+	req[0] = 4<<3 | 3
+	originTime := now()
+	if _, err = conn.Write(req); err != nil {
+		return 0, err
+	}
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return 0, err
+	}
+	destTime := now()
+	// Server mode with a transmit timestamp.
+	if n < 48 || resp[0]&0x7 != 4 || binary.BigEndian.Uint64(resp[40:48]) == 0 {
+		return 0, errors.New("Invalid NTP response")
+	}
+	receiveTime := ntpTime(resp[32:40])
+	transmitTime := ntpTime(resp[40:48])
+	return (receiveTime.Sub(originTime) + transmitTime.Sub(destTime)) / 2, nil
+}
+
+// clockDriftChecker - checks the drift of the clock from an NTP server.
+type clockDriftChecker struct {
+	server   string
+	maxDrift time.Duration
+
+	// Clock checked and the query of the NTP offset, replaced by the
+	// tests.
+	now   func() time.Time
+	query func(server string, now func() time.Time) (time.Duration, error)
+}
+
+// newClockDriftChecker - checks the drift of the local clock from the
+// NTP server.
+func newClockDriftChecker(server string, maxDrift time.Duration) *clockDriftChecker {
+	return &clockDriftChecker{
+		server:   server,
+		maxDrift: maxDrift,
+		now:      time.Now,
+		query:    queryNTPOffset,
+	}
+}
+
+// Check - returns the drift of the clock, and errClockDrift after
+// logging an alert if it is past the maximum drift.
+func (c *clockDriftChecker) Check() (time.Duration, error) {
+	drift, err := c.query(c.server, c.now)
+	if err != nil {
+		return 0, err
+	}
+	if drift <= c.maxDrift && drift >= -c.maxDrift {
+		return drift, nil
+	}
+	fields := logrus.Fields{
+		"alert":     "ClockDrift",
+		"ntpServer": c.server,
+		"drift":     drift.String(),
+	}
+	for _, log := range log.loggers {
+		log.WithFields(fields).Errorf("Clock drifted by %s from the NTP server %s, requests may fail their signature validation.", drift, c.server)
+	}
+	return drift, errClockDrift
+}
+
+// startClockDriftCheck - checks the clock drift now, refusing to start
+// past the maximum drift in strict mode, then periodically until the
+// server stops. Does nothing without an NTP server.
+func startClockDriftCheck() {
+	if globalNTPServer == "" {
+		return
+	}
+	checker := newClockDriftChecker(globalNTPServer, globalNTPMaxDrift)
+	_, err := checker.Check()
+	if err == errClockDrift && globalIsNTPStrict {
+		fatalIf(err, "Unable to start the server, fix the clock drift.")
+	}
+	if err != nil && err != errClockDrift {
+		errorIf(err, "Unable to check the clock drift from %s.", globalNTPServer)
+	}
+	if globalNTPCheckInterval == 0 {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(globalNTPCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if _, err := checker.Check(); err != nil && err != errClockDrift {
+					errorIf(err, "Unable to check the clock drift from %s.", globalNTPServer)
+				}
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,143 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// putNTPTime - encodes a 64-bit NTP timestamp.
+func putNTPTime(b []byte, t time.Time) {
+	binary.BigEndian.PutUint32(b[0:4], uint32(t.Unix()+ntpEpochOffset))
+	binary.BigEndian.PutUint32(b[4:8], uint32((int64(t.Nanosecond())<<32)/int64(time.Second)))
+}
+
+// startTestNTPServer - starts an SNTP server answering with the time
+// of its clock, or with mode as the mode of the response if not zero.
+func startTestNTPServer(t *testing.T, mode byte) net.PacketConn {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		buf := make([]byte, 48)
+		for {
+			_, addr, rErr := conn.ReadFrom(buf)
+			if rErr != nil {
+				return
+			}
+			resp := make([]byte, 48)
+			resp[0] = 4<<3 | 4
+			if mode != 0 {
+				resp[0] = 4<<3 | mode
+			}
+			now := time.Now()
+			putNTPTime(resp[32:40], now)
+			putNTPTime(resp[40:48], now)
+			conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn
+}
+
+// Tests the offset from an SNTP server, the local clock is mocked to
+// drift from the server clock.
+func TestQueryNTPOffset(t *testing.T) {
+	server := startTestNTPServer(t, 0)
+	defer server.Close()
+
+	for _, drift := range []time.Duration{0, 10 * time.Minute, -3 * time.Hour} {
+		now := func() time.Time { return time.Now().Add(drift) }
+		offset, err := queryNTPOffset(server.LocalAddr().String(), now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The local clock ahead by drift has an offset of -drift.
+		if diff := offset + drift; diff > time.Second || diff < -time.Second {
+			t.Fatalf("Expected an offset of %s, got %s", -drift, offset)
+		}
+	}
+
+	// Responses other than the server mode are invalid.
+	invalid := startTestNTPServer(t, 3)
+	defer invalid.Close()
+	if _, err := queryNTPOffset(invalid.LocalAddr().String(), time.Now); err == nil {
+		t.Fatal("Expected an invalid response error")
+	}
+}
+
+// Tests the drift past the maximum drift is alerted.
+func TestClockDriftChecker(t *testing.T) {
+	var buffer bytes.Buffer
+	testLog := logrus.New()
+	testLog.Out = &buffer
+	testLog.Formatter = new(logrus.JSONFormatter)
+	log.mu.Lock()
+	savedLoggers := log.loggers
+	log.loggers = []*logrus.Logger{testLog}
+	log.mu.Unlock()
+	defer func() {
+		log.mu.Lock()
+		log.loggers = savedLoggers
+		log.mu.Unlock()
+	}()
+
+	server := startTestNTPServer(t, 0)
+	defer server.Close()
+
+	testCases := []struct {
+		drift     time.Duration
+		expectErr error
+	}{
+		// Acceptable drift.
+		{0, nil},
+		{30 * time.Second, nil},
+		{-30 * time.Second, nil},
+		// Excessive drift, ahead or behind.
+		{10 * time.Minute, errClockDrift},
+		{-10 * time.Minute, errClockDrift},
+	}
+	for i, testCase := range testCases {
+		buffer.Reset()
+		checker := newClockDriftChecker(server.LocalAddr().String(), time.Minute)
+		drift := testCase.drift
+		checker.now = func() time.Time { return time.Now().Add(drift) }
+		if _, err := checker.Check(); err != testCase.expectErr {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expectErr, err)
+		}
+		if testCase.expectErr == nil {
+			if buffer.Len() != 0 {
+				t.Fatalf("Test %d: Expected no alert, got %s", i+1, buffer.String())
+			}
+			continue
+		}
+		var fields logrus.Fields
+		if err := json.Unmarshal(buffer.Bytes(), &fields); err != nil {
+			t.Fatal(err)
+		}
+		if fields["alert"] != "ClockDrift" || fields["ntpServer"] != server.LocalAddr().String() || fields["level"] != "error" {
+			t.Fatalf("Test %d: Unexpected alert %v", i+1, fields)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/minio/mc/pkg/console"
+	"github.com/minio/minio/pkg/quick"
+)
+
+// loadConfigFile - loads and validates the server config file, the
+// loaded config is not applied.
+func loadConfigFile() (*serverConfigV13, error) {
+	configFile, err := getConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
+	srvCfg := &serverConfigV13{}
+	srvCfg.Version = globalMinioConfigVersion
+	qc, err := quick.New(srvCfg)
+	if err != nil {
+		return nil, err
+	}
+	if err = qc.Load(configFile); err != nil {
+		return nil, err
+	}
+
+	srvCfg.Credential, err = getCredential(srvCfg.Credential.AccessKey, srvCfg.Credential.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return srvCfg, nil
+}
+
+// newLoggers - returns the loggers enabled by the logger config.
+func newLoggers(l logger) ([]*logrus.Logger, error) {
+	var loggers []*logrus.Logger
+	consoleLogger, err := newConsoleLogger(l.Console)
+	if err != nil {
+		return nil, err
+	}
+	if consoleLogger != nil {
+		loggers = append(loggers, consoleLogger)
+	}
+	fileLogger, err := newFileLogger(l.File)
+	if err != nil {
+		return nil, err
+	}
+	if fileLogger != nil {
+		loggers = append(loggers, fileLogger)
+	}
+	return loggers, nil
+}
+
+// reloadConfig - reloads the server config file, usually on SIGHUP,
+// applying the changes of the region, loggers and notification targets
+// in place. The new config is fully validated and all its loggers and
+// targets are initialized before any of them is applied, on error the
+// running config is left untouched. The credential can not be changed
+// safely while peers are running with the current one, its changes are
+// logged as requiring a restart and are not applied.
+func reloadConfig() error {
+	newConfig, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	serverConfigMu.RLock()
+	oldConfig := serverConfig
+	serverConfigMu.RUnlock()
+
+	var applied, restartRequired []string
+	if newConfig.Credential.AccessKey != oldConfig.Credential.AccessKey ||
+		newConfig.Credential.SecretKey != oldConfig.Credential.SecretKey {
+		restartRequired = append(restartRequired, "credential")
+	}
+	newConfig.Credential = oldConfig.Credential
+
+	regionChanged := newConfig.Region != oldConfig.Region
+	if regionChanged {
+		applied = append(applied, "region")
+	}
+
+	var loggers []*logrus.Logger
+	loggerChanged := !reflect.DeepEqual(newConfig.Logger, oldConfig.Logger)
+	if loggerChanged {
+		if loggers, err = newLoggers(newConfig.Logger); err != nil {
+			return err
+		}
+		applied = append(applied, "logger")
+	}
+
+	// Notification target ARNs include the region, targets are
+	// initialized again if any of them changes.
+	var targets map[string]*logrus.Logger
+	targetsChanged := regionChanged || !reflect.DeepEqual(newConfig.Notify, oldConfig.Notify)
+	if targetsChanged {
+		if targets, err = loadAllQueueTargets(newConfig); err != nil {
+			for _, l := range loggers {
+				closeLogger(l)
+			}
+			return err
+		}
+		applied = append(applied, "notify")
+	}
+
+	// Everything is validated, apply the new config.
+	serverConfigMu.Lock()
+	serverConfig = newConfig
+	serverConfigMu.Unlock()
+
+	// The files and the connections of the replaced loggers and
+	// targets are closed.
+	if loggerChanged {
+		log.mu.Lock()
+		oldLoggers := log.loggers
+		log.loggers = loggers
+		log.mu.Unlock()
+		for _, l := range oldLoggers {
+			closeLogger(l)
+		}
+	}
+
+	if targetsChanged {
+		// The new targets are not used before the event notifier is
+		// initialized, it loads the targets of the new config then.
+		oldTargets := targets
+		if globalEventNotifier != nil {
+			oldTargets = globalEventNotifier.SetExternalTargets(targets)
+		}
+		for _, target := range oldTargets {
+			closeLogger(target)
+		}
+	}
+
+	if len(applied) == 0 {
+		console.Println("Reloaded the server config, no changes to apply.")
+	} else {
+		console.Println("Reloaded the server config, applied changes to: " + strings.Join(applied, ", ") + ".")
+	}
+	if len(restartRequired) != 0 {
+		console.Println("Changes to " + strings.Join(restartRequired, ", ") + " require a server restart, not applied.")
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Tests reloading the server config, as done on SIGHUP, with the
+// changes applied in place and the changes rejected.
+func TestReloadConfig(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	// remove the root directory after the test ends.
+	defer removeAll(rootPath)
+
+	log.mu.Lock()
+	savedLoggers := log.loggers
+	log.mu.Unlock()
+	defer func() {
+		log.mu.Lock()
+		log.loggers = savedLoggers
+		log.mu.Unlock()
+	}()
+
+	globalEventNotifier = &eventNotifier{
+		external: externalNotifier{
+			targets: make(map[string]*logrus.Logger),
+			rwMutex: &sync.RWMutex{},
+		},
+	}
+	defer func() { globalEventNotifier = nil }()
+
+	// Saves the config modified by fn into the config file.
+	saveConfig := func(fn func(cfg *serverConfigV13)) {
+		cfg := *serverConfig
+		fn(&cfg)
+		if err := cfg.Save(); err != nil {
+			t.Fatalf("Unable to save config: %v", err)
+		}
+	}
+
+	// Safe changes are applied in place.
+	oldCredential := serverConfig.GetCredential()
+	saveConfig(func(cfg *serverConfigV13) {
+		cfg.Region = "us-west-1"
+		cfg.Logger.Console = consoleLogger{Enable: true, Level: "fatal"}
+		cfg.Credential = credential{AccessKey: "minio-new-access", SecretKey: "minio-new-secret"}
+	})
+	if err = reloadConfig(); err != nil {
+		t.Fatalf("Unexpected error reloading config: %v", err)
+	}
+	if region := serverConfig.GetRegion(); region != "us-west-1" {
+		t.Fatalf("Expected region us-west-1, got %s", region)
+	}
+	log.mu.Lock()
+	loggers := log.loggers
+	log.mu.Unlock()
+	if len(loggers) != 1 || loggers[0].Level != logrus.FatalLevel {
+		t.Fatalf("Expected a console logger at fatal level, got %v", loggers)
+	}
+	// Credential changes require a restart.
+	if cred := serverConfig.GetCredential(); cred.AccessKey != oldCredential.AccessKey ||
+		cred.SecretKey != oldCredential.SecretKey {
+		t.Fatalf("Expected credential not to change")
+	}
+
+	testCases := []func(cfg *serverConfigV13){
+		// Test case - 1.
+		// Invalid log level.
+		func(cfg *serverConfigV13) {
+			cfg.Region = "us-east-2"
+			cfg.Logger.Console.Level = "verbose"
+		},
+		// Test case - 2.
+		// Invalid credential.
+		func(cfg *serverConfigV13) {
+			cfg.Region = "us-east-2"
+			cfg.Credential.SecretKey = "short"
+		},
+		// Test case - 3.
+		// Notification target failing to initialize.
+		func(cfg *serverConfigV13) {
+			cfg.Region = "us-east-2"
+			cfg.Notify.Webhook = map[string]webhookNotify{"1": {Enable: true}}
+		},
+	}
+	for i, testCase := range testCases {
+		saveConfig(testCase)
+		if err = reloadConfig(); err == nil {
+			t.Fatalf("Test %d: Expected reloading config to fail", i+1)
+		}
+		// Nothing is applied on error.
+		if region := serverConfig.GetRegion(); region != "us-west-1" {
+			t.Fatalf("Test %d: Expected region us-west-1, got %s", i+1, region)
+		}
+		if len(serverConfig.GetWebhook()) != 1 || serverConfig.GetWebhookNotifyByID("1").Enable {
+			t.Fatalf("Test %d: Expected webhook not to change", i+1)
+		}
+		log.mu.Lock()
+		loggers = log.loggers
+		log.mu.Unlock()
+		if len(loggers) != 1 || loggers[0].Level != logrus.FatalLevel {
+			t.Fatalf("Test %d: Expected loggers not to change, got %v", i+1, loggers)
+		}
+	}
+
+	// The file of a replaced file logger is closed.
+	getLogFile := func() *os.File {
+		log.mu.Lock()
+		defer log.mu.Unlock()
+		for _, l := range log.loggers {
+			for _, hook := range l.Hooks[logrus.ErrorLevel] {
+				if file, ok := hook.(*localFile); ok {
+					return file.File
+				}
+			}
+		}
+		return nil
+	}
+	for _, fileName := range []string{"minio-1.log", "minio-2.log"} {
+		saveConfig(func(cfg *serverConfigV13) {
+			cfg.Region = "us-west-1"
+			cfg.Logger.File = fileLogger{Enable: true, Filename: filepath.Join(rootPath, fileName), Level: "error"}
+			cfg.Notify.Webhook = map[string]webhookNotify{"1": {}}
+		})
+		oldFile := getLogFile()
+		if err = reloadConfig(); err != nil {
+			t.Fatalf("Unexpected error reloading config: %v", err)
+		}
+		if getLogFile() == nil {
+			t.Fatalf("Expected a file logger")
+		}
+		if oldFile != nil {
+			if _, err = oldFile.Write([]byte("log")); err == nil {
+				t.Fatalf("Expected the file of the replaced logger to be closed")
+			}
+		}
+	}
+}
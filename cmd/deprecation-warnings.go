@@ -0,0 +1,98 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/pkg/set"
+)
+
+// Deprecated features the responses warn about.
+const (
+	// Signature V2, signed headers, presigned URLs and POST policies.
+	deprecationSigV2 = "sigv2"
+	// ListObjects V1, superseded by ListObjectsV2.
+	deprecationListObjectsV1 = "list-objects-v1"
+)
+
+// Warnings of the deprecated features.
+var deprecationWarnings = map[string]string{
+	deprecationSigV2:         "Signature Version 2 is deprecated, sign the requests with Signature Version 4",
+	deprecationListObjectsV1: "ListObjects is deprecated, list the objects with ListObjectsV2",
+}
+
+// Header naming the deprecated feature the request used, along with the
+// standard Warning header.
+const deprecationHeader = "X-Minio-Deprecation"
+
+// setDeprecationWarnings - sets the deprecated features the responses
+// warn about from MINIO_DEPRECATION_WARNINGS env.
+func setDeprecationWarnings() {
+	features := os.Getenv("MINIO_DEPRECATION_WARNINGS")
+	if features == "" {
+		return
+	}
+	if strings.ToLower(features) == "off" {
+		globalDeprecationWarnings = set.NewStringSet()
+		return
+	}
+	warnings := set.NewStringSet()
+	for _, feature := range strings.Split(features, ",") {
+		feature = strings.ToLower(strings.TrimSpace(feature))
+		if _, ok := deprecationWarnings[feature]; !ok {
+			fatalIf(errInvalidArgument, "Invalid MINIO_DEPRECATION_WARNINGS value %s.", features)
+		}
+		warnings.Add(feature)
+	}
+	globalDeprecationWarnings = warnings
+}
+
+// setDeprecationWarning - warns in the response that the request used a
+// deprecated feature, if warnings are enabled for it. The request is
+// served as usual.
+func setDeprecationWarning(w http.ResponseWriter, feature string) {
+	if !globalDeprecationWarnings.Contains(feature) {
+		return
+	}
+	// 299 is the miscellaneous persistent warning.
+	w.Header().Add("Warning", "299 minio \""+deprecationWarnings[feature]+"\"")
+	w.Header().Add(deprecationHeader, feature)
+}
+
+// deprecationWarningHandler - warns in the responses of the requests
+// signed with Signature V2.
+type deprecationWarningHandler struct {
+	handler http.Handler
+}
+
+// setDeprecationWarningHandler - adds the deprecation warning of the
+// Signature V2 to the responses, the other deprecated features are
+// warned about by their handlers.
+func setDeprecationWarningHandler(h http.Handler) http.Handler {
+	return deprecationWarningHandler{h}
+}
+
+func (h deprecationWarningHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch getRequestAuthType(r) {
+	case authTypeSignedV2, authTypePresignedV2:
+		setDeprecationWarning(w, deprecationSigV2)
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,140 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/minio/minio-go/pkg/set"
+)
+
+// Tests the deprecated features warned about are set from the env.
+func TestSetDeprecationWarnings(t *testing.T) {
+	defer func(warnings set.StringSet) { globalDeprecationWarnings = warnings }(globalDeprecationWarnings)
+	defer os.Unsetenv("MINIO_DEPRECATION_WARNINGS")
+
+	testCases := []struct {
+		env      string
+		expected set.StringSet
+	}{
+		{"", set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)},
+		{"SigV2", set.CreateStringSet(deprecationSigV2)},
+		{"list-objects-v1, sigv2", set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)},
+		{"off", set.NewStringSet()},
+	}
+	for i, testCase := range testCases {
+		globalDeprecationWarnings = set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)
+		os.Setenv("MINIO_DEPRECATION_WARNINGS", testCase.env)
+		setDeprecationWarnings()
+		if !globalDeprecationWarnings.Equals(testCase.expected) {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expected, globalDeprecationWarnings)
+		}
+	}
+}
+
+// Tests the responses of the requests signed with Signature V2 warn
+// about it, unlike the ones signed with Signature V4.
+func TestDeprecationWarningHandler(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+	defer func(warnings set.StringSet) { globalDeprecationWarnings = warnings }(globalDeprecationWarnings)
+	globalDeprecationWarnings = set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)
+
+	handler := setDeprecationWarningHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serveRequest := func(sigV2 bool) *httptest.ResponseRecorder {
+		var req *http.Request
+		var err error
+		if sigV2 {
+			req, err = newTestSignedRequestV2("GET", "http://127.0.0.1:9000/bucket/object", 0, nil, "minio", "miniostorage")
+		} else {
+			req, err = newTestSignedRequestV4("GET", "http://127.0.0.1:9000/bucket/object", 0, nil, "minio", "miniostorage")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serveRequest(true)
+	if rec.Header().Get(deprecationHeader) != deprecationSigV2 {
+		t.Fatalf("Expected %s deprecation, got %q", deprecationSigV2, rec.Header().Get(deprecationHeader))
+	}
+	if warning := rec.Header().Get("Warning"); warning != `299 minio "`+deprecationWarnings[deprecationSigV2]+`"` {
+		t.Fatalf("Unexpected warning %q", warning)
+	}
+	if rec.Code != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+
+	rec = serveRequest(false)
+	if rec.Header().Get(deprecationHeader) != "" || rec.Header().Get("Warning") != "" {
+		t.Fatalf("Expected no warning for Signature V4, got %v", rec.Header())
+	}
+
+	// Warnings are disabled.
+	globalDeprecationWarnings = set.NewStringSet()
+	rec = serveRequest(true)
+	if rec.Header().Get(deprecationHeader) != "" || rec.Header().Get("Warning") != "" {
+		t.Fatalf("Expected no warning once disabled, got %v", rec.Header())
+	}
+}
+
+// Tests the responses of ListObjects V1 warn about it, unlike the ones
+// of ListObjectsV2.
+func TestListObjectsV1DeprecationWarning(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsV1DeprecationWarning, []string{"ListObjectsV2", "ListObjectsV1"})
+}
+
+func testListObjectsV1DeprecationWarning(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(warnings set.StringSet) { globalDeprecationWarnings = warnings }(globalDeprecationWarnings)
+	globalDeprecationWarnings = set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)
+
+	testCases := []struct {
+		values      url.Values
+		deprecation string
+	}{
+		{url.Values{}, deprecationListObjectsV1},
+		{url.Values{"list-type": {"2"}}, ""},
+	}
+	for i, testCase := range testCases {
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", testCase.values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		if deprecation := rec.Header().Get(deprecationHeader); deprecation != testCase.deprecation {
+			t.Fatalf("Minio %s: Test %d: Expected deprecation %q, got %q", instanceType, i+1, testCase.deprecation, deprecation)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Default interval between two alerts of the same full disk.
+const globalDefaultDiskFullAlertInterval = time.Minute
+
+// setDiskFullPolicy - sets the status of the responses of the writes
+// failing on full disks and the interval between the alerts of a full
+// disk from MINIO_DISK_FULL_STATUS and MINIO_DISK_FULL_ALERT_INTERVAL
+// env.
+func setDiskFullPolicy() {
+	if status := os.Getenv("MINIO_DISK_FULL_STATUS"); status != "" {
+		apiErr := errorCodeResponse[ErrStorageFull]
+		switch status {
+		case "503":
+			apiErr.HTTPStatusCode = http.StatusServiceUnavailable
+		case "507":
+			apiErr.HTTPStatusCode = http.StatusInsufficientStorage
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_DISK_FULL_STATUS value %s.", status)
+		}
+		errorCodeResponse[ErrStorageFull] = apiErr
+	}
+	if interval := os.Getenv("MINIO_DISK_FULL_ALERT_INTERVAL"); interval != "" {
+		duration, err := time.ParseDuration(interval)
+		fatalIf(err, "Invalid MINIO_DISK_FULL_ALERT_INTERVAL value %s.", interval)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_DISK_FULL_ALERT_INTERVAL value %s.", interval)
+		}
+		globalDiskFullAlertInterval = duration
+	}
+}
+
+// Variable represents the alerts of the full disks.
+var globalDiskFullAlerts = newDiskFullAlerts()
+
+// diskFullAlerts - last alert time of each full disk, so that a disk
+// staying full does not flood the loggers.
+type diskFullAlerts struct {
+	mu sync.Mutex
+
+	// Time of the last alert indexed by disk path.
+	lastAlerts map[string]time.Time
+}
+
+// newDiskFullAlerts - initializes the alerts of the full disks.
+func newDiskFullAlerts() *diskFullAlerts {
+	return &diskFullAlerts{
+		lastAlerts: make(map[string]time.Time),
+	}
+}
+
+// Alert - logs an alert that the disk is full, unless one was logged
+// for the disk within the alert interval. Returns true if logged.
+func (a *diskFullAlerts) Alert(diskPath string) bool {
+	a.mu.Lock()
+	now := time.Now().UTC()
+	if lastAlert, ok := a.lastAlerts[diskPath]; ok && now.Sub(lastAlert) < globalDiskFullAlertInterval {
+		a.mu.Unlock()
+		return false
+	}
+	a.lastAlerts[diskPath] = now
+	a.mu.Unlock()
+
+	fields := logrus.Fields{
+		"alert": "DiskFull",
+		"disk":  diskPath,
+	}
+	for _, log := range log.loggers {
+		log.WithFields(fields).Errorf("Disk %s is full, the writes to it are failing.", diskPath)
+	}
+	return true
+}
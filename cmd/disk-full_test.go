@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Tests the status of the responses of the writes failing on full disks.
+func TestSetDiskFullPolicy(t *testing.T) {
+	defer func(apiErr APIError) { errorCodeResponse[ErrStorageFull] = apiErr }(errorCodeResponse[ErrStorageFull])
+	defer func(interval time.Duration) { globalDiskFullAlertInterval = interval }(globalDiskFullAlertInterval)
+	defer os.Unsetenv("MINIO_DISK_FULL_STATUS")
+	defer os.Unsetenv("MINIO_DISK_FULL_ALERT_INTERVAL")
+
+	if status := getAPIError(ErrStorageFull).HTTPStatusCode; status != http.StatusInsufficientStorage {
+		t.Fatalf("Expected the default status %d, got %d", http.StatusInsufficientStorage, status)
+	}
+
+	os.Setenv("MINIO_DISK_FULL_STATUS", "503")
+	os.Setenv("MINIO_DISK_FULL_ALERT_INTERVAL", "10s")
+	setDiskFullPolicy()
+	if status := getAPIError(ErrStorageFull).HTTPStatusCode; status != http.StatusServiceUnavailable {
+		t.Fatalf("Expected the status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+	if globalDiskFullAlertInterval != 10*time.Second {
+		t.Fatalf("Expected the alert interval 10s, got %s", globalDiskFullAlertInterval)
+	}
+
+	os.Setenv("MINIO_DISK_FULL_STATUS", "507")
+	setDiskFullPolicy()
+	if status := getAPIError(ErrStorageFull).HTTPStatusCode; status != http.StatusInsufficientStorage {
+		t.Fatalf("Expected the status %d, got %d", http.StatusInsufficientStorage, status)
+	}
+}
+
+// Tests the alerts of a full disk are not repeated within the interval.
+func TestDiskFullAlerts(t *testing.T) {
+	defer func(interval time.Duration) { globalDiskFullAlertInterval = interval }(globalDiskFullAlertInterval)
+
+	var buffer bytes.Buffer
+	testLog := logrus.New()
+	testLog.Out = &buffer
+	testLog.Formatter = new(logrus.JSONFormatter)
+	log.mu.Lock()
+	savedLoggers := log.loggers
+	log.loggers = []*logrus.Logger{testLog}
+	log.mu.Unlock()
+	defer func() {
+		log.mu.Lock()
+		log.loggers = savedLoggers
+		log.mu.Unlock()
+	}()
+
+	alerts := newDiskFullAlerts()
+	globalDiskFullAlertInterval = time.Hour
+	if !alerts.Alert("/mnt/disk1") {
+		t.Fatal("Expected the first alert of the disk to be logged")
+	}
+	var fields logrus.Fields
+	if err := json.Unmarshal(buffer.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["alert"] != "DiskFull" || fields["disk"] != "/mnt/disk1" || fields["level"] != "error" {
+		t.Fatalf("Unexpected alert %v", fields)
+	}
+
+	buffer.Reset()
+	if alerts.Alert("/mnt/disk1") || buffer.Len() != 0 {
+		t.Fatal("Expected the alert to be skipped within the interval")
+	}
+	if !alerts.Alert("/mnt/disk2") {
+		t.Fatal("Expected the first alert of another disk to be logged")
+	}
+
+	globalDiskFullAlertInterval = 0
+	if !alerts.Alert("/mnt/disk1") {
+		t.Fatal("Expected the alert to be logged past the interval")
+	}
+}
+
+// Tests running out of space while writing a file of the FS backend
+// fails with errDiskFull.
+func TestFSCreateFileDiskFull(t *testing.T) {
+	// /dev/full fails all the writes with ENOSPC.
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full is not available")
+	}
+	data := []byte("data")
+	_, err := fsCreateFile("/dev/full", bytes.NewReader(data), make([]byte, len(data)), 0)
+	if errorCause(err) != errDiskFull {
+		t.Fatalf("Expected %v, got %v", errDiskFull, err)
+	}
+}
+
+// fullDisk - disk running out of space after a number of appends.
+type fullDisk struct {
+	StorageAPI
+
+	mu      sync.Mutex
+	appends int
+}
+
+func (d *fullDisk) AppendFile(volume, path string, buf []byte) error {
+	d.mu.Lock()
+	if d.appends == 0 {
+		d.mu.Unlock()
+		return errDiskFull
+	}
+	d.appends--
+	d.mu.Unlock()
+	return d.StorageAPI.AppendFile(volume, path, buf)
+}
+
+// Tests the drives filling up during a PUT of the XL backend are left
+// out of the object while write quorum remains, and that the PUT fails
+// with StorageFull and is rolled back once it is lost.
+func TestXLPutObjectDiskFull(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+	disks := append([]StorageAPI(nil), xl.storageDisks...)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	expectNoTmpFiles := func(testName string) {
+		for i, disk := range disks {
+			entries, lErr := disk.ListDir(minioMetaTmpBucket, "")
+			if lErr != nil && lErr != errFileNotFound {
+				t.Fatalf("%s: disk %d: %v", testName, i, lErr)
+			}
+			if len(entries) != 0 {
+				t.Fatalf("%s: disk %d: Expected the partial writes to be removed, got %v", testName, i, entries)
+			}
+		}
+	}
+
+	// Two blocks, the first disk fills up after the first one.
+	data := bytes.Repeat([]byte("a"), blockSizeV1+1)
+	xl.storageDisks[0] = &fullDisk{StorageAPI: disks[0], appends: 1}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("Expected the PUT to succeed on the other disks, got %v", err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("Unexpected object data")
+	}
+	if _, err = disks[0].StatFile(bucket, "object/part.1"); err != errFileNotFound {
+		t.Fatalf("Expected the full disk to be left out of the object, got %v", err)
+	}
+	expectNoTmpFiles("disk full within quorum")
+
+	// Write quorum of disks full from the start.
+	for i := 0; i < xl.writeQuorum; i++ {
+		xl.storageDisks[i] = &fullDisk{StorageAPI: disks[i]}
+	}
+	_, err = obj.PutObject(bucket, "full", int64(len("data")), bytes.NewReader([]byte("data")), nil, "")
+	if _, ok := errorCause(err).(StorageFull); !ok {
+		t.Fatalf("Expected %v, got %v", StorageFull{}, err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "full"); !isErrObjectNotFound(err) {
+		t.Fatalf("Expected the object not to be created, got %v", err)
+	}
+	expectNoTmpFiles("disks full past quorum")
+}
@@ -29,8 +29,11 @@ import (
 // all the disks, writes also calculate individual block's checksum
 // for future bit-rot protection.
 func erasureCreateFile(disks []StorageAPI, volume, path string, reader io.Reader, allowEmpty bool, blockSize int64, dataBlocks int, parityBlocks int, algo string, writeQuorum int) (bytesWritten int64, checkSums []string, err error) {
-	// Allocated blockSized buffer for reading from incoming stream.
-	buf := make([]byte, blockSize)
+	// Pooled blockSized buffer for reading from incoming stream, the
+	// encoded blocks reference the buffer until written to all disks.
+	bufPool := getErasureBufferPool(blockSize, dataBlocks, parityBlocks)
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
 
 	hashWriters := newHashWriters(len(disks), algo)
 
@@ -92,7 +95,7 @@ func encodeData(dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, erro
 	}
 	// Split the input buffer into data and parity blocks.
 	var blocks [][]byte
-	blocks, err = rs.Split(dataBuffer)
+	blocks, err = splitData(rs, dataBuffer, dataBlocks, parityBlocks)
 	if err != nil {
 		return nil, traceError(err)
 	}
@@ -107,6 +110,31 @@ func encodeData(dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, erro
 	return blocks, nil
 }
 
+// splitData - splits the data buffer into equal data and parity blocks
+// like reedsolomon.Split, in place if the buffer has the capacity for
+// the parity blocks, allocating a new buffer otherwise.
+func splitData(rs reedsolomon.Encoder, dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, error) {
+	if len(dataBuffer) == 0 {
+		return nil, reedsolomon.ErrShortData
+	}
+	shardSize := (len(dataBuffer) + dataBlocks - 1) / dataBlocks
+	totalSize := shardSize * (dataBlocks + parityBlocks)
+	if cap(dataBuffer) < totalSize {
+		return rs.Split(dataBuffer)
+	}
+
+	// Pooled buffers hold previous data, the padding is zeroed.
+	data := dataBuffer[:totalSize]
+	for i := len(dataBuffer); i < totalSize; i++ {
+		data[i] = 0
+	}
+	blocks := make([][]byte, dataBlocks+parityBlocks)
+	for i := range blocks {
+		blocks[i] = data[i*shardSize : (i+1)*shardSize : (i+1)*shardSize]
+	}
+	return blocks, nil
+}
+
 // appendFile - append data buffer at path.
 func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, hashWriters []hash.Hash, writeQuorum int) (err error) {
 	var wg = &sync.WaitGroup{}
@@ -114,6 +142,7 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, hash
 	// Write encoded data to quorum disks in parallel.
 	for index, disk := range disks {
 		if disk == nil {
+			wErrs[index] = traceError(errDiskNotFound)
 			continue
 		}
 		wg.Add(1)
@@ -137,5 +166,14 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, hash
 	// Wait for all the appends to finish.
 	wg.Wait()
 
+	// The disks failing an append, for instance being full, are left
+	// out of the next appends and of the object, rather than storing
+	// blocks with holes on them.
+	for index, wErr := range wErrs {
+		if wErr != nil {
+			disks[index] = nil
+		}
+	}
+
 	return reduceWriteQuorumErrs(wErrs, objectOpIgnoredErrs, writeQuorum)
 }
@@ -62,6 +62,8 @@ func erasureHealFile(latestDisks []StorageAPI, outDatedDisks []StorageAPI, volum
 			if disk == nil {
 				continue
 			}
+			// Heals are limited to the heal bandwidth of the server.
+			globalHealBandwidth.Wait(len(enBlocks[index]))
 			err := disk.AppendFile(healBucket, healPath, enBlocks[index])
 			if err != nil {
 				return nil, traceError(err)
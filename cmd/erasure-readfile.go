@@ -110,6 +110,38 @@ func getReadDisks(orderedDisks []StorageAPI, index int, dataBlocks int) (readDis
 	return nil, 0, traceError(errXLReadQuorum)
 }
 
+// getLessBusyReadDisks - returns the readable disks slice skipping the
+// busy disks and the disks of the slow nodes, when enough other disks
+// are available to read the data blocks. Slow nodes are read rather
+// than failing over to the default order if too many disks are skipped.
+// Returns false if no disk is skipped or too many disks are busy.
+func getLessBusyReadDisks(orderedDisks []StorageAPI, slowDisks []bool, dataBlocks int) (readDisks []StorageAPI, ok bool) {
+	pickReadDisks := func(skipSlow bool) ([]StorageAPI, bool) {
+		readDisks := make([]StorageAPI, len(orderedDisks))
+		readCount := 0
+		skipped := false
+		for i, disk := range orderedDisks {
+			if disk == nil {
+				continue
+			}
+			if isDiskBusy(disk) || (skipSlow && slowDisks[i]) {
+				skipped = true
+				continue
+			}
+			readDisks[i] = disk
+			readCount++
+			if readCount == dataBlocks {
+				return readDisks, skipped
+			}
+		}
+		return nil, false
+	}
+	if readDisks, ok = pickReadDisks(true); ok {
+		return readDisks, true
+	}
+	return pickReadDisks(false)
+}
+
 // parallelRead - reads chunks in parallel from the disks specified in []readDisks.
 func parallelRead(volume, path string, readDisks []StorageAPI, orderedDisks []StorageAPI, enBlocks [][]byte, blockOffset int64, curChunkSize int64, bitRotVerify func(diskIndex int) bool, pool *bpool.BytePool) {
 	// WaitGroup to synchronise the read go-routines.
@@ -191,6 +223,9 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		}
 	}()
 
+	// Disks of the nodes slow to serve the reads.
+	slowDisks := getSlowDisks(disks)
+
 	// Total bytes written to writer
 	bytesWritten := int64(0)
 
@@ -227,11 +262,17 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		// then it can result in wrong offset for the last block.
 		blockOffset := block * chunkSize
 
+		// Route the reads around the busy disks and the slow nodes,
+		// parity blocks are read instead of their data blocks.
+		if readDisks, ok := getLessBusyReadDisks(disks, slowDisks, dataBlocks); ok {
+			parallelRead(volume, path, readDisks, disks, enBlocks, blockOffset, curChunkSize, bitRotVerify, pool)
+		}
+
 		// nextIndex - index from which next set of parallel reads
 		// should happen.
 		nextIndex := 0
 
-		for {
+		for !isSuccessDecodeBlocks(enBlocks, dataBlocks) {
 			// readDisks - disks from which we need to read in parallel.
 			var readDisks []StorageAPI
 			var err error
@@ -240,6 +281,12 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 			if err != nil {
 				return bytesWritten, err
 			}
+			// Skip the blocks already read around the busy disks.
+			for index := range readDisks {
+				if enBlocks[index] != nil {
+					readDisks[index] = nil
+				}
+			}
 			// Issue a parallel read across the disks specified in readDisks.
 			parallelRead(volume, path, readDisks, disks, enBlocks, blockOffset, curChunkSize, bitRotVerify, pool)
 			if isSuccessDecodeBlocks(enBlocks, dataBlocks) {
@@ -25,6 +25,7 @@ import (
 	"net/url"
 	"path"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Sirupsen/logrus"
@@ -85,6 +86,54 @@ type eventData struct {
 	ReqParams map[string]string
 }
 
+// Last sequence number of the events of this server, in nanoseconds.
+var lastEventSequence int64
+
+// nextEventSequencer - returns the sequencer of an event, an hexadecimal
+// value increasing with each event so that the events of an object are
+// ordered by comparing their sequencers.
+func nextEventSequencer(t time.Time) string {
+	for {
+		last := atomic.LoadInt64(&lastEventSequence)
+		next := t.UnixNano()
+		if next <= last {
+			next = last + 1
+		}
+		if atomic.CompareAndSwapInt64(&lastEventSequence, last, next) {
+			return fmt.Sprintf("%016X", next)
+		}
+	}
+}
+
+// getEventRequestParams - returns the request parameters of an event,
+// the source IP address is sent without the port as in S3.
+func getEventRequestParams(reqParams map[string]string) map[string]string {
+	params := make(map[string]string, len(reqParams)+1)
+	for k, v := range reqParams {
+		params[k] = v
+	}
+	sourceIP := params[requestSourceIPAddressKey]
+	if host, _, err := net.SplitHostPort(sourceIP); err == nil {
+		sourceIP = host
+	}
+	params[requestSourceIPAddressKey] = sourceIP
+	return params
+}
+
+// setEventsConfigurationID - returns the events with the ID of the
+// notification configuration they matched, if any.
+func setEventsConfigurationID(events []NotificationEvent, id string) []NotificationEvent {
+	if id == "" {
+		return events
+	}
+	configEvents := make([]NotificationEvent, len(events))
+	for i, event := range events {
+		event.S3.ConfigurationID = id
+		configEvents[i] = event
+	}
+	return configEvents
+}
+
 // New notification event constructs a new notification event message from
 // input request metadata which completed successfully.
 func newNotificationEvent(event eventData) NotificationEvent {
@@ -108,6 +157,9 @@ func newNotificationEvent(event eventData) NotificationEvent {
 	// Fetch a hexadecimal representation of event time in nano seconds.
 	uniqueID := mustGetRequestID(eventTime)
 
+	// Sequencer ordering the events of the object.
+	sequencer := nextEventSequencer(eventTime)
+
 	/// Construct a new object created event.
 
 	// Following blocks fills in all the necessary details of s3
@@ -117,12 +169,13 @@ func newNotificationEvent(event eventData) NotificationEvent {
 		EventVersion:      eventVersion,
 		EventSource:       eventSource,
 		AwsRegion:         region,
-		EventTime:         eventTime.Format(timeFormatAMZ),
-		EventName:         event.Type.String(),
+		EventTime:         eventTime.Format(timeFormatAMZLong),
+		EventName:         event.Type.RecordName(),
 		UserIdentity:      identity{creds.AccessKey},
-		RequestParameters: event.ReqParams,
+		RequestParameters: getEventRequestParams(event.ReqParams),
 		ResponseElements: map[string]string{
 			responseRequestIDKey: uniqueID,
+			responseHostIDKey:    apiEndpoint,
 			// Following is a custom response element to indicate
 			// event origin server endpoint.
 			responseOriginEndpointKey: apiEndpoint,
@@ -145,29 +198,41 @@ func newNotificationEvent(event eventData) NotificationEvent {
 	if event.Type == ObjectRemovedDelete {
 		nEvent.S3.Object = objectMeta{
 			Key:       escapedObj,
-			Sequencer: uniqueID,
+			Sequencer: sequencer,
 		}
 		return nEvent
 	}
 
 	// For all other events we should set ETag and Size.
+	size := event.ObjInfo.Size
 	nEvent.S3.Object = objectMeta{
 		Key:       escapedObj,
 		ETag:      event.ObjInfo.MD5Sum,
-		Size:      event.ObjInfo.Size,
-		Sequencer: uniqueID,
+		Size:      &size,
+		Sequencer: sequencer,
 	}
 
 	// Success.
 	return nEvent
 }
 
-// Fetch the external target. No locking needed here since this map is
-// never written after initial startup.
+// Fetch the external target.
 func (en eventNotifier) GetExternalTarget(queueARN string) *logrus.Logger {
+	en.external.rwMutex.RLock()
+	defer en.external.rwMutex.RUnlock()
 	return en.external.targets[queueARN]
 }
 
+// Replaces all the external targets, used when the server config is
+// reloaded. Returns the replaced targets.
+func (en *eventNotifier) SetExternalTargets(targets map[string]*logrus.Logger) map[string]*logrus.Logger {
+	en.external.rwMutex.Lock()
+	oldTargets := en.external.targets
+	en.external.targets = targets
+	en.external.rwMutex.Unlock()
+	return oldTargets
+}
+
 func (en eventNotifier) GetInternalTarget(arn string) *listenerLogger {
 	en.internal.rwMutex.RLock()
 	defer en.internal.rwMutex.RUnlock()
@@ -261,13 +326,24 @@ func eventNotifyForBucketNotifications(eventType, objectName, bucketName string,
 		ruleMatch := filterRuleMatch(objectName, qConfig.Filter.Key.FilterRules)
 		if eventMatch && ruleMatch {
 			targetLog := globalEventNotifier.GetExternalTarget(qConfig.QueueARN)
-			if targetLog != nil {
-				targetLog.WithFields(logrus.Fields{
-					"Key":       path.Join(bucketName, objectName),
-					"EventType": eventType,
-					"Records":   nEvent,
-				}).Info()
+			if targetLog == nil {
+				continue
 			}
+			key := path.Join(bucketName, objectName)
+			entry := targetLog.WithFields(logrus.Fields{
+				"Key":       key,
+				"EventType": eventType,
+				"Records":   setEventsConfigurationID(nEvent, qConfig.ID),
+			})
+			// The events of each object are sent in order to the
+			// ordered targets.
+			if globalNotifyOrderedTargets[qConfig.QueueARN] {
+				globalOrderedEventDispatcher.Dispatch(qConfig.QueueARN, key, nEvent[0].S3.Object.Sequencer, func() {
+					entry.Info()
+				})
+				continue
+			}
+			entry.Info()
 		}
 	}
 }
@@ -289,7 +365,7 @@ func eventNotifyForBucketListeners(eventType, objectName, bucketName string,
 				targetLog.log.WithFields(logrus.Fields{
 					"Key":       path.Join(bucketName, objectName),
 					"EventType": eventType,
-					"Records":   nEvent,
+					"Records":   setEventsConfigurationID(nEvent, lcfg.TopicConfig.ID),
 				}).Info()
 			}
 		}
@@ -314,6 +390,9 @@ func eventNotify(event eventData) {
 	// Object name.
 	objectName := event.ObjInfo.Name
 
+	// Replicate the change to the remote target of the bucket, if any.
+	queueReplication(event)
+
 	// Save the notification event to be sent.
 	notificationEvent := []NotificationEvent{newNotificationEvent(event)}
 
@@ -527,23 +606,32 @@ func loadAllBucketNotifications(objAPI ObjectLayer) (map[string]*notificationCon
 // Loads all queue targets, initializes each queueARNs depending on their config.
 // Each instance of queueARN registers its own logrus to communicate with the
 // queue service. QueueARN once initialized is not initialized again for the
-// same queueARN, instead previous connection is used.
-func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
+// same queueARN, instead previous connection is used. The targets are
+// initialized from the given config, the targets already connected are
+// closed if any of them fails.
+func loadAllQueueTargets(srvCfg *serverConfigV13) (_ map[string]*logrus.Logger, err error) {
 	queueTargets := make(map[string]*logrus.Logger)
+	defer func() {
+		if err != nil {
+			for _, target := range queueTargets {
+				closeLogger(target)
+			}
+		}
+	}()
 	// Load all amqp targets, initialize their respective loggers.
-	for accountID, amqpN := range serverConfig.GetAMQP() {
+	for accountID, amqpN := range srvCfg.GetAMQP() {
 		if !amqpN.Enable {
 			continue
 		}
 		// Construct the queue ARN for AMQP.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeAMQP
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeAMQP
 		// Queue target if already initialized we move to the next ARN.
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID we can now initialize a new AMQP logrus instance.
-		amqpLog, err := newAMQPNotify(accountID)
+		amqpLog, err := newAMQPNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -558,19 +646,19 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 		queueTargets[queueARN] = amqpLog
 	}
 	// Load all nats targets, initialize their respective loggers.
-	for accountID, natsN := range serverConfig.GetNATS() {
+	for accountID, natsN := range srvCfg.GetNATS() {
 		if !natsN.Enable {
 			continue
 		}
 		// Construct the queue ARN for NATS.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeNATS
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeNATS
 		// Queue target if already initialized we move to the next ARN.
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID we can now initialize a new NATS logrus instance.
-		natsLog, err := newNATSNotify(accountID)
+		natsLog, err := newNATSNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -586,19 +674,19 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 	}
 
 	// Load redis targets, initialize their respective loggers.
-	for accountID, redisN := range serverConfig.GetRedis() {
+	for accountID, redisN := range srvCfg.GetRedis() {
 		if !redisN.Enable {
 			continue
 		}
 		// Construct the queue ARN for Redis.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeRedis
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeRedis
 		// Queue target if already initialized we move to the next ARN.
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID we can now initialize a new Redis logrus instance.
-		redisLog, err := newRedisNotify(accountID)
+		redisLog, err := newRedisNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -614,19 +702,19 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 	}
 
 	// Load Webhook targets, initialize their respective loggers.
-	for accountID, webhookN := range serverConfig.GetWebhook() {
+	for accountID, webhookN := range srvCfg.GetWebhook() {
 		if !webhookN.Enable {
 			continue
 		}
 		// Construct the queue ARN for Webhook.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeWebhook
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeWebhook
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 
 		// Using accountID we can now initialize a new Webhook logrus instance.
-		webhookLog, err := newWebhookNotify(accountID)
+		webhookLog, err := newWebhookNotify(srvCfg, accountID)
 		if err != nil {
 
 			return nil, err
@@ -635,18 +723,18 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 	}
 
 	// Load elastic targets, initialize their respective loggers.
-	for accountID, elasticN := range serverConfig.GetElasticSearch() {
+	for accountID, elasticN := range srvCfg.GetElasticSearch() {
 		if !elasticN.Enable {
 			continue
 		}
 		// Construct the queue ARN for Elastic.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeElastic
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeElastic
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID we can now initialize a new ElasticSearch logrus instance.
-		elasticLog, err := newElasticNotify(accountID)
+		elasticLog, err := newElasticNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -661,18 +749,18 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 	}
 
 	// Load PostgreSQL targets, initialize their respective loggers.
-	for accountID, pgN := range serverConfig.GetPostgreSQL() {
+	for accountID, pgN := range srvCfg.GetPostgreSQL() {
 		if !pgN.Enable {
 			continue
 		}
 		// Construct the queue ARN for Postgres.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypePostgreSQL
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypePostgreSQL
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID initialize a new Postgresql logrus instance.
-		pgLog, err := newPostgreSQLNotify(accountID)
+		pgLog, err := newPostgreSQLNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -686,18 +774,18 @@ func loadAllQueueTargets() (map[string]*logrus.Logger, error) {
 		queueTargets[queueARN] = pgLog
 	}
 	// Load Kafka targets, initialize their respective loggers.
-	for accountID, kafkaN := range serverConfig.GetKafka() {
+	for accountID, kafkaN := range srvCfg.GetKafka() {
 		if !kafkaN.Enable {
 			continue
 		}
 		// Construct the queue ARN for Kafka.
-		queueARN := minioSqs + serverConfig.GetRegion() + ":" + accountID + ":" + queueTypeKafka
+		queueARN := minioSqs + srvCfg.GetRegion() + ":" + accountID + ":" + queueTypeKafka
 		_, ok := queueTargets[queueARN]
 		if ok {
 			continue
 		}
 		// Using accountID initialize a new Kafka logrus instance.
-		kafkaLog, err := newKafkaNotify(accountID)
+		kafkaLog, err := newKafkaNotify(srvCfg, accountID)
 		if err != nil {
 			// Encapsulate network error to be more informative.
 			if _, ok := err.(net.Error); ok {
@@ -732,7 +820,7 @@ func initEventNotifier(objAPI ObjectLayer) error {
 	}
 
 	// Initializes all queue targets.
-	queueTargets, err := loadAllQueueTargets()
+	queueTargets, err := loadAllQueueTargets(serverConfig)
 	if err != nil {
 		return err
 	}
@@ -18,9 +18,11 @@ package cmd
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"net"
 	"reflect"
+	"sort"
 	"testing"
 	"time"
 )
@@ -618,3 +620,122 @@ func TestAddRemoveBucketListenerConfig(t *testing.T) {
 			lcSlice)
 	}
 }
+
+// Tests the notification records of a create and a delete have the
+// exact fields of the S3 event records.
+// ref: http://docs.aws.amazon.com/AmazonS3/latest/dev/notification-content-structure.html
+func TestNotificationEventAWSSchema(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	// Returns the sorted keys of a JSON object.
+	keysOf := func(value interface{}) []string {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			t.Fatalf("Expected a JSON object, got %v", value)
+		}
+		var keys []string
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	recordKeys := []string{"awsRegion", "eventName", "eventSource", "eventTime", "eventVersion",
+		"requestParameters", "responseElements", "s3", "userIdentity"}
+	s3Keys := []string{"bucket", "configurationId", "object", "s3SchemaVersion"}
+	bucketKeys := []string{"arn", "name", "ownerIdentity"}
+
+	testCases := []struct {
+		event      eventData
+		eventName  string
+		objectKeys []string
+	}{
+		{
+			event: eventData{
+				Type:      ObjectCreatedPut,
+				Bucket:    "bucket",
+				ObjInfo:   ObjectInfo{Name: "red flower.jpg", MD5Sum: "d41d8cd98f00b204e9800998ecf8427e"},
+				ReqParams: map[string]string{"sourceIPAddress": "127.0.0.1:9000"},
+			},
+			eventName:  "ObjectCreated:Put",
+			objectKeys: []string{"eTag", "key", "sequencer", "size"},
+		},
+		{
+			event: eventData{
+				Type:      ObjectRemovedDelete,
+				Bucket:    "bucket",
+				ObjInfo:   ObjectInfo{Name: "red flower.jpg"},
+				ReqParams: map[string]string{"sourceIPAddress": "127.0.0.1:9000"},
+			},
+			eventName:  "ObjectRemoved:Delete",
+			objectKeys: []string{"key", "sequencer"},
+		},
+	}
+
+	var lastSequencer string
+	for i, testCase := range testCases {
+		recordBytes, err := json.Marshal(newNotificationEvent(testCase.event))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record map[string]interface{}
+		if err = json.Unmarshal(recordBytes, &record); err != nil {
+			t.Fatal(err)
+		}
+
+		if keys := keysOf(record); !reflect.DeepEqual(keys, recordKeys) {
+			t.Fatalf("Test %d: Expected record fields %v, got %v", i+1, recordKeys, keys)
+		}
+		if record["eventVersion"] != "2.1" || record["eventSource"] != "aws:s3" || record["eventName"] != testCase.eventName {
+			t.Fatalf("Test %d: Unexpected record %s", i+1, recordBytes)
+		}
+		if _, err = time.Parse("2006-01-02T15:04:05.000Z", record["eventTime"].(string)); err != nil {
+			t.Fatalf("Test %d: Unexpected event time: %v", i+1, err)
+		}
+		if params := record["requestParameters"].(map[string]interface{}); params["sourceIPAddress"] != "127.0.0.1" {
+			t.Fatalf("Test %d: Unexpected request parameters %v", i+1, params)
+		}
+		responseElements := record["responseElements"].(map[string]interface{})
+		if _, ok := responseElements["x-amz-request-id"]; !ok {
+			t.Fatalf("Test %d: Expected x-amz-request-id in %v", i+1, responseElements)
+		}
+		if _, ok := responseElements["x-amz-id-2"]; !ok {
+			t.Fatalf("Test %d: Expected x-amz-id-2 in %v", i+1, responseElements)
+		}
+		if keys := keysOf(record["userIdentity"]); !reflect.DeepEqual(keys, []string{"principalId"}) {
+			t.Fatalf("Test %d: Unexpected user identity fields %v", i+1, keys)
+		}
+
+		s3 := record["s3"].(map[string]interface{})
+		if keys := keysOf(s3); !reflect.DeepEqual(keys, s3Keys) {
+			t.Fatalf("Test %d: Expected s3 fields %v, got %v", i+1, s3Keys, keys)
+		}
+		if keys := keysOf(s3["bucket"]); !reflect.DeepEqual(keys, bucketKeys) {
+			t.Fatalf("Test %d: Expected bucket fields %v, got %v", i+1, bucketKeys, keys)
+		}
+		object := s3["object"].(map[string]interface{})
+		if keys := keysOf(object); !reflect.DeepEqual(keys, testCase.objectKeys) {
+			t.Fatalf("Test %d: Expected object fields %v, got %v", i+1, testCase.objectKeys, keys)
+		}
+		if object["key"] != "red+flower.jpg" {
+			t.Fatalf("Test %d: Unexpected object key %v", i+1, object["key"])
+		}
+
+		// Sequencers of the later events are greater.
+		sequencer := object["sequencer"].(string)
+		if len(sequencer) != 16 || sequencer <= lastSequencer {
+			t.Fatalf("Test %d: Expected sequencer greater than %s, got %s", i+1, lastSequencer, sequencer)
+		}
+		lastSequencer = sequencer
+	}
+
+	// Records carry the ID of the configuration they matched.
+	events := setEventsConfigurationID([]NotificationEvent{newNotificationEvent(testCases[0].event)}, "1")
+	if events[0].S3.ConfigurationID != "1" {
+		t.Fatalf("Expected configuration ID 1, got %s", events[0].S3.ConfigurationID)
+	}
+}
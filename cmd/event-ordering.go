@@ -0,0 +1,126 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// setNotifyOrderedTargets - sets the targets receiving the events of
+// each object in order from MINIO_NOTIFY_ORDERED_TARGETS env, a comma
+// separated list of queue ARNs.
+func setNotifyOrderedTargets() {
+	if targets := os.Getenv("MINIO_NOTIFY_ORDERED_TARGETS"); targets != "" {
+		orderedTargets := make(map[string]bool)
+		for _, arn := range strings.Split(targets, ",") {
+			arn = strings.TrimSpace(arn)
+			if !strings.HasPrefix(arn, minioSqs) {
+				fatalIf(errInvalidArgument, "Invalid MINIO_NOTIFY_ORDERED_TARGETS value %s.", targets)
+			}
+			orderedTargets[arn] = true
+		}
+		globalNotifyOrderedTargets = orderedTargets
+	}
+}
+
+// orderedEvent - event waiting to be sent to an ordered target.
+type orderedEvent struct {
+	sequencer string
+	send      func()
+}
+
+// orderedEventDispatcher - sends the events of each object to the
+// ordered targets one at a time, in the order of their sequencers. The
+// events of different objects are still sent concurrently, an object
+// having events pending is served by its own go-routine until they are
+// all sent. The events are sent outside of the requests notifying them,
+// a slow target delays the following events of the object only.
+type orderedEventDispatcher struct {
+	mu sync.Mutex
+
+	// Events pending indexed by target ARN and object key, oldest
+	// first. The first event of a key is being sent.
+	pending map[string][]orderedEvent
+
+	// Events not sent yet, for the tests to wait for the targets.
+	inflight sync.WaitGroup
+}
+
+// Sends the events of the ordered targets.
+var globalOrderedEventDispatcher = newOrderedEventDispatcher()
+
+// newOrderedEventDispatcher - initializes a new ordered event dispatcher.
+func newOrderedEventDispatcher() *orderedEventDispatcher {
+	return &orderedEventDispatcher{pending: make(map[string][]orderedEvent)}
+}
+
+// Dispatch - queues the event of the object key to be sent to the
+// target once its previous events are sent. An event whose sequencer
+// is lower than events still waiting, its request having notified it
+// late, is sent before them.
+func (d *orderedEventDispatcher) Dispatch(targetARN, key, sequencer string, send func()) {
+	d.inflight.Add(1)
+	queueKey := targetARN + "\x00" + key
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	events, ok := d.pending[queueKey]
+	i := len(events)
+	// The first event is being sent already.
+	for i > 1 && events[i-1].sequencer > sequencer {
+		i--
+	}
+	events = append(events, orderedEvent{})
+	copy(events[i+1:], events[i:])
+	events[i] = orderedEvent{sequencer: sequencer, send: send}
+	d.pending[queueKey] = events
+	if !ok {
+		go d.sendEvents(queueKey)
+	}
+}
+
+// sendEvents - sends the pending events of the queue key in order until
+// none is left.
+func (d *orderedEventDispatcher) sendEvents(queueKey string) {
+	for {
+		d.mu.Lock()
+		event := d.pending[queueKey][0]
+		d.mu.Unlock()
+
+		event.send()
+
+		d.mu.Lock()
+		events := d.pending[queueKey][1:]
+		if len(events) == 0 {
+			delete(d.pending, queueKey)
+		} else {
+			d.pending[queueKey] = events
+		}
+		d.mu.Unlock()
+		d.inflight.Done()
+		if len(events) == 0 {
+			return
+		}
+	}
+}
+
+// Wait - waits for all the events dispatched to be sent.
+func (d *orderedEventDispatcher) Wait() {
+	d.inflight.Wait()
+}
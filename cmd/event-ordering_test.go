@@ -0,0 +1,142 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io/ioutil"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// orderedTestHook - records the event types sent to a target, the
+// first event being held until released.
+type orderedTestHook struct {
+	mu      sync.Mutex
+	events  []string
+	release chan struct{}
+}
+
+func (h *orderedTestHook) Levels() []logrus.Level {
+	return []logrus.Level{logrus.InfoLevel}
+}
+
+func (h *orderedTestHook) Fire(entry *logrus.Entry) error {
+	h.mu.Lock()
+	first := len(h.events) == 0
+	h.events = append(h.events, entry.Data["EventType"].(string))
+	h.mu.Unlock()
+	if first {
+		<-h.release
+	}
+	return nil
+}
+
+// Tests the create and the delete of an object notified in a row are
+// sent in order to an ordered target, even when the create is slow to
+// be sent.
+func TestOrderedEventNotify(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	arn := "arn:minio:sqs:" + globalMinioDefaultRegion + ":1:webhook"
+	hook := &orderedTestHook{release: make(chan struct{})}
+	target := logrus.New()
+	target.Out = ioutil.Discard
+	target.Hooks.Add(hook)
+
+	globalEventNotifier = &eventNotifier{
+		external: externalNotifier{
+			notificationConfigs: map[string]*notificationConfig{
+				"bucket": {QueueConfigs: []queueConfig{{
+					ServiceConfig: ServiceConfig{Events: []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}},
+					QueueARN:      arn,
+				}}},
+			},
+			targets: map[string]*logrus.Logger{arn: target},
+			rwMutex: &sync.RWMutex{},
+		},
+		internal: internalNotifier{rwMutex: &sync.RWMutex{}},
+	}
+	globalNotifyOrderedTargets = map[string]bool{arn: true}
+	defer func() {
+		globalEventNotifier = nil
+		globalNotifyOrderedTargets = nil
+	}()
+
+	// The requests return while the create is held by the target.
+	objInfo := ObjectInfo{Bucket: "bucket", Name: "object"}
+	eventNotify(eventData{Type: ObjectCreatedPut, Bucket: "bucket", ObjInfo: objInfo})
+	eventNotify(eventData{Type: ObjectRemovedDelete, Bucket: "bucket", ObjInfo: objInfo})
+	close(hook.release)
+	globalOrderedEventDispatcher.Wait()
+
+	expected := []string{ObjectCreatedPut.String(), ObjectRemovedDelete.String()}
+	if !reflect.DeepEqual(hook.events, expected) {
+		t.Fatalf("Expected the events %v, got %v", expected, hook.events)
+	}
+}
+
+// Tests the events notified late are sent before the events of the
+// object with a higher sequencer.
+func TestOrderedEventDispatcher(t *testing.T) {
+	d := newOrderedEventDispatcher()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var sent []string
+	send := func(sequencer string) func() {
+		return func() {
+			if sequencer == "1" {
+				<-release
+			}
+			mu.Lock()
+			sent = append(sent, sequencer)
+			mu.Unlock()
+		}
+	}
+
+	d.Dispatch("arn", "bucket/object", "1", send("1"))
+	d.Dispatch("arn", "bucket/object", "3", send("3"))
+	d.Dispatch("arn", "bucket/object", "2", send("2"))
+	// The events of other objects are not held.
+	d.Dispatch("arn", "bucket/other", "0", send("0"))
+	for {
+		mu.Lock()
+		n := len(sent)
+		mu.Unlock()
+		if n == 1 {
+			break
+		}
+	}
+	close(release)
+	d.Wait()
+
+	expected := []string{"0", "1", "2", "3"}
+	if !reflect.DeepEqual(sent, expected) {
+		t.Fatalf("Expected the events %v, got %v", expected, sent)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.pending) != 0 {
+		t.Fatalf("Expected no event pending, got %v", d.pending)
+	}
+}
@@ -260,6 +260,9 @@ func fsCreateFile(tempObjPath string, reader io.Reader, buf []byte, fallocSize i
 
 	bytesWritten, err := io.CopyBuffer(writer, reader, buf)
 	if err != nil {
+		if isSysErrNoSpace(err) {
+			return 0, traceError(errDiskFull)
+		}
 		return 0, traceError(err)
 	}
 
@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -534,9 +533,13 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	partSuffix := fmt.Sprintf("object%d", partID)
 	tmpPartPath := uploadID + "." + mustGetUUID() + "." + partSuffix
 
-	// Initialize md5 writer.
-	md5Writer := md5.New()
-	hashWriters := []io.Writer{md5Writer}
+	// Initialize ETag writer.
+	etagWriter := newETagWriter(md5Hex != "")
+
+	// Validate the content while it is written.
+	validation := newContentValidation(bucket, object)
+
+	hashWriters := []io.Writer{etagWriter, validation}
 
 	var sha256Writer hash.Hash
 	if sha256sum != "" {
@@ -556,16 +559,22 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	}
 
 	teeReader := io.TeeReader(limitDataReader, multiWriter)
-	bufSize := int64(readSizeV1)
+	bufSize := globalUploadBufferSize
 	if size > 0 && bufSize > size {
 		bufSize = size
 	}
-	buf := make([]byte, int(bufSize))
+	bufPool := getUploadBufferPool()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	buf = buf[:bufSize]
 
 	fsPartPath := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID, tmpPartPath)
 	bytesWritten, cErr := fsCreateFile(fsPartPath, teeReader, buf, size)
 	if cErr != nil {
 		fsRemoveFile(fsPartPath)
+		if errorCause(cErr) == errDiskFull {
+			globalDiskFullAlerts.Alert(fs.fsPath)
+		}
 		return PartInfo{}, toObjectErr(cErr, minioMetaTmpBucket, tmpPartPath)
 	}
 
@@ -581,12 +590,12 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	// delete.
 	defer fsRemoveFile(fsPartPath)
 
-	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
 	if md5Hex != "" {
-		if newMD5Hex != md5Hex {
+		if newMD5Hex := etagWriter.MD5Hex(); newMD5Hex != md5Hex {
 			return PartInfo{}, traceError(BadDigest{md5Hex, newMD5Hex})
 		}
 	}
+	newETag := etagWriter.ETag()
 
 	if sha256sum != "" {
 		newSHA256sum := hex.EncodeToString(sha256Writer.Sum(nil))
@@ -595,6 +604,10 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 		}
 	}
 
+	if err = validation.Validate(); err != nil {
+		return PartInfo{}, err
+	}
+
 	partPath := pathJoin(bucket, object, uploadID, partSuffix)
 	// Lock the part so that another part upload with same part-number gets blocked
 	// while the part is getting appended in the background.
@@ -608,7 +621,7 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	}
 
 	// Save the object part info in `fs.json`.
-	fsMeta.AddObjectPart(partID, partSuffix, newMD5Hex, size)
+	fsMeta.AddObjectPart(partID, partSuffix, newETag, size)
 	if _, err = fsMeta.WriteTo(rwlk); err != nil {
 		partLock.Unlock()
 		return PartInfo{}, toObjectErr(err, minioMetaMultipartBucket, uploadIDPath)
@@ -634,7 +647,7 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	return PartInfo{
 		PartNumber:   partID,
 		LastModified: fi.ModTime(),
-		ETag:         newMD5Hex,
+		ETag:         newETag,
 		Size:         fi.Size(),
 	}, nil
 }
@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"crypto/md5"
 	"encoding/hex"
 	"errors"
 	"fmt"
@@ -166,6 +165,96 @@ func newFSObjectLayer(fsPath string) (ObjectLayer, error) {
 		return nil, fmt.Errorf("Unable to initialize event notification. %s", err)
 	}
 
+	// Initialize and load bucket logging configs.
+	err = initBucketLogging(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket logging configs. %s", err)
+	}
+
+	// Initialize and load bucket lifecycle configs.
+	err = initBucketLifecycle(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket lifecycle configs. %s", err)
+	}
+
+	// Initialize and load bucket replication configs.
+	err = initBucketReplication(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket replication configs. %s", err)
+	}
+
+	// Initialize and load bucket content sniffing configs.
+	err = initBucketContentSniffing(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket content sniffing configs. %s", err)
+	}
+
+	// Initialize and load bucket website configs.
+	err = initBucketWebsite(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket website configs. %s", err)
+	}
+
+	// Initialize and load bucket request payment configs.
+	err = initBucketRequestPayment(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket request payment configs. %s", err)
+	}
+
+	// Initialize and load bucket object lock configs.
+	err = initBucketObjectLock(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket object lock configs. %s", err)
+	}
+
+	// Initialize and load bucket trash configs.
+	err = initBucketTrash(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket trash configs. %s", err)
+	}
+
+	// Initialize and load bucket object size limits configs.
+	err = initBucketObjectSizeLimits(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket object size limits configs. %s", err)
+	}
+
+	// Initialize and load bucket public access block configs.
+	err = initBucketPublicAccessBlock(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket public access block configs. %s", err)
+	}
+
+	// Initialize and load bucket accelerate configs.
+	err = initBucketAccelerate(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket accelerate configs. %s", err)
+	}
+
+	// Initialize and load bucket client certificate configs.
+	err = initBucketClientCert(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket client certificate configs. %s", err)
+	}
+
+	// Initialize and load bucket content addressing configs.
+	err = initBucketContentAddressing(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket content addressing configs. %s", err)
+	}
+
+	// Initialize and load bucket ownership controls configs.
+	err = initBucketOwnershipControls(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to load all bucket ownership controls configs. %s", err)
+	}
+
+	// Initialize bucket inventory.
+	err = initBucketInventory(fs)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to initialize bucket inventory. %s", err)
+	}
+
 	// Return successfully initialized object layer.
 	return fs, nil
 }
@@ -405,27 +494,20 @@ func (fs fsObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 	// Length of the file to read.
 	length := fi.Size()
 
-	// Initialize pipe.
-	pipeReader, pipeWriter := io.Pipe()
+	// Large objects are read in chunks concurrently.
+	reader := newCopyReader(func(startOffset, length int64, writer io.Writer) error {
+		gerr := fs.GetObject(srcBucket, srcObject, startOffset, length, writer)
+		errorIf(gerr, "Unable to read %s/%s.", srcBucket, srcObject)
+		return gerr
+	}, length)
 
-	go func() {
-		startOffset := int64(0) // Read the whole file.
-		if gerr := fs.GetObject(srcBucket, srcObject, startOffset, length, pipeWriter); gerr != nil {
-			errorIf(gerr, "Unable to read %s/%s.", srcBucket, srcObject)
-			pipeWriter.CloseWithError(gerr)
-			return
-		}
-		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
-	}()
-
-	objInfo, err := fs.PutObject(dstBucket, dstObject, length, pipeReader, metadata, "")
+	objInfo, err := fs.PutObject(dstBucket, dstObject, length, reader, metadata, "")
+	// Explicitly close the reader.
+	reader.Close()
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, dstBucket, dstObject)
 	}
 
-	// Explicitly close the reader.
-	pipeReader.Close()
-
 	return objInfo, nil
 }
 
@@ -471,7 +553,7 @@ func (fs fsObjects) GetObject(bucket, object string, offset int64, length int64,
 	}
 	defer reader.Close()
 
-	bufSize := int64(readSizeV1)
+	bufSize := globalUploadBufferSize
 	if length > 0 && bufSize > length {
 		bufSize = length
 	}
@@ -486,8 +568,11 @@ func (fs fsObjects) GetObject(bucket, object string, offset int64, length int64,
 		return traceError(InvalidRange{offset, length, size})
 	}
 
-	// Allocate a staging buffer.
-	buf := make([]byte, int(bufSize))
+	// Get a staging buffer from the pool.
+	bufPool := getUploadBufferPool()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	buf = buf[:bufSize]
 
 	_, err = io.CopyBuffer(writer, io.LimitReader(reader, length), buf)
 
@@ -585,10 +670,13 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 	// so that cleaning it up will be easy if the server goes down.
 	tempObj := mustGetUUID()
 
-	// Initialize md5 writer.
-	md5Writer := md5.New()
+	// Initialize ETag writer.
+	etagWriter := newETagWriter(metadata["md5Sum"] != "")
+
+	// Validate the content while it is written.
+	validation := newContentValidation(bucket, object)
 
-	hashWriters := []io.Writer{md5Writer}
+	hashWriters := []io.Writer{etagWriter, validation}
 
 	var sha256Writer hash.Hash
 	if sha256sum != "" {
@@ -607,17 +695,23 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 		limitDataReader = data
 	}
 
-	// Allocate a buffer to Read() from request body
-	bufSize := int64(readSizeV1)
+	// Get a buffer from the pool to Read() from request body
+	bufSize := globalUploadBufferSize
 	if size > 0 && bufSize > size {
 		bufSize = size
 	}
-	buf := make([]byte, int(bufSize))
+	bufPool := getUploadBufferPool()
+	buf := bufPool.Get()
+	defer bufPool.Put(buf)
+	buf = buf[:bufSize]
 	teeReader := io.TeeReader(limitDataReader, multiWriter)
 	fsTmpObjPath := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID, tempObj)
 	bytesWritten, err := fsCreateFile(fsTmpObjPath, teeReader, buf, size)
 	if err != nil {
 		fsRemoveFile(fsTmpObjPath)
+		if errorCause(err) == errDiskFull {
+			globalDiskFullAlerts.Alert(fs.fsPath)
+		}
 		errorIf(err, "Failed to create object %s/%s", bucket, object)
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
@@ -634,20 +728,16 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 	// nothing to delete.
 	defer fsRemoveFile(fsTmpObjPath)
 
-	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
-	// Update the md5sum if not set with the newly calculated one.
-	if len(metadata["md5Sum"]) == 0 {
-		metadata["md5Sum"] = newMD5Hex
-	}
-
 	// md5Hex representation.
 	md5Hex := metadata["md5Sum"]
 	if md5Hex != "" {
-		if newMD5Hex != md5Hex {
+		if newMD5Hex := etagWriter.MD5Hex(); newMD5Hex != md5Hex {
 			// Returns md5 mismatch.
 			return ObjectInfo{}, traceError(BadDigest{md5Hex, newMD5Hex})
 		}
 	}
+	// Save the newly calculated ETag.
+	metadata["md5Sum"] = etagWriter.ETag()
 
 	if sha256sum != "" {
 		newSHA256sum := hex.EncodeToString(sha256Writer.Sum(nil))
@@ -656,6 +746,10 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 		}
 	}
 
+	if err = validation.Validate(); err != nil {
+		return ObjectInfo{}, err
+	}
+
 	// Entire object was written to the temp location, now it's safe to rename it to the actual location.
 	fsNSObjPath := pathJoin(fs.fsPath, bucket, object)
 	if err = fsRenameFile(fsTmpObjPath, fsNSObjPath); err != nil {
@@ -679,6 +773,75 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 	return fsMeta.ToObjectInfo(bucket, object, fi), nil
 }
 
+// RenameObject - moves an object along with its `fs.json` to another
+// bucket or name, its data is not copied. The `fs.json` of an object
+// moved to the meta bucket is kept, so that its metadata is restored
+// once moved back. The destination object must not exist.
+func (fs fsObjects) RenameObject(srcBucket, srcObject, dstBucket, dstObject string) (ObjectInfo, error) {
+	if err := checkDelObjArgs(srcBucket, srcObject); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := checkBucketAndObjectNames(dstBucket, dstObject); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	if _, err := fs.statBucketDir(srcBucket); err != nil {
+		return ObjectInfo{}, toObjectErr(err, srcBucket)
+	}
+	if _, err := fs.statBucketDir(dstBucket); err != nil {
+		return ObjectInfo{}, toObjectErr(err, dstBucket)
+	}
+
+	if err := fs.renameObject(srcBucket, srcObject, dstBucket, dstObject); err != nil {
+		return ObjectInfo{}, err
+	}
+	return fs.getObjectInfo(dstBucket, dstObject)
+}
+
+// renameObject - moves the object and its `fs.json` with the lock of
+// the `fs.json` held, which must be released before reading it again.
+func (fs fsObjects) renameObject(srcBucket, srcObject, dstBucket, dstObject string) error {
+	minioMetaBucketDir := pathJoin(fs.fsPath, minioMetaBucket)
+	srcMetaPath := pathJoin(minioMetaBucketDir, bucketMetaPrefix, srcBucket, srcObject, fsMetaJSONFile)
+	dstMetaPath := pathJoin(minioMetaBucketDir, bucketMetaPrefix, dstBucket, dstObject, fsMetaJSONFile)
+	if srcBucket != minioMetaBucket {
+		rwlk, lerr := fs.rwPool.Write(srcMetaPath)
+		if lerr == nil {
+			// This close will allow for fs locks to be synchronized on `fs.json`.
+			defer rwlk.Close()
+		}
+		if lerr != nil && lerr != errFileNotFound {
+			return toObjectErr(traceError(lerr), srcBucket, srcObject)
+		}
+	}
+
+	srcObjPath := pathJoin(fs.fsPath, srcBucket, srcObject)
+	if _, err := fsStatFile(srcObjPath); err != nil {
+		return toObjectErr(err, srcBucket, srcObject)
+	}
+	if _, err := fsStatFile(pathJoin(fs.fsPath, dstBucket, dstObject)); err == nil {
+		return toObjectErr(traceError(errFileAccessDenied), dstBucket, dstObject)
+	}
+	if err := fsRenameFile(srcObjPath, pathJoin(fs.fsPath, dstBucket, dstObject)); err != nil {
+		return toObjectErr(err, srcBucket, srcObject)
+	}
+	// Remove the parent directories left empty.
+	if err := fsDeleteFile(pathJoin(fs.fsPath, srcBucket), filepath.Dir(srcObjPath)); err != nil {
+		return toObjectErr(err, srcBucket, srcObject)
+	}
+
+	// Objects without `fs.json` have no metadata to move.
+	if _, err := fsStatFile(srcMetaPath); err == nil {
+		if err = fsRenameFile(srcMetaPath, dstMetaPath); err != nil {
+			return toObjectErr(err, srcBucket, srcObject)
+		}
+		if err = fsDeleteFile(minioMetaBucketDir, filepath.Dir(srcMetaPath)); err != nil {
+			return toObjectErr(err, srcBucket, srcObject)
+		}
+	}
+	return nil
+}
+
 // DeleteObject - deletes an object from a bucket, this operation is destructive
 // and there are no rollbacks supported.
 func (fs fsObjects) DeleteObject(bucket, object string) error {
@@ -703,17 +866,30 @@ func (fs fsObjects) DeleteObject(bucket, object string) error {
 		}
 	}
 
-	// Delete the object.
-	if err := fsDeleteFile(pathJoin(fs.fsPath, bucket), pathJoin(fs.fsPath, bucket, object)); err != nil {
+	// Delete the object, large objects are moved to the temporary
+	// bucket and removed asynchronously.
+	objPath := pathJoin(fs.fsPath, bucket, object)
+	if fi, err := fsStatFile(objPath); err == nil && isAsyncDelete(bucket, fi.Size()) {
+		tmpObjPath := pathJoin(fs.fsPath, minioMetaTmpBucket, fs.fsUUID, mustGetUUID())
+		if err = fsRenameFile(objPath, tmpObjPath); err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		// Remove the parent directories left empty.
+		if err = fsDeleteFile(pathJoin(fs.fsPath, bucket), filepath.Dir(objPath)); err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		globalAsyncDeleter.Delete(func() error {
+			return fsRemoveFile(tmpObjPath)
+		})
+	} else if err := fsDeleteFile(pathJoin(fs.fsPath, bucket), objPath); err != nil {
 		return toObjectErr(err, bucket, object)
 	}
 
-	if bucket != minioMetaBucket {
-		// Delete the metadata object.
-		err := fsDeleteFile(minioMetaBucketDir, fsMetaPath)
-		if err != nil && errorCause(err) != errFileNotFound {
-			return toObjectErr(err, bucket, object)
-		}
+	// Delete the metadata object, the objects of the meta bucket only
+	// have one once moved there by a rename.
+	err := fsDeleteFile(minioMetaBucketDir, fsMetaPath)
+	if err != nil && errorCause(err) != errFileNotFound {
+		return toObjectErr(err, bucket, object)
 	}
 	return nil
 }
@@ -742,7 +918,7 @@ func (fs fsObjects) listDirFactory(isLeaf isLeafFunc) listDirFunc {
 	return listDir
 }
 
-// ListObjects - list all objects at prefix upto maxKeys., optionally delimited by '/'. Maintains the list pool
+// ListObjects - list all objects at prefix upto maxKeys., optionally delimited by delimiter. Maintains the list pool
 // state for future re-entrant list requests.
 func (fs fsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
 	if err := checkListObjsArgs(bucket, prefix, marker, delimiter, fs); err != nil {
@@ -772,6 +948,14 @@ func (fs fsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKey
 		maxKeys = maxObjectList
 	}
 
+	// Delimiters other than '/' are rolled up over a recursive listing.
+	if delimiter != "" && delimiter != slashSeparator {
+		listFn := func(marker string, maxKeys int) (ListObjectsInfo, error) {
+			return fs.ListObjects(bucket, prefix, marker, "", maxKeys)
+		}
+		return listObjectsWithDelimiter(listFn, prefix, marker, delimiter, maxKeys)
+	}
+
 	// Default is recursive, if delimiter is set then list non recursive.
 	recursive := true
 	if delimiter == slashSeparator {
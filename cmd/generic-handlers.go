@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -282,77 +283,87 @@ func setCorsHandler(h http.Handler) http.Handler {
 
 // setIgnoreResourcesHandler -
 // Ignore resources handler is wrapper handler used for API request resource validation
-// Since we do not support all the S3 queries, it is necessary for us to throw back a
-// valid error message indicating that requested feature is not implemented.
+// Not implemented S3 APIs are handled by the API router, see registerNotImplementedBucketAPIs()
+// and registerNotImplementedObjectAPIs().
 func setIgnoreResourcesHandler(h http.Handler) http.Handler {
 	return resourceHandler{h}
 }
 
-// Checks requests for not implemented Bucket resources
-func ignoreNotImplementedBucketResources(req *http.Request) bool {
-	for name := range req.URL.Query() {
-		if notimplementedBucketResourceNames[name] {
-			return true
-		}
+// Resource handler ServeHTTP() wrapper
+func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// A put method on path "/" doesn't make sense, ignore it.
+	if r.Method == httpPUT && r.URL.Path == "/" {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
 	}
-	return false
+
+	// Serve HTTP.
+	h.handler.ServeHTTP(w, r)
 }
 
-// Checks requests for not implemented Object resources
-func ignoreNotImplementedObjectResources(req *http.Request) bool {
-	for name := range req.URL.Query() {
-		if notimplementedObjectResourceNames[name] {
-			return true
-		}
-	}
-	return false
+// readOnlyMode - read-only mode of the server, toggled at runtime by
+// the admin API hence accessed atomically.
+type readOnlyMode struct {
+	enabled int32
 }
 
-// List of not implemented bucket queries
-var notimplementedBucketResourceNames = map[string]bool{
-	"acl":            true,
-	"cors":           true,
-	"lifecycle":      true,
-	"logging":        true,
-	"replication":    true,
-	"tagging":        true,
-	"versions":       true,
-	"requestPayment": true,
-	"versioning":     true,
-	"website":        true,
+// newReadOnlyMode - initializes read-only mode.
+func newReadOnlyMode(enabled bool) *readOnlyMode {
+	m := &readOnlyMode{}
+	m.Set(enabled)
+	return m
 }
 
-// List of not implemented object queries
-var notimplementedObjectResourceNames = map[string]bool{
-	"torrent": true,
-	"acl":     true,
-	"policy":  true,
+// IsEnabled - returns true if the server is in read-only mode.
+func (m *readOnlyMode) IsEnabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
 }
 
-// Resource handler ServeHTTP() wrapper
-func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	bucketName, objectName := urlPath2BucketObjectName(r.URL)
+// Set - enables or disables read-only mode.
+func (m *readOnlyMode) Set(enabled bool) {
+	var value int32
+	if enabled {
+		value = 1
+	}
+	atomic.StoreInt32(&m.enabled, value)
+}
 
-	// If bucketName is present and not objectName check for bucket level resource queries.
-	if bucketName != "" && objectName == "" {
-		if ignoreNotImplementedBucketResources(r) {
-			writeErrorResponse(w, ErrNotImplemented, r.URL)
-			return
-		}
+// isWriteRequest - returns true if the S3 request mutates buckets or
+// objects. Aborting a multipart upload is not considered a write, it
+// only removes data of an upload which can not be completed anymore
+// while the server is read-only. Other in-progress multipart uploads
+// are left intact so they can be resumed once the server is writable.
+func isWriteRequest(r *http.Request) bool {
+	switch r.Method {
+	case httpPUT, httpPOST:
+		return true
+	case httpDELETE:
+		_, ok := r.URL.Query()["uploadId"]
+		return !ok
 	}
-	// If bucketName and objectName are present check for its resource queries.
-	if bucketName != "" && objectName != "" {
-		if ignoreNotImplementedObjectResources(r) {
-			writeErrorResponse(w, ErrNotImplemented, r.URL)
+	return false
+}
+
+type readOnlyHandler struct {
+	handler http.Handler
+}
+
+// setReadOnlyHandler - rejects all the S3 write requests while the
+// server is in read-only mode, reads continue to be served. Admin and
+// internal RPC requests are not affected, browser requests are checked
+// by the web handlers themselves.
+func setReadOnlyHandler(h http.Handler) http.Handler {
+	return readOnlyHandler{h}
+}
+
+func (h readOnlyHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if globalReadOnlyMode.IsEnabled() && isWriteRequest(r) {
+		isAdminReq := r.URL.Path == "/" && r.Header.Get(minioAdminOpHeader) != ""
+		isReservedReq := r.URL.Path == reservedBucket || strings.HasPrefix(r.URL.Path, reservedBucket+"/")
+		if !isAdminReq && !isReservedReq {
+			writeErrorResponse(w, ErrServerReadOnly, r.URL)
 			return
 		}
 	}
-	// A put method on path "/" doesn't make sense, ignore it.
-	if r.Method == httpPUT && r.URL.Path == "/" {
-		writeErrorResponse(w, ErrNotImplemented, r.URL)
-		return
-	}
-
-	// Serve HTTP.
 	h.handler.ServeHTTP(w, r)
 }
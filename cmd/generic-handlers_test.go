@@ -17,6 +17,9 @@
 package cmd
 
 import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
 	"net/http"
 	"testing"
 )
@@ -88,3 +91,101 @@ func TestGuessIsBrowser(t *testing.T) {
 		t.Fatal("Test shouldn't report as browser for a non browser request.")
 	}
 }
+
+// Tests that writes are rejected in read-only mode while reads and
+// aborting multipart uploads continue to work.
+func TestReadOnlyMode(t *testing.T) {
+	initNSLock(false)
+	ts := StartTestServer(t, "FS")
+	defer ts.Stop()
+	defer globalReadOnlyMode.Set(false)
+
+	client := http.Client{}
+	bucket, object := "read-only-bucket", "object"
+	doRequest := func(method, urlStr string, body []byte) (*http.Response, []byte) {
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body), ts.AccessKey, ts.SecretKey)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, urlStr, err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, urlStr, err)
+		}
+		defer resp.Body.Close()
+		respBody, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("%s %s: %s", method, urlStr, err)
+		}
+		return resp, respBody
+	}
+
+	// Prepare a bucket, an object and an in-progress multipart upload.
+	if resp, _ := doRequest("PUT", getMakeBucketURL(ts.Server.URL, bucket), nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unable to create bucket, status %d", resp.StatusCode)
+	}
+	if resp, _ := doRequest("PUT", getPutObjectURL(ts.Server.URL, bucket, object), []byte("hello")); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unable to create object, status %d", resp.StatusCode)
+	}
+	resp, body := doRequest("POST", getNewMultipartURL(ts.Server.URL, bucket, object), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unable to create multipart upload, status %d", resp.StatusCode)
+	}
+	var upload InitiateMultipartUploadResponse
+	if err := xml.Unmarshal(body, &upload); err != nil {
+		t.Fatal(err)
+	}
+
+	globalReadOnlyMode.Set(true)
+
+	writes := []struct {
+		method, url string
+		body        []byte
+	}{
+		{"PUT", getMakeBucketURL(ts.Server.URL, "new-bucket"), nil},
+		{"PUT", getPutObjectURL(ts.Server.URL, bucket, "new-object"), []byte("hello")},
+		{"DELETE", getDeleteObjectURL(ts.Server.URL, bucket, object), nil},
+		{"POST", getNewMultipartURL(ts.Server.URL, bucket, "new-object"), nil},
+		{"PUT", getPartUploadURL(ts.Server.URL, bucket, object, upload.UploadID, "1"), []byte("hello")},
+	}
+	for i, write := range writes {
+		resp, body = doRequest(write.method, write.url, write.body)
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("Test %d: %s %s: Expected status %d, got %d", i+1, write.method, write.url,
+				http.StatusServiceUnavailable, resp.StatusCode)
+			continue
+		}
+		var errResp APIErrorResponse
+		if err := xml.Unmarshal(body, &errResp); err != nil {
+			t.Fatal(err)
+		}
+		if errResp.Code != getAPIError(ErrServerReadOnly).Code {
+			t.Errorf("Test %d: Expected error code %s, got %s", i+1, getAPIError(ErrServerReadOnly).Code, errResp.Code)
+		}
+	}
+
+	reads := []struct {
+		method, url string
+	}{
+		{"GET", getGetObjectURL(ts.Server.URL, bucket, object)},
+		{"HEAD", getHeadObjectURL(ts.Server.URL, bucket, object)},
+		{"GET", getListObjectsV1URL(ts.Server.URL, bucket, "")},
+	}
+	for i, read := range reads {
+		if resp, _ = doRequest(read.method, read.url, nil); resp.StatusCode != http.StatusOK {
+			t.Errorf("Test %d: %s %s: Expected status %d, got %d", i+1, read.method, read.url,
+				http.StatusOK, resp.StatusCode)
+		}
+	}
+
+	// Aborting in-progress multipart uploads is allowed.
+	resp, _ = doRequest("DELETE", getAbortMultipartUploadURL(ts.Server.URL, bucket, object, upload.UploadID), nil)
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Expected abort multipart upload to succeed, got status %d", resp.StatusCode)
+	}
+
+	// Writes succeed once read-only mode is disabled.
+	globalReadOnlyMode.Set(false)
+	if resp, _ = doRequest("PUT", getPutObjectURL(ts.Server.URL, bucket, "new-object"), []byte("hello")); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+}
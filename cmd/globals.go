@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"crypto/x509"
+	"net/http"
 	"net/url"
 	"os"
 	"runtime"
@@ -28,6 +29,8 @@ import (
 	"github.com/fatih/color"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/console"
+	"github.com/minio/minio-go/pkg/policy"
+	"github.com/minio/minio-go/pkg/set"
 	"github.com/minio/minio/pkg/objcache"
 )
 
@@ -38,14 +41,16 @@ const (
 
 // minio configuration related constants.
 const (
-	globalMinioConfigVersion      = "13"
-	globalMinioConfigDir          = ".minio"
-	globalMinioCertsDir           = "certs"
-	globalMinioCertsCADir         = "CAs"
-	globalMinioCertFile           = "public.crt"
-	globalMinioKeyFile            = "private.key"
-	globalMinioConfigFile         = "config.json"
-	globalMinioCertExpireWarnDays = time.Hour * 24 * 30 // 30 days.
+	globalMinioConfigVersion        = "13"
+	globalMinioConfigDir            = ".minio"
+	globalMinioCertsDir             = "certs"
+	globalMinioCertsCADir           = "CAs"
+	globalMinioClientCertsCADir     = "client-CAs"
+	globalMinioClientIdentitiesFile = "client-identities.json"
+	globalMinioCertFile             = "public.crt"
+	globalMinioKeyFile              = "private.key"
+	globalMinioConfigFile           = "config.json"
+	globalMinioCertExpireWarnDays   = time.Hour * 24 * 30 // 30 days.
 
 	globalMinioDefaultRegion       = "us-east-1"
 	globalMinioDefaultOwnerID      = "minio"
@@ -64,6 +69,62 @@ const (
 
 	// The maximum allowed difference between the request generation time and the server processing time
 	globalMaxSkewTime = 15 * time.Minute
+
+	// Default limits of the request headers, can be changed by
+	// MINIO_MAX_HEADER_BYTES and MINIO_MAX_HEADER_COUNT env.
+	globalDefaultMaxHeaderBytes = 1 * humanize.MiByte
+	globalDefaultMaxHeaderCount = 1000
+
+	// Default timeout to read the request headers, can be changed by
+	// MINIO_HEADER_READ_TIMEOUT env.
+	globalDefaultHeaderReadTimeout = 30 * time.Second
+
+	// Default timeout to start the next request on a keep-alive
+	// connection, can be changed by MINIO_IDLE_CONN_TIMEOUT env.
+	globalDefaultIdleConnTimeout = 30 * time.Second
+
+	// Default interval between two rotations of the TLS session ticket
+	// keys, can be changed by MINIO_TLS_SESSION_TICKET_ROTATION env.
+	globalDefaultSessionTicketRotation = time.Hour
+
+	// Default number of previous TLS session ticket keys kept to resume
+	// the sessions of their tickets, can be changed by
+	// MINIO_TLS_SESSION_TICKET_PREVIOUS_KEYS env.
+	globalDefaultSessionTicketPreviousKeys = 2
+
+	// Default interval at which the number of online disks of the XL
+	// backend is verified, can be changed by MINIO_QUORUM_CHECK_INTERVAL env.
+	globalDefaultQuorumCheckInterval = 5 * time.Second
+
+	// Default retry policy of the idempotent inter-node RPCs, can be
+	// changed by MINIO_RPC_RETRY_ATTEMPTS, MINIO_RPC_RETRY_BACKOFF and
+	// MINIO_RPC_RETRY_DEADLINE env.
+	globalDefaultRPCRetryAttempts = 3
+	globalDefaultRPCRetryBackoff  = 100 * time.Millisecond
+	globalDefaultRPCRetryCap      = time.Second
+	globalDefaultRPCRetryDeadline = 5 * time.Second
+
+	// Default half-life of the read latency scores of the nodes, can
+	// be changed by MINIO_NODE_LATENCY_HALF_LIFE env.
+	globalDefaultNodeLatencyHalfLife = time.Minute
+
+	// Default number of chunks of a server-side copy read concurrently
+	// and their size, can be changed by MINIO_COPY_CONCURRENCY and
+	// MINIO_COPY_CHUNK_SIZE env.
+	globalDefaultCopyConcurrency = 4
+	globalDefaultCopyChunkSize   = 16 * humanize.MiByte
+
+	// Default memory held by the chunks of all the server-side copies,
+	// can be changed by MINIO_COPY_MAX_MEMORY env.
+	globalDefaultCopyMaxMemory = 256 * humanize.MiByte
+
+	// Default size of the buffers copying the object data of the FS
+	// backend, can be changed by MINIO_UPLOAD_BUFFER_SIZE env.
+	globalDefaultUploadBufferSize = readSizeV1
+
+	// Default number of objects healed concurrently by a heal of the
+	// objects of a bucket, can be changed by MINIO_HEAL_CONCURRENCY env.
+	globalDefaultHealConcurrency = 4
 )
 
 var (
@@ -81,6 +142,229 @@ var (
 	// when MINIO_BROWSER env is set to 'off'.
 	globalIsBrowserEnabled = !strings.EqualFold(os.Getenv("MINIO_BROWSER"), "off")
 
+	// Server starts in read-only mode when MINIO_READ_ONLY env is
+	// set to 'on', can be toggled at runtime by the admin API.
+	globalReadOnlyMode = newReadOnlyMode(strings.EqualFold(os.Getenv("MINIO_READ_ONLY"), "on"))
+
+	// Maximum total size and number of the request headers,
+	// requests exceeding them are rejected.
+	globalMaxHeaderBytes = globalDefaultMaxHeaderBytes
+	globalMaxHeaderCount = globalDefaultMaxHeaderCount
+
+	// Timeout to read the request headers from their first byte.
+	globalHeaderReadTimeout = globalDefaultHeaderReadTimeout
+
+	// Timeout after which keep-alive connections idle between two
+	// requests are closed.
+	globalIdleConnTimeout = globalDefaultIdleConnTimeout
+
+	// Maximum number of requests pipelined on a connection ahead of
+	// their responses, zero is unlimited.
+	globalMaxPipelinedRequests = 0
+
+	// Interval between two rotations of the TLS session ticket keys,
+	// zero leaves the keys to crypto/tls.
+	globalSessionTicketRotation = globalDefaultSessionTicketRotation
+
+	// Number of previous TLS session ticket keys kept.
+	globalSessionTicketPreviousKeys = globalDefaultSessionTicketPreviousKeys
+
+	// Allowed and denied source IPs of the connections, nil serves
+	// all of them.
+	globalConnIPFilter *connIPFilter
+
+	// Maximum number of open connections, the connections over it are
+	// closed as soon as accepted. Zero is unlimited.
+	globalMaxConnections = 0
+
+	// Time the connections must keep being rejected over the limit
+	// before an alert is logged.
+	globalConnRejectAlertPeriod = globalDefaultConnRejectAlertPeriod
+
+	// Connections statistics of the listeners.
+	globalConnStats = newConnStats()
+
+	// Interval at which quorum of the XL backend is verified, operations
+	// fail fast with a quorum error once quorum is lost. Zero disables it.
+	globalQuorumCheckInterval = globalDefaultQuorumCheckInterval
+
+	// Retry policy of the idempotent inter-node RPCs and the number
+	// of retries made.
+	globalRPCRetryPolicy = rpcRetryPolicy{
+		MaxAttempts: globalDefaultRPCRetryAttempts,
+		Unit:        globalDefaultRPCRetryBackoff,
+		Cap:         globalDefaultRPCRetryCap,
+		Deadline:    globalDefaultRPCRetryDeadline,
+	}
+	globalRPCRetryStats = &rpcRetryStats{}
+
+	// Bandwidth limits of the object data transfers, unlimited by default.
+	globalBandwidthLimits = bandwidthLimits{}
+
+	// Maximum number of keys of a fan-out PUT.
+	globalFanOutMaxKeys = 100
+
+	// Maximum number of buckets, zero is unlimited.
+	globalMaxBuckets = 0
+
+	// Maximum number of multipart uploads in progress per bucket, zero
+	// is unlimited.
+	globalMaxMultipartUploads = 0
+
+	// Creates the buckets on their first write instead of failing with
+	// NoSuchBucket, disabled by default.
+	globalIsBucketAutoCreate = false
+
+	// Algorithm of the ETags of the objects written, MD5 by default.
+	globalETagAlgorithm = etagAlgorithmMD5
+
+	// Queue ARNs of the targets receiving the events of each object in
+	// order, none by default.
+	globalNotifyOrderedTargets map[string]bool
+
+	// Minimum size of the objects deleted asynchronously, zero deletes
+	// all the objects synchronously.
+	globalAsyncDeleteMinSize int64
+
+	// Validation of the bucket names, DNS compliant names only by default.
+	globalBucketNameValidation = bucketNameValidationStrict
+
+	// Reads of the objects whose client disconnected are canceled
+	// unless disabled.
+	globalIsGetCancelOnDisconnect = true
+
+	// Requests accepted in TLS early data, the reads only by default.
+	globalTLSEarlyData = tlsEarlyDataReads
+
+	// Maximum size of the objects uploaded by a single operation and of
+	// the parts of the multipart uploads.
+	globalMaxObjectSize = int64(maxObjectSize)
+
+	// Maximum size of the objects completed from their parts.
+	globalMaxMultipartObjectSize = int64(maxMultipartObjectSize)
+
+	// Maximum part ID of the multipart uploads.
+	globalMaxPartID = maxPartID
+
+	// Maximum size of the bodies of CompleteMultipartUpload.
+	globalMaxCompleteMultipartSize = int64(defaultMaxCompleteMultipartSize)
+
+	// Maximum number of concurrent operations on each drive of the
+	// XL backend. Defaults to unlimited.
+	globalDriveMaxConcurrency = 0
+
+	// Half-life of the read latency scores of the nodes of a
+	// distributed setup, reads prefer the faster nodes. Zero disables
+	// the scores.
+	globalNodeLatencyHalfLife = globalDefaultNodeLatencyHalfLife
+	globalNodeLatency         = newNodeLatencyScores()
+
+	// Server-side copies of the objects larger than a chunk read
+	// globalCopyConcurrency chunks at a time.
+	globalCopyConcurrency = globalDefaultCopyConcurrency
+	globalCopyChunkSize   = int64(globalDefaultCopyChunkSize)
+
+	// Chunks of all the server-side copies held in memory at a time
+	// are limited to globalCopyMaxMemory bytes.
+	globalCopyMaxMemory = int64(globalDefaultCopyMaxMemory)
+
+	// Buffers of the data path are pooled and reused across the
+	// requests unless disabled.
+	globalIsUploadBufferPool = true
+	globalUploadBufferSize   = int64(globalDefaultUploadBufferSize)
+
+	// Number of objects healed concurrently by a heal of the objects of
+	// a bucket, and the rate in bytes per second of the healed data
+	// written to the drives. The rate is unlimited by default and can
+	// be changed at runtime by the admin API.
+	globalHealConcurrency = globalDefaultHealConcurrency
+	globalHealBandwidth   = newHealBandwidthLimiter(0)
+
+	// Compaction of the fragmented objects of the XL backend in
+	// background, disabled by default. The objects with at least
+	// globalCompactionMinParts parts are rewritten every interval, at a
+	// rate in bytes per second which is unlimited by default.
+	globalIsCompaction        = false
+	globalCompactionMinParts  = globalDefaultCompactionMinParts
+	globalCompactionInterval  = globalDefaultCompactionInterval
+	globalCompactionBandwidth = newHealBandwidthLimiter(0)
+
+	// Time the listings are cached, zero disables the cache.
+	globalListCacheTTL time.Duration
+
+	// Concurrent reads of the same object range share one backend
+	// read, disabled by default.
+	globalIsGetCoalescing = false
+
+	// Status of the responses to the unsatisfiable ranges of the empty
+	// objects, either 416 or 200 ignoring the range.
+	globalEmptyObjectRangeStatus = http.StatusRequestedRangeNotSatisfiable
+
+	// Minimum time between two alerts of the same full disk.
+	globalDiskFullAlertInterval = globalDefaultDiskFullAlertInterval
+
+	// Bounds of the Retry-After of the throttling responses, an upper
+	// bound of zero disables the header.
+	globalRetryAfterMin = globalDefaultRetryAfterMin
+	globalRetryAfterMax = globalDefaultRetryAfterMax
+
+	// Number of requests being served.
+	globalRequestsInFlight int64
+
+	// Validates the content of the objects written before they are
+	// committed, accepts all of them by default.
+	globalObjectValidator ObjectValidator = nopObjectValidator{}
+
+	// Generates the torrents of the objects, replies NotImplemented to
+	// ?torrent by default.
+	globalIsTorrent = false
+
+	// NTP server the clock drift is checked against, none disables
+	// the check. Past the maximum drift an alert is logged, and the
+	// server refuses to start in strict mode.
+	globalNTPServer        = ""
+	globalNTPMaxDrift      = globalDefaultNTPMaxDrift
+	globalNTPCheckInterval = globalDefaultNTPCheckInterval
+	globalIsNTPStrict      = false
+
+	// Canonical names of the response headers allowed on the objects.
+	globalObjectResponseHeaders = set.CreateStringSet(defaultObjectResponseHeaders...)
+
+	// User metadata keys of the objects indexed for the metadata
+	// search, lower cased without their prefix. None disables the index.
+	globalMetadataIndexKeys []string
+
+	// Maximum number of entries scanned by a listing filtered on user
+	// metadata without the metadata index.
+	globalMetadataFilterMaxScan = globalDefaultMetadataFilterMaxScan
+
+	// Continuation tokens of the listings are gzip compressed when it
+	// makes them shorter, enabled by default.
+	globalIsListTokenCompression = true
+
+	// Continuation tokens of the listings are signed with the secret
+	// key of the server, disabled by default.
+	globalIsListTokenSigning = false
+
+	// Regions other than the server region accepted in the scope of
+	// the signature V4 of the requests. Defaults to none.
+	globalAlternateSigningRegions = set.NewStringSet()
+
+	// Deprecated features the responses of the requests using them warn
+	// about, all of them by default.
+	globalDeprecationWarnings = set.CreateStringSet(deprecationSigV2, deprecationListObjectsV1)
+
+	// Streaming uploads followed by a trailing checksum are accepted
+	// unless disabled.
+	globalIsStreamingTrailer = true
+
+	// Content types of the objects gzip compressed on the wire for
+	// the clients accepting it, disabled by default.
+	globalCompressContentTypes []string
+
+	// Cache of the objects on a faster backend, disabled by default.
+	globalDiskCacheConfig = diskCacheConfig{admission: cacheAdmissionAlways}
+
 	// Maximum cache size. Defaults to disabled.
 	// Caching is enabled only for RAM size > 8GiB.
 	globalMaxCacheSize = uint64(0)
@@ -110,6 +394,18 @@ var (
 	// IsSSL indicates if the server is configured with SSL.
 	globalIsSSL bool
 
+	// CA certificates of TLS clients, a nil value means client
+	// certificates are not required.
+	globalClientCAs *x509.CertPool
+
+	// Client certificates are verified if presented but not required
+	// during the TLS handshake when MINIO_CLIENT_CERT_OPTIONAL env is
+	// set to 'on', the buckets may still require them for their writes.
+	globalIsClientCertOptional = strings.EqualFold(os.Getenv("MINIO_CLIENT_CERT_OPTIONAL"), "on")
+
+	// Client certificate identities mapped to their canned policies.
+	globalClientCertIdentities map[string]policy.BucketPolicy
+
 	// List of admin peers.
 	globalAdminPeers = adminPeers{}
 
@@ -122,6 +418,12 @@ var (
 	// url.URL endpoints of disks that belong to the object storage.
 	globalEndpoints = []*url.URL{}
 
+	// Time when the server was started, used to report uptime.
+	globalBootTime = time.Now().UTC()
+
+	// HTTP server serving the S3 and admin APIs, nil until the server starts.
+	globalServerMux *ServerMux
+
 	// Add new variable global values here.
 )
 
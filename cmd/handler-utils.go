@@ -20,6 +20,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 )
 
@@ -55,12 +56,16 @@ func isValidLocationConstraint(r *http.Request) (s3Error APIErrorCode) {
 	return ErrMalformedXML
 }
 
+// Object metadata holding the storage class the object was uploaded with.
+const objectStorageClassKey = "X-Amz-Storage-Class"
+
 // Supported headers that needs to be extracted.
 var supportedHeaders = []string{
 	"content-type",
 	"cache-control",
 	"content-encoding",
 	"content-disposition",
+	websiteRedirectLocationKey,
 	// Add more supported headers here.
 }
 
@@ -86,6 +91,23 @@ func isMetadataReplace(h http.Header) bool {
 	return h.Get("X-Amz-Metadata-Directive") == "REPLACE"
 }
 
+// getCopySource - returns the source bucket and object of a copy from
+// the X-Amz-Copy-Source header. The header is percent-decoded as a path,
+// so that a "+" in the key is kept as is rather than turned into a
+// space, and an invalid escape or a missing bucket or object is an
+// invalid copy source.
+func getCopySource(h http.Header) (bucket, object string, s3Error APIErrorCode) {
+	cpSrcPath, err := url.PathUnescape(h.Get("X-Amz-Copy-Source"))
+	if err != nil {
+		return "", "", ErrInvalidCopySource
+	}
+	bucket, object = path2BucketAndObject(cpSrcPath)
+	if bucket == "" || object == "" {
+		return "", "", ErrInvalidCopySource
+	}
+	return bucket, object, ErrNone
+}
+
 // Splits an incoming path into bucket and object components.
 func path2BucketAndObject(path string) (bucket, object string) {
 	// Skip the first element if it is '/', split the rest.
@@ -117,6 +139,15 @@ func extractMetadataFromHeader(header http.Header) map[string]string {
 			metadata[supportedHeader] = header.Get(supportedHeader)
 		}
 	}
+	// aws-chunked only describes the transfer of streaming signed
+	// uploads, objects are stored with the remaining encodings.
+	if contentEncoding, ok := metadata["content-encoding"]; ok {
+		if contentEncoding = trimAWSChunkedEncoding(contentEncoding); contentEncoding != "" {
+			metadata["content-encoding"] = contentEncoding
+		} else {
+			delete(metadata, "content-encoding")
+		}
+	}
 	// Go through all other headers for any additional headers that needs to be saved.
 	for key := range header {
 		cKey := http.CanonicalHeaderKey(key)
@@ -126,10 +157,35 @@ func extractMetadataFromHeader(header http.Header) map[string]string {
 			metadata[cKey] = header.Get(key)
 		}
 	}
+	// Save tags of the object, matched by replication rules.
+	if tagging := header.Get(objectTaggingKey); tagging != "" {
+		metadata[objectTaggingKey] = tagging
+	}
+	// Save storage class of the object, sent back on GET and HEAD.
+	if storageClass := header.Get(objectStorageClassKey); storageClass != "" {
+		metadata[objectStorageClassKey] = storageClass
+	}
+	// Objects written by replication are marked as replicas.
+	if header.Get(replicationStatusKey) == replicationReplica {
+		metadata[replicationStatusKey] = replicationReplica
+	}
 	// Return.
 	return metadata
 }
 
+// trimAWSChunkedEncoding - removes aws-chunked from a comma separated
+// list of content encodings.
+func trimAWSChunkedEncoding(contentEncoding string) string {
+	var encodings []string
+	for _, encoding := range strings.Split(contentEncoding, ",") {
+		encoding = strings.TrimSpace(encoding)
+		if encoding != "" && !strings.EqualFold(encoding, "aws-chunked") {
+			encodings = append(encodings, encoding)
+		}
+	}
+	return strings.Join(encodings, ",")
+}
+
 // extractMetadataFromForm extracts metadata from Post Form.
 func extractMetadataFromForm(formValues map[string]string) map[string]string {
 	metadata := make(map[string]string)
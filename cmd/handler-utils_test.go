@@ -115,6 +115,30 @@ func TestExtractMetadataHeaders(t *testing.T) {
 				"X-Amz-Meta-Appid":   "amz-meta",
 				"X-Minio-Meta-Appid": "minio-meta"},
 		},
+		// Validate if 'content-encoding' is saved.
+		{
+			header: http.Header{
+				"Content-Encoding": []string{"gzip"},
+			},
+			metadata: map[string]string{
+				"content-encoding": "gzip",
+			},
+		},
+		// Validate if aws-chunked is not saved in 'content-encoding'.
+		{
+			header: http.Header{
+				"Content-Encoding": []string{"aws-chunked, gzip"},
+			},
+			metadata: map[string]string{
+				"content-encoding": "gzip",
+			},
+		},
+		{
+			header: http.Header{
+				"Content-Encoding": []string{"aws-chunked"},
+			},
+			metadata: map[string]string{},
+		},
 	}
 
 	// Validate if the extracting headers.
@@ -125,3 +149,45 @@ func TestExtractMetadataHeaders(t *testing.T) {
 		}
 	}
 }
+
+// Tests the bucket and object of the copy source are percent-decoded
+// as a path.
+func TestGetCopySource(t *testing.T) {
+	testCases := []struct {
+		copySource string
+		bucket     string
+		object     string
+		s3Error    APIErrorCode
+	}{
+		{"/bucket/object", "bucket", "object", ErrNone},
+		{"bucket/dir/object", "bucket", "dir/object", ErrNone},
+		{"%2Fbucket%2Fdir%2Fobject", "bucket", "dir/object", ErrNone},
+		// Spaces are percent-encoded.
+		{"/bucket/my%20object", "bucket", "my object", ErrNone},
+		// A "+" is not a space in a path, encoded or not.
+		{"/bucket/a+b", "bucket", "a+b", ErrNone},
+		{"/bucket/a%2Bb", "bucket", "a+b", ErrNone},
+		// Unicode keys are percent-encoded UTF-8.
+		{"/bucket/%E6%97%A5%E6%9C%AC%E8%AA%9E", "bucket", "日本語", ErrNone},
+		{"/bucket/日本語", "bucket", "日本語", ErrNone},
+		// Invalid copy sources.
+		{"", "", "", ErrInvalidCopySource},
+		{"/", "", "", ErrInvalidCopySource},
+		{"/bucket", "", "", ErrInvalidCopySource},
+		{"/bucket/", "", "", ErrInvalidCopySource},
+		{"//object", "", "", ErrInvalidCopySource},
+		{"/bucket/100%", "", "", ErrInvalidCopySource},
+	}
+
+	for i, testCase := range testCases {
+		header := http.Header{}
+		header.Set("X-Amz-Copy-Source", testCase.copySource)
+		bucket, object, s3Error := getCopySource(header)
+		if s3Error != testCase.s3Error {
+			t.Fatalf("Test %d: Expected error %d, got %d", i+1, testCase.s3Error, s3Error)
+		}
+		if bucket != testCase.bucket || object != testCase.object {
+			t.Fatalf("Test %d: Expected %s/%s, got %s/%s", i+1, testCase.bucket, testCase.object, bucket, object)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Maximum number of objects needing heal listed at a time by a heal
+// of the objects of a bucket.
+const healObjectsListSize = 1000
+
+// Longest time a heal waits before the heal bandwidth is read again,
+// the rate changed at runtime applies quickly to the waiting heals.
+const healBandwidthMaxWait = 100 * time.Millisecond
+
+// healBandwidthLimiter - token bucket shared by all the heals of the
+// server, limiting the rate at which the healed data is written to the
+// drives so that the heals do not starve the live traffic. The rate is
+// in bytes per second and can be changed at runtime, 0 is unlimited.
+type healBandwidthLimiter struct {
+	mu     sync.Mutex
+	rate   uint64
+	tokens float64
+	last   time.Time
+}
+
+func newHealBandwidthLimiter(rate uint64) *healBandwidthLimiter {
+	return &healBandwidthLimiter{rate: rate, last: time.Now()}
+}
+
+// Rate - returns the rate of the heals, 0 is unlimited.
+func (l *healBandwidthLimiter) Rate() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate
+}
+
+// SetRate - changes the rate of the heals in progress and of the next
+// ones.
+func (l *healBandwidthLimiter) SetRate(rate uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+	l.rate = rate
+	if burst := float64(rate); l.tokens > burst {
+		l.tokens = burst
+	}
+}
+
+// refill - adds the tokens accrued since the last refill, at most a
+// second worth of them. Must be called with the lock held.
+func (l *healBandwidthLimiter) refill(now time.Time) {
+	if l.rate > 0 {
+		l.tokens += now.Sub(l.last).Seconds() * float64(l.rate)
+		if burst := float64(l.rate); l.tokens > burst {
+			l.tokens = burst
+		}
+	}
+	l.last = now
+}
+
+// Wait - waits until the heals are back within the rate and takes n
+// bytes from it. A write may take the rate over for a while, the next
+// writes of all the heals wait until the excess is paid back.
+func (l *healBandwidthLimiter) Wait(n int) {
+	for {
+		l.mu.Lock()
+		if l.rate == 0 {
+			l.mu.Unlock()
+			return
+		}
+		l.refill(time.Now())
+		if l.tokens >= 0 {
+			l.tokens -= float64(n)
+			l.mu.Unlock()
+			return
+		}
+		delay := time.Duration(-l.tokens / float64(l.rate) * float64(time.Second))
+		l.mu.Unlock()
+		if delay > healBandwidthMaxWait {
+			delay = healBandwidthMaxWait
+		}
+		time.Sleep(delay)
+	}
+}
+
+// HealObjectsResult - result of the heal of the objects of a bucket.
+type HealObjectsResult struct {
+	Healed int               `json:"healed"`
+	Failed []HealObjectError `json:"failed,omitempty"`
+}
+
+// HealObjectError - object which could not be healed and the reason.
+type HealObjectError struct {
+	Object string `json:"object"`
+	Error  string `json:"error"`
+}
+
+// healObjectErrorsByName - sorts the heal errors by object name.
+type healObjectErrorsByName []HealObjectError
+
+func (e healObjectErrorsByName) Len() int           { return len(e) }
+func (e healObjectErrorsByName) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e healObjectErrorsByName) Less(i, j int) bool { return e[i].Object < e[j].Object }
+
+// healObjects - heals all the objects of the bucket matching the
+// prefix which need heal, concurrency objects at a time. The objects
+// are spread across all the drives, so the heals proceed in parallel on
+// the drives. Objects failing to heal are reported without stopping the
+// heal of the others.
+func healObjects(objLayer ObjectLayer, bucket, prefix string, concurrency int) (HealObjectsResult, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var mu sync.Mutex
+	var result HealObjectsResult
+	var wg sync.WaitGroup
+	objectCh := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for object := range objectCh {
+				err := objLayer.HealObject(bucket, object)
+				mu.Lock()
+				if err != nil {
+					result.Failed = append(result.Failed, HealObjectError{
+						Object: object,
+						Error:  errorCause(err).Error(),
+					})
+				} else {
+					result.Healed++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+	marker := ""
+	for {
+		var listInfo ListObjectsInfo
+		listInfo, err = objLayer.ListObjectsHeal(bucket, prefix, marker, "", healObjectsListSize)
+		if err != nil {
+			break
+		}
+		for _, objInfo := range listInfo.Objects {
+			objectCh <- objInfo.Name
+		}
+		if !listInfo.IsTruncated {
+			break
+		}
+		marker = listInfo.NextMarker
+	}
+	close(objectCh)
+	wg.Wait()
+	if err != nil {
+		return HealObjectsResult{}, err
+	}
+
+	sort.Sort(healObjectErrorsByName(result.Failed))
+	return result, nil
+}
+
+// setHealLimits - sets the number of objects healed concurrently and
+// the heal bandwidth from MINIO_HEAL_CONCURRENCY and MINIO_HEAL_BANDWIDTH
+// env.
+func setHealLimits() {
+	if concurrency := os.Getenv("MINIO_HEAL_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 1 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_HEAL_CONCURRENCY value %s.", concurrency)
+		}
+		globalHealConcurrency = n
+	}
+	if bandwidth := os.Getenv("MINIO_HEAL_BANDWIDTH"); bandwidth != "" {
+		rate, err := humanize.ParseBytes(bandwidth)
+		fatalIf(err, "Invalid MINIO_HEAL_BANDWIDTH value %s.", bandwidth)
+		globalHealBandwidth.SetRate(rate)
+	}
+}
@@ -0,0 +1,290 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests the heals waiting on the limiter are within its rate.
+func TestHealBandwidthLimiter(t *testing.T) {
+	const chunkSize = 64 * humanize.KiByte
+	waitAll := func(limiter *healBandwidthLimiter, workers, chunks int) time.Duration {
+		start := time.Now()
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < chunks; j++ {
+					limiter.Wait(chunkSize)
+				}
+			}()
+		}
+		wg.Wait()
+		return time.Since(start)
+	}
+
+	// Unlimited heals never wait.
+	if elapsed := waitAll(newHealBandwidthLimiter(0), 4, 100); elapsed > time.Second {
+		t.Fatalf("Expected unlimited heals not to wait, took %s", elapsed)
+	}
+
+	// The rate is shared by all the heals, only the first chunk is free.
+	rate := uint64(4 * humanize.MiByte)
+	elapsed := waitAll(newHealBandwidthLimiter(rate), 4, 5)
+	expected := time.Duration(float64(19*chunkSize) / float64(rate) * float64(time.Second))
+	if elapsed < expected*9/10 {
+		t.Fatalf("Expected the heals to take at least %s, took %s", expected, elapsed)
+	}
+}
+
+// Tests the rate changed at runtime applies to the heals in progress.
+func TestHealBandwidthLimiterSetRate(t *testing.T) {
+	const chunkSize = 16 * humanize.KiByte
+	limiter := newHealBandwidthLimiter(4 * humanize.MiByte)
+
+	var transferred int64
+	doneCh := make(chan struct{})
+	stoppedCh := make(chan struct{})
+	go func() {
+		defer close(stoppedCh)
+		for {
+			select {
+			case <-doneCh:
+				return
+			default:
+			}
+			limiter.Wait(chunkSize)
+			atomic.AddInt64(&transferred, chunkSize)
+		}
+	}()
+	measure := func() int64 {
+		before := atomic.LoadInt64(&transferred)
+		time.Sleep(300 * time.Millisecond)
+		return atomic.LoadInt64(&transferred) - before
+	}
+
+	fast := measure()
+	// Lowering the rate slows down the heal in progress.
+	limiter.SetRate(256 * humanize.KiByte)
+	time.Sleep(healBandwidthMaxWait)
+	if slow := measure(); slow*4 > fast {
+		t.Fatalf("Expected the heal to slow down from %d bytes to less than %d bytes, got %d bytes", fast, fast/4, slow)
+	}
+
+	// Removing the limit resumes the waiting heal right away.
+	limiter.SetRate(1)
+	time.Sleep(healBandwidthMaxWait)
+	limiter.SetRate(0)
+	if unlimited := measure(); unlimited <= fast {
+		t.Fatalf("Expected the heal to speed up past %d bytes, got %d bytes", fast, unlimited)
+	}
+	if rate := limiter.Rate(); rate != 0 {
+		t.Fatalf("Expected rate 0, got %d", rate)
+	}
+
+	close(doneCh)
+	select {
+	case <-stoppedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the heal to stop, timed out waiting")
+	}
+}
+
+// healObjectsTestLayer - lists a fixed set of objects needing heal by
+// pages of at most 10 objects, and records the concurrent heals.
+type healObjectsTestLayer struct {
+	ObjectLayer
+	objects []string
+	failing map[string]bool
+	listErr error
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	healed      []string
+}
+
+func (l *healObjectsTestLayer) ListObjectsHeal(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	if l.listErr != nil {
+		return ListObjectsInfo{}, l.listErr
+	}
+	if maxKeys > 10 {
+		maxKeys = 10
+	}
+	var result ListObjectsInfo
+	for _, object := range l.objects {
+		if object <= marker {
+			continue
+		}
+		if len(result.Objects) == maxKeys {
+			result.IsTruncated = true
+			break
+		}
+		result.Objects = append(result.Objects, ObjectInfo{Bucket: bucket, Name: object})
+		result.NextMarker = object
+	}
+	return result, nil
+}
+
+func (l *healObjectsTestLayer) HealObject(bucket, object string) error {
+	l.mu.Lock()
+	l.inFlight++
+	if l.inFlight > l.maxInFlight {
+		l.maxInFlight = l.inFlight
+	}
+	l.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.healed = append(l.healed, object)
+	if l.failing[object] {
+		return traceError(errDiskNotFound)
+	}
+	return nil
+}
+
+// Tests the objects are healed concurrently up to the limit, and the
+// objects failing to heal are reported.
+func TestHealObjectsConcurrency(t *testing.T) {
+	var objects []string
+	for i := 0; i < 25; i++ {
+		objects = append(objects, "object"+strconv.Itoa(100+i))
+	}
+
+	for _, concurrency := range []int{1, 4, 8} {
+		objLayer := &healObjectsTestLayer{
+			objects: objects,
+			failing: map[string]bool{"object105": true, "object120": true},
+		}
+		result, err := healObjects(objLayer, "bucket", "", concurrency)
+		if err != nil {
+			t.Fatalf("Concurrency %d: Unexpected error %v", concurrency, err)
+		}
+		if objLayer.maxInFlight != concurrency {
+			t.Fatalf("Concurrency %d: Expected %d concurrent heals, got %d", concurrency, concurrency, objLayer.maxInFlight)
+		}
+		sort.Strings(objLayer.healed)
+		if len(objLayer.healed) != len(objects) {
+			t.Fatalf("Concurrency %d: Expected %d objects healed once, got %v", concurrency, len(objects), objLayer.healed)
+		}
+		for i := range objects {
+			if objLayer.healed[i] != objects[i] {
+				t.Fatalf("Concurrency %d: Expected %d objects healed once, got %v", concurrency, len(objects), objLayer.healed)
+			}
+		}
+		if result.Healed != len(objects)-2 {
+			t.Fatalf("Concurrency %d: Expected %d objects healed, got %d", concurrency, len(objects)-2, result.Healed)
+		}
+		expectedFailed := []HealObjectError{
+			{Object: "object105", Error: errDiskNotFound.Error()},
+			{Object: "object120", Error: errDiskNotFound.Error()},
+		}
+		if len(result.Failed) != len(expectedFailed) || result.Failed[0] != expectedFailed[0] || result.Failed[1] != expectedFailed[1] {
+			t.Fatalf("Concurrency %d: Expected failed %v, got %v", concurrency, expectedFailed, result.Failed)
+		}
+	}
+
+	// Listing errors are returned.
+	listErr := errors.New("list error")
+	if _, err := healObjects(&healObjectsTestLayer{listErr: listErr}, "bucket", "", 4); err != listErr {
+		t.Fatalf("Expected %v, got %v", listErr, err)
+	}
+}
+
+// Tests the objects of a XL backend missing on a drive are healed in
+// parallel within the heal bandwidth.
+func TestHealObjectsXL(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+	initNSLock(false)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), humanize.MiByte)
+	objects := []string{"dir/object1", "dir/object2", "dir/object3", "dir/object4", "dir/object5", "other"}
+	for _, object := range objects {
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+			t.Fatal(err)
+		}
+		// Simulate the first drive was down when the object was created.
+		if err = os.RemoveAll(path.Join(fsDirs[0], bucket, object)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each object heals a block of 1MiB / 8 data drives on the first drive.
+	defer globalHealBandwidth.SetRate(globalHealBandwidth.Rate())
+	rate := uint64(humanize.MiByte)
+	globalHealBandwidth.SetRate(rate)
+
+	start := time.Now()
+	result, err := healObjects(obj, bucket, "dir/", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	elapsed := time.Since(start)
+	if result.Healed != 5 || len(result.Failed) != 0 {
+		t.Fatalf("Expected 5 objects healed, got %#v", result)
+	}
+	expected := time.Duration(float64(4*humanize.MiByte/8) / float64(rate) * float64(time.Second))
+	if elapsed < expected*9/10 {
+		t.Fatalf("Expected the heal to take at least %s, took %s", expected, elapsed)
+	}
+
+	// Only the objects outside of the prefix still need heal.
+	listInfo, err := obj.ListObjectsHeal(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listInfo.Objects) != 1 || listInfo.Objects[0].Name != "other" {
+		t.Fatalf("Expected only other to need heal, got %v", listInfo.Objects)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "dir/object1", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("Expected the healed object to be unchanged")
+	}
+}
@@ -0,0 +1,64 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	healthCheckPath      = "/health"
+	healthCheckReadyPath = "/ready"
+)
+
+// registerHealthCheckRouter - add handler functions for the health checks.
+func registerHealthCheckRouter(mux *router.Router) {
+	healthRouter := mux.NewRoute().PathPrefix(reservedBucket + healthCheckPath).Subrouter()
+
+	// Readiness probe.
+	healthRouter.Methods("GET", "HEAD").Path(healthCheckReadyPath).HandlerFunc(ReadinessCheckHandler)
+}
+
+// ReadinessCheckHandler - GET /minio/health/ready
+// ----------
+// Replies with 200 OK when the server can serve requests i.e read quorum
+// is available, 503 Service Unavailable otherwise. Write quorum is
+// reported in the body since reads continue to be served without it.
+func ReadinessCheckHandler(w http.ResponseWriter, r *http.Request) {
+	objLayer := newObjectLayerFn()
+	if objLayer == nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	quorumStatus := getQuorumStatus(objLayer)
+	jsonBytes, err := json.Marshal(quorumStatus)
+	if err != nil {
+		errorIf(err, "Failed to marshal quorum status into json.")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !quorumStatus.ReadQuorum {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(jsonBytes)
+}
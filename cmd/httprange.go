@@ -18,6 +18,8 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
@@ -47,6 +49,9 @@ func (hrange httpRange) getLength() int64 {
 	return 1 + hrange.offsetEnd - hrange.offsetBegin
 }
 
+// parseRequestRange - parses the Range header value for an object of
+// given size. A nil range with no error means the whole object is to
+// be sent.
 func parseRequestRange(rangeString string, resourceSize int64) (hrange *httpRange, err error) {
 	// Return error if given range string doesn't start with byte range prefix.
 	if !strings.HasPrefix(rangeString, byteRangePrefix) {
@@ -118,6 +123,12 @@ func parseRequestRange(rangeString string, resourceSize int64) (hrange *httpRang
 			return nil, errInvalidRange
 		}
 
+		if resourceSize == 0 {
+			// Suffix of an empty object is the whole empty object, which
+			// cannot be expressed as a byte range, the range is ignored.
+			return nil, nil
+		}
+
 		if offsetEnd >= resourceSize {
 			offsetBegin = 0
 		} else {
@@ -132,3 +143,39 @@ func parseRequestRange(rangeString string, resourceSize int64) (hrange *httpRang
 
 	return &httpRange{offsetBegin, offsetEnd, resourceSize}, nil
 }
+
+// unsatisfiedContentRange - returns the Content-Range header value of
+// the responses to unsatisfiable ranges of an object of given size.
+func unsatisfiedContentRange(resourceSize int64) string {
+	return fmt.Sprintf("bytes */%d", resourceSize)
+}
+
+// setEmptyObjectRangeStatus - sets the status of the responses to the
+// unsatisfiable ranges of the empty objects from
+// MINIO_EMPTY_OBJECT_RANGE_STATUS env.
+func setEmptyObjectRangeStatus() {
+	if status := os.Getenv("MINIO_EMPTY_OBJECT_RANGE_STATUS"); status != "" {
+		switch status {
+		case "416":
+			globalEmptyObjectRangeStatus = http.StatusRequestedRangeNotSatisfiable
+		case "200":
+			globalEmptyObjectRangeStatus = http.StatusOK
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_EMPTY_OBJECT_RANGE_STATUS value %s.", status)
+		}
+	}
+}
+
+// isContentRangeFullObject - returns true if the Content-Range header
+// value is empty or covers the whole object of given size, i.e
+// 'bytes 0-<size-1>/<size>' or 'bytes */0' for empty objects.
+func isContentRangeFullObject(contentRange string, size int64) bool {
+	if contentRange == "" {
+		return true
+	}
+	if size == 0 {
+		return contentRange == "bytes */0"
+	}
+	fullRange := httpRange{offsetBegin: 0, offsetEnd: size - 1, resourceSize: size}
+	return contentRange == fullRange.String()
+}
@@ -87,4 +87,39 @@ func TestParseRequestRange(t *testing.T) {
 			t.Fatalf("expected: %s, got: %s", errInvalidRange, err)
 		}
 	}
+
+	// Test ranges of an empty object, only a suffix range is
+	// satisfiable and it is ignored.
+	for _, rangeString := range []string{"bytes=0-", "bytes=0-0", "bytes=-0"} {
+		if _, err := parseRequestRange(rangeString, 0); err != errInvalidRange {
+			t.Fatalf("expected: %s, got: %s", errInvalidRange, err)
+		}
+	}
+	if hrange, err := parseRequestRange("bytes=-5", 0); hrange != nil || err != nil {
+		t.Fatalf("expected: <nil> range and error, got: %v, %v", hrange, err)
+	}
+}
+
+// Test validates Content-Range values covering the whole object.
+func TestIsContentRangeFullObject(t *testing.T) {
+	testCases := []struct {
+		contentRange string
+		size         int64
+		expected     bool
+	}{
+		{"", 10, true},
+		{"bytes 0-9/10", 10, true},
+		{"bytes */0", 0, true},
+		{"bytes 0-8/10", 10, false},
+		{"bytes 1-9/10", 10, false},
+		{"bytes 0-9/*", 10, false},
+		{"bytes 0-9/11", 10, false},
+		{"bytes=0-9", 10, false},
+		{"bytes 0--1/0", 0, false},
+	}
+	for i, testCase := range testCases {
+		if actual := isContentRangeFullObject(testCase.contentRange, testCase.size); actual != testCase.expected {
+			t.Errorf("Test %d: Expected %t for %q of size %d, got %t", i+1, testCase.expected, testCase.contentRange, testCase.size, actual)
+		}
+	}
 }
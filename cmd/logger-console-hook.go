@@ -26,21 +26,34 @@ type consoleLogger struct {
 
 // enable console logger.
 func enableConsoleLogger() {
-	clogger := serverConfig.GetConsoleLogger()
-	if !clogger.Enable {
+	consoleLogger, err := newConsoleLogger(serverConfig.GetConsoleLogger())
+	fatalIf(err, "Unknown log level found in the config file.")
+	if consoleLogger == nil {
 		return
 	}
 
+	log.mu.Lock()
+	log.loggers = append(log.loggers, consoleLogger)
+	log.mu.Unlock()
+}
+
+// newConsoleLogger - returns a console logger for the config, nil if
+// the console logger is disabled.
+func newConsoleLogger(clogger consoleLogger) (*logrus.Logger, error) {
+	if !clogger.Enable {
+		return nil, nil
+	}
+
 	consoleLogger := logrus.New()
 
 	// log.Out and log.Formatter use the default versions.
 	// Only set specific log level.
 	lvl, err := logrus.ParseLevel(clogger.Level)
-	fatalIf(err, "Unknown log level found in the config file.")
+	if err != nil {
+		return nil, err
+	}
 
 	consoleLogger.Level = lvl
 	consoleLogger.Formatter = new(logrus.TextFormatter)
-	log.mu.Lock()
-	log.loggers = append(log.loggers, consoleLogger)
-	log.mu.Unlock()
+	return consoleLogger, nil
 }
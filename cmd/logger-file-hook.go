@@ -35,31 +35,45 @@ type localFile struct {
 }
 
 func enableFileLogger() {
-	flogger := serverConfig.GetFileLogger()
-	if !flogger.Enable || flogger.Filename == "" {
+	fileLogger, err := newFileLogger(serverConfig.GetFileLogger())
+	fatalIf(err, "Unable to initialize the file logger.")
+	if fileLogger == nil {
 		return
 	}
 
+	log.mu.Lock()
+	log.loggers = append(log.loggers, fileLogger)
+	log.mu.Unlock()
+}
+
+// newFileLogger - returns a file logger for the config, nil if the
+// file logger is disabled.
+func newFileLogger(flogger fileLogger) (*logrus.Logger, error) {
+	if !flogger.Enable || flogger.Filename == "" {
+		return nil, nil
+	}
+
+	lvl, err := logrus.ParseLevel(flogger.Level)
+	if err != nil {
+		return nil, err
+	}
+
 	// Creates the named file with mode 0666, honors system umask.
 	file, err := os.OpenFile(flogger.Filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
-	fatalIf(err, "Unable to open log file.")
+	if err != nil {
+		return nil, err
+	}
 
 	fileLogger := logrus.New()
 
 	// Add a local file hook.
 	fileLogger.Hooks.Add(&localFile{file})
 
-	lvl, err := logrus.ParseLevel(flogger.Level)
-	fatalIf(err, "Unknown log level found in the config file.")
-
 	// Set default JSON formatter.
 	fileLogger.Out = ioutil.Discard
 	fileLogger.Formatter = new(logrus.JSONFormatter)
 	fileLogger.Level = lvl // Minimum log level.
-
-	log.mu.Lock()
-	log.loggers = append(log.loggers, fileLogger)
-	log.mu.Unlock()
+	return fileLogger, nil
 }
 
 // Fire fires the file logger hook and logs to the file.
@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"path"
 	"runtime"
 	"strings"
@@ -44,6 +45,27 @@ type logger struct {
 	// Add new loggers here.
 }
 
+// closeLogger - closes the files and the connections of the hooks of
+// the logger, once it is no longer used. Every hook is registered for
+// each of its levels, it is closed once with its first level.
+func closeLogger(l *logrus.Logger) {
+	for level, hooks := range l.Hooks {
+		for _, hook := range hooks {
+			if levels := hook.Levels(); len(levels) == 0 || levels[0] != level {
+				continue
+			}
+			switch c := hook.(type) {
+			case io.Closer:
+				c.Close()
+			case interface {
+				Close()
+			}:
+				c.Close()
+			}
+		}
+	}
+}
+
 // Get file, line, function name of the caller.
 func callerSource() string {
 	pc, file, line, success := runtime.Caller(2)
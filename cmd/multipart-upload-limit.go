@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path"
+	"strconv"
+)
+
+// Lock path prefix in the meta bucket serializing the new multipart
+// uploads of a bucket while their maximum number is enforced.
+const maxMultipartUploadsLockPrefix = "max-multipart-uploads"
+
+// errTooManyMultipartUploads - the maximum number of multipart uploads
+// in progress in the bucket is reached.
+var errTooManyMultipartUploads = errors.New("The maximum number of multipart uploads in progress in the bucket is reached")
+
+// setMaxMultipartUploads - sets the maximum number of multipart uploads
+// in progress per bucket from MINIO_MAX_MULTIPART_UPLOADS env.
+func setMaxMultipartUploads() {
+	if maxUploads := os.Getenv("MINIO_MAX_MULTIPART_UPLOADS"); maxUploads != "" {
+		n, err := strconv.Atoi(maxUploads)
+		if err != nil || n < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_MULTIPART_UPLOADS value %s.", maxUploads)
+		}
+		globalMaxMultipartUploads = n
+	}
+}
+
+// countMultipartUploads - returns the number of multipart uploads in
+// progress in the bucket, counted up to limit.
+func countMultipartUploads(objAPI ObjectLayer, bucket string, limit int) (int, error) {
+	count := 0
+	keyMarker, uploadIDMarker := "", ""
+	for count < limit {
+		result, err := objAPI.ListMultipartUploads(bucket, "", keyMarker, uploadIDMarker, "", maxUploadsList)
+		if err != nil {
+			return 0, err
+		}
+		count += len(result.Uploads)
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, uploadIDMarker = result.NextKeyMarker, result.NextUploadIDMarker
+	}
+	return count, nil
+}
+
+// newMultipartUploadWithinLimit - initiates the multipart upload unless
+// the maximum number of multipart uploads in progress in the bucket is
+// reached. The uploads are counted from the object layer, completed and
+// aborted uploads make room for new ones. While a maximum is set the
+// new uploads of the bucket are serialized, so that concurrent ones
+// never exceed it.
+func newMultipartUploadWithinLimit(objAPI ObjectLayer, bucket, object string, metadata map[string]string) (string, error) {
+	if globalMaxMultipartUploads == 0 {
+		return objAPI.NewMultipartUpload(bucket, object, metadata)
+	}
+
+	maxUploadsLock := globalNSMutex.NewNSLock(minioMetaBucket, path.Join(maxMultipartUploadsLockPrefix, bucket))
+	maxUploadsLock.Lock()
+	defer maxUploadsLock.Unlock()
+
+	count, err := countMultipartUploads(objAPI, bucket, globalMaxMultipartUploads)
+	if err != nil {
+		return "", err
+	}
+	if count >= globalMaxMultipartUploads {
+		return "", traceError(errTooManyMultipartUploads)
+	}
+	return objAPI.NewMultipartUpload(bucket, object, metadata)
+}
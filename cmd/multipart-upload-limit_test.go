@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// Tests concurrent multipart uploads never exceed the maximum number of
+// multipart uploads in progress in the bucket, and that completed and
+// aborted uploads make room for new ones.
+func TestNewMultipartUploadWithinLimit(t *testing.T) {
+	ExecObjectLayerTest(t, testNewMultipartUploadWithinLimit)
+}
+
+func testNewMultipartUploadWithinLimit(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	maxUploads := 5
+	globalMaxMultipartUploads = maxUploads
+	defer func() { globalMaxMultipartUploads = 0 }()
+
+	bucket, otherBucket := "bucket", "other-bucket"
+	for _, name := range []string{bucket, otherBucket} {
+		if err := obj.MakeBucket(name); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+	}
+
+	attempts := 3 * maxUploads
+	uploadIDs := make([]string, attempts)
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			uploadIDs[i], errs[i] = newMultipartUploadWithinLimit(obj, bucket, fmt.Sprintf("object-%d", i%2), nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var objects, initiated []string
+	for i, err := range errs {
+		switch {
+		case err == nil:
+			objects = append(objects, fmt.Sprintf("object-%d", i%2))
+			initiated = append(initiated, uploadIDs[i])
+		case toAPIErrorCode(err) != ErrTooManyMultipartUploads:
+			t.Fatalf("%s: Attempt %d: Expected error %v, got %v", instanceType, i+1, errTooManyMultipartUploads, err)
+		}
+	}
+	if len(initiated) != maxUploads {
+		t.Fatalf("%s: Expected %d uploads to be initiated, got %d", instanceType, maxUploads, len(initiated))
+	}
+	count, err := countMultipartUploads(obj, bucket, attempts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != maxUploads {
+		t.Fatalf("%s: Expected %d uploads in progress, got %d", instanceType, maxUploads, count)
+	}
+
+	// The limit applies per bucket.
+	if _, err = newMultipartUploadWithinLimit(obj, otherBucket, "object", nil); err != nil {
+		t.Fatalf("%s: Expected the upload to be initiated in another bucket, got %v", instanceType, err)
+	}
+
+	// Aborted uploads make room for a new one.
+	if err = obj.AbortMultipartUpload(bucket, objects[0], initiated[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newMultipartUploadWithinLimit(obj, bucket, "object-new", nil); err != nil {
+		t.Fatalf("%s: Expected the upload to be initiated after an abort, got %v", instanceType, err)
+	}
+	if _, err = newMultipartUploadWithinLimit(obj, bucket, "object-new", nil); toAPIErrorCode(err) != ErrTooManyMultipartUploads {
+		t.Fatalf("%s: Expected error %v, got %v", instanceType, errTooManyMultipartUploads, err)
+	}
+
+	// Completed uploads make room for a new one.
+	data := []byte("data")
+	part, err := obj.PutObjectPart(bucket, objects[1], initiated[1], 1, int64(len(data)), bytes.NewReader(data), "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, objects[1], initiated[1], []completePart{{PartNumber: 1, ETag: part.ETag}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newMultipartUploadWithinLimit(obj, bucket, "object-new", nil); err != nil {
+		t.Fatalf("%s: Expected the upload to be initiated after a completion, got %v", instanceType, err)
+	}
+}
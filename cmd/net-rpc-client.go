@@ -78,7 +78,22 @@ func (rpcClient *RPCClient) dial() (netRPCClient *rpc.Client, err error) {
 		}
 
 		// ServerName in tls.Config needs to be specified to support SNI certificates.
-		conn, err = tls.Dial("tcp", rpcClient.serverAddr, &tls.Config{ServerName: hostname, RootCAs: globalRootCAs})
+		tlsConfig := &tls.Config{ServerName: hostname, RootCAs: globalRootCAs}
+		// Peers require client certificates when client CAs are
+		// configured, present this server's own certificate.
+		if globalClientCAs != nil {
+			var cert tls.Certificate
+			if cert, err = tls.LoadX509KeyPair(mustGetCertFile(), mustGetKeyFile()); err != nil {
+				return nil, &net.OpError{
+					Op:   "dial-http",
+					Net:  rpcClient.serverAddr + rpcClient.serviceEndpoint,
+					Addr: nil,
+					Err:  err,
+				}
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		conn, err = tls.Dial("tcp", rpcClient.serverAddr, tlsConfig)
 	} else {
 		// Dial with a timeout.
 		conn, err = net.DialTimeout("tcp", rpcClient.serverAddr, defaultDialTimeout)
@@ -58,8 +58,8 @@ func dialAMQP(amqpL amqpNotify) (amqpConn, error) {
 	return amqpConn{Connection: conn, params: amqpL}, nil
 }
 
-func newAMQPNotify(accountID string) (*logrus.Logger, error) {
-	amqpL := serverConfig.GetAMQPNotifyByID(accountID)
+func newAMQPNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	amqpL := srvCfg.GetAMQPNotifyByID(accountID)
 
 	// Connect to amqp server.
 	amqpC, err := dialAMQP(amqpL)
@@ -54,8 +54,8 @@ func dialElastic(esNotify elasticSearchNotify) (*elastic.Client, error) {
 	return client, nil
 }
 
-func newElasticNotify(accountID string) (*logrus.Logger, error) {
-	esNotify := serverConfig.GetElasticSearchNotifyByID(accountID)
+func newElasticNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	esNotify := srvCfg.GetElasticSearchNotifyByID(accountID)
 
 	// Dial to elastic search.
 	client, err := dialElastic(esNotify)
@@ -133,6 +133,11 @@ func (q elasticClient) Fire(entry *logrus.Entry) error {
 	return err
 }
 
+// Close - stops the background processes of the elastic search client.
+func (q elasticClient) Close() {
+	q.Client.Stop()
+}
+
 // Required for logrus hook implementation
 func (q elasticClient) Levels() []logrus.Level {
 	return []logrus.Level{
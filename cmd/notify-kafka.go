@@ -74,8 +74,8 @@ func dialKafka(kn kafkaNotify) (kafkaConn, error) {
 	return kafkaConn{p, kn.Topic}, nil
 }
 
-func newKafkaNotify(accountID string) (*logrus.Logger, error) {
-	kafkaNotifyCfg := serverConfig.GetKafkaNotifyByID(accountID)
+func newKafkaNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	kafkaNotifyCfg := srvCfg.GetKafkaNotifyByID(accountID)
 
 	// Try connecting to the configured Kafka broker(s).
 	kc, err := dialKafka(kafkaNotifyCfg)
@@ -126,8 +126,13 @@ func closeNATS(conn natsIOConn) {
 	}
 }
 
-func newNATSNotify(accountID string) (*logrus.Logger, error) {
-	natsL := serverConfig.GetNATSNotifyByID(accountID)
+// Close - closes the NATS connection of the hook.
+func (n natsIOConn) Close() {
+	closeNATS(n)
+}
+
+func newNATSNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	natsL := srvCfg.GetNATSNotifyByID(accountID)
 
 	// Connect to nats server.
 	natsC, err := dialNATS(natsL, false)
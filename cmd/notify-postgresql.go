@@ -173,8 +173,8 @@ func dialPostgreSQL(pgN postgreSQLNotify) (pgConn, error) {
 	return pgConn{connStr, pgN.Table, stmts, db}, nil
 }
 
-func newPostgreSQLNotify(accountID string) (*logrus.Logger, error) {
-	pgNotify := serverConfig.GetPostgreSQLNotifyByID(accountID)
+func newPostgreSQLNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	pgNotify := srvCfg.GetPostgreSQLNotifyByID(accountID)
 
 	// Dial postgres
 	pgC, err := dialPostgreSQL(pgNotify)
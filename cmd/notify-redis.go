@@ -82,8 +82,8 @@ func dialRedis(rNotify redisNotify) (*redis.Pool, error) {
 	return rPool, nil
 }
 
-func newRedisNotify(accountID string) (*logrus.Logger, error) {
-	rNotify := serverConfig.GetRedisNotifyByID(accountID)
+func newRedisNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	rNotify := srvCfg.GetRedisNotifyByID(accountID)
 
 	// Dial redis.
 	rPool, err := dialRedis(rNotify)
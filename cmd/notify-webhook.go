@@ -51,8 +51,8 @@ func lookupEndpoint(u *url.URL) error {
 }
 
 // Initializes new webhook logrus notifier.
-func newWebhookNotify(accountID string) (*logrus.Logger, error) {
-	rNotify := serverConfig.GetWebhookNotifyByID(accountID)
+func newWebhookNotify(srvCfg *serverConfigV13, accountID string) (*logrus.Logger, error) {
+	rNotify := srvCfg.GetWebhookNotifyByID(accountID)
 
 	if rNotify.Endpoint == "" {
 		return nil, errInvalidArgument
@@ -131,6 +131,13 @@ func (n httpConn) Fire(entry *logrus.Entry) error {
 	return nil
 }
 
+// Close - closes the idle connections to the endpoint.
+func (n httpConn) Close() {
+	if transport, ok := n.Client.Transport.(*http.Transport); ok {
+		transport.CloseIdleConnections()
+	}
+}
+
 // Levels are Required for logrus hook implementation
 func (httpConn) Levels() []logrus.Level {
 	return []logrus.Level{
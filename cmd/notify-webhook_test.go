@@ -46,19 +46,19 @@ func TestNewWebHookNotify(t *testing.T) {
 	}
 	defer removeAll(root)
 
-	_, err = newWebhookNotify("1")
+	_, err = newWebhookNotify(serverConfig, "1")
 	if err == nil {
 		t.Fatal("Unexpected should fail")
 	}
 
 	serverConfig.SetWebhookNotifyByID("10", webhookNotify{Enable: true, Endpoint: "http://www."})
-	_, err = newWebhookNotify("10")
+	_, err = newWebhookNotify(serverConfig, "10")
 	if err == nil {
 		t.Fatal("Unexpected should fail with lookupHost")
 	}
 
 	serverConfig.SetWebhookNotifyByID("15", webhookNotify{Enable: true, Endpoint: "http://%"})
-	_, err = newWebhookNotify("15")
+	_, err = newWebhookNotify(serverConfig, "15")
 	if err == nil {
 		t.Fatal("Unexpected should fail with invalid URL escape")
 	}
@@ -67,7 +67,7 @@ func TestNewWebHookNotify(t *testing.T) {
 	defer server.Close()
 
 	serverConfig.SetWebhookNotifyByID("20", webhookNotify{Enable: true, Endpoint: server.URL})
-	webhook, err := newWebhookNotify("20")
+	webhook, err := newWebhookNotify(serverConfig, "20")
 	if err != nil {
 		t.Fatal("Unexpected shouldn't fail", err)
 	}
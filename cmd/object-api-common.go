@@ -309,3 +309,51 @@ func cleanupDir(storage StorageAPI, volume, dirPath string) error {
 	err := delFunc(retainSlash(pathJoin(dirPath)))
 	return err
 }
+
+// listObjectsFunc - lists upto maxKeys objects recursively after marker.
+type listObjectsFunc func(marker string, maxKeys int) (ListObjectsInfo, error)
+
+// listObjectsWithDelimiter - lists objects grouped by any delimiter other
+// than '/', which the tree walk handles natively. All the keys are listed
+// recursively, keys containing the delimiter after the prefix are rolled
+// up into a single common prefix ending at the first such delimiter.
+func listObjectsWithDelimiter(listFn listObjectsFunc, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result := ListObjectsInfo{}
+
+	// Marker set to a common prefix, skip all the keys rolled up into it.
+	var skipPrefix string
+	if strings.HasSuffix(marker, delimiter) && strings.Contains(marker[len(prefix):], delimiter) {
+		skipPrefix = marker
+	}
+
+	var count int
+	for {
+		listObjInfo, err := listFn(marker, maxObjectList)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		for _, objInfo := range listObjInfo.Objects {
+			marker = objInfo.Name
+			if skipPrefix != "" && hasPrefix(objInfo.Name, skipPrefix) {
+				continue
+			}
+			if count == maxKeys {
+				result.IsTruncated = true
+				return result, nil
+			}
+			count++
+			index := strings.Index(objInfo.Name[len(prefix):], delimiter)
+			if index == -1 {
+				result.NextMarker = objInfo.Name
+				result.Objects = append(result.Objects, objInfo)
+				continue
+			}
+			skipPrefix = objInfo.Name[:len(prefix)+index+len(delimiter)]
+			result.NextMarker = skipPrefix
+			result.Prefixes = append(result.Prefixes, skipPrefix)
+		}
+		if !listObjInfo.IsTruncated {
+			return result, nil
+		}
+	}
+}
@@ -190,6 +190,17 @@ func (e BadDigest) Error() string {
 	return "Bad digest: Expected " + e.ExpectedMD5 + " is not valid with what we calculated " + e.CalculatedMD5
 }
 
+// ObjectContentRejected - content of the object rejected by the object
+// validator.
+type ObjectContentRejected struct {
+	GenericError
+	Reason string
+}
+
+func (e ObjectContentRejected) Error() string {
+	return "Content of the object " + e.Bucket + "/" + e.Object + " was rejected: " + e.Reason
+}
+
 // UnsupportedDelimiter - unsupported delimiter.
 type UnsupportedDelimiter struct {
 	Delimiter string
@@ -58,12 +58,6 @@ func checkListObjsArgs(bucket, prefix, marker, delimiter string, obj ObjectLayer
 			Object: prefix,
 		})
 	}
-	// Verify if delimiter is anything other than '/', which we do not support.
-	if delimiter != "" && delimiter != slashSeparator {
-		return traceError(UnsupportedDelimiter{
-			Delimiter: delimiter,
-		})
-	}
 	// Verify if marker has prefix.
 	if marker != "" && !hasPrefix(marker, prefix) {
 		return traceError(InvalidMarkerPrefixCombination{
@@ -79,6 +73,12 @@ func checkListMultipartArgs(bucket, prefix, keyMarker, uploadIDMarker, delimiter
 	if err := checkListObjsArgs(bucket, prefix, keyMarker, delimiter, obj); err != nil {
 		return err
 	}
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != slashSeparator {
+		return traceError(UnsupportedDelimiter{
+			Delimiter: delimiter,
+		})
+	}
 	if uploadIDMarker != "" {
 		if hasSuffix(keyMarker, slashSeparator) {
 			return traceError(InvalidUploadIDKeyCombination{
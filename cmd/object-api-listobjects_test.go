@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"reflect"
 	"strconv"
 	"strings"
 	"testing"
@@ -430,10 +431,9 @@ func testListObjects(obj ObjectLayer, instanceType string, t TestErrHandler) {
 		{"volatile-bucket-1", "", "", "", 0, ListObjectsInfo{}, BucketNotFound{Bucket: "volatile-bucket-1"}, false},
 		{"volatile-bucket-2", "", "", "", 0, ListObjectsInfo{}, BucketNotFound{Bucket: "volatile-bucket-2"}, false},
 		{"volatile-bucket-3", "", "", "", 0, ListObjectsInfo{}, BucketNotFound{Bucket: "volatile-bucket-3"}, false},
-		// Valid, existing bucket, but sending invalid delimeter values (9-10).
-		// Empty string < "" > and forward slash < / > are the ony two valid arguments for delimeter.
-		{"test-bucket-list-object", "", "", "*", 0, ListObjectsInfo{}, fmt.Errorf("delimiter '%s' is not supported", "*"), false},
-		{"test-bucket-list-object", "", "", "-", 0, ListObjectsInfo{}, fmt.Errorf("delimiter '%s' is not supported", "-"), false},
+		// Valid, existing bucket, with delimiters other than '/' (9-10).
+		{"test-bucket-list-object", "", "", "*", 0, ListObjectsInfo{}, nil, true},
+		{"test-bucket-list-object", "", "", "-", 0, ListObjectsInfo{}, nil, true},
 		// Testing for failure cases with both perfix and marker (11).
 		// The prefix and marker combination to be valid it should satisy strings.HasPrefix(marker, prefix).
 		{"test-bucket-list-object", "asia", "europe-object", "", 0, ListObjectsInfo{}, fmt.Errorf("Invalid combination of marker '%s' and prefix '%s'", "europe-object", "asia"), false},
@@ -569,6 +569,85 @@ func testListObjects(obj ObjectLayer, instanceType string, t TestErrHandler) {
 	}
 }
 
+// Wrapper for calling ListObjects tests with multi-character delimiter for both XL multiple disks and single node setup.
+func TestListObjectsWithDelimiter(t *testing.T) {
+	ExecObjectLayerTest(t, testListObjectsWithDelimiter)
+}
+
+// Unit test for ListObjects with delimiters other than '/'.
+func testListObjectsWithDelimiter(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "test-bucket-list-delimiter"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	testObjects := []string{
+		"a/b::c",
+		"photos::",
+		"photos::2016::dec.png",
+		"photos::2017::feb.png",
+		"photos::2017::jan.png",
+		"photos:x",
+		"readme",
+		"videos::intro.mp4",
+	}
+	for _, object := range testObjects {
+		_, err := obj.PutObject(bucket, object, int64(len(object)), bytes.NewBufferString(object), nil, "")
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+	}
+
+	testCases := []struct {
+		prefix     string
+		marker     string
+		delimiter  string
+		maxKeys    int
+		objects    []string
+		prefixes   []string
+		truncated  bool
+		nextMarker string
+	}{
+		// Keys are rolled up at the first delimiter (1).
+		{"", "", "::", 1000, []string{"photos:x", "readme"}, []string{"a/b::", "photos::", "videos::"}, false, "videos::"},
+		// Key equal to the prefix is listed as an object (2).
+		{"photos::", "", "::", 1000, []string{"photos::"}, []string{"photos::2016::", "photos::2017::"}, false, "photos::2017::"},
+		// Key ending with the delimiter is rolled up (3).
+		{"photos", "", "::", 1000, []string{"photos:x"}, []string{"photos::"}, false, "photos:x"},
+		// Delimiter in the middle of the prefix (4).
+		{"photos::2017", "", "::", 1000, nil, []string{"photos::2017::"}, false, "photos::2017::"},
+		// Truncated listing, common prefixes count towards max keys (5-7).
+		{"", "", "::", 2, nil, []string{"a/b::", "photos::"}, true, "photos::"},
+		{"", "photos::", "::", 2, []string{"photos:x", "readme"}, nil, true, "readme"},
+		{"", "readme", "::", 2, nil, []string{"videos::"}, false, "videos::"},
+		// Delimiter which matches no keys lists all the keys (8).
+		{"", "", "--", 1000, testObjects, nil, false, "videos::intro.mp4"},
+	}
+
+	for i, testCase := range testCases {
+		result, err := obj.ListObjects(bucket, testCase.prefix, testCase.marker, testCase.delimiter, testCase.maxKeys)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Expected to pass, but failed with: <ERROR> %s", i+1, instanceType, err)
+		}
+		var objects []string
+		for _, objInfo := range result.Objects {
+			objects = append(objects, objInfo.Name)
+		}
+		if !reflect.DeepEqual(objects, testCase.objects) {
+			t.Errorf("Test %d: %s: Expected objects %v, but found %v", i+1, instanceType, testCase.objects, objects)
+		}
+		if !reflect.DeepEqual(result.Prefixes, testCase.prefixes) {
+			t.Errorf("Test %d: %s: Expected prefixes %v, but found %v", i+1, instanceType, testCase.prefixes, result.Prefixes)
+		}
+		if result.IsTruncated != testCase.truncated {
+			t.Errorf("Test %d: %s: Expected IsTruncated flag to be %v, but found %v", i+1, instanceType, testCase.truncated, result.IsTruncated)
+		}
+		if result.NextMarker != testCase.nextMarker {
+			t.Errorf("Test %d: %s: Expected NextMarker \"%s\", but found \"%s\"", i+1, instanceType, testCase.nextMarker, result.NextMarker)
+		}
+	}
+}
+
 // Initialize FS backend for the benchmark.
 func initFSObjectsB(disk string, t *testing.B) (obj ObjectLayer) {
 	var err error
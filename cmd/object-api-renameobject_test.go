@@ -0,0 +1,94 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Wrapper for calling RenameObject tests for both XL multiple disks and single node setup.
+func TestRenameObject(t *testing.T) {
+	ExecObjectLayerTest(t, testRenameObject)
+}
+
+// Tests objects are moved along with their metadata, through the meta
+// bucket and back.
+func testRenameObject(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	srcBucket, dstBucket := "rename-src", "rename-dst"
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	data := []byte("hello rename")
+	metadata := map[string]string{"content-type": "text/plain", "X-Amz-Meta-Color": "blue"}
+	if _, err := obj.PutObject(srcBucket, "dir/object", int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Into the meta bucket.
+	metaObject := "rename-test/" + mustGetUUID()
+	if _, err := obj.RenameObject(srcBucket, "dir/object", minioMetaBucket, metaObject); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := obj.GetObjectInfo(srcBucket, "dir/object"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the source to be moved, got %v", instanceType, err)
+	}
+	result, err := obj.ListObjects(srcBucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 0 || len(result.Prefixes) != 0 {
+		t.Fatalf("%s: Expected an empty bucket, got %#v", instanceType, result)
+	}
+
+	// And back to another bucket.
+	objInfo, err := obj.RenameObject(minioMetaBucket, metaObject, dstBucket, "object")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.Bucket != dstBucket || objInfo.Name != "object" || objInfo.Size != int64(len(data)) {
+		t.Fatalf("%s: Unexpected object info %#v", instanceType, objInfo)
+	}
+	if objInfo.ContentType != "text/plain" || objInfo.UserDefined["X-Amz-Meta-Color"] != "blue" {
+		t.Fatalf("%s: Expected the metadata to be moved, got %#v", instanceType, objInfo.UserDefined)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(dstBucket, "object", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected content %q, got %q", instanceType, data, buffer.Bytes())
+	}
+
+	// Existing objects are not replaced.
+	if _, err = obj.PutObject(srcBucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err = obj.RenameObject(srcBucket, "object", dstBucket, "object"); err == nil {
+		t.Fatalf("%s: Expected the rename to an existing object to fail", instanceType)
+	}
+	if _, err = obj.GetObjectInfo(srcBucket, "object"); err != nil {
+		t.Fatalf("%s: Expected the source to be kept, got %v", instanceType, err)
+	}
+
+	// Missing objects.
+	if _, err = obj.RenameObject(srcBucket, "missing", dstBucket, "missing"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected ObjectNotFound, got %v", instanceType, err)
+	}
+}
@@ -23,6 +23,7 @@ import (
 	"path"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -55,21 +56,18 @@ func isMinioMetaBucketName(bucket string) bool {
 // IsValidBucketName verifies a bucket name in accordance with Amazon's
 // requirements. It must be 3-63 characters long, can contain dashes
 // and periods, but must begin and end with a lowercase letter or a number.
+// The legacy bucket names are accepted as well in the relaxed mode of
+// MINIO_BUCKET_NAME_VALIDATION.
 // See: http://docs.aws.amazon.com/AmazonS3/latest/dev/BucketRestrictions.html
 func IsValidBucketName(bucket string) bool {
 	// Special case when bucket is equal to one of the meta buckets.
 	if isMinioMetaBucketName(bucket) {
 		return true
 	}
-	if len(bucket) < 3 || len(bucket) > 63 {
-		return false
-	}
-	if bucket[0] == '.' || bucket[len(bucket)-1] == '.' {
-		return false
+	if globalBucketNameValidation == bucketNameValidationRelaxed {
+		return isValidRelaxedBucketName(bucket)
 	}
-	return (validBucket.MatchString(bucket) &&
-		!isIPAddress.MatchString(bucket) &&
-		!strings.Contains(bucket, ".."))
+	return isValidStrictBucketName(bucket)
 }
 
 // IsValidObjectName verifies an object name in accordance with Amazon's
@@ -146,20 +144,40 @@ func mustGetUUID() string {
 	return uuid.String()
 }
 
-// Create an s3 compatible MD5sum for complete multipart transaction.
+// Create an s3 compatible MD5sum for complete multipart transaction,
+// the sum of the concatenated sums of the parts computed with the
+// configured ETag algorithm.
 func getCompleteMultipartMD5(parts []completePart) (string, error) {
-	var finalMD5Bytes []byte
+	etagHash := newETagHash()
 	for _, part := range parts {
-		md5Bytes, err := hex.DecodeString(part.ETag)
+		sum, err := getETagSum(part.ETag)
 		if err != nil {
 			return "", traceError(err)
 		}
-		finalMD5Bytes = append(finalMD5Bytes, md5Bytes...)
+		etagHash.Write(sum)
 	}
-	s3MD5 := fmt.Sprintf("%s-%d", getMD5Hash(finalMD5Bytes), len(parts))
+	s3MD5 := fmt.Sprintf("%s-%d", hex.EncodeToString(etagHash.Sum(nil)), len(parts))
 	return s3MD5, nil
 }
 
+// getMultipartPartsCount - returns the number of parts of a multipart
+// object from its s3 compatible MD5sum, 0 for the objects uploaded by
+// a single operation.
+func getMultipartPartsCount(md5Sum string) int {
+	i := strings.LastIndex(md5Sum, "-")
+	if i == -1 {
+		return 0
+	}
+	if _, err := hex.DecodeString(md5Sum[:i]); err != nil {
+		return 0
+	}
+	partsCount, err := strconv.Atoi(md5Sum[i+1:])
+	if err != nil || partsCount <= 0 {
+		return 0
+	}
+	return partsCount
+}
+
 // Prefix matcher string matches prefix in a platform specific way.
 // For example on windows since its case insensitive we are supposed
 // to do case insensitive checks.
@@ -210,3 +228,39 @@ func (l *rangeReader) Read(p []byte) (n int, err error) {
 	}
 	return
 }
+
+// sizeReader returns a Reader that reads from r the size declared by
+// an upload, and returns errDataTooLarge once that size is read if r
+// holds more data. The object layer reads only the declared size, the
+// data sent past it, by an aws-chunked upload lying about its decoded
+// length, would otherwise be dropped silently.
+type sizeReader struct {
+	Reader io.Reader // underlying reader
+	N      int64     // bytes remaining
+}
+
+func newSizeReader(reader io.Reader, size int64) io.Reader {
+	return &sizeReader{Reader: reader, N: size}
+}
+
+func (s *sizeReader) Read(p []byte) (n int, err error) {
+	if int64(len(p)) > s.N {
+		p = p[:s.N]
+	}
+	if len(p) > 0 {
+		n, err = s.Reader.Read(p)
+		s.N -= int64(n)
+	}
+	if s.N > 0 || err != nil {
+		return n, err
+	}
+	// Declared size read, the upload must end here.
+	var extra [1]byte
+	if _, err = io.ReadFull(s.Reader, extra[:]); err == nil {
+		return 0, errDataTooLarge
+	}
+	if err == io.EOF {
+		return n, io.EOF
+	}
+	return 0, err
+}
@@ -64,6 +64,20 @@ func TestIsValidBucketName(t *testing.T) {
 		{"ThisBeginsAndEndsWithUpperCase", false},
 		{"una ñina", false},
 		{"lalalallalallalalalallalallalala-theString-size-is-greater-than-64", false},
+		{"10.0.0.1", false},
+		{"my.-bucket", false},
+		{"my-.bucket", false},
+		{"under_score", false},
+		{"xn--bucket", false},
+		{"bucket-s3alias", false},
+		{"bucket--ol-s3", false},
+		// edge cases of the length and the IP-like names.
+		{"abc", true},
+		{strings.Repeat("a", 63), true},
+		{strings.Repeat("a", 64), false},
+		{"192.168.1.1.bucket", true},
+		{"1.2.3", true},
+		{"my-xn--bucket", true},
 	}
 
 	for i, testCase := range testCases {
@@ -176,6 +190,31 @@ func TestGetCompleteMultipartMD5(t *testing.T) {
 	}
 }
 
+// Tests the number of parts of the multipart objects from their MD5sum.
+func TestGetMultipartPartsCount(t *testing.T) {
+	testCases := []struct {
+		md5Sum     string
+		partsCount int
+	}{
+		{"10dc1617fbcf0bd0858048cb96e6bd77-1", 1},
+		{"0239a86b5266bb624f0ac60ba2aed6c8-2", 2},
+		{"0239a86b5266bb624f0ac60ba2aed6c8-10000", 10000},
+		// Objects uploaded by a single operation.
+		{"cf1f738a5924e645913c984e0fe3d708", 0},
+		{"e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855-sha256", 0},
+		{"", 0},
+		// Malformed sums.
+		{"wrong-md5-hash-string", 0},
+		{"0239a86b5266bb624f0ac60ba2aed6c8-0", 0},
+		{"0239a86b5266bb624f0ac60ba2aed6c8-", 0},
+	}
+	for i, testCase := range testCases {
+		if partsCount := getMultipartPartsCount(testCase.md5Sum); partsCount != testCase.partsCount {
+			t.Errorf("Test %d: Expected %d parts for %q, got %d", i+1, testCase.partsCount, testCase.md5Sum, partsCount)
+		}
+	}
+}
+
 // TestIsMinioBucketName - Tests isMinioBucketName helper function.
 func TestIsMinioMetaBucketName(t *testing.T) {
 	testCases := []struct {
@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"sync"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Maximum number of deleted objects waiting for their storage to be
+// reclaimed, the storage is reclaimed by the deletes themselves once
+// reached.
+const asyncDeleteQueueSize = 1000
+
+// setAsyncDeleteMinSize - sets the minimum size of the objects deleted
+// asynchronously from MINIO_ASYNC_DELETE_MIN_SIZE env.
+func setAsyncDeleteMinSize() {
+	if minSize := os.Getenv("MINIO_ASYNC_DELETE_MIN_SIZE"); minSize != "" {
+		size, err := humanize.ParseBytes(minSize)
+		if err != nil {
+			fatalIf(errInvalidArgument, "Invalid MINIO_ASYNC_DELETE_MIN_SIZE value %s.", minSize)
+		}
+		globalAsyncDeleteMinSize = int64(size)
+	}
+}
+
+// isAsyncDelete - returns true if the object of the size is deleted
+// asynchronously. The objects of the meta bucket are always deleted
+// synchronously.
+func isAsyncDelete(bucket string, size int64) bool {
+	return globalAsyncDeleteMinSize > 0 && size >= globalAsyncDeleteMinSize &&
+		!isMinioMetaBucketName(bucket)
+}
+
+// asyncDeleter - reclaims the storage of the objects deleted
+// asynchronously. The deletes move the objects out of their bucket
+// first, so that they are gone from the reads and the listings as soon
+// as the deletes return, and leave the removal of their data to the
+// reaper. The objects moved to the temporary bucket and not reclaimed
+// yet when the server stops are removed along with it on the next
+// start.
+type asyncDeleter struct {
+	once    sync.Once
+	queue   chan func() error
+	pending sync.WaitGroup
+}
+
+// Reclaims the storage of the objects deleted asynchronously.
+var globalAsyncDeleter = newAsyncDeleter(asyncDeleteQueueSize)
+
+// newAsyncDeleter - initializes the async deleter, its reaper is
+// started on the first delete.
+func newAsyncDeleter(queueSize int) *asyncDeleter {
+	return &asyncDeleter{queue: make(chan func() error, queueSize)}
+}
+
+// Delete - queues the reclamation of the storage of an object moved
+// out of its bucket.
+func (d *asyncDeleter) Delete(reclaim func() error) {
+	d.once.Do(func() { go d.reap() })
+	d.pending.Add(1)
+	select {
+	case d.queue <- reclaim:
+	default:
+		// The reaper is late, reclaim right away.
+		d.reclaim(reclaim)
+	}
+}
+
+// Wait - waits for the storage of all the objects queued to be reclaimed.
+func (d *asyncDeleter) Wait() {
+	d.pending.Wait()
+}
+
+func (d *asyncDeleter) reap() {
+	for reclaim := range d.queue {
+		d.reclaim(reclaim)
+	}
+}
+
+func (d *asyncDeleter) reclaim(reclaim func() error) {
+	defer d.pending.Done()
+	errorIf(reclaim(), "Unable to reclaim the storage of a deleted object.")
+}
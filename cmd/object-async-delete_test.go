@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+// Tests the large objects deleted asynchronously are gone from the
+// reads and the listings right away, their storage being reclaimed
+// afterwards.
+func TestAsyncDeleteObject(t *testing.T) {
+	ExecObjectLayerTest(t, testAsyncDeleteObject)
+}
+
+func testAsyncDeleteObject(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func() { globalAsyncDeleteMinSize = 0 }()
+	globalAsyncDeleteMinSize = 1024
+
+	// tmpEntries - returns the number of entries in the temporary bucket.
+	tmpEntries := func() int {
+		switch layer := obj.(type) {
+		case *fsObjects:
+			entries, err := ioutil.ReadDir(pathJoin(layer.fsPath, minioMetaTmpBucket, layer.fsUUID))
+			if err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+			return len(entries)
+		case *xlObjects:
+			entries, err := layer.storageDisks[0].ListDir(minioMetaTmpBucket, "")
+			if err != nil {
+				t.Fatalf("%s: %s", instanceType, err)
+			}
+			return len(entries)
+		}
+		t.Fatalf("%s: Unexpected object layer %T", instanceType, obj)
+		return 0
+	}
+
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	large, small := "dir/large", "small"
+	for object, size := range map[string]int{large: 2048, small: 512} {
+		if _, err := obj.PutObject(bucket, object, int64(size), bytes.NewReader(bytes.Repeat([]byte("a"), size)), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	before := tmpEntries()
+
+	// Hold the reaper until the deleted object is checked.
+	release := make(chan struct{})
+	globalAsyncDeleter.Delete(func() error {
+		<-release
+		return nil
+	})
+
+	if err := obj.DeleteObject(bucket, large); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := obj.GetObjectInfo(bucket, large); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the deleted object not to be found, got %v", instanceType, err)
+	}
+	if err := obj.GetObject(bucket, large, 0, -1, ioutil.Discard); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the deleted object not to be read, got %v", instanceType, err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Name != small || len(result.Prefixes) != 0 {
+		t.Fatalf("%s: Expected only %s to be listed, got %v %v", instanceType, small, result.Objects, result.Prefixes)
+	}
+	// A new object can be written right away under the same name.
+	if _, err = obj.PutObject(bucket, large, 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, large); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if n := tmpEntries(); n != before+1 {
+		t.Fatalf("%s: Expected the deleted object to wait in the temporary bucket, got %d entries instead of %d", instanceType, n, before+1)
+	}
+
+	// The storage is reclaimed once the reaper is done.
+	close(release)
+	globalAsyncDeleter.Wait()
+	if n := tmpEntries(); n != before {
+		t.Fatalf("%s: Expected the storage of the deleted object to be reclaimed, got %d entries instead of %d", instanceType, n, before)
+	}
+
+	// Small objects are deleted synchronously.
+	if err = obj.DeleteObject(bucket, small); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if n := tmpEntries(); n != before {
+		t.Fatalf("%s: Expected the small object to be deleted synchronously, got %d entries instead of %d", instanceType, n, before)
+	}
+	if _, err = obj.GetObjectInfo(bucket, small); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the deleted object not to be found, got %v", instanceType, err)
+	}
+}
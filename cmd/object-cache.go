@@ -0,0 +1,406 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"container/list"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/sha256-simd"
+)
+
+// Admission policies of the objects to the cache.
+const (
+	// Objects are admitted on their first read, the default.
+	cacheAdmissionAlways = "always"
+	// Objects are admitted on their second read, so that the objects
+	// read once like by a scan do not evict the hot objects.
+	cacheAdmissionSecondHit = "second-hit"
+)
+
+// Maximum number of the objects read once remembered for the second-hit
+// admission, all of them are forgotten once exceeded.
+const cacheMaxSeenObjects = 100000
+
+// diskCacheConfig - configuration of the cache of the objects on a
+// faster backend, the cache is disabled if dir is empty.
+type diskCacheConfig struct {
+	dir       string
+	capacity  int64
+	admission string
+}
+
+// setDiskCacheConfig - sets the cache of the objects from the
+// MINIO_CACHE_DIR, MINIO_CACHE_SIZE and MINIO_CACHE_ADMISSION env.
+func setDiskCacheConfig() {
+	dir := os.Getenv("MINIO_CACHE_DIR")
+	if dir == "" {
+		return
+	}
+	size := os.Getenv("MINIO_CACHE_SIZE")
+	capacity, err := humanize.ParseBytes(size)
+	if err != nil || capacity == 0 {
+		fatalIf(errInvalidArgument, "Invalid MINIO_CACHE_SIZE value %s.", size)
+	}
+	globalDiskCacheConfig.dir = dir
+	globalDiskCacheConfig.capacity = int64(capacity)
+	if admission := os.Getenv("MINIO_CACHE_ADMISSION"); admission != "" {
+		switch strings.ToLower(admission) {
+		case cacheAdmissionAlways:
+			globalDiskCacheConfig.admission = cacheAdmissionAlways
+		case cacheAdmissionSecondHit:
+			globalDiskCacheConfig.admission = cacheAdmissionSecondHit
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_CACHE_ADMISSION value %s.", admission)
+		}
+	}
+}
+
+// diskCacheEntry - object held by the cache. The object is served from
+// the cache only while it is the same object in the backend.
+type diskCacheEntry struct {
+	key     string
+	size    int64
+	md5Sum  string
+	modTime time.Time
+}
+
+// diskCache - objects cached as files in a directory, evicted by least
+// recent use once the capacity is reached. The cache is not persisted,
+// it is emptied when the server starts.
+type diskCache struct {
+	// Number of reads served from the cache and from the backend,
+	// accessed atomically.
+	hits   uint64
+	misses uint64
+
+	dataDir   string
+	tmpDir    string
+	capacity  int64
+	admission string
+
+	mu      sync.Mutex
+	used    int64
+	lru     *list.List // Most recently used entries at the front.
+	entries map[string]*list.Element
+	seen    map[string]struct{} // Objects read once, for the second-hit admission.
+}
+
+// newDiskCache - initializes the cache in the dir, the files of a
+// previous run are removed.
+func newDiskCache(config diskCacheConfig) (*diskCache, error) {
+	if config.dir == "" || config.capacity <= 0 {
+		return nil, errInvalidArgument
+	}
+	dir, err := filepath.Abs(config.dir)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir := filepath.Join(dir, minioMetaBucket, "cache")
+	if err = os.RemoveAll(cacheDir); err != nil {
+		return nil, err
+	}
+	cache := &diskCache{
+		dataDir:   filepath.Join(cacheDir, "data"),
+		tmpDir:    filepath.Join(cacheDir, "tmp"),
+		capacity:  config.capacity,
+		admission: config.admission,
+		lru:       list.New(),
+		entries:   make(map[string]*list.Element),
+		seen:      make(map[string]struct{}),
+	}
+	for _, d := range []string{cache.dataDir, cache.tmpDir} {
+		if err = mkdirAll(d, 0777); err != nil {
+			return nil, err
+		}
+	}
+	return cache, nil
+}
+
+// getPath - returns the path of the file of the cached object.
+func (c *diskCache) getPath(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dataDir, hex.EncodeToString(sum[:]))
+}
+
+// Open - returns the file of the object if it is cached, nil otherwise.
+// The entry is removed if the object changed in the backend.
+func (c *diskCache) Open(key string, objInfo ObjectInfo) *os.File {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := elem.Value.(*diskCacheEntry)
+	if entry.size != objInfo.Size || entry.md5Sum != objInfo.MD5Sum || !entry.modTime.Equal(objInfo.ModTime) {
+		c.remove(elem)
+		return nil
+	}
+	file, err := os.Open(c.getPath(key))
+	if err != nil {
+		errorIf(err, "Unable to open the cached object %s.", key)
+		c.remove(elem)
+		return nil
+	}
+	c.lru.MoveToFront(elem)
+	return file
+}
+
+// Admit - returns true if the object read is to be cached according
+// to the admission policy.
+func (c *diskCache) Admit(key string, size int64) bool {
+	if size > c.capacity {
+		return false
+	}
+	if c.admission != cacheAdmissionSecondHit {
+		return true
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.seen[key]; ok {
+		delete(c.seen, key)
+		return true
+	}
+	if len(c.seen) >= cacheMaxSeenObjects {
+		c.seen = make(map[string]struct{})
+	}
+	c.seen[key] = struct{}{}
+	return false
+}
+
+// Create - returns a new file filling the cache with an object read
+// from the backend.
+func (c *diskCache) Create() (*diskCacheFill, error) {
+	file, err := ioutil.TempFile(c.tmpDir, "fill")
+	if err != nil {
+		return nil, err
+	}
+	return &diskCacheFill{file: file}, nil
+}
+
+// Commit - adds the object filled to the cache, evicting the least
+// recently used objects to make room for it.
+func (c *diskCache) Commit(key string, fill *diskCacheFill, objInfo ObjectInfo) {
+	if err := fill.close(); err != nil || fill.size != objInfo.Size {
+		errorIf(err, "Unable to cache the object %s.", key)
+		fill.Abort()
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	for c.used+objInfo.Size > c.capacity && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+	if err := os.Rename(fill.file.Name(), c.getPath(key)); err != nil {
+		errorIf(err, "Unable to cache the object %s.", key)
+		fill.Abort()
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&diskCacheEntry{
+		key:     key,
+		size:    objInfo.Size,
+		md5Sum:  objInfo.MD5Sum,
+		modTime: objInfo.ModTime,
+	})
+	c.used += objInfo.Size
+}
+
+// Delete - removes the object from the cache.
+func (c *diskCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// DeletePrefix - removes the objects with the prefix from the cache.
+func (c *diskCache) DeletePrefix(prefix string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, elem := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			c.remove(elem)
+		}
+	}
+}
+
+// remove - removes the entry and its file, the files opened for the
+// reads in progress are still read till the end.
+func (c *diskCache) remove(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*diskCacheEntry)
+	delete(c.entries, entry.key)
+	c.used -= entry.size
+	if err := os.Remove(c.getPath(entry.key)); err != nil && !os.IsNotExist(err) {
+		errorIf(err, "Unable to remove the cached object %s.", entry.key)
+	}
+}
+
+// diskCacheFill - file filled with an object as it is read from the
+// backend. Writing never fails, so that the read is not failed by the
+// cache, the object is not cached instead.
+type diskCacheFill struct {
+	file *os.File
+	size int64
+	err  error
+}
+
+func (f *diskCacheFill) Write(p []byte) (int, error) {
+	if f.err == nil {
+		var n int
+		n, f.err = f.file.Write(p)
+		f.size += int64(n)
+	}
+	return len(p), nil
+}
+
+func (f *diskCacheFill) close() error {
+	if err := f.file.Close(); f.err == nil {
+		f.err = err
+	}
+	return f.err
+}
+
+// Abort - removes the file filled.
+func (f *diskCacheFill) Abort() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// cacheObjects - object layer serving the objects read from the cache
+// on a faster backend. Cold objects are read from the backend and
+// cached as they are sent. Every write of an object replaces the whole
+// object and removes it from the cache, the cached objects are checked
+// against the backend on each read as well so that an object changed
+// by another server is never served from the cache.
+type cacheObjects struct {
+	ObjectLayer
+
+	cache *diskCache
+}
+
+// newCacheObjects - returns the object layer caching the objects of
+// the backend object layer.
+func newCacheObjects(objAPI ObjectLayer, config diskCacheConfig) (ObjectLayer, error) {
+	cache, err := newDiskCache(config)
+	if err != nil {
+		return nil, err
+	}
+	return &cacheObjects{ObjectLayer: objAPI, cache: cache}, nil
+}
+
+// GetObject - reads the object from the cache if it is cached, from the
+// backend otherwise. Objects read whole are cached if admitted, ranged
+// reads of the cold objects are sent from the backend only.
+func (c *cacheObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	objInfo, err := c.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		length = objInfo.Size - startOffset
+	}
+	// Invalid ranges are rejected by the backend.
+	if startOffset < 0 || startOffset+length > objInfo.Size {
+		return c.ObjectLayer.GetObject(bucket, object, startOffset, length, writer)
+	}
+
+	key := pathJoin(bucket, object)
+	if file := c.cache.Open(key, objInfo); file != nil {
+		defer file.Close()
+		atomic.AddUint64(&c.cache.hits, 1)
+		if _, err = file.Seek(startOffset, 0); err != nil {
+			return traceError(err)
+		}
+		if _, err = io.CopyN(writer, file, length); err != nil {
+			return traceError(err)
+		}
+		return nil
+	}
+	atomic.AddUint64(&c.cache.misses, 1)
+
+	if startOffset != 0 || length != objInfo.Size || !c.cache.Admit(key, objInfo.Size) {
+		return c.ObjectLayer.GetObject(bucket, object, startOffset, length, writer)
+	}
+	fill, err := c.cache.Create()
+	if err != nil {
+		errorIf(err, "Unable to cache the object %s.", key)
+		return c.ObjectLayer.GetObject(bucket, object, startOffset, length, writer)
+	}
+	if err = c.ObjectLayer.GetObject(bucket, object, startOffset, length, io.MultiWriter(writer, fill)); err != nil {
+		fill.Abort()
+		return err
+	}
+	c.cache.Commit(key, fill, objInfo)
+	return nil
+}
+
+// PutObject - writes the object to the backend and removes it from the
+// cache.
+func (c *cacheObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	defer c.cache.Delete(pathJoin(bucket, object))
+	return c.ObjectLayer.PutObject(bucket, object, size, data, metadata, sha256sum)
+}
+
+// CopyObject - copies the object in the backend and removes the
+// destination object from the cache.
+func (c *cacheObjects) CopyObject(srcBucket, srcObject, destBucket, destObject string, metadata map[string]string) (ObjectInfo, error) {
+	defer c.cache.Delete(pathJoin(destBucket, destObject))
+	return c.ObjectLayer.CopyObject(srcBucket, srcObject, destBucket, destObject, metadata)
+}
+
+// RenameObject - moves the object in the backend and removes both
+// objects from the cache.
+func (c *cacheObjects) RenameObject(srcBucket, srcObject, destBucket, destObject string) (ObjectInfo, error) {
+	defer c.cache.Delete(pathJoin(srcBucket, srcObject))
+	defer c.cache.Delete(pathJoin(destBucket, destObject))
+	return c.ObjectLayer.RenameObject(srcBucket, srcObject, destBucket, destObject)
+}
+
+// DeleteObject - deletes the object from the backend and the cache.
+func (c *cacheObjects) DeleteObject(bucket, object string) error {
+	defer c.cache.Delete(pathJoin(bucket, object))
+	return c.ObjectLayer.DeleteObject(bucket, object)
+}
+
+// CompleteMultipartUpload - completes the upload in the backend and
+// removes the object from the cache.
+func (c *cacheObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (ObjectInfo, error) {
+	defer c.cache.Delete(pathJoin(bucket, object))
+	return c.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+}
+
+// DeleteBucket - deletes the bucket from the backend and its objects
+// from the cache.
+func (c *cacheObjects) DeleteBucket(bucket string) error {
+	defer c.cache.DeletePrefix(bucket + slashSeparator)
+	return c.ObjectLayer.DeleteBucket(bucket)
+}
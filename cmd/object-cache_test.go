@@ -0,0 +1,242 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// Returns the object layer caching the objects of obj in a new
+// directory, along with its cache.
+func newTestCacheObjects(obj ObjectLayer, capacity int64, admission string, t TestErrHandler) (*cacheObjects, string) {
+	dir, err := getRandomDisks(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objAPI, err := newCacheObjects(obj, diskCacheConfig{dir: dir[0], capacity: capacity, admission: admission})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return objAPI.(*cacheObjects), dir[0]
+}
+
+// Reads the object through the cache, fails if the data read differs
+// from the expected data.
+func readTestCacheObject(c *cacheObjects, bucket, object string, offset, length int64, expected []byte, t TestErrHandler) {
+	var buffer bytes.Buffer
+	if err := c.GetObject(bucket, object, offset, length, &buffer); err != nil {
+		t.Fatalf("%s: %s", object, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Fatalf("%s: Expected %q, got %q", object, expected, buffer.Bytes())
+	}
+}
+
+// Tests the reads served from the cache and from the backend.
+func TestCacheObjectsHitMiss(t *testing.T) {
+	ExecObjectLayerTest(t, testCacheObjectsHitMiss)
+}
+
+func testCacheObjectsHitMiss(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	c, dir := newTestCacheObjects(obj, 100, cacheAdmissionAlways, t)
+	defer removeAll(dir)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := []byte("cached data")
+	if _, err := c.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		offset, length int64
+		expected       []byte
+		hits, misses   uint64
+	}{
+		// Test case - 1.
+		// Ranged read of a cold object is not cached.
+		{2, 4, data[2:6], 0, 1},
+		// Test case - 2.
+		// Whole read of a cold object caches it.
+		{0, int64(len(data)), data, 0, 2},
+		// Test case - 3.
+		{0, int64(len(data)), data, 1, 2},
+		// Test case - 4.
+		// Ranged reads are served from the cache.
+		{7, 4, data[7:], 2, 2},
+		// Test case - 5.
+		{0, -1, data, 3, 2},
+	}
+	for i, testCase := range testCases {
+		readTestCacheObject(c, bucket, "object", testCase.offset, testCase.length, testCase.expected, t)
+		if c.cache.hits != testCase.hits || c.cache.misses != testCase.misses {
+			t.Fatalf("%s: Test %d: Expected %d hits and %d misses, got %d and %d", instanceType, i+1,
+				testCase.hits, testCase.misses, c.cache.hits, c.cache.misses)
+		}
+	}
+
+	// Objects larger than the cache are never cached.
+	large := bytes.Repeat([]byte("a"), 101)
+	if _, err := c.PutObject(bucket, "large", int64(len(large)), bytes.NewReader(large), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	readTestCacheObject(c, bucket, "large", 0, int64(len(large)), large, t)
+	readTestCacheObject(c, bucket, "large", 0, int64(len(large)), large, t)
+	if c.cache.hits != 3 || c.cache.misses != 4 {
+		t.Fatalf("%s: Expected 3 hits and 4 misses, got %d and %d", instanceType, c.cache.hits, c.cache.misses)
+	}
+
+	// Objects are cached on their second read with the second-hit admission.
+	c.cache.admission = cacheAdmissionSecondHit
+	if _, err := c.PutObject(bucket, "scanned", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for i := 0; i < 3; i++ {
+		readTestCacheObject(c, bucket, "scanned", 0, int64(len(data)), data, t)
+	}
+	if c.cache.hits != 4 || c.cache.misses != 6 {
+		t.Fatalf("%s: Expected 4 hits and 6 misses, got %d and %d", instanceType, c.cache.hits, c.cache.misses)
+	}
+}
+
+// Tests that the least recently used objects are evicted once the
+// capacity is reached.
+func TestCacheObjectsEviction(t *testing.T) {
+	ExecObjectLayerTest(t, testCacheObjectsEviction)
+}
+
+func testCacheObjectsEviction(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	// Room for 3 objects of 10 bytes.
+	c, dir := newTestCacheObjects(obj, 35, cacheAdmissionAlways, t)
+	defer removeAll(dir)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	for i := 0; i < 4; i++ {
+		data := []byte(fmt.Sprintf("object-%03d", i))
+		if _, err := c.PutObject(bucket, fmt.Sprintf("object-%d", i), int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	read := func(i int) {
+		readTestCacheObject(c, bucket, fmt.Sprintf("object-%d", i), 0, -1, []byte(fmt.Sprintf("object-%03d", i)), t)
+	}
+
+	// Objects 0, 1 and 2 are cached, object 0 is used the most recently.
+	read(0)
+	read(1)
+	read(2)
+	read(0)
+	// Object 1 is evicted for object 3.
+	read(3)
+	if c.cache.used != 30 || c.cache.lru.Len() != 3 {
+		t.Fatalf("%s: Expected 30 bytes of 3 objects cached, got %d bytes of %d", instanceType, c.cache.used, c.cache.lru.Len())
+	}
+	if _, ok := c.cache.entries[pathJoin(bucket, "object-1")]; ok {
+		t.Fatalf("%s: Expected object-1 to be evicted", instanceType)
+	}
+	if _, err := os.Stat(c.cache.getPath(pathJoin(bucket, "object-1"))); !os.IsNotExist(err) {
+		t.Fatalf("%s: Expected the file of object-1 to be removed, got %v", instanceType, err)
+	}
+
+	hits := c.cache.hits
+	read(0)
+	read(2)
+	read(3)
+	if c.cache.hits != hits+3 {
+		t.Fatalf("%s: Expected %d hits, got %d", instanceType, hits+3, c.cache.hits)
+	}
+	misses := c.cache.misses
+	read(1)
+	if c.cache.misses != misses+1 {
+		t.Fatalf("%s: Expected %d misses, got %d", instanceType, misses+1, c.cache.misses)
+	}
+}
+
+// Tests that the objects written or deleted are never read from the
+// cache afterwards.
+func TestCacheObjectsInvalidation(t *testing.T) {
+	ExecObjectLayerTest(t, testCacheObjectsInvalidation)
+}
+
+func testCacheObjectsInvalidation(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	c, dir := newTestCacheObjects(obj, 1000, cacheAdmissionAlways, t)
+	defer removeAll(dir)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	put := func(object string, data []byte) {
+		if _, err := c.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	isCached := func(object string) bool {
+		_, ok := c.cache.entries[pathJoin(bucket, object)]
+		return ok
+	}
+
+	// Overwrite through the cache.
+	put("object", []byte("old data"))
+	readTestCacheObject(c, bucket, "object", 0, -1, []byte("old data"), t)
+	if !isCached("object") {
+		t.Fatalf("%s: Expected the object to be cached", instanceType)
+	}
+	put("object", []byte("new data!"))
+	if isCached("object") {
+		t.Fatalf("%s: Expected the overwritten object to be removed from the cache", instanceType)
+	}
+	readTestCacheObject(c, bucket, "object", 0, -1, []byte("new data!"), t)
+
+	// Overwrite bypassing the cache, like by another server.
+	if _, err := obj.PutObject(bucket, "object", 8, bytes.NewReader([]byte("backend!")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	readTestCacheObject(c, bucket, "object", 0, -1, []byte("backend!"), t)
+
+	// Copy over a cached object.
+	put("source", []byte("source data"))
+	if _, err := c.CopyObject(bucket, "source", bucket, "object", nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if isCached("object") {
+		t.Fatalf("%s: Expected the copied over object to be removed from the cache", instanceType)
+	}
+	readTestCacheObject(c, bucket, "object", 0, -1, []byte("source data"), t)
+
+	// Delete of a cached object.
+	if err := c.DeleteObject(bucket, "object"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if isCached("object") {
+		t.Fatalf("%s: Expected the deleted object to be removed from the cache", instanceType)
+	}
+	if err := c.GetObject(bucket, "object", 0, -1, &bytes.Buffer{}); err == nil {
+		t.Fatalf("%s: Expected the deleted object not to be found", instanceType)
+	}
+	if c.cache.used != 0 {
+		t.Fatalf("%s: Expected no bytes cached, got %d", instanceType, c.cache.used)
+	}
+}
@@ -0,0 +1,192 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Supported values of x-amz-checksum-algorithm.
+const (
+	checksumCRC32  = "CRC32"
+	checksumCRC32C = "CRC32C"
+	checksumSHA1   = "SHA1"
+	checksumSHA256 = "SHA256"
+)
+
+// Returns a new hash for the given checksum algorithm.
+var checksumHashers = map[string]func() hash.Hash{
+	checksumCRC32:  func() hash.Hash { return crc32.NewIEEE() },
+	checksumCRC32C: func() hash.Hash { return crc32.New(crc32.MakeTable(crc32.Castagnoli)) },
+	checksumSHA1:   sha1.New,
+	checksumSHA256: sha256.New,
+}
+
+// getChecksumAlgorithm - returns the upper cased x-amz-checksum-algorithm
+// of the request, empty if not set.
+func getChecksumAlgorithm(header http.Header) (string, APIErrorCode) {
+	algorithm := strings.ToUpper(header.Get("X-Amz-Checksum-Algorithm"))
+	if algorithm == "" {
+		return "", ErrNone
+	}
+	if _, ok := checksumHashers[algorithm]; !ok {
+		return "", ErrInvalidChecksumAlgorithm
+	}
+	return algorithm, ErrNone
+}
+
+// getTrailerChecksumAlgorithm - returns the upper cased algorithm of the
+// checksum declared by x-amz-trailer, such as x-amz-checksum-crc32.
+func getTrailerChecksumAlgorithm(header http.Header) (string, APIErrorCode) {
+	trailer := strings.ToLower(strings.TrimSpace(header.Get("X-Amz-Trailer")))
+	if !strings.HasPrefix(trailer, "x-amz-checksum-") {
+		return "", ErrInvalidTrailer
+	}
+	algorithm := strings.ToUpper(strings.TrimPrefix(trailer, "x-amz-checksum-"))
+	if _, ok := checksumHashers[algorithm]; !ok {
+		return "", ErrInvalidTrailer
+	}
+	return algorithm, ErrNone
+}
+
+// checksumMetadataKey - returns the metadata key under which the checksum
+// of an object is saved, it is also the response header name.
+func checksumMetadataKey(algorithm string) string {
+	return http.CanonicalHeaderKey("X-Amz-Checksum-" + strings.ToLower(algorithm))
+}
+
+// setChecksumMetadata - saves checksum into metadata, removing any checksum
+// saved earlier using another algorithm.
+func setChecksumMetadata(metadata map[string]string, algorithm, checksum string) {
+	for alg := range checksumHashers {
+		delete(metadata, checksumMetadataKey(alg))
+	}
+	metadata[checksumMetadataKey(algorithm)] = checksum
+}
+
+// setCopyObjectResponseChecksum - sets the checksum of the copied object.
+func setCopyObjectResponseChecksum(response *CopyObjectResponse, algorithm, checksum string) {
+	switch algorithm {
+	case checksumCRC32:
+		response.ChecksumCRC32 = checksum
+	case checksumCRC32C:
+		response.ChecksumCRC32C = checksum
+	case checksumSHA1:
+		response.ChecksumSHA1 = checksum
+	case checksumSHA256:
+		response.ChecksumSHA256 = checksum
+	}
+}
+
+// setListObjectChecksum - sets the checksum saved in the metadata of a
+// listed object, if any. Nothing is computed, the objects written
+// without a checksum are listed without one.
+func setListObjectChecksum(content *Object, metadata map[string]string) {
+	for algorithm := range checksumHashers {
+		checksum, ok := metadata[checksumMetadataKey(algorithm)]
+		if !ok {
+			continue
+		}
+		switch algorithm {
+		case checksumCRC32:
+			content.ChecksumCRC32 = checksum
+		case checksumCRC32C:
+			content.ChecksumCRC32C = checksum
+		case checksumSHA1:
+			content.ChecksumSHA1 = checksum
+		case checksumSHA256:
+			content.ChecksumSHA256 = checksum
+		}
+	}
+}
+
+// loadListObjectsMetadata - loads the metadata of the listed objects,
+// not carried by the listings of every backend, so that their stored
+// checksums can be listed. The objects removed since listed are left
+// as is.
+func loadListObjectsMetadata(objectAPI ObjectLayer, bucket string, objects []ObjectInfo) error {
+	for i, object := range objects {
+		if object.IsDir {
+			continue
+		}
+		objInfo, err := objectAPI.GetObjectInfo(bucket, object.Name)
+		if err != nil {
+			if isErrObjectNotFound(err) {
+				continue
+			}
+			return err
+		}
+		objects[i].UserDefined = objInfo.UserDefined
+	}
+	return nil
+}
+
+// getObjectChecksums - reads the stored content of an object and returns
+// its ETag computed with the configured algorithm, along with the base64
+// encoded checksum for the given algorithm if any. Caller should hold a
+// lock on the object.
+func getObjectChecksums(objectAPI ObjectLayer, bucket, object string, size int64, algorithm string) (etag, checksum string, err error) {
+	etagHash := newETagHash()
+	writers := []io.Writer{etagHash}
+	var checksumWriter hash.Hash
+	if algorithm != "" {
+		checksumWriter = checksumHashers[algorithm]()
+		writers = append(writers, checksumWriter)
+	}
+
+	if err = objectAPI.GetObject(bucket, object, 0, size, io.MultiWriter(writers...)); err != nil {
+		return "", "", err
+	}
+	if checksumWriter != nil {
+		checksum = base64.StdEncoding.EncodeToString(checksumWriter.Sum(nil))
+	}
+	return getETag(etagHash.Sum(nil)), checksum, nil
+}
+
+// copyObjectWithChecksum - copies the object computing the checksum of
+// the given algorithm over the copied data, the checksum is saved along
+// with the metadata of the destination once it is written. Caller
+// should hold the locks of both objects.
+func copyObjectWithChecksum(objectAPI ObjectLayer, srcBucket, srcObject, dstBucket, dstObject string, size int64,
+	metadata map[string]string, algorithm string) (objInfo ObjectInfo, checksum string, err error) {
+	checksumWriter := checksumHashers[algorithm]()
+	reader := newCopyReader(func(startOffset, length int64, writer io.Writer) error {
+		return objectAPI.GetObject(srcBucket, srcObject, startOffset, length, writer)
+	}, size)
+	objInfo, err = objectAPI.PutObject(dstBucket, dstObject, size, io.TeeReader(reader, checksumWriter), metadata, "")
+	// Explicitly close the reader.
+	reader.Close()
+	if err != nil {
+		return ObjectInfo{}, "", err
+	}
+
+	// Only the metadata of the destination is written again.
+	checksum = base64.StdEncoding.EncodeToString(checksumWriter.Sum(nil))
+	setChecksumMetadata(metadata, algorithm, checksum)
+	metadata["md5Sum"] = objInfo.MD5Sum
+	if objInfo, err = objectAPI.CopyObject(dstBucket, dstObject, dstBucket, dstObject, metadata); err != nil {
+		return ObjectInfo{}, "", err
+	}
+	return objInfo, checksum, nil
+}
@@ -0,0 +1,83 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"sync/atomic"
+	"testing"
+)
+
+// countingReadObjectLayer - object layer counting the bytes of the
+// objects read.
+type countingReadObjectLayer struct {
+	ObjectLayer
+	bytesRead int64
+}
+
+func (l *countingReadObjectLayer) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	return l.ObjectLayer.GetObject(bucket, object, startOffset, length, funcToWriter(func(p []byte) (int, error) {
+		atomic.AddInt64(&l.bytesRead, int64(len(p)))
+		return writer.Write(p)
+	}))
+}
+
+// Tests the checksum of a copy is computed over the copied data, the
+// source being read once.
+func TestCopyObjectWithChecksum(t *testing.T) {
+	ExecObjectLayerTest(t, testCopyObjectWithChecksum)
+}
+
+func testCopyObjectWithChecksum(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("checksum"), 1000)
+	if _, err := obj.PutObject(bucket, "source", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	counting := &countingReadObjectLayer{ObjectLayer: obj}
+	metadata := map[string]string{"X-Amz-Meta-App": "logs"}
+	objInfo, checksum, err := copyObjectWithChecksum(counting, bucket, "source", bucket, "copy", int64(len(data)), metadata, checksumSHA256)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if bytesRead := atomic.LoadInt64(&counting.bytesRead); bytesRead != int64(len(data)) {
+		t.Fatalf("%s: Expected the source to be read once, %d bytes read", instanceType, bytesRead)
+	}
+
+	sum := sha256.Sum256(data)
+	if expected := base64.StdEncoding.EncodeToString(sum[:]); checksum != expected {
+		t.Fatalf("%s: Expected checksum %s, got %s", instanceType, expected, checksum)
+	}
+	if objInfo.MD5Sum != getMD5Hash(data) {
+		t.Fatalf("%s: Expected ETag %s, got %s", instanceType, getMD5Hash(data), objInfo.MD5Sum)
+	}
+	objInfo, err = obj.GetObjectInfo(bucket, "copy")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.MD5Sum != getMD5Hash(data) || objInfo.UserDefined[checksumMetadataKey(checksumSHA256)] != checksum ||
+		objInfo.UserDefined["X-Amz-Meta-App"] != "logs" {
+		t.Fatalf("%s: Unexpected metadata of the copy %#v", instanceType, objInfo)
+	}
+}
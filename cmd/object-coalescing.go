@@ -0,0 +1,257 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// setGetCoalescing - sets the coalescing of the concurrent reads of the
+// same object range from MINIO_GET_COALESCING env.
+func setGetCoalescing() {
+	if coalescing := os.Getenv("MINIO_GET_COALESCING"); coalescing != "" {
+		switch strings.ToLower(coalescing) {
+		case "on":
+			globalIsGetCoalescing = true
+		case "off":
+			globalIsGetCoalescing = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_GET_COALESCING value %s.", coalescing)
+		}
+	}
+}
+
+// errAllGetFlightWritersDetached - all the writers of a flight fell
+// behind, the backend read is stopped.
+var errAllGetFlightWritersDetached = errors.New("All the writers of the read are detached")
+
+// Number of the chunks read which are queued for each writer of a
+// flight, a writer whose queue stays full for getFlightWriterTimeout is
+// detached from the flight. A writer whose client is gone is detached
+// right away.
+const (
+	getFlightQueueSize     = 8
+	getFlightWriterTimeout = time.Second
+)
+
+// getFlightWriter - writer of a read sharing the backend read, the
+// chunks read are queued for it and written by its own read. Writers
+// falling behind are detached, they read the rest of the range on their
+// own so that a slow client never slows the other ones down.
+type getFlightWriter struct {
+	writer  io.Writer
+	queue   chan []byte
+	written int64
+
+	// Closed by the read of the writer once a write to its client
+	// failed, nothing more is queued for it.
+	failed chan struct{}
+
+	// Set by the backend read, read once the flight is done.
+	detached bool
+}
+
+// newGetFlightWriter - returns the writer of a read joining a flight.
+func newGetFlightWriter(writer io.Writer) *getFlightWriter {
+	return &getFlightWriter{
+		writer: writer,
+		queue:  make(chan []byte, getFlightQueueSize),
+		failed: make(chan struct{}),
+	}
+}
+
+// getFlight - backend read of an object range shared by the concurrent
+// reads of the same range. Reads join the flight until its first bytes
+// are read, the later ones read on their own.
+type getFlight struct {
+	writers []*getFlightWriter
+	started bool
+	done    chan struct{}
+	err     error
+}
+
+// coalescingObjects - object layer sharing one backend read between the
+// concurrent reads of the same range of the same object. The reads are
+// keyed by the ETag and the modification time of the object as well,
+// so that a read never joins the read of a previous content of the
+// object.
+type coalescingObjects struct {
+	ObjectLayer
+
+	// Number of the backend reads and of the reads which joined
+	// another read, accessed atomically.
+	reads     uint64
+	coalesced uint64
+
+	mu      sync.Mutex
+	flights map[string]*getFlight
+}
+
+// newCoalescingObjects - returns the object layer coalescing the reads
+// of the backend object layer.
+func newCoalescingObjects(objAPI ObjectLayer) ObjectLayer {
+	return &coalescingObjects{
+		ObjectLayer: objAPI,
+		flights:     make(map[string]*getFlight),
+	}
+}
+
+// GetObject - joins the read in flight of the same range of the object
+// if it has not read any bytes yet, reads from the backend and queues
+// the chunks read to the reads joining otherwise. Every read writes the
+// chunks queued to its own writer.
+func (c *coalescingObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	objInfo, err := c.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	if length < 0 {
+		length = objInfo.Size - startOffset
+	}
+	key := fmt.Sprintf("%s:%d:%d:%s:%d", pathJoin(bucket, object), startOffset, length,
+		objInfo.MD5Sum, objInfo.ModTime.UnixNano())
+
+	flightWriter := newGetFlightWriter(writer)
+	c.mu.Lock()
+	flight, ok := c.flights[key]
+	if ok && !flight.started {
+		flight.writers = append(flight.writers, flightWriter)
+		c.mu.Unlock()
+		atomic.AddUint64(&c.coalesced, 1)
+	} else {
+		// A flight already reading is not replaced, this read is on
+		// its own.
+		flight = &getFlight{
+			writers: []*getFlightWriter{flightWriter},
+			done:    make(chan struct{}),
+		}
+		if !ok {
+			c.flights[key] = flight
+		}
+		c.mu.Unlock()
+		atomic.AddUint64(&c.reads, 1)
+		go c.readFlight(flight, key, !ok, bucket, object, startOffset, length)
+	}
+
+	for p := range flightWriter.queue {
+		if _, err = flightWriter.writer.Write(p); err != nil {
+			// The flight detaches the writer on its next chunk.
+			close(flightWriter.failed)
+			return err
+		}
+		flightWriter.written += int64(len(p))
+	}
+	<-flight.done
+	if !flightWriter.detached {
+		return flight.err
+	}
+
+	// The rest of the range is read on its own, the object can not
+	// change meanwhile as the handlers hold the lock of the object
+	// while it is read.
+	atomic.AddUint64(&c.reads, 1)
+	return c.ObjectLayer.GetObject(bucket, object, startOffset+flightWriter.written,
+		length-flightWriter.written, writer)
+}
+
+// readFlight - reads the range of the flight from the backend, the
+// writers left are done once it is read.
+func (c *coalescingObjects) readFlight(flight *getFlight, key string, shared bool, bucket, object string,
+	startOffset int64, length int64) {
+	err := c.ObjectLayer.GetObject(bucket, object, startOffset, length, funcToWriter(func(p []byte) (int, error) {
+		return c.writeFlight(flight, p)
+	}))
+
+	c.mu.Lock()
+	if shared {
+		delete(c.flights, key)
+	}
+	flight.started = true
+	flight.err = err
+	c.mu.Unlock()
+
+	for _, flightWriter := range flight.writers {
+		if !flightWriter.detached {
+			close(flightWriter.queue)
+		}
+	}
+	close(flight.done)
+}
+
+// queueFlightChunk - queues the chunk to the writer, returns false if
+// its client is gone or if its queue stays full for
+// getFlightWriterTimeout.
+func queueFlightChunk(flightWriter *getFlightWriter, chunk []byte) bool {
+	select {
+	case <-flightWriter.failed:
+		return false
+	default:
+	}
+	select {
+	case flightWriter.queue <- chunk:
+		return true
+	default:
+	}
+	timer := time.NewTimer(getFlightWriterTimeout)
+	defer timer.Stop()
+	select {
+	case flightWriter.queue <- chunk:
+		return true
+	case <-flightWriter.failed:
+		return false
+	case <-timer.C:
+		return false
+	}
+}
+
+// writeFlight - queues the bytes read to the writers of the flight, no
+// more writers join it from then on. Writers falling behind are
+// detached, the backend read is stopped once all the writers are.
+func (c *coalescingObjects) writeFlight(flight *getFlight, p []byte) (int, error) {
+	c.mu.Lock()
+	flight.started = true
+	c.mu.Unlock()
+
+	// The backend reuses its buffer, the chunk queued is shared by
+	// all the writers.
+	chunk := make([]byte, len(p))
+	copy(chunk, p)
+
+	attached := 0
+	for _, flightWriter := range flight.writers {
+		if flightWriter.detached {
+			continue
+		}
+		if queueFlightChunk(flightWriter, chunk) {
+			attached++
+			continue
+		}
+		flightWriter.detached = true
+		close(flightWriter.queue)
+	}
+	if attached == 0 {
+		return 0, errAllGetFlightWritersDetached
+	}
+	return len(p), nil
+}
@@ -0,0 +1,293 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingObjectLayer - object layer whose reads wait to be released,
+// the backend reads are counted.
+type blockingObjectLayer struct {
+	ObjectLayer
+	reads   uint64
+	release chan struct{}
+}
+
+func (b *blockingObjectLayer) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	atomic.AddUint64(&b.reads, 1)
+	<-b.release
+	return b.ObjectLayer.GetObject(bucket, object, startOffset, length, writer)
+}
+
+// failingWriter - writer failing all the writes.
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("client gone")
+}
+
+// chunkedObjectLayer - object layer writing the objects read in chunks
+// of chunkSize bytes.
+type chunkedObjectLayer struct {
+	ObjectLayer
+	chunkSize int
+}
+
+func (l chunkedObjectLayer) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	return l.ObjectLayer.GetObject(bucket, object, startOffset, length, funcToWriter(func(p []byte) (int, error) {
+		for i := 0; i < len(p); i += l.chunkSize {
+			end := i + l.chunkSize
+			if end > len(p) {
+				end = len(p)
+			}
+			if _, err := writer.Write(p[i:end]); err != nil {
+				return i, err
+			}
+		}
+		return len(p), nil
+	}))
+}
+
+// slowWriter - writer whose writes wait to be released.
+type slowWriter struct {
+	bytes.Buffer
+	release chan struct{}
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+	return w.Buffer.Write(p)
+}
+
+// Tests the concurrent identical reads share a single backend read.
+func TestCoalescingObjects(t *testing.T) {
+	ExecObjectLayerTest(t, testCoalescingObjects)
+}
+
+func testCoalescingObjects(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	backend := &blockingObjectLayer{ObjectLayer: obj, release: make(chan struct{})}
+	c := newCoalescingObjects(backend).(*coalescingObjects)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("coalesced data"), 1000)
+	if _, err := c.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	// Concurrent identical reads, one of the clients is gone.
+	readers := 20
+	buffers := make([]bytes.Buffer, readers)
+	errs := make([]error, readers)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var writer io.Writer = &buffers[i]
+			if i == readers-1 {
+				writer = failingWriter{}
+			}
+			errs[i] = c.GetObject(bucket, "object", 0, -1, writer)
+		}(i)
+	}
+	// All the reads joined the first one before it reads.
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint64(&c.coalesced) != uint64(readers-1) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: Expected %d reads to be coalesced, got %d", instanceType, readers-1, atomic.LoadUint64(&c.coalesced))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+	wg.Wait()
+
+	if reads := atomic.LoadUint64(&backend.reads); reads != 1 {
+		t.Fatalf("%s: Expected a single backend read, got %d", instanceType, reads)
+	}
+	for i := 0; i < readers-1; i++ {
+		if errs[i] != nil {
+			t.Fatalf("%s: Reader %d: %s", instanceType, i+1, errs[i])
+		}
+		if !bytes.Equal(buffers[i].Bytes(), data) {
+			t.Fatalf("%s: Reader %d: Data does not match", instanceType, i+1)
+		}
+	}
+	if errs[readers-1] == nil {
+		t.Fatalf("%s: Expected the error of the gone client", instanceType)
+	}
+	if len(c.flights) != 0 {
+		t.Fatalf("%s: Expected no flights left, got %d", instanceType, len(c.flights))
+	}
+
+	// Different ranges are read on their own.
+	var buffer bytes.Buffer
+	if err := c.GetObject(bucket, "object", 10, 20, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data[10:30]) {
+		t.Fatalf("%s: Expected %q, got %q", instanceType, data[10:30], buffer.Bytes())
+	}
+
+	// Changed objects are read again with their new content.
+	data = []byte("new content")
+	if _, err := c.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	buffer.Reset()
+	if err := c.GetObject(bucket, "object", 0, -1, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected %q, got %q", instanceType, data, buffer.Bytes())
+	}
+	if reads := atomic.LoadUint64(&backend.reads); reads != 3 {
+		t.Fatalf("%s: Expected 3 backend reads, got %d", instanceType, reads)
+	}
+}
+
+// Tests a slow client does not slow down the reads sharing its backend
+// read, it is detached and reads the rest of the object on its own.
+func TestCoalescingObjectsSlowWriter(t *testing.T) {
+	ExecObjectLayerTest(t, testCoalescingObjectsSlowWriter)
+}
+
+func testCoalescingObjectsSlowWriter(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	backend := &blockingObjectLayer{ObjectLayer: chunkedObjectLayer{obj, 1024}, release: make(chan struct{})}
+	c := newCoalescingObjects(backend).(*coalescingObjects)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// More chunks than queued for a writer.
+	data := bytes.Repeat([]byte("a"), 1024*(getFlightQueueSize*2))
+	if _, err := c.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	slow := &slowWriter{release: make(chan struct{})}
+	slowErrCh := make(chan error, 1)
+	go func() {
+		slowErrCh <- c.GetObject(bucket, "object", 0, -1, slow)
+	}()
+
+	readers := 4
+	buffers := make([]bytes.Buffer, readers)
+	errs := make([]error, readers)
+	var wg sync.WaitGroup
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = c.GetObject(bucket, "object", 0, -1, &buffers[i])
+		}(i)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint64(&c.coalesced) != uint64(readers) {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: Expected %d reads to be coalesced, got %d", instanceType, readers, atomic.LoadUint64(&c.coalesced))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(backend.release)
+
+	// The other reads are done while the slow client is blocked.
+	wg.Wait()
+	for i := 0; i < readers; i++ {
+		if errs[i] != nil {
+			t.Fatalf("%s: Reader %d: %s", instanceType, i+1, errs[i])
+		}
+		if !bytes.Equal(buffers[i].Bytes(), data) {
+			t.Fatalf("%s: Reader %d: Data does not match", instanceType, i+1)
+		}
+	}
+
+	close(slow.release)
+	if err := <-slowErrCh; err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(slow.Bytes(), data) {
+		t.Fatalf("%s: Slow reader: Data does not match", instanceType)
+	}
+	// The slow client read the rest on its own.
+	if reads := atomic.LoadUint64(&backend.reads); reads != 2 {
+		t.Fatalf("%s: Expected 2 backend reads, got %d", instanceType, reads)
+	}
+}
+
+// Tests a client gone does not hold the reads sharing its backend read
+// until its queue times out, it is detached as soon as its write fails.
+func TestCoalescingObjectsFailedWriter(t *testing.T) {
+	ExecObjectLayerTest(t, testCoalescingObjectsFailedWriter)
+}
+
+func testCoalescingObjectsFailedWriter(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	backend := &blockingObjectLayer{ObjectLayer: chunkedObjectLayer{obj, 1024}, release: make(chan struct{})}
+	c := newCoalescingObjects(backend).(*coalescingObjects)
+
+	bucket := getRandomBucketName()
+	if err := c.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	// More chunks than queued for a writer.
+	data := bytes.Repeat([]byte("a"), 1024*(getFlightQueueSize*4))
+	if _, err := c.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	failedErrCh := make(chan error, 1)
+	go func() {
+		failedErrCh <- c.GetObject(bucket, "object", 0, -1, failingWriter{})
+	}()
+	var buffer bytes.Buffer
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- c.GetObject(bucket, "object", 0, -1, &buffer)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for atomic.LoadUint64(&c.coalesced) != 1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s: Expected the reads to be coalesced, got %d", instanceType, atomic.LoadUint64(&c.coalesced))
+		}
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	close(backend.release)
+
+	if err := <-failedErrCh; err == nil {
+		t.Fatalf("%s: Expected the error of the gone client", instanceType)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if elapsed := time.Since(start); elapsed >= getFlightWriterTimeout/2 {
+		t.Fatalf("%s: Expected the gone client to be detached right away, the read took %s", instanceType, elapsed)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Data does not match", instanceType)
+	}
+}
@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+const (
+	// Default minimum number of parts of the fragmented objects compacted.
+	globalDefaultCompactionMinParts = 32
+
+	// Default interval between two compactions of all the buckets.
+	globalDefaultCompactionInterval = time.Hour
+)
+
+// setCompaction - sets the compaction of the fragmented objects from
+// MINIO_COMPACTION, MINIO_COMPACTION_MIN_PARTS, MINIO_COMPACTION_INTERVAL
+// and MINIO_COMPACTION_BANDWIDTH env.
+func setCompaction() {
+	if compaction := os.Getenv("MINIO_COMPACTION"); compaction != "" {
+		switch strings.ToLower(compaction) {
+		case "on":
+			globalIsCompaction = true
+		case "off":
+			globalIsCompaction = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_COMPACTION value %s.", compaction)
+		}
+	}
+	if minParts := os.Getenv("MINIO_COMPACTION_MIN_PARTS"); minParts != "" {
+		n, err := strconv.Atoi(minParts)
+		if err != nil || n < 2 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COMPACTION_MIN_PARTS value %s.", minParts)
+		}
+		globalCompactionMinParts = n
+	}
+	if interval := os.Getenv("MINIO_COMPACTION_INTERVAL"); interval != "" {
+		d, err := time.ParseDuration(interval)
+		if err != nil || d <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COMPACTION_INTERVAL value %s.", interval)
+		}
+		globalCompactionInterval = d
+	}
+	if bandwidth := os.Getenv("MINIO_COMPACTION_BANDWIDTH"); bandwidth != "" {
+		rate, err := humanize.ParseBytes(bandwidth)
+		fatalIf(err, "Invalid MINIO_COMPACTION_BANDWIDTH value %s.", bandwidth)
+		globalCompactionBandwidth.SetRate(rate)
+	}
+}
+
+// compactionReader - throttles the reads of the objects compacted by the
+// limiter, so that the compactions do not starve the live traffic.
+type compactionReader struct {
+	reader  io.Reader
+	limiter *healBandwidthLimiter
+}
+
+func (r compactionReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		r.limiter.Wait(n)
+	}
+	return n, err
+}
+
+// getXLObjects - returns the XL backend under the layers wrapping it,
+// nil on FS.
+func getXLObjects(objLayer ObjectLayer) *xlObjects {
+	if m, ok := objLayer.(*metadataIndexObjects); ok {
+		objLayer = m.ObjectLayer
+	}
+	if c, ok := objLayer.(*listCacheObjects); ok {
+		objLayer = c.ObjectLayer
+	}
+	if c, ok := objLayer.(*coalescingObjects); ok {
+		objLayer = c.ObjectLayer
+	}
+	if c, ok := objLayer.(*cacheObjects); ok {
+		objLayer = c.ObjectLayer
+	}
+	xl, _ := objLayer.(*xlObjects)
+	return xl
+}
+
+// compactObjects - compacts the fragmented objects of all the buckets,
+// returns the number of objects compacted. Does nothing on FS where the
+// objects are always stored contiguously.
+func compactObjects(objLayer ObjectLayer) int {
+	xl := getXLObjects(objLayer)
+	if xl == nil {
+		return 0
+	}
+	buckets, err := xl.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return 0
+	}
+	compacted := 0
+	for _, bucket := range buckets {
+		marker := ""
+		for {
+			result, err := xl.ListObjects(bucket.Name, "", marker, "", maxObjectList)
+			if err != nil {
+				errorIf(err, "Unable to list the objects of the bucket %s.", bucket.Name)
+				break
+			}
+			for _, objInfo := range result.Objects {
+				// Stop compacting once the server stops.
+				select {
+				case <-globalServiceDoneCh:
+					return compacted
+				default:
+				}
+				ok, err := xl.compactObject(bucket.Name, objInfo.Name, globalCompactionBandwidth)
+				if err != nil && !isErrObjectNotFound(err) {
+					errorIf(err, "Unable to compact %s/%s.", bucket.Name, objInfo.Name)
+				}
+				if ok {
+					compacted++
+				}
+			}
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+		}
+	}
+	return compacted
+}
+
+// startCompactor - compacts the fragmented objects periodically until
+// the server stops. Does nothing unless the compaction is enabled.
+func startCompactor(objAPI func() ObjectLayer) {
+	if !globalIsCompaction {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(globalCompactionInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if objLayer := objAPI(); objLayer != nil {
+					compactObjects(objLayer)
+				}
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Tests the fragmented objects are compacted into fewer parts, and read
+// back unchanged along with their ETag, size and modification time.
+func TestCompactObjects(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := getXLObjects(obj)
+
+	defer func(minParts int) { globalCompactionMinParts = minParts }(globalCompactionMinParts)
+	globalCompactionMinParts = 3
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+
+	// Object uploaded in three parts, fitting in one part.
+	uploadID, err := obj.NewMultipartUpload(bucket, "fragmented", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data []byte
+	var parts []completePart
+	for i, size := range []int{5 * 1024 * 1024, 5 * 1024 * 1024, 1024} {
+		partData := bytes.Repeat([]byte{byte('a' + i)}, size)
+		partInfo, err := obj.PutObjectPart(bucket, "fragmented", uploadID, i+1, int64(size), bytes.NewReader(partData), "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = append(data, partData...)
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: partInfo.ETag})
+	}
+	objInfo, err := obj.CompleteMultipartUpload(bucket, "fragmented", uploadID, parts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, "contiguous", 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if compacted := compactObjects(obj); compacted != 1 {
+		t.Fatalf("Expected 1 object compacted, got %d", compacted)
+	}
+	// Compacted objects are not fragmented anymore.
+	if compacted := compactObjects(obj); compacted != 0 {
+		t.Fatalf("Expected no object compacted, got %d", compacted)
+	}
+
+	metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, "fragmented")
+	for i := range metaArr {
+		if errs[i] != nil {
+			t.Fatal(errs[i])
+		}
+		if len(metaArr[i].Parts) != 1 {
+			t.Fatalf("Disk %d: Expected 1 part, got %d", i, len(metaArr[i].Parts))
+		}
+	}
+
+	newObjInfo, err := obj.GetObjectInfo(bucket, "fragmented")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if newObjInfo.MD5Sum != objInfo.MD5Sum || newObjInfo.Size != objInfo.Size || !newObjInfo.ModTime.Equal(objInfo.ModTime) {
+		t.Fatalf("Expected the object %v, got %v", objInfo, newObjInfo)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "fragmented", 0, newObjInfo.Size, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("Compacted object does not read back the same data")
+	}
+}
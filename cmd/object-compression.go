@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Content types of the objects compressed on the wire once enabled,
+// already compressed formats like images and archives are left out.
+var defaultCompressContentTypes = []string{
+	"text/*",
+	"application/javascript",
+	"application/json",
+	"application/xml",
+	"image/svg+xml",
+}
+
+// setCompressContentTypes - sets the content types of the objects
+// compressed on the wire from MINIO_COMPRESS_CONTENT_TYPES env, a comma
+// separated list of content types where "type/*" matches all the
+// subtypes. Compression is off by default, "on" enables it for the
+// default content types.
+func setCompressContentTypes() {
+	contentTypes := os.Getenv("MINIO_COMPRESS_CONTENT_TYPES")
+	if contentTypes == "" {
+		return
+	}
+	if strings.EqualFold(contentTypes, "off") {
+		globalCompressContentTypes = nil
+		return
+	}
+	if strings.EqualFold(contentTypes, "on") {
+		globalCompressContentTypes = defaultCompressContentTypes
+		return
+	}
+	globalCompressContentTypes = nil
+	for _, contentType := range strings.Split(contentTypes, ",") {
+		contentType = strings.ToLower(strings.TrimSpace(contentType))
+		if !strings.Contains(contentType, "/") {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COMPRESS_CONTENT_TYPES value %s.", contentTypes)
+		}
+		globalCompressContentTypes = append(globalCompressContentTypes, contentType)
+	}
+}
+
+// isCompressibleContentType - returns true if the content type matches
+// the content types compressed on the wire.
+func isCompressibleContentType(contentType string) bool {
+	if i := strings.Index(contentType, ";"); i != -1 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	for _, pattern := range globalCompressContentTypes {
+		if pattern == contentType {
+			return true
+		}
+		if strings.HasSuffix(pattern, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(pattern, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// isGzipAccepted - returns true if the client accepts gzip content
+// encoding, i.e gzip or "*" is listed in Accept-Encoding without a
+// zero quality.
+func isGzipAccepted(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(encoding, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		accepted := true
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				accepted = err == nil && q > 0
+			}
+		}
+		return accepted
+	}
+	return false
+}
+
+// isObjectCompressionRequired - returns true if the object data sent
+// for the request is to be gzip compressed on the wire. The stored
+// object and its ETag are unchanged, range requests are sent as is
+// since Content-Range refers to the stored bytes.
+func isObjectCompressionRequired(r *http.Request, objInfo ObjectInfo, hrange *httpRange) bool {
+	if hrange != nil || objInfo.Size == 0 {
+		return false
+	}
+	// Objects stored or requested with a content encoding are sent as is.
+	if objInfo.ContentEncoding != "" || objInfo.UserDefined["Content-Encoding"] != "" ||
+		r.URL.Query().Get("response-content-encoding") != "" {
+		return false
+	}
+	return isCompressibleContentType(objInfo.ContentType) && isGzipAccepted(r)
+}
@@ -0,0 +1,198 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// Tests compression is off by default and enabled by
+// MINIO_COMPRESS_CONTENT_TYPES.
+func TestSetCompressContentTypes(t *testing.T) {
+	defer func(contentTypes []string) { globalCompressContentTypes = contentTypes }(globalCompressContentTypes)
+	defer os.Unsetenv("MINIO_COMPRESS_CONTENT_TYPES")
+
+	testCases := []struct {
+		env      string
+		expected []string
+	}{
+		{"", nil},
+		{"on", defaultCompressContentTypes},
+		{"Text/*, application/json", []string{"text/*", "application/json"}},
+		{"off", nil},
+	}
+	for i, testCase := range testCases {
+		globalCompressContentTypes = nil
+		os.Setenv("MINIO_COMPRESS_CONTENT_TYPES", testCase.env)
+		setCompressContentTypes()
+		if !reflect.DeepEqual(globalCompressContentTypes, testCase.expected) {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expected, globalCompressContentTypes)
+		}
+	}
+}
+
+// Tests the content types compressed on the wire.
+func TestIsCompressibleContentType(t *testing.T) {
+	defer func(contentTypes []string) { globalCompressContentTypes = contentTypes }(globalCompressContentTypes)
+	globalCompressContentTypes = defaultCompressContentTypes
+
+	testCases := []struct {
+		contentType string
+		expected    bool
+	}{
+		{"text/plain", true},
+		{"text/html; charset=utf-8", true},
+		{"Application/JSON", true},
+		{"image/svg+xml", true},
+		{"image/jpeg", false},
+		{"application/zip", false},
+		{"application/octet-stream", false},
+		{"textual/plain", false},
+		{"", false},
+	}
+	for i, testCase := range testCases {
+		if actual := isCompressibleContentType(testCase.contentType); actual != testCase.expected {
+			t.Errorf("Test %d: Expected %v for %q, got %v", i+1, testCase.expected, testCase.contentType, actual)
+		}
+	}
+}
+
+// Tests the gzip content encoding accepted by the clients.
+func TestIsGzipAccepted(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		expected       bool
+	}{
+		{"", false},
+		{"gzip", true},
+		{"deflate, gzip;q=0.5", true},
+		{"GZIP", true},
+		{"*", true},
+		{"gzip;q=0", false},
+		{"identity", false},
+		{"x-gzip", false},
+	}
+	for i, testCase := range testCases {
+		r, err := http.NewRequest("GET", "http://localhost/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+		if actual := isGzipAccepted(r); actual != testCase.expected {
+			t.Errorf("Test %d: Expected %v for %q, got %v", i+1, testCase.expected, testCase.acceptEncoding, actual)
+		}
+	}
+}
+
+// Tests text objects are compressed on the wire once enabled and jpeg
+// objects are not, the stored objects and their ETags are unchanged.
+func TestGetObjectCompression(t *testing.T) {
+	ExecObjectLayerAPITest(t, testGetObjectCompression, []string{"GetObject"})
+}
+
+func testGetObjectCompression(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	data := bytes.Repeat([]byte("compress me on the wire, "), 100)
+	putObject := func(object, contentType string) ObjectInfo {
+		objInfo, err := obj.PutObject(bucketName, object, int64(len(data)), bytes.NewReader(data),
+			map[string]string{"content-type": contentType}, "")
+		if err != nil {
+			t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+		}
+		return objInfo
+	}
+	getObject := func(object string, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest("GET", getGetObjectURL("", bucketName, object), 0, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK && rec.Code != http.StatusPartialContent {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		return rec
+	}
+	acceptGzip := http.Header{"Accept-Encoding": {"gzip"}}
+
+	// Nothing is compressed by default.
+	textInfo := putObject("object.txt", "text/plain")
+	rec := getObject("object.txt", acceptGzip)
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "" || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("%s: Expected the object sent as is, got content encoding %q", instanceType, encoding)
+	}
+
+	defer func(contentTypes []string) { globalCompressContentTypes = contentTypes }(globalCompressContentTypes)
+	globalCompressContentTypes = defaultCompressContentTypes
+
+	// Text objects are compressed.
+	rec = getObject("object.txt", acceptGzip)
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("%s: Expected gzip content encoding, got %q", instanceType, encoding)
+	}
+	if rec.Body.Len() >= len(data) {
+		t.Fatalf("%s: Expected compressed body, got %d bytes", instanceType, rec.Body.Len())
+	}
+	if etag := rec.Header().Get("ETag"); etag != "\""+textInfo.MD5Sum+"\"" {
+		t.Fatalf("%s: Expected ETag of the stored object %s, got %s", instanceType, textInfo.MD5Sum, etag)
+	}
+	gzipReader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("%s: Unexpected gzip body %s", instanceType, err)
+	}
+	body, err := ioutil.ReadAll(gzipReader)
+	if err != nil {
+		t.Fatalf("%s: Unexpected gzip body %s", instanceType, err)
+	}
+	if !bytes.Equal(body, data) {
+		t.Fatalf("%s: Expected decompressed body to match the stored object", instanceType)
+	}
+
+	// Text objects are sent as is to clients not accepting gzip, and for range requests.
+	for _, header := range []http.Header{nil, {"Accept-Encoding": {"gzip"}, "Range": {"bytes=0-9"}}} {
+		rec = getObject("object.txt", header)
+		if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+			t.Fatalf("%s: Expected no content encoding, got %q", instanceType, encoding)
+		}
+	}
+
+	// Jpeg objects are not compressed.
+	jpegInfo := putObject("object.jpg", "image/jpeg")
+	rec = getObject("object.jpg", acceptGzip)
+	if encoding := rec.Header().Get("Content-Encoding"); encoding != "" {
+		t.Fatalf("%s: Expected no content encoding, got %q", instanceType, encoding)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("%s: Expected body to match the stored object", instanceType)
+	}
+	if etag := rec.Header().Get("ETag"); etag != "\""+jpegInfo.MD5Sum+"\"" {
+		t.Fatalf("%s: Expected ETag of the stored object %s, got %s", instanceType, jpegInfo.MD5Sum, etag)
+	}
+}
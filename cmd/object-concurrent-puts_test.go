@@ -0,0 +1,106 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// Wrapper for calling TestConcurrentPutObjectHandler tests for both XL multiple disks and single node setup.
+func TestConcurrentPutObjectHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testConcurrentPutObjectHandler, []string{"PutObject"})
+}
+
+// testConcurrentPutObjectHandler - hammers a single object with
+// concurrent PUTs, which are serialized by the lock of the object taken
+// by the handler. The final object must be one of the writes with its
+// own data and metadata on every disk.
+func testConcurrentPutObjectHandler(obj ObjectLayer, instanceType, bucket string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	object := "hammered-object"
+
+	const writers = 20
+	getData := func(writer int) []byte {
+		return bytes.Repeat([]byte{byte('a' + writer)}, 1024+writer*100)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(writer int) {
+			defer wg.Done()
+			data := getData(writer)
+			req, err := newTestRequest("PUT", getPutObjectURL("", bucket, object), int64(len(data)), bytes.NewReader(data))
+			if err != nil {
+				t.Errorf("%s: Writer %d: Failed to create HTTP request: <ERROR> %v", instanceType, writer, err)
+				return
+			}
+			req.Header.Set("X-Amz-Meta-Writer", strconv.Itoa(writer))
+			if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+				t.Errorf("%s: Writer %d: Failed to sign HTTP request: <ERROR> %v", instanceType, writer, err)
+				return
+			}
+			rec := httptest.NewRecorder()
+			apiRouter.ServeHTTP(rec, req)
+			if rec.Code != http.StatusOK {
+				t.Errorf("%s: Writer %d: Expected status %d, got %d", instanceType, writer, http.StatusOK, rec.Code)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	objInfo, err := obj.GetObjectInfo(bucket, object)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	writer, err := strconv.Atoi(objInfo.UserDefined["X-Amz-Meta-Writer"])
+	if err != nil || writer < 0 || writer >= writers {
+		t.Fatalf("%s: Unexpected writer metadata %#v", instanceType, objInfo.UserDefined)
+	}
+	data := getData(writer)
+	sum := md5.Sum(data)
+	if objInfo.Size != int64(len(data)) || objInfo.MD5Sum != hex.EncodeToString(sum[:]) {
+		t.Fatalf("%s: Object info %#v does not match the data of writer %d", instanceType, objInfo, writer)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, objInfo.Size, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Object data does not match the data of writer %d", instanceType, writer)
+	}
+
+	// Every disk holds the same version of the object.
+	if xl, ok := obj.(*xlObjects); ok {
+		metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+		for i, xlMeta := range metaArr {
+			if errs[i] != nil {
+				t.Fatalf("%s: Disk %d: %s", instanceType, i, errs[i])
+			}
+			if xlMeta.Meta["X-Amz-Meta-Writer"] != strconv.Itoa(writer) || xlMeta.Meta["md5Sum"] != objInfo.MD5Sum {
+				t.Fatalf("%s: Disk %d: Unexpected metadata %#v", instanceType, i, xlMeta.Meta)
+			}
+		}
+	}
+}
@@ -0,0 +1,209 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// setCopyConcurrency - sets the number of chunks of the server-side
+// copies read concurrently, their size and the memory they may hold
+// from MINIO_COPY_CONCURRENCY, MINIO_COPY_CHUNK_SIZE and
+// MINIO_COPY_MAX_MEMORY env.
+func setCopyConcurrency() {
+	if concurrency := os.Getenv("MINIO_COPY_CONCURRENCY"); concurrency != "" {
+		n, err := strconv.Atoi(concurrency)
+		if err != nil || n < 1 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COPY_CONCURRENCY value %s.", concurrency)
+		}
+		globalCopyConcurrency = n
+	}
+	if chunkSize := os.Getenv("MINIO_COPY_CHUNK_SIZE"); chunkSize != "" {
+		size, err := humanize.ParseBytes(chunkSize)
+		if err != nil || size == 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COPY_CHUNK_SIZE value %s.", chunkSize)
+		}
+		globalCopyChunkSize = int64(size)
+	}
+	if maxMemory := os.Getenv("MINIO_COPY_MAX_MEMORY"); maxMemory != "" {
+		size, err := humanize.ParseBytes(maxMemory)
+		if err != nil || size == 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_COPY_MAX_MEMORY value %s.", maxMemory)
+		}
+		globalCopyMaxMemory = int64(size)
+	}
+}
+
+// Slots of the chunks held in memory by all the server-side copies,
+// sized from globalCopyMaxMemory for the chunk size they were made for.
+var copyBufferSlots = struct {
+	mutex     sync.Mutex
+	slots     chan struct{}
+	chunkSize int64
+	maxMemory int64
+}{}
+
+// getCopyBufferSlots - returns the slots shared by the copies of
+// chunkSize bytes chunks, at least one chunk is always allowed.
+func getCopyBufferSlots(chunkSize int64) chan struct{} {
+	copyBufferSlots.mutex.Lock()
+	defer copyBufferSlots.mutex.Unlock()
+
+	if copyBufferSlots.slots == nil || copyBufferSlots.chunkSize != chunkSize ||
+		copyBufferSlots.maxMemory != globalCopyMaxMemory {
+		n := globalCopyMaxMemory / chunkSize
+		if n < 1 {
+			n = 1
+		}
+		copyBufferSlots.slots = make(chan struct{}, n)
+		copyBufferSlots.chunkSize = chunkSize
+		copyBufferSlots.maxMemory = globalCopyMaxMemory
+	}
+	return copyBufferSlots.slots
+}
+
+// copyReadFunc - reads length bytes of the source object of a copy
+// from startOffset into the writer.
+type copyReadFunc func(startOffset, length int64, writer io.Writer) error
+
+// copyChunk - content of a chunk of the source object, read ahead of
+// being written to the destination.
+type copyChunk struct {
+	buffer []byte
+	err    error
+}
+
+// copyChunkWriter - writes the content of a chunk into its buffer.
+type copyChunkWriter struct {
+	buffer []byte
+	n      int
+}
+
+func (w *copyChunkWriter) Write(p []byte) (int, error) {
+	if len(p) > len(w.buffer)-w.n {
+		return 0, io.ErrShortWrite
+	}
+	w.n += copy(w.buffer[w.n:], p)
+	return len(p), nil
+}
+
+// newCopyReader - returns a reader of the size bytes of the source
+// object of a copy. Objects larger than a chunk are read in chunks,
+// up to globalCopyConcurrency of them concurrently, and the chunks
+// are returned in order. A failure to read any chunk fails the reader,
+// so the destination written from it is never committed.
+func newCopyReader(readObject copyReadFunc, size int64) io.ReadCloser {
+	pipeReader, pipeWriter := io.Pipe()
+	chunkSize, concurrency := globalCopyChunkSize, globalCopyConcurrency
+	go func() {
+		if concurrency <= 1 || size <= chunkSize {
+			pipeWriter.CloseWithError(readObject(0, size, pipeWriter))
+			return
+		}
+		pipeWriter.CloseWithError(copyChunks(readObject, size, chunkSize, concurrency, pipeWriter))
+	}()
+	return pipeReader
+}
+
+// copyChunks - reads the chunks of the source object concurrently and
+// writes them in order. At most concurrency chunks of this copy are
+// read or held in memory at a time, and no more than the slots of all
+// the copies allow. The chunk buffers are pooled across the copies.
+// The chunks being read are waited for on failure.
+func copyChunks(readObject copyReadFunc, size, chunkSize int64, concurrency int, writer io.Writer) error {
+	chunks := make([]chan copyChunk, (size+chunkSize-1)/chunkSize)
+	for i := range chunks {
+		chunks[i] = make(chan copyChunk, 1)
+	}
+
+	// A slot of this copy and a slot shared by all the copies are
+	// taken before reading a chunk, both are released once the chunk
+	// buffer is back into the pool.
+	slots := make(chan struct{}, concurrency)
+	sharedSlots := getCopyBufferSlots(chunkSize)
+	pool := getBufferPool(int(chunkSize), 0)
+	releaseChunk := func(chunk copyChunk) {
+		pool.Put(chunk.buffer)
+		<-sharedSlots
+		<-slots
+	}
+
+	doneCh := make(chan struct{})
+	var wg sync.WaitGroup
+	written := 0
+	defer func() {
+		close(doneCh)
+		wg.Wait()
+		// Release the chunks read but not written.
+		for _, chunkCh := range chunks[written:] {
+			select {
+			case chunk := <-chunkCh:
+				releaseChunk(chunk)
+			default:
+			}
+		}
+	}()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range chunks {
+			select {
+			case slots <- struct{}{}:
+			case <-doneCh:
+				return
+			}
+			select {
+			case sharedSlots <- struct{}{}:
+			case <-doneCh:
+				<-slots
+				return
+			}
+			offset := int64(i) * chunkSize
+			length := chunkSize
+			if offset+length > size {
+				length = size - offset
+			}
+			wg.Add(1)
+			go func(chunkCh chan<- copyChunk, offset, length int64) {
+				defer wg.Done()
+				w := &copyChunkWriter{buffer: pool.Get()[:length]}
+				err := readObject(offset, length, w)
+				chunkCh <- copyChunk{w.buffer[:w.n], err}
+			}(chunks[i], offset, length)
+		}
+	}()
+
+	for _, chunkCh := range chunks {
+		chunk := <-chunkCh
+		written++
+		err := chunk.err
+		if err == nil {
+			_, err = writer.Write(chunk.buffer)
+		}
+		releaseChunk(chunk)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
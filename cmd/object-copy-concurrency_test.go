@@ -0,0 +1,233 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Tests large objects are copied in chunks read concurrently, the
+// copy is identical to the source and a failure to read a chunk leaves
+// no destination.
+func TestCopyObjectConcurrent(t *testing.T) {
+	ExecObjectLayerTest(t, testCopyObjectConcurrent)
+}
+
+func testCopyObjectConcurrent(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func(concurrency int, chunkSize int64) {
+		globalCopyConcurrency = concurrency
+		globalCopyChunkSize = chunkSize
+	}(globalCopyConcurrency, globalCopyChunkSize)
+	chunkSize := int64(64 * humanize.KiByte)
+	globalCopyConcurrency = 3
+	globalCopyChunkSize = chunkSize
+
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+	data := make([]byte, 17*chunkSize+123)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := obj.PutObject(bucket, "source", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	metadata := map[string]string{"content-type": "application/octet-stream"}
+	objInfo, err := obj.CopyObject(bucket, "source", bucket, "copy", metadata)
+	if err != nil {
+		t.Fatalf("%s: Failed to copy object: <ERROR> %v", instanceType, err)
+	}
+	md5Sum := md5.Sum(data)
+	if objInfo.Size != int64(len(data)) || objInfo.MD5Sum != hex.EncodeToString(md5Sum[:]) {
+		t.Fatalf("%s: Unexpected size %d and ETag %s of the copy", instanceType, objInfo.Size, objInfo.MD5Sum)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "copy", 0, objInfo.Size, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Contents of the copy differ from the source", instanceType)
+	}
+
+	// Fail the read of a chunk in the middle of the copy.
+	errChunk := errors.New("chunk read failure")
+	var reading, maxReading int32
+	reader := newCopyReader(func(startOffset, length int64, writer io.Writer) error {
+		n := atomic.AddInt32(&reading, 1)
+		defer atomic.AddInt32(&reading, -1)
+		for {
+			max := atomic.LoadInt32(&maxReading)
+			if n <= max || atomic.CompareAndSwapInt32(&maxReading, max, n) {
+				break
+			}
+		}
+		if startOffset == 9*chunkSize {
+			return errChunk
+		}
+		return obj.GetObject(bucket, "source", startOffset, length, writer)
+	}, int64(len(data)))
+	_, err = obj.PutObject(bucket, "partial", int64(len(data)), reader, nil, "")
+	reader.Close()
+	if err == nil {
+		t.Fatalf("%s: Expected the copy to fail", instanceType)
+	}
+	if _, err = obj.GetObjectInfo(bucket, "partial"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected no partial destination, got %v", instanceType, err)
+	}
+	if max := atomic.LoadInt32(&maxReading); max > int32(globalCopyConcurrency) {
+		t.Fatalf("%s: Expected at most %d chunks read concurrently, got %d", instanceType, globalCopyConcurrency, max)
+	}
+}
+
+// Tests the chunks held in memory by all the copies are limited by
+// globalCopyMaxMemory, whatever the concurrency of each copy.
+func TestCopyChunksMaxMemory(t *testing.T) {
+	defer func(concurrency int, chunkSize, maxMemory int64) {
+		globalCopyConcurrency = concurrency
+		globalCopyChunkSize = chunkSize
+		globalCopyMaxMemory = maxMemory
+	}(globalCopyConcurrency, globalCopyChunkSize, globalCopyMaxMemory)
+	chunkSize := int64(humanize.KiByte)
+	globalCopyConcurrency = 4
+	globalCopyChunkSize = chunkSize
+	globalCopyMaxMemory = 2 * chunkSize
+
+	data := make([]byte, 10*chunkSize+123)
+	if _, err := rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	var reading, maxReading int32
+	readObject := func(startOffset, length int64, writer io.Writer) error {
+		n := atomic.AddInt32(&reading, 1)
+		defer atomic.AddInt32(&reading, -1)
+		for {
+			max := atomic.LoadInt32(&maxReading)
+			if n <= max || atomic.CompareAndSwapInt32(&maxReading, max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		_, err := writer.Write(data[startOffset : startOffset+length])
+		return err
+	}
+
+	// Copies running at the same time share the memory limit.
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reader := newCopyReader(readObject, int64(len(data)))
+			defer reader.Close()
+			var buffer bytes.Buffer
+			if _, err := io.Copy(&buffer, reader); err != nil {
+				t.Errorf("Copy %d: %s", i+1, err)
+				return
+			}
+			if !bytes.Equal(buffer.Bytes(), data) {
+				t.Errorf("Copy %d: Contents of the copy differ from the source", i+1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if max := atomic.LoadInt32(&maxReading); max > 2 {
+		t.Fatalf("Expected at most 2 chunks held concurrently, got %d", max)
+	}
+}
+
+// countingXLMetaDisk - counts the reads of `xl.json` of an object.
+type countingXLMetaDisk struct {
+	StorageAPI
+	object string
+	reads  *int32
+}
+
+func (d countingXLMetaDisk) ReadAll(volume, filePath string) ([]byte, error) {
+	if filePath == path.Join(d.object, xlMetaJSONFile) {
+		atomic.AddInt32(d.reads, 1)
+	}
+	return d.StorageAPI.ReadAll(volume, filePath)
+}
+
+// Tests the chunks of a copy are read from the metadata of the source
+// read once per copy.
+func TestXLCopyObjectReadsMetadataOnce(t *testing.T) {
+	defer func(concurrency int, chunkSize int64) {
+		globalCopyConcurrency = concurrency
+		globalCopyChunkSize = chunkSize
+	}(globalCopyConcurrency, globalCopyChunkSize)
+	chunkSize := int64(64 * humanize.KiByte)
+	globalCopyConcurrency = 3
+	globalCopyChunkSize = chunkSize
+
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 5*chunkSize+123)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, "source", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	xl := obj.(*xlObjects)
+	var reads int32
+	for i := range xl.storageDisks {
+		xl.storageDisks[i] = countingXLMetaDisk{xl.storageDisks[i], "source", &reads}
+	}
+	if _, err = obj.CopyObject(bucket, "source", bucket, "copy", nil); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&reads); n != int32(len(xl.storageDisks)) {
+		t.Fatalf("Expected xl.json of the source read once from each of the %d disks, got %d reads", len(xl.storageDisks), n)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "copy", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("Contents of the copy differ from the source")
+	}
+}
@@ -0,0 +1,590 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+)
+
+// Server side encryption with customer provided keys (SSE-C), the
+// object is encrypted with a random object key sealed by the customer
+// key. The object is encrypted in fixed size blocks, each one sealed
+// with AES-256-GCM under a nonce derived from its index, so that the
+// ranged reads only decrypt the blocks covering the range.
+const (
+	sseCustomerAlgorithmHeader = "X-Amz-Server-Side-Encryption-Customer-Algorithm"
+	sseCustomerKeyHeader       = "X-Amz-Server-Side-Encryption-Customer-Key"
+	sseCustomerKeyMD5Header    = "X-Amz-Server-Side-Encryption-Customer-Key-Md5"
+
+	// Customer provided key of the source of a copy.
+	sseCopyCustomerAlgorithmHeader = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Algorithm"
+	sseCopyCustomerKeyHeader       = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key"
+	sseCopyCustomerKeyMD5Header    = "X-Amz-Copy-Source-Server-Side-Encryption-Customer-Key-Md5"
+
+	// Only supported algorithm of the customer keys.
+	sseAlgorithmAES256 = "AES256"
+
+	// Metadata of the object holding its sealed object key, internal
+	// metadata is never sent back.
+	minioInternalMetaPrefix = "X-Minio-Internal-"
+	sseSealedKeyMetaKey     = minioInternalMetaPrefix + "Server-Side-Encryption-Sealed-Key"
+
+	// Plaintext size of the blocks, each block is stored with the
+	// authentication tag of GCM appended.
+	sseBlockSize    = 64 * 1024
+	sseBlockTagSize = 16
+	sseSealedSize   = sseBlockSize + sseBlockTagSize
+)
+
+var (
+	// errEncryptedObjectUnsupported - the operation is not supported on
+	// the objects encrypted with a customer provided key.
+	errEncryptedObjectUnsupported = errors.New("Operation is not supported on the objects encrypted with a customer provided key")
+
+	// errSSECustomerKeyMismatch - the customer key does not unseal the
+	// object key.
+	errSSECustomerKeyMismatch = errors.New("Customer provided key does not match the key of the object")
+
+	// errSSECorruptedObject - a block of the object failed its
+	// authentication.
+	errSSECorruptedObject = errors.New("Encrypted object data is corrupted")
+)
+
+// isEncryptedObject - returns true if the object is encrypted with a
+// customer provided key.
+func isEncryptedObject(metadata map[string]string) bool {
+	_, ok := metadata[sseSealedKeyMetaKey]
+	return ok
+}
+
+// hasSSECustomerHeaders - returns true if the request carries any of the
+// customer provided key headers.
+func hasSSECustomerHeaders(header http.Header) bool {
+	return header.Get(sseCustomerAlgorithmHeader) != "" ||
+		header.Get(sseCustomerKeyHeader) != "" ||
+		header.Get(sseCustomerKeyMD5Header) != ""
+}
+
+// hasSSECopyCustomerHeaders - returns true if the request carries any
+// of the customer provided key headers of the copy source.
+func hasSSECopyCustomerHeaders(header http.Header) bool {
+	return header.Get(sseCopyCustomerAlgorithmHeader) != "" ||
+		header.Get(sseCopyCustomerKeyHeader) != "" ||
+		header.Get(sseCopyCustomerKeyMD5Header) != ""
+}
+
+// parseSSECustomerKey - returns the customer provided key of the
+// request, nil if the request does not carry one. The keys are only
+// accepted over TLS.
+func parseSSECustomerKey(header http.Header) ([]byte, APIErrorCode) {
+	if !hasSSECustomerHeaders(header) {
+		return nil, ErrNone
+	}
+	return parseSSECustomerKeyHeaders(header.Get(sseCustomerAlgorithmHeader),
+		header.Get(sseCustomerKeyHeader), header.Get(sseCustomerKeyMD5Header))
+}
+
+// parseSSECopyCustomerKey - returns the customer provided key of the
+// copy source, nil if the request does not carry one.
+func parseSSECopyCustomerKey(header http.Header) ([]byte, APIErrorCode) {
+	if !hasSSECopyCustomerHeaders(header) {
+		return nil, ErrNone
+	}
+	return parseSSECustomerKeyHeaders(header.Get(sseCopyCustomerAlgorithmHeader),
+		header.Get(sseCopyCustomerKeyHeader), header.Get(sseCopyCustomerKeyMD5Header))
+}
+
+// parseSSECustomerKeyHeaders - validates the algorithm, the key and its
+// MD5 sent by the client, the keys are only accepted over TLS.
+func parseSSECustomerKeyHeaders(algorithm, encodedKey, keyMD5Base64 string) ([]byte, APIErrorCode) {
+	if !globalIsSSL {
+		return nil, ErrInsecureSSECustomerRequest
+	}
+	if algorithm != sseAlgorithmAES256 {
+		return nil, ErrInvalidSSECustomerAlgorithm
+	}
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(key) != 32 {
+		return nil, ErrInvalidSSECustomerKey
+	}
+	keyMD5 := md5.Sum(key)
+	if keyMD5Base64 != base64.StdEncoding.EncodeToString(keyMD5[:]) {
+		return nil, ErrSSECustomerKeyMD5Mismatch
+	}
+	return key, ErrNone
+}
+
+// setSSECustomerResponseHeaders - confirms the customer provided key
+// used by the request.
+func setSSECustomerResponseHeaders(w http.ResponseWriter, header http.Header) {
+	w.Header().Set(sseCustomerAlgorithmHeader, sseAlgorithmAES256)
+	w.Header().Set(sseCustomerKeyMD5Header, header.Get(sseCustomerKeyMD5Header))
+}
+
+// newGCM - returns AES-256-GCM with the key.
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealObjectKey - seals the object key with the customer key.
+func sealObjectKey(customerKey, objectKey []byte) (string, error) {
+	aead, err := newGCM(customerKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, objectKey, []byte(sseAlgorithmAES256))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// unsealObjectKey - unseals the object key with the customer key,
+// returns errSSECustomerKeyMismatch if the key is not the one the
+// object was sealed with.
+func unsealObjectKey(customerKey []byte, sealedKey string) ([]byte, error) {
+	aead, err := newGCM(customerKey)
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(sealedKey)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return nil, errSSECorruptedObject
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	objectKey, err := aead.Open(nil, nonce, sealed, []byte(sseAlgorithmAES256))
+	if err != nil {
+		return nil, errSSECustomerKeyMismatch
+	}
+	return objectKey, nil
+}
+
+// sseBlockNonce - returns the nonce of the block, unique per block of
+// the object since every object has its own key.
+func sseBlockNonce(block uint64) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[4:], block)
+	return nonce
+}
+
+// sseBlockAdditionalData - authenticates the final block of the object
+// as such, so that the truncation of the object at a block boundary is
+// detected.
+func sseBlockAdditionalData(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// getEncryptedSize - returns the size of the encrypted object.
+func getEncryptedSize(size int64) int64 {
+	encSize := (size / sseBlockSize) * sseSealedSize
+	if rem := size % sseBlockSize; rem > 0 {
+		encSize += rem + sseBlockTagSize
+	}
+	return encSize
+}
+
+// getDecryptedSize - returns the plaintext size of the encrypted object.
+func getDecryptedSize(encSize int64) (int64, error) {
+	size := (encSize / sseSealedSize) * sseBlockSize
+	if rem := encSize % sseSealedSize; rem > 0 {
+		if rem <= sseBlockTagSize {
+			return 0, errSSECorruptedObject
+		}
+		size += rem - sseBlockTagSize
+	}
+	return size, nil
+}
+
+// getEncryptedRange - returns the range of the encrypted object holding
+// the blocks of the plaintext range of length bytes at offset.
+func getEncryptedRange(offset, length, size int64) (encOffset, encLength int64) {
+	if length <= 0 {
+		return 0, 0
+	}
+	firstBlock := offset / sseBlockSize
+	lastBlock := (offset + length - 1) / sseBlockSize
+	encOffset = firstBlock * sseSealedSize
+	encEnd := (lastBlock + 1) * sseSealedSize
+	if encSize := getEncryptedSize(size); encEnd > encSize {
+		encEnd = encSize
+	}
+	return encOffset, encEnd - encOffset
+}
+
+// encryptObject - returns the reader of the size bytes of the object
+// encrypted with a new object key, and their encrypted size. The object
+// key sealed with the customer key is saved to the metadata. The
+// Content-MD5 in the metadata and sha256sum are verified on the
+// plaintext since the object layer only sees the encrypted data.
+func encryptObject(reader io.Reader, size int64, customerKey []byte, metadata map[string]string, sha256sum string) (io.Reader, int64, error) {
+	objectKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, objectKey); err != nil {
+		return nil, 0, err
+	}
+	sealedKey, err := sealObjectKey(customerKey, objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	aead, err := newGCM(objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	encReader := &sseEncryptReader{
+		reader:    io.LimitReader(reader, size),
+		aead:      aead,
+		remaining: size,
+		plain:     make([]byte, sseBlockSize),
+		out:       make([]byte, 0, sseSealedSize),
+		md5Hex:    metadata["md5Sum"],
+		sha256Hex: sha256sum,
+	}
+	if encReader.md5Hex != "" {
+		encReader.md5Writer = md5.New()
+	}
+	if encReader.sha256Hex != "" {
+		encReader.sha256Writer = sha256.New()
+	}
+	delete(metadata, "md5Sum")
+	metadata[sseSealedKeyMetaKey] = sealedKey
+	return encReader, getEncryptedSize(size), nil
+}
+
+// sseEncryptReader - encrypts the plaintext read block by block.
+type sseEncryptReader struct {
+	reader    io.Reader
+	aead      cipher.AEAD
+	remaining int64
+	block     uint64
+	done      bool
+
+	plain  []byte
+	out    []byte
+	sealed []byte
+
+	md5Hex, sha256Hex       string
+	md5Writer, sha256Writer hash.Hash
+}
+
+func (r *sseEncryptReader) Read(p []byte) (int, error) {
+	for len(r.sealed) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealNext(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.sealed)
+	r.sealed = r.sealed[n:]
+	return n, nil
+}
+
+// sealNext - reads and encrypts the next block, the digests are
+// verified before the final block is sealed.
+func (r *sseEncryptReader) sealNext() error {
+	if r.remaining == 0 {
+		r.done = true
+		return r.verify()
+	}
+	plain := r.plain
+	if r.remaining < int64(len(plain)) {
+		plain = plain[:r.remaining]
+	}
+	if _, err := io.ReadFull(r.reader, plain); err != nil {
+		if err == io.ErrUnexpectedEOF || err == io.EOF {
+			// Short body, the object layer reports the incomplete
+			// body from the size read.
+			r.done = true
+			return nil
+		}
+		return err
+	}
+	if r.md5Writer != nil {
+		r.md5Writer.Write(plain)
+	}
+	if r.sha256Writer != nil {
+		r.sha256Writer.Write(plain)
+	}
+	r.remaining -= int64(len(plain))
+	if r.remaining == 0 {
+		r.done = true
+		if err := r.verify(); err != nil {
+			return err
+		}
+	}
+	r.sealed = r.aead.Seal(r.out[:0], sseBlockNonce(r.block), plain, sseBlockAdditionalData(r.done))
+	r.block++
+	return nil
+}
+
+// verify - verifies the digests of the plaintext read.
+func (r *sseEncryptReader) verify() error {
+	if r.md5Writer != nil {
+		if md5Hex := hex.EncodeToString(r.md5Writer.Sum(nil)); md5Hex != r.md5Hex {
+			return traceError(BadDigest{r.md5Hex, md5Hex})
+		}
+	}
+	if r.sha256Writer != nil {
+		if hex.EncodeToString(r.sha256Writer.Sum(nil)) != r.sha256Hex {
+			return traceError(SHA256Mismatch{})
+		}
+	}
+	return nil
+}
+
+// getObjectDecryptionKey - returns the object key of the encrypted
+// object unsealed with the customer key of the request, nil if the
+// object is not encrypted. The size of the object info is replaced by
+// its plaintext size.
+func getObjectDecryptionKey(header http.Header, objInfo *ObjectInfo) ([]byte, APIErrorCode) {
+	if !isEncryptedObject(objInfo.UserDefined) {
+		return nil, ErrNone
+	}
+	customerKey, s3Error := parseSSECustomerKey(header)
+	if s3Error != ErrNone {
+		return nil, s3Error
+	}
+	return unsealObjectDecryptionKey(customerKey, objInfo)
+}
+
+// getCopySourceDecryptionKey - returns the object key of the encrypted
+// copy source unsealed with the copy source customer key of the request,
+// nil if the source is not encrypted. The size of the object info is
+// replaced by its plaintext size.
+func getCopySourceDecryptionKey(header http.Header, objInfo *ObjectInfo) ([]byte, APIErrorCode) {
+	if !isEncryptedObject(objInfo.UserDefined) {
+		return nil, ErrNone
+	}
+	customerKey, s3Error := parseSSECopyCustomerKey(header)
+	if s3Error != ErrNone {
+		return nil, s3Error
+	}
+	return unsealObjectDecryptionKey(customerKey, objInfo)
+}
+
+// unsealObjectDecryptionKey - unseals the object key of the encrypted
+// object with the customer key.
+func unsealObjectDecryptionKey(customerKey []byte, objInfo *ObjectInfo) ([]byte, APIErrorCode) {
+	if customerKey == nil {
+		return nil, ErrSSEEncryptedObject
+	}
+	objectKey, err := unsealObjectKey(customerKey, objInfo.UserDefined[sseSealedKeyMetaKey])
+	if err == errSSECustomerKeyMismatch {
+		return nil, ErrSSECustomerKeyMismatch
+	}
+	if err != nil {
+		errorIf(err, "Unable to unseal the key of %s/%s.", objInfo.Bucket, objInfo.Name)
+		return nil, ErrInternalError
+	}
+	size, err := getDecryptedSize(objInfo.Size)
+	if err != nil {
+		errorIf(err, "Invalid size of the encrypted object %s/%s.", objInfo.Bucket, objInfo.Name)
+		return nil, ErrInternalError
+	}
+	objInfo.Size = size
+	return objectKey, ErrNone
+}
+
+// sseObjectReader - reads the ranges of an encrypted object.
+type sseObjectReader struct {
+	objectAPI ObjectLayer
+	objectKey []byte
+	size      int64
+}
+
+// newSSEObjectReader - reads the encrypted object of plaintext size
+// with the object key.
+func newSSEObjectReader(objectAPI ObjectLayer, objectKey []byte, size int64) *sseObjectReader {
+	return &sseObjectReader{
+		objectAPI: objectAPI,
+		objectKey: objectKey,
+		size:      size,
+	}
+}
+
+// GetObject - writes length bytes of the plaintext at startOffset,
+// only the blocks covering the range are read and decrypted. A negative
+// length reads up to the end of the object.
+func (o *sseObjectReader) GetObject(bucket, object string, startOffset, length int64, writer io.Writer) error {
+	if length < 0 {
+		length = o.size - startOffset
+	}
+	aead, err := newGCM(o.objectKey)
+	if err != nil {
+		return err
+	}
+	encOffset, encLength := getEncryptedRange(startOffset, length, o.size)
+	decWriter := newSSEDecryptWriter(writer, aead, startOffset, length, o.size)
+	if encLength > 0 {
+		if err = o.objectAPI.GetObject(bucket, object, encOffset, encLength, decWriter); err != nil {
+			return err
+		}
+	}
+	return decWriter.Close()
+}
+
+// sseDecryptWriter - decrypts the encrypted blocks written, and writes
+// the plaintext of the range.
+type sseDecryptWriter struct {
+	writer    io.Writer
+	aead      cipher.AEAD
+	block     uint64
+	lastBlock uint64
+	skip      int64
+	remaining int64
+	sealed    []byte
+
+	// Number of the blocks decrypted.
+	blocks int
+}
+
+// newSSEDecryptWriter - writes length bytes of the plaintext at offset
+// of the object of plaintext size, the encrypted blocks are written
+// from the block holding offset.
+func newSSEDecryptWriter(writer io.Writer, aead cipher.AEAD, offset, length, size int64) *sseDecryptWriter {
+	var lastBlock int64
+	if size > 0 {
+		lastBlock = (size - 1) / sseBlockSize
+	}
+	return &sseDecryptWriter{
+		writer:    writer,
+		aead:      aead,
+		block:     uint64(offset / sseBlockSize),
+		lastBlock: uint64(lastBlock),
+		skip:      offset % sseBlockSize,
+		remaining: length,
+		sealed:    make([]byte, 0, sseSealedSize),
+	}
+}
+
+func (w *sseDecryptWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		m := copy(w.sealed[len(w.sealed):cap(w.sealed)], p)
+		w.sealed = w.sealed[:len(w.sealed)+m]
+		p = p[m:]
+		if len(w.sealed) == cap(w.sealed) {
+			if err := w.open(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Close - decrypts the final block written, shorter than the others.
+func (w *sseDecryptWriter) Close() error {
+	if len(w.sealed) == 0 {
+		return nil
+	}
+	return w.open()
+}
+
+// open - decrypts the block buffered and writes its plaintext within
+// the range.
+func (w *sseDecryptWriter) open() error {
+	plain, err := w.aead.Open(w.sealed[:0], sseBlockNonce(w.block), w.sealed, sseBlockAdditionalData(w.block == w.lastBlock))
+	if err != nil {
+		return traceError(errSSECorruptedObject)
+	}
+	w.sealed = w.sealed[:0]
+	w.block++
+	w.blocks++
+
+	plain = plain[w.skip:]
+	w.skip = 0
+	if int64(len(plain)) > w.remaining {
+		plain = plain[:w.remaining]
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+	w.remaining -= int64(len(plain))
+	_, err = w.writer.Write(plain)
+	return err
+}
+
+// copyEncryptedObject - copies the plaintext of the source, decrypted
+// with srcObjectKey if the source is encrypted, to the destination,
+// encrypted with dstCustomerKey if any. The checksum of the algorithm,
+// if any, is computed over the copied plaintext and saved along with
+// the metadata of the destination once it is written. Caller should
+// hold the locks of both objects.
+func copyEncryptedObject(objectAPI ObjectLayer, srcBucket, srcObject, dstBucket, dstObject string, size int64,
+	srcObjectKey, dstCustomerKey []byte, metadata map[string]string, algorithm string) (objInfo ObjectInfo, checksum string, err error) {
+	getObject := objectAPI.GetObject
+	if srcObjectKey != nil {
+		getObject = newSSEObjectReader(objectAPI, srcObjectKey, size).GetObject
+	}
+	copyReader := newCopyReader(func(startOffset, length int64, writer io.Writer) error {
+		return getObject(srcBucket, srcObject, startOffset, length, writer)
+	}, size)
+	// Explicitly close the reader.
+	defer copyReader.Close()
+
+	var reader io.Reader = copyReader
+	var checksumWriter hash.Hash
+	if algorithm != "" {
+		checksumWriter = checksumHashers[algorithm]()
+		reader = io.TeeReader(reader, checksumWriter)
+	}
+
+	// The sealed key of the source never applies to the destination,
+	// the ETag is computed over the data stored.
+	delete(metadata, sseSealedKeyMetaKey)
+	delete(metadata, "md5Sum")
+	putSize := size
+	if dstCustomerKey != nil {
+		if reader, putSize, err = encryptObject(reader, size, dstCustomerKey, metadata, ""); err != nil {
+			return ObjectInfo{}, "", err
+		}
+	}
+	if objInfo, err = objectAPI.PutObject(dstBucket, dstObject, putSize, reader, metadata, ""); err != nil {
+		return ObjectInfo{}, "", err
+	}
+	if checksumWriter == nil {
+		return objInfo, "", nil
+	}
+
+	// Only the metadata of the destination is written again.
+	checksum = base64.StdEncoding.EncodeToString(checksumWriter.Sum(nil))
+	setChecksumMetadata(metadata, algorithm, checksum)
+	metadata["md5Sum"] = objInfo.MD5Sum
+	if objInfo, err = objectAPI.CopyObject(dstBucket, dstObject, dstBucket, dstObject, metadata); err != nil {
+		return ObjectInfo{}, "", err
+	}
+	return objInfo, checksum, nil
+}
@@ -0,0 +1,489 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// setTestSSECustomerKey - sets the customer provided key headers.
+func setTestSSECustomerKey(header http.Header, key []byte) {
+	keyMD5 := md5.Sum(key)
+	header.Set(sseCustomerAlgorithmHeader, sseAlgorithmAES256)
+	header.Set(sseCustomerKeyHeader, base64.StdEncoding.EncodeToString(key))
+	header.Set(sseCustomerKeyMD5Header, base64.StdEncoding.EncodeToString(keyMD5[:]))
+}
+
+// setTestSSECopyCustomerKey - sets the customer provided key headers of
+// the copy source.
+func setTestSSECopyCustomerKey(header http.Header, key []byte) {
+	keyMD5 := md5.Sum(key)
+	header.Set(sseCopyCustomerAlgorithmHeader, sseAlgorithmAES256)
+	header.Set(sseCopyCustomerKeyHeader, base64.StdEncoding.EncodeToString(key))
+	header.Set(sseCopyCustomerKeyMD5Header, base64.StdEncoding.EncodeToString(keyMD5[:]))
+}
+
+// Tests the sizes of the encrypted objects.
+func TestSSEEncryptedSize(t *testing.T) {
+	for _, size := range []int64{0, 1, sseBlockSize - 1, sseBlockSize, sseBlockSize + 1, 5*sseBlockSize + 100} {
+		encSize := getEncryptedSize(size)
+		decSize, err := getDecryptedSize(encSize)
+		if err != nil {
+			t.Fatalf("Size %d: Unexpected error %v", size, err)
+		}
+		if decSize != size {
+			t.Fatalf("Size %d: Expected the decrypted size %d, got %d", size, size, decSize)
+		}
+	}
+	// A final block without data is invalid.
+	if _, err := getDecryptedSize(sseSealedSize + sseBlockTagSize); err == nil {
+		t.Fatal("Expected an invalid encrypted size")
+	}
+}
+
+// Tests the digests of the plaintext are verified while encrypting.
+func TestEncryptObjectDigests(t *testing.T) {
+	key := bytes.Repeat([]byte{'k'}, 32)
+	data := bytes.Repeat([]byte("a"), 2*sseBlockSize)
+	md5Sum := md5.Sum(data)
+	testCases := []struct {
+		md5Hex, sha256Hex string
+		expectErr         error
+	}{
+		{hex.EncodeToString(md5Sum[:]), getSHA256Hash(data), nil},
+		{"", "", nil},
+		{hex.EncodeToString(md5Sum[:]), getSHA256Hash([]byte("b")), SHA256Mismatch{}},
+	}
+	for i, testCase := range testCases {
+		metadata := map[string]string{"md5Sum": testCase.md5Hex}
+		reader, encSize, err := encryptObject(bytes.NewReader(data), int64(len(data)), key, metadata, testCase.sha256Hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := metadata["md5Sum"]; ok {
+			t.Fatalf("Test %d: Expected md5Sum to be verified on the plaintext", i+1)
+		}
+		encData, err := ioutil.ReadAll(reader)
+		if errorCause(err) != testCase.expectErr {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expectErr, err)
+		}
+		if err == nil && int64(len(encData)) != encSize {
+			t.Fatalf("Test %d: Expected %d bytes, got %d", i+1, encSize, len(encData))
+		}
+	}
+
+	// Content-MD5 mismatch.
+	metadata := map[string]string{"md5Sum": hex.EncodeToString(make([]byte, md5.Size))}
+	reader, _, err := encryptObject(bytes.NewReader(data), int64(len(data)), key, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ioutil.ReadAll(reader); !isBadDigest(errorCause(err)) {
+		t.Fatalf("Expected BadDigest, got %v", err)
+	}
+}
+
+func isBadDigest(err error) bool {
+	_, ok := err.(BadDigest)
+	return ok
+}
+
+// rangeRecordingObjectLayer - records the ranges read from the object
+// layer.
+type rangeRecordingObjectLayer struct {
+	ObjectLayer
+	offset, length int64
+}
+
+func (r *rangeRecordingObjectLayer) GetObject(bucket, object string, startOffset, length int64, writer io.Writer) error {
+	r.offset, r.length = startOffset, length
+	return r.ObjectLayer.GetObject(bucket, object, startOffset, length, writer)
+}
+
+// Tests the ranges of the encrypted objects only read and decrypt the
+// blocks covering them.
+func TestSSEObjectReader(t *testing.T) {
+	ExecObjectLayerTest(t, testSSEObjectReader)
+}
+
+func testSSEObjectReader(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "bucket", "object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	key := bytes.Repeat([]byte{'k'}, 32)
+	size := int64(5*sseBlockSize + 1000)
+	data := bytes.Repeat([]byte("0123456789abcdef"), int(size/16+1))[:size]
+	metadata := make(map[string]string)
+	reader, encSize, err := encryptObject(bytes.NewReader(data), size, key, metadata, "")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	objInfo, err := obj.PutObject(bucket, object, encSize, reader, metadata, "")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if objInfo.Size != encSize {
+		t.Fatalf("%s: Expected the stored size %d, got %d", instanceType, encSize, objInfo.Size)
+	}
+	objectKey, err := unsealObjectKey(key, metadata[sseSealedKeyMetaKey])
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if _, err = unsealObjectKey(bytes.Repeat([]byte{'x'}, 32), metadata[sseSealedKeyMetaKey]); err != errSSECustomerKeyMismatch {
+		t.Fatalf("%s: Expected errSSECustomerKeyMismatch, got %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		offset, length int64
+		// Encrypted range read.
+		encOffset, encLength int64
+	}{
+		// Tail range, only the final block.
+		{size - 100, 100, 5 * sseSealedSize, 1000 + sseBlockTagSize},
+		// Within a block.
+		{sseBlockSize + 10, 10, sseSealedSize, sseSealedSize},
+		// Across two blocks.
+		{2*sseBlockSize - 5, 10, sseSealedSize, 2 * sseSealedSize},
+		// Whole object.
+		{0, size, 0, encSize},
+		// Up to the end.
+		{3 * sseBlockSize, -1, 3 * sseSealedSize, encSize - 3*sseSealedSize},
+	}
+	for i, testCase := range testCases {
+		recorder := &rangeRecordingObjectLayer{ObjectLayer: obj}
+		var buffer bytes.Buffer
+		err = newSSEObjectReader(recorder, objectKey, size).GetObject(bucket, object, testCase.offset, testCase.length, &buffer)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %v", instanceType, i+1, err)
+		}
+		if recorder.offset != testCase.encOffset || recorder.length != testCase.encLength {
+			t.Fatalf("%s: Test %d: Expected the encrypted range %d-%d, got %d-%d", instanceType, i+1,
+				testCase.encOffset, testCase.encLength, recorder.offset, recorder.length)
+		}
+		length := testCase.length
+		if length < 0 {
+			length = size - testCase.offset
+		}
+		if !bytes.Equal(buffer.Bytes(), data[testCase.offset:testCase.offset+length]) {
+			t.Fatalf("%s: Test %d: Decrypted data does not match", instanceType, i+1)
+		}
+	}
+}
+
+// Tests the blocks decrypted for a tail range and the detection of the
+// truncated objects.
+func TestSSEDecryptWriter(t *testing.T) {
+	key := bytes.Repeat([]byte{'k'}, 32)
+	size := int64(4*sseBlockSize + 10)
+	data := bytes.Repeat([]byte("x"), int(size))
+	metadata := make(map[string]string)
+	reader, _, err := encryptObject(bytes.NewReader(data), size, key, metadata, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encData, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectKey, err := unsealObjectKey(key, metadata[sseSealedKeyMetaKey])
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := newGCM(objectKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Tail range spanning the last two blocks.
+	offset, length := size-20, int64(20)
+	encOffset, encLength := getEncryptedRange(offset, length, size)
+	var buffer bytes.Buffer
+	decWriter := newSSEDecryptWriter(&buffer, aead, offset, length, size)
+	if _, err = decWriter.Write(encData[encOffset : encOffset+encLength]); err != nil {
+		t.Fatal(err)
+	}
+	if err = decWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if decWriter.blocks != 2 {
+		t.Fatalf("Expected 2 blocks decrypted, got %d", decWriter.blocks)
+	}
+	if !bytes.Equal(buffer.Bytes(), data[offset:]) {
+		t.Fatal("Decrypted data does not match")
+	}
+
+	// Object truncated at a block boundary, its last block is not the
+	// final block.
+	truncatedSize := int64(4 * sseBlockSize)
+	decWriter = newSSEDecryptWriter(ioutil.Discard, aead, 3*sseBlockSize, sseBlockSize, truncatedSize)
+	decWriter.Write(encData[3*sseSealedSize : 4*sseSealedSize])
+	if err = decWriter.Close(); errorCause(err) != errSSECorruptedObject {
+		t.Fatalf("Expected errSSECorruptedObject, got %v", err)
+	}
+}
+
+// Tests the objects written with a customer provided key are only read
+// with it, and their ranges are decrypted.
+func TestSSECustomerKeyHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testSSECustomerKeyHandlers, []string{"PutObject", "GetObject", "HeadObject"})
+}
+
+func testSSECustomerKeyHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+	globalIsSSL = true
+
+	key := bytes.Repeat([]byte{'k'}, 32)
+	data := bytes.Repeat([]byte("0123456789"), 3*sseBlockSize/10)
+
+	serveRequest := func(method, urlStr string, body []byte, customerKey []byte, headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if customerKey != nil {
+			setTestSSECustomerKey(req.Header, customerKey)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		// Headers are signed.
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serveRequest("PUT", getPutObjectURL("", bucketName, "object"), data, key, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get(sseCustomerAlgorithmHeader) != sseAlgorithmAES256 {
+		t.Fatalf("%s: Expected the customer key to be confirmed", instanceType)
+	}
+
+	// The stored data is encrypted.
+	var stored bytes.Buffer
+	if err := obj.GetObject(bucketName, "object", 0, -1, &stored); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if int64(stored.Len()) != getEncryptedSize(int64(len(data))) || bytes.Contains(stored.Bytes(), data[:100]) {
+		t.Fatalf("%s: Expected the stored object to be encrypted", instanceType)
+	}
+
+	// HEAD requires the key, and reports the plaintext size.
+	testCases := []struct {
+		customerKey    []byte
+		expectedStatus int
+	}{
+		{nil, http.StatusBadRequest},
+		{bytes.Repeat([]byte{'x'}, 32), http.StatusForbidden},
+		{key, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		rec = serveRequest("HEAD", getGetObjectURL("", bucketName, "object"), nil, testCase.customerKey, nil)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+	if rec.Header().Get("Content-Length") != strconv.Itoa(len(data)) {
+		t.Fatalf("%s: Expected Content-Length %d, got %s", instanceType, len(data), rec.Header().Get("Content-Length"))
+	}
+	if rec.Header().Get(sseSealedKeyMetaKey) != "" {
+		t.Fatalf("%s: Unexpected sealed key header", instanceType)
+	}
+
+	// Tail range.
+	rec = serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, key, map[string]string{"Range": "bytes=-100"})
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusPartialContent, rec.Code, rec.Body.String())
+	}
+	if !bytes.Equal(rec.Body.Bytes(), data[len(data)-100:]) {
+		t.Fatalf("%s: Tail range does not match", instanceType)
+	}
+	expectedRange := "bytes " + strconv.Itoa(len(data)-100) + "-" + strconv.Itoa(len(data)-1) + "/" + strconv.Itoa(len(data))
+	if rec.Header().Get("Content-Range") != expectedRange {
+		t.Fatalf("%s: Expected Content-Range %s, got %s", instanceType, expectedRange, rec.Header().Get("Content-Range"))
+	}
+
+	// Whole object.
+	rec = serveRequest("GET", getGetObjectURL("", bucketName, "object"), nil, key, nil)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Fatalf("%s: Expected the object, got status %d", instanceType, rec.Code)
+	}
+
+	// Keys are only accepted over TLS.
+	globalIsSSL = false
+	rec = serveRequest("PUT", getPutObjectURL("", bucketName, "insecure"), data, key, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+}
+
+// Tests the copies decrypt their source with the copy source customer
+// key and encrypt their destination with the customer key, the ETag
+// and the checksum of the copies match the data stored.
+func TestSSECopyObjectHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testSSECopyObjectHandler, []string{"CopyObject", "PutObject", "GetObject"})
+}
+
+func testSSECopyObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(isSSL bool) { globalIsSSL = isSSL }(globalIsSSL)
+	globalIsSSL = true
+
+	key := bytes.Repeat([]byte{'k'}, 32)
+	otherKey := bytes.Repeat([]byte{'o'}, 32)
+	data := bytes.Repeat([]byte("0123456789"), 3*sseBlockSize/10)
+	if _, err := obj.PutObject(bucketName, "plain", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	copyObject := func(source, object string, srcKey, dstKey []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest("PUT", getCopyObjectURL("", bucketName, object), 0, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		req.Header.Set("X-Amz-Copy-Source", "/"+bucketName+"/"+source)
+		if srcKey != nil {
+			setTestSSECopyCustomerKey(req.Header, srcKey)
+		}
+		if dstKey != nil {
+			setTestSSECustomerKey(req.Header, dstKey)
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	getObject := func(object string, customerKey []byte) []byte {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest("GET", getGetObjectURL("", bucketName, object), 0, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if customerKey != nil {
+			setTestSSECustomerKey(req.Header, customerKey)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d reading %s, got %d", instanceType, http.StatusOK, object, rec.Code)
+		}
+		return rec.Body.Bytes()
+	}
+	// The ETag of the copy is the one of the data stored.
+	checkCopy := func(rec *httptest.ResponseRecorder, object string) CopyObjectResponse {
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d copying %s, got %d: %s", instanceType, http.StatusOK, object, rec.Code, rec.Body.String())
+		}
+		var response CopyObjectResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		var stored bytes.Buffer
+		if err := obj.GetObject(bucketName, object, 0, -1, &stored); err != nil {
+			t.Fatalf("%s: %v", instanceType, err)
+		}
+		if etag := "\"" + getMD5Hash(stored.Bytes()) + "\""; response.ETag != etag {
+			t.Fatalf("%s: Expected the ETag %s of %s, got %s", instanceType, etag, object, response.ETag)
+		}
+		return response
+	}
+
+	// Plaintext to encrypted.
+	rec := copyObject("plain", "encrypted", nil, key, nil)
+	checkCopy(rec, "encrypted")
+	if rec.Header().Get(sseCustomerAlgorithmHeader) != sseAlgorithmAES256 {
+		t.Fatalf("%s: Expected the customer key to be confirmed", instanceType)
+	}
+	objInfo, err := obj.GetObjectInfo(bucketName, "encrypted")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if objInfo.Size != getEncryptedSize(int64(len(data))) {
+		t.Fatalf("%s: Expected the copy to be encrypted, got size %d", instanceType, objInfo.Size)
+	}
+	if !bytes.Equal(getObject("encrypted", key), data) {
+		t.Fatalf("%s: Data of the encrypted copy does not match", instanceType)
+	}
+
+	// Encrypted sources are only copied with their key.
+	testCases := []struct {
+		srcKey         []byte
+		expectedStatus int
+	}{
+		{nil, http.StatusBadRequest},
+		{otherKey, http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		if rec = copyObject("encrypted", "copy", testCase.srcKey, nil, nil); rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+
+	// Encrypted to encrypted with another key.
+	checkCopy(copyObject("encrypted", "rotated", key, otherKey, nil), "rotated")
+	if !bytes.Equal(getObject("rotated", otherKey), data) {
+		t.Fatalf("%s: Data of the rotated copy does not match", instanceType)
+	}
+
+	// Encrypted to plaintext, the checksum is computed over the plaintext.
+	header := http.Header{"X-Amz-Checksum-Algorithm": {checksumSHA256}}
+	response := checkCopy(copyObject("rotated", "decrypted", otherKey, nil, header), "decrypted")
+	sum := sha256.Sum256(data)
+	if expected := base64.StdEncoding.EncodeToString(sum[:]); response.ChecksumSHA256 != expected {
+		t.Fatalf("%s: Expected checksum %s, got %s", instanceType, expected, response.ChecksumSHA256)
+	}
+	if response.ETag != "\""+getMD5Hash(data)+"\"" {
+		t.Fatalf("%s: Expected the ETag of the plaintext, got %s", instanceType, response.ETag)
+	}
+	if !bytes.Equal(getObject("decrypted", nil), data) {
+		t.Fatalf("%s: Data of the decrypted copy does not match", instanceType)
+	}
+
+	// Encrypted objects are not rewritten in place.
+	header = http.Header{"X-Amz-Metadata-Directive": {"REPLACE"}}
+	if rec = copyObject("rotated", "rotated", otherKey, key, header); rec.Code != http.StatusNotImplemented {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotImplemented, rec.Code)
+	}
+	if !bytes.Equal(getObject("rotated", otherKey), data) {
+		t.Fatalf("%s: Expected the object to be kept", instanceType)
+	}
+}
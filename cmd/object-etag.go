@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"hash"
+	"os"
+	"strings"
+
+	"github.com/minio/sha256-simd"
+)
+
+// Supported algorithms of the ETags of the objects.
+const (
+	// ETag is the MD5 sum of the data, the default.
+	etagAlgorithmMD5 = "md5"
+	// ETag is the SHA-256 sum of the data, for the setups where MD5
+	// is not allowed.
+	etagAlgorithmSHA256 = "sha256"
+)
+
+// Suffix marking the ETags which are the SHA-256 sum of the data. Like
+// the ETags of the multipart objects they contain a '-' so that the
+// clients do not verify them against the MD5 sum of the data.
+const etagSHA256Suffix = "-sha256"
+
+// etagWriter - computes the ETag of the data written to it. The MD5
+// sum of the data is computed as well to verify the Content-MD5 sent
+// by the clients, with the MD5 algorithm it is the ETag itself.
+type etagWriter struct {
+	etag hash.Hash
+	md5  hash.Hash
+}
+
+// newETagWriter - returns a writer computing the ETag of the data with
+// the configured algorithm, the MD5 sum is computed besides the ETag
+// only if verifyMD5 is set.
+func newETagWriter(verifyMD5 bool) *etagWriter {
+	if globalETagAlgorithm == etagAlgorithmMD5 {
+		md5Writer := md5.New()
+		return &etagWriter{etag: md5Writer, md5: md5Writer}
+	}
+	writer := &etagWriter{etag: sha256.New()}
+	if verifyMD5 {
+		writer.md5 = md5.New()
+	}
+	return writer
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	w.etag.Write(p)
+	if w.md5 != nil && w.md5 != w.etag {
+		w.md5.Write(p)
+	}
+	return len(p), nil
+}
+
+// ETag - returns the ETag of the data written.
+func (w *etagWriter) ETag() string {
+	return getETag(w.etag.Sum(nil))
+}
+
+// MD5Hex - returns the hex encoded MD5 sum of the data written, empty
+// if it is not computed.
+func (w *etagWriter) MD5Hex() string {
+	if w.md5 == nil {
+		return ""
+	}
+	return hex.EncodeToString(w.md5.Sum(nil))
+}
+
+// getETag - returns the ETag of the sum computed with the configured
+// algorithm.
+func getETag(sum []byte) string {
+	if globalETagAlgorithm == etagAlgorithmSHA256 {
+		return hex.EncodeToString(sum) + etagSHA256Suffix
+	}
+	return hex.EncodeToString(sum)
+}
+
+// getETagSum - returns the sum held by the ETag of an object or a part,
+// whichever algorithm computed it.
+func getETagSum(etag string) ([]byte, error) {
+	return hex.DecodeString(strings.TrimSuffix(etag, etagSHA256Suffix))
+}
+
+// isContentETag - returns true if the ETag is the sum of the whole data
+// computed with the configured algorithm, as opposed to the ETags of
+// the multipart objects and of the other algorithm.
+func isContentETag(etag string) bool {
+	if globalETagAlgorithm == etagAlgorithmSHA256 {
+		return strings.HasSuffix(etag, etagSHA256Suffix) && strings.Count(etag, "-") == 1
+	}
+	return etag != "" && !strings.Contains(etag, "-")
+}
+
+// newETagHash - returns a new hash of the configured ETag algorithm.
+func newETagHash() hash.Hash {
+	if globalETagAlgorithm == etagAlgorithmSHA256 {
+		return sha256.New()
+	}
+	return md5.New()
+}
+
+// setETagAlgorithm - sets the algorithm of the ETags of the objects
+// written from MINIO_ETAG_ALGORITHM env. The ETags of the objects are
+// saved along with them, the objects written earlier keep their ETags.
+func setETagAlgorithm() {
+	if algorithm := os.Getenv("MINIO_ETAG_ALGORITHM"); algorithm != "" {
+		switch strings.ToLower(algorithm) {
+		case etagAlgorithmMD5:
+			globalETagAlgorithm = etagAlgorithmMD5
+		case etagAlgorithmSHA256:
+			globalETagAlgorithm = etagAlgorithmSHA256
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_ETAG_ALGORITHM value %s.", algorithm)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	humanize "github.com/dustin/go-humanize"
+	"github.com/minio/sha256-simd"
+)
+
+// Tests the ETags of the objects and parts written with both ETag
+// algorithms.
+func TestObjectETagAlgorithm(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectETagAlgorithm)
+}
+
+func testObjectETagAlgorithm(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func(algorithm string) {
+		globalETagAlgorithm = algorithm
+	}(globalETagAlgorithm)
+
+	bucket := getRandomBucketName()
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	data := []byte("hello, world")
+	sha256Sum := sha256.Sum256(data)
+	testCases := []struct {
+		algorithm string
+		etag      string
+	}{
+		{etagAlgorithmMD5, getMD5Hash(data)},
+		{etagAlgorithmSHA256, hex.EncodeToString(sha256Sum[:]) + etagSHA256Suffix},
+	}
+	for i, testCase := range testCases {
+		globalETagAlgorithm = testCase.algorithm
+		object := "object-" + testCase.algorithm
+
+		objInfo, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, "")
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if objInfo.MD5Sum != testCase.etag {
+			t.Fatalf("%s: Test %d: Expected ETag %s, got %s", instanceType, i+1, testCase.etag, objInfo.MD5Sum)
+		}
+
+		// Content-MD5 sent by the client is verified whatever the algorithm.
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data),
+			map[string]string{"md5Sum": getMD5Hash(data)}, ""); err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		_, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data),
+			map[string]string{"md5Sum": getMD5Hash([]byte("other"))}, "")
+		if _, ok := errorCause(err).(BadDigest); !ok {
+			t.Fatalf("%s: Test %d: Expected BadDigest, got %v", instanceType, i+1, err)
+		}
+
+		// ETag of multipart objects is the sum of the sums of the parts
+		// followed by the number of parts.
+		uploadID, err := obj.NewMultipartUpload(bucket, object+"-multipart", nil)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		partData := bytes.Repeat([]byte("a"), 5*humanize.MiByte)
+		var parts []completePart
+		for partID, part := range [][]byte{partData, data} {
+			partInfo, pErr := obj.PutObjectPart(bucket, object+"-multipart", uploadID, partID+1, int64(len(part)), bytes.NewReader(part), "", "")
+			if pErr != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, pErr)
+			}
+			parts = append(parts, completePart{PartNumber: partID + 1, ETag: partInfo.ETag})
+		}
+		if parts[1].ETag != testCase.etag {
+			t.Fatalf("%s: Test %d: Expected part ETag %s, got %s", instanceType, i+1, testCase.etag, parts[1].ETag)
+		}
+		etagHash := newETagHash()
+		for _, part := range parts {
+			sum, sErr := getETagSum(part.ETag)
+			if sErr != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, sErr)
+			}
+			etagHash.Write(sum)
+		}
+		expectedETag := fmt.Sprintf("%s-%d", hex.EncodeToString(etagHash.Sum(nil)), len(parts))
+		objInfo, err = obj.CompleteMultipartUpload(bucket, object+"-multipart", uploadID, parts)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if objInfo.MD5Sum != expectedETag {
+			t.Fatalf("%s: Test %d: Expected ETag %s, got %s", instanceType, i+1, expectedETag, objInfo.MD5Sum)
+		}
+	}
+
+	// Objects keep their ETags once the algorithm is changed.
+	for i, testCase := range testCases {
+		globalETagAlgorithm = testCases[(i+1)%len(testCases)].algorithm
+		objInfo, err := obj.GetObjectInfo(bucket, "object-"+testCase.algorithm)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if objInfo.MD5Sum != testCase.etag {
+			t.Fatalf("%s: Test %d: Expected ETag %s, got %s", instanceType, i+1, testCase.etag, objInfo.MD5Sum)
+		}
+	}
+}
+
+// Tests that the ETag returned by PUT is the one returned by GET and
+// HEAD with both ETag algorithms.
+func TestObjectETagHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testObjectETagHandlers, []string{"PutObject", "GetObject", "HeadObject"})
+}
+
+func testObjectETagHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(algorithm string) {
+		globalETagAlgorithm = algorithm
+	}(globalETagAlgorithm)
+
+	serveRequest := func(method, urlStr string, body []byte) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	data := []byte("etag data")
+	for i, algorithm := range []string{etagAlgorithmMD5, etagAlgorithmSHA256} {
+		globalETagAlgorithm = algorithm
+		object := "object-" + algorithm
+
+		rec := serveRequest("PUT", getPutObjectURL("", bucketName, object), data)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		etag := rec.Header().Get("ETag")
+		isSHA256 := strings.HasSuffix(strings.Trim(etag, "\""), etagSHA256Suffix)
+		if isSHA256 != (algorithm == etagAlgorithmSHA256) {
+			t.Fatalf("%s: Test %d: Unexpected ETag %s", instanceType, i+1, etag)
+		}
+
+		for _, method := range []string{"GET", "HEAD"} {
+			rec = serveRequest(method, getGetObjectURL("", bucketName, object), nil)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+			}
+			if rec.Header().Get("ETag") != etag {
+				t.Fatalf("%s: Test %d: %s: Expected ETag %s, got %s", instanceType, i+1, method, etag, rec.Header().Get("ETag"))
+			}
+		}
+	}
+}
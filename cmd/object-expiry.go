@@ -0,0 +1,263 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Header setting the time to live of an object in seconds, the
+	// object is deleted once expired independently of the lifecycle
+	// rules of its bucket.
+	objectTTLHeader = "X-Minio-Object-Ttl"
+
+	// Expiry date of an object, saved in its metadata and sent back
+	// on GET and HEAD.
+	objectExpiresKey = "X-Minio-Object-Expires"
+
+	// Maximum time to live of an object, 100 years.
+	maxObjectTTL = 100 * 365 * 24 * 60 * 60
+
+	// Expiries are indexed under 'expiry/<bucket>/' in the meta bucket,
+	// one entry per object named after the time it is due so that the
+	// entries are listed in the order they are due.
+	expiryPrefix = "expiry"
+)
+
+// Interval between the deletions of the expired objects.
+var objectExpiryReapInterval = time.Minute
+
+// errInvalidObjectTTL - time to live of the object is not valid.
+var errInvalidObjectTTL = errors.New("Invalid object TTL")
+
+// objectExpiryEntry - entry of the expiry index, the object is deleted
+// once due if it still expires at the same date.
+type objectExpiryEntry struct {
+	Object    string    `json:"object"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// setObjectExpiryMetadata - saves the expiry date of the object from the
+// time to live requested, the expiry of the source of a copy is never
+// inherited.
+func setObjectExpiryMetadata(header http.Header, metadata map[string]string, now time.Time) error {
+	delete(metadata, objectExpiresKey)
+	ttl := header.Get(objectTTLHeader)
+	if ttl == "" {
+		return nil
+	}
+	seconds, err := strconv.ParseInt(ttl, 10, 64)
+	if err != nil || seconds <= 0 || seconds > maxObjectTTL {
+		return errInvalidObjectTTL
+	}
+	metadata[objectExpiresKey] = now.Add(time.Duration(seconds) * time.Second).Format(http.TimeFormat)
+	return nil
+}
+
+// getObjectExpiry - returns the expiry date saved in the metadata of the
+// object, false if it does not expire.
+func getObjectExpiry(metadata map[string]string) (time.Time, bool) {
+	expires, ok := metadata[objectExpiresKey]
+	if !ok {
+		return time.Time{}, false
+	}
+	expiresAt, err := http.ParseTime(expires)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return expiresAt, true
+}
+
+// getObjectExpiryPrefix - returns the prefix of the expiry index of the
+// bucket.
+func getObjectExpiryPrefix(bucket string) string {
+	return path.Join(expiryPrefix, bucket) + slashSeparator
+}
+
+// parseObjectExpiryEntryDue - returns the time an entry of the expiry
+// index is due from its name.
+func parseObjectExpiryEntryDue(entryPath string) (time.Time, bool) {
+	name := path.Base(entryPath)
+	i := strings.Index(name, "-")
+	if i < 0 {
+		return time.Time{}, false
+	}
+	due, err := strconv.ParseInt(name[:i], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(due, 0).UTC(), true
+}
+
+// writeObjectExpiryEntry - adds an entry due at the given time to the
+// expiry index of the bucket.
+func writeObjectExpiryEntry(objAPI ObjectLayer, bucket string, entry objectExpiryEntry, due time.Time) error {
+	buf, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	// Zero padded so that the entries sort in the order they are due.
+	entryPath := getObjectExpiryPrefix(bucket) + fmt.Sprintf("%020d-%s.json", due.Unix(), mustGetUUID())
+	if _, err = objAPI.PutObject(minioMetaBucket, entryPath, int64(len(buf)), bytes.NewReader(buf), nil, ""); err != nil {
+		return errorCause(err)
+	}
+	return nil
+}
+
+// readObjectExpiryEntry - reads an entry of the expiry index.
+func readObjectExpiryEntry(objAPI ObjectLayer, entryPath string) (objectExpiryEntry, error) {
+	var entry objectExpiryEntry
+	var buffer bytes.Buffer
+	if err := objAPI.GetObject(minioMetaBucket, entryPath, 0, -1, &buffer); err != nil {
+		return entry, errorCause(err)
+	}
+	err := json.Unmarshal(buffer.Bytes(), &entry)
+	return entry, err
+}
+
+// addObjectExpiry - indexes the expiry of the object written with the
+// metadata, if any. Entries left by failed writes or by overwritten
+// objects are dropped by the reaper.
+func addObjectExpiry(objAPI ObjectLayer, bucket, object string, metadata map[string]string) error {
+	expiresAt, ok := getObjectExpiry(metadata)
+	if !ok {
+		return nil
+	}
+	return writeObjectExpiryEntry(objAPI, bucket, objectExpiryEntry{Object: object, ExpiresAt: expiresAt}, expiresAt)
+}
+
+// expireObject - deletes the object of an entry of the expiry index if it
+// still expires at the date of the entry. Objects under retention are
+// not deleted, the time their retention ends is returned instead.
+func expireObject(objAPI ObjectLayer, bucket string, entry objectExpiryEntry, now time.Time) (time.Time, error) {
+	objectLock := globalNSMutex.NewNSLock(bucket, entry.Object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	objInfo, err := objAPI.GetObjectInfo(bucket, entry.Object)
+	if err != nil {
+		switch errorCause(err).(type) {
+		case ObjectNotFound, BucketNotFound:
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	// Object was overwritten since, without an expiry or with another one.
+	if expiresAt, ok := getObjectExpiry(objInfo.UserDefined); !ok || !expiresAt.Equal(entry.ExpiresAt) {
+		return time.Time{}, nil
+	}
+	if isObjectRetained(objInfo.UserDefined, false, now) {
+		retainUntil, _ := time.Parse(time.RFC3339, objInfo.UserDefined[amzObjectLockRetainUntilDate])
+		return retainUntil, nil
+	}
+	return time.Time{}, deleteObjectOrTrash(objAPI, bucket, entry.Object)
+}
+
+// reapObjectExpiryEntry - expires the object of an entry of the expiry
+// index, the objects under retention are indexed again at the time
+// their retention ends.
+func reapObjectExpiryEntry(objAPI ObjectLayer, bucket, entryPath string, now time.Time) error {
+	entry, err := readObjectExpiryEntry(objAPI, entryPath)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	retainUntil, err := expireObject(objAPI, bucket, entry, now)
+	if err != nil {
+		return err
+	}
+	if !retainUntil.IsZero() {
+		return writeObjectExpiryEntry(objAPI, bucket, entry, retainUntil)
+	}
+	return nil
+}
+
+// reapBucketExpiredObjects - deletes the objects of the bucket expired
+// at the given time, only the entries of the index already due are
+// read. The entries failing to be reaped are retried by the next reap.
+func reapBucketExpiredObjects(objAPI ObjectLayer, bucket string, now time.Time) error {
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(minioMetaBucket, getObjectExpiryPrefix(bucket), marker, "", maxObjectList)
+		if err != nil {
+			return errorCause(err)
+		}
+		for _, entryInfo := range result.Objects {
+			due, ok := parseObjectExpiryEntryDue(entryInfo.Name)
+			// Entries are listed in the order they are due.
+			if ok && due.After(now) {
+				return nil
+			}
+			if ok {
+				if err = reapObjectExpiryEntry(objAPI, bucket, entryInfo.Name, now); err != nil {
+					errorIf(err, "Unable to expire the objects of the bucket %s.", bucket)
+					continue
+				}
+			}
+			if err = objAPI.DeleteObject(minioMetaBucket, entryInfo.Name); err != nil && !isErrObjectNotFound(err) {
+				errorIf(err, "Unable to remove the expiry entry %s.", entryInfo.Name)
+			}
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// reapExpiredObjects - deletes the expired objects of all the buckets.
+func reapExpiredObjects(objAPI ObjectLayer, now time.Time) {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return
+	}
+	for _, bucket := range buckets {
+		err = reapBucketExpiredObjects(objAPI, bucket.Name, now)
+		errorIf(err, "Unable to delete the expired objects of the bucket %s.", bucket.Name)
+	}
+}
+
+// startObjectExpiryReaper - deletes the expired objects periodically
+// until the server stops.
+func startObjectExpiryReaper(objAPI func() ObjectLayer) {
+	go func() {
+		ticker := time.NewTicker(objectExpiryReapInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if objLayer := objAPI(); objLayer != nil {
+					reapExpiredObjects(objLayer, time.Now().UTC())
+				}
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+}
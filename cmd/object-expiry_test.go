@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests the objects uploaded with a time to live are deleted by the
+// reaper once expired, and only then.
+func TestObjectExpiry(t *testing.T) {
+	ExecObjectLayerAPITest(t, testObjectExpiry, []string{"PutObject", "HeadObject"})
+}
+
+func testObjectExpiry(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	putObject := func(object, ttl string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		data := []byte("data")
+		req, err := newTestRequest("PUT", getPutObjectURL("", bucketName, object), int64(len(data)), bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if ttl != "" {
+			req.Header.Set(objectTTLHeader, ttl)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Invalid time to live.
+	for i, ttl := range []string{"0", "-1", "1h", "99999999999"} {
+		if rec := putObject("invalid", ttl); rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+	}
+
+	before := time.Now().UTC().Truncate(time.Second)
+	if rec := putObject("expiring", "3600"); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec := putObject("overwritten", "3600"); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	// Overwritten without a time to live, the object never expires.
+	if rec := putObject("overwritten", ""); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+
+	// Expiry date is sent back on HEAD.
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("HEAD", getHeadObjectURL("", bucketName, "expiring"), 0, nil, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	expiresAt, err := http.ParseTime(rec.Header().Get(objectExpiresKey))
+	if err != nil {
+		t.Fatalf("%s: Unexpected expiry date %s", instanceType, err)
+	}
+	if expiresAt.Before(before.Add(time.Hour)) || expiresAt.After(time.Now().UTC().Add(time.Hour)) {
+		t.Fatalf("%s: Unexpected expiry date %s", instanceType, expiresAt)
+	}
+
+	// Not expired yet.
+	reapExpiredObjects(obj, expiresAt.Add(-time.Second))
+	if _, err = obj.GetObjectInfo(bucketName, "expiring"); err != nil {
+		t.Fatalf("%s: Expected the object to be kept, got %v", instanceType, err)
+	}
+
+	reapExpiredObjects(obj, expiresAt)
+	if _, err = obj.GetObjectInfo(bucketName, "expiring"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the object to be expired, got %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "overwritten"); err != nil {
+		t.Fatalf("%s: Expected the overwritten object to be kept, got %v", instanceType, err)
+	}
+
+	// Entries of the index are removed once reaped.
+	result, err := obj.ListObjects(minioMetaBucket, getObjectExpiryPrefix(bucketName), "", "", maxObjectList)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Objects) != 0 {
+		t.Fatalf("%s: Expected no expiry entries, got %d", instanceType, len(result.Objects))
+	}
+}
@@ -0,0 +1,382 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Minio extension to PUT Object, a POST on the bucket with the
+// `x-minio-fan-out` query param stores the request body under each of
+// the keys listed by the `key` query params.
+const (
+	fanOutQueryParam    = "x-minio-fan-out"
+	fanOutKeyQueryParam = "key"
+)
+
+// errFanOutFailed - no destination of the fan-out accepts data anymore.
+var errFanOutFailed = errors.New("All the fan-out destinations failed")
+
+// fanOutWriter - tees the data to multiple destinations, a destination
+// failing to accept the data is dropped while the data keeps being
+// written to the others.
+type fanOutWriter struct {
+	writers []io.Writer
+	errs    []error
+}
+
+func newFanOutWriter(writers []io.Writer) *fanOutWriter {
+	return &fanOutWriter{
+		writers: writers,
+		errs:    make([]error, len(writers)),
+	}
+}
+
+func (f *fanOutWriter) Write(p []byte) (int, error) {
+	active := false
+	for i, writer := range f.writers {
+		if f.errs[i] != nil {
+			continue
+		}
+		if _, err := writer.Write(p); err != nil {
+			f.errs[i] = err
+			continue
+		}
+		active = true
+	}
+	if !active {
+		return 0, errFanOutFailed
+	}
+	return len(p), nil
+}
+
+// getFanOutKeys - returns the keys of the fan-out without duplicates
+// in the order of the request.
+func getFanOutKeys(r *http.Request) ([]string, APIErrorCode) {
+	var keys []string
+	seen := make(map[string]struct{})
+	for _, key := range r.URL.Query()[fanOutKeyQueryParam] {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 || len(keys) > globalFanOutMaxKeys {
+		return nil, ErrInvalidFanOutKeys
+	}
+	return keys, ErrNone
+}
+
+// setFanOutMaxKeys - sets the maximum number of keys of a fan-out PUT
+// from MINIO_FAN_OUT_MAX_KEYS env.
+func setFanOutMaxKeys() {
+	if maxKeys := os.Getenv("MINIO_FAN_OUT_MAX_KEYS"); maxKeys != "" {
+		n, err := strconv.Atoi(maxKeys)
+		if err != nil || n <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_FAN_OUT_MAX_KEYS value %s.", maxKeys)
+		}
+		globalFanOutMaxKeys = n
+	}
+}
+
+// FanOutPutObjectHandler - POST /{bucket}?x-minio-fan-out&key={key}...
+// ----------
+// This implementation of the fan-out PUT stores the body of a single
+// upload as an object under each of the listed keys. The body is read
+// once and streamed to all the keys at the same time, the result of
+// each key is reported in the response, a failed key does not fail the
+// other ones.
+func (api objectAPIHandlers) FanOutPutObjectHandler(w http.ResponseWriter, r *http.Request) {
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	// Object data is read within the bandwidth limit.
+	defer throttleRequestBody(r)()
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	keys, s3Error := getFanOutKeys(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Get Content-Md5 sent by client and verify if valid
+	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
+	if err != nil {
+		errorIf(err, "Unable to validate content-md5 format.")
+		writeErrorResponse(w, ErrInvalidDigest, r.URL)
+		return
+	}
+
+	/// if Content-Length is unknown/missing, deny the request
+	size := r.ContentLength
+	rAuthType := getRequestAuthType(r)
+	if rAuthType == authTypeStreamingSigned {
+		sizeStr := r.Header.Get("x-amz-decoded-content-length")
+		size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			errorIf(err, "Unable to parse `x-amz-decoded-content-length` %s into its integer value", sizeStr)
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+	if size == -1 {
+		writeErrorResponse(w, ErrMissingContentLength, r.URL)
+		return
+	}
+
+	/// maximum Upload size for objects in a single operation
+	if isMaxObjectSize(size) {
+		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
+		return
+	}
+
+	// Website redirect location must be a path or a http(s) URL.
+	if location := r.Header.Get(websiteRedirectLocationKey); location != "" && !isValidWebsiteRedirectLocation(location) {
+		writeErrorResponse(w, ErrInvalidRedirectLocation, r.URL)
+		return
+	}
+
+	// Object ACLs are rejected if disabled on the bucket.
+	if err = checkObjectACLAllowed(bucket, r.Header.Get(amzACL)); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Fan-out writes are not encrypted.
+	if hasSSECustomerHeaders(r.Header) {
+		writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+		return
+	}
+
+	// The same content can not be stored under several content addresses.
+	if globalBucketContentAddressing.IsEnabled(bucket) {
+		writeErrorResponse(w, ErrContentAddressingNotSupported, r.URL)
+		return
+	}
+
+	// Response headers of the objects must be allowed.
+	if s3Error := extractObjectResponseHeaders(r.Header, make(map[string]string)); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Retention of the objects, requested or inherited from the
+	// default retention of the bucket.
+	retention := make(map[string]string)
+	if err = setObjectRetentionMetadata(bucket, r.Header, retention); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err = checkBucketExist(bucket, objectAPI); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Errors of the keys rejected before the upload. Objects must be
+	// within the size limits of the bucket, objects stored purely as
+	// website redirects are exempted from the minimum size.
+	redirectObject := isWebsiteRedirectObject(r.Header, size)
+	keyErrs := make([]APIErrorCode, len(keys))
+	for i, key := range keys {
+		if !IsValidObjectName(key) {
+			keyErrs[i] = ErrInvalidObjectName
+			continue
+		}
+		if redirectObject {
+			continue
+		}
+		if lErr := checkObjectSizeLimits(bucket, size); lErr != nil {
+			keyErrs[i] = toAPIErrorCode(lErr)
+		}
+	}
+
+	var reader io.Reader = r.Body
+	sha256sum := ""
+	switch rAuthType {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, ErrAccessDenied, r.URL)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		for i, key := range keys {
+			if keyErrs[i] != ErrNone {
+				continue
+			}
+			keyErrs[i] = enforceBucketPolicy(bucket, "s3:PutObject", slashSeparator+bucket+slashSeparator+key,
+				r.Referer(), r.URL.Query())
+		}
+	case authTypeClientCert:
+		if s3Error = isReqAuthenticatedByCert(r, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	case authTypeStreamingSigned:
+		// Initialize stream signature verifier.
+		reader, s3Error = newSignV4ChunkedReader(r)
+		if s3Error != ErrNone {
+			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	case authTypeSignedV2, authTypePresignedV2:
+		if s3Error = isReqAuthenticatedV2(r); s3Error != ErrNone {
+			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error = reqSignatureV4Verify(r); s3Error != ErrNone {
+			errorIf(errSignatureMismatch, "%s", dumpRequest(r))
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+		if !skipContentSha256Cksum(r) {
+			sha256sum = r.Header.Get("X-Amz-Content-Sha256")
+		}
+	}
+
+	// Lock all the objects, in sorted order so that concurrent fan-outs
+	// over the same keys cannot deadlock.
+	var lockKeys []string
+	for i, key := range keys {
+		if keyErrs[i] == ErrNone {
+			lockKeys = append(lockKeys, key)
+		}
+	}
+	sort.Strings(lockKeys)
+	for _, key := range lockKeys {
+		objectLock := globalNSMutex.NewNSLock(bucket, key)
+		objectLock.Lock()
+		defer objectLock.Unlock()
+	}
+
+	// Objects under retention can not be overwritten, they fail alone.
+	for i, key := range keys {
+		if keyErrs[i] != ErrNone {
+			continue
+		}
+		if rErr := enforceObjectRetention(objectAPI, bucket, key, r); rErr != nil {
+			keyErrs[i] = toAPIErrorCode(rErr)
+		}
+	}
+
+	// Each key is uploaded from its own pipe, fed by the fan-out writer.
+	objInfos := make([]ObjectInfo, len(keys))
+	errs := make([]error, len(keys))
+	var pipeWriters []*io.PipeWriter
+	var writers []io.Writer
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		if keyErrs[i] != ErrNone {
+			continue
+		}
+		pipeReader, pipeWriter := io.Pipe()
+		pipeWriters = append(pipeWriters, pipeWriter)
+		writers = append(writers, pipeWriter)
+
+		// Extract metadata to be saved from incoming HTTP header.
+		metadata := extractMetadataFromHeader(r.Header)
+		// Make sure we hex encode md5sum here.
+		metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+		// Validated above.
+		extractObjectResponseHeaders(r.Header, metadata)
+		for k, v := range retention {
+			metadata[k] = v
+		}
+
+		wg.Add(1)
+		go func(i int, key string, metadata map[string]string) {
+			defer wg.Done()
+			var objReader io.Reader = pipeReader
+			// Enforce the object size limits of the bucket as the
+			// data is read, the key fails as soon as they are exceeded.
+			if !redirectObject {
+				objReader = newObjectSizeLimitsReader(bucket, objReader, false)
+			}
+			if isContentSniffingRequired(bucket, key, r.Header, size) {
+				objReader, errs[i] = sniffContentType(objReader, size, metadata)
+			}
+			if errs[i] == nil {
+				objInfos[i], errs[i] = objectAPI.PutObject(bucket, key, size, objReader, metadata, sha256sum)
+			}
+			// Unblock the fan-out writer if the upload stopped early.
+			pipeReader.CloseWithError(errFanOutFailed)
+		}(i, key, metadata)
+	}
+
+	_, err = io.Copy(newFanOutWriter(writers), reader)
+	if err == errFanOutFailed {
+		err = nil
+	}
+	for _, pipeWriter := range pipeWriters {
+		pipeWriter.CloseWithError(err)
+	}
+	wg.Wait()
+
+	// Collect the results of all the keys.
+	response := FanOutResponse{}
+	for i, key := range keys {
+		result := FanOutResult{Key: key}
+		if keyErrs[i] == ErrNone && errs[i] != nil {
+			errorIf(errs[i], "Unable to create the fan-out object %s.", key)
+			keyErrs[i] = toAPIErrorCode(errs[i])
+		}
+		if keyErrs[i] != ErrNone {
+			result.Error = &FanOutError{
+				Code:    errorCodeResponse[keyErrs[i]].Code,
+				Message: errorCodeResponse[keyErrs[i]].Description,
+			}
+		} else {
+			result.ETag = "\"" + objInfos[i].MD5Sum + "\""
+		}
+		response.Results = append(response.Results, result)
+	}
+	writeSuccessResponseXML(w, encodeResponse(response))
+
+	// Notify object created event of the stored objects.
+	for i := range keys {
+		if keyErrs[i] != ErrNone {
+			continue
+		}
+		eventNotify(eventData{
+			Type:    ObjectCreatedPut,
+			Bucket:  bucket,
+			ObjInfo: objInfos[i],
+			ReqParams: map[string]string{
+				"sourceIPAddress": r.RemoteAddr,
+			},
+		})
+	}
+}
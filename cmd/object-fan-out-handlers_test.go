@@ -0,0 +1,329 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests that a destination failing midway is dropped while the data
+// keeps being written to the other destinations.
+func TestFanOutWriter(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 64*1024)
+
+	var readers []*io.PipeReader
+	var writers []io.Writer
+	for i := 0; i < 3; i++ {
+		pipeReader, pipeWriter := io.Pipe()
+		readers = append(readers, pipeReader)
+		writers = append(writers, pipeWriter)
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]byte, len(readers))
+	for i, pipeReader := range readers {
+		wg.Add(1)
+		go func(i int, pipeReader *io.PipeReader) {
+			defer wg.Done()
+			if i == 1 {
+				// Second destination fails after the first chunk.
+				buf := make([]byte, 1024)
+				io.ReadFull(pipeReader, buf)
+				pipeReader.CloseWithError(errFaultyDisk)
+				return
+			}
+			results[i], _ = ioutil.ReadAll(pipeReader)
+		}(i, pipeReader)
+	}
+
+	fanOut := newFanOutWriter(writers)
+	for offset := 0; offset < len(data); offset += 1024 {
+		if _, err := fanOut.Write(data[offset : offset+1024]); err != nil {
+			t.Fatalf("Unexpected error %s", err)
+		}
+	}
+	for _, writer := range writers {
+		writer.(*io.PipeWriter).Close()
+	}
+	wg.Wait()
+
+	if fanOut.errs[0] != nil || fanOut.errs[2] != nil {
+		t.Fatalf("Unexpected errors %v", fanOut.errs)
+	}
+	if fanOut.errs[1] != errFaultyDisk {
+		t.Fatalf("Expected the error %s, got %v", errFaultyDisk, fanOut.errs[1])
+	}
+	if !bytes.Equal(results[0], data) || !bytes.Equal(results[2], data) {
+		t.Fatal("Data of the destinations does not match")
+	}
+
+	// Writes fail once all the destinations failed.
+	failed := newFanOutWriter([]io.Writer{writers[1]})
+	if _, err := failed.Write(data); err != errFanOutFailed {
+		t.Fatalf("Expected the error %s, got %v", errFanOutFailed, err)
+	}
+}
+
+// Tests the fan-out PUT of an upload to multiple keys with the
+// failures of some of the keys reported per key.
+func TestFanOutPutObjectHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testFanOutPutObjectHandler, []string{"FanOutPutObject", "GetObject"})
+}
+
+func testFanOutPutObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	data := bytes.Repeat([]byte("fan-out"), 10*1024)
+	sum := md5.Sum(data)
+	etag := "\"" + hex.EncodeToString(sum[:]) + "\""
+
+	serveRequest := func(urlStr string, body []byte, signed bool) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest("POST", urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		if signed {
+			if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+				t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+			}
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Keys are required and limited.
+	if rec := serveRequest(getFanOutPutObjectURL("", bucketName, nil), data, true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+	defer func(maxKeys int) {
+		globalFanOutMaxKeys = maxKeys
+	}(globalFanOutMaxKeys)
+	globalFanOutMaxKeys = 2
+	if rec := serveRequest(getFanOutPutObjectURL("", bucketName, []string{"a", "b", "c"}), data, true); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+	globalFanOutMaxKeys = 100
+
+	// Missing bucket fails the whole request.
+	if rec := serveRequest(getFanOutPutObjectURL("", "missing-bucket", []string{"a"}), data, true); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Anonymous uploads are allowed only under the public prefix.
+	policy := bucketPolicy{
+		Version:    "1.0",
+		Statements: []policyStatement{getWriteOnlyObjectStatement(bucketName, "public/")},
+	}
+	globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{false, &policy})
+	defer globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{true, nil})
+
+	type keyResult struct {
+		key  string
+		code string
+	}
+	testCases := []struct {
+		keys     []string
+		signed   bool
+		expected []keyResult
+	}{
+		// Test case - 1.
+		// Upload is stored under all the keys, duplicate keys are
+		// stored once.
+		{
+			keys:     []string{"dest/1", "dest/2", "dest/3", "dest/1"},
+			signed:   true,
+			expected: []keyResult{{"dest/1", ""}, {"dest/2", ""}, {"dest/3", ""}},
+		},
+		// Test case - 2.
+		// Invalid key fails alone.
+		{
+			keys:     []string{"partial/1", "partial/", "partial/2"},
+			signed:   true,
+			expected: []keyResult{{"partial/1", ""}, {"partial/", "XMinioInvalidObjectName"}, {"partial/2", ""}},
+		},
+		// Test case - 3.
+		// Keys denied by the bucket policy fail alone.
+		{
+			keys:     []string{"public/1", "private/1", "public/2"},
+			signed:   false,
+			expected: []keyResult{{"public/1", ""}, {"private/1", "AccessDenied"}, {"public/2", ""}},
+		},
+	}
+	for i, testCase := range testCases {
+		rec := serveRequest(getFanOutPutObjectURL("", bucketName, testCase.keys), data, testCase.signed)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		var response FanOutResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: Test %d: Unexpected XML received %s", instanceType, i+1, err)
+		}
+		if len(response.Results) != len(testCase.expected) {
+			t.Fatalf("%s: Test %d: Expected %d results, got %#v", instanceType, i+1, len(testCase.expected), response.Results)
+		}
+		for j, expected := range testCase.expected {
+			result := response.Results[j]
+			if result.Key != expected.key {
+				t.Fatalf("%s: Test %d: Expected key %s, got %s", instanceType, i+1, expected.key, result.Key)
+			}
+			if expected.code != "" {
+				if result.Error == nil || result.Error.Code != expected.code || result.ETag != "" {
+					t.Fatalf("%s: Test %d: Expected key %s to fail with %s, got %#v", instanceType, i+1, expected.key, expected.code, result)
+				}
+				if _, err := obj.GetObjectInfo(bucketName, expected.key); err == nil {
+					t.Fatalf("%s: Test %d: Expected key %s not to be stored", instanceType, i+1, expected.key)
+				}
+				continue
+			}
+			if result.Error != nil || result.ETag != etag {
+				t.Fatalf("%s: Test %d: Expected key %s to be stored, got %#v", instanceType, i+1, expected.key, result)
+			}
+			var buffer bytes.Buffer
+			if err := obj.GetObject(bucketName, expected.key, 0, int64(len(data)), &buffer); err != nil {
+				t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+			}
+			if !bytes.Equal(buffer.Bytes(), data) {
+				t.Fatalf("%s: Test %d: Data of key %s does not match", instanceType, i+1, expected.key)
+			}
+		}
+	}
+}
+
+// Tests that the fan-out keys under retention are not overwritten and
+// that the new objects inherit the default retention of the bucket.
+func TestFanOutPutObjectRetention(t *testing.T) {
+	ExecObjectLayerAPITest(t, testFanOutPutObjectRetention, []string{"FanOutPutObject"})
+}
+
+func testFanOutPutObjectRetention(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	globalBucketObjectLock.Set(bucketName, &ObjectLockConfiguration{
+		ObjectLockEnabled: "Enabled",
+		Rule:              &ObjectLockRule{DefaultRetention: DefaultRetention{Mode: retentionModeGovernance, Days: 1}},
+	})
+	defer globalBucketObjectLock.Set(bucketName, nil)
+
+	retained := []byte("retained")
+	metadata := map[string]string{
+		amzObjectLockMode:            retentionModeCompliance,
+		amzObjectLockRetainUntilDate: time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+	}
+	if _, err := obj.PutObject(bucketName, "retained", int64(len(retained)), bytes.NewReader(retained), metadata, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	data := []byte("fan-out")
+	rec := httptest.NewRecorder()
+	req, err := newTestSignedRequestV4("POST", getFanOutPutObjectURL("", bucketName, []string{"retained", "new"}),
+		int64(len(data)), bytes.NewReader(data), credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var response FanOutResponse
+	if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if len(response.Results) != 2 || response.Results[0].Error == nil ||
+		response.Results[0].Error.Code != "AccessDenied" || response.Results[1].Error != nil {
+		t.Fatalf("%s: Unexpected results %#v", instanceType, response.Results)
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucketName, "retained", 0, int64(len(retained)), &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), retained) {
+		t.Fatalf("%s: Expected the object under retention to be kept, got %q", instanceType, buffer.Bytes())
+	}
+	objInfo, err := obj.GetObjectInfo(bucketName, "new")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.UserDefined[amzObjectLockMode] != retentionModeGovernance || objInfo.UserDefined[amzObjectLockRetainUntilDate] == "" {
+		t.Fatalf("%s: Expected the default retention of the bucket, got %#v", instanceType, objInfo.UserDefined)
+	}
+}
+
+// Tests that the fan-out keys are rejected out of the object size
+// limits of the bucket.
+func TestFanOutPutObjectSizeLimits(t *testing.T) {
+	ExecObjectLayerAPITest(t, testFanOutPutObjectSizeLimits, []string{"FanOutPutObject"})
+}
+
+func testFanOutPutObjectSizeLimits(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	globalBucketObjectSizeLimits.Set(bucketName, &ObjectSizeLimitsConfiguration{MinSize: 10, MaxSize: 100})
+	defer globalBucketObjectSizeLimits.Set(bucketName, nil)
+
+	testCases := []struct {
+		data         []byte
+		expectedCode string
+	}{
+		{bytes.Repeat([]byte("a"), 5), "EntityTooSmall"},
+		{bytes.Repeat([]byte("a"), 200), "EntityTooLarge"},
+		{bytes.Repeat([]byte("a"), 50), ""},
+	}
+	for i, testCase := range testCases {
+		keys := []string{fmt.Sprintf("object-%d-a", i), fmt.Sprintf("object-%d-b", i)}
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("POST", getFanOutPutObjectURL("", bucketName, keys),
+			int64(len(testCase.data)), bytes.NewReader(testCase.data), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		var response FanOutResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+		}
+		if len(response.Results) != len(keys) {
+			t.Fatalf("%s: Test %d: Unexpected results %#v", instanceType, i+1, response.Results)
+		}
+		for j, result := range response.Results {
+			if testCase.expectedCode == "" {
+				if result.Error != nil {
+					t.Fatalf("%s: Test %d: Expected key %s to be stored, got %#v", instanceType, i+1, keys[j], result.Error)
+				}
+				continue
+			}
+			if result.Error == nil || result.Error.Code != testCase.expectedCode {
+				t.Fatalf("%s: Test %d: Expected error %s for key %s, got %#v", instanceType, i+1, testCase.expectedCode, keys[j], result)
+			}
+			if _, err = obj.GetObjectInfo(bucketName, keys[j]); err == nil {
+				t.Fatalf("%s: Test %d: Expected the rejected key %s not to be stored", instanceType, i+1, keys[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// errClientDisconnected - the client went away before the response was
+// written in full.
+var errClientDisconnected = errors.New("Client disconnected")
+
+// setGetCancelOnDisconnect - cancels the reads of the objects whose
+// client disconnected unless MINIO_GET_CANCEL_ON_DISCONNECT env is set
+// to "off".
+func setGetCancelOnDisconnect() {
+	if cancel := os.Getenv("MINIO_GET_CANCEL_ON_DISCONNECT"); cancel != "" {
+		switch strings.ToLower(cancel) {
+		case "on":
+			globalIsGetCancelOnDisconnect = true
+		case "off":
+			globalIsGetCancelOnDisconnect = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_GET_CANCEL_ON_DISCONNECT value %s.", cancel)
+		}
+	}
+}
+
+// cancelableWriter - fails the writes once its context is done. The
+// object layers read the objects by blocks and write each block before
+// reading the next, a failed write ends the read.
+type cancelableWriter struct {
+	io.Writer
+	ctx context.Context
+}
+
+func (w cancelableWriter) Write(p []byte) (int, error) {
+	select {
+	case <-w.ctx.Done():
+		return 0, errClientDisconnected
+	default:
+	}
+	return w.Writer.Write(p)
+}
+
+// cancelOnDisconnect - returns a writer failing the writes of the
+// object data once the client of the request disconnected, so that the
+// object is not read to completion for nobody.
+func cancelOnDisconnect(w io.Writer, r *http.Request) io.Writer {
+	if !globalIsGetCancelOnDisconnect {
+		return w
+	}
+	return cancelableWriter{Writer: w, ctx: r.Context()}
+}
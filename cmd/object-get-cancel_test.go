@@ -0,0 +1,86 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// disconnectingRecorder - records the response, its client
+// disconnecting once the first bytes of the object are received.
+type disconnectingRecorder struct {
+	*httptest.ResponseRecorder
+	disconnect context.CancelFunc
+}
+
+func (r *disconnectingRecorder) Write(p []byte) (int, error) {
+	n, err := r.ResponseRecorder.Write(p)
+	if len(p) > 0 {
+		r.disconnect()
+	}
+	return n, err
+}
+
+// Tests the read of an object stops once its client disconnected
+// mid-download, unless disabled.
+func TestGetObjectCancelOnDisconnect(t *testing.T) {
+	ExecObjectLayerAPITest(t, testGetObjectCancelOnDisconnect, []string{"GetObject"})
+}
+
+func testGetObjectCancelOnDisconnect(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func() { globalIsGetCancelOnDisconnect = true }()
+
+	// The object spans several blocks of both object layers.
+	size := 3 * blockSizeV1
+	data := bytes.Repeat([]byte("a"), size)
+	objectName := "large"
+	if _, err := obj.PutObject(bucketName, objectName, int64(size), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	download := func() int {
+		req, err := newTestSignedRequestV4("GET", getGetObjectURL("", bucketName, objectName), 0, nil,
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		ctx, cancel := context.WithCancel(req.Context())
+		defer cancel()
+		rec := &disconnectingRecorder{ResponseRecorder: httptest.NewRecorder(), disconnect: cancel}
+		apiRouter.ServeHTTP(rec, req.WithContext(ctx))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+		return rec.Body.Len()
+	}
+
+	// Only the block being written when the client disconnected is read.
+	if n := download(); n == 0 || n > blockSizeV1 {
+		t.Fatalf("%s: Expected the read to stop after the first block, got %d bytes of %d", instanceType, n, size)
+	}
+
+	// The object is read to completion once disabled.
+	globalIsGetCancelOnDisconnect = false
+	if n := download(); n != size {
+		t.Fatalf("%s: Expected the whole object to be read, got %d bytes of %d", instanceType, n, size)
+	}
+}
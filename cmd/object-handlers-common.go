@@ -56,7 +56,7 @@ func checkCopyObjectPreconditions(w http.ResponseWriter, r *http.Request, objInf
 		setCommonHeaders(w)
 
 		// set object-related metadata headers
-		w.Header().Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", formatLastModifiedHeader(objInfo.ModTime))
 
 		if objInfo.MD5Sum != "" {
 			w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
@@ -137,7 +137,7 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, objInfo ObjectIn
 		setCommonHeaders(w)
 
 		// set object-related metadata headers
-		w.Header().Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Last-Modified", formatLastModifiedHeader(objInfo.ModTime))
 
 		if objInfo.MD5Sum != "" {
 			w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
@@ -194,18 +194,24 @@ func checkPreconditions(w http.ResponseWriter, r *http.Request, objInfo ObjectIn
 	return false
 }
 
+// getDeleteIfMatchETag - returns the ETag a DELETE request is
+// conditioned on, set either by If-Match or x-amz-if-match header.
+func getDeleteIfMatchETag(r *http.Request) string {
+	if ifMatchETag := r.Header.Get("If-Match"); ifMatchETag != "" {
+		return ifMatchETag
+	}
+	return r.Header.Get("X-Amz-If-Match")
+}
+
 // returns true if object was modified after givenTime.
 func ifModifiedSince(objTime time.Time, givenTimeStr string) bool {
 	givenTime, err := time.Parse(http.TimeFormat, givenTimeStr)
 	if err != nil {
 		return true
 	}
-	// The Date-Modified header truncates sub-second precision, so
-	// use mtime < t+1s instead of mtime <= t to check for unmodified.
-	if objTime.After(givenTime.Add(1 * time.Second)) {
-		return true
-	}
-	return false
+	// The Last-Modified header truncates sub-second precision, so
+	// compare the given time with the truncated object time.
+	return objTime.UTC().Truncate(time.Second).After(givenTime)
 }
 
 // canonicalizeETag returns ETag with leading and trailing double-quotes removed,
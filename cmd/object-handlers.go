@@ -17,15 +17,14 @@
 package cmd
 
 import (
+	"compress/gzip"
 	"encoding/hex"
-	"encoding/xml"
-	"io/ioutil"
+	"io"
 	"net/http"
 	"net/url"
-	"path"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	mux "github.com/gorilla/mux"
 )
@@ -49,6 +48,28 @@ func setGetRespHeaders(w http.ResponseWriter, reqParams url.Values) {
 	}
 }
 
+// setGetObjectHeaders - sets the headers of the GET responses of the
+// object, the HEAD responses have the very same headers. The status of
+// the ranged responses is written as well.
+func setGetObjectHeaders(w http.ResponseWriter, r *http.Request, objInfo ObjectInfo, hrange *httpRange, compressed bool) {
+	// Set standard object headers.
+	setObjectHeaders(w, objInfo, hrange)
+
+	// Set any additional requested response headers.
+	setGetRespHeaders(w, r.URL.Query())
+
+	// Length of the compressed data is not known upfront.
+	if compressed {
+		w.Header().Del("Content-Length")
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+
+	if hrange != nil && hrange.offsetBegin > -1 {
+		w.WriteHeader(http.StatusPartialContent)
+	}
+}
+
 // errAllowableNotFound - For an anon user, return 404 if have ListBucket, 403 otherwise
 // this is in keeping with the permissions sections of the docs of both:
 //   HEAD Object: http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectHEAD.html
@@ -110,6 +131,14 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Encrypted objects are only read with their customer provided key,
+	// the ranges apply to the plaintext.
+	objectKey, s3Error := getObjectDecryptionKey(r.Header, &objInfo)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("Range")
@@ -117,7 +146,9 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		if hrange, err = parseRequestRange(rangeHeader, objInfo.Size); err != nil {
 			// Handle only errInvalidRange
 			// Ignore other parse error and treat it as regular Get request like Amazon S3.
-			if err == errInvalidRange {
+			// Unsatisfiable ranges of empty objects are ignored as well if configured so.
+			if err == errInvalidRange && (objInfo.Size > 0 || globalEmptyObjectRangeStatus != http.StatusOK) {
+				w.Header().Set("Content-Range", unsatisfiedContentRange(objInfo.Size))
 				writeErrorResponse(w, ErrInvalidRange, r.URL)
 				return
 			}
@@ -139,26 +170,44 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		startOffset = hrange.offsetBegin
 		length = hrange.getLength()
 	}
+	// Object data is written within the bandwidth limit.
+	dataWriter, releaseThrottle := throttleResponseWriter(w, r)
+	defer releaseThrottle()
+	// Object data is gzip compressed on the wire if accepted by the
+	// client, the stored object and its ETag are unchanged.
+	var gzipWriter *gzip.Writer
+	if isObjectCompressionRequired(r, objInfo, hrange) {
+		gzipWriter = gzip.NewWriter(dataWriter)
+		dataWriter = gzipWriter
+	}
 	// Indicates if any data was written to the http.ResponseWriter
 	dataWritten := false
 	// io.Writer type which keeps track if any data was written.
 	writer := funcToWriter(func(p []byte) (int, error) {
 		if !dataWritten {
 			// Set headers on the first write.
-			// Set standard object headers.
-			setObjectHeaders(w, objInfo, hrange)
-
-			// Set any additional requested response headers.
-			setGetRespHeaders(w, r.URL.Query())
-
+			if objectKey != nil {
+				setSSECustomerResponseHeaders(w, r.Header)
+			}
+			setGetObjectHeaders(w, r, objInfo, hrange, gzipWriter != nil)
 			dataWritten = true
 		}
-		return w.Write(p)
+		return dataWriter.Write(p)
 	})
 
-	// Reads the object at startOffset and writes to mw.
-	if err := objectAPI.GetObject(bucket, object, startOffset, length, writer); err != nil {
-		errorIf(err, "Unable to write to client.")
+	// Encrypted objects are read only from the blocks covering the
+	// range.
+	getObject := objectAPI.GetObject
+	if objectKey != nil {
+		getObject = newSSEObjectReader(objectAPI, objectKey, objInfo.Size).GetObject
+	}
+
+	// Reads the object at startOffset and writes to mw, the read stops
+	// if the client disconnects.
+	if err := getObject(bucket, object, startOffset, length, cancelOnDisconnect(writer, r)); err != nil {
+		if errorCause(err) != errClientDisconnected {
+			errorIf(err, "Unable to write to client.")
+		}
 		if !dataWritten {
 			// Error response only if no data has been written to client yet. i.e if
 			// partial data has already been written before an error
@@ -174,6 +223,10 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		// call wrter.Write(nil) to set appropriate headers.
 		writer.Write(nil)
 	}
+	if gzipWriter != nil {
+		// Flush the remaining compressed data.
+		errorIf(gzipWriter.Close(), "Unable to write to client.")
+	}
 }
 
 // HeadObjectHandler - HEAD Object
@@ -212,13 +265,25 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Encrypted objects require their customer provided key.
+	objectKey, s3Error := getObjectDecryptionKey(r.Header, &objInfo)
+	if s3Error != ErrNone {
+		writeErrorResponseHeadersOnly(w, s3Error)
+		return
+	}
+
 	// Validate pre-conditions if any.
 	if checkPreconditions(w, r, objInfo) {
 		return
 	}
 
-	// Set standard object headers.
-	setObjectHeaders(w, objInfo, nil)
+	if objectKey != nil {
+		setSSECustomerResponseHeaders(w, r.Header)
+	}
+
+	// Set the headers of the GET response, without the body. The range
+	// is ignored.
+	setGetObjectHeaders(w, r, objInfo, nil, isObjectCompressionRequired(r, objInfo, nil))
 
 	// Successful response.
 	w.WriteHeader(http.StatusOK)
@@ -251,7 +316,6 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	dstBucket := vars["bucket"]
 	dstObject := vars["object"]
-	cpDestPath := "/" + path.Join(dstBucket, dstObject)
 
 	objectAPI := api.ObjectAPI()
 	if objectAPI == nil {
@@ -266,17 +330,10 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	// TODO: Reject requests where body/payload is present, for now we don't even read it.
 
-	// Copy source path.
-	cpSrcPath, err := url.QueryUnescape(r.Header.Get("X-Amz-Copy-Source"))
-	if err != nil {
-		// Save unescaped string as is.
-		cpSrcPath = r.Header.Get("X-Amz-Copy-Source")
-	}
-
-	srcBucket, srcObject := path2BucketAndObject(cpSrcPath)
-	// If source object is empty or bucket is empty, reply back invalid copy source.
-	if srcObject == "" || srcBucket == "" {
-		writeErrorResponse(w, ErrInvalidCopySource, r.URL)
+	// Copy source bucket and object.
+	srcBucket, srcObject, s3Error := getCopySource(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 
@@ -286,7 +343,13 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	cpSrcDstSame := cpSrcPath == cpDestPath
+	checksumAlgorithm, s3Error := getChecksumAlgorithm(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	cpSrcDstSame := srcBucket == dstBucket && srcObject == dstObject
 	// Hold write lock on destination since in both cases
 	// - if source and destination are same
 	// - if source and destination are different
@@ -314,6 +377,30 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Encrypted sources are decrypted with the copy source customer key,
+	// the size of the source is its plaintext size from here on.
+	srcObjectKey, s3Error := getCopySourceDecryptionKey(r.Header, &objInfo)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Encrypt the copy with the customer provided key, if any.
+	sseKey, s3Error := parseSSECustomerKey(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Copies from or to an encrypted object rewrite the data, which is
+	// not done in place since the FS backend holds the lock of the
+	// object metadata while it is written.
+	reencrypt := srcObjectKey != nil || sseKey != nil
+	if reencrypt && cpSrcDstSame {
+		writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+		return
+	}
+
 	// Verify before x-amz-copy-source preconditions before continuing with CopyObject.
 	if checkCopyObjectPreconditions(w, r, objInfo) {
 		return
@@ -325,6 +412,12 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Object size must be within the limits of the destination bucket, if any.
+	if err = checkObjectSizeLimits(dstBucket, objInfo.Size); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	defaultMeta := objInfo.UserDefined
 
 	// Make sure to remove saved md5sum, object might have been uploaded
@@ -332,7 +425,37 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 	// CopyObject calculate a new one.
 	delete(defaultMeta, "md5Sum")
 
+	// Copies are new objects, replication status and retention of
+	// the source do not apply to them.
+	delete(defaultMeta, replicationStatusKey)
+	removeObjectRetentionMetadata(defaultMeta)
+
+	// Object ACLs are rejected if disabled on the bucket.
+	if err = checkObjectACLAllowed(dstBucket, r.Header.Get(amzACL)); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	newMetadata := getCpObjMetadataFromHeader(r.Header, defaultMeta)
+	if location := newMetadata[websiteRedirectLocationKey]; location != "" && !isValidWebsiteRedirectLocation(location) {
+		writeErrorResponse(w, ErrInvalidRedirectLocation, r.URL)
+		return
+	}
+	// Replaced metadata carries the response headers of the request.
+	if isMetadataReplace(r.Header) {
+		if s3Error := extractObjectResponseHeaders(r.Header, newMetadata); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+	}
+	if err = setObjectRetentionMetadata(dstBucket, r.Header, newMetadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	if err = setObjectExpiryMetadata(r.Header, newMetadata, time.Now().UTC()); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
 	// Check if x-amz-metadata-directive was not set to REPLACE and source,
 	// desination are same objects.
 	if !isMetadataReplace(r.Header) && cpSrcDstSame {
@@ -342,16 +465,111 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// The copied data is the data of the source, the checksum stored
+	// with the source for the requested algorithm is kept as is.
+	var checksum string
+	if checksumAlgorithm != "" {
+		checksum = objInfo.UserDefined[checksumMetadataKey(checksumAlgorithm)]
+	}
+	if cpSrcDstSame {
+		// Metadata only updates do not rewrite the object, the stored
+		// content is read to compute its ETag and the requested
+		// checksum only if they are not stored with the object, so
+		// that the ETag always reflects the actual bytes.
+		md5Hex := objInfo.MD5Sum
+		algorithm := ""
+		if checksumAlgorithm != "" && checksum == "" {
+			algorithm = checksumAlgorithm
+		}
+		if !isContentETag(md5Hex) || algorithm != "" {
+			var computed string
+			md5Hex, computed, err = getObjectChecksums(objectAPI, srcBucket, srcObject, objInfo.Size, algorithm)
+			if err != nil {
+				errorIf(err, "Unable to compute checksum of %s/%s.", srcBucket, srcObject)
+				writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+				return
+			}
+			if algorithm != "" {
+				checksum = computed
+			}
+		}
+		newMetadata["md5Sum"] = md5Hex
+	}
+	if checksum != "" {
+		setChecksumMetadata(newMetadata, checksumAlgorithm, checksum)
+	}
+
+	// Copies into content-addressed buckets are named after the SHA256
+	// of the source, which is only read again if its content is not
+	// already verified against its key. Encrypted content is never
+	// verified against its key.
+	if globalBucketContentAddressing.IsEnabled(dstBucket) {
+		if reencrypt {
+			writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+			return
+		}
+		if err = checkContentAddressedCopy(objectAPI, srcBucket, srcObject, dstBucket, dstObject, objInfo.Size); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		if checksumAlgorithm == "" {
+			setContentAddressMetadata(newMetadata, dstObject)
+		}
+	}
+
+	// Create the destination bucket on its first write, if enabled.
+	if err = autoCreateBucket(objectAPI, dstBucket, getRequestAuthType(r)); err != nil {
+		errorIf(err, "Unable to create the bucket %s.", dstBucket)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Destination under retention can not be overwritten.
+	if err = enforceObjectRetention(objectAPI, dstBucket, dstObject, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	if err = addObjectExpiry(objectAPI, dstBucket, dstObject, newMetadata); err != nil {
+		errorIf(err, "Unable to index the expiry of %s/%s.", dstBucket, dstObject)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Copy source object to destination, if source and destination
-	// object is same then only metadata is updated.
-	objInfo, err = objectAPI.CopyObject(srcBucket, srcObject, dstBucket, dstObject, newMetadata)
+	// object is same then only metadata is updated. The requested
+	// checksum not stored with the source is computed over the copied
+	// data. Encrypted copies are decrypted and encrypted again with the
+	// keys of the request.
+	algorithm := ""
+	if checksumAlgorithm != "" && checksum == "" {
+		algorithm = checksumAlgorithm
+	}
+	if reencrypt {
+		var computed string
+		objInfo, computed, err = copyEncryptedObject(objectAPI, srcBucket, srcObject, dstBucket, dstObject,
+			objInfo.Size, srcObjectKey, sseKey, newMetadata, algorithm)
+		if algorithm != "" {
+			checksum = computed
+		}
+	} else if algorithm != "" {
+		objInfo, checksum, err = copyObjectWithChecksum(objectAPI, srcBucket, srcObject, dstBucket, dstObject,
+			objInfo.Size, newMetadata, checksumAlgorithm)
+	} else {
+		objInfo, err = objectAPI.CopyObject(srcBucket, srcObject, dstBucket, dstObject, newMetadata)
+	}
 	if err != nil {
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
 
+	if sseKey != nil {
+		setSSECustomerResponseHeaders(w, r.Header)
+	}
+
 	md5Sum := objInfo.MD5Sum
 	response := generateCopyObjectResponse(md5Sum, objInfo.ModTime)
+	setCopyObjectResponseChecksum(&response, checksumAlgorithm, checksum)
 	encodedSuccessResponse := encodeResponse(response)
 
 	// Write success response.
@@ -384,6 +602,9 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Object data is read within the bandwidth limit.
+	defer throttleRequestBody(r)()
+
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 	object := vars["object"]
@@ -413,19 +634,136 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Content-Range has no meaning on PUT, like S3 the whole body is
+	// always stored as the object and the header is ignored. Partial
+	// uploads are not supported, an aws-chunked upload whose
+	// Content-Range does not cover the whole decoded body is rejected
+	// instead of storing a body the client did not intend to.
+	if rAuthType == authTypeStreamingSigned && !isContentRangeFullObject(r.Header.Get("Content-Range"), size) {
+		writeErrorResponse(w, ErrInvalidContentRange, r.URL)
+		return
+	}
+
 	/// maximum Upload size for objects in a single operation
 	if isMaxObjectSize(size) {
 		writeErrorResponse(w, ErrEntityTooLarge, r.URL)
 		return
 	}
 
+	// Object size must be within the limits of the bucket, if any.
+	// Objects stored purely as website redirects are exempted from the
+	// minimum size.
+	redirectObject := isWebsiteRedirectObject(r.Header, size)
+	if !redirectObject {
+		if err = checkObjectSizeLimits(bucket, size); err != nil {
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+	}
+
+	// Website redirect location must be a path or a http(s) URL.
+	if location := r.Header.Get(websiteRedirectLocationKey); location != "" && !isValidWebsiteRedirectLocation(location) {
+		writeErrorResponse(w, ErrInvalidRedirectLocation, r.URL)
+		return
+	}
+
+	// Object ACLs are rejected if disabled on the bucket.
+	if err = checkObjectACLAllowed(bucket, r.Header.Get(amzACL)); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Objects of content-addressed buckets are named after their SHA256.
+	if err = checkContentAddress(bucket, object); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	contentAddressed := globalBucketContentAddressing.IsEnabled(bucket)
+
 	// Extract metadata to be saved from incoming HTTP header.
 	metadata := extractMetadataFromHeader(r.Header)
 	// Make sure we hex encode md5sum here.
 	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+	if contentAddressed {
+		setContentAddressMetadata(metadata, object)
+	}
+
+	// Save the response headers of the object.
+	if s3Error := extractObjectResponseHeaders(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Save the retention of the object, requested or inherited from
+	// the default retention of the bucket.
+	if err = setObjectRetentionMetadata(bucket, r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Save the expiry of the object, if a time to live is requested.
+	if err = setObjectExpiryMetadata(r.Header, metadata, time.Now().UTC()); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Encrypt the object with the customer provided key, if any.
+	sseKey, s3Error := parseSSECustomerKey(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
 
 	sha256sum := ""
 
+	// Detect the content type from the data if enabled on the bucket,
+	// the sniffed bytes are replayed to the object layer.
+	sniffContent := isContentSniffingRequired(bucket, object, r.Header, size)
+	putObject := func(reader io.Reader) (ObjectInfo, error) {
+		// Create the bucket on its first write, if enabled.
+		if cErr := autoCreateBucket(objectAPI, bucket, rAuthType); cErr != nil {
+			return ObjectInfo{}, cErr
+		}
+		// Objects under retention can not be overwritten.
+		if rErr := enforceObjectRetention(objectAPI, bucket, object, r); rErr != nil {
+			return ObjectInfo{}, rErr
+		}
+		// Index the expiry first, so that an object is never left
+		// expiring without being reaped.
+		if eErr := addObjectExpiry(objectAPI, bucket, object, metadata); eErr != nil {
+			return ObjectInfo{}, eErr
+		}
+		// Abort the upload as soon as it sends more than its declared
+		// size, which was checked against the maximum object size.
+		reader = newSizeReader(reader, size)
+		// Enforce the object size limits of the bucket as the data
+		// is read, the upload is aborted as soon as they are exceeded.
+		if !redirectObject {
+			reader = newObjectSizeLimitsReader(bucket, reader, false)
+		}
+		// Content not hashing to its key is never stored.
+		if contentAddressed {
+			var cErr error
+			if reader, cErr = newContentAddressReader(reader, size, object, sha256sum); cErr != nil {
+				return ObjectInfo{}, cErr
+			}
+		}
+		if sniffContent {
+			var sErr error
+			if reader, sErr = sniffContentType(reader, size, metadata); sErr != nil {
+				return ObjectInfo{}, sErr
+			}
+		}
+		if sseKey != nil {
+			encReader, encSize, eErr := encryptObject(reader, size, sseKey, metadata, sha256sum)
+			if eErr != nil {
+				return ObjectInfo{}, eErr
+			}
+			return objectAPI.PutObject(bucket, object, encSize, encReader, metadata, "")
+		}
+		return objectAPI.PutObject(bucket, object, size, reader, metadata, sha256sum)
+	}
+
 	// Lock the object.
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.Lock()
@@ -445,7 +783,13 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			return
 		}
 		// Create anonymous object.
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
+		objInfo, err = putObject(r.Body)
+	case authTypeClientCert:
+		if s3Error := isReqAuthenticatedByCert(r, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+		objInfo, err = putObject(r.Body)
 	case authTypeStreamingSigned:
 		// Initialize stream signature verifier.
 		reader, s3Error := newSignV4ChunkedReader(r)
@@ -454,7 +798,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, reader, metadata, sha256sum)
+		objInfo, err = putObject(reader)
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -462,7 +806,7 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
+		objInfo, err = putObject(r.Body)
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, dumpRequest(r))
@@ -473,13 +817,16 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			sha256sum = r.Header.Get("X-Amz-Content-Sha256")
 		}
 		// Create object.
-		objInfo, err = objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
+		objInfo, err = putObject(r.Body)
 	}
 	if err != nil {
 		errorIf(err, "Unable to create an object.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
 		return
 	}
+	if sseKey != nil {
+		setSSECustomerResponseHeaders(w, r.Header)
+	}
 	w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
 	writeSuccessResponseHeadersOnly(w)
 
@@ -514,10 +861,61 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// Multipart uploads are not encrypted.
+	if hasSSECustomerHeaders(r.Header) {
+		writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+		return
+	}
+
+	// Parts are not verified against the key of the object.
+	if globalBucketContentAddressing.IsEnabled(bucket) {
+		writeErrorResponse(w, ErrContentAddressingNotSupported, r.URL)
+		return
+	}
+
+	// Website redirect location must be a path or a http(s) URL.
+	if location := r.Header.Get(websiteRedirectLocationKey); location != "" && !isValidWebsiteRedirectLocation(location) {
+		writeErrorResponse(w, ErrInvalidRedirectLocation, r.URL)
+		return
+	}
+
+	// Object ACLs are rejected if disabled on the bucket.
+	if err := checkObjectACLAllowed(bucket, r.Header.Get(amzACL)); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Extract metadata that needs to be saved.
 	metadata := extractMetadataFromHeader(r.Header)
 
-	uploadID, err := objectAPI.NewMultipartUpload(bucket, object, metadata)
+	// Save the response headers of the object.
+	if s3Error := extractObjectResponseHeaders(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Save the retention of the object, requested or inherited from
+	// the default retention of the bucket.
+	if err := setObjectRetentionMetadata(bucket, r.Header, metadata); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Save the expiry of the object, counted from the initiation of
+	// the upload.
+	if err := setObjectExpiryMetadata(r.Header, metadata, time.Now().UTC()); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// Create the bucket on its first write, if enabled.
+	if err := autoCreateBucket(objectAPI, bucket, getRequestAuthType(r)); err != nil {
+		errorIf(err, "Unable to create the bucket %s.", bucket)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	uploadID, err := newMultipartUploadWithinLimit(objectAPI, bucket, object, metadata)
 	if err != nil {
 		errorIf(err, "Unable to initiate new multipart upload id.")
 		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
@@ -548,17 +946,10 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// Copy source path.
-	cpSrcPath, err := url.QueryUnescape(r.Header.Get("X-Amz-Copy-Source"))
-	if err != nil {
-		// Save unescaped string as is.
-		cpSrcPath = r.Header.Get("X-Amz-Copy-Source")
-	}
-
-	srcBucket, srcObject := path2BucketAndObject(cpSrcPath)
-	// If source object is empty or bucket is empty, reply back invalid copy source.
-	if srcObject == "" || srcBucket == "" {
-		writeErrorResponse(w, ErrInvalidCopySource, r.URL)
+	// Copy source bucket and object.
+	srcBucket, srcObject, s3Error := getCopySource(r.Header)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
 		return
 	}
 
@@ -571,9 +962,9 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
-	// check partID with maximum part ID for multipart objects
-	if isMaxPartID(partID) {
-		writeErrorResponse(w, ErrInvalidMaxParts, r.URL)
+	// check partID is within the part IDs allowed for multipart objects
+	if !isValidPartID(partID) {
+		writePartNumberErrorResponse(w, ErrInvalidPartNumber, partID, r.URL)
 		return
 	}
 
@@ -590,6 +981,12 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// Encrypted objects are not copied.
+	if isEncryptedObject(objInfo.UserDefined) {
+		writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+		return
+	}
+
 	// Get request range.
 	var hrange *httpRange
 	rangeHeader := r.Header.Get("x-amz-copy-source-range")
@@ -626,6 +1023,12 @@ func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	// A part alone must not exceed the maximum object size of the bucket.
+	if err = checkPartSizeLimits(dstBucket, length); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	// Copy source object to destination, if source and destination
 	// object is same then only metadata is updated.
 	partInfo, err := objectAPI.CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID, partID, startOffset, length)
@@ -653,6 +1056,9 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// Object data is read within the bandwidth limit.
+	defer throttleRequestBody(r)()
+
 	// get Content-Md5 sent by client and verify if valid
 	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
 	if err != nil {
@@ -685,6 +1091,12 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// A part alone must not exceed the maximum object size of the bucket.
+	if err = checkPartSizeLimits(bucket, size); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	uploadID := r.URL.Query().Get("uploadId")
 	partIDString := r.URL.Query().Get("partNumber")
 
@@ -694,15 +1106,23 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	// check partID with maximum part ID for multipart objects
-	if isMaxPartID(partID) {
-		writeErrorResponse(w, ErrInvalidMaxParts, r.URL)
+	// check partID is within the part IDs allowed for multipart objects
+	if !isValidPartID(partID) {
+		writePartNumberErrorResponse(w, ErrInvalidPartNumber, partID, r.URL)
 		return
 	}
 
 	var partInfo PartInfo
 	incomingMD5 := hex.EncodeToString(md5Bytes)
 	sha256sum := ""
+	putObjectPart := func(reader io.Reader) (PartInfo, error) {
+		// Abort the upload as soon as it sends more than its declared
+		// size, which was checked against the maximum object size.
+		reader = newSizeReader(reader, size)
+		// Enforce the maximum object size of the bucket as the data is read.
+		reader = newObjectSizeLimitsReader(bucket, reader, true)
+		return objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, incomingMD5, sha256sum)
+	}
 	switch rAuthType {
 	default:
 		// For all unknown auth types return error.
@@ -716,7 +1136,13 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			return
 		}
 		// No need to verify signature, anonymous request access is already allowed.
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
+		partInfo, err = putObjectPart(r.Body)
+	case authTypeClientCert:
+		if s3Error := isReqAuthenticatedByCert(r, "s3:PutObject"); s3Error != ErrNone {
+			writeErrorResponse(w, s3Error, r.URL)
+			return
+		}
+		partInfo, err = putObjectPart(r.Body)
 	case authTypeStreamingSigned:
 		// Initialize stream signature verifier.
 		reader, s3Error := newSignV4ChunkedReader(r)
@@ -725,7 +1151,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, reader, incomingMD5, sha256sum)
+		partInfo, err = putObjectPart(reader)
 	case authTypeSignedV2, authTypePresignedV2:
 		s3Error := isReqAuthenticatedV2(r)
 		if s3Error != ErrNone {
@@ -733,7 +1159,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 			writeErrorResponse(w, s3Error, r.URL)
 			return
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
+		partInfo, err = putObjectPart(r.Body)
 	case authTypePresigned, authTypeSigned:
 		if s3Error := reqSignatureV4Verify(r); s3Error != ErrNone {
 			errorIf(errSignatureMismatch, dumpRequest(r))
@@ -744,7 +1170,7 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		if !skipContentSha256Cksum(r) {
 			sha256sum = r.Header.Get("X-Amz-Content-Sha256")
 		}
-		partInfo, err = objectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, incomingMD5, sha256sum)
+		partInfo, err = putObjectPart(r.Body)
 	}
 	if err != nil {
 		errorIf(err, "Unable to create object part.")
@@ -844,14 +1270,13 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	// Get upload id.
 	uploadID, _, _, _ := getObjectResources(r.URL.Query())
 
-	completeMultipartBytes, err := ioutil.ReadAll(r.Body)
-	if err != nil {
-		errorIf(err, "Unable to complete multipart upload.")
-		writeErrorResponse(w, ErrInternalError, r.URL)
+	// Oversized bodies are rejected before being read.
+	if r.ContentLength > globalMaxCompleteMultipartSize {
+		writeErrorResponse(w, ErrMalformedXML, r.URL)
 		return
 	}
-	complMultipartUpload := &completeMultipartUpload{}
-	if err = xml.Unmarshal(completeMultipartBytes, complMultipartUpload); err != nil {
+	complMultipartUpload, err := parseCompleteMultipartUpload(r.Body)
+	if err != nil {
 		errorIf(err, "Unable to parse complete multipart upload XML.")
 		writeErrorResponse(w, ErrMalformedXML, r.URL)
 		return
@@ -860,9 +1285,17 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		writeErrorResponse(w, ErrMalformedXML, r.URL)
 		return
 	}
-	if !sort.IsSorted(completedParts(complMultipartUpload.Parts)) {
-		writeErrorResponse(w, ErrInvalidPartOrder, r.URL)
-		return
+	// Parts are to be listed in ascending order of their part numbers
+	// without duplicates, within the part numbers allowed.
+	for i, part := range complMultipartUpload.Parts {
+		if !isValidPartID(part.PartNumber) {
+			writePartNumberErrorResponse(w, ErrInvalidPartNumber, part.PartNumber, r.URL)
+			return
+		}
+		if i > 0 && part.PartNumber <= complMultipartUpload.Parts[i-1].PartNumber {
+			writePartNumberErrorResponse(w, ErrInvalidPartOrder, part.PartNumber, r.URL)
+			return
+		}
 	}
 
 	// Complete parts.
@@ -878,6 +1311,19 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	destLock.Lock()
 	defer destLock.Unlock()
 
+	// Objects under retention can not be overwritten.
+	if err = enforceObjectRetention(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	// The completed object must be within the maximum size of the
+	// multipart objects and the size limits of the bucket.
+	if err = checkMultipartSizeLimits(objectAPI, bucket, object, uploadID, completeParts); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	objInfo, err := objectAPI.CompleteMultipartUpload(bucket, object, uploadID, completeParts)
 	if err != nil {
 		errorIf(err, "Unable to complete multipart upload.")
@@ -892,6 +1338,8 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 		}
 		return
 	}
+	// Index the expiry saved at the initiation of the upload, if any.
+	errorIf(addObjectExpiry(objectAPI, bucket, object, objInfo.UserDefined), "Unable to index the expiry of %s/%s.", bucket, object)
 
 	// Get object location.
 	location := getLocation(r)
@@ -944,10 +1392,32 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
+	// Conditional delete, the ETag is validated while holding the
+	// object lock so that the object can not be replaced between the
+	// check and the delete.
+	if ifMatchETag := getDeleteIfMatchETag(r); ifMatchETag != "" {
+		objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+		if err != nil {
+			errorIf(err, "Unable to fetch object info.")
+			writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+			return
+		}
+		if !isETagEqual(objInfo.MD5Sum, ifMatchETag) {
+			writeErrorResponse(w, ErrPreconditionFailed, r.URL)
+			return
+		}
+	}
+
+	// Objects under retention can not be deleted.
+	if err := enforceObjectRetention(objectAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
 	/// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	/// Ignore delete object errors, since we are suppposed to reply
 	/// only 204.
-	if err := objectAPI.DeleteObject(bucket, object); err != nil {
+	if err := deleteObjectOrTrash(objectAPI, bucket, object); err != nil {
 		writeSuccessNoContent(w)
 		return
 	}
@@ -18,16 +18,23 @@ package cmd
 
 import (
 	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/xml"
 	"fmt"
+	"hash/crc32"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"strconv"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 )
@@ -191,6 +198,299 @@ func testAPIHeadObjectHandler(obj ObjectLayer, instanceType, bucketName string,
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Tests the number of parts is sent on GET and HEAD of the multipart
+// objects only.
+func TestAPIObjectPartsCountHeader(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIObjectPartsCountHeader, []string{"GetObject", "HeadObject"})
+}
+
+func testAPIObjectPartsCountHeader(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	singlePartObject, multipartObject := "single-part-object", "multipart-object"
+	if _, err := obj.PutObject(bucketName, singlePartObject, 5, bytes.NewReader([]byte("hello")), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucketName, multipartObject, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	var parts []completePart
+	for i, data := range [][]byte{generateBytesData(5 * humanize.MiByte), generateBytesData(5 * humanize.MiByte), []byte("hello")} {
+		partInfo, pErr := obj.PutObjectPart(bucketName, multipartObject, uploadID, i+1, int64(len(data)), bytes.NewReader(data), "", "")
+		if pErr != nil {
+			t.Fatalf("%s: %s", instanceType, pErr)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: partInfo.ETag})
+	}
+	if _, err = obj.CompleteMultipartUpload(bucketName, multipartObject, uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		method             string
+		objectName         string
+		expectedPartsCount string
+	}{
+		{"GET", multipartObject, "3"},
+		{"HEAD", multipartObject, "3"},
+		{"GET", singlePartObject, ""},
+		{"HEAD", singlePartObject, ""},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(testCase.method, getGetObjectURL("", bucketName, testCase.objectName),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Test %d: Failed to create HTTP request: <ERROR> %v", instanceType, i+1, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusOK, rec.Code)
+		}
+		partsCount, ok := rec.HeaderMap["X-Amz-Mp-Parts-Count"]
+		if testCase.expectedPartsCount == "" {
+			if ok {
+				t.Errorf("%s: Test %d: %s %s: Expected no parts count, got %v", instanceType, i+1, testCase.method, testCase.objectName, partsCount)
+			}
+			continue
+		}
+		if len(partsCount) != 1 || partsCount[0] != testCase.expectedPartsCount {
+			t.Errorf("%s: Test %d: %s %s: Expected %s parts, got %v", instanceType, i+1, testCase.method, testCase.objectName, testCase.expectedPartsCount, partsCount)
+		}
+	}
+}
+
+// Tests HEAD returns the very same headers as GET for an object with
+// rich metadata, including the overridden response headers.
+func TestAPIHeadObjectMatchesGetObject(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPIHeadObjectMatchesGetObject, []string{"PutObject", "HeadObject", "GetObject"})
+}
+
+func testAPIHeadObjectMatchesGetObject(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	objectName := "report.csv"
+	header := http.Header{}
+	header.Set("Content-Type", "text/csv")
+	header.Set("Content-Disposition", `attachment; filename="report.csv"`)
+	header.Set("Content-Encoding", "identity")
+	header.Set("Cache-Control", "max-age=60")
+	header.Set("X-Amz-Meta-Owner", "finance")
+	header.Set("X-Amz-Meta-Quarter", "Q3")
+	header.Set("X-Amz-Meta-Empty-Looking", "-")
+	header.Set("X-Amz-Tagging", "team=finance&confidential=yes")
+	header.Set("X-Amz-Storage-Class", "REDUCED_REDUNDANCY")
+	header.Set("X-Amz-Website-Redirect-Location", "/other.csv")
+	data := []byte("a,b,c\n1,2,3\n")
+	if rec := serveRequest("PUT", getPutObjectURL("", bucketName, objectName), data, header); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusOK, rec.Code, rec.Body.String())
+	}
+
+	expectedHeaders := map[string]string{
+		"Content-Type":                    "text/csv",
+		"Content-Disposition":             `attachment; filename="report.csv"`,
+		"Content-Encoding":                "identity",
+		"Cache-Control":                   "max-age=60",
+		"Content-Length":                  strconv.Itoa(len(data)),
+		"X-Amz-Meta-Owner":                "finance",
+		"X-Amz-Meta-Quarter":              "Q3",
+		"X-Amz-Meta-Empty-Looking":        "-",
+		"X-Amz-Tagging-Count":             "2",
+		"X-Amz-Storage-Class":             "REDUCED_REDUNDANCY",
+		"X-Amz-Website-Redirect-Location": "/other.csv",
+	}
+	testCases := []struct {
+		query  url.Values
+		header http.Header
+		status int
+	}{
+		// Plain requests.
+		{nil, nil, http.StatusOK},
+		// Requests accepting compression, the CSV is stored with a content encoding.
+		{nil, http.Header{"Accept-Encoding": []string{"gzip"}}, http.StatusOK},
+		// Requests overriding the response headers.
+		{url.Values{"response-content-type": []string{"text/plain"}, "response-cache-control": []string{"no-cache"}}, nil, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		urlStr := getGetObjectURL("", bucketName, objectName)
+		if testCase.query != nil {
+			urlStr += "?" + testCase.query.Encode()
+		}
+		getRec := serveRequest("GET", urlStr, nil, testCase.header)
+		headRec := serveRequest("HEAD", urlStr, nil, testCase.header)
+		if getRec.Code != testCase.status || headRec.Code != testCase.status {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d on GET and %d on HEAD", instanceType, i+1,
+				testCase.status, getRec.Code, headRec.Code)
+		}
+		if headRec.Body.Len() != 0 {
+			t.Fatalf("%s: Test %d: Expected no body on HEAD, got %q", instanceType, i+1, headRec.Body.String())
+		}
+
+		// Every header of the GET response is sent on HEAD, the request
+		// IDs differ.
+		getHeader, headHeader := getRec.Header(), headRec.Header()
+		getHeader.Del(responseRequestIDKey)
+		headHeader.Del(responseRequestIDKey)
+		if !reflect.DeepEqual(getHeader, headHeader) {
+			t.Fatalf("%s: Test %d: Expected HEAD headers %v, got %v", instanceType, i+1, getHeader, headHeader)
+		}
+		switch i {
+		case 1:
+			if headHeader.Get("Content-Encoding") != "identity" {
+				t.Fatalf("%s: Expected the stored content encoding, got %v", instanceType, headHeader)
+			}
+			continue
+		case 2:
+			if headHeader.Get("Content-Type") != "text/plain" || headHeader.Get("Cache-Control") != "no-cache" {
+				t.Fatalf("%s: Expected the overridden headers, got %v", instanceType, headHeader)
+			}
+			continue
+		}
+		for key, value := range expectedHeaders {
+			if actual := headHeader.Get(key); actual != value {
+				t.Fatalf("%s: Expected header %s to be %q, got %q", instanceType, key, value, actual)
+			}
+		}
+		if tagging := headHeader.Get("X-Amz-Tagging"); tagging != "" {
+			t.Fatalf("%s: Expected the tags not to be sent, got %s", instanceType, tagging)
+		}
+	}
+}
+
+// Tests HEAD, GET, CopyObject and the listings report the same last
+// modified time of an object, truncated to seconds.
+func TestAPILastModifiedConsistency(t *testing.T) {
+	ExecObjectLayerAPITest(t, testAPILastModifiedConsistency, []string{"CopyObject", "PutObject", "HeadObject",
+		"GetObject", "ListObjectsV1", "ListObjectsV2"})
+}
+
+func testAPILastModifiedConsistency(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %s %s: Expected status %d, got %d: %s", instanceType, method, urlStr, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	serveRequest("PUT", getPutObjectURL("", bucketName, "source"), []byte("data"), nil)
+	copyHeader := http.Header{"X-Amz-Copy-Source": []string{url.QueryEscape("/" + bucketName + "/source")}}
+	copyRec := serveRequest("PUT", getCopyObjectURL("", bucketName, "object"), nil, copyHeader)
+	var copyResponse CopyObjectResponse
+	if err := xml.Unmarshal(copyRec.Body.Bytes(), &copyResponse); err != nil {
+		t.Fatalf("%s: Failed to parse the copy response: <ERROR> %v", instanceType, err)
+	}
+
+	objInfo, err := obj.GetObjectInfo(bucketName, "object")
+	if err != nil {
+		t.Fatalf("%s: Failed to get object info: <ERROR> %v", instanceType, err)
+	}
+	modTime := objInfo.ModTime.UTC().Truncate(time.Second)
+
+	// Last-Modified headers are RFC1123 dates.
+	for _, method := range []string{"HEAD", "GET"} {
+		rec := serveRequest(method, getGetObjectURL("", bucketName, "object"), nil, nil)
+		if lastModified := rec.Header().Get("Last-Modified"); lastModified != modTime.Format(http.TimeFormat) {
+			t.Fatalf("%s: Expected %s Last-Modified %s, got %s", instanceType, method, modTime.Format(http.TimeFormat), lastModified)
+		}
+	}
+
+	// XML responses are ISO8601 dates.
+	lastModifieds := map[string]string{"CopyObject": copyResponse.LastModified}
+	var listV1 ListObjectsResponse
+	if err = xml.Unmarshal(serveRequest("GET", getListObjectsV1URL("", bucketName, ""), nil, nil).Body.Bytes(), &listV1); err != nil {
+		t.Fatalf("%s: Failed to parse the listing: <ERROR> %v", instanceType, err)
+	}
+	var listV2 ListObjectsV2Response
+	if err = xml.Unmarshal(serveRequest("GET", getListObjectsV2URL("", bucketName, "", ""), nil, nil).Body.Bytes(), &listV2); err != nil {
+		t.Fatalf("%s: Failed to parse the listing: <ERROR> %v", instanceType, err)
+	}
+	for _, content := range listV1.Contents {
+		if content.Key == "object" {
+			lastModifieds["ListObjectsV1"] = content.LastModified
+		}
+	}
+	for _, content := range listV2.Contents {
+		if content.Key == "object" {
+			lastModifieds["ListObjectsV2"] = content.LastModified
+		}
+	}
+	if len(lastModifieds) != 3 {
+		t.Fatalf("%s: Expected the object to be listed, got %v", instanceType, lastModifieds)
+	}
+	for surface, lastModified := range lastModifieds {
+		if lastModified != modTime.Format(timeFormatAMZLong) {
+			t.Fatalf("%s: Expected %s LastModified %s, got %s", instanceType, surface, modTime.Format(timeFormatAMZLong), lastModified)
+		}
+	}
+
+	// The Last-Modified header sent back is not modified since.
+	rec := httptest.NewRecorder()
+	req, err := newTestRequest("GET", getGetObjectURL("", bucketName, "object"), 0, nil)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	req.Header.Set("If-Modified-Since", modTime.Format(http.TimeFormat))
+	if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+		t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotModified, rec.Code)
+	}
+}
+
+// Tests the objects are modified since a time only once their last
+// modified time truncated to seconds is after it.
+func TestIfModifiedSince(t *testing.T) {
+	modTime := time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		objTime  time.Time
+		given    string
+		modified bool
+	}{
+		{modTime, modTime.Format(http.TimeFormat), false},
+		{modTime.Add(999 * time.Millisecond), modTime.Format(http.TimeFormat), false},
+		{modTime.Add(time.Second), modTime.Format(http.TimeFormat), true},
+		{modTime, modTime.Add(-time.Second).Format(http.TimeFormat), true},
+		{modTime, modTime.Add(time.Second).Format(http.TimeFormat), false},
+		// Invalid dates are ignored.
+		{modTime, "yesterday", true},
+	}
+	for i, testCase := range testCases {
+		if modified := ifModifiedSince(testCase.objTime, testCase.given); modified != testCase.modified {
+			t.Fatalf("Test %d: Expected modified %t, got %t", i+1, testCase.modified, modified)
+		}
+	}
+}
+
 // Wrapper for calling GetObject API handler tests for both XL multiple disks and FS single drive setup.
 func TestAPIGetObjectHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -413,6 +713,170 @@ func testAPIGetObjectHandler(obj ObjectLayer, instanceType, bucketName string, a
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Tests the Accept-Ranges header and the selection of the 200 and 206
+// statuses of GET and HEAD across full and partial requests.
+func TestAPIGetObjectRangeHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIGetObjectRangeHandler, []string{"GetObject", "HeadObject"})
+}
+
+func testAPIGetObjectRangeHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	data := []byte("0123456789")
+	for object, objectData := range map[string][]byte{"object": data, "empty-object": nil} {
+		_, err := obj.PutObject(bucketName, object, int64(len(objectData)), bytes.NewReader(objectData), nil, "")
+		if err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+
+	testCases := []struct {
+		method     string
+		objectName string
+		byteRange  string
+		// expected output.
+		expectedRespStatus   int
+		expectedContent      []byte
+		expectedContentRange string
+	}{
+		// Test case - 1.
+		// Entire object without a range.
+		{"GET", "object", "", http.StatusOK, data, ""},
+		// Test case - 2.
+		{"GET", "object", "bytes=2-5", http.StatusPartialContent, data[2:6], "bytes 2-5/10"},
+		// Test case - 3.
+		{"GET", "object", "bytes=7-", http.StatusPartialContent, data[7:], "bytes 7-9/10"},
+		// Test case - 4.
+		{"GET", "object", "bytes=-3", http.StatusPartialContent, data[7:], "bytes 7-9/10"},
+		// Test case - 5.
+		// Range covering the entire object is still a partial response.
+		{"GET", "object", "bytes=0-", http.StatusPartialContent, data, "bytes 0-9/10"},
+		// Test case - 6.
+		// Range not satisfiable.
+		{"GET", "object", "bytes=10-", http.StatusRequestedRangeNotSatisfiable, nil, "bytes */10"},
+		// Test case - 7.
+		// Invalid range is ignored.
+		{"GET", "object", "bytes=5-2", http.StatusOK, data, ""},
+		// Test case - 8.
+		// Range is ignored by HEAD.
+		{"HEAD", "object", "bytes=2-5", http.StatusOK, nil, ""},
+		// Test case - 9.
+		// Empty object without a range.
+		{"GET", "empty-object", "", http.StatusOK, []byte{}, ""},
+		// Test case - 10.
+		// Suffix of an empty object is the whole empty object.
+		{"GET", "empty-object", "bytes=-5", http.StatusOK, []byte{}, ""},
+		// Test case - 11.
+		// No byte of an empty object is satisfiable.
+		{"GET", "empty-object", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, nil, "bytes */0"},
+		// Test case - 12.
+		{"HEAD", "empty-object", "", http.StatusOK, nil, ""},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(testCase.method, getGetObjectURL("", bucketName, testCase.objectName), 0, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		if testCase.byteRange != "" {
+			req.Header.Add("Range", testCase.byteRange)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("Test %d: %s: Failed to sign HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Header().Get("Accept-Ranges") != "bytes" {
+			t.Errorf("Test %d: %s: Expected Accept-Ranges `bytes`, but instead found `%s`", i+1, instanceType, rec.Header().Get("Accept-Ranges"))
+		}
+		if contentRange := rec.Header().Get("Content-Range"); contentRange != testCase.expectedContentRange {
+			t.Errorf("Test %d: %s: Expected Content-Range `%s`, but instead found `%s`", i+1, instanceType, testCase.expectedContentRange, contentRange)
+		}
+		if testCase.expectedRespStatus == http.StatusRequestedRangeNotSatisfiable {
+			continue
+		}
+		if testCase.method == "HEAD" {
+			continue
+		}
+		if contentLength := rec.Header().Get("Content-Length"); contentLength != strconv.Itoa(len(testCase.expectedContent)) {
+			t.Errorf("Test %d: %s: Expected Content-Length `%d`, but instead found `%s`", i+1, instanceType, len(testCase.expectedContent), contentLength)
+		}
+		if !bytes.Equal(rec.Body.Bytes(), testCase.expectedContent) {
+			t.Errorf("Test %d: %s: Object content differs from expected value.", i+1, instanceType)
+		}
+	}
+}
+
+// Tests GET and HEAD of an empty object send no content, and the
+// configurable status of the ranges of an empty object.
+func TestAPIEmptyObjectHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIEmptyObjectHandler, []string{"GetObject", "HeadObject"})
+}
+
+func testAPIEmptyObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(status int) { globalEmptyObjectRangeStatus = status }(globalEmptyObjectRangeStatus)
+
+	if _, err := obj.PutObject(bucketName, "empty-object", 0, bytes.NewReader(nil), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		method      string
+		byteRange   string
+		rangeStatus int
+		// expected output.
+		expectedRespStatus   int
+		expectedContentRange string
+	}{
+		{"GET", "", http.StatusRequestedRangeNotSatisfiable, http.StatusOK, ""},
+		{"HEAD", "", http.StatusRequestedRangeNotSatisfiable, http.StatusOK, ""},
+		{"GET", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+		{"GET", "bytes=0-10", http.StatusRequestedRangeNotSatisfiable, http.StatusRequestedRangeNotSatisfiable, "bytes */0"},
+		{"GET", "bytes=-10", http.StatusRequestedRangeNotSatisfiable, http.StatusOK, ""},
+		// Ranges are ignored if configured so.
+		{"GET", "bytes=0-", http.StatusOK, http.StatusOK, ""},
+		{"GET", "bytes=0-10", http.StatusOK, http.StatusOK, ""},
+		// Range is ignored by HEAD.
+		{"HEAD", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, http.StatusOK, ""},
+	}
+	for i, testCase := range testCases {
+		globalEmptyObjectRangeStatus = testCase.rangeStatus
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(testCase.method, getGetObjectURL("", bucketName, "empty-object"), 0, nil)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		if testCase.byteRange != "" {
+			req.Header.Add("Range", testCase.byteRange)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("Test %d: %s: Failed to sign HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if contentRange := rec.Header().Get("Content-Range"); contentRange != testCase.expectedContentRange {
+			t.Errorf("Test %d: %s: Expected Content-Range `%s`, but instead found `%s`", i+1, instanceType, testCase.expectedContentRange, contentRange)
+		}
+		if testCase.expectedRespStatus != http.StatusOK {
+			continue
+		}
+		if contentLength := rec.Header().Get("Content-Length"); contentLength != "0" {
+			t.Errorf("Test %d: %s: Expected Content-Length `0`, but instead found `%s`", i+1, instanceType, contentLength)
+		}
+		if rec.Body.Len() != 0 {
+			t.Errorf("Test %d: %s: Expected no content, but instead found %d bytes", i+1, instanceType, rec.Body.Len())
+		}
+	}
+}
+
 // Wrapper for calling PutObject API handler tests using streaming signature v4 for both XL multiple disks and FS single drive setup.
 func TestAPIPutObjectStreamSigV4Handler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -681,6 +1145,91 @@ func testAPIPutObjectStreamSigV4Handler(obj ObjectLayer, instanceType, bucketNam
 	}
 }
 
+// Tests the streaming uploads followed by a trailing checksum, the
+// objects are only created when the checksum matches the data.
+func TestAPIPutObjectStreamTrailerHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectStreamTrailerHandler, []string{"PutObject"})
+}
+
+func testAPIPutObjectStreamTrailerHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(trailer bool) { globalIsStreamingTrailer = trailer }(globalIsStreamingTrailer)
+
+	data := bytes.Repeat([]byte("trailer checksum "), 4*humanize.KiByte)
+	checksumOf := func(algorithm string, data []byte) string {
+		hash := checksumHashers[algorithm]()
+		hash.Write(data)
+		return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	}
+	badData := append([]byte("x"), data[1:]...)
+
+	testCases := []struct {
+		trailer            string
+		checksum           string
+		signed             bool
+		chunkSize          int64
+		disabled           bool
+		expectedRespStatus int
+		expectedErrCode    string
+	}{
+		// Correct checksums, signed and unsigned.
+		{"x-amz-checksum-crc32", checksumOf(checksumCRC32, data), true, 64 * humanize.KiByte, false, http.StatusOK, ""},
+		{"x-amz-checksum-crc32c", checksumOf(checksumCRC32C, data), true, 8 * humanize.KiByte, false, http.StatusOK, ""},
+		{"x-amz-checksum-sha256", checksumOf(checksumSHA256, data), false, 8 * humanize.KiByte, false, http.StatusOK, ""},
+		{"x-amz-checksum-sha1", checksumOf(checksumSHA1, data), false, 100 * humanize.KiByte, false, http.StatusOK, ""},
+		// Incorrect checksums, signed and unsigned.
+		{"x-amz-checksum-crc32", checksumOf(checksumCRC32, badData), true, 8 * humanize.KiByte, false, http.StatusBadRequest, "BadDigest"},
+		{"x-amz-checksum-sha256", checksumOf(checksumSHA256, badData), false, 8 * humanize.KiByte, false, http.StatusBadRequest, "BadDigest"},
+		// Checksum of another algorithm.
+		{"x-amz-checksum-crc32c", checksumOf(checksumCRC32, data), true, 8 * humanize.KiByte, false, http.StatusBadRequest, "BadDigest"},
+		// Unsupported trailers.
+		{"x-amz-checksum-md5", checksumOf(checksumSHA1, data), true, 8 * humanize.KiByte, false, http.StatusBadRequest, "InvalidRequest"},
+		{"x-amz-meta-checksum", checksumOf(checksumSHA1, data), false, 8 * humanize.KiByte, false, http.StatusBadRequest, "InvalidRequest"},
+		// Trailers disabled.
+		{"x-amz-checksum-crc32", checksumOf(checksumCRC32, data), true, 8 * humanize.KiByte, true, http.StatusNotImplemented, "NotImplemented"},
+	}
+
+	for i, testCase := range testCases {
+		globalIsStreamingTrailer = !testCase.disabled
+		objectName := fmt.Sprintf("trailer-object-%d", i+1)
+		req, err := newTestStreamingTrailerRequest("PUT", getPutObjectURL("", bucketName, objectName), data, testCase.chunkSize,
+			testCase.trailer, testCase.checksum, testCase.signed, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: Failed to create HTTP request for Put Object: <ERROR> %v", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if testCase.expectedErrCode != "" {
+			var errResponse APIErrorResponse
+			if err = xml.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+				t.Fatalf("Test %d: %s: Failed to parse the error response: <ERROR> %v", i+1, instanceType, err)
+			}
+			if errResponse.Code != testCase.expectedErrCode {
+				t.Fatalf("Test %d: %s: Expected the error code `%s`, but instead found `%s`", i+1, instanceType, testCase.expectedErrCode, errResponse.Code)
+			}
+		}
+
+		var buffer bytes.Buffer
+		err = obj.GetObject(bucketName, objectName, 0, int64(len(data)), &buffer)
+		if rec.Code != http.StatusOK {
+			if !isErrObjectNotFound(err) {
+				t.Fatalf("Test %d: %s: Expected no object to be created, got %v", i+1, instanceType, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to read the object: <ERROR> %v", i+1, instanceType, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Fatalf("Test %d: %s: Contents of the object differ from the uploaded data", i+1, instanceType)
+		}
+	}
+}
+
 // Wrapper for calling PutObject API handler tests for both XL multiple disks and FS single drive setup.
 func TestAPIPutObjectHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -918,6 +1467,88 @@ func testAPIPutObjectHandler(obj ObjectLayer, instanceType, bucketName string, a
 
 }
 
+// Wrapper for calling PutObject API handler tests with Content-Range for both XL multiple disks and FS single drive setup.
+func TestAPIPutObjectContentRangeHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectContentRangeHandler, []string{"PutObject"})
+}
+
+func testAPIPutObjectContentRangeHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+
+	objectName := "test-object"
+	bytesData := generateBytesData(6 * humanize.KiByte)
+	fullRange := fmt.Sprintf("bytes 0-%d/%d", len(bytesData)-1, len(bytesData))
+
+	testCases := []struct {
+		streaming    bool
+		contentRange string
+		// expected output.
+		expectedRespStatus int
+	}{
+		// Test case - 1.
+		// Content-Range is ignored, the whole body is stored.
+		{false, "bytes 100-199/1000", http.StatusOK},
+		// Test case - 2.
+		// Content-Range covering the whole object is ignored.
+		{false, fullRange, http.StatusOK},
+		// Test case - 3.
+		// aws-chunked upload with Content-Range covering the whole object.
+		{true, fullRange, http.StatusOK},
+		// Test case - 4.
+		// aws-chunked upload with partial Content-Range.
+		{true, "bytes 100-199/1000", http.StatusBadRequest},
+		// Test case - 5.
+		// aws-chunked upload with Content-Range total not matching the decoded length.
+		{true, fmt.Sprintf("bytes 0-%d/*", len(bytesData)-1), http.StatusBadRequest},
+	}
+
+	for i, testCase := range testCases {
+		// Remove the object uploaded by the previous test case.
+		obj.DeleteObject(bucketName, objectName)
+
+		var req *http.Request
+		var err error
+		if testCase.streaming {
+			req, err = newTestStreamingSignedRequest("PUT", getPutObjectURL("", bucketName, objectName),
+				int64(len(bytesData)), 1024, bytes.NewReader(bytesData), credentials.AccessKey, credentials.SecretKey)
+		} else {
+			req, err = newTestSignedRequestV4("PUT", getPutObjectURL("", bucketName, objectName),
+				int64(len(bytesData)), bytes.NewReader(bytesData), credentials.AccessKey, credentials.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("Test %d: Failed to create HTTP request for Put Object: <ERROR> %v", i+1, err)
+		}
+		req.Header.Set("Content-Range", testCase.contentRange)
+
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d %s: Expected the response status to be `%d`, but instead found `%d`",
+				i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if testCase.expectedRespStatus != http.StatusOK {
+			verifyErrorResponse := getAPIError(ErrInvalidContentRange)
+			if !strings.Contains(rec.Body.String(), verifyErrorResponse.Code) {
+				t.Errorf("Test %d %s: Expected error code %s, got %s", i+1, instanceType, verifyErrorResponse.Code, rec.Body.String())
+			}
+			if _, err = obj.GetObjectInfo(bucketName, objectName); err == nil {
+				t.Errorf("Test %d %s: Expected the object not to be stored", i+1, instanceType)
+			}
+			continue
+		}
+
+		// The whole body must be stored regardless of Content-Range.
+		buffer := new(bytes.Buffer)
+		if err = obj.GetObject(bucketName, objectName, 0, int64(len(bytesData)), buffer); err != nil {
+			t.Fatalf("Test %d: %s: Failed to fetch the object: <ERROR> %s", i+1, instanceType, err)
+		}
+		if !bytes.Equal(bytesData, buffer.Bytes()) {
+			t.Errorf("Test %d: %s: Data Mismatch: Data fetched back from the uploaded object doesn't match the original one.", i+1, instanceType)
+		}
+	}
+}
+
 // Wrapper for calling Copy Object Part API handler tests for both XL multiple disks and single node setup.
 func TestAPICopyObjectPartHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -1566,6 +2197,74 @@ func testAPICopyObjectHandler(obj ObjectLayer, instanceType, bucketName string,
 
 }
 
+// Tests copying from the sources whose keys hold spaces, unicode and
+// plus signs, percent-encoded in the X-Amz-Copy-Source header.
+func TestAPICopyObjectEncodedSourceHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPICopyObjectEncodedSourceHandler, []string{"CopyObject"})
+}
+
+func testAPICopyObjectEncodedSourceHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+
+	sourceObjects := []string{"my object", "日本語/ファイル", "a+b", "a b+c"}
+	for i, object := range sourceObjects {
+		data := []byte(fmt.Sprintf("source %d", i))
+		if _, err := obj.PutObject(bucketName, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+	// Decoys the sources must not be mistaken for.
+	for _, object := range []string{"a b", "a  b+c"} {
+		if _, err := obj.PutObject(bucketName, object, 5, bytes.NewReader([]byte("decoy")), nil, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+
+	testCases := []struct {
+		copySource         string
+		sourceIndex        int
+		expectedRespStatus int
+	}{
+		{"/" + bucketName + "/my%20object", 0, http.StatusOK},
+		{"/" + bucketName + "/%E6%97%A5%E6%9C%AC%E8%AA%9E/%E3%83%95%E3%82%A1%E3%82%A4%E3%83%AB", 1, http.StatusOK},
+		{bucketName + "%2F%E6%97%A5%E6%9C%AC%E8%AA%9E%2F%E3%83%95%E3%82%A1%E3%82%A4%E3%83%AB", 1, http.StatusOK},
+		{"/" + bucketName + "/a+b", 2, http.StatusOK},
+		{"/" + bucketName + "/a%2Bb", 2, http.StatusOK},
+		{"/" + bucketName + "/a%20b%2Bc", 3, http.StatusOK},
+		{"/" + bucketName + "/a%20b+c", 3, http.StatusOK},
+		// The source must exist.
+		{"/" + bucketName + "/my%20other%20object", -1, http.StatusNotFound},
+		// Invalid escapes.
+		{"/" + bucketName + "/my%2object", -1, http.StatusBadRequest},
+	}
+
+	for i, testCase := range testCases {
+		newObject := fmt.Sprintf("copy-%d", i)
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("PUT", getCopyObjectURL("", bucketName, newObject), 0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: Failed to create HTTP request for copy Object: <ERROR> %v", i+1, err)
+		}
+		req.Header.Set("X-Amz-Copy-Source", testCase.copySource)
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedRespStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`", i+1, instanceType, testCase.expectedRespStatus, rec.Code)
+		}
+		if rec.Code != http.StatusOK {
+			continue
+		}
+
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucketName, newObject, 0, -1, &buffer); err != nil {
+			t.Fatalf("Test %d: %s: Failed to read the copy: <ERROR> %v", i+1, instanceType, err)
+		}
+		if expected := fmt.Sprintf("source %d", testCase.sourceIndex); buffer.String() != expected {
+			t.Fatalf("Test %d: %s: Expected the copy of \"%s\", got \"%s\"", i+1, instanceType, expected, buffer.String())
+		}
+	}
+}
+
 // Wrapper for calling NewMultipartUpload tests for both XL multiple disks and single node setup.
 // First register the HTTP handler for NewMutlipartUpload, then a HTTP request for NewMultipart upload is made.
 // The UploadID from the response body is parsed and its existence is asserted with an attempt to ListParts using it.
@@ -1902,6 +2601,22 @@ func testAPICompleteMultipartHandler(obj ObjectLayer, instanceType, bucketName s
 				{ETag: validPartMD5, PartNumber: 2},
 			},
 		},
+		// inputParts - 6.
+		// Case with a duplicate part number.
+		{
+			[]completePart{
+				{ETag: validPartMD5, PartNumber: 5},
+				{ETag: validPartMD5, PartNumber: 5},
+			},
+		},
+		// inputParts - 7.
+		// Case with a part number beyond the maximum part ID.
+		{
+			[]completePart{
+				{ETag: validPartMD5, PartNumber: 5},
+				{ETag: validPartMD5, PartNumber: maxPartID + 1},
+			},
+		},
 	}
 
 	// on successful complete multipart operation the s3MD5 for the parts uploaded will be returned.
@@ -2010,8 +2725,8 @@ func testAPICompleteMultipartHandler(obj ObjectLayer, instanceType, bucketName s
 			accessKey: credentials.AccessKey,
 			secretKey: credentials.SecretKey,
 
-			expectedContent: encodeResponse(getAPIErrorResponse(getAPIError(ErrInvalidPartOrder),
-				getGetObjectURL("", bucketName, objectName))),
+			expectedContent: encodeResponse(partNumberAPIError{5, getAPIErrorResponse(getAPIError(ErrInvalidPartOrder),
+				getGetObjectURL("", bucketName, objectName))}),
 			expectedRespStatus: http.StatusBadRequest,
 		},
 		// Test case - 7.
@@ -2043,6 +2758,36 @@ func testAPICompleteMultipartHandler(obj ObjectLayer, instanceType, bucketName s
 			expectedContent:    encodedSuccessResponse,
 			expectedRespStatus: http.StatusOK,
 		},
+		// Test case - 9.
+		// Parts with a duplicate part number.
+		// This should return ErrInvalidPartOrder along with the part number.
+		{
+			bucket:    bucketName,
+			object:    objectName,
+			uploadID:  uploadIDs[0],
+			parts:     inputParts[6].parts,
+			accessKey: credentials.AccessKey,
+			secretKey: credentials.SecretKey,
+
+			expectedContent: encodeResponse(partNumberAPIError{5, getAPIErrorResponse(getAPIError(ErrInvalidPartOrder),
+				getGetObjectURL("", bucketName, objectName))}),
+			expectedRespStatus: http.StatusBadRequest,
+		},
+		// Test case - 10.
+		// Parts with a part number out of range.
+		// This should return ErrInvalidPartNumber along with the part number.
+		{
+			bucket:    bucketName,
+			object:    objectName,
+			uploadID:  uploadIDs[0],
+			parts:     inputParts[7].parts,
+			accessKey: credentials.AccessKey,
+			secretKey: credentials.SecretKey,
+
+			expectedContent: encodeResponse(partNumberAPIError{maxPartID + 1, getAPIErrorResponse(getAPIError(ErrInvalidPartNumber),
+				getGetObjectURL("", bucketName, objectName))}),
+			expectedRespStatus: http.StatusBadRequest,
+		},
 	}
 
 	for i, testCase := range testCases {
@@ -2298,6 +3043,77 @@ func testAPIAbortMultipartHandler(obj ObjectLayer, instanceType, bucketName stri
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
 
+// Wrapper for calling conditional Delete Object API handler tests for both XL multiple disks and FS single drive setup.
+func TestAPIConditionalDeleteObjectHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIConditionalDeleteObjectHandler, []string{"DeleteObject"})
+}
+
+func testAPIConditionalDeleteObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	objectName := "test-object"
+	deleteObject := func(etagHeader, etag string) int {
+		req, err := newTestSignedRequestV4("DELETE", getDeleteObjectURL("", bucketName, objectName),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request for DeleteObject: <ERROR> %v", instanceType, err)
+		}
+		// Conditional headers are not signed.
+		req.Header.Set(etagHeader, etag)
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Read the ETag of the first version of the object, then replace
+	// the object before the conditional delete is issued.
+	objInfo, err := obj.PutObject(bucketName, objectName, int64(len("hello")), bytes.NewReader([]byte("hello")), nil, "")
+	if err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+	staleETag := objInfo.MD5Sum
+	objInfo, err = obj.PutObject(bucketName, objectName, int64(len("world")), bytes.NewReader([]byte("world")), nil, "")
+	if err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	for _, etagHeader := range []string{"If-Match", "X-Amz-If-Match"} {
+		if code := deleteObject(etagHeader, "\""+staleETag+"\""); code != http.StatusPreconditionFailed {
+			t.Fatalf("%s: %s: Expected status %d for a stale ETag, got %d", instanceType, etagHeader,
+				http.StatusPreconditionFailed, code)
+		}
+	}
+	if _, err = obj.GetObjectInfo(bucketName, objectName); err != nil {
+		t.Fatalf("%s: Expected object to be retained, got <ERROR> %v", instanceType, err)
+	}
+
+	// Concurrent conditional deletes with the current ETag, exactly one
+	// of them removes the object while others observe it is gone.
+	var wg sync.WaitGroup
+	codes := make([]int, 10)
+	for i := range codes {
+		wg.Add(1)
+		go func(idx int) {
+			defer wg.Done()
+			codes[idx] = deleteObject("If-Match", objInfo.MD5Sum)
+		}(i)
+	}
+	wg.Wait()
+	deleted := 0
+	for _, code := range codes {
+		switch code {
+		case http.StatusNoContent:
+			deleted++
+		case http.StatusNotFound:
+		default:
+			t.Fatalf("%s: Unexpected status %d for conditional delete", instanceType, code)
+		}
+	}
+	if deleted != 1 {
+		t.Fatalf("%s: Expected exactly one conditional delete to succeed, %d succeeded", instanceType, deleted)
+	}
+}
+
 // Wrapper for calling Delete Object API handler tests for both XL multiple disks and FS single drive setup.
 func TestAPIDeleteObjectHandler(t *testing.T) {
 	defer DetectTestLeak(t)()
@@ -2654,8 +3470,8 @@ func testAPIPutObjectPartHandler(obj ObjectLayer, instanceType, bucketName strin
 	badChecksum := getAPIError(ErrInvalidDigest)
 	// expected error when the part number in the request is invalid.
 	invalidPart := getAPIError(ErrInvalidPart)
-	// expected error when maxPart is beyond the limit.
-	invalidMaxParts := getAPIError(ErrInvalidMaxParts)
+	// expected error when the part number is out of range.
+	invalidPartNumber := getAPIError(ErrInvalidPartNumber)
 	// expected error the when the uploadID is invalid.
 	noSuchUploadID := getAPIError(ErrNoSuchUpload)
 	// expected error when InvalidAccessID is set.
@@ -2706,7 +3522,7 @@ func testAPIPutObjectPartHandler(obj ObjectLayer, instanceType, bucketName strin
 			accessKey:  credentials.AccessKey,
 			secretKey:  credentials.SecretKey,
 
-			expectedAPIError: invalidMaxParts,
+			expectedAPIError: invalidPartNumber,
 		},
 		// Test case - 4.
 		// Case where the content length is not set in the HTTP request.
@@ -2782,6 +3598,18 @@ func testAPIPutObjectPartHandler(obj ObjectLayer, instanceType, bucketName strin
 
 			expectedAPIError: invalidAccessID,
 		},
+		// Test case - 10.
+		// Case where the part number is below the minimum of 1.
+		{
+			objectName: testObject,
+			reader:     bytes.NewReader([]byte("hello")),
+			partNumber: "0",
+			fault:      None,
+			accessKey:  credentials.AccessKey,
+			secretKey:  credentials.SecretKey,
+
+			expectedAPIError: invalidPartNumber,
+		},
 	}
 
 	reqV2Str := "V2 Signed HTTP request"
@@ -3236,3 +4064,323 @@ func testAPIListObjectPartsHandler(obj ObjectLayer, instanceType, bucketName str
 	// `ExecObjectLayerAPINilTest` sets the Object Layer to `nil` and calls the handler.
 	ExecObjectLayerAPINilTest(t, nilBucket, nilObject, instanceType, apiRouter, nilReq)
 }
+
+// Tests that CopyObject computes the destination ETag and the requested
+// checksum over the copied content.
+func TestAPICopyObjectChecksumHandler(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPICopyObjectChecksumHandler, []string{"CopyObject"})
+}
+
+func testAPICopyObjectChecksumHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Upload the source as multipart, its ETag is not the md5sum of the content.
+	objectName := "multipart-object"
+	uploadID, err := obj.NewMultipartUpload(bucketName, objectName, nil)
+	if err != nil {
+		t.Fatalf("%s: Failed to initiate multipart upload: <ERROR> %v", instanceType, err)
+	}
+	var content []byte
+	var parts []completePart
+	for i, data := range [][]byte{bytes.Repeat([]byte("a"), 5*humanize.MiByte), []byte("hello")} {
+		info, perr := obj.PutObjectPart(bucketName, objectName, uploadID, i+1, int64(len(data)), bytes.NewReader(data), "", "")
+		if perr != nil {
+			t.Fatalf("%s: Failed to upload part: <ERROR> %v", instanceType, perr)
+		}
+		parts = append(parts, completePart{PartNumber: i + 1, ETag: info.ETag})
+		content = append(content, data...)
+	}
+	if _, err = obj.CompleteMultipartUpload(bucketName, objectName, uploadID, parts); err != nil {
+		t.Fatalf("%s: Failed to complete multipart upload: <ERROR> %v", instanceType, err)
+	}
+	contentMD5 := getMD5Hash(content)
+
+	copyObject := func(dstObject string, headers map[string]string) (*httptest.ResponseRecorder, CopyObjectResponse) {
+		req, rerr := newTestRequest("PUT", getCopyObjectURL("", bucketName, dstObject), 0, nil)
+		if rerr != nil {
+			t.Fatalf("%s: Failed to create HTTP request for CopyObject: <ERROR> %v", instanceType, rerr)
+		}
+		req.Header.Set("X-Amz-Copy-Source", url.QueryEscape("/"+bucketName+"/"+objectName))
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if rerr = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); rerr != nil {
+			t.Fatalf("%s: Failed to sign CopyObject request: <ERROR> %v", instanceType, rerr)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		var response CopyObjectResponse
+		if rec.Code == http.StatusOK {
+			if rerr = xml.Unmarshal(rec.Body.Bytes(), &response); rerr != nil {
+				t.Fatalf("%s: Failed to parse CopyObject response: <ERROR> %v", instanceType, rerr)
+			}
+		}
+		return rec, response
+	}
+
+	sha256Sum := sha256.Sum256(content)
+	crc32cSum := crc32.Checksum(content, crc32.MakeTable(crc32.Castagnoli))
+	testCases := []struct {
+		dstObject      string
+		headers        map[string]string
+		checksumHeader string
+		checksum       string
+	}{
+		// Test case - 1.
+		// Copy to a new object without checksum.
+		{dstObject: "copy-object"},
+		// Test case - 2.
+		// Copy to a new object with a checksum.
+		{
+			dstObject:      "copy-object-sha256",
+			headers:        map[string]string{"X-Amz-Checksum-Algorithm": "sha256"},
+			checksumHeader: "X-Amz-Checksum-Sha256",
+			checksum:       base64.StdEncoding.EncodeToString(sha256Sum[:]),
+		},
+		// Test case - 3.
+		// Metadata replacing copy of the object onto itself with a checksum.
+		{
+			dstObject: objectName,
+			headers: map[string]string{
+				"X-Amz-Metadata-Directive": "REPLACE",
+				"Content-Type":             "application/json",
+				"X-Amz-Checksum-Algorithm": "CRC32C",
+			},
+			checksumHeader: "X-Amz-Checksum-Crc32c",
+			checksum:       base64.StdEncoding.EncodeToString([]byte{byte(crc32cSum >> 24), byte(crc32cSum >> 16), byte(crc32cSum >> 8), byte(crc32cSum)}),
+		},
+		// Test case - 4.
+		// Copy to a new object with the checksum stored with the source.
+		{
+			dstObject:      "copy-object-crc32c",
+			headers:        map[string]string{"X-Amz-Checksum-Algorithm": "CRC32C"},
+			checksumHeader: "X-Amz-Checksum-Crc32c",
+			checksum:       base64.StdEncoding.EncodeToString([]byte{byte(crc32cSum >> 24), byte(crc32cSum >> 16), byte(crc32cSum >> 8), byte(crc32cSum)}),
+		},
+	}
+	for i, testCase := range testCases {
+		rec, response := copyObject(testCase.dstObject, testCase.headers)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Test %d: %s: Expected status %d, got %d", i+1, instanceType, http.StatusOK, rec.Code)
+		}
+		if response.ETag != "\""+contentMD5+"\"" {
+			t.Errorf("Test %d: %s: Expected ETag %s, got %s", i+1, instanceType, contentMD5, response.ETag)
+		}
+		objInfo, oerr := obj.GetObjectInfo(bucketName, testCase.dstObject)
+		if oerr != nil {
+			t.Fatalf("Test %d: %s: Failed to fetch copied object info: <ERROR> %v", i+1, instanceType, oerr)
+		}
+		if objInfo.MD5Sum != contentMD5 {
+			t.Errorf("Test %d: %s: Expected stored ETag %s, got %s", i+1, instanceType, contentMD5, objInfo.MD5Sum)
+		}
+		if testCase.checksumHeader == "" {
+			continue
+		}
+		if got := objInfo.UserDefined[testCase.checksumHeader]; got != testCase.checksum {
+			t.Errorf("Test %d: %s: Expected stored checksum %s, got %s", i+1, instanceType, testCase.checksum, got)
+		}
+		if got := response.ChecksumSHA256 + response.ChecksumCRC32C; got != testCase.checksum {
+			t.Errorf("Test %d: %s: Expected response checksum %s, got %s", i+1, instanceType, testCase.checksum, got)
+		}
+	}
+
+	// Unsupported checksum algorithm.
+	rec, _ := copyObject("copy-object-md5", map[string]string{"X-Amz-Checksum-Algorithm": "MD5"})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d for an invalid checksum algorithm, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+}
+
+// Tests Content-Encoding is stored on PUT and CopyObject and returned
+// as is on GET and HEAD, the body is never decoded nor compressed again.
+func TestAPIContentEncodingPassthrough(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIContentEncodingPassthrough, []string{"CopyObject", "PutObject", "GetObject", "HeadObject"})
+}
+
+func testAPIContentEncodingPassthrough(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	serveRequest := func(method, urlStr string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %s %s: Expected status %d, got %d %s", instanceType, method, urlStr, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	// Client compressed object.
+	var buf bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buf)
+	gzipWriter.Write(bytes.Repeat([]byte("hello, "), 100))
+	gzipWriter.Close()
+	data := buf.Bytes()
+	serveRequest("PUT", getPutObjectURL("", bucketName, "object.txt.gz"), data, map[string]string{
+		"Content-Type":     "text/plain",
+		"Content-Encoding": "gzip",
+	})
+	serveRequest("PUT", getCopyObjectURL("", bucketName, "copy.txt.gz"), nil, map[string]string{
+		"X-Amz-Copy-Source": url.QueryEscape("/" + bucketName + "/object.txt.gz"),
+	})
+
+	testCases := []struct {
+		method           string
+		object           string
+		query            string
+		expectedEncoding string
+	}{
+		{"GET", "object.txt.gz", "", "gzip"},
+		{"HEAD", "object.txt.gz", "", "gzip"},
+		{"GET", "copy.txt.gz", "", "gzip"},
+		// response-content-encoding overrides the stored encoding.
+		{"GET", "object.txt.gz", "?response-content-encoding=identity", "identity"},
+	}
+	for i, testCase := range testCases {
+		urlStr := getGetObjectURL("", bucketName, testCase.object) + testCase.query
+		rec := serveRequest(testCase.method, urlStr, nil, map[string]string{"Accept-Encoding": "gzip"})
+		if encoding := rec.Header().Get("Content-Encoding"); encoding != testCase.expectedEncoding {
+			t.Errorf("Test %d: %s: Expected Content-Encoding %s, got %s", i+1, instanceType, testCase.expectedEncoding, encoding)
+		}
+		if testCase.method == "GET" && !bytes.Equal(rec.Body.Bytes(), data) {
+			t.Errorf("Test %d: %s: Expected the stored body to be returned as is", i+1, instanceType)
+		}
+	}
+}
+
+// Tests the multipart uploads completing an object larger than a
+// lowered maximum multipart object size are rejected, and can still be
+// completed within it.
+func TestAPICompleteMultipartMaxSize(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPICompleteMultipartMaxSize, []string{"CompleteMultipart"})
+}
+
+func testAPICompleteMultipartMaxSize(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(maxSize int64) {
+		globalMaxMultipartObjectSize = maxSize
+	}(globalMaxMultipartObjectSize)
+
+	object := "large-multipart"
+	uploadID, err := obj.NewMultipartUpload(bucketName, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("a"), 2*humanize.KiByte)
+	partInfo, err := obj.PutObjectPart(bucketName, object, uploadID, 1, int64(len(data)), bytes.NewReader(data), "", "")
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	completeBytes, err := xml.Marshal(&completeMultipartUpload{
+		Parts: []completePart{{PartNumber: 1, ETag: partInfo.ETag}},
+	})
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		maxSize      int64
+		expectedCode int
+	}{
+		{1 * humanize.KiByte, http.StatusBadRequest},
+		{2 * humanize.KiByte, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		globalMaxMultipartObjectSize = testCase.maxSize
+		req, err := newTestSignedRequestV4("POST", getCompleteMultipartUploadURL("", bucketName, object, uploadID),
+			int64(len(completeBytes)), bytes.NewReader(completeBytes), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("Test %d: %s: Expected status %d, got %d %s", i+1, instanceType, testCase.expectedCode, rec.Code, rec.Body.String())
+		}
+		if testCase.expectedCode == http.StatusOK {
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), "<Code>EntityTooLarge</Code>") {
+			t.Errorf("Test %d: %s: Expected EntityTooLarge, got %s", i+1, instanceType, rec.Body.String())
+		}
+		if _, err = obj.GetObjectInfo(bucketName, object); !isErrObjectNotFound(err) {
+			t.Errorf("Test %d: %s: Expected the object not to be created, got %v", i+1, instanceType, err)
+		}
+	}
+}
+
+// Tests the uploads larger than a lowered maximum object size are
+// rejected from their declared length, and the aws-chunked uploads
+// sending more than their declared length are aborted.
+func TestAPIPutObjectMaxSize(t *testing.T) {
+	defer DetectTestLeak(t)()
+	ExecObjectLayerAPITest(t, testAPIPutObjectMaxSize, []string{"PutObject"})
+}
+
+func testAPIPutObjectMaxSize(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(maxSize int64) {
+		globalMaxObjectSize = maxSize
+	}(globalMaxObjectSize)
+	globalMaxObjectSize = 1 * humanize.KiByte
+
+	data := bytes.Repeat([]byte("a"), 2*humanize.KiByte)
+	testCases := []struct {
+		object       string
+		declaredSize int64
+		sentSize     int64
+		streaming    bool
+		expectedCode int
+	}{
+		// Within the maximum object size.
+		{"small", 1 * humanize.KiByte, 1 * humanize.KiByte, false, http.StatusOK},
+		{"small-streaming", 1 * humanize.KiByte, 1 * humanize.KiByte, true, http.StatusOK},
+		// Declaring more than the maximum object size.
+		{"large", 2 * humanize.KiByte, 2 * humanize.KiByte, false, http.StatusBadRequest},
+		{"large-streaming", 2 * humanize.KiByte, 2 * humanize.KiByte, true, http.StatusBadRequest},
+		// Sending more than declared, overshooting the maximum
+		// object size.
+		{"overshoot-streaming", 512, 2 * humanize.KiByte, true, http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		var req *http.Request
+		var err error
+		urlStr := getPutObjectURL("", bucketName, testCase.object)
+		body := bytes.NewReader(data[:testCase.sentSize])
+		if testCase.streaming {
+			req, err = newTestStreamingSignedRequest("PUT", urlStr, testCase.declaredSize, 256,
+				body, credentials.AccessKey, credentials.SecretKey)
+		} else {
+			req, err = newTestSignedRequestV4("PUT", urlStr, testCase.declaredSize,
+				body, credentials.AccessKey, credentials.SecretKey)
+		}
+		if err != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request: <ERROR> %v", i+1, instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("Test %d: %s: Expected status %d, got %d %s", i+1, instanceType, testCase.expectedCode, rec.Code, rec.Body.String())
+		}
+		if testCase.expectedCode == http.StatusOK {
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), "<Code>EntityTooLarge</Code>") {
+			t.Errorf("Test %d: %s: Expected EntityTooLarge, got %s", i+1, instanceType, rec.Body.String())
+		}
+		// Nothing is left of the aborted uploads.
+		if _, err = obj.GetObjectInfo(bucketName, testCase.object); !isErrObjectNotFound(err) {
+			t.Errorf("Test %d: %s: Expected the object not to be created, got %v", i+1, instanceType, err)
+		}
+	}
+}
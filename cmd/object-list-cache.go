@@ -0,0 +1,217 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Maximum number of the cached listings, all of them are dropped once
+// exceeded.
+const listCacheMaxEntries = 10000
+
+// setListCacheTTL - sets the time the listings are cached from
+// MINIO_LIST_CACHE_TTL env.
+func setListCacheTTL() {
+	if ttl := os.Getenv("MINIO_LIST_CACHE_TTL"); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		fatalIf(err, "Invalid MINIO_LIST_CACHE_TTL value %s.", ttl)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_LIST_CACHE_TTL value %s.", ttl)
+		}
+		globalListCacheTTL = duration
+	}
+}
+
+// listCacheKey - arguments of a listing.
+type listCacheKey struct {
+	bucket    string
+	prefix    string
+	marker    string
+	delimiter string
+	maxKeys   int
+}
+
+// listCacheEntry - cached result of a listing.
+type listCacheEntry struct {
+	result  ListObjectsInfo
+	expires time.Time
+}
+
+// listCacheObjects - object layer caching the listings of the backend
+// object layer for a short time, so that the same prefix listed again
+// and again is walked once. Every write of an object drops the cached
+// listings of the prefixes of the object, and the listings running
+// while a bucket is written are not cached, so that a listing never
+// misses an object written through this server.
+type listCacheObjects struct {
+	ObjectLayer
+
+	ttl time.Duration
+
+	mutex   sync.Mutex
+	entries map[listCacheKey]listCacheEntry
+	// Incremented on every write of the bucket.
+	generations map[string]uint64
+}
+
+// newListCacheObjects - returns the object layer caching the listings
+// of the backend object layer for ttl.
+func newListCacheObjects(objAPI ObjectLayer, ttl time.Duration) ObjectLayer {
+	return &listCacheObjects{
+		ObjectLayer: objAPI,
+		ttl:         ttl,
+		entries:     make(map[listCacheKey]listCacheEntry),
+		generations: make(map[string]uint64),
+	}
+}
+
+// get - returns the cached listing if not expired, along with the
+// generation of the bucket.
+func (c *listCacheObjects) get(key listCacheKey) (ListObjectsInfo, uint64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	generation := c.generations[key.bucket]
+	entry, ok := c.entries[key]
+	if !ok {
+		return ListObjectsInfo{}, generation, false
+	}
+	if time.Now().UTC().After(entry.expires) {
+		delete(c.entries, key)
+		return ListObjectsInfo{}, generation, false
+	}
+	return entry.result, generation, true
+}
+
+// set - caches the listing unless the bucket was written since the
+// listing started.
+func (c *listCacheObjects) set(key listCacheKey, result ListObjectsInfo, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.generations[key.bucket] != generation {
+		return
+	}
+	if len(c.entries) >= listCacheMaxEntries {
+		c.entries = make(map[listCacheKey]listCacheEntry)
+	}
+	c.entries[key] = listCacheEntry{result: result, expires: time.Now().UTC().Add(c.ttl)}
+}
+
+// invalidate - drops the cached listings of the bucket whose prefix is
+// a prefix of the object, all of them if the object is empty.
+func (c *listCacheObjects) invalidate(bucket, object string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.generations[bucket]++
+	for key := range c.entries {
+		if key.bucket == bucket && (object == "" || strings.HasPrefix(object, key.prefix)) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// copyListObjectsInfo - returns a copy of the listing, so that the
+// cached listing is not modified by the callers.
+func copyListObjectsInfo(result ListObjectsInfo) ListObjectsInfo {
+	result.Objects = append([]ObjectInfo(nil), result.Objects...)
+	result.Prefixes = append([]string(nil), result.Prefixes...)
+	return result
+}
+
+// ListObjects - returns the cached listing if any, lists the backend
+// and caches the listing otherwise. Listings of the meta bucket are
+// never cached.
+func (c *listCacheObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	if isMinioMetaBucketName(bucket) {
+		return c.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	}
+
+	key := listCacheKey{bucket, prefix, marker, delimiter, maxKeys}
+	result, generation, ok := c.get(key)
+	if ok {
+		return copyListObjectsInfo(result), nil
+	}
+	result, err := c.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectsInfo{}, err
+	}
+	c.set(key, copyListObjectsInfo(result), generation)
+	return result, nil
+}
+
+// PutObject - writes the object to the backend and drops the listings
+// of its prefixes.
+func (c *listCacheObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	defer c.invalidate(bucket, object)
+	return c.ObjectLayer.PutObject(bucket, object, size, data, metadata, sha256sum)
+}
+
+// CopyObject - copies the object in the backend and drops the listings
+// of the prefixes of the destination object.
+func (c *listCacheObjects) CopyObject(srcBucket, srcObject, destBucket, destObject string, metadata map[string]string) (ObjectInfo, error) {
+	defer c.invalidate(destBucket, destObject)
+	return c.ObjectLayer.CopyObject(srcBucket, srcObject, destBucket, destObject, metadata)
+}
+
+// RenameObject - moves the object in the backend and drops the
+// listings of the prefixes of both objects.
+func (c *listCacheObjects) RenameObject(srcBucket, srcObject, destBucket, destObject string) (ObjectInfo, error) {
+	defer c.invalidate(srcBucket, srcObject)
+	defer c.invalidate(destBucket, destObject)
+	return c.ObjectLayer.RenameObject(srcBucket, srcObject, destBucket, destObject)
+}
+
+// DeleteObject - deletes the object from the backend and drops the
+// listings of its prefixes.
+func (c *listCacheObjects) DeleteObject(bucket, object string) error {
+	defer c.invalidate(bucket, object)
+	return c.ObjectLayer.DeleteObject(bucket, object)
+}
+
+// CompleteMultipartUpload - completes the upload in the backend and
+// drops the listings of the prefixes of the object.
+func (c *listCacheObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (ObjectInfo, error) {
+	defer c.invalidate(bucket, object)
+	return c.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+}
+
+// HealObject - heals the object in the backend and drops the listings
+// of its prefixes.
+func (c *listCacheObjects) HealObject(bucket, object string) error {
+	defer c.invalidate(bucket, object)
+	return c.ObjectLayer.HealObject(bucket, object)
+}
+
+// MakeBucket - creates the bucket in the backend and drops its listings.
+func (c *listCacheObjects) MakeBucket(bucket string) error {
+	defer c.invalidate(bucket, "")
+	return c.ObjectLayer.MakeBucket(bucket)
+}
+
+// DeleteBucket - deletes the bucket from the backend and drops its
+// listings.
+func (c *listCacheObjects) DeleteBucket(bucket string) error {
+	defer c.invalidate(bucket, "")
+	return c.ObjectLayer.DeleteBucket(bucket)
+}
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// writeDuringListObjects - object layer writing an object through the
+// list cache while a listing runs, after the backend was listed.
+type writeDuringListObjects struct {
+	ObjectLayer
+
+	cache  ObjectLayer
+	object string
+}
+
+func (w *writeDuringListObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result, err := w.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err == nil && w.object != "" {
+		_, err = w.cache.PutObject(bucket, w.object, 4, bytes.NewReader([]byte("data")), nil, "")
+		w.object = ""
+	}
+	return result, err
+}
+
+// Tests the listings are cached until an object is written under their
+// prefix, and a listing never misses an object written through the cache.
+func TestListCacheObjects(t *testing.T) {
+	ExecObjectLayerTest(t, testListCacheObjects)
+}
+
+func testListCacheObjects(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	cache := newListCacheObjects(obj, time.Minute)
+	if err := cache.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+
+	putObject := func(objAPI ObjectLayer, object string) {
+		data := []byte("data")
+		if _, err := objAPI.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+	expectListing := func(testName string, objAPI ObjectLayer, prefix string, expected ...string) {
+		result, err := objAPI.ListObjects(bucket, prefix, "", "", 1000)
+		if err != nil {
+			t.Fatalf("%s: %s: Failed to list objects: <ERROR> %v", instanceType, testName, err)
+		}
+		var objects []string
+		for _, objInfo := range result.Objects {
+			objects = append(objects, objInfo.Name)
+		}
+		if len(objects) != len(expected) {
+			t.Fatalf("%s: %s: Expected objects %v, got %v", instanceType, testName, expected, objects)
+		}
+		for i := range expected {
+			if objects[i] != expected[i] {
+				t.Fatalf("%s: %s: Expected objects %v, got %v", instanceType, testName, expected, objects)
+			}
+		}
+	}
+
+	putObject(cache, "logs/1")
+	putObject(cache, "data/1")
+	expectListing("first listing", cache, "logs/", "logs/1")
+	expectListing("other prefix", cache, "data/", "data/1")
+
+	// Objects written to the backend only are not seen while cached.
+	putObject(obj, "logs/2")
+	expectListing("cached listing", cache, "logs/", "logs/1")
+
+	// Writes under other prefixes keep the listing cached.
+	putObject(cache, "data/2")
+	expectListing("cached after other write", cache, "logs/", "logs/1")
+	expectListing("other prefix after write", cache, "data/", "data/1", "data/2")
+
+	// A PUT under the prefix drops the cached listing.
+	putObject(cache, "logs/3")
+	expectListing("listing after put", cache, "logs/", "logs/1", "logs/2", "logs/3")
+
+	// So does a DELETE.
+	if err := cache.DeleteObject(bucket, "logs/1"); err != nil {
+		t.Fatal(err)
+	}
+	expectListing("listing after delete", cache, "logs/", "logs/2", "logs/3")
+
+	// A listing running while an object is written is not cached.
+	racing := &writeDuringListObjects{ObjectLayer: obj, object: "logs/4"}
+	racingCache := newListCacheObjects(racing, time.Minute)
+	racing.cache = racingCache
+	expectListing("listing during put", racingCache, "logs/", "logs/2", "logs/3")
+	expectListing("listing after racing put", racingCache, "logs/", "logs/2", "logs/3", "logs/4")
+
+	// Cached listings expire.
+	expiring := newListCacheObjects(obj, time.Millisecond)
+	expectListing("expiring listing", expiring, "data/", "data/1", "data/2")
+	putObject(obj, "data/3")
+	time.Sleep(10 * time.Millisecond)
+	expectListing("expired listing", expiring, "data/", "data/1", "data/2", "data/3")
+}
@@ -0,0 +1,173 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Minio extension to ListObjects, the `x-minio-meta-filter` query
+// param `key=value` lists only the objects whose user metadata key is
+// set to value.
+const metadataFilterQueryParam = "x-minio-meta-filter"
+
+// Default maximum number of entries scanned by a filtered listing
+// served without the metadata index.
+const globalDefaultMetadataFilterMaxScan = 10000
+
+// setMetadataFilterMaxScan - sets the maximum number of entries scanned
+// by a filtered listing from MINIO_METADATA_FILTER_MAX_SCAN env.
+func setMetadataFilterMaxScan() {
+	if maxScan := os.Getenv("MINIO_METADATA_FILTER_MAX_SCAN"); maxScan != "" {
+		n, err := strconv.Atoi(maxScan)
+		if err != nil || n < 1 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_METADATA_FILTER_MAX_SCAN value %s.", maxScan)
+		}
+		globalMetadataFilterMaxScan = n
+	}
+}
+
+// getMetadataFilter - returns the match of the `x-minio-meta-filter`
+// query param, nil if absent.
+func getMetadataFilter(values url.Values) (*metadataPredicate, APIErrorCode) {
+	filters, ok := values[metadataFilterQueryParam]
+	if !ok {
+		return nil, ErrNone
+	}
+	if len(filters) != 1 {
+		return nil, ErrInvalidMetadataFilter
+	}
+	filter, ok := parseMetadataPredicate(filters[0])
+	if !ok || filter.exists {
+		return nil, ErrInvalidMetadataFilter
+	}
+	return &filter, ErrNone
+}
+
+// objectMatchesMetadata - returns true if the user metadata key of the
+// object is set to the value of the filter. The metadata is looked up,
+// not all the backends list the objects along with their metadata.
+func objectMatchesMetadata(objectAPI ObjectLayer, bucket, object string, filter metadataPredicate) (bool, error) {
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		if isErrObjectNotFound(err) {
+			// Deleted since listed.
+			return false, nil
+		}
+		return false, err
+	}
+	for key, value := range objInfo.UserDefined {
+		if strings.ToLower(key) == userMetadataPrefix+filter.key {
+			return value == filter.value, nil
+		}
+	}
+	return false, nil
+}
+
+// trimListObjectsInfo - keeps the first maxKeys objects and prefixes
+// in lexical order, the result is then truncated after the last one.
+func trimListObjectsInfo(result ListObjectsInfo, maxKeys int) ListObjectsInfo {
+	if len(result.Objects)+len(result.Prefixes) <= maxKeys {
+		return result
+	}
+	names := make([]string, 0, len(result.Objects)+len(result.Prefixes))
+	for _, objInfo := range result.Objects {
+		names = append(names, objInfo.Name)
+	}
+	names = append(names, result.Prefixes...)
+	sort.Strings(names)
+	last := names[maxKeys-1]
+
+	trimmed := ListObjectsInfo{IsTruncated: true, NextMarker: last}
+	for _, objInfo := range result.Objects {
+		if objInfo.Name <= last {
+			trimmed.Objects = append(trimmed.Objects, objInfo)
+		}
+	}
+	for _, prefix := range result.Prefixes {
+		if prefix <= last {
+			trimmed.Prefixes = append(trimmed.Prefixes, prefix)
+		}
+	}
+	return trimmed
+}
+
+// listObjectsWithMetadataFilter - lists upto maxKeys objects like
+// ListObjects, limited to the objects matching the filter if not nil.
+// Flat listings of an indexed key are served by the metadata index,
+// others scan the listing. A scan stops after the maximum number of
+// entries scanned, the listing is then truncated after the last entry
+// scanned even if fewer than maxKeys objects matched.
+func listObjectsWithMetadataFilter(objectAPI ObjectLayer, bucket, prefix, marker, delimiter string, maxKeys int, filter *metadataPredicate) (ListObjectsInfo, error) {
+	if filter == nil {
+		return objectAPI.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	}
+
+	// With max keys of zero we have reached eof, return right here.
+	if maxKeys == 0 {
+		return ListObjectsInfo{}, nil
+	}
+
+	if index, ok := objectAPI.(*metadataIndexObjects); ok && delimiter == "" && index.isIndexed(filter.key) {
+		result, err := index.Search(bucket, prefix, marker, []metadataPredicate{*filter}, maxKeys)
+		if errorCause(err) != errMetadataIndexNotReady {
+			return result, err
+		}
+		// Scan while the index of the bucket is being built.
+	}
+
+	result := ListObjectsInfo{}
+	scanned := 0
+	for {
+		pageKeys := maxObjectList
+		if remaining := globalMetadataFilterMaxScan - scanned; remaining < pageKeys {
+			pageKeys = remaining
+		}
+		page, err := objectAPI.ListObjects(bucket, prefix, marker, delimiter, pageKeys)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		for _, objInfo := range page.Objects {
+			if objInfo.IsDir {
+				continue
+			}
+			matched, mErr := objectMatchesMetadata(objectAPI, bucket, objInfo.Name, *filter)
+			if mErr != nil {
+				return ListObjectsInfo{}, mErr
+			}
+			if matched {
+				result.Objects = append(result.Objects, objInfo)
+			}
+		}
+		result.Prefixes = append(result.Prefixes, page.Prefixes...)
+		scanned += len(page.Objects) + len(page.Prefixes)
+		if !page.IsTruncated {
+			break
+		}
+		marker = page.NextMarker
+		if len(result.Objects)+len(result.Prefixes) >= maxKeys || scanned >= globalMetadataFilterMaxScan {
+			result.IsTruncated = true
+			result.NextMarker = marker
+			break
+		}
+	}
+	return trimListObjectsInfo(result, maxKeys), nil
+}
@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// Tests the filtered listings scan a bounded number of entries, and
+// are served by the metadata index once built.
+func TestListObjectsWithMetadataFilter(t *testing.T) {
+	ExecObjectLayerTest(t, testListObjectsWithMetadataFilter)
+}
+
+func testListObjectsWithMetadataFilter(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	defer func(maxScan int) { globalMetadataFilterMaxScan = maxScan }(globalMetadataFilterMaxScan)
+
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+	putObject := func(objAPI ObjectLayer, object, color string) {
+		metadata := map[string]string{"X-Amz-Meta-Color": color}
+		if _, err := objAPI.PutObject(bucket, object, int64(len("data")), bytes.NewReader([]byte("data")), metadata, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+	for i := 0; i < 10; i++ {
+		color := "blue"
+		if i%2 == 0 {
+			color = "red"
+		}
+		putObject(obj, fmt.Sprintf("o%d", i), color)
+	}
+
+	filter := &metadataPredicate{key: "color", value: "red"}
+	// listAll - lists all the pages, returns the objects and the number of pages.
+	listAll := func(objAPI ObjectLayer, maxKeys int) (string, int) {
+		var names []string
+		marker := ""
+		for pages := 1; ; pages++ {
+			result, err := listObjectsWithMetadataFilter(objAPI, bucket, "", marker, "", maxKeys, filter)
+			if err != nil {
+				t.Fatalf("%s: Failed to list objects: <ERROR> %v", instanceType, err)
+			}
+			for _, objInfo := range result.Objects {
+				names = append(names, objInfo.Name)
+			}
+			if !result.IsTruncated {
+				return strings.Join(names, ","), pages
+			}
+			marker = result.NextMarker
+		}
+	}
+
+	// Scans are truncated past the maximum number of entries scanned.
+	globalMetadataFilterMaxScan = 3
+	if names, pages := listAll(obj, 1000); names != "o0,o2,o4,o6,o8" || pages != 4 {
+		t.Fatalf("%s: Expected o0,o2,o4,o6,o8 in 4 pages, got %s in %d pages", instanceType, names, pages)
+	}
+	globalMetadataFilterMaxScan = globalDefaultMetadataFilterMaxScan
+	if names, pages := listAll(obj, 2); names != "o0,o2,o4,o6,o8" || pages != 3 {
+		t.Fatalf("%s: Expected o0,o2,o4,o6,o8 in 3 pages, got %s in %d pages", instanceType, names, pages)
+	}
+
+	// Scans are used until the index of the bucket is built.
+	index := newMetadataIndexObjects(obj, []string{"color"})
+	if names, _ := listAll(index, 1000); names != "o0,o2,o4,o6,o8" {
+		t.Fatalf("%s: Expected o0,o2,o4,o6,o8, got %s", instanceType, names)
+	}
+	if err := index.Rebuild(); err != nil {
+		t.Fatalf("%s: Failed to build the index: <ERROR> %v", instanceType, err)
+	}
+
+	// Objects written to the backend only are not seen by the index.
+	putObject(obj, "p", "red")
+	if names, _ := listAll(index, 1000); names != "o0,o2,o4,o6,o8" {
+		t.Fatalf("%s: Expected the listing to be served by the index, got %s", instanceType, names)
+	}
+	if names, _ := listAll(obj, 1000); names != "o0,o2,o4,o6,o8,p" {
+		t.Fatalf("%s: Expected o0,o2,o4,o6,o8,p, got %s", instanceType, names)
+	}
+}
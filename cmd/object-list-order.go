@@ -0,0 +1,169 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"container/heap"
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Minio extension to ListObjectsV2, the `x-minio-order` query param
+// selects the order of the listed objects.
+const (
+	listOrderQueryParam = "x-minio-order"
+
+	// Standard S3 lexical order of the object names, the default.
+	listOrderLexical = "lexical"
+	// Objects sorted by last modified time, newest first.
+	listOrderNewestFirst = "newest-first"
+)
+
+// errInvalidListOrderToken - continuation token is not a valid
+// newest-first listing token.
+var errInvalidListOrderToken = errors.New("Invalid continuation token")
+
+// Parse `x-minio-order` query param, defaults to lexical order.
+func getListObjectsOrder(order string) (string, APIErrorCode) {
+	switch order {
+	case "", listOrderLexical:
+		return listOrderLexical, ErrNone
+	case listOrderNewestFirst:
+		return listOrderNewestFirst, ErrNone
+	}
+	return "", ErrInvalidListOrder
+}
+
+// byModTimeDesc - sorts objects by last modified time newest first,
+// objects with the same time are sorted by name.
+type byModTimeDesc []ObjectInfo
+
+func (o byModTimeDesc) Len() int      { return len(o) }
+func (o byModTimeDesc) Swap(i, j int) { o[i], o[j] = o[j], o[i] }
+func (o byModTimeDesc) Less(i, j int) bool {
+	return isNewerListEntry(o[i].ModTime, o[i].Name, o[j].ModTime, o[j].Name)
+}
+
+// oldestFirstHeap - heap of the objects kept for a newest-first page,
+// the oldest kept object is at the top to be replaced by newer ones.
+type oldestFirstHeap []ObjectInfo
+
+func (h oldestFirstHeap) Len() int      { return len(h) }
+func (h oldestFirstHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h oldestFirstHeap) Less(i, j int) bool {
+	return isNewerListEntry(h[j].ModTime, h[j].Name, h[i].ModTime, h[i].Name)
+}
+func (h *oldestFirstHeap) Push(x interface{}) { *h = append(*h, x.(ObjectInfo)) }
+func (h *oldestFirstHeap) Pop() interface{} {
+	old := *h
+	objInfo := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return objInfo
+}
+
+// isNewerListEntry - returns true if the first entry is listed before
+// the second one in newest-first order.
+func isNewerListEntry(modTime1 time.Time, name1 string, modTime2 time.Time, name2 string) bool {
+	if !modTime1.Equal(modTime2) {
+		return modTime1.After(modTime2)
+	}
+	return name1 < name2
+}
+
+// Continuation token of newest-first listing holds the last modified
+// time and name of the last listed object.
+func encodeListOrderToken(objInfo ObjectInfo) string {
+	token := strconv.FormatInt(objInfo.ModTime.UnixNano(), 10) + slashSeparator + objInfo.Name
+	return base64.StdEncoding.EncodeToString([]byte(token))
+}
+
+func decodeListOrderToken(token string) (modTime time.Time, name string, err error) {
+	data, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return time.Time{}, "", errInvalidListOrderToken
+	}
+	fields := strings.SplitN(string(data), slashSeparator, 2)
+	if len(fields) != 2 || fields[1] == "" {
+		return time.Time{}, "", errInvalidListOrderToken
+	}
+	nsec, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return time.Time{}, "", errInvalidListOrderToken
+	}
+	return time.Unix(0, nsec).UTC(), fields[1], nil
+}
+
+// listObjectsNewestFirst - lists upto maxKeys objects under prefix
+// sorted by last modified time newest first, continuing after token.
+// All the objects under prefix are scanned for every page, only the
+// newest maxKeys objects not listed yet are kept while scanning.
+func listObjectsNewestFirst(objectAPI ObjectLayer, bucket, prefix, token string, maxKeys int) (ListObjectsInfo, error) {
+	var tokenModTime time.Time
+	var tokenName string
+	if token != "" {
+		var err error
+		if tokenModTime, tokenName, err = decodeListOrderToken(token); err != nil {
+			return ListObjectsInfo{}, err
+		}
+	}
+
+	// With max keys of zero we have reached eof, return right here.
+	if maxKeys == 0 {
+		return ListObjectsInfo{}, nil
+	}
+
+	// One more object is kept to know if the page is truncated.
+	var kept oldestFirstHeap
+	marker := ""
+	for {
+		result, err := objectAPI.ListObjects(bucket, prefix, marker, "", maxObjectList)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		for _, objInfo := range result.Objects {
+			// Skip all the objects listed in the previous pages.
+			if token != "" && !isNewerListEntry(tokenModTime, tokenName, objInfo.ModTime, objInfo.Name) {
+				continue
+			}
+			if kept.Len() <= maxKeys {
+				heap.Push(&kept, objInfo)
+			} else if isNewerListEntry(objInfo.ModTime, objInfo.Name, kept[0].ModTime, kept[0].Name) {
+				kept[0] = objInfo
+				heap.Fix(&kept, 0)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	objects := []ObjectInfo(kept)
+	sort.Sort(byModTimeDesc(objects))
+
+	result := ListObjectsInfo{}
+	if len(objects) > maxKeys {
+		objects = objects[:maxKeys]
+		result.IsTruncated = true
+		result.NextMarker = encodeListOrderToken(objects[len(objects)-1])
+	}
+	result.Objects = objects
+	return result, nil
+}
@@ -0,0 +1,170 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const (
+	// Version of the continuation tokens format.
+	listTokenVersion = 1
+
+	// Flags of the continuation tokens.
+	listTokenCompressed = 1 << 0
+	listTokenSigned     = 1 << 1
+
+	// Size of the truncated HMAC-SHA256 of the signed tokens.
+	listTokenMACSize = 16
+
+	// Maximum size of the marker of a token once decompressed, past
+	// the longest object names and newest-first listing tokens.
+	listTokenMaxMarkerSize = 4096
+)
+
+// errInvalidListToken - continuation token is malformed, tampered with
+// or was not issued by the server.
+var errInvalidListToken = errors.New("Invalid continuation token")
+
+// setListTokenCompression - sets the compression of the continuation
+// tokens from MINIO_LIST_TOKEN_COMPRESSION env.
+func setListTokenCompression() {
+	if compression := os.Getenv("MINIO_LIST_TOKEN_COMPRESSION"); compression != "" {
+		switch strings.ToLower(compression) {
+		case "on":
+			globalIsListTokenCompression = true
+		case "off":
+			globalIsListTokenCompression = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_LIST_TOKEN_COMPRESSION value %s.", compression)
+		}
+	}
+}
+
+// setListTokenSigning - sets the signing of the continuation tokens from
+// MINIO_LIST_TOKEN_SIGNING env.
+func setListTokenSigning() {
+	if signing := os.Getenv("MINIO_LIST_TOKEN_SIGNING"); signing != "" {
+		switch strings.ToLower(signing) {
+		case "on":
+			globalIsListTokenSigning = true
+		case "off":
+			globalIsListTokenSigning = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_LIST_TOKEN_SIGNING value %s.", signing)
+		}
+	}
+}
+
+// getListTokenMAC - returns the truncated HMAC-SHA256 of the token of
+// the bucket, keyed by the secret key of the server shared by all the
+// nodes. Tokens are only valid for the bucket they were issued for.
+func getListTokenMAC(bucket string, token []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(serverConfig.GetCredential().SecretKey))
+	mac.Write([]byte(bucket))
+	mac.Write([]byte{0})
+	mac.Write(token)
+	return mac.Sum(nil)[:listTokenMACSize]
+}
+
+// encodeListToken - returns the opaque continuation token of the marker
+// of a listing of the bucket. The marker is gzip compressed when it
+// makes the token shorter, and the token is signed if enabled.
+func encodeListToken(bucket, marker string) string {
+	if marker == "" {
+		return ""
+	}
+
+	flags := byte(0)
+	payload := []byte(marker)
+	if globalIsListTokenCompression {
+		var buffer bytes.Buffer
+		gzipWriter, _ := gzip.NewWriterLevel(&buffer, gzip.BestCompression)
+		gzipWriter.Write(payload)
+		gzipWriter.Close()
+		if buffer.Len() < len(payload) {
+			flags |= listTokenCompressed
+			payload = buffer.Bytes()
+		}
+	}
+	if globalIsListTokenSigning {
+		flags |= listTokenSigned
+	}
+
+	token := append([]byte{listTokenVersion, flags}, payload...)
+	if flags&listTokenSigned != 0 {
+		token = append(token, getListTokenMAC(bucket, token)...)
+	}
+	return base64.RawURLEncoding.EncodeToString(token)
+}
+
+// decodeListToken - returns the marker of the continuation token of a
+// listing of the bucket. Unsigned tokens are rejected while signing is
+// enabled, so that no marker is forged.
+func decodeListToken(bucket, token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < 2 || data[0] != listTokenVersion {
+		return "", errInvalidListToken
+	}
+	flags := data[1]
+	if flags&^(listTokenCompressed|listTokenSigned) != 0 {
+		return "", errInvalidListToken
+	}
+	if globalIsListTokenSigning && flags&listTokenSigned == 0 {
+		return "", errInvalidListToken
+	}
+	if flags&listTokenSigned != 0 {
+		if len(data) < 2+listTokenMACSize {
+			return "", errInvalidListToken
+		}
+		mac := data[len(data)-listTokenMACSize:]
+		data = data[:len(data)-listTokenMACSize]
+		if !hmac.Equal(mac, getListTokenMAC(bucket, data)) {
+			return "", errInvalidListToken
+		}
+	}
+
+	payload := data[2:]
+	if flags&listTokenCompressed != 0 {
+		gzipReader, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return "", errInvalidListToken
+		}
+		// Decompressed size is bounded, the token may be forged.
+		payload, err = ioutil.ReadAll(io.LimitReader(gzipReader, listTokenMaxMarkerSize+1))
+		if err != nil {
+			return "", errInvalidListToken
+		}
+	}
+	if len(payload) == 0 || len(payload) > listTokenMaxMarkerSize {
+		return "", errInvalidListToken
+	}
+	return string(payload), nil
+}
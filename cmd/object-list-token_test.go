@@ -0,0 +1,187 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Tests the markers round-trip through the continuation tokens, and the
+// tampered tokens are rejected.
+func TestListToken(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+	defer func(compression, signing bool) {
+		globalIsListTokenCompression, globalIsListTokenSigning = compression, signing
+	}(globalIsListTokenCompression, globalIsListTokenSigning)
+
+	longMarker := strings.Repeat("photos/2017/06/01/", 20) + "image.jpg"
+	for _, compression := range []bool{false, true} {
+		for _, signing := range []bool{false, true} {
+			globalIsListTokenCompression, globalIsListTokenSigning = compression, signing
+			for _, marker := range []string{"a", "logs/b", longMarker} {
+				token := encodeListToken("bucket", marker)
+				if strings.Contains(token, "/") {
+					t.Fatalf("Compression %v, signing %v: Expected an opaque token, got %s", compression, signing, token)
+				}
+				decoded, err := decodeListToken("bucket", token)
+				if err != nil {
+					t.Fatalf("Compression %v, signing %v: Unexpected error %v", compression, signing, err)
+				}
+				if decoded != marker {
+					t.Fatalf("Compression %v, signing %v: Expected %s, got %s", compression, signing, marker, decoded)
+				}
+			}
+		}
+	}
+
+	// Long markers are compressed.
+	globalIsListTokenCompression, globalIsListTokenSigning = true, false
+	compressed := encodeListToken("bucket", longMarker)
+	globalIsListTokenCompression = false
+	if uncompressed := encodeListToken("bucket", longMarker); len(compressed) >= len(uncompressed) {
+		t.Fatalf("Expected the compressed token to be shorter, got %d and %d bytes", len(compressed), len(uncompressed))
+	}
+
+	globalIsListTokenCompression, globalIsListTokenSigning = true, true
+	token := encodeListToken("bucket", longMarker)
+	data, _ := base64.RawURLEncoding.DecodeString(token)
+	tampered := append([]byte{}, data...)
+	tampered[3] ^= 0xff
+	globalIsListTokenSigning = false
+	unsigned := encodeListToken("bucket", "a")
+	globalIsListTokenSigning = true
+
+	testCases := []struct {
+		bucket, token string
+	}{
+		// Not base64.
+		{"bucket", "logs/b"},
+		// Tampered payload.
+		{"bucket", base64.RawURLEncoding.EncodeToString(tampered)},
+		// Truncated MAC.
+		{"bucket", base64.RawURLEncoding.EncodeToString(data[:len(data)-1])},
+		// Issued for another bucket.
+		{"other", token},
+		// Unknown version.
+		{"bucket", base64.RawURLEncoding.EncodeToString(append([]byte{2}, data[1:]...))},
+		// Unsigned while signing is enabled.
+		{"bucket", unsigned},
+	}
+	for i, testCase := range testCases {
+		if _, err := decodeListToken(testCase.bucket, testCase.token); err != errInvalidListToken {
+			t.Errorf("Test %d: Expected errInvalidListToken, got %v", i+1, err)
+		}
+	}
+}
+
+// Tests the pagination of ListObjectsV2 with signed and compressed
+// continuation tokens.
+func TestListObjectsV2TokenHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testListObjectsV2TokenHandler, []string{"ListObjectsV2"})
+}
+
+func testListObjectsV2TokenHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(compression, signing bool) {
+		globalIsListTokenCompression, globalIsListTokenSigning = compression, signing
+	}(globalIsListTokenCompression, globalIsListTokenSigning)
+	globalIsListTokenCompression, globalIsListTokenSigning = true, true
+
+	prefix := strings.Repeat("deep/", 50)
+	var objects []string
+	for i := 0; i < 7; i++ {
+		object := fmt.Sprintf("%sobject-%d", prefix, i)
+		if _, err := obj.PutObject(bucketName, object, 0, bytes.NewReader(nil), nil, ""); err != nil {
+			t.Fatalf("Minio %s: %v", instanceType, err)
+		}
+		objects = append(objects, object)
+	}
+
+	listObjects := func(token string) *httptest.ResponseRecorder {
+		values := url.Values{}
+		values.Set("list-type", "2")
+		values.Set("max-keys", "3")
+		if token != "" {
+			values.Set("continuation-token", token)
+		}
+		req, err := newTestSignedRequestV4("GET", makeTestTargetURL("", bucketName, "", values),
+			0, nil, credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request for ListObjectsV2: <ERROR> %v", instanceType, err)
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	var listed []string
+	var lastToken string
+	token := ""
+	for page := 1; ; page++ {
+		rec := listObjects(token)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Minio %s: Page %d: Expected status %d, got %d", instanceType, page, http.StatusOK, rec.Code)
+		}
+		var response ListObjectsV2Response
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Minio %s: %v", instanceType, err)
+		}
+		for _, object := range response.Contents {
+			listed = append(listed, object.Key)
+		}
+		if !response.IsTruncated {
+			break
+		}
+		// Tokens are shorter than the object names they continue after.
+		if strings.Contains(response.NextContinuationToken, "deep") || len(response.NextContinuationToken) >= len(prefix) {
+			t.Fatalf("Minio %s: Page %d: Expected a compact opaque token, got %s", instanceType, page, response.NextContinuationToken)
+		}
+		token, lastToken = response.NextContinuationToken, response.NextContinuationToken
+	}
+	if strings.Join(listed, ",") != strings.Join(objects, ",") {
+		t.Fatalf("Minio %s: Expected %v, got %v", instanceType, objects, listed)
+	}
+
+	// Tampered and forged tokens are rejected.
+	data, _ := base64.RawURLEncoding.DecodeString(lastToken)
+	data[len(data)/2] ^= 0x01
+	for i, token := range []string{base64.RawURLEncoding.EncodeToString(data), objects[2]} {
+		rec := listObjects(token)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+		var errResponse APIErrorResponse
+		if err := xml.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+			t.Fatalf("Minio %s: %v", instanceType, err)
+		}
+		if errResponse.Code != "InvalidToken" {
+			t.Fatalf("Minio %s: Test %d: Expected InvalidToken, got %s", instanceType, i+1, errResponse.Code)
+		}
+	}
+}
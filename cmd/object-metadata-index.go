@@ -0,0 +1,444 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Prefix of the user metadata headers.
+const userMetadataPrefix = "x-amz-meta-"
+
+// errMetadataIndexNotReady - the index of the bucket is being built.
+var errMetadataIndexNotReady = errors.New("Metadata index of the bucket is being built")
+
+// setMetadataIndexKeys - sets the user metadata keys indexed from
+// MINIO_METADATA_INDEX_KEYS env.
+func setMetadataIndexKeys() {
+	if keys := os.Getenv("MINIO_METADATA_INDEX_KEYS"); keys != "" {
+		globalMetadataIndexKeys = parseMetadataIndexKeys(keys)
+		if len(globalMetadataIndexKeys) == 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_METADATA_INDEX_KEYS value %s.", keys)
+		}
+	}
+}
+
+// parseMetadataIndexKeys - parses a comma separated list of user
+// metadata keys, with or without their `x-amz-meta-` prefix. Keys are
+// case insensitive and returned lower cased without their prefix.
+func parseMetadataIndexKeys(keys string) []string {
+	var indexKeys []string
+	for _, key := range strings.Split(keys, ",") {
+		key = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(key)), userMetadataPrefix)
+		if key != "" {
+			indexKeys = append(indexKeys, key)
+		}
+	}
+	return indexKeys
+}
+
+// metadataPredicate - matches the objects whose user metadata key is
+// set to value, or is set at all if exists is true.
+type metadataPredicate struct {
+	key    string
+	value  string
+	exists bool
+}
+
+// parseMetadataPredicate - parses a `key=value` or `key` predicate.
+func parseMetadataPredicate(predicate string) (metadataPredicate, bool) {
+	key, value, exists := predicate, "", true
+	if i := strings.Index(predicate, "="); i >= 0 {
+		key, value, exists = predicate[:i], predicate[i+1:], false
+	}
+	key = strings.TrimPrefix(strings.ToLower(key), userMetadataPrefix)
+	if key == "" {
+		return metadataPredicate{}, false
+	}
+	return metadataPredicate{key: key, value: value, exists: exists}, true
+}
+
+// metadataIndexEntry - indexed object, its user metadata is limited to
+// the indexed keys.
+type metadataIndexEntry struct {
+	objInfo  ObjectInfo
+	metadata map[string]string
+}
+
+// matches - returns if the entry matches the predicate.
+func (e metadataIndexEntry) matches(p metadataPredicate) bool {
+	value, ok := e.metadata[p.key]
+	return ok && (p.exists || value == p.value)
+}
+
+// metadataBucketIndex - index of the objects of a bucket having at least
+// one of the indexed keys, along with the objects of each value of each
+// key.
+type metadataBucketIndex struct {
+	objects map[string]metadataIndexEntry
+	values  map[string]map[string]map[string]struct{}
+}
+
+func newMetadataBucketIndex() *metadataBucketIndex {
+	return &metadataBucketIndex{
+		objects: make(map[string]metadataIndexEntry),
+		values:  make(map[string]map[string]map[string]struct{}),
+	}
+}
+
+// add - indexes the object, replacing its previous entry if any.
+func (b *metadataBucketIndex) add(object string, entry metadataIndexEntry) {
+	b.remove(object)
+	if len(entry.metadata) == 0 {
+		return
+	}
+	b.objects[object] = entry
+	for key, value := range entry.metadata {
+		if b.values[key] == nil {
+			b.values[key] = make(map[string]map[string]struct{})
+		}
+		if b.values[key][value] == nil {
+			b.values[key][value] = make(map[string]struct{})
+		}
+		b.values[key][value][object] = struct{}{}
+	}
+}
+
+// remove - drops the entry of the object if any.
+func (b *metadataBucketIndex) remove(object string) {
+	entry, ok := b.objects[object]
+	if !ok {
+		return
+	}
+	delete(b.objects, object)
+	for key, value := range entry.metadata {
+		delete(b.values[key][value], object)
+		if len(b.values[key][value]) == 0 {
+			delete(b.values[key], value)
+		}
+		if len(b.values[key]) == 0 {
+			delete(b.values, key)
+		}
+	}
+}
+
+// candidates - returns the objects matching the predicate.
+func (b *metadataBucketIndex) candidates(p metadataPredicate) map[string]struct{} {
+	if !p.exists {
+		return b.values[p.key][p.value]
+	}
+	objects := make(map[string]struct{})
+	for _, valueObjects := range b.values[p.key] {
+		for object := range valueObjects {
+			objects[object] = struct{}{}
+		}
+	}
+	return objects
+}
+
+// metadataIndexObjects - object layer indexing selected user metadata
+// keys of the objects of the backend object layer, so that the objects
+// can be searched by their metadata without walking the bucket. The
+// index is held in memory, updated by every write going through this
+// server and rebuilt from the backend when the server starts.
+type metadataIndexObjects struct {
+	ObjectLayer
+
+	keys map[string]struct{}
+
+	mutex   sync.RWMutex
+	buckets map[string]*metadataBucketIndex
+	// Objects written while the index of the bucket is rebuilt, their
+	// entries are taken from the live index once the rebuild ends.
+	rebuilds map[string]map[string]struct{}
+}
+
+// newMetadataIndexObjects - returns the object layer indexing the keys
+// of the backend object layer. The indexes of the buckets are empty
+// until rebuilt.
+func newMetadataIndexObjects(objAPI ObjectLayer, keys []string) *metadataIndexObjects {
+	indexKeys := make(map[string]struct{})
+	for _, key := range keys {
+		indexKeys[key] = struct{}{}
+	}
+	return &metadataIndexObjects{
+		ObjectLayer: objAPI,
+		keys:        indexKeys,
+		buckets:     make(map[string]*metadataBucketIndex),
+		rebuilds:    make(map[string]map[string]struct{}),
+	}
+}
+
+// newEntry - returns the index entry of the object.
+func (m *metadataIndexObjects) newEntry(objInfo ObjectInfo) metadataIndexEntry {
+	metadata := make(map[string]string)
+	for key, value := range objInfo.UserDefined {
+		key = strings.ToLower(key)
+		if !strings.HasPrefix(key, userMetadataPrefix) {
+			continue
+		}
+		key = strings.TrimPrefix(key, userMetadataPrefix)
+		if _, ok := m.keys[key]; ok {
+			metadata[key] = value
+		}
+	}
+	objInfo.UserDefined = nil
+	return metadataIndexEntry{objInfo: objInfo, metadata: metadata}
+}
+
+// update - indexes the written object, drops its entry if deleted.
+func (m *metadataIndexObjects) update(bucket, object string, objInfo ObjectInfo, deleted bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	// Buckets not indexed yet are indexed by their rebuild.
+	objects, rebuilding := m.rebuilds[bucket]
+	if rebuilding {
+		objects[object] = struct{}{}
+	}
+	index, ok := m.buckets[bucket]
+	if !ok {
+		if !rebuilding {
+			return
+		}
+		index = newMetadataBucketIndex()
+		m.buckets[bucket] = index
+	}
+	if deleted {
+		index.remove(object)
+		return
+	}
+	index.add(object, m.newEntry(objInfo))
+}
+
+// RebuildBucket - rebuilds the index of the bucket from the objects of
+// the backend. The objects written during the rebuild keep their
+// entries of the live index.
+func (m *metadataIndexObjects) RebuildBucket(bucket string) error {
+	m.mutex.Lock()
+	_, indexed := m.buckets[bucket]
+	m.rebuilds[bucket] = make(map[string]struct{})
+	m.mutex.Unlock()
+
+	index := newMetadataBucketIndex()
+	err := m.walkBucket(bucket, index)
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	written := m.rebuilds[bucket]
+	delete(m.rebuilds, bucket)
+	if err != nil {
+		// Drop the partial index of the writes during the rebuild.
+		if !indexed {
+			delete(m.buckets, bucket)
+		}
+		return err
+	}
+	if live, ok := m.buckets[bucket]; ok {
+		for object := range written {
+			index.remove(object)
+			if entry, ok := live.objects[object]; ok {
+				index.add(object, entry)
+			}
+		}
+	}
+	m.buckets[bucket] = index
+	return nil
+}
+
+// walkBucket - indexes all the objects of the bucket.
+func (m *metadataIndexObjects) walkBucket(bucket string, index *metadataBucketIndex) error {
+	marker := ""
+	for {
+		result, err := m.ObjectLayer.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, listed := range result.Objects {
+			objInfo, err := m.ObjectLayer.GetObjectInfo(bucket, listed.Name)
+			if err != nil {
+				// Objects deleted since listed are not indexed.
+				if isErrObjectNotFound(err) {
+					continue
+				}
+				return err
+			}
+			index.add(listed.Name, m.newEntry(objInfo))
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// Rebuild - rebuilds the indexes of all the buckets.
+func (m *metadataIndexObjects) Rebuild() error {
+	buckets, err := m.ObjectLayer.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range buckets {
+		if err = m.RebuildBucket(bucket.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isIndexed - returns if the user metadata key is indexed.
+func (m *metadataIndexObjects) isIndexed(key string) bool {
+	_, ok := m.keys[key]
+	return ok
+}
+
+// Search - lists the objects of the bucket under the prefix, after the
+// marker, matching all the predicates in lexical order. At least one
+// predicate is expected.
+func (m *metadataIndexObjects) Search(bucket, prefix, marker string, predicates []metadataPredicate, maxKeys int) (ListObjectsInfo, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	index, ok := m.buckets[bucket]
+	if !ok {
+		return ListObjectsInfo{}, traceError(errMetadataIndexNotReady)
+	}
+	if _, ok = m.rebuilds[bucket]; ok {
+		return ListObjectsInfo{}, traceError(errMetadataIndexNotReady)
+	}
+
+	// Start from the predicate matching the fewest objects.
+	var candidates map[string]struct{}
+	for i, p := range predicates {
+		objects := index.candidates(p)
+		if i == 0 || len(objects) < len(candidates) {
+			candidates = objects
+		}
+	}
+
+	var names []string
+	for object := range candidates {
+		if !strings.HasPrefix(object, prefix) || object <= marker {
+			continue
+		}
+		entry := index.objects[object]
+		matched := true
+		for _, p := range predicates {
+			if !entry.matches(p) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			names = append(names, object)
+		}
+	}
+	sort.Strings(names)
+
+	result := ListObjectsInfo{}
+	if len(names) > maxKeys {
+		names = names[:maxKeys]
+		result.IsTruncated = true
+		if maxKeys > 0 {
+			result.NextMarker = names[maxKeys-1]
+		}
+	}
+	for _, object := range names {
+		result.Objects = append(result.Objects, index.objects[object].objInfo)
+	}
+	return result, nil
+}
+
+// PutObject - writes the object to the backend and indexes it.
+func (m *metadataIndexObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string, sha256sum string) (ObjectInfo, error) {
+	objInfo, err := m.ObjectLayer.PutObject(bucket, object, size, data, metadata, sha256sum)
+	if err == nil {
+		m.update(bucket, object, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// CopyObject - copies the object in the backend and indexes the
+// destination object.
+func (m *metadataIndexObjects) CopyObject(srcBucket, srcObject, destBucket, destObject string, metadata map[string]string) (ObjectInfo, error) {
+	objInfo, err := m.ObjectLayer.CopyObject(srcBucket, srcObject, destBucket, destObject, metadata)
+	if err == nil {
+		m.update(destBucket, destObject, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// CompleteMultipartUpload - completes the upload in the backend and
+// indexes the object.
+func (m *metadataIndexObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (ObjectInfo, error) {
+	objInfo, err := m.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+	if err == nil {
+		m.update(bucket, object, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// RenameObject - moves the object in the backend, its entry is moved
+// to the destination object.
+func (m *metadataIndexObjects) RenameObject(srcBucket, srcObject, destBucket, destObject string) (ObjectInfo, error) {
+	objInfo, err := m.ObjectLayer.RenameObject(srcBucket, srcObject, destBucket, destObject)
+	if err == nil {
+		m.update(srcBucket, srcObject, ObjectInfo{}, true)
+		m.update(destBucket, destObject, objInfo, false)
+	}
+	return objInfo, err
+}
+
+// DeleteObject - deletes the object from the backend and drops its
+// entry.
+func (m *metadataIndexObjects) DeleteObject(bucket, object string) error {
+	err := m.ObjectLayer.DeleteObject(bucket, object)
+	if err == nil || isErrObjectNotFound(err) {
+		m.update(bucket, object, ObjectInfo{}, true)
+	}
+	return err
+}
+
+// MakeBucket - creates the bucket in the backend along with its empty
+// index.
+func (m *metadataIndexObjects) MakeBucket(bucket string) error {
+	if err := m.ObjectLayer.MakeBucket(bucket); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	m.buckets[bucket] = newMetadataBucketIndex()
+	m.mutex.Unlock()
+	return nil
+}
+
+// DeleteBucket - deletes the bucket from the backend and drops its
+// index.
+func (m *metadataIndexObjects) DeleteBucket(bucket string) error {
+	if err := m.ObjectLayer.DeleteBucket(bucket); err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	delete(m.buckets, bucket)
+	m.mutex.Unlock()
+	return nil
+}
@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// Tests the parsing of the indexed keys and of the search predicates.
+func TestParseMetadataIndex(t *testing.T) {
+	keys := parseMetadataIndexKeys(" Color, x-amz-meta-Owner,,X-Amz-Meta-size ")
+	if !reflect.DeepEqual(keys, []string{"color", "owner", "size"}) {
+		t.Fatalf("Unexpected keys %v", keys)
+	}
+	if keys = parseMetadataIndexKeys(" , "); len(keys) != 0 {
+		t.Fatalf("Expected no keys, got %v", keys)
+	}
+
+	testCases := []struct {
+		predicate string
+		expected  metadataPredicate
+		ok        bool
+	}{
+		{"color=red", metadataPredicate{key: "color", value: "red"}, true},
+		{"X-Amz-Meta-Color=Red", metadataPredicate{key: "color", value: "Red"}, true},
+		{"color=", metadataPredicate{key: "color"}, true},
+		{"color", metadataPredicate{key: "color", exists: true}, true},
+		{"color=a=b", metadataPredicate{key: "color", value: "a=b"}, true},
+		{"=red", metadataPredicate{}, false},
+		{"", metadataPredicate{}, false},
+	}
+	for i, testCase := range testCases {
+		predicate, ok := parseMetadataPredicate(testCase.predicate)
+		if ok != testCase.ok || predicate != testCase.expected {
+			t.Fatalf("Test %d: Expected %v %t, got %v %t", i+1, testCase.expected, testCase.ok, predicate, ok)
+		}
+	}
+}
+
+// writeDuringRebuildObjects - object layer writing objects through the
+// metadata index while the index of a bucket is rebuilt, after the
+// backend was listed.
+type writeDuringRebuildObjects struct {
+	ObjectLayer
+
+	index  *metadataIndexObjects
+	write  func(*metadataIndexObjects)
+	listed bool
+}
+
+func (w *writeDuringRebuildObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	result, err := w.ObjectLayer.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err == nil && !w.listed {
+		w.listed = true
+		w.write(w.index)
+	}
+	return result, err
+}
+
+// Tests the index follows the writes and the deletes of the objects,
+// and is rebuilt from the backend.
+func TestMetadataIndexObjects(t *testing.T) {
+	ExecObjectLayerTest(t, testMetadataIndexObjects)
+}
+
+func testMetadataIndexObjects(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket := "bucket"
+	index := newMetadataIndexObjects(obj, []string{"color", "size"})
+
+	// Buckets are not searchable until indexed.
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+	if _, err := index.Search(bucket, "", "", []metadataPredicate{{key: "color", exists: true}}, 1000); errorCause(err) != errMetadataIndexNotReady {
+		t.Fatalf("%s: Expected the index not to be ready, got %v", instanceType, err)
+	}
+	if err := index.Rebuild(); err != nil {
+		t.Fatalf("%s: Failed to build the index: <ERROR> %v", instanceType, err)
+	}
+
+	putObject := func(objAPI ObjectLayer, object string, metadata map[string]string) {
+		data := []byte("data")
+		if _, err := objAPI.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+			t.Fatalf("%s: Failed to upload object %s: <ERROR> %v", instanceType, object, err)
+		}
+	}
+	expectSearch := func(testName, prefix, marker string, maxKeys int, predicates []string, expected ...string) ListObjectsInfo {
+		var searchPredicates []metadataPredicate
+		for _, predicate := range predicates {
+			p, _ := parseMetadataPredicate(predicate)
+			searchPredicates = append(searchPredicates, p)
+		}
+		result, err := index.Search(bucket, prefix, marker, searchPredicates, maxKeys)
+		if err != nil {
+			t.Fatalf("%s: %s: Failed to search objects: <ERROR> %v", instanceType, testName, err)
+		}
+		var objects []string
+		for _, objInfo := range result.Objects {
+			objects = append(objects, objInfo.Name)
+		}
+		if len(objects) != len(expected) {
+			t.Fatalf("%s: %s: Expected objects %v, got %v", instanceType, testName, expected, objects)
+		}
+		for i := range expected {
+			if objects[i] != expected[i] {
+				t.Fatalf("%s: %s: Expected objects %v, got %v", instanceType, testName, expected, objects)
+			}
+		}
+		return result
+	}
+
+	putObject(index, "a/red-small", map[string]string{"X-Amz-Meta-Color": "red", "X-Amz-Meta-Size": "small"})
+	putObject(index, "a/red-large", map[string]string{"X-Amz-Meta-Color": "red", "X-Amz-Meta-Size": "large"})
+	putObject(index, "b/blue", map[string]string{"X-Amz-Meta-Color": "blue", "X-Amz-Meta-Owner": "alice"})
+	putObject(index, "b/none", map[string]string{"X-Amz-Meta-Owner": "bob"})
+
+	expectSearch("equality", "", "", 1000, []string{"color=red"}, "a/red-large", "a/red-small")
+	expectSearch("conjunction", "", "", 1000, []string{"color=red", "size=small"}, "a/red-small")
+	expectSearch("existence", "", "", 1000, []string{"color"}, "a/red-large", "a/red-small", "b/blue")
+	expectSearch("prefix", "b/", "", 1000, []string{"color"}, "b/blue")
+	expectSearch("values are case sensitive", "", "", 1000, []string{"color=Red"})
+	expectSearch("no match", "", "", 1000, []string{"color=blue", "size"})
+
+	// Results are paginated by marker.
+	result := expectSearch("first page", "", "", 2, []string{"color"}, "a/red-large", "a/red-small")
+	if !result.IsTruncated || result.NextMarker != "a/red-small" {
+		t.Fatalf("%s: Expected a truncated result with next marker a/red-small, got %t %s", instanceType, result.IsTruncated, result.NextMarker)
+	}
+	result = expectSearch("second page", "", result.NextMarker, 2, []string{"color"}, "b/blue")
+	if result.IsTruncated {
+		t.Fatalf("%s: Expected the last page not to be truncated", instanceType)
+	}
+
+	// Overwrites replace the indexed values.
+	putObject(index, "a/red-small", map[string]string{"X-Amz-Meta-Color": "green"})
+	expectSearch("old value after overwrite", "", "", 1000, []string{"color=red"}, "a/red-large")
+	expectSearch("new value after overwrite", "", "", 1000, []string{"color=green"}, "a/red-small")
+	expectSearch("dropped key after overwrite", "", "", 1000, []string{"size"}, "a/red-large")
+
+	// Deleted objects are not found anymore.
+	if err := index.DeleteObject(bucket, "a/red-large"); err != nil {
+		t.Fatal(err)
+	}
+	expectSearch("after delete", "", "", 1000, []string{"color=red"})
+	expectSearch("other key after delete", "", "", 1000, []string{"size"})
+	if len(index.buckets[bucket].values["size"]) != 0 {
+		t.Fatalf("%s: Expected the values of the deleted object to be dropped, got %v", instanceType, index.buckets[bucket].values["size"])
+	}
+
+	// Copies index the destination object.
+	srcInfo, err := index.GetObjectInfo(bucket, "b/blue")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = index.CopyObject(bucket, "b/blue", bucket, "c/blue", srcInfo.UserDefined); err != nil {
+		t.Fatal(err)
+	}
+	expectSearch("after copy", "", "", 1000, []string{"color=blue"}, "b/blue", "c/blue")
+
+	// Writes to the backend only are seen once the index is rebuilt.
+	putObject(obj, "d/red", map[string]string{"X-Amz-Meta-Color": "red"})
+	if err := obj.DeleteObject(bucket, "b/blue"); err != nil {
+		t.Fatal(err)
+	}
+	expectSearch("before rebuild", "", "", 1000, []string{"color"}, "a/red-small", "b/blue", "c/blue")
+	if err := index.RebuildBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to rebuild the index: <ERROR> %v", instanceType, err)
+	}
+	expectSearch("after rebuild", "", "", 1000, []string{"color"}, "a/red-small", "c/blue", "d/red")
+
+	// Writes through the index while it is rebuilt are not lost.
+	racing := &writeDuringRebuildObjects{ObjectLayer: obj}
+	racingIndex := newMetadataIndexObjects(racing, []string{"color", "size"})
+	racing.index = racingIndex
+	racing.write = func(m *metadataIndexObjects) {
+		putObject(m, "e/red", map[string]string{"X-Amz-Meta-Color": "red"})
+		if err := m.DeleteObject(bucket, "d/red"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := racingIndex.RebuildBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to rebuild the index: <ERROR> %v", instanceType, err)
+	}
+	index = racingIndex
+	expectSearch("writes during rebuild", "", "", 1000, []string{"color=red"}, "e/red")
+
+	// Deleted buckets drop their index.
+	for _, object := range []string{"a/red-small", "b/none", "c/blue", "e/red"} {
+		if err := index.DeleteObject(bucket, object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := index.DeleteBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := index.buckets[bucket]; ok {
+		t.Fatalf("%s: Expected the index of the deleted bucket to be dropped", instanceType)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Minio extension, a POST on the destination object with the
+// `x-minio-move` query param moves the object named by the
+// `x-minio-move-source` header to it, across buckets.
+const (
+	moveQueryParam   = "x-minio-move"
+	moveSourceHeader = "X-Minio-Move-Source"
+)
+
+// errMoveDestinationExists - destination object of a move already exists.
+var errMoveDestinationExists = errors.New("The destination object of the move already exists")
+
+// getMoveSource - returns the bucket and the object moved.
+func getMoveSource(h http.Header) (bucket, object string, s3Error APIErrorCode) {
+	srcPath, err := url.PathUnescape(h.Get(moveSourceHeader))
+	if err != nil {
+		return "", "", ErrInvalidMoveSource
+	}
+	bucket, object = path2BucketAndObject(srcPath)
+	if bucket == "" || object == "" {
+		return "", "", ErrInvalidMoveSource
+	}
+	return bucket, object, ErrNone
+}
+
+// checkMoveSourceAuth - verifies the source of a move may be read, as
+// by a copy, and removed, as by a delete. The objects of a bucket which
+// may not be read can not be moved to another bucket to be read there.
+// The bucket policies of anonymous requests are enforced on the source
+// object, not on the destination of the request path.
+func checkMoveSourceAuth(r *http.Request, srcBucket, srcObject string) APIErrorCode {
+	if getRequestAuthType(r) != authTypeAnonymous {
+		if s3Error := checkRequestAuthType(r, srcBucket, "s3:GetObject", serverConfig.GetRegion()); s3Error != ErrNone {
+			return s3Error
+		}
+		return checkRequestAuthType(r, srcBucket, "s3:DeleteObject", serverConfig.GetRegion())
+	}
+	resource := slashSeparator + srcBucket + slashSeparator + srcObject
+	for _, action := range []string{"s3:GetObject", "s3:DeleteObject"} {
+		if s3Error := enforceBucketPolicy(srcBucket, action, resource, r.Referer(), r.URL.Query()); s3Error != ErrNone {
+			return s3Error
+		}
+	}
+	return ErrNone
+}
+
+// moveObject - copies the object to the destination with its metadata,
+// tags included, and removes the source as a DELETE does, to the trash
+// of its bucket if enabled. The destination must not exist so that a
+// failure to remove the source is rolled back by removing the copy,
+// leaving the source as it was. Must be called with the locks of both
+// objects held.
+func moveObject(objAPI ObjectLayer, srcBucket, srcObject, dstBucket, dstObject string) (ObjectInfo, error) {
+	objInfo, err := objAPI.GetObjectInfo(srcBucket, srcObject)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	// Encrypted objects are not copied.
+	if isEncryptedObject(objInfo.UserDefined) {
+		return ObjectInfo{}, traceError(errEncryptedObjectUnsupported)
+	}
+	if err = checkObjectSizeLimits(dstBucket, objInfo.Size); err != nil {
+		return ObjectInfo{}, err
+	}
+	if _, err = objAPI.GetObjectInfo(dstBucket, dstObject); err == nil {
+		return ObjectInfo{}, traceError(errMoveDestinationExists)
+	} else if !isErrObjectNotFound(err) {
+		return ObjectInfo{}, err
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	// The copy computes the md5sum of multipart sources again and is
+	// not replicated yet.
+	delete(metadata, "md5Sum")
+	delete(metadata, replicationStatusKey)
+
+	// The retention of the source, which allowed the move, has expired.
+	// The moved object inherits the default retention of its bucket.
+	removeObjectRetentionMetadata(metadata)
+	if err = setObjectRetentionMetadata(dstBucket, nil, metadata); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	// Objects moved into content-addressed buckets are named after
+	// their SHA256.
+	if globalBucketContentAddressing.IsEnabled(dstBucket) {
+		if err = checkContentAddressedCopy(objAPI, srcBucket, srcObject, dstBucket, dstObject, objInfo.Size); err != nil {
+			return ObjectInfo{}, err
+		}
+		setContentAddressMetadata(metadata, dstObject)
+	}
+
+	dstInfo, err := objAPI.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	if err = deleteObjectOrTrash(objAPI, srcBucket, srcObject); err != nil {
+		if rerr := objAPI.DeleteObject(dstBucket, dstObject); rerr != nil {
+			errorIf(rerr, "Unable to roll back the move of %s/%s to %s/%s.", srcBucket, srcObject, dstBucket, dstObject)
+		}
+		return ObjectInfo{}, err
+	}
+	// The time to live of the object, if any, applies to the moved object.
+	errorIf(addObjectExpiry(objAPI, dstBucket, dstObject, metadata), "Unable to index the expiry of %s/%s.", dstBucket, dstObject)
+	return dstInfo, nil
+}
+
+// MoveObjectHandler - POST /{bucket}/{object}?x-minio-move
+// ----------
+// This implementation of the move operation moves an object to another
+// bucket or name server-side, in place of a copy followed by a delete
+// of the client. The moved object gets the ETag of a copy, which is
+// sent back.
+func (api objectAPIHandlers) MoveObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	dstBucket := vars["bucket"]
+	dstObject := vars["object"]
+
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, dstBucket, "s3:PutObject", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	srcBucket, srcObject, s3Error := getMoveSource(r.Header)
+	if s3Error == ErrNone && srcBucket == dstBucket && srcObject == dstObject {
+		s3Error = ErrInvalidMoveSource
+	}
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	if s3Error = checkMoveSourceAuth(r, srcBucket, srcObject); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock both objects in the same order for all the moves, so that
+	// two moves between the same objects do not deadlock.
+	first, second := [2]string{srcBucket, srcObject}, [2]string{dstBucket, dstObject}
+	if pathJoin(dstBucket, dstObject) < pathJoin(srcBucket, srcObject) {
+		first, second = second, first
+	}
+	firstLock := globalNSMutex.NewNSLock(first[0], first[1])
+	firstLock.Lock()
+	defer firstLock.Unlock()
+	secondLock := globalNSMutex.NewNSLock(second[0], second[1])
+	secondLock.Lock()
+	defer secondLock.Unlock()
+
+	// Source under retention can not be removed.
+	if err := enforceObjectRetention(objAPI, srcBucket, srcObject, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	objInfo, err := moveObject(objAPI, srcBucket, srcObject, dstBucket, dstObject)
+	if err != nil {
+		if errorCause(err) != errMoveDestinationExists && !isErrObjectNotFound(err) {
+			errorIf(err, "Unable to move %s/%s to %s/%s.", srcBucket, srcObject, dstBucket, dstObject)
+		}
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
+	writeSuccessResponseHeadersOnly(w)
+
+	// Notify object created and removed events.
+	eventNotify(eventData{
+		Type:    ObjectCreatedCopy,
+		Bucket:  dstBucket,
+		ObjInfo: objInfo,
+		ReqParams: map[string]string{
+			"sourceIPAddress": r.RemoteAddr,
+		},
+	})
+	eventNotify(eventData{
+		Type:   ObjectRemovedDelete,
+		Bucket: srcBucket,
+		ObjInfo: ObjectInfo{
+			Name: srcObject,
+		},
+		ReqParams: map[string]string{
+			"sourceIPAddress": r.RemoteAddr,
+		},
+	})
+}
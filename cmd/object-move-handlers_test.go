@@ -0,0 +1,283 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests moving objects server-side across buckets, the moved objects
+// keep their metadata and tags.
+func TestMoveObjectHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testMoveObjectHandler, []string{"MoveObject"})
+}
+
+func testMoveObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	dstBucket := getRandomBucketName()
+	if err := obj.MakeBucket(dstBucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+	data := []byte("hello world")
+	metadata := map[string]string{
+		"content-type":   "text/plain",
+		"X-Amz-Meta-App": "logs",
+		objectTaggingKey: "env=prod",
+	}
+	srcInfo, err := obj.PutObject(bucketName, "src", int64(len(data)), bytes.NewReader(data), metadata, "")
+	if err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+	if _, err = obj.PutObject(dstBucket, "existing", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	serveRequest := func(bucket, object, source string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, rerr := newTestSignedRequestV4("POST", getMoveObjectURL("", bucket, object), 0, nil,
+			credentials.AccessKey, credentials.SecretKey)
+		if rerr != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, rerr)
+		}
+		if source != "" {
+			req.Header.Set(moveSourceHeader, source)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Invalid sources, missing objects and existing destinations are
+	// rejected.
+	testCases := []struct {
+		object         string
+		source         string
+		expectedStatus int
+	}{
+		{"dst", "", http.StatusBadRequest},
+		{"dst", "/" + bucketName, http.StatusBadRequest},
+		{"existing", "/" + dstBucket + "/existing", http.StatusBadRequest},
+		{"dst", "/" + bucketName + "/missing", http.StatusNotFound},
+		{"existing", "/" + bucketName + "/src", http.StatusConflict},
+	}
+	for i, testCase := range testCases {
+		if rec := serveRequest(dstBucket, testCase.object, testCase.source); rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+
+	rec := serveRequest(dstBucket, "dst", "/"+bucketName+"/src")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	if etag := rec.Header().Get("ETag"); etag != "\""+srcInfo.MD5Sum+"\"" {
+		t.Fatalf("%s: Expected ETag %s, got %s", instanceType, srcInfo.MD5Sum, etag)
+	}
+
+	// The object is in the destination only.
+	if _, err = obj.GetObjectInfo(bucketName, "src"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the source to be removed, got %v", instanceType, err)
+	}
+	objInfo, err := obj.GetObjectInfo(dstBucket, "dst")
+	if err != nil {
+		t.Fatalf("%s: Expected the object to be moved, got %v", instanceType, err)
+	}
+	if objInfo.ContentType != "text/plain" || objInfo.UserDefined["X-Amz-Meta-App"] != "logs" ||
+		objInfo.UserDefined[objectTaggingKey] != "env=prod" {
+		t.Fatalf("%s: Expected metadata and tags to be kept, got %v", instanceType, objInfo.UserDefined)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(dstBucket, "dst", 0, objInfo.Size, &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected content %q, got %q", instanceType, data, buffer.Bytes())
+	}
+
+	// Objects which may be deleted but not read can not be moved out
+	// of their bucket.
+	if _, err = obj.PutObject(bucketName, "secret", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+	for _, bucket := range []string{bucketName, dstBucket} {
+		policy := bucketPolicy{
+			Version:    "1.0",
+			Statements: []policyStatement{getWriteOnlyObjectStatement(bucket, "")},
+		}
+		globalBucketPolicies.SetBucketPolicy(bucket, policyChange{false, &policy})
+		defer globalBucketPolicies.SetBucketPolicy(bucket, policyChange{true, nil})
+	}
+	anonRec := httptest.NewRecorder()
+	anonReq, err := newTestRequest("POST", getMoveObjectURL("", dstBucket, "stolen"), 0, nil)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	anonReq.Header.Set(moveSourceHeader, "/"+bucketName+"/secret")
+	apiRouter.ServeHTTP(anonRec, anonReq)
+	if anonRec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, anonRec.Code)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, "secret"); err != nil {
+		t.Fatalf("%s: Expected the source to be kept, got %v", instanceType, err)
+	}
+	if _, err = obj.GetObjectInfo(dstBucket, "stolen"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected no object moved, got %v", instanceType, err)
+	}
+
+	// The same move is allowed once the source may be read.
+	policy := bucketPolicy{
+		Version:    "1.0",
+		Statements: []policyStatement{getReadWriteObjectStatement(bucketName, "")},
+	}
+	globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{false, &policy})
+	anonRec = httptest.NewRecorder()
+	anonReq, err = newTestRequest("POST", getMoveObjectURL("", dstBucket, "stolen"), 0, nil)
+	if err != nil {
+		t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+	}
+	anonReq.Header.Set(moveSourceHeader, "/"+bucketName+"/secret")
+	apiRouter.ServeHTTP(anonRec, anonReq)
+	if anonRec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, anonRec.Code)
+	}
+}
+
+// Tests the source of a move is removed to the trash of its bucket if
+// enabled, as by a DELETE.
+func TestMoveObjectTrash(t *testing.T) {
+	ExecObjectLayerTest(t, testMoveObjectTrash)
+}
+
+func testMoveObjectTrash(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	srcBucket, dstBucket := "src-bucket", "dst-bucket"
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	globalBucketTrash.Set(srcBucket, &TrashConfiguration{Status: trashStatusEnabled, RetentionDays: 1})
+	defer globalBucketTrash.Set(srcBucket, nil)
+
+	data := []byte("hello world")
+	if _, err := obj.PutObject(srcBucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := moveObject(obj, srcBucket, "object", dstBucket, "object"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := obj.GetObjectInfo(srcBucket, "object"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the source to be removed, got %v", instanceType, err)
+	}
+	if _, err := readTrashEntry(obj, getTrashEntryPath(srcBucket, "object")); err != nil {
+		t.Fatalf("%s: Expected the source to be in the trash, got %v", instanceType, err)
+	}
+}
+
+// failingDeleteObjectLayer - object layer failing to delete the objects
+// of a bucket.
+type failingDeleteObjectLayer struct {
+	ObjectLayer
+	bucket string
+}
+
+func (l failingDeleteObjectLayer) DeleteObject(bucket, object string) error {
+	if bucket == l.bucket {
+		return traceError(errors.New("simulated failure"))
+	}
+	return l.ObjectLayer.DeleteObject(bucket, object)
+}
+
+// Tests a move failing to remove the source is rolled back, leaving the
+// source intact and no copy in the destination.
+func TestMoveObjectRollback(t *testing.T) {
+	ExecObjectLayerTest(t, testMoveObjectRollback)
+}
+
+func testMoveObjectRollback(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	srcBucket, dstBucket := "src-bucket", "dst-bucket"
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	data := []byte("hello world")
+	metadata := map[string]string{"X-Amz-Meta-App": "logs"}
+	if _, err := obj.PutObject(srcBucket, "object", int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	failing := failingDeleteObjectLayer{ObjectLayer: obj, bucket: srcBucket}
+	if _, err := moveObject(failing, srcBucket, "object", dstBucket, "object"); err == nil {
+		t.Fatalf("%s: Expected the move to fail", instanceType)
+	}
+
+	objInfo, err := obj.GetObjectInfo(srcBucket, "object")
+	if err != nil {
+		t.Fatalf("%s: Expected the source to be intact, got %v", instanceType, err)
+	}
+	if objInfo.UserDefined["X-Amz-Meta-App"] != "logs" {
+		t.Fatalf("%s: Expected the metadata of the source to be intact, got %v", instanceType, objInfo.UserDefined)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(srcBucket, "object", 0, objInfo.Size, &buffer); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected content %q, got %q", instanceType, data, buffer.Bytes())
+	}
+	if _, err = obj.GetObjectInfo(dstBucket, "object"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the copy to be rolled back, got %v", instanceType, err)
+	}
+}
+
+// Tests moved objects inherit the default retention of the destination
+// bucket.
+func TestMoveObjectRetention(t *testing.T) {
+	ExecObjectLayerTest(t, testMoveObjectRetention)
+}
+
+func testMoveObjectRetention(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	srcBucket, dstBucket := "src-bucket", "dst-bucket"
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+	globalBucketObjectLock.Set(dstBucket, &ObjectLockConfiguration{
+		ObjectLockEnabled: "Enabled",
+		Rule:              &ObjectLockRule{DefaultRetention: DefaultRetention{Mode: retentionModeGovernance, Days: 1}},
+	})
+	defer globalBucketObjectLock.Set(dstBucket, nil)
+
+	data := []byte("hello world")
+	if _, err := obj.PutObject(srcBucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if _, err := moveObject(obj, srcBucket, "object", dstBucket, "object"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objInfo, err := obj.GetObjectInfo(dstBucket, "object")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.UserDefined[amzObjectLockMode] != retentionModeGovernance || objInfo.UserDefined[amzObjectLockRetainUntilDate] == "" {
+		t.Fatalf("%s: Expected the default retention of the bucket, got %#v", instanceType, objInfo.UserDefined)
+	}
+}
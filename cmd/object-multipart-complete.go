@@ -0,0 +1,96 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+
+	humanize "github.com/dustin/go-humanize"
+)
+
+// Default maximum size of a CompleteMultipartUpload body, room for the
+// 10000 parts of an upload along with their checksums.
+const defaultMaxCompleteMultipartSize = 4 * humanize.MiByte
+
+// errCompleteMultipartTooLarge - CompleteMultipartUpload body exceeds the
+// maximum size.
+var errCompleteMultipartTooLarge = errors.New("CompleteMultipartUpload body is too large")
+
+// errCompleteMultipartTooManyParts - CompleteMultipartUpload lists more
+// parts than an upload may have.
+var errCompleteMultipartTooManyParts = errors.New("CompleteMultipartUpload lists too many parts")
+
+// setMaxCompleteMultipartSize - sets the maximum size of the bodies of
+// CompleteMultipartUpload from MINIO_MAX_COMPLETE_MULTIPART_SIZE env.
+func setMaxCompleteMultipartSize() {
+	if maxSize := os.Getenv("MINIO_MAX_COMPLETE_MULTIPART_SIZE"); maxSize != "" {
+		size, err := humanize.ParseBytes(maxSize)
+		if err != nil || size == 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_COMPLETE_MULTIPART_SIZE value %s.", maxSize)
+		}
+		globalMaxCompleteMultipartSize = int64(size)
+	}
+}
+
+// parseCompleteMultipartUpload - parses the parts listed by the body of
+// a CompleteMultipartUpload. The body is read up to the maximum size and
+// the parts are decoded one at a time, so that neither a large body nor
+// a list of more parts than an upload may have are held in memory.
+func parseCompleteMultipartUpload(reader io.Reader) (*completeMultipartUpload, error) {
+	limitedReader := &io.LimitedReader{R: reader, N: globalMaxCompleteMultipartSize + 1}
+	decoder := xml.NewDecoder(limitedReader)
+
+	complMultipartUpload := &completeMultipartUpload{}
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if limitedReader.N == 0 {
+			return nil, errCompleteMultipartTooLarge
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch element := token.(type) {
+		case xml.StartElement:
+			// Parts are the children of the root element.
+			if depth == 1 && element.Name.Local == "Part" {
+				if len(complMultipartUpload.Parts) == globalMaxPartID {
+					return nil, errCompleteMultipartTooManyParts
+				}
+				var part completePart
+				if err = decoder.DecodeElement(&part, &element); err != nil {
+					if limitedReader.N == 0 {
+						return nil, errCompleteMultipartTooLarge
+					}
+					return nil, err
+				}
+				complMultipartUpload.Parts = append(complMultipartUpload.Parts, part)
+				continue
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return complMultipartUpload, nil
+}
@@ -0,0 +1,144 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// completeMultipartBody - returns a CompleteMultipartUpload body listing
+// the parts numbered from 1 to count.
+func completeMultipartBody(count int) string {
+	var body bytes.Buffer
+	body.WriteString(`<CompleteMultipartUpload xmlns="http://s3.amazonaws.com/doc/2006-03-01/">`)
+	for i := 1; i <= count; i++ {
+		fmt.Fprintf(&body, `<Part><PartNumber>%d</PartNumber><ETag>"etag-%d"</ETag></Part>`, i, i)
+	}
+	body.WriteString(`</CompleteMultipartUpload>`)
+	return body.String()
+}
+
+// Tests the parts of the CompleteMultipartUpload bodies are parsed, and
+// the oversized and malformed bodies are rejected.
+func TestParseCompleteMultipartUpload(t *testing.T) {
+	defer func(size int64, partID int) {
+		globalMaxCompleteMultipartSize, globalMaxPartID = size, partID
+	}(globalMaxCompleteMultipartSize, globalMaxPartID)
+	globalMaxCompleteMultipartSize, globalMaxPartID = 1024, 5
+
+	complMultipartUpload, err := parseCompleteMultipartUpload(strings.NewReader(
+		`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>"a"</ETag></Part>` +
+			`<Other><Part><PartNumber>9</PartNumber></Part></Other>` +
+			`<Part><ETag>b</ETag><PartNumber>2</PartNumber></Part></CompleteMultipartUpload>`))
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	expected := []completePart{{PartNumber: 1, ETag: `"a"`}, {PartNumber: 2, ETag: "b"}}
+	if !reflect.DeepEqual(complMultipartUpload.Parts, expected) {
+		t.Fatalf("Expected the parts %v, got %v", expected, complMultipartUpload.Parts)
+	}
+
+	testCases := []struct {
+		body        string
+		expectedErr error
+	}{
+		// Larger than the maximum size.
+		{completeMultipartBody(5) + strings.Repeat(" ", 1024), errCompleteMultipartTooLarge},
+		{`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber><ETag>` + strings.Repeat("a", 2048) + `</ETag></Part></CompleteMultipartUpload>`, errCompleteMultipartTooLarge},
+		// More parts than an upload may have.
+		{completeMultipartBody(6), errCompleteMultipartTooManyParts},
+	}
+	for i, testCase := range testCases {
+		if _, err := parseCompleteMultipartUpload(strings.NewReader(testCase.body)); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+
+	// Malformed bodies.
+	malformedBodies := []string{
+		`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber>`,
+		`<CompleteMultipartUpload><Part><PartNumber>one</PartNumber></Part></CompleteMultipartUpload>`,
+		`<CompleteMultipartUpload></Part></CompleteMultipartUpload>`,
+	}
+	for i, body := range malformedBodies {
+		if _, err := parseCompleteMultipartUpload(strings.NewReader(body)); err == nil {
+			t.Errorf("Test %d: Expected the malformed body to be rejected", i+1)
+		}
+	}
+}
+
+// Tests the oversized and malformed CompleteMultipartUpload bodies are
+// rejected with MalformedXML.
+func TestCompleteMultipartUploadBodyLimits(t *testing.T) {
+	ExecObjectLayerAPITest(t, testCompleteMultipartUploadBodyLimits, []string{"CompleteMultipart"})
+}
+
+func testCompleteMultipartUploadBodyLimits(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(size int64) { globalMaxCompleteMultipartSize = size }(globalMaxCompleteMultipartSize)
+	globalMaxCompleteMultipartSize = 4096
+
+	objectName := "object"
+	uploadID, err := obj.NewMultipartUpload(bucketName, objectName, nil)
+	if err != nil {
+		t.Fatalf("Minio %s: %v", instanceType, err)
+	}
+
+	testCases := []struct {
+		body          string
+		unknownLength bool
+	}{
+		// Oversized, rejected from the Content-Length and as read.
+		{completeMultipartBody(100), false},
+		{completeMultipartBody(100), true},
+		// Malformed.
+		{`<CompleteMultipartUpload><Part><PartNumber>1</PartNumber>`, false},
+		{`<CompleteMultipartUpload><Part><PartNumber>x</PartNumber></Part></CompleteMultipartUpload>`, false},
+		// No parts.
+		{`<CompleteMultipartUpload></CompleteMultipartUpload>`, false},
+	}
+	for i, testCase := range testCases {
+		req, err := newTestSignedRequestV4("POST", getCompleteMultipartUploadURL("", bucketName, objectName, uploadID),
+			int64(len(testCase.body)), strings.NewReader(testCase.body), credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("Minio %s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		// Body sent without a Content-Length is only limited as read.
+		if testCase.unknownLength {
+			req.ContentLength = -1
+		}
+		rec := httptest.NewRecorder()
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("Minio %s: Test %d: Expected status %d, got %d", instanceType, i+1, http.StatusBadRequest, rec.Code)
+		}
+		var errResponse APIErrorResponse
+		if err = xml.Unmarshal(rec.Body.Bytes(), &errResponse); err != nil {
+			t.Fatalf("Minio %s: %v", instanceType, err)
+		}
+		if errResponse.Code != "MalformedXML" {
+			t.Fatalf("Minio %s: Test %d: Expected MalformedXML, got %s", instanceType, i+1, errResponse.Code)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/pkg/set"
+)
+
+// Minio extension, the `X-Minio-Response-Header-<Name>` headers of the
+// object writes are saved with the object, and sent back as `<Name>`
+// on GET and HEAD. Only the names allowed by the server are accepted.
+const objectResponseHeaderPrefix = "X-Minio-Response-Header-"
+
+// Response headers allowed on the objects by default, canonical names.
+var defaultObjectResponseHeaders = []string{
+	"X-Content-Type-Options",
+	"Content-Security-Policy",
+	"X-Frame-Options",
+	"X-Xss-Protection",
+	"Referrer-Policy",
+	"Strict-Transport-Security",
+}
+
+// Headers set by the server itself, never allowed on the objects.
+var reservedObjectResponseHeaders = set.CreateStringSet(
+	"Accept-Ranges",
+	"Connection",
+	"Content-Length",
+	"Content-Range",
+	"Content-Type",
+	"Date",
+	"Etag",
+	"Last-Modified",
+	"Server",
+	"Set-Cookie",
+	"Transfer-Encoding",
+	"Vary",
+	"Www-Authenticate",
+)
+
+// newObjectResponseHeaders - returns the set of the canonical names of
+// the response headers allowed on the objects.
+func newObjectResponseHeaders(names []string) (set.StringSet, error) {
+	allowed := set.NewStringSet()
+	for _, name := range names {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if reservedObjectResponseHeaders.Contains(name) ||
+			strings.HasPrefix(name, "X-Amz-") || strings.HasPrefix(name, "X-Minio-") {
+			return nil, errInvalidArgument
+		}
+		allowed.Add(name)
+	}
+	return allowed, nil
+}
+
+// setObjectResponseHeaders - sets the response headers allowed on the
+// objects from MINIO_OBJECT_RESPONSE_HEADERS env, a comma separated
+// list of header names replacing the default ones.
+func setObjectResponseHeaders() {
+	if names := os.Getenv("MINIO_OBJECT_RESPONSE_HEADERS"); names != "" {
+		allowed, err := newObjectResponseHeaders(strings.Split(names, ","))
+		fatalIf(err, "Invalid MINIO_OBJECT_RESPONSE_HEADERS value %s.", names)
+		globalObjectResponseHeaders = allowed
+	}
+}
+
+// extractObjectResponseHeaders - saves the response headers of the
+// object requested in the header to the metadata. Returns
+// ErrInvalidObjectResponseHeader if one of them is not allowed.
+func extractObjectResponseHeaders(header http.Header, metadata map[string]string) APIErrorCode {
+	for key := range header {
+		cKey := http.CanonicalHeaderKey(key)
+		if !strings.HasPrefix(cKey, objectResponseHeaderPrefix) {
+			continue
+		}
+		name := strings.TrimPrefix(cKey, objectResponseHeaderPrefix)
+		if !globalObjectResponseHeaders.Contains(name) {
+			return ErrInvalidObjectResponseHeader
+		}
+		metadata[cKey] = header.Get(key)
+	}
+	return ErrNone
+}
+
+// getObjectResponseHeader - returns the response header name saved
+// under the metadata key, if it is still allowed.
+func getObjectResponseHeader(key string) (string, bool) {
+	if !strings.HasPrefix(key, objectResponseHeaderPrefix) {
+		return "", false
+	}
+	name := strings.TrimPrefix(key, objectResponseHeaderPrefix)
+	return name, globalObjectResponseHeaders.Contains(name)
+}
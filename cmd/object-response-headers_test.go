@@ -0,0 +1,142 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/pkg/set"
+)
+
+// Tests the validation of the response headers allowed on the objects.
+func TestNewObjectResponseHeaders(t *testing.T) {
+	testCases := []struct {
+		names     []string
+		allowed   []string
+		shouldErr bool
+	}{
+		{[]string{"x-content-type-options", " Link ", ""}, []string{"X-Content-Type-Options", "Link"}, false},
+		{[]string{"Content-Length"}, nil, true},
+		{[]string{"set-cookie"}, nil, true},
+		{[]string{"X-Amz-Meta-Color"}, nil, true},
+		{[]string{"x-minio-internal"}, nil, true},
+	}
+	for i, testCase := range testCases {
+		allowed, err := newObjectResponseHeaders(testCase.names)
+		if testCase.shouldErr {
+			if err == nil {
+				t.Fatalf("Test %d: Expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if !allowed.Equals(set.CreateStringSet(testCase.allowed...)) {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.allowed, allowed)
+		}
+	}
+}
+
+// Tests the allowed response headers set on the writes of the objects
+// are sent back on GET and HEAD, and the others rejected.
+func TestObjectResponseHeadersHandlers(t *testing.T) {
+	ExecObjectLayerAPITest(t, testObjectResponseHeadersHandlers, []string{"NewMultipart", "PutObject", "GetObject", "HeadObject"})
+}
+
+func testObjectResponseHeadersHandlers(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(allowed set.StringSet) { globalObjectResponseHeaders = allowed }(globalObjectResponseHeaders)
+
+	serveRequest := func(method, urlStr string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4(method, urlStr, int64(len(body)), bytes.NewReader(body),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		// Headers are signed.
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	csp := "default-src 'none'"
+
+	// Allowed headers are saved.
+	rec := serveRequest("PUT", getPutObjectURL("", bucketName, "object"), []byte("<html></html>"), map[string]string{
+		"x-minio-response-header-content-security-policy": csp,
+		"X-Minio-Response-Header-X-Content-Type-Options":  "nosniff",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusOK, rec.Code, rec.Body.String())
+	}
+	for _, method := range []string{"GET", "HEAD"} {
+		rec = serveRequest(method, getGetObjectURL("", bucketName, "object"), nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: %s: Expected status %d, got %d", instanceType, method, http.StatusOK, rec.Code)
+		}
+		if value := rec.Header().Get("Content-Security-Policy"); value != csp {
+			t.Fatalf("%s: %s: Expected Content-Security-Policy %q, got %q", instanceType, method, csp, value)
+		}
+		if value := rec.Header().Get("X-Content-Type-Options"); value != "nosniff" {
+			t.Fatalf("%s: %s: Expected X-Content-Type-Options nosniff, got %q", instanceType, method, value)
+		}
+		for key := range rec.Header() {
+			if strings.HasPrefix(key, objectResponseHeaderPrefix) {
+				t.Fatalf("%s: %s: Unexpected header %s", instanceType, method, key)
+			}
+		}
+	}
+
+	// Other headers are rejected, the object is not written.
+	for _, name := range []string{"Access-Control-Allow-Origin", "Content-Length"} {
+		rec = serveRequest("PUT", getPutObjectURL("", bucketName, "rejected"), []byte("data"), map[string]string{
+			objectResponseHeaderPrefix + name: "*",
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: %s: Expected status %d, got %d", instanceType, name, http.StatusBadRequest, rec.Code)
+		}
+		if _, err := obj.GetObjectInfo(bucketName, "rejected"); !isErrObjectNotFound(err) {
+			t.Fatalf("%s: %s: Expected the object not to be written, got %v", instanceType, name, err)
+		}
+	}
+	rec = serveRequest("POST", getNewMultipartURL("", bucketName, "rejected"), nil, map[string]string{
+		objectResponseHeaderPrefix + "Access-Control-Allow-Origin": "*",
+	})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d for the multipart upload, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Headers no longer allowed are not sent anymore.
+	globalObjectResponseHeaders = set.CreateStringSet("X-Content-Type-Options")
+	rec = serveRequest("HEAD", getGetObjectURL("", bucketName, "object"), nil, nil)
+	if value := rec.Header().Get("Content-Security-Policy"); value != "" {
+		t.Fatalf("%s: Expected no Content-Security-Policy, got %q", instanceType, value)
+	}
+	if value := rec.Header().Get("X-Content-Type-Options"); value != "nosniff" {
+		t.Fatalf("%s: Expected X-Content-Type-Options nosniff, got %q", instanceType, value)
+	}
+}
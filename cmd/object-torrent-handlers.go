@@ -0,0 +1,232 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"hash"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	mux "github.com/gorilla/mux"
+)
+
+const (
+	// Smallest length of the pieces of the torrents, doubled until
+	// the torrent has at most maxTorrentPieces pieces.
+	minTorrentPieceLength = 256 * 1024
+	maxTorrentPieces      = 2048
+
+	// Torrents are generated for the objects up to 5GiB, like S3.
+	maxTorrentObjectSize = 5 * 1024 * 1024 * 1024
+)
+
+// setTorrent - enables the generation of the torrents of the objects
+// when MINIO_TORRENT env is set to "on".
+func setTorrent() {
+	if torrent := os.Getenv("MINIO_TORRENT"); torrent != "" {
+		switch strings.ToLower(torrent) {
+		case "on":
+			globalIsTorrent = true
+		case "off":
+			globalIsTorrent = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_TORRENT value %s.", torrent)
+		}
+	}
+}
+
+// getTorrentPieceLength - returns the length of the pieces of the
+// torrent of an object of size bytes.
+func getTorrentPieceLength(size int64) int64 {
+	pieceLength := int64(minTorrentPieceLength)
+	for size > pieceLength*maxTorrentPieces {
+		pieceLength *= 2
+	}
+	return pieceLength
+}
+
+// torrentPieces - computes the SHA1 of each piece of the content
+// written to it.
+type torrentPieces struct {
+	pieceLength int64
+	hash        hash.Hash
+	written     int64
+	pieces      []byte
+}
+
+func newTorrentPieces(pieceLength int64) *torrentPieces {
+	return &torrentPieces{
+		pieceLength: pieceLength,
+		hash:        sha1.New(),
+	}
+}
+
+func (p *torrentPieces) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 {
+		chunk := b
+		if remaining := p.pieceLength - p.written; int64(len(chunk)) > remaining {
+			chunk = chunk[:remaining]
+		}
+		p.hash.Write(chunk)
+		p.written += int64(len(chunk))
+		b = b[len(chunk):]
+		if p.written == p.pieceLength {
+			p.pieces = p.hash.Sum(p.pieces)
+			p.hash.Reset()
+			p.written = 0
+		}
+	}
+	return n, nil
+}
+
+// Pieces - returns the concatenated SHA1 of the pieces, the last one
+// may be shorter than the piece length.
+func (p *torrentPieces) Pieces() []byte {
+	if p.written > 0 {
+		p.pieces = p.hash.Sum(p.pieces)
+		p.hash.Reset()
+		p.written = 0
+	}
+	return p.pieces
+}
+
+// bencode - encodes the value in the bencoding of the torrent files,
+// supports strings, integers, lists and dictionaries.
+func bencode(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case string:
+		buf.WriteString(strconv.Itoa(len(v)))
+		buf.WriteByte(':')
+		buf.WriteString(v)
+	case []byte:
+		bencode(buf, string(v))
+	case int64:
+		buf.WriteString("i" + strconv.FormatInt(v, 10) + "e")
+	case []interface{}:
+		buf.WriteByte('l')
+		for _, e := range v {
+			bencode(buf, e)
+		}
+		buf.WriteByte('e')
+	case map[string]interface{}:
+		// Keys are sorted as raw strings.
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteByte('d')
+		for _, key := range keys {
+			bencode(buf, key)
+			bencode(buf, v[key])
+		}
+		buf.WriteByte('e')
+	default:
+		panic(fmt.Sprintf("bencode: unsupported type %T", v))
+	}
+}
+
+// GetObjectTorrentHandler - GET Object torrent
+// ----------
+// This implementation of the GET operation returns a single file
+// torrent of the object, seeded by the server itself through the
+// object URL (BEP 19), without a tracker. Replies NotImplemented
+// unless enabled by MINIO_TORRENT.
+func (api objectAPIHandlers) GetObjectTorrentHandler(w http.ResponseWriter, r *http.Request) {
+	if !globalIsTorrent {
+		writeErrorResponse(w, ErrNotImplemented, r.URL)
+		return
+	}
+
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := api.ObjectAPI()
+	if objectAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:GetObject", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+	// Webseeds can not serve the plaintext of encrypted objects.
+	if isEncryptedObject(objInfo.UserDefined) {
+		writeErrorResponse(w, ErrEncryptedObjectNotSupported, r.URL)
+		return
+	}
+	if objInfo.Size > maxTorrentObjectSize {
+		writeErrorResponse(w, ErrTorrentObjectTooLarge, r.URL)
+		return
+	}
+
+	pieceLength := getTorrentPieceLength(objInfo.Size)
+	pieces := newTorrentPieces(pieceLength)
+	if err = objectAPI.GetObject(bucket, object, 0, objInfo.Size, pieces); err != nil {
+		errorIf(err, "Unable to read object %s/%s.", bucket, object)
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	scheme := httpScheme
+	if globalIsSSL {
+		scheme = httpsScheme
+	}
+	objectURL := &url.URL{
+		Scheme: scheme,
+		Host:   r.Host,
+		Path:   getObjectLocation(bucket, object),
+	}
+	name := path.Base(object)
+
+	var torrent bytes.Buffer
+	bencode(&torrent, map[string]interface{}{
+		"info": map[string]interface{}{
+			"length":       objInfo.Size,
+			"name":         name,
+			"piece length": pieceLength,
+			"pieces":       pieces.Pieces(),
+		},
+		"url-list": []interface{}{objectURL.String()},
+	})
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name+".torrent"))
+	writeResponse(w, http.StatusOK, torrent.Bytes(), mimeTorrent)
+}
@@ -0,0 +1,185 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Minio extension, a POST on the object with the `x-minio-truncate`
+// query param truncates the object to the size given by the `length`
+// query param.
+const (
+	truncateQueryParam       = "x-minio-truncate"
+	truncateLengthQueryParam = "length"
+
+	// Truncated content is staged under 'truncate/<id>' in the meta
+	// bucket before replacing the object.
+	truncatePrefix = "truncate"
+)
+
+// errInvalidTruncateLength - truncate length is beyond the object size.
+var errInvalidTruncateLength = errors.New("Truncate length exceeds the object size")
+
+// getTruncateLength - returns the length the object is truncated to.
+func getTruncateLength(r *http.Request) (int64, APIErrorCode) {
+	length, err := strconv.ParseInt(r.URL.Query().Get(truncateLengthQueryParam), 10, 64)
+	if err != nil || length < 0 {
+		return 0, ErrInvalidTruncateLength
+	}
+	return length, ErrNone
+}
+
+// truncateObject - replaces the object with its first length bytes,
+// keeping its metadata. The content is staged in the meta bucket since
+// the object can not be read and written at the same time, the
+// checksum saved with the object, if any, is computed again for the
+// truncated content. Must be called with the object lock held.
+func truncateObject(objAPI ObjectLayer, bucket, object string, length int64) (ObjectInfo, error) {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	// Encrypted objects are truncated block by block, not supported.
+	if isEncryptedObject(objInfo.UserDefined) {
+		return ObjectInfo{}, traceError(errEncryptedObjectUnsupported)
+	}
+	// Objects of content-addressed buckets are immutable.
+	if globalBucketContentAddressing.IsEnabled(bucket) {
+		return ObjectInfo{}, traceError(errContentAddressingUnsupported)
+	}
+	if length > objInfo.Size {
+		return ObjectInfo{}, traceError(errInvalidTruncateLength)
+	}
+
+	metadata := make(map[string]string)
+	for k, v := range objInfo.UserDefined {
+		metadata[k] = v
+	}
+	// Truncated content gets a new md5sum and is not replicated yet.
+	delete(metadata, "md5Sum")
+	delete(metadata, replicationStatusKey)
+
+	var checksumAlgorithm string
+	var checksumWriter hash.Hash
+	for algorithm, newHash := range checksumHashers {
+		if _, ok := metadata[checksumMetadataKey(algorithm)]; ok {
+			checksumAlgorithm = algorithm
+			checksumWriter = newHash()
+		}
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(objAPI.GetObject(bucket, object, 0, length, pipeWriter))
+	}()
+
+	var reader io.Reader = pipeReader
+	if checksumWriter != nil {
+		reader = io.TeeReader(pipeReader, checksumWriter)
+	}
+	stagePath := path.Join(truncatePrefix, mustGetUUID())
+	_, err = objAPI.PutObject(minioMetaBucket, stagePath, length, reader, nil, "")
+	pipeReader.Close()
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer func() {
+		if derr := objAPI.DeleteObject(minioMetaBucket, stagePath); derr != nil {
+			errorIf(derr, "Unable to remove the truncated content of %s/%s.", bucket, object)
+		}
+	}()
+
+	if checksumWriter != nil {
+		setChecksumMetadata(metadata, checksumAlgorithm, base64.StdEncoding.EncodeToString(checksumWriter.Sum(nil)))
+	}
+	return objAPI.CopyObject(minioMetaBucket, stagePath, bucket, object, metadata)
+}
+
+// TruncateObjectHandler - POST /{bucket}/{object}?x-minio-truncate&length={length}
+// ----------
+// This implementation of the truncate operation cuts the object down
+// to the given length server-side, without uploading it again. The
+// truncated object gets a new ETag, which is sent back.
+func (api objectAPIHandlers) TruncateObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objAPI := api.ObjectAPI()
+	if objAPI == nil {
+		writeErrorResponse(w, ErrServerNotInitialized, r.URL)
+		return
+	}
+
+	if s3Error := checkRequestAuthType(r, bucket, "s3:PutObject", serverConfig.GetRegion()); s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	length, s3Error := getTruncateLength(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, s3Error, r.URL)
+		return
+	}
+
+	// Truncated size must be within the limits of the bucket, if any.
+	if err := checkObjectSizeLimits(bucket, length); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	// Object under retention can not be truncated.
+	if err := enforceObjectRetention(objAPI, bucket, object, r); err != nil {
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	objInfo, err := truncateObject(objAPI, bucket, object, length)
+	if err != nil {
+		if errorCause(err) != errInvalidTruncateLength {
+			errorIf(err, "Unable to truncate %s/%s.", bucket, object)
+		}
+		writeErrorResponse(w, toAPIErrorCode(err), r.URL)
+		return
+	}
+
+	w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
+	writeSuccessResponseHeadersOnly(w)
+
+	// Notify object created event.
+	eventNotify(eventData{
+		Type:    ObjectCreatedPut,
+		Bucket:  bucket,
+		ObjInfo: objInfo,
+		ReqParams: map[string]string{
+			"sourceIPAddress": r.RemoteAddr,
+		},
+	})
+}
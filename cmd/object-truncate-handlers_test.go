@@ -0,0 +1,124 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// Tests truncating objects server-side, the truncated objects keep
+// their metadata and get the ETag of the truncated content.
+func TestTruncateObjectHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testTruncateObjectHandler, []string{"TruncateObject"})
+}
+
+func testTruncateObjectHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	data := []byte("line 1\nline 2\nline 3\n")
+	sum := sha256.Sum256(data)
+	metadata := map[string]string{
+		"content-type":                      "text/plain",
+		"X-Amz-Meta-Log":                    "app",
+		checksumMetadataKey(checksumSHA256): base64.StdEncoding.EncodeToString(sum[:]),
+	}
+	objectName := "app.log"
+	if _, err := obj.PutObject(bucketName, objectName, int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+		t.Fatalf("%s: Failed to upload object: <ERROR> %v", instanceType, err)
+	}
+
+	serveRequest := func(object, length string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("POST", getTruncateObjectURL("", bucketName, object, length), 0, nil,
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Invalid lengths and missing objects are rejected.
+	testCases := []struct {
+		object         string
+		length         string
+		expectedStatus int
+	}{
+		{objectName, "", http.StatusBadRequest},
+		{objectName, "abc", http.StatusBadRequest},
+		{objectName, "-1", http.StatusBadRequest},
+		{objectName, "22", http.StatusBadRequest},
+		{"missing.log", "1", http.StatusNotFound},
+	}
+	for i, testCase := range testCases {
+		if rec := serveRequest(testCase.object, testCase.length); rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+
+	for _, length := range []int{14, 14, 7, 0} {
+		rec := serveRequest(objectName, strconv.Itoa(length))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d: %s", instanceType, http.StatusOK, rec.Code, rec.Body.String())
+		}
+		truncated := data[:length]
+
+		etagHash := newETagHash()
+		etagHash.Write(truncated)
+		etag := getETag(etagHash.Sum(nil))
+		if actual := rec.Header().Get("ETag"); actual != "\""+etag+"\"" {
+			t.Fatalf("%s: Expected ETag %s, got %s", instanceType, etag, actual)
+		}
+
+		objInfo, err := obj.GetObjectInfo(bucketName, objectName)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if objInfo.Size != int64(length) || objInfo.MD5Sum != etag {
+			t.Fatalf("%s: Expected size %d and ETag %s, got %d and %s", instanceType, length, etag, objInfo.Size, objInfo.MD5Sum)
+		}
+		if objInfo.ContentType != "text/plain" || objInfo.UserDefined["X-Amz-Meta-Log"] != "app" {
+			t.Fatalf("%s: Expected metadata to be kept, got %v", instanceType, objInfo.UserDefined)
+		}
+		sum = sha256.Sum256(truncated)
+		if checksum := objInfo.UserDefined[checksumMetadataKey(checksumSHA256)]; checksum != base64.StdEncoding.EncodeToString(sum[:]) {
+			t.Fatalf("%s: Expected checksum of the truncated content, got %s", instanceType, checksum)
+		}
+
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucketName, objectName, 0, objInfo.Size, &buffer); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(buffer.Bytes(), truncated) {
+			t.Fatalf("%s: Expected content %q, got %q", instanceType, truncated, buffer.Bytes())
+		}
+	}
+
+	// Staged content is removed.
+	result, err := obj.ListObjects(minioMetaBucket, truncatePrefix, "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != 0 {
+		t.Fatalf("%s: Expected no staged content left, got %v", instanceType, result.Objects)
+	}
+}
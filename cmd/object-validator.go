@@ -0,0 +1,101 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import "io"
+
+// ObjectValidator - validates the content of the objects written to
+// the buckets, e.g. to scan them for malware. The content is streamed
+// to the validation as it is written, before the object is committed,
+// so that a rejected object is never visible. Parts of the multipart
+// uploads are validated one by one.
+type ObjectValidator interface {
+	// NewValidation - starts the validation of the content written
+	// to the object.
+	NewValidation(bucket, object string) ObjectValidation
+}
+
+// ObjectValidation - validation of the content of a single write.
+type ObjectValidation interface {
+	// Write - receives the content in order as it is written. An
+	// error rejects the content right away, the rest of it is not
+	// read.
+	io.Writer
+
+	// Validate - called once the whole content was received, before
+	// the object is committed. An error rejects the content.
+	Validate() error
+}
+
+// nopObjectValidator - accepts all the content, the default.
+type nopObjectValidator struct{}
+
+func (nopObjectValidator) NewValidation(bucket, object string) ObjectValidation {
+	return nopObjectValidation{}
+}
+
+type nopObjectValidation struct{}
+
+func (nopObjectValidation) Write(p []byte) (int, error) { return len(p), nil }
+
+func (nopObjectValidation) Validate() error { return nil }
+
+// contentValidation - runs the validation of an object write, the
+// errors of the validation are returned as ObjectContentRejected.
+type contentValidation struct {
+	bucket, object string
+	validation     ObjectValidation
+}
+
+// newContentValidation - starts the validation of the content of the
+// object by globalObjectValidator. The internal writes of the meta
+// buckets are not validated.
+func newContentValidation(bucket, object string) contentValidation {
+	var validation ObjectValidation = nopObjectValidation{}
+	if !isMinioMetaBucketName(bucket) {
+		validation = globalObjectValidator.NewValidation(bucket, object)
+	}
+	return contentValidation{
+		bucket:     bucket,
+		object:     object,
+		validation: validation,
+	}
+}
+
+func (v contentValidation) rejected(err error) error {
+	return ObjectContentRejected{
+		GenericError: GenericError{Bucket: v.bucket, Object: v.object},
+		Reason:       err.Error(),
+	}
+}
+
+func (v contentValidation) Write(p []byte) (int, error) {
+	n, err := v.validation.Write(p)
+	if err != nil {
+		return n, v.rejected(err)
+	}
+	return n, nil
+}
+
+// Validate - returns ObjectContentRejected if the content of the
+// object is rejected.
+func (v contentValidation) Validate() error {
+	if err := v.validation.Validate(); err != nil {
+		return traceError(v.rejected(err))
+	}
+	return nil
+}
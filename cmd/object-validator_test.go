@@ -0,0 +1,195 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// signatureValidator - rejects the content containing the signature,
+// even when split across writes.
+type signatureValidator struct {
+	signature []byte
+
+	// Rejects the content of more than maxSize bytes while written.
+	maxSize int
+
+	mu        sync.Mutex
+	validated []string
+}
+
+func (v *signatureValidator) NewValidation(bucket, object string) ObjectValidation {
+	v.mu.Lock()
+	v.validated = append(v.validated, bucket+"/"+object)
+	v.mu.Unlock()
+	return &signatureValidation{validator: v}
+}
+
+type signatureValidation struct {
+	validator *signatureValidator
+	size      int
+	// Tail of the content written so far, matches the signatures
+	// split across writes.
+	tail  []byte
+	found bool
+}
+
+func (v *signatureValidation) Write(p []byte) (int, error) {
+	v.size += len(p)
+	if v.validator.maxSize > 0 && v.size > v.validator.maxSize {
+		return 0, errors.New("content too large to validate")
+	}
+	window := append(v.tail, p...)
+	if bytes.Contains(window, v.validator.signature) {
+		v.found = true
+	}
+	if keep := len(v.validator.signature) - 1; len(window) > keep {
+		window = window[len(window)-keep:]
+	}
+	v.tail = append([]byte(nil), window...)
+	return len(p), nil
+}
+
+func (v *signatureValidation) Validate() error {
+	if v.found {
+		return errors.New("signature found")
+	}
+	return nil
+}
+
+// Tests the objects and the parts whose content is rejected by the
+// validator are not written.
+func TestObjectValidator(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectValidator)
+}
+
+func testObjectValidator(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	validator := &signatureValidator{signature: []byte("X5O!P%@AP")}
+	defer func(v ObjectValidator) { globalObjectValidator = v }(globalObjectValidator)
+	globalObjectValidator = validator
+
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Failed to create bucket: <ERROR> %v", instanceType, err)
+	}
+	expectRejected := func(testName string, err error) {
+		if _, ok := errorCause(err).(ObjectContentRejected); !ok {
+			t.Fatalf("%s: %s: Expected %T, got %v", instanceType, testName, ObjectContentRejected{}, err)
+		}
+		if code := toAPIErrorCode(err); code != ErrObjectContentRejected {
+			t.Fatalf("%s: %s: Expected the API error %s, got %s", instanceType, testName,
+				getAPIError(ErrObjectContentRejected).Code, getAPIError(code).Code)
+		}
+	}
+
+	// Clean content is written.
+	clean := []byte("hello world")
+	if _, err := obj.PutObject(bucket, "clean", int64(len(clean)), bytes.NewReader(clean), nil, ""); err != nil {
+		t.Fatalf("%s: Expected the clean object to be written, got %v", instanceType, err)
+	}
+
+	// The signature is found even when spanning several reads of
+	// the body, the object is not written.
+	infected := append(bytes.Repeat([]byte("a"), 2*blockSizeV1-4), []byte("X5O!P%@AP")...)
+	_, err := obj.PutObject(bucket, "infected", int64(len(infected)), bytes.NewReader(infected), nil, "")
+	expectRejected("put infected", err)
+	if _, err = obj.GetObjectInfo(bucket, "infected"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the rejected object not to be written, got %v", instanceType, err)
+	}
+
+	// An existing object is not replaced by rejected content.
+	_, err = obj.PutObject(bucket, "clean", int64(len(infected)), bytes.NewReader(infected), nil, "")
+	expectRejected("overwrite with infected", err)
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "clean", 0, int64(len(clean)), &buffer); err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), clean) {
+		t.Fatalf("%s: Expected the object to be unchanged, got %q", instanceType, buffer.String())
+	}
+
+	// Content rejected while written.
+	validator.maxSize = 4
+	_, err = obj.PutObject(bucket, "large", int64(len(clean)), bytes.NewReader(clean), nil, "")
+	expectRejected("put large", err)
+	if _, err = obj.GetObjectInfo(bucket, "large"); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the rejected object not to be written, got %v", instanceType, err)
+	}
+	validator.maxSize = 0
+
+	// Parts are validated too.
+	uploadID, err := obj.NewMultipartUpload(bucket, "multipart", nil)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	_, err = obj.PutObjectPart(bucket, "multipart", uploadID, 1, int64(len(infected)), bytes.NewReader(infected), "", "")
+	expectRejected("put infected part", err)
+	result, err := obj.ListObjectParts(bucket, "multipart", uploadID, 0, 1000)
+	if err != nil {
+		t.Fatalf("%s: %v", instanceType, err)
+	}
+	if len(result.Parts) != 0 {
+		t.Fatalf("%s: Expected the rejected part not to be written, got %v", instanceType, result.Parts)
+	}
+	if _, err = obj.PutObjectPart(bucket, "multipart", uploadID, 1, int64(len(clean)), bytes.NewReader(clean), "", ""); err != nil {
+		t.Fatalf("%s: Expected the clean part to be written, got %v", instanceType, err)
+	}
+
+	// Internal writes of the meta buckets are not validated.
+	for _, name := range validator.validated {
+		if isMinioMetaBucketName(strings.SplitN(name, "/", 2)[0]) {
+			t.Fatalf("%s: Unexpected validation of %s", instanceType, name)
+		}
+	}
+}
+
+// Tests the PUTs of rejected content fail with 403.
+func TestPutObjectContentRejectedHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testPutObjectContentRejectedHandler, []string{"PutObject"})
+}
+
+func testPutObjectContentRejectedHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	defer func(v ObjectValidator) { globalObjectValidator = v }(globalObjectValidator)
+	globalObjectValidator = &signatureValidator{signature: []byte("X5O!P%@AP")}
+
+	for i, data := range [][]byte{[]byte("hello"), []byte("hello X5O!P%@AP")} {
+		rec := httptest.NewRecorder()
+		req, err := newTestSignedRequestV4("PUT", getPutObjectURL("", bucketName, "object"), int64(len(data)), bytes.NewReader(data),
+			credentials.AccessKey, credentials.SecretKey)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		expectedStatus := http.StatusOK
+		if i == 1 {
+			expectedStatus = http.StatusForbidden
+			if !strings.Contains(rec.Body.String(), "XMinioObjectContentRejected") {
+				t.Fatalf("%s: Expected XMinioObjectContentRejected, got %s", instanceType, rec.Body.String())
+			}
+		}
+		if rec.Code != expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, expectedStatus, rec.Code)
+		}
+	}
+}
@@ -661,6 +661,9 @@ func (s *posix) PrepareFile(volume, path string, fileSize int64) (err error) {
 		if err == syscall.EIO {
 			atomic.AddInt32(&s.ioErrCount, 1)
 		}
+		if err == errDiskFull {
+			globalDiskFullAlerts.Alert(s.diskPath)
+		}
 	}()
 
 	if s.ioErrCount > maxAllowedIOError {
@@ -708,6 +711,9 @@ func (s *posix) AppendFile(volume, path string, buf []byte) (err error) {
 		if err == syscall.EIO {
 			atomic.AddInt32(&s.ioErrCount, 1)
 		}
+		if err == errDiskFull {
+			globalDiskFullAlerts.Alert(s.diskPath)
+		}
 	}()
 
 	if s.ioErrCount > maxAllowedIOError {
@@ -730,6 +736,9 @@ func (s *posix) AppendFile(volume, path string, buf []byte) (err error) {
 
 	// Return io.Copy
 	_, err = io.CopyBuffer(w, bytes.NewReader(buf), *bufp)
+	if isSysErrNoSpace(err) {
+		return errDiskFull
+	}
 	return err
 }
 
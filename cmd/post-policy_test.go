@@ -19,6 +19,7 @@ package cmd
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/xml"
 	"fmt"
 	"io/ioutil"
 	"mime/multipart"
@@ -502,6 +503,161 @@ func testPostPolicyBucketHandlerRedirect(obj ObjectLayer, instanceType string, t
 
 }
 
+// Wrapper for calling TestPostPolicyBucketHandlerConditions tests for both XL multiple disks and single node setup.
+func TestPostPolicyBucketHandlerConditions(t *testing.T) {
+	ExecObjectLayerTest(t, testPostPolicyBucketHandlerConditions)
+}
+
+// testPostPolicyBucketHandlerConditions tests POST Object policy
+// conditions and the responses to successful uploads.
+func testPostPolicyBucketHandlerConditions(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Initializing config.json failed")
+	}
+	defer removeAll(root)
+
+	// Register event notifier.
+	err = initEventNotifier(obj)
+	if err != nil {
+		t.Fatalf("Initializing event notifiers failed")
+	}
+
+	bucketName := getRandomBucketName()
+	apiRouter := initTestAPIEndPoints(obj, []string{"PostPolicy"})
+	credentials := serverConfig.GetCredential()
+	if err = obj.MakeBucket(bucketName); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	curTime := time.Now().UTC()
+	expiration := curTime.Add(5 * time.Minute).Format(expirationDateFormat)
+
+	testCases := []struct {
+		keyName    string
+		data       []byte
+		conditions string
+		formData   map[string]string
+
+		expectedStatus   int
+		expectedLocation string
+	}{
+		// Test case - 1.
+		// All the conditions are satisfied, object info is returned.
+		{
+			keyName:        "uploads/photo",
+			data:           []byte("hello"),
+			conditions:     `["starts-with", "$key", "uploads/"], {"key": "uploads/photo/upload.txt"}, ["content-length-range", 1, 1024], ["starts-with", "$x-amz-meta-uuid", ""], {"success_action_status": "201"}`,
+			formData:       map[string]string{"success_action_status": "201"},
+			expectedStatus: http.StatusCreated,
+		},
+		// Test case - 2.
+		// Every condition of a field must be satisfied.
+		{
+			keyName:        "uploads/other",
+			data:           []byte("hello"),
+			conditions:     `{"key": "uploads/photo/upload.txt"}, ["starts-with", "$key", "uploads/"]`,
+			expectedStatus: http.StatusForbidden,
+		},
+		// Test case - 3.
+		// Meta data does not match the policy.
+		{
+			keyName:        "uploads/meta",
+			data:           []byte("hello"),
+			conditions:     `["eq", "$x-amz-meta-uuid", "5678"]`,
+			expectedStatus: http.StatusForbidden,
+		},
+		// Test case - 4.
+		// Object is larger than the allowed content length.
+		{
+			keyName:        "uploads/large",
+			data:           bytes.Repeat([]byte("a"), 2048),
+			conditions:     `["content-length-range", 1, 1024]`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		// Test case - 5.
+		// Object info is added to the query of the redirect URL.
+		{
+			keyName:          "uploads/redirect",
+			data:             []byte("hello"),
+			conditions:       `["starts-with", "$success_action_redirect", "http://example.com/"]`,
+			formData:         map[string]string{"success_action_redirect": "http://example.com/done?src=form"},
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "http://example.com/done?src=form&bucket=" + bucketName + "&key=uploads/redirect/upload.txt&etag=%225d41402abc4b2a76b9719d911017c592%22",
+		},
+		// Test case - 6.
+		// Redirect URL does not satisfy the policy.
+		{
+			keyName:        "uploads/redirect-denied",
+			data:           []byte("hello"),
+			conditions:     `["starts-with", "$success_action_redirect", "http://example.com/"]`,
+			formData:       map[string]string{"success_action_redirect": "http://example.org/"},
+			expectedStatus: http.StatusForbidden,
+		},
+		// Test case - 7.
+		// Redirect field of older forms.
+		{
+			keyName:          "uploads/legacy",
+			data:             []byte("hello"),
+			formData:         map[string]string{"redirect": "http://example.com/done"},
+			expectedStatus:   http.StatusSeeOther,
+			expectedLocation: "http://example.com/done?bucket=" + bucketName + "&key=uploads/legacy/upload.txt&etag=%225d41402abc4b2a76b9719d911017c592%22",
+		},
+		// Test case - 8.
+		// Relative redirect URL is ignored in favor of success_action_status.
+		{
+			keyName:        "uploads/relative",
+			data:           []byte("hello"),
+			formData:       map[string]string{"success_action_redirect": "done", "success_action_status": "200"},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for i, testCase := range testCases {
+		conditions := fmt.Sprintf(`["eq", "$bucket", "%s"], ["eq", "$x-amz-algorithm", "AWS4-HMAC-SHA256"]`, bucketName)
+		if testCase.conditions != "" {
+			conditions += ", " + testCase.conditions
+		}
+		policy := fmt.Sprintf(`{"expiration": "%s", "conditions": [%s]}`, expiration, conditions)
+
+		rec := httptest.NewRecorder()
+		req, perr := newPostRequestV4Generic("", bucketName, testCase.keyName, testCase.data,
+			credentials.AccessKey, credentials.SecretKey, curTime, []byte(policy), testCase.formData, false, false)
+		if perr != nil {
+			t.Fatalf("Test %d: %s: Failed to create HTTP request for PostPolicyHandler: <ERROR> %v", i+1, instanceType, perr)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("Test %d: %s: Expected the response status to be `%d`, but instead found `%d`: %s",
+				i+1, instanceType, testCase.expectedStatus, rec.Code, rec.Body.String())
+		}
+
+		objectName := testCase.keyName + "/upload.txt"
+		_, err = obj.GetObjectInfo(bucketName, objectName)
+		if testCase.expectedStatus >= http.StatusBadRequest {
+			if err == nil {
+				t.Errorf("Test %d: %s: Expected the object not to be created", i+1, instanceType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test %d: %s: Expected the object to be created: %v", i+1, instanceType, err)
+		}
+		if location := rec.Header().Get("Location"); testCase.expectedLocation != "" && location != testCase.expectedLocation {
+			t.Errorf("Test %d: %s: Expected location `%s`, found `%s`", i+1, instanceType, testCase.expectedLocation, location)
+		}
+		if testCase.expectedStatus == http.StatusCreated {
+			var resp PostResponse
+			if err = xml.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("Test %d: %s: Unexpected XML received %s", i+1, instanceType, err)
+			}
+			if resp.Bucket != bucketName || resp.Key != objectName {
+				t.Errorf("Test %d: %s: Unexpected response %#v", i+1, instanceType, resp)
+			}
+		}
+	}
+}
+
 // postPresignSignatureV4 - presigned signature for PostPolicy requests.
 func postPresignSignatureV4(policyBase64 string, t time.Time, secretAccessKey, location string) string {
 	// Get signining key.
@@ -99,14 +99,19 @@ type contentLengthRange struct {
 	Valid bool // If content-length-range was part of policy
 }
 
+// policyCondition - a single condition of a POST policy, a form
+// field may be restricted by multiple conditions.
+type policyCondition struct {
+	Operator string
+	Key      string
+	Value    string
+}
+
 // PostPolicyForm provides strict static type conversion and validation for Amazon S3's POST policy JSON string.
 type PostPolicyForm struct {
 	Expiration time.Time // Expiration date and time of the POST policy.
 	Conditions struct {  // Conditional policy structure.
-		Policies map[string]struct {
-			Operator string
-			Value    string
-		}
+		Policies           []policyCondition
 		ContentLengthRange contentLengthRange
 	}
 }
@@ -132,10 +137,6 @@ func parsePostPolicyForm(policy string) (PostPolicyForm, error) {
 	if err != nil {
 		return PostPolicyForm{}, err
 	}
-	parsedPolicy.Conditions.Policies = make(map[string]struct {
-		Operator string
-		Value    string
-	})
 
 	// Parse conditions.
 	for _, val := range rawPolicy.Conditions {
@@ -148,13 +149,11 @@ func parsePostPolicyForm(policy string) (PostPolicyForm, error) {
 				}
 				// {"acl": "public-read" } is an alternate way to indicate - [ "eq", "$acl", "public-read" ]
 				// In this case we will just collapse this into "eq" for all use cases.
-				parsedPolicy.Conditions.Policies["$"+strings.ToLower(k)] = struct {
-					Operator string
-					Value    string
-				}{
+				parsedPolicy.Conditions.Policies = append(parsedPolicy.Conditions.Policies, policyCondition{
 					Operator: policyCondEqual,
+					Key:      "$" + strings.ToLower(k),
 					Value:    toString(v),
-				}
+				})
 			}
 		case []interface{}: // Handle array types.
 			if len(condt) != 3 { // Return error if we have insufficient elements.
@@ -169,13 +168,11 @@ func parsePostPolicyForm(policy string) (PostPolicyForm, error) {
 					}
 				}
 				operator, matchType, value := toLowerString(condt[0]), toLowerString(condt[1]), toString(condt[2])
-				parsedPolicy.Conditions.Policies[matchType] = struct {
-					Operator string
-					Value    string
-				}{
+				parsedPolicy.Conditions.Policies = append(parsedPolicy.Conditions.Policies, policyCondition{
 					Operator: operator,
+					Key:      matchType,
 					Value:    value,
-				}
+				})
 			case policyCondContentLength:
 				min, err := toInteger(condt[1])
 				if err != nil {
@@ -229,7 +226,8 @@ func checkPostPolicy(formValues map[string]string, postPolicyForm PostPolicyForm
 	condPassed := true
 
 	// Iterate over policy conditions and check them against received form fields
-	for cond, v := range postPolicyForm.Conditions.Policies {
+	for _, v := range postPolicyForm.Conditions.Policies {
+		cond := v.Key
 		// Form fields names are in canonical format, convert conditions names
 		// to canonical for simplification purpose, so `$key` will become `Key`
 		formCanonicalName := http.CanonicalHeaderKey(strings.TrimPrefix(cond, "$"))
@@ -330,12 +330,20 @@ func waitForFormatXLDisks(firstDisk bool, endpoints []*url.URL, storageDisks []S
 	for i, storage := range storageDisks {
 		// After formatting is done we need a smaller time
 		// window and lower retry value before formatting.
-		formattedDisks[i] = &retryStorage{
+		var disk StorageAPI = &retryStorage{
 			remoteStorage:    storage,
 			maxRetryAttempts: globalStorageRetryThreshold,
 			retryUnit:        time.Millisecond,
 			retryCap:         time.Millisecond * 5, // 5 milliseconds.
 		}
+		// Read latency of the nodes is scored in a distributed
+		// setup, so that reads prefer the faster nodes.
+		if globalIsDistXL {
+			disk = newLatencyStorage(disk, endpoints[i].Host, globalNodeLatency)
+		}
+		// Concurrent operations on each disk are limited if
+		// configured.
+		formattedDisks[i] = newConcurrencyStorage(disk, globalDriveMaxConcurrency)
 	}
 
 	// Success.
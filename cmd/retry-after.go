@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Default bounds of the Retry-After of the throttling responses.
+	globalDefaultRetryAfterMin = time.Second
+	globalDefaultRetryAfterMax = 30 * time.Second
+
+	// Number of in-flight requests per CPU at which the Retry-After
+	// is half way between its bounds.
+	retryAfterRequestsPerCPU = 64
+)
+
+// setRetryAfterBounds - sets the bounds of the Retry-After of the
+// throttling responses from MINIO_RETRY_AFTER_MIN and
+// MINIO_RETRY_AFTER_MAX env.
+func setRetryAfterBounds() {
+	getBound := func(envName string, bound time.Duration) time.Duration {
+		value := os.Getenv(envName)
+		if value == "" {
+			return bound
+		}
+		duration, err := time.ParseDuration(value)
+		fatalIf(err, "Invalid %s value %s.", envName, value)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid %s value %s.", envName, value)
+		}
+		return duration
+	}
+	minRetryAfter := getBound("MINIO_RETRY_AFTER_MIN", globalRetryAfterMin)
+	maxRetryAfter := getBound("MINIO_RETRY_AFTER_MAX", globalRetryAfterMax)
+	if maxRetryAfter != 0 && minRetryAfter > maxRetryAfter {
+		fatalIf(errInvalidArgument, "MINIO_RETRY_AFTER_MIN %s is above MINIO_RETRY_AFTER_MAX %s.", minRetryAfter, maxRetryAfter)
+	}
+	globalRetryAfterMin, globalRetryAfterMax = minRetryAfter, maxRetryAfter
+}
+
+// isThrottlingError - returns true for the errors the client is
+// expected to retry once the server load or its disks recover.
+func isThrottlingError(errorCode APIErrorCode) bool {
+	switch errorCode {
+	case ErrReadQuorum, ErrWriteQuorum, ErrServerNotInitialized, ErrMetadataIndexNotReady:
+		return true
+	}
+	return false
+}
+
+// retryAfterSeconds - returns the number of seconds the client should
+// wait before retrying with inFlight requests being served. It grows
+// with the load from the lower to the upper bound, in whole seconds so
+// that the exact load is not disclosed.
+func retryAfterSeconds(inFlight int64) int64 {
+	minSecs := int64(globalRetryAfterMin / time.Second)
+	maxSecs := int64(globalRetryAfterMax / time.Second)
+	if maxSecs <= minSecs {
+		return minSecs
+	}
+	if inFlight < 0 {
+		inFlight = 0
+	}
+	halfLoad := int64(runtime.NumCPU() * retryAfterRequestsPerCPU)
+	return minSecs + (maxSecs-minSecs)*inFlight/(inFlight+halfLoad)
+}
+
+// setRetryAfterHeader - sets the Retry-After of the throttling error
+// responses, unless disabled with an upper bound of zero.
+func setRetryAfterHeader(w http.ResponseWriter, errorCode APIErrorCode) {
+	if globalRetryAfterMax == 0 || !isThrottlingError(errorCode) {
+		return
+	}
+	inFlight := atomic.LoadInt64(&globalRequestsInFlight)
+	w.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds(inFlight), 10))
+}
+
+// requestLoadHandler - counts the requests being served, the load the
+// Retry-After of the throttling responses is based on.
+type requestLoadHandler struct {
+	handler http.Handler
+}
+
+// setRequestLoadHandler - counts the in-flight requests.
+func setRequestLoadHandler(h http.Handler) http.Handler {
+	return requestLoadHandler{h}
+}
+
+func (h requestLoadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	atomic.AddInt64(&globalRequestsInFlight, 1)
+	defer atomic.AddInt64(&globalRequestsInFlight, -1)
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,139 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Tests the bounds of the Retry-After are read from the env.
+func TestSetRetryAfterBounds(t *testing.T) {
+	defer func(minRetryAfter, maxRetryAfter time.Duration) {
+		globalRetryAfterMin, globalRetryAfterMax = minRetryAfter, maxRetryAfter
+	}(globalRetryAfterMin, globalRetryAfterMax)
+	defer os.Unsetenv("MINIO_RETRY_AFTER_MIN")
+	defer os.Unsetenv("MINIO_RETRY_AFTER_MAX")
+
+	os.Setenv("MINIO_RETRY_AFTER_MIN", "2s")
+	os.Setenv("MINIO_RETRY_AFTER_MAX", "1m")
+	setRetryAfterBounds()
+	if globalRetryAfterMin != 2*time.Second || globalRetryAfterMax != time.Minute {
+		t.Fatalf("Expected the bounds 2s and 1m, got %s and %s", globalRetryAfterMin, globalRetryAfterMax)
+	}
+}
+
+// Tests the Retry-After grows with the load within its bounds.
+func TestRetryAfterSeconds(t *testing.T) {
+	defer func(minRetryAfter, maxRetryAfter time.Duration) {
+		globalRetryAfterMin, globalRetryAfterMax = minRetryAfter, maxRetryAfter
+	}(globalRetryAfterMin, globalRetryAfterMax)
+
+	globalRetryAfterMin, globalRetryAfterMax = time.Second, 30*time.Second
+	if secs := retryAfterSeconds(0); secs != 1 {
+		t.Fatalf("Expected 1s without load, got %ds", secs)
+	}
+	prev := int64(0)
+	for _, inFlight := range []int64{-1, 0, 1, 100, 1000, 100000, 1 << 40} {
+		secs := retryAfterSeconds(inFlight)
+		if secs < 1 || secs > 30 {
+			t.Fatalf("%d in-flight: Expected the Retry-After within [1, 30], got %d", inFlight, secs)
+		}
+		if secs < prev {
+			t.Fatalf("%d in-flight: Expected the Retry-After to grow with the load, got %d after %d", inFlight, secs, prev)
+		}
+		prev = secs
+	}
+	if prev != 29 {
+		t.Fatalf("Expected the Retry-After to approach its upper bound, got %d", prev)
+	}
+
+	// Equal bounds, the load does not matter.
+	globalRetryAfterMin, globalRetryAfterMax = 5*time.Second, 5*time.Second
+	if secs := retryAfterSeconds(1 << 20); secs != 5 {
+		t.Fatalf("Expected 5s, got %ds", secs)
+	}
+}
+
+// Tests the Retry-After header is set on the throttling error responses
+// only, within its bounds.
+func TestRetryAfterHeader(t *testing.T) {
+	defer func(minRetryAfter, maxRetryAfter time.Duration) {
+		globalRetryAfterMin, globalRetryAfterMax = minRetryAfter, maxRetryAfter
+	}(globalRetryAfterMin, globalRetryAfterMax)
+	globalRetryAfterMin, globalRetryAfterMax = 2*time.Second, 10*time.Second
+
+	var errorCode APIErrorCode
+	var inFlight int64
+	handler := setRequestLoadHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight = atomic.LoadInt64(&globalRequestsInFlight)
+		writeErrorResponse(w, errorCode, r.URL)
+	}))
+	serveRequest := func(code APIErrorCode) *httptest.ResponseRecorder {
+		errorCode = code
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "http://127.0.0.1:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for _, code := range []APIErrorCode{ErrReadQuorum, ErrWriteQuorum, ErrServerNotInitialized, ErrMetadataIndexNotReady} {
+		rec := serveRequest(code)
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s: Expected status %d, got %d", getAPIError(code).Code, http.StatusServiceUnavailable, rec.Code)
+		}
+		secs, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil {
+			t.Fatalf("%s: Expected a Retry-After in seconds, got %q", getAPIError(code).Code, rec.Header().Get("Retry-After"))
+		}
+		if secs < 2 || secs > 10 {
+			t.Fatalf("%s: Expected the Retry-After within [2, 10], got %d", getAPIError(code).Code, secs)
+		}
+	}
+	if inFlight < 1 {
+		t.Fatalf("Expected the request to be counted while served, got %d", inFlight)
+	}
+
+	// Heavy load raises the Retry-After up to its upper bound.
+	atomic.AddInt64(&globalRequestsInFlight, 1<<30)
+	rec := serveRequest(ErrWriteQuorum)
+	atomic.AddInt64(&globalRequestsInFlight, -(1 << 30))
+	if retryAfter := rec.Header().Get("Retry-After"); retryAfter != "9" {
+		t.Fatalf("Expected the Retry-After 9 under heavy load, got %q", retryAfter)
+	}
+
+	// Other errors, including other 503s, have no Retry-After.
+	for _, code := range []APIErrorCode{ErrNoSuchKey, ErrServerReadOnly, ErrInternalError} {
+		if retryAfter := serveRequest(code).Header().Get("Retry-After"); retryAfter != "" {
+			t.Fatalf("%s: Expected no Retry-After, got %q", getAPIError(code).Code, retryAfter)
+		}
+	}
+
+	// An upper bound of zero disables the header.
+	globalRetryAfterMin, globalRetryAfterMax = 0, 0
+	if retryAfter := serveRequest(ErrReadQuorum).Header().Get("Retry-After"); retryAfter != "" {
+		t.Fatalf("Expected no Retry-After once disabled, got %q", retryAfter)
+	}
+}
@@ -69,6 +69,13 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) (http.Handler, error)
 		return nil, err
 	}
 
+	// Add health check router, registered before the web router
+	// which serves all the other paths under reserved bucket.
+	registerHealthCheckRouter(mux)
+
+	// Add website router, registered before the web router as well.
+	registerWebsiteRouter(mux)
+
 	// Register web router when its enabled.
 	if globalIsBrowserEnabled {
 		if err := registerWebRouter(mux); err != nil {
@@ -92,6 +99,11 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) (http.Handler, error)
 		setBrowserRedirectHandler,
 		// Validates if incoming request is for restricted buckets.
 		setPrivateBucketHandler,
+		// Rejects the requests sent in TLS early data which may be
+		// replayed.
+		setEarlyDataHandler,
+		// Rejects the requests of invalid bucket names.
+		setBucketNameValidationHandler,
 		// Adds cache control for all browser requests.
 		setBrowserCacheControlHandler,
 		// Validates all incoming requests to have a valid date header.
@@ -101,10 +113,30 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) (http.Handler, error)
 		// Validates all incoming URL resources, for invalid/unsupported
 		// resources client receives a HTTP error.
 		setIgnoreResourcesHandler,
+		// Rejects all the S3 write requests when the server is in
+		// read-only mode.
+		setReadOnlyHandler,
+		// Rejects the requests of the requester pays buckets not
+		// acknowledging that the requester pays.
+		setRequesterPaysHandler,
+		// Rejects the writes of the buckets requiring a client
+		// certificate made without a verified one.
+		setClientCertRequiredHandler,
 		// Auth handler verifies incoming authorization headers and
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
 		setAuthHandler,
+		// Warns in the responses of the requests signed with the
+		// deprecated Signature V2.
+		setDeprecationWarningHandler,
+		// Writes access logs of the buckets with logging enabled.
+		setBucketLoggingHandler,
+		// Traces all the requests, including the rejected ones, for
+		// the admin trace API.
+		setTraceHandler,
+		// Counts the requests being served for the Retry-After of
+		// the throttling responses.
+		setRequestLoadHandler,
 		// Add new handlers here.
 	}
 
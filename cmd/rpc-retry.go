@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"net"
+	"net/rpc"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// rpcRetryPolicy - retry policy of the idempotent inter-node RPCs
+// failing with transient network errors.
+type rpcRetryPolicy struct {
+	// Maximum number of attempts of a call, 1 disables retries.
+	MaxAttempts int
+	// Base and maximum wait between the attempts, the wait grows
+	// exponentially with full jitter.
+	Unit time.Duration
+	Cap  time.Duration
+	// No attempt is started once the deadline since the first
+	// attempt passed.
+	Deadline time.Duration
+}
+
+// idempotentRPCMethods - RPCs which can be safely sent again when
+// their reply is lost, mutations are never retried. The clients with
+// reconnect disabled, such as the storage clients whose reconnects are
+// handled by retryStorage, never retry.
+var idempotentRPCMethods = map[string]bool{
+	"Storage.DiskInfoHandler": true,
+	"Storage.ListVolsHandler": true,
+	"Storage.StatVolHandler":  true,
+	"Storage.StatFileHandler": true,
+	"Storage.ReadAllHandler":  true,
+	"Storage.ReadFileHandler": true,
+	"Storage.ListDirHandler":  true,
+	"Dsync.Expired":           true,
+	"Admin.ListLocks":         true,
+	"Admin.ServerInfoData":    true,
+}
+
+// isRetriableRPCError - returns true for the errors of a broken
+// connection, errors returned by the remote service are final.
+func isRetriableRPCError(err error) bool {
+	switch err.(type) {
+	case *net.OpError:
+		return true
+	case rpc.ServerError:
+		return false
+	}
+	return err == rpc.ErrShutdown || err == io.EOF || err == io.ErrUnexpectedEOF
+}
+
+// RPCRetryStats - counters of the retried inter-node RPCs.
+type RPCRetryStats struct {
+	// Number of attempts made after a failed attempt.
+	Retries uint64 `json:"retries"`
+	// Number of calls which succeeded after a retry.
+	Recovered uint64 `json:"recovered"`
+	// Number of calls which failed after the attempts or the
+	// deadline were exhausted.
+	Exhausted uint64 `json:"exhausted"`
+}
+
+// rpcRetryStats - safe to be updated concurrently.
+type rpcRetryStats struct {
+	retries   uint64
+	recovered uint64
+	exhausted uint64
+}
+
+// Stats - returns a snapshot of the counters.
+func (s *rpcRetryStats) Stats() RPCRetryStats {
+	return RPCRetryStats{
+		Retries:   atomic.LoadUint64(&s.retries),
+		Recovered: atomic.LoadUint64(&s.recovered),
+		Exhausted: atomic.LoadUint64(&s.exhausted),
+	}
+}
+
+// callWithRetry - calls fn until it succeeds, fails with a non
+// retriable error or the policy is exhausted. reset is called after
+// every retriable failure to drop the broken connection.
+func callWithRetry(policy rpcRetryPolicy, stats *rpcRetryStats, fn func() error, reset func()) (err error) {
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+	retryTimer := newRetryTimer(policy.Unit, policy.Cap, MaxJitter, doneCh)
+
+	deadline := time.NewTimer(policy.Deadline)
+	defer deadline.Stop()
+
+	for attempt := 0; ; attempt++ {
+		select {
+		case <-retryTimer:
+		case <-deadline.C:
+			atomic.AddUint64(&stats.exhausted, 1)
+			return err
+		}
+		if attempt > 0 {
+			atomic.AddUint64(&stats.retries, 1)
+		}
+
+		if err = fn(); err == nil {
+			if attempt > 0 {
+				atomic.AddUint64(&stats.recovered, 1)
+			}
+			return nil
+		}
+		if !isRetriableRPCError(err) {
+			return err
+		}
+		reset()
+
+		if attempt+1 >= policy.MaxAttempts {
+			if policy.MaxAttempts > 1 {
+				atomic.AddUint64(&stats.exhausted, 1)
+			}
+			return err
+		}
+	}
+}
+
+// setRPCRetryPolicy - sets the retry policy of the inter-node RPCs
+// from MINIO_RPC_RETRY_ATTEMPTS, MINIO_RPC_RETRY_BACKOFF and
+// MINIO_RPC_RETRY_DEADLINE env.
+func setRPCRetryPolicy() {
+	if attempts := os.Getenv("MINIO_RPC_RETRY_ATTEMPTS"); attempts != "" {
+		maxAttempts, err := strconv.Atoi(attempts)
+		fatalIf(err, "Invalid MINIO_RPC_RETRY_ATTEMPTS value %s.", attempts)
+		if maxAttempts < 1 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_RPC_RETRY_ATTEMPTS value %s.", attempts)
+		}
+		globalRPCRetryPolicy.MaxAttempts = maxAttempts
+	}
+	if backoff := os.Getenv("MINIO_RPC_RETRY_BACKOFF"); backoff != "" {
+		duration, err := time.ParseDuration(backoff)
+		fatalIf(err, "Invalid MINIO_RPC_RETRY_BACKOFF value %s.", backoff)
+		if duration <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_RPC_RETRY_BACKOFF value %s.", backoff)
+		}
+		globalRPCRetryPolicy.Unit = duration
+		if globalRPCRetryPolicy.Cap < duration {
+			globalRPCRetryPolicy.Cap = duration
+		}
+	}
+	if deadline := os.Getenv("MINIO_RPC_RETRY_DEADLINE"); deadline != "" {
+		duration, err := time.ParseDuration(deadline)
+		fatalIf(err, "Invalid MINIO_RPC_RETRY_DEADLINE value %s.", deadline)
+		if duration <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_RPC_RETRY_DEADLINE value %s.", deadline)
+		}
+		globalRPCRetryPolicy.Deadline = duration
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// faultyRPCService - counts the calls of a read and a write method,
+// the replies of the first failures calls are lost as the connection
+// is closed before the reply is written.
+type faultyRPCService struct {
+	mu       sync.Mutex
+	calls    map[string]int
+	failures int
+	// Set when the reply of the current call is to be dropped.
+	dropReply int32
+}
+
+func (s *faultyRPCService) Login(args *LoginRPCArgs, reply *LoginRPCReply) error {
+	reply.AuthToken = "token"
+	return nil
+}
+
+func (s *faultyRPCService) call(method string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls[method]++
+	if s.failures > 0 {
+		s.failures--
+		atomic.StoreInt32(&s.dropReply, 1)
+	}
+}
+
+func (s *faultyRPCService) StatVolHandler(args *AuthRPCArgs, reply *AuthRPCReply) error {
+	s.call("StatVolHandler")
+	return nil
+}
+
+func (s *faultyRPCService) MakeVolHandler(args *AuthRPCArgs, reply *AuthRPCReply) error {
+	s.call("MakeVolHandler")
+	return nil
+}
+
+func (s *faultyRPCService) reset(failures int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = make(map[string]int)
+	s.failures = failures
+}
+
+func (s *faultyRPCService) getCalls(method string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[method]
+}
+
+// faultyRPCConn - closes the connection instead of writing a reply
+// which is to be dropped.
+type faultyRPCConn struct {
+	net.Conn
+	reader *bufio.Reader
+	svc    *faultyRPCService
+}
+
+func (c *faultyRPCConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
+
+func (c *faultyRPCConn) Write(b []byte) (int, error) {
+	if atomic.CompareAndSwapInt32(&c.svc.dropReply, 1, 0) {
+		c.Conn.Close()
+		return 0, errors.New("reply dropped")
+	}
+	return c.Conn.Write(b)
+}
+
+// startFaultyRPCServer - serves the service over the RPC CONNECT
+// protocol used by RPCClient, returns the server address.
+func startFaultyRPCServer(t *testing.T, svc *faultyRPCService) net.Listener {
+	server := rpc.NewServer()
+	if err := server.RegisterName("Storage", svc); err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				reader := bufio.NewReader(conn)
+				if _, err := http.ReadRequest(reader); err != nil {
+					conn.Close()
+					return
+				}
+				io.WriteString(conn, "HTTP/1.0 200 Connected to Go RPC\n\n")
+				server.ServeConn(&faultyRPCConn{Conn: conn, reader: reader, svc: svc})
+			}()
+		}
+	}()
+	return listener
+}
+
+// Tests that idempotent RPCs are retried on transient network errors
+// while mutations, and the calls of the clients with reconnect
+// disabled, are never retried.
+func TestAuthRPCClientRetry(t *testing.T) {
+	svc := &faultyRPCService{}
+	listener := startFaultyRPCServer(t, svc)
+	defer listener.Close()
+
+	savedPolicy, savedStats := globalRPCRetryPolicy, globalRPCRetryStats
+	defer func() {
+		globalRPCRetryPolicy, globalRPCRetryStats = savedPolicy, savedStats
+	}()
+
+	testCases := []struct {
+		method           string
+		failures         int
+		policy           rpcRetryPolicy
+		disableReconnect bool
+
+		expectErr   bool
+		expectCalls int
+		expectStats RPCRetryStats
+	}{
+		// Test case - 1.
+		// Read succeeds on retry.
+		{"StatVolHandler", 1, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, false,
+			false, 2, RPCRetryStats{Retries: 1, Recovered: 1}},
+		// Test case - 2.
+		// Read succeeds on the last attempt.
+		{"StatVolHandler", 2, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, false,
+			false, 3, RPCRetryStats{Retries: 2, Recovered: 1}},
+		// Test case - 3.
+		// Read fails once the attempts are exhausted.
+		{"StatVolHandler", 3, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, false,
+			true, 3, RPCRetryStats{Retries: 2, Exhausted: 1}},
+		// Test case - 4.
+		// Read fails once the deadline passed.
+		{"StatVolHandler", 1, rpcRetryPolicy{3, time.Hour, time.Hour, 50 * time.Millisecond}, false,
+			true, 1, RPCRetryStats{Exhausted: 1}},
+		// Test case - 5.
+		// Retries are disabled.
+		{"StatVolHandler", 1, rpcRetryPolicy{1, time.Millisecond, time.Millisecond, time.Second}, false,
+			true, 1, RPCRetryStats{}},
+		// Test case - 6.
+		// Write is not retried.
+		{"MakeVolHandler", 1, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, false,
+			true, 1, RPCRetryStats{}},
+		// Test case - 7.
+		// Write without failures.
+		{"MakeVolHandler", 0, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, false,
+			false, 1, RPCRetryStats{}},
+		// Test case - 8.
+		// Read of a client with reconnect disabled fails fast.
+		{"StatVolHandler", 1, rpcRetryPolicy{3, time.Millisecond, time.Millisecond, time.Second}, true,
+			true, 1, RPCRetryStats{}},
+	}
+
+	for i, testCase := range testCases {
+		globalRPCRetryPolicy = testCase.policy
+		globalRPCRetryStats = &rpcRetryStats{}
+		svc.reset(testCase.failures)
+
+		client := newAuthRPCClient(authConfig{
+			accessKey:        "minio",
+			secretKey:        "minio123",
+			serverAddr:       listener.Addr().String(),
+			serviceEndpoint:  "/test",
+			serviceName:      "Storage",
+			disableReconnect: testCase.disableReconnect,
+		})
+		err := client.Call("Storage."+testCase.method, &AuthRPCArgs{}, &AuthRPCReply{})
+		client.Close()
+		if testCase.expectErr && err == nil {
+			t.Errorf("Test %d: Expected the call to fail", i+1)
+		}
+		if !testCase.expectErr && err != nil {
+			t.Errorf("Test %d: Expected the call to succeed, got %v", i+1, err)
+		}
+		if calls := svc.getCalls(testCase.method); calls != testCase.expectCalls {
+			t.Errorf("Test %d: Expected %d calls, got %d", i+1, testCase.expectCalls, calls)
+		}
+		if stats := globalRPCRetryStats.Stats(); stats != testCase.expectStats {
+			t.Errorf("Test %d: Expected stats %#v, got %#v", i+1, testCase.expectStats, stats)
+		}
+	}
+}
+
+// Tests the errors considered transient.
+func TestIsRetriableRPCError(t *testing.T) {
+	testCases := []struct {
+		err       error
+		retriable bool
+	}{
+		{&net.OpError{Op: "dial-http", Err: errors.New("connection refused")}, true},
+		{rpc.ErrShutdown, true},
+		{io.ErrUnexpectedEOF, true},
+		{io.EOF, true},
+		{rpc.ServerError(io.EOF.Error()), false},
+		{rpc.ServerError(errFileNotFound.Error()), false},
+		{errServerTimeMismatch, false},
+	}
+	for i, testCase := range testCases {
+		if retriable := isRetriableRPCError(testCase.err); retriable != testCase.retriable {
+			t.Errorf("Test %d: Expected %v for %v, got %v", i+1, testCase.retriable, testCase.err, retriable)
+		}
+	}
+}
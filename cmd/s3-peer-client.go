@@ -197,3 +197,199 @@ func S3PeersUpdateBucketPolicy(bucket string, pCh policyChange) {
 		)
 	}
 }
+
+// S3PeersUpdateBucketLogging - Sends update bucket logging request to
+// all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketLogging(bucket string, target *LoggingEnabled) {
+	setBLPArgs := &SetBucketLoggingPeerArgs{Bucket: bucket, Target: target}
+	errs := globalS3Peers.SendUpdate(nil, setBLPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket logging to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketLifecycle - Sends update bucket lifecycle request to
+// all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketLifecycle(bucket string, config *LifecycleConfiguration) {
+	setBLCArgs := &SetBucketLifecyclePeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBLCArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket lifecycle to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketReplication - Sends update bucket replication request
+// to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketReplication(bucket string, config *ReplicationConfiguration) {
+	setBRCArgs := &SetBucketReplicationPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBRCArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket replication to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketContentSniffing - Sends update bucket content sniffing
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketContentSniffing(bucket string, config *ContentSniffingConfiguration) {
+	setBCSArgs := &SetBucketContentSniffingPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBCSArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket content sniffing to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketWebsite - Sends update bucket website request to
+// all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketWebsite(bucket string, config *WebsiteConfiguration) {
+	setBWArgs := &SetBucketWebsitePeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBWArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket website to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketRequestPayment - Sends update bucket request payment
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketRequestPayment(bucket string, config *RequestPaymentConfiguration) {
+	setBRPArgs := &SetBucketRequestPaymentPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBRPArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket request payment to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketObjectLock - Sends update bucket object lock
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketObjectLock(bucket string, config *ObjectLockConfiguration) {
+	setBOLArgs := &SetBucketObjectLockPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBOLArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket object lock to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketTrash - Sends update bucket trash request to all
+// peers. Currently we log an error and continue.
+func S3PeersUpdateBucketTrash(bucket string, config *TrashConfiguration) {
+	setBTArgs := &SetBucketTrashPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBTArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket trash to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketObjectSizeLimits - Sends update bucket object size
+// limits request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketObjectSizeLimits(bucket string, config *ObjectSizeLimitsConfiguration) {
+	setBOSLArgs := &SetBucketObjectSizeLimitsPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBOSLArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket object size limits to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketPublicAccessBlock - Sends update bucket public
+// access block request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketPublicAccessBlock(bucket string, config *PublicAccessBlockConfiguration) {
+	setBPABArgs := &SetBucketPublicAccessBlockPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBPABArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket public access block to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketAccelerate - Sends update bucket accelerate
+// request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketAccelerate(bucket string, config *AccelerateConfiguration) {
+	setBAArgs := &SetBucketAcceleratePeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBAArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket accelerate to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketClientCert - Sends update bucket client
+// certificate request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketClientCert(bucket string, config *ClientCertConfiguration) {
+	setBCCArgs := &SetBucketClientCertPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBCCArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket client certificate to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketContentAddressing - Sends update bucket content
+// addressing request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketContentAddressing(bucket string, config *ContentAddressingConfiguration) {
+	setBCAArgs := &SetBucketContentAddressingPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBCAArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket content addressing to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
+
+// S3PeersUpdateBucketOwnershipControls - Sends update bucket ownership
+// controls request to all peers. Currently we log an error and continue.
+func S3PeersUpdateBucketOwnershipControls(bucket string, config *OwnershipControls) {
+	setBOCArgs := &SetBucketOwnershipControlsPeerArgs{Bucket: bucket, Config: config}
+	errs := globalS3Peers.SendUpdate(nil, setBOCArgs)
+	for idx, err := range errs {
+		errorIf(
+			err,
+			"Error sending update bucket ownership controls to %s - %v",
+			globalS3Peers[idx].addr, err,
+		)
+	}
+}
@@ -116,3 +116,381 @@ func (s3 *s3PeerAPIHandlers) SetBucketPolicyPeer(args *SetBucketPolicyPeerArgs,
 
 	return s3.bms.UpdateBucketPolicy(args)
 }
+
+// SetBucketLoggingPeerArgs - Arguments collection for SetBucketLoggingPeer RPC call
+type SetBucketLoggingPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Logging target of the bucket, nil disables logging.
+	Target *LoggingEnabled
+}
+
+// BucketUpdate - implements bucket logging updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset logging.
+func (s *SetBucketLoggingPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketLogging(s)
+}
+
+// tell receiving server to update a bucket logging config
+func (s3 *s3PeerAPIHandlers) SetBucketLoggingPeer(args *SetBucketLoggingPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketLogging(args)
+}
+
+// SetBucketLifecyclePeerArgs - Arguments collection for SetBucketLifecyclePeer RPC call
+type SetBucketLifecyclePeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Lifecycle config of the bucket, nil removes the config.
+	Config *LifecycleConfiguration
+}
+
+// BucketUpdate - implements bucket lifecycle updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset lifecycle.
+func (s *SetBucketLifecyclePeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketLifecycle(s)
+}
+
+// tell receiving server to update a bucket lifecycle config
+func (s3 *s3PeerAPIHandlers) SetBucketLifecyclePeer(args *SetBucketLifecyclePeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketLifecycle(args)
+}
+
+// SetBucketReplicationPeerArgs - Arguments collection for SetBucketReplicationPeer RPC call
+type SetBucketReplicationPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Replication config of the bucket, nil removes the config.
+	Config *ReplicationConfiguration
+}
+
+// BucketUpdate - implements bucket replication updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset replication.
+func (s *SetBucketReplicationPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketReplication(s)
+}
+
+// tell receiving server to update a bucket replication config
+func (s3 *s3PeerAPIHandlers) SetBucketReplicationPeer(args *SetBucketReplicationPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketReplication(args)
+}
+
+// SetBucketContentSniffingPeerArgs - Arguments collection for SetBucketContentSniffingPeer RPC call
+type SetBucketContentSniffingPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Content sniffing config of the bucket, nil removes the config.
+	Config *ContentSniffingConfiguration
+}
+
+// BucketUpdate - implements bucket content sniffing updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset content sniffing.
+func (s *SetBucketContentSniffingPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketContentSniffing(s)
+}
+
+// tell receiving server to update a bucket content sniffing config
+func (s3 *s3PeerAPIHandlers) SetBucketContentSniffingPeer(args *SetBucketContentSniffingPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketContentSniffing(args)
+}
+
+// SetBucketWebsitePeerArgs - Arguments collection for SetBucketWebsitePeer RPC call
+type SetBucketWebsitePeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Website config of the bucket, nil removes the config.
+	Config *WebsiteConfiguration
+}
+
+// BucketUpdate - implements bucket website updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset website.
+func (s *SetBucketWebsitePeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketWebsite(s)
+}
+
+// tell receiving server to update a bucket website config
+func (s3 *s3PeerAPIHandlers) SetBucketWebsitePeer(args *SetBucketWebsitePeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketWebsite(args)
+}
+
+// SetBucketRequestPaymentPeerArgs - Arguments collection for SetBucketRequestPaymentPeer RPC call
+type SetBucketRequestPaymentPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Request payment config of the bucket, nil removes the config.
+	Config *RequestPaymentConfiguration
+}
+
+// BucketUpdate - implements bucket request payment updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset request payment.
+func (s *SetBucketRequestPaymentPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketRequestPayment(s)
+}
+
+// tell receiving server to update a bucket request payment config
+func (s3 *s3PeerAPIHandlers) SetBucketRequestPaymentPeer(args *SetBucketRequestPaymentPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketRequestPayment(args)
+}
+
+// SetBucketObjectLockPeerArgs - Arguments collection for SetBucketObjectLockPeer RPC call
+type SetBucketObjectLockPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Object lock config of the bucket, nil removes the config.
+	Config *ObjectLockConfiguration
+}
+
+// BucketUpdate - implements bucket object lock updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset object lock.
+func (s *SetBucketObjectLockPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketObjectLock(s)
+}
+
+// tell receiving server to update a bucket object lock config
+func (s3 *s3PeerAPIHandlers) SetBucketObjectLockPeer(args *SetBucketObjectLockPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketObjectLock(args)
+}
+
+// SetBucketTrashPeerArgs - Arguments collection for SetBucketTrashPeer RPC call
+type SetBucketTrashPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Trash config of the bucket, nil removes the config.
+	Config *TrashConfiguration
+}
+
+// BucketUpdate - implements bucket trash updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset trash.
+func (s *SetBucketTrashPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketTrash(s)
+}
+
+// tell receiving server to update a bucket trash config
+func (s3 *s3PeerAPIHandlers) SetBucketTrashPeer(args *SetBucketTrashPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketTrash(args)
+}
+
+// SetBucketObjectSizeLimitsPeerArgs - Arguments collection for SetBucketObjectSizeLimitsPeer RPC call
+type SetBucketObjectSizeLimitsPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Object size limits config of the bucket, nil removes the config.
+	Config *ObjectSizeLimitsConfiguration
+}
+
+// BucketUpdate - implements bucket object size limits updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset object size limits.
+func (s *SetBucketObjectSizeLimitsPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketObjectSizeLimits(s)
+}
+
+// tell receiving server to update a bucket object size limits config
+func (s3 *s3PeerAPIHandlers) SetBucketObjectSizeLimitsPeer(args *SetBucketObjectSizeLimitsPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketObjectSizeLimits(args)
+}
+
+// SetBucketPublicAccessBlockPeerArgs - Arguments collection for SetBucketPublicAccessBlockPeer RPC call
+type SetBucketPublicAccessBlockPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Public access block config of the bucket, nil removes the config.
+	Config *PublicAccessBlockConfiguration
+}
+
+// BucketUpdate - implements bucket public access block updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset public access block.
+func (s *SetBucketPublicAccessBlockPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketPublicAccessBlock(s)
+}
+
+// tell receiving server to update a bucket public access block config
+func (s3 *s3PeerAPIHandlers) SetBucketPublicAccessBlockPeer(args *SetBucketPublicAccessBlockPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketPublicAccessBlock(args)
+}
+
+// SetBucketAcceleratePeerArgs - Arguments collection for SetBucketAcceleratePeer RPC call
+type SetBucketAcceleratePeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Accelerate config of the bucket, nil removes the config.
+	Config *AccelerateConfiguration
+}
+
+// BucketUpdate - implements bucket accelerate updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset accelerate.
+func (s *SetBucketAcceleratePeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketAccelerate(s)
+}
+
+// tell receiving server to update a bucket accelerate config
+func (s3 *s3PeerAPIHandlers) SetBucketAcceleratePeer(args *SetBucketAcceleratePeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketAccelerate(args)
+}
+
+// SetBucketClientCertPeerArgs - Arguments collection for SetBucketClientCertPeer RPC call
+type SetBucketClientCertPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Client certificate config of the bucket, nil removes the config.
+	Config *ClientCertConfiguration
+}
+
+// BucketUpdate - implements bucket client certificate updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset client certificate.
+func (s *SetBucketClientCertPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketClientCert(s)
+}
+
+// tell receiving server to update a bucket client certificate config
+func (s3 *s3PeerAPIHandlers) SetBucketClientCertPeer(args *SetBucketClientCertPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketClientCert(args)
+}
+
+// SetBucketContentAddressingPeerArgs - Arguments collection for SetBucketContentAddressingPeer RPC call
+type SetBucketContentAddressingPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Content addressing config of the bucket, nil removes the config.
+	Config *ContentAddressingConfiguration
+}
+
+// BucketUpdate - implements bucket content addressing updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset content addressing.
+func (s *SetBucketContentAddressingPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketContentAddressing(s)
+}
+
+// tell receiving server to update a bucket content addressing config
+func (s3 *s3PeerAPIHandlers) SetBucketContentAddressingPeer(args *SetBucketContentAddressingPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketContentAddressing(args)
+}
+
+// SetBucketOwnershipControlsPeerArgs - Arguments collection for SetBucketOwnershipControlsPeer RPC call
+type SetBucketOwnershipControlsPeerArgs struct {
+	// For Auth
+	AuthRPCArgs
+
+	Bucket string
+
+	// Ownership controls config of the bucket, nil removes the config.
+	Config *OwnershipControls
+}
+
+// BucketUpdate - implements bucket ownership controls updates,
+// the underlying operation is a network call updates all
+// the peers participating for new set/unset ownership controls.
+func (s *SetBucketOwnershipControlsPeerArgs) BucketUpdate(client BucketMetaState) error {
+	return client.UpdateBucketOwnershipControls(s)
+}
+
+// tell receiving server to update a bucket ownership controls config
+func (s3 *s3PeerAPIHandlers) SetBucketOwnershipControlsPeer(args *SetBucketOwnershipControlsPeerArgs, reply *AuthRPCReply) error {
+	if err := args.IsAuthenticated(); err != nil {
+		return err
+	}
+
+	return s3.bms.UpdateBucketOwnershipControls(args)
+}
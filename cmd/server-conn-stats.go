@@ -0,0 +1,164 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Default time the connections must keep being rejected over the
+// limit before an alert is logged.
+const globalDefaultConnRejectAlertPeriod = time.Minute
+
+// setMaxConnections - sets the maximum number of open connections and
+// the period of rejections before an alert from MINIO_MAX_CONNECTIONS
+// and MINIO_CONN_REJECT_ALERT_PERIOD env.
+func setMaxConnections() {
+	if maxConns := os.Getenv("MINIO_MAX_CONNECTIONS"); maxConns != "" {
+		n, err := strconv.Atoi(maxConns)
+		if err != nil || n < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_CONNECTIONS value %s.", maxConns)
+		}
+		globalMaxConnections = n
+	}
+	if period := os.Getenv("MINIO_CONN_REJECT_ALERT_PERIOD"); period != "" {
+		duration, err := time.ParseDuration(period)
+		fatalIf(err, "Invalid MINIO_CONN_REJECT_ALERT_PERIOD value %s.", period)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_CONN_REJECT_ALERT_PERIOD value %s.", period)
+		}
+		globalConnRejectAlertPeriod = duration
+	}
+}
+
+// ConnStats - connections statistics of the listeners.
+type ConnStats struct {
+	// Connections accepted within the limit.
+	Accepted uint64 `json:"accepted"`
+	// Connections closed as soon as accepted, over the limit.
+	Rejected uint64 `json:"rejected"`
+	// Accepted connections not handed to the HTTP server yet, while
+	// their protocol is detected or their TLS handshake is done.
+	Queued int64 `json:"queued"`
+	// Accepted connections not closed yet.
+	Open int64 `json:"open"`
+	// Maximum number of open connections, zero is unlimited.
+	MaxConnections int `json:"maxConnections"`
+}
+
+// connStats - counts the connections of the listeners, and rejects
+// them over the maximum number of open connections.
+type connStats struct {
+	// Counters, accessed atomically. Kept first for 64-bit alignment
+	// on 32-bit platforms.
+	accepted uint64
+	rejected uint64
+	queued   int64
+	open     int64
+
+	// Guards the rejection streak below.
+	mu sync.Mutex
+	// Start of the ongoing rejections, the rejections closer than the
+	// alert period to each other are one streak.
+	streakStart time.Time
+	lastReject  time.Time
+	lastAlert   time.Time
+}
+
+// newConnStats - initializes the connections statistics.
+func newConnStats() *connStats {
+	return &connStats{}
+}
+
+// admit - counts an accepted connection, queued until handed to the
+// HTTP server. Returns false, counting the rejection, if the maximum
+// number of connections are open.
+func (s *connStats) admit() bool {
+	for {
+		open := atomic.LoadInt64(&s.open)
+		if globalMaxConnections > 0 && open >= int64(globalMaxConnections) {
+			s.reject(time.Now().UTC())
+			return false
+		}
+		if atomic.CompareAndSwapInt64(&s.open, open, open+1) {
+			break
+		}
+	}
+	atomic.AddUint64(&s.accepted, 1)
+	atomic.AddInt64(&s.queued, 1)
+	return true
+}
+
+// dequeue - the admitted connection is handed to the HTTP server or
+// dropped.
+func (s *connStats) dequeue() {
+	atomic.AddInt64(&s.queued, -1)
+}
+
+// release - the admitted connection is closed.
+func (s *connStats) release() {
+	atomic.AddInt64(&s.open, -1)
+}
+
+// reject - counts a rejected connection, and logs an alert once the
+// connections keep being rejected for the alert period. Alerts are
+// repeated at most once per period. Returns true if alerted.
+func (s *connStats) reject(now time.Time) bool {
+	rejected := atomic.AddUint64(&s.rejected, 1)
+
+	period := globalConnRejectAlertPeriod
+	s.mu.Lock()
+	if s.lastReject.IsZero() || now.Sub(s.lastReject) > period {
+		s.streakStart = now
+	}
+	s.lastReject = now
+	alert := now.Sub(s.streakStart) >= period && (s.lastAlert.IsZero() || now.Sub(s.lastAlert) >= period)
+	if alert {
+		s.lastAlert = now
+	}
+	s.mu.Unlock()
+	if !alert {
+		return false
+	}
+
+	fields := logrus.Fields{
+		"alert":          "ConnectionsSaturated",
+		"rejected":       rejected,
+		"maxConnections": globalMaxConnections,
+	}
+	for _, log := range log.loggers {
+		log.WithFields(fields).Errorf("Connections are being rejected over the limit of %d open connections.", globalMaxConnections)
+	}
+	return true
+}
+
+// Stats - returns the connections statistics.
+func (s *connStats) Stats() ConnStats {
+	return ConnStats{
+		Accepted:       atomic.LoadUint64(&s.accepted),
+		Rejected:       atomic.LoadUint64(&s.rejected),
+		Queued:         atomic.LoadInt64(&s.queued),
+		Open:           atomic.LoadInt64(&s.open),
+		MaxConnections: globalMaxConnections,
+	}
+}
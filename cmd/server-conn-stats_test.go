@@ -0,0 +1,161 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Tests the alerts are only logged once the connections keep being
+// rejected for the alert period.
+func TestConnStatsRejectAlert(t *testing.T) {
+	defer func(period time.Duration) { globalConnRejectAlertPeriod = period }(globalConnRejectAlertPeriod)
+	globalConnRejectAlertPeriod = time.Minute
+
+	stats := newConnStats()
+	start := time.Now().UTC()
+	testCases := []struct {
+		after time.Duration
+		alert bool
+	}{
+		// A single rejection does not alert.
+		{0, false},
+		{30 * time.Second, false},
+		// Rejections sustained for the period.
+		{61 * time.Second, true},
+		// Alerts are not repeated within the period.
+		{90 * time.Second, false},
+		{121 * time.Second, true},
+		// A pause longer than the period starts a new streak.
+		{300 * time.Second, false},
+		{330 * time.Second, false},
+		{360 * time.Second, true},
+	}
+	for i, testCase := range testCases {
+		if alert := stats.reject(start.Add(testCase.after)); alert != testCase.alert {
+			t.Fatalf("Test %d: Expected alert %t after %s, got %t", i+1, testCase.alert, testCase.after, alert)
+		}
+	}
+	if rejected := stats.Stats().Rejected; rejected != uint64(len(testCases)) {
+		t.Fatalf("Expected %d rejections, got %d", len(testCases), rejected)
+	}
+}
+
+// Tests the connections over the maximum number of open connections
+// are rejected and counted, and an alert is logged.
+func TestListenerMuxMaxConnections(t *testing.T) {
+	defer func(stats *connStats) { globalConnStats = stats }(globalConnStats)
+	defer func(maxConns int) { globalMaxConnections = maxConns }(globalMaxConnections)
+	defer func(period time.Duration) { globalConnRejectAlertPeriod = period }(globalConnRejectAlertPeriod)
+
+	var buffer bytes.Buffer
+	testLog := logrus.New()
+	testLog.Out = &buffer
+	testLog.Formatter = new(logrus.JSONFormatter)
+	log.mu.Lock()
+	savedLoggers := log.loggers
+	log.loggers = []*logrus.Logger{testLog}
+	log.mu.Unlock()
+	defer func() {
+		log.mu.Lock()
+		log.loggers = savedLoggers
+		log.mu.Unlock()
+	}()
+
+	globalConnStats = newConnStats()
+	globalMaxConnections = 1
+	globalConnRejectAlertPeriod = 0
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := newListenerMux(ln, &tls.Config{})
+	defer listener.Close()
+
+	// waitForStats - waits for the connections statistics to match,
+	// the accept loop runs asynchronously.
+	waitForStats := func(testName string, expected ConnStats) {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			stats := globalConnStats.Stats()
+			if stats == expected {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("%s: Expected %+v, got %+v", testName, expected, stats)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	accept := func(testName string) net.Conn {
+		conn, dErr := net.Dial("tcp", listener.Addr().String())
+		if dErr != nil {
+			t.Fatalf("%s: %v", testName, dErr)
+		}
+		if _, dErr = conn.Write([]byte("GET / HTTP/1.1\r\n\r\n")); dErr != nil {
+			t.Fatalf("%s: %v", testName, dErr)
+		}
+		serverConn, aErr := listener.Accept()
+		if aErr != nil {
+			t.Fatalf("%s: %v", testName, aErr)
+		}
+		conn.Close()
+		return serverConn
+	}
+
+	// Queued until the protocol is peeked.
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForStats("queued", ConnStats{Accepted: 1, Queued: 1, Open: 1, MaxConnections: 1})
+	conn.Close()
+	waitForStats("dropped", ConnStats{Accepted: 1, MaxConnections: 1})
+
+	// First connection within the limit.
+	serverConn := accept("first connection")
+	waitForStats("first connection", ConnStats{Accepted: 2, Open: 1, MaxConnections: 1})
+
+	// Connections over the limit are rejected, and alerted.
+	for i := 0; i < 2; i++ {
+		if conn, err = net.Dial("tcp", listener.Addr().String()); err == nil {
+			conn.Close()
+		}
+	}
+	waitForStats("over the limit", ConnStats{Accepted: 2, Rejected: 2, Open: 1, MaxConnections: 1})
+	var fields logrus.Fields
+	if err = json.NewDecoder(&buffer).Decode(&fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["alert"] != "ConnectionsSaturated" || fields["level"] != "error" {
+		t.Fatalf("Unexpected alert %v", fields)
+	}
+
+	// Closing the connection makes room for the next one.
+	serverConn.Close()
+	waitForStats("closed", ConnStats{Accepted: 2, Rejected: 2, MaxConnections: 1})
+	accept("next connection").Close()
+	waitForStats("next connection", ConnStats{Accepted: 3, Rejected: 2, MaxConnections: 1})
+}
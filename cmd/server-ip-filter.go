@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+)
+
+// connIPFilter - allow and deny lists of the source IPs of the
+// connections, checked as soon as the connections are accepted.
+type connIPFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// parseCIDRList - parses a comma separated list of CIDRs, plain IPs
+// are taken as single address CIDRs.
+func parseCIDRList(cidrs string) ([]*net.IPNet, error) {
+	var ipNets []*net.IPNet
+	for _, cidr := range strings.Split(cidrs, ",") {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			ip := net.ParseIP(cidr)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %s", cidr)
+			}
+			if ip.To4() != nil {
+				cidr += "/32"
+			} else {
+				cidr += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, err
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+	return ipNets, nil
+}
+
+// newConnIPFilter - returns the filter of the comma separated allowed
+// and denied CIDRs, nil if both are empty.
+func newConnIPFilter(allow, deny string) (*connIPFilter, error) {
+	allowNets, err := parseCIDRList(allow)
+	if err != nil {
+		return nil, err
+	}
+	denyNets, err := parseCIDRList(deny)
+	if err != nil {
+		return nil, err
+	}
+	if len(allowNets) == 0 && len(denyNets) == 0 {
+		return nil, nil
+	}
+	return &connIPFilter{allow: allowNets, deny: denyNets}, nil
+}
+
+// isAllowed - returns if connections from the IP are served. Denied
+// IPs are never served, and only the allowed ones are once an allow
+// list is set.
+func (f *connIPFilter) isAllowed(ip net.IP) bool {
+	if f == nil {
+		return true
+	}
+	for _, ipNet := range f.deny {
+		if ipNet.Contains(ip) {
+			return false
+		}
+	}
+	if len(f.allow) == 0 {
+		return true
+	}
+	for _, ipNet := range f.allow {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isConnAllowed - returns if the connection is served based on its
+// remote IP.
+func (f *connIPFilter) isConnAllowed(conn net.Conn) bool {
+	if f == nil {
+		return true
+	}
+	tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	return f.isAllowed(tcpAddr.IP)
+}
+
+// setConnIPFilter - sets the allowed and denied source IPs of the
+// connections from MINIO_CONN_ALLOW_CIDRS and MINIO_CONN_DENY_CIDRS env.
+func setConnIPFilter() {
+	allow, deny := os.Getenv("MINIO_CONN_ALLOW_CIDRS"), os.Getenv("MINIO_CONN_DENY_CIDRS")
+	filter, err := newConnIPFilter(allow, deny)
+	fatalIf(err, "Invalid MINIO_CONN_ALLOW_CIDRS %s or MINIO_CONN_DENY_CIDRS %s value.", allow, deny)
+	globalConnIPFilter = filter
+}
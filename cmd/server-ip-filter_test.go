@@ -0,0 +1,156 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/tls"
+	"net"
+	"testing"
+	"time"
+)
+
+// Tests the allow and deny lists of the source IPs.
+func TestConnIPFilter(t *testing.T) {
+	testCases := []struct {
+		allow     string
+		deny      string
+		ip        string
+		allowed   bool
+		shouldErr bool
+	}{
+		// No lists serve all the IPs.
+		{"", "", "10.1.2.3", true, false},
+		// Denied CIDRs.
+		{"", "10.0.0.0/8", "10.1.2.3", false, false},
+		{"", "10.0.0.0/8", "192.168.1.1", true, false},
+		{"", "10.0.0.0/8, 192.168.1.1", "192.168.1.1", false, false},
+		{"", "192.168.1.1", "192.168.1.2", true, false},
+		// Allowed CIDRs only.
+		{"10.0.0.0/8", "", "10.1.2.3", true, false},
+		{"10.0.0.0/8", "", "192.168.1.1", false, false},
+		// Denied CIDRs take precedence.
+		{"10.0.0.0/8", "10.1.0.0/16", "10.1.2.3", false, false},
+		{"10.0.0.0/8", "10.1.0.0/16", "10.2.2.3", true, false},
+		// IPv6.
+		{"", "fd00::/8", "fd00::1", false, false},
+		{"::1", "", "::1", true, false},
+		{"::1", "", "127.0.0.1", false, false},
+		// Invalid lists.
+		{"10.0.0.0/33", "", "", false, true},
+		{"", "10.0.0", "", false, true},
+	}
+
+	for i, testCase := range testCases {
+		filter, err := newConnIPFilter(testCase.allow, testCase.deny)
+		if testCase.shouldErr {
+			if err == nil {
+				t.Fatalf("Test %d: Expected an error", i+1)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Test %d: Unexpected error %v", i+1, err)
+		}
+		if allowed := filter.isAllowed(net.ParseIP(testCase.ip)); allowed != testCase.allowed {
+			t.Fatalf("Test %d: Expected %s to be allowed %t, got %t", i+1, testCase.ip, testCase.allowed, allowed)
+		}
+	}
+}
+
+// Tests the connections of the denied IPs are closed as soon as
+// accepted, and never handed to the server.
+func TestListenerMuxIPFilter(t *testing.T) {
+	defer func(filter *connIPFilter) { globalConnIPFilter = filter }(globalConnIPFilter)
+
+	newListener := func(allow, deny string) *ListenerMux {
+		filter, err := newConnIPFilter(allow, deny)
+		if err != nil {
+			t.Fatal(err)
+		}
+		globalConnIPFilter = filter
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newListenerMux(ln, &tls.Config{})
+	}
+	acceptCh := func(ln *ListenerMux) chan net.Conn {
+		connCh := make(chan net.Conn, 1)
+		go func() {
+			conn, err := ln.Accept()
+			if err != nil {
+				close(connCh)
+				return
+			}
+			connCh <- conn
+		}()
+		return connCh
+	}
+
+	// The reset of a closed connection may fail the dial already.
+	expectClosed := func(addr string) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		if _, err = conn.Read(make([]byte, 1)); err == nil {
+			t.Fatal("Expected the connection to be closed")
+		} else if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			t.Fatal("Expected the connection to be closed by the server, timed out waiting")
+		}
+	}
+
+	// Connections of the denied IPs are closed without a request read.
+	denied := newListener("", "127.0.0.0/8")
+	defer denied.Close()
+	connCh := acceptCh(denied)
+	expectClosed(denied.Addr().String())
+	select {
+	case conn := <-connCh:
+		t.Fatalf("Expected the connection from %s not to be accepted", conn.RemoteAddr())
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Connections of the allowed IPs are served.
+	allowed := newListener("127.0.0.1", "10.0.0.0/8")
+	defer allowed.Close()
+	connCh = acceptCh(allowed)
+	conn, err := net.Dial("tcp", allowed.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err = conn.Write([]byte("GET / HTTP/1.1\r\n\r\n")); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case serverConn := <-connCh:
+		if serverConn == nil {
+			t.Fatal("Expected the connection to be accepted")
+		}
+		serverConn.Close()
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the connection to be accepted, timed out waiting")
+	}
+
+	// Connections outside of the allow list are closed.
+	notAllowed := newListener("10.0.0.0/8", "")
+	defer notAllowed.Close()
+	expectClosed(notAllowed.Addr().String())
+}
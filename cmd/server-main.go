@@ -62,6 +62,144 @@ ENVIRONMENT VARIABLES:
   BROWSER:
      MINIO_BROWSER: To disable web browser access, set this value to "off".
 
+  HEADERS:
+     MINIO_MAX_HEADER_BYTES: Maximum total size of the request headers, for example "64KiB". Defaults to "1MiB".
+     MINIO_MAX_HEADER_COUNT: Maximum number of the request headers. Defaults to 1000.
+     MINIO_HEADER_READ_TIMEOUT: Time allowed to send the request headers after their first byte. Defaults to "30s".
+     MINIO_IDLE_CONN_TIMEOUT: Time after which keep-alive connections idle between two requests are closed, independently of the request timeouts. Defaults to "30s".
+     MINIO_MAX_PIPELINED_REQUESTS: Maximum number of requests a client may pipeline on a connection ahead of their responses, each request past it is read after a delay. Requests sent after their previous response are never delayed. Defaults to "0" (unlimited).
+
+  CONNECTIONS:
+     MINIO_CONN_ALLOW_CIDRS: Comma separated list of CIDRs or IPs, for example "10.0.0.0/8,192.168.1.5", only the connections from them are served. Defaults to all.
+     MINIO_CONN_DENY_CIDRS: Comma separated list of CIDRs or IPs whose connections are closed as soon as accepted, before any request is read. Takes precedence over MINIO_CONN_ALLOW_CIDRS. Defaults to none.
+     MINIO_MAX_CONNECTIONS: Maximum number of open connections, the connections over it are closed as soon as accepted and counted as rejected in the server info. Defaults to "0" (unlimited).
+     MINIO_CONN_REJECT_ALERT_PERIOD: Time the connections must keep being rejected over MINIO_MAX_CONNECTIONS before an alert is logged, repeated at most once per period. Defaults to "1m".
+
+  TLS:
+     MINIO_TLS_SESSION_TICKET_ROTATION: Interval between two rotations of the keys encrypting the TLS session tickets, for example "30m". Defaults to "1h", set "0" to leave the keys to the Go runtime.
+     MINIO_TLS_SESSION_TICKET_PREVIOUS_KEYS: Number of previous session ticket keys kept, the sessions of the tickets they issued are still resumed. Defaults to 2.
+     MINIO_TLS_EARLY_DATA: Requests accepted in TLS 1.3 early data (0-RTT), which may be replayed, "reads" for GET, HEAD and OPTIONS only or "off" for none. The other requests are rejected with 425 Too Early. The server never accepts early data nor renegotiates on its own listener, early data is marked by the TLS terminating proxies with the Early-Data header. Defaults to "reads".
+
+  CLIENT CERTIFICATES:
+     MINIO_CLIENT_CERT_OPTIONAL: To accept the TLS clients without a certificate while client CAs are configured, set this value to "on". The certificates presented are still verified, and the buckets may require them for their writes. Defaults to "off".
+
+  QUORUM:
+     MINIO_QUORUM_CHECK_INTERVAL: Interval at which online disks are verified, operations fail fast once quorum is lost. Defaults to "5s", set "0" to disable.
+
+  RPC:
+     MINIO_RPC_RETRY_ATTEMPTS: Maximum number of attempts of idempotent inter-node calls failing with network errors. Defaults to 3, set 1 to disable retries.
+     MINIO_RPC_RETRY_BACKOFF: Base wait between the attempts, grows exponentially with jitter. Defaults to "100ms".
+     MINIO_RPC_RETRY_DEADLINE: Time after the first attempt past which no retry is made. Defaults to "5s".
+
+  BANDWIDTH:
+     MINIO_BANDWIDTH_LIMIT: Maximum rate of the object data uploaded or downloaded by a connection, for example "10MiB" per second. Defaults to unlimited.
+     MINIO_BANDWIDTH_LIMIT_KEYS: Comma separated list of limits overriding MINIO_BANDWIDTH_LIMIT for the requests of an access key, for example "minio=1MiB,backup=0". A limit of 0 is unlimited.
+
+  BUCKETS:
+     MINIO_MAX_BUCKETS: Maximum number of buckets, creating more buckets fails with TooManyBuckets. Defaults to 0 (unlimited).
+     MINIO_MAX_MULTIPART_UPLOADS: Maximum number of multipart uploads in progress per bucket, initiating more uploads fails with TooManyMultipartUploads until some are completed or aborted. Defaults to 0 (unlimited).
+     MINIO_BUCKET_AUTO_CREATE: To create the missing buckets on the first authenticated PUT of an object instead of failing with NoSuchBucket, set this value to "on". Defaults to "off".
+
+  FAN-OUT:
+     MINIO_FAN_OUT_MAX_KEYS: Maximum number of keys an upload is stored under by a x-minio-fan-out request. Defaults to 100.
+
+  ETAG:
+     MINIO_ETAG_ALGORITHM: Algorithm of the ETags of the objects written, "md5" or "sha256" where MD5 is not allowed. SHA-256 ETags are marked with a "-sha256" suffix. Defaults to "md5".
+
+  OBJECT SIZE:
+     MINIO_MAX_OBJECT_SIZE: Maximum size of an object uploaded by a single PUT, POST or copy, and of each part of a multipart upload, for example "1GiB". Larger uploads are rejected with EntityTooLarge from their declared length, before their data is read. Defaults to "5GiB".
+     MINIO_MAX_MULTIPART_OBJECT_SIZE: Maximum size of an object completed from the parts of a multipart upload, for example "1TiB". Larger completions are rejected with EntityTooLarge, the upload is kept so that it can be aborted. Defaults to "5TiB".
+
+  NOTIFICATIONS:
+     MINIO_NOTIFY_ORDERED_TARGETS: Comma separated list of queue ARNs of the targets receiving the events of each object in order, for example "arn:minio:sqs:us-east-1:1:webhook". The events of an object are sent one at a time in the background, the events of different objects concurrently. Defaults to no ordered target.
+
+  DELETE:
+     MINIO_ASYNC_DELETE_MIN_SIZE: Minimum size of the objects deleted asynchronously, for example "1GiB". Their DELETE returns once they are moved out of their bucket, gone from the reads and the listings, and their storage is reclaimed in the background. Defaults to all the objects deleted synchronously.
+
+  BUCKET NAMES:
+     MINIO_BUCKET_NAME_VALIDATION: Validation of the bucket names, "strict" or "relaxed". Strict names are DNS compliant, 3-63 lowercase letters, numbers, dashes and periods. Relaxed names are legacy names, 3-255 letters, numbers, dashes, periods and underscores. Requests naming an invalid bucket are rejected with InvalidBucketName. Defaults to "strict".
+
+  DOWNLOADS:
+     MINIO_GET_CANCEL_ON_DISCONNECT: To keep reading the objects to completion when their client disconnected mid-download, set this value to "off". Defaults to "on", stopping the read of the object as soon as the client is gone.
+
+  MULTIPART:
+     MINIO_MAX_PARTS: Maximum number of parts of a multipart upload, part numbers range from 1 to this value. Defaults to 10000.
+     MINIO_MAX_COMPLETE_MULTIPART_SIZE: Maximum size of the body of a CompleteMultipartUpload, larger bodies are rejected as malformed. Defaults to "4MiB".
+
+  CACHE:
+     MINIO_CACHE_DIR: Directory on a faster drive caching the objects read, for example "/mnt/nvme". Defaults to no cache.
+     MINIO_CACHE_SIZE: Capacity of the cache, for example "100GiB", the least recently used objects are evicted once reached.
+     MINIO_CACHE_ADMISSION: Admission of the objects to the cache, "always" on their first read or "second-hit" on their second read. Defaults to "always".
+     MINIO_GET_COALESCING: To share one read of the backend between the concurrent GETs of the same range of an object, set this value to "on". Defaults to "off".
+     MINIO_LIST_CACHE_TTL: Time the results of the listings are cached, for example "5s". The writes drop the cached listings of their prefixes. Ignored on distributed setups. Defaults to "0" (disabled).
+
+  METADATA INDEX:
+     MINIO_METADATA_INDEX_KEYS: Comma separated list of user metadata keys indexed for the x-minio-metadata-search listing, for example "color,owner". Ignored on distributed setups. Defaults to none (disabled).
+     MINIO_METADATA_FILTER_MAX_SCAN: Maximum number of entries scanned by a listing filtered by x-minio-meta-filter on a key not indexed, the listing is truncated past them. Defaults to 10000.
+
+  LISTING:
+     MINIO_LIST_TOKEN_COMPRESSION: To stop gzip compressing the continuation tokens of ListObjectsV2 where it makes them shorter, set this value to "off". Defaults to "on".
+     MINIO_LIST_TOKEN_SIGNING: To sign the continuation tokens of ListObjectsV2 with the secret key of the server, rejecting the tampered or forged tokens with InvalidToken, set this value to "on". Defaults to "off".
+
+  DRIVE:
+     MINIO_DRIVE_MAX_CONCURRENCY: Maximum number of concurrent operations on each drive of an erasure coded setup, further operations queue and reads are routed to the less busy drives. Defaults to 0 (unlimited).
+     MINIO_NODE_LATENCY_HALF_LIFE: Half-life of the read latency scores of the nodes of a distributed setup, reads are routed away from the nodes much slower than the fastest one. Defaults to "1m", set "0" to disable.
+
+  COPY:
+     MINIO_COPY_CONCURRENCY: Number of chunks of the objects copied server-side read concurrently. Defaults to 4, set 1 to read serially.
+     MINIO_COPY_CHUNK_SIZE: Size of the chunks of the objects copied server-side, for example "32MiB". Defaults to "16MiB".
+     MINIO_COPY_MAX_MEMORY: Memory held by the chunks of all the objects copied server-side, for example "1GiB". Defaults to "256MiB".
+
+  BUFFERS:
+     MINIO_UPLOAD_BUFFER_POOL: Reuse of the buffers of the uploads and downloads across the requests, "on" or "off". Defaults to "on".
+     MINIO_UPLOAD_BUFFER_SIZE: Size of the buffers of the uploads and downloads of the FS backend, for example "4MiB". Defaults to "1MiB".
+
+  HEAL:
+     MINIO_HEAL_CONCURRENCY: Number of objects healed concurrently by a heal of the objects of a bucket. Defaults to 4.
+     MINIO_HEAL_BANDWIDTH: Maximum rate of the healed data written to the drives by all the heals, for example "50MiB" per second. Can be changed at runtime by the admin API. Defaults to unlimited.
+
+  COMPACTION:
+     MINIO_COMPACTION: To rewrite the fragmented objects of an erasure coded setup contiguously in background, set this value to "on". Their ETag, size and modification time are unchanged. Defaults to "off".
+     MINIO_COMPACTION_MIN_PARTS: Minimum number of parts of the objects compacted, objects with more parts than needed for their size. Defaults to 32.
+     MINIO_COMPACTION_INTERVAL: Interval between two compactions of all the buckets. Defaults to "1h".
+     MINIO_COMPACTION_BANDWIDTH: Maximum rate of the data of the objects compacted, for example "50MiB" per second. Defaults to unlimited.
+
+  SIGNATURE:
+     MINIO_SIGNING_ALTERNATE_REGIONS: Comma separated list of regions accepted in the signature V4 of the requests besides the server region, for example "us-east-1" behind proxies rewriting the requests. Defaults to none.
+     MINIO_STREAMING_TRAILER: To reject the aws-chunked uploads followed by a trailing checksum declared by x-amz-trailer, set this value to "off". Defaults to "on".
+
+  DEPRECATION:
+     MINIO_DEPRECATION_WARNINGS: Comma separated list of the deprecated features the responses of the requests using them warn about with the Warning and X-Minio-Deprecation headers, "sigv2" and "list-objects-v1", set "off" to disable. The requests are served as usual. Defaults to all.
+
+  COMPRESSION:
+     MINIO_COMPRESS_CONTENT_TYPES: Comma separated list of content types of the objects gzip compressed on the wire for the clients accepting it, "type/*" matches all the subtypes. The stored objects and their ETags are unchanged, set "on" for "text/*,application/javascript,application/json,application/xml,image/svg+xml". Compression costs CPU on every GET of these objects and the responses are sent chunked, without Content-Length. Defaults to "off".
+
+  RANGES:
+     MINIO_EMPTY_OBJECT_RANGE_STATUS: Status of the responses to the ranges of the empty objects, none of their bytes being satisfiable, "416" (Range Not Satisfiable) or "200" sending the whole empty object. Suffix ranges are always answered with the whole empty object. Defaults to "416".
+
+  DISK FULL:
+     MINIO_DISK_FULL_STATUS: Status of the responses of the writes failing on full drives, "507" (Insufficient Storage) or "503" (Service Unavailable). Partial writes are removed and an alert is logged. Defaults to "507".
+     MINIO_DISK_FULL_ALERT_INTERVAL: Minimum time between two alerts logged for the same full drive. Defaults to "1m".
+
+  RETRY AFTER:
+     MINIO_RETRY_AFTER_MIN: Lower bound of the Retry-After, in whole seconds, of the 503 responses to retry later such as lost quorum, growing with the number of requests being served. Defaults to "1s".
+     MINIO_RETRY_AFTER_MAX: Upper bound of the Retry-After of the 503 responses to retry later, "0" omits the header. Defaults to "30s".
+
+  RESPONSE HEADERS:
+     MINIO_OBJECT_RESPONSE_HEADERS: Comma separated list of the response headers which may be set on the objects with "x-minio-response-header-<name>" on their writes, sent back on GET and HEAD. Headers set by the server itself are not allowed. Defaults to "X-Content-Type-Options,Content-Security-Policy,X-Frame-Options,X-XSS-Protection,Referrer-Policy,Strict-Transport-Security".
+
+  TORRENT:
+     MINIO_TORRENT: To generate the single file torrents of the objects up to 5GiB on GET ?torrent, seeded by the server through the object URL, set this value to "on". Defaults to "off" replying NotImplemented.
+
+  CLOCK:
+     MINIO_NTP_SERVER: NTP server, "host" or "host:port", the drift of the clock is checked against at startup and periodically, an alert is logged past MINIO_NTP_MAX_DRIFT as the signatures of the requests are sensitive to it. Defaults to none, no check.
+     MINIO_NTP_MAX_DRIFT: Maximum drift of the clock from the NTP server. Defaults to "1m".
+     MINIO_NTP_CHECK_INTERVAL: Interval between two checks of the clock drift, "0" only checks at startup. Defaults to "1h".
+     MINIO_NTP_STRICT: To refuse to start the server when the clock drifted past MINIO_NTP_MAX_DRIFT at startup, set this value to "on". Defaults to "off".
+
+  READ-ONLY:
+     MINIO_READ_ONLY: To start the server in read-only mode rejecting all the writes, set this value to "on".
+
 EXAMPLES:
   1. Start minio server on "/home/shared" directory.
       $ minio {{.Name}} /home/shared
@@ -137,6 +275,9 @@ func initServerConfig(c *cli.Context) {
 	// Load user supplied root CAs
 	loadRootCAs()
 
+	// Load user supplied client CAs for client certificate authentication.
+	loadClientCAs()
+
 	// When credentials inherited from the env, server cmd has to save them in the disk
 	if os.Getenv("MINIO_ACCESS_KEY") != "" && os.Getenv("MINIO_SECRET_KEY") != "" {
 		// Env credentials are already loaded in serverConfig, just save in the disk
@@ -148,6 +289,143 @@ func initServerConfig(c *cli.Context) {
 	// system limits of 1024, 2048 are not enough for Minio server.
 	setMaxOpenFiles()
 
+	// Set maximum size and number of the request headers.
+	setMaxHeaderLimits()
+	setHeaderReadTimeout()
+
+	// Set the timeout of the idle keep-alive connections.
+	setIdleConnTimeout()
+
+	// Set the maximum number of requests pipelined on a connection.
+	setMaxPipelinedRequests()
+
+	// Set the rotation of the TLS session ticket keys.
+	setSessionTicketRotation()
+
+	// Set the allowed and denied source IPs of the connections.
+	setConnIPFilter()
+
+	// Set the maximum number of open connections.
+	setMaxConnections()
+
+	// Set the interval at which quorum of the XL backend is verified.
+	setQuorumCheckInterval()
+
+	// Set the retry policy of the inter-node RPCs.
+	setRPCRetryPolicy()
+
+	// Set the bandwidth limits of the object data transfers.
+	setBandwidthLimits()
+
+	// Set the maximum number of keys of a fan-out PUT.
+	setFanOutMaxKeys()
+
+	// Set the maximum number of buckets.
+	setMaxBuckets()
+
+	// Set the maximum number of multipart uploads in progress per bucket.
+	setMaxMultipartUploads()
+
+	// Set the creation of the buckets on their first write.
+	setBucketAutoCreate()
+
+	// Set the algorithm of the ETags.
+	setETagAlgorithm()
+
+	// Set the maximum size of the objects uploaded by a single operation.
+	setMaxObjectSize()
+
+	// Set the maximum size of the objects completed from their parts.
+	setMaxMultipartObjectSize()
+
+	// Set the validation of the bucket names.
+	setBucketNameValidation()
+
+	// Set the minimum size of the objects deleted asynchronously.
+	setAsyncDeleteMinSize()
+
+	// Set the targets receiving the events of each object in order.
+	setNotifyOrderedTargets()
+
+	// Set the cancellation of the reads of the disconnected clients.
+	setGetCancelOnDisconnect()
+
+	// Set the requests accepted in TLS early data.
+	setTLSEarlyData()
+
+	// Set the maximum part ID of the multipart uploads.
+	setMaxPartID()
+
+	// Set the maximum size of the bodies of CompleteMultipartUpload.
+	setMaxCompleteMultipartSize()
+
+	// Set the cache of the objects on a faster backend.
+	setDiskCacheConfig()
+
+	// Set the time the listings are cached.
+	setListCacheTTL()
+
+	// Set the coalescing of the concurrent reads of the same object range.
+	setGetCoalescing()
+
+	// Set the user metadata keys indexed.
+	setMetadataIndexKeys()
+
+	// Set the maximum number of entries scanned by the filtered listings.
+	setMetadataFilterMaxScan()
+
+	// Set the compression and the signing of the continuation tokens.
+	setListTokenCompression()
+	setListTokenSigning()
+
+	// Set the maximum number of concurrent operations on each drive.
+	setDriveMaxConcurrency()
+
+	// Set the half-life of the read latency scores of the nodes.
+	setNodeLatencyHalfLife()
+
+	// Set the concurrency of the server-side copies.
+	setCopyConcurrency()
+
+	// Set the pooling and size of the buffers of the data path.
+	setUploadBufferPool()
+
+	// Set the concurrency and the bandwidth of the heals.
+	setHealLimits()
+
+	// Set the compaction of the fragmented objects.
+	setCompaction()
+
+	// Set the status of the responses to the ranges of the empty objects.
+	setEmptyObjectRangeStatus()
+
+	// Set the handling of the writes failing on full disks.
+	setDiskFullPolicy()
+
+	// Set the bounds of the Retry-After of the throttling responses.
+	setRetryAfterBounds()
+
+	// Set the generation of the torrents of the objects.
+	setTorrent()
+
+	// Set the response headers allowed on the objects.
+	setObjectResponseHeaders()
+
+	// Set the check of the clock drift from an NTP server.
+	setClockDriftCheck()
+
+	// Set the regions accepted in the signatures besides the server region.
+	setAlternateSigningRegions()
+
+	// Set the acceptance of the streaming uploads with a trailer.
+	setStreamingTrailer()
+
+	// Set the deprecated features the responses warn about.
+	setDeprecationWarnings()
+
+	// Set the content types of the objects compressed on the wire.
+	setCompressContentTypes()
+
 	// Set maxMemory, This is necessary since default operating
 	// system limits might be changed and we need to make sure we
 	// do not crash the server so the set the maxCacheSize appropriately.
@@ -438,6 +716,7 @@ func serverMain(c *cli.Context) {
 
 	// Initialize a new HTTP server.
 	apiServer := NewServerMux(serverAddr, handler)
+	globalServerMux = apiServer
 
 	// Set the global minio addr for this server.
 	globalMinioAddr = getLocalAddress(srvConfig)
@@ -455,6 +734,9 @@ func serverMain(c *cli.Context) {
 	// Set the global API endpoints value.
 	globalAPIEndpoints = apiEndPoints
 
+	// Check the clock drift before serving any signed request.
+	startClockDriftCheck()
+
 	// Start server, automatically configures TLS if certs are available.
 	go func() {
 		cert, key := "", ""
@@ -470,10 +752,20 @@ func serverMain(c *cli.Context) {
 	newObject, err := newObjectLayer(srvConfig)
 	fatalIf(err, "Initializing object layer failed")
 
+	// Add the caches and the indexes configured.
+	newObject, err = wrapObjectLayer(newObject)
+	fatalIf(err, "Initializing object layer failed")
+
 	globalObjLayerMutex.Lock()
 	globalObjectAPI = newObject
 	globalObjLayerMutex.Unlock()
 
+	// Compact the fragmented objects in background if enabled.
+	startCompactor(newObjectLayerFn)
+
+	// Delete the objects expired at the end of their time to live.
+	startObjectExpiryReaper(newObjectLayerFn)
+
 	// Prints the formatted startup message once object layer is initialized.
 	printStartupMessage(apiEndPoints)
 
@@ -481,6 +773,44 @@ func serverMain(c *cli.Context) {
 	<-globalServiceDoneCh
 }
 
+// wrapObjectLayer - returns the backend object layer wrapped in the
+// caches and the indexes configured. Every object layer served, at
+// startup or after the disks are re-initialized, must be wrapped here
+// so that none of them is dropped.
+func wrapObjectLayer(newObject ObjectLayer) (ObjectLayer, error) {
+	// Serve the hot objects from the cache if configured.
+	if globalDiskCacheConfig.dir != "" {
+		var err error
+		if newObject, err = newCacheObjects(newObject, globalDiskCacheConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	// Share the backend reads of the concurrent GETs if configured.
+	if globalIsGetCoalescing {
+		newObject = newCoalescingObjects(newObject)
+	}
+
+	// Serve the repeated listings from the cache if configured, the
+	// writes through other servers are not seen by the cache.
+	if globalListCacheTTL > 0 && !globalIsDistXL {
+		newObject = newListCacheObjects(newObject, globalListCacheTTL)
+	}
+
+	// Index the user metadata of the objects if configured, the index
+	// is rebuilt in the background and the writes through other
+	// servers are not seen by the index.
+	if len(globalMetadataIndexKeys) > 0 && !globalIsDistXL {
+		metadataIndex := newMetadataIndexObjects(newObject, globalMetadataIndexKeys)
+		go func() {
+			errorIf(metadataIndex.Rebuild(), "Unable to build the metadata index.")
+		}()
+		newObject = metadataIndex
+	}
+
+	return newObject, nil
+}
+
 // Initialize object layer with the supplied disks, objectLayer is nil upon any error.
 func newObjectLayer(srvCmdCfg serverCmdConfig) (newObject ObjectLayer, err error) {
 	// For FS only, directly use the disk.
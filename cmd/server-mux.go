@@ -18,15 +18,22 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"crypto/tls"
 	"errors"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	humanize "github.com/dustin/go-humanize"
 )
 
 // The value chosen below is longest word chosen
@@ -59,6 +66,29 @@ var defaultHTTP1Methods = []string{
 type ConnMux struct {
 	net.Conn
 	bufrw *bufio.ReadWriter
+
+	// Guards the header read state below.
+	mu sync.Mutex
+	// Timeout to complete reading the request headers, starts with
+	// the first byte of the request while awaitingHeader is set.
+	headerTimeout  time.Duration
+	awaitingHeader bool
+	headerDeadline time.Time
+	// Deadline to start the next request while the connection is
+	// idle between two requests.
+	idleDeadline time.Time
+	// Read deadline set by the http.Server, Read never pushes the
+	// deadline past it.
+	readDeadline time.Time
+	// Number of requests served back-to-back from the data the client
+	// sent ahead of their responses, and whether the current request
+	// was read off the wire.
+	pipelined int
+	wireRead  bool
+
+	// Called once when the connection is closed.
+	closeOnce sync.Once
+	onClose   func()
 }
 
 // NewConnMux - creates a new ConnMux instance
@@ -111,9 +141,107 @@ func (c *ConnMux) PeekProtocol() (string, error) {
 // Read - streams the ConnMux buffer when reset flag is activated, otherwise
 // streams from the incoming network connection
 func (c *ConnMux) Read(b []byte) (int, error) {
-	// Push read deadline
-	c.Conn.SetReadDeadline(time.Now().Add(defaultTCPReadTimeout))
-	return c.bufrw.Read(b)
+	// Push read deadline, but never past the deadline to complete
+	// reading the request headers. Idle connections are only subject
+	// to the idle timeout.
+	deadline := time.Now().Add(defaultTCPReadTimeout)
+	c.mu.Lock()
+	if !c.idleDeadline.IsZero() {
+		deadline = c.idleDeadline
+	}
+	if !c.headerDeadline.IsZero() && c.headerDeadline.Before(deadline) {
+		deadline = c.headerDeadline
+	}
+	if !c.readDeadline.IsZero() && c.readDeadline.Before(deadline) {
+		deadline = c.readDeadline
+	}
+	// Set under the lock so that a concurrent SetReadDeadline, used
+	// to abort a pending read, is never overwritten.
+	c.Conn.SetReadDeadline(deadline)
+	c.mu.Unlock()
+
+	n, err := c.bufrw.Read(b)
+	if n > 0 {
+		// First bytes of the request start the header deadline and
+		// end the idle period, the request is read off the wire.
+		c.mu.Lock()
+		if c.awaitingHeader && c.headerDeadline.IsZero() && c.headerTimeout > 0 {
+			c.headerDeadline = time.Now().Add(c.headerTimeout)
+		}
+		if !c.idleDeadline.IsZero() {
+			c.wireRead = true
+		}
+		c.idleDeadline = time.Time{}
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// startHeaderRead - the next request headers should be read within
+// timeout from their first byte. Waiting for the request is only
+// subject to the idle timeout.
+func (c *ConnMux) startHeaderRead(timeout time.Duration) {
+	c.mu.Lock()
+	c.headerTimeout = timeout
+	c.awaitingHeader = true
+	c.headerDeadline = time.Time{}
+	c.mu.Unlock()
+}
+
+// endHeaderRead - request headers are read, the request body is only
+// subject to the idle timeout so that slow but steady clients work.
+func (c *ConnMux) endHeaderRead() {
+	c.mu.Lock()
+	c.awaitingHeader = false
+	c.headerDeadline = time.Time{}
+	c.mu.Unlock()
+}
+
+// SetReadDeadline - sets the read deadline of the connection, Read
+// does not push the deadline past it until it is reset.
+func (c *ConnMux) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readDeadline = t
+	return c.Conn.SetReadDeadline(t)
+}
+
+// startIdle - the connection is idle between two requests, it is
+// closed if the next request does not start within timeout.
+func (c *ConnMux) startIdle(timeout time.Duration) {
+	c.mu.Lock()
+	c.idleDeadline = time.Now().Add(timeout)
+	c.mu.Unlock()
+}
+
+// endIdle - the connection is serving a request.
+func (c *ConnMux) endIdle() {
+	c.mu.Lock()
+	c.idleDeadline = time.Time{}
+	c.mu.Unlock()
+}
+
+// startConn - the first request is read off the wire.
+func (c *ConnMux) startConn() {
+	c.mu.Lock()
+	c.wireRead = true
+	c.mu.Unlock()
+}
+
+// startRequest - a request is served, returns the number of requests
+// the client pipelined ahead of their responses served since the last
+// one read off the wire, this one included. The http.Server reads those
+// from its buffer, the connection is not read while idle before them.
+func (c *ConnMux) startRequest() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.wireRead {
+		c.pipelined = 0
+	} else {
+		c.pipelined++
+	}
+	c.wireRead = false
+	return c.pipelined
 }
 
 // Close the connection.
@@ -122,6 +250,12 @@ func (c *ConnMux) Close() (err error) {
 	// even if the bufioWriter flush sends an error.
 	defer c.Conn.Close()
 
+	c.closeOnce.Do(func() {
+		if c.onClose != nil {
+			c.onClose()
+		}
+	})
+
 	// Flush and write to the connection.
 	return c.bufrw.Flush()
 }
@@ -161,6 +295,18 @@ type ListenerMux struct {
 	// Cond is used to signal Close when there are no references to the listener.
 	cond *sync.Cond
 	refs int
+
+	// TLS connections served from this listener mapped to their
+	// underlying ConnMux.
+	tlsConnsMu sync.Mutex
+	tlsConns   map[net.Conn]*ConnMux
+
+	// Source IPs whose connections are closed as soon as accepted.
+	ipFilter *connIPFilter
+
+	// Counts the connections, and rejects them over the maximum
+	// number of open connections.
+	conns *connStats
 }
 
 // ListenerMuxAcceptRes contains then final net.Conn data (wrapper by tls or not) to be sent to the http handler
@@ -181,6 +327,10 @@ var defaultKeepAliveTimeout = 10 * time.Second // 10 seconds.
 // Timeout to close connection when a client is not sending any data
 var defaultTCPReadTimeout = 30 * time.Second
 
+// Delay before reading each request pipelined past the maximum number
+// of pipelined requests of a connection.
+var pipelineThrottleDelay = 100 * time.Millisecond
+
 // newListenerMux listens and wraps accepted connections with tls after protocol peeking
 func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 	l := ListenerMux{
@@ -188,6 +338,9 @@ func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 		config:      config,
 		cond:        sync.NewCond(&sync.Mutex{}),
 		acceptResCh: make(chan ListenerMuxAcceptRes),
+		tlsConns:    make(map[net.Conn]*ConnMux),
+		ipFilter:    globalConnIPFilter,
+		conns:       globalConnStats,
 	}
 	// Start listening, wrap connections with tls when needed
 	go func() {
@@ -207,6 +360,21 @@ func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 				continue
 			}
 
+			// Drop the connections of the filtered source IPs right
+			// away, with a reset so that no socket lingers either.
+			if !l.ipFilter.isConnAllowed(conn) {
+				conn.SetLinger(0)
+				conn.Close()
+				continue
+			}
+
+			// Close the connections over the limit right away as well.
+			if !l.conns.admit() {
+				conn.SetLinger(0)
+				conn.Close()
+				continue
+			}
+
 			// Enable Read timeout
 			conn.SetReadDeadline(time.Now().Add(defaultTCPReadTimeout))
 
@@ -216,10 +384,14 @@ func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 
 			// Allocate new conn muxer.
 			connMux := NewConnMux(conn)
+			connMux.onClose = l.conns.release
 
 			// Wrap the connection with ConnMux to be able to peek the data in the incoming connection
 			// and decide if we need to wrap the connection itself with a TLS or not
 			go func(connMux *ConnMux) {
+				// Queued until handed to the HTTP server or dropped.
+				defer l.conns.dequeue()
+
 				protocol, err := connMux.PeekProtocol()
 				if err != nil {
 					// io.EOF is usually returned by non-http clients,
@@ -241,6 +413,9 @@ func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 						tlsConn.Close()
 						return
 					}
+					l.tlsConnsMu.Lock()
+					l.tlsConns[tlsConn] = connMux
+					l.tlsConnsMu.Unlock()
 					l.acceptResCh <- ListenerMuxAcceptRes{
 						conn: tlsConn,
 					}
@@ -255,6 +430,77 @@ func newListenerMux(listener net.Listener, config *tls.Config) *ListenerMux {
 	return &l
 }
 
+// connState - tracks the state of a connection served from this
+// listener to enforce the header read timeout of its requests and the
+// idle timeout between them.
+func (l *ListenerMux) connState(conn net.Conn, state http.ConnState, headerReadTimeout, idleTimeout time.Duration) {
+	connMux, ok := conn.(*ConnMux)
+	if !ok {
+		l.tlsConnsMu.Lock()
+		connMux = l.tlsConns[conn]
+		if state == http.StateHijacked || state == http.StateClosed {
+			delete(l.tlsConns, conn)
+		}
+		l.tlsConnsMu.Unlock()
+		if connMux == nil {
+			return
+		}
+		// HTTP/2 multiplexes requests, there is no single request
+		// whose headers are read on the connection.
+		if tlsConn, ok := conn.(*tls.Conn); ok && tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+			return
+		}
+	}
+
+	switch state {
+	case http.StateIdle:
+		connMux.startIdle(idleTimeout)
+		connMux.startHeaderRead(headerReadTimeout)
+	case http.StateNew:
+		connMux.startConn()
+		connMux.startHeaderRead(headerReadTimeout)
+	default:
+		connMux.endIdle()
+		connMux.endHeaderRead()
+	}
+}
+
+// connMuxContextKey - request context key of the ConnMux of the
+// connection a request is read from.
+type connMuxContextKey struct{}
+
+// connContext - returns the context of the requests read from a
+// connection served from this listener, carrying its ConnMux.
+func (l *ListenerMux) connContext(ctx context.Context, conn net.Conn) context.Context {
+	connMux, ok := conn.(*ConnMux)
+	if !ok {
+		l.tlsConnsMu.Lock()
+		connMux = l.tlsConns[conn]
+		l.tlsConnsMu.Unlock()
+		if connMux == nil {
+			return ctx
+		}
+	}
+	return context.WithValue(ctx, connMuxContextKey{}, connMux)
+}
+
+// throttlePipelinedRequests - throttles the clients pipelining more
+// than maxPipelined requests on a connection, zero is unlimited. Each
+// request past it is served after a delay, requests sent after their
+// previous response are never throttled.
+func throttlePipelinedRequests(h http.Handler, maxPipelined int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// HTTP/2 multiplexes requests, none is pipelined.
+		connMux, ok := r.Context().Value(connMuxContextKey{}).(*ConnMux)
+		if ok && r.ProtoMajor == 1 {
+			if pipelined := connMux.startRequest(); maxPipelined > 0 && pipelined > maxPipelined {
+				time.Sleep(pipelineThrottleDelay)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
 // IsClosed - Returns if the underlying listener is closed fully.
 func (l *ListenerMux) IsClosed() bool {
 	l.cond.L.Lock()
@@ -309,6 +555,11 @@ func (l *ListenerMux) Accept() (net.Conn, error) {
 
 // ServerMux - the main mux server
 type ServerMux struct {
+	// Request counters, accessed atomically. Kept first for 64-bit
+	// alignment on 32-bit platforms.
+	activeRequests int64
+	totalRequests  uint64
+
 	Addr      string
 	handler   http.Handler
 	listeners []*ListenerMux
@@ -316,10 +567,44 @@ type ServerMux struct {
 	gracefulWait    *sync.WaitGroup
 	gracefulTimeout time.Duration
 
+	// Maximum total size and number of the request headers.
+	maxHeaderBytes int
+	maxHeaderCount int
+
+	// Timeout to read the request headers, connections are closed
+	// when a client does not complete them in time.
+	headerReadTimeout time.Duration
+
+	// Timeout to start the next request on a keep-alive connection,
+	// connections idle for longer are closed.
+	idleConnTimeout time.Duration
+
+	// Maximum number of requests pipelined on a connection ahead of
+	// their responses, past it the connection is throttled.
+	maxPipelinedRequests int
+
 	mu     sync.Mutex // guards closed, and listener
 	closed bool
 }
 
+// ServerMuxStats - requests statistics of a ServerMux.
+type ServerMuxStats struct {
+	ActiveRequests int64     `json:"activeRequests"`
+	TotalRequests  uint64    `json:"totalRequests"`
+	Connections    ConnStats `json:"connections"`
+}
+
+// Stats - returns the number of requests being served currently, the
+// total number of requests served since the server started and the
+// connections statistics of the listeners.
+func (m *ServerMux) Stats() ServerMuxStats {
+	return ServerMuxStats{
+		ActiveRequests: atomic.LoadInt64(&m.activeRequests),
+		TotalRequests:  atomic.LoadUint64(&m.totalRequests),
+		Connections:    globalConnStats.Stats(),
+	}
+}
+
 // NewServerMux constructor to create a ServerMux
 func NewServerMux(addr string, handler http.Handler) *ServerMux {
 	m := &ServerMux{
@@ -329,12 +614,90 @@ func NewServerMux(addr string, handler http.Handler) *ServerMux {
 		// forcibly close them during graceful stop or restart.
 		gracefulTimeout: 5 * time.Second,
 		gracefulWait:    &sync.WaitGroup{},
+		maxHeaderBytes:  globalMaxHeaderBytes,
+		maxHeaderCount:  globalMaxHeaderCount,
+
+		headerReadTimeout: globalHeaderReadTimeout,
+		idleConnTimeout:   globalIdleConnTimeout,
+
+		maxPipelinedRequests: globalMaxPipelinedRequests,
 	}
 
 	// Returns configured HTTP server.
 	return m
 }
 
+// countHeaders - returns the number of header fields, repeated
+// headers are counted for every value.
+func countHeaders(header http.Header) int {
+	count := 0
+	for _, values := range header {
+		count += len(values)
+	}
+	return count
+}
+
+// setMaxHeaderLimits - sets the maximum total size and number of the
+// request headers from MINIO_MAX_HEADER_BYTES and MINIO_MAX_HEADER_COUNT
+// env, defaults are used when not set.
+func setMaxHeaderLimits() {
+	if maxHeaderBytes := os.Getenv("MINIO_MAX_HEADER_BYTES"); maxHeaderBytes != "" {
+		size, err := humanize.ParseBytes(maxHeaderBytes)
+		fatalIf(err, "Invalid MINIO_MAX_HEADER_BYTES value %s.", maxHeaderBytes)
+		if size == 0 || size > math.MaxInt32 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_HEADER_BYTES value %s.", maxHeaderBytes)
+		}
+		globalMaxHeaderBytes = int(size)
+	}
+	if maxHeaderCount := os.Getenv("MINIO_MAX_HEADER_COUNT"); maxHeaderCount != "" {
+		count, err := strconv.Atoi(maxHeaderCount)
+		fatalIf(err, "Invalid MINIO_MAX_HEADER_COUNT value %s.", maxHeaderCount)
+		if count <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_HEADER_COUNT value %s.", maxHeaderCount)
+		}
+		globalMaxHeaderCount = count
+	}
+}
+
+// setHeaderReadTimeout - sets the timeout to read the request headers
+// from MINIO_HEADER_READ_TIMEOUT env.
+func setHeaderReadTimeout() {
+	if timeout := os.Getenv("MINIO_HEADER_READ_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		fatalIf(err, "Invalid MINIO_HEADER_READ_TIMEOUT value %s.", timeout)
+		if duration <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_HEADER_READ_TIMEOUT value %s.", timeout)
+		}
+		globalHeaderReadTimeout = duration
+	}
+}
+
+// setIdleConnTimeout - sets the timeout to start the next request on
+// a keep-alive connection from MINIO_IDLE_CONN_TIMEOUT env.
+func setIdleConnTimeout() {
+	if timeout := os.Getenv("MINIO_IDLE_CONN_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout)
+		fatalIf(err, "Invalid MINIO_IDLE_CONN_TIMEOUT value %s.", timeout)
+		if duration <= 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_IDLE_CONN_TIMEOUT value %s.", timeout)
+		}
+		globalIdleConnTimeout = duration
+	}
+}
+
+// setMaxPipelinedRequests - sets the maximum number of requests
+// pipelined on a connection from MINIO_MAX_PIPELINED_REQUESTS env.
+func setMaxPipelinedRequests() {
+	if maxPipelined := os.Getenv("MINIO_MAX_PIPELINED_REQUESTS"); maxPipelined != "" {
+		count, err := strconv.Atoi(maxPipelined)
+		fatalIf(err, "Invalid MINIO_MAX_PIPELINED_REQUESTS value %s.", maxPipelined)
+		if count < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_PIPELINED_REQUESTS value %s.", maxPipelined)
+		}
+		globalMaxPipelinedRequests = count
+	}
+}
+
 // Initialize listeners on all ports.
 func initListeners(serverAddr string, tls *tls.Config) ([]*ListenerMux, error) {
 	host, port, err := net.SplitHostPort(serverAddr)
@@ -413,6 +776,18 @@ func (m *ServerMux) ListenAndServe(certFile, keyFile string) (err error) {
 		if err != nil {
 			return err
 		}
+		// Require and verify client certificates, if client CAs are configured.
+		if globalClientCAs != nil {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+			if globalIsClientCertOptional {
+				config.ClientAuth = tls.VerifyClientCertIfGiven
+			}
+			config.ClientCAs = globalClientCAs
+		}
+		// Rotate the keys encrypting the session tickets.
+		if err = startSessionTicketRotation(config); err != nil {
+			return err
+		}
 	}
 
 	go m.handleServiceSignals()
@@ -428,6 +803,12 @@ func (m *ServerMux) ListenAndServe(certFile, keyFile string) (err error) {
 
 	// All http requests start to be processed by httpHandler
 	httpHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Size of the headers is limited by http.Server, count them
+		// here as many tiny headers fit in the size limit.
+		if countHeaders(r.Header) > m.maxHeaderCount {
+			writeErrorResponse(w, ErrRequestHeaderFieldsTooLarge, r.URL)
+			return
+		}
 		if tlsEnabled && r.TLS == nil {
 			// TLS is enabled but Request is not TLS configured
 			u := url.URL{
@@ -444,7 +825,10 @@ func (m *ServerMux) ListenAndServe(certFile, keyFile string) (err error) {
 			// Execute registered handlers, protect with a waitgroup
 			// to accomplish a graceful shutdown when the user asks to quit
 			m.gracefulWait.Add(1)
+			atomic.AddUint64(&m.totalRequests, 1)
+			atomic.AddInt64(&m.activeRequests, 1)
 			m.handler.ServeHTTP(w, r)
+			atomic.AddInt64(&m.activeRequests, -1)
 			m.gracefulWait.Done()
 		}
 	})
@@ -454,7 +838,19 @@ func (m *ServerMux) ListenAndServe(certFile, keyFile string) (err error) {
 		wg.Add(1)
 		go func(listener *ListenerMux) {
 			defer wg.Done()
-			serr := http.Serve(listener, httpHandler)
+			srv := &http.Server{
+				Handler: throttlePipelinedRequests(httpHandler, m.maxPipelinedRequests),
+				// Requests with larger headers are rejected with
+				// '431 Request Header Fields Too Large'.
+				MaxHeaderBytes: m.maxHeaderBytes,
+				// Protect against clients trickling the request
+				// headers to exhaust the connections.
+				ConnState: func(conn net.Conn, state http.ConnState) {
+					listener.connState(conn, state, m.headerReadTimeout, m.idleConnTimeout)
+				},
+				ConnContext: listener.connContext,
+			}
+			serr := srv.Serve(listener)
 			// Do not print the error if the listener is closed.
 			if !listener.IsClosed() {
 				errorIf(serr, "Unable to serve incoming requests.")
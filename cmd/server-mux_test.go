@@ -18,6 +18,7 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -31,6 +32,7 @@ import (
 	"net/http"
 	"os"
 	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -467,3 +469,325 @@ func generateTestCert(host string) error {
 	keyOut.Close()
 	return nil
 }
+
+// Tests that requests exceeding the maximum size or number of headers
+// are rejected with 431 Request Header Fields Too Large.
+func TestServerListenAndServeHeaderLimits(t *testing.T) {
+	addr := net.JoinHostPort("127.0.0.1", getFreePort())
+	errc := make(chan error)
+
+	// Initialize done channel specifically for each tests.
+	globalServiceDoneCh = make(chan struct{}, 1)
+
+	m := NewServerMux(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	m.maxHeaderBytes = 4096
+	m.maxHeaderCount = 50
+	go func() { errc <- m.ListenAndServe("", "") }()
+	defer m.Close()
+
+	// Keep trying the server until it's accepting connections
+	client := http.Client{Timeout: 5 * time.Second}
+	for {
+		res, err := client.Get("http://" + addr)
+		if err == nil {
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, res.StatusCode)
+			}
+			break
+		}
+		select {
+		case err = <-errc:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	testCases := []struct {
+		headerCount    int
+		headerSize     int
+		expectedStatus int
+	}{
+		// Within the limits.
+		{40, 8, http.StatusOK},
+		// Too many tiny headers.
+		{1000, 1, http.StatusRequestHeaderFieldsTooLarge},
+		// Few but oversized headers, http.Server allows additional
+		// 4KiB over MaxHeaderBytes.
+		{4, 4096, http.StatusRequestHeaderFieldsTooLarge},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://"+addr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < testCase.headerCount; j++ {
+			req.Header.Set(fmt.Sprintf("X-Test-%d", j), strings.Repeat("a", testCase.headerSize))
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		res.Body.Close()
+		if res.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, res.StatusCode)
+		}
+	}
+}
+
+// Tests that connections trickling the request headers are closed once
+// the header read timeout expires while slow but steady clients work.
+func TestServerListenAndServeHeaderReadTimeout(t *testing.T) {
+	addr := net.JoinHostPort("127.0.0.1", getFreePort())
+	errc := make(chan error)
+
+	// Initialize done channel specifically for each tests.
+	globalServiceDoneCh = make(chan struct{}, 1)
+
+	m := NewServerMux(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write(body)
+	}))
+	headerReadTimeout := 500 * time.Millisecond
+	m.headerReadTimeout = headerReadTimeout
+	go func() { errc <- m.ListenAndServe("", "") }()
+	defer m.Close()
+
+	// Keep trying the server until it's accepting connections
+	var conn net.Conn
+	var err error
+	for {
+		conn, err = net.Dial("tcp", addr)
+		if err == nil {
+			break
+		}
+		select {
+		case err = <-errc:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	defer conn.Close()
+
+	// Slow but steady client, idles longer than the header read
+	// timeout before the request and sends the body slowly.
+	time.Sleep(2 * headerReadTimeout)
+	body := "slow-body"
+	if _, err = fmt.Fprintf(conn, "PUT / HTTP/1.1\r\nHost: %s\r\nContent-Length: %d\r\n\r\n", addr, len(body)); err != nil {
+		t.Fatal(err)
+	}
+	for i := range body {
+		time.Sleep(headerReadTimeout / 4)
+		if _, err = conn.Write([]byte{body[i]}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	res, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resBody, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK || string(resBody) != body {
+		t.Fatalf("Expected status %d with body %s, got %d with body %s", http.StatusOK, body, res.StatusCode, resBody)
+	}
+
+	// Slowloris client trickling the request headers.
+	conn, err = net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go func() {
+		header := fmt.Sprintf("GET / HTTP/1.1\r\nHost: %s\r\nX-Slow: %s\r\n\r\n", addr, strings.Repeat("a", 100))
+		for i := range header {
+			select {
+			case <-stopCh:
+				return
+			case <-time.After(headerReadTimeout / 10):
+			}
+			if _, werr := conn.Write([]byte{header[i]}); werr != nil {
+				return
+			}
+		}
+	}()
+
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(10 * headerReadTimeout))
+	n, err := conn.Read(make([]byte, 1))
+	if err == nil || n != 0 {
+		t.Fatal("Expected connection to be closed")
+	}
+	if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		t.Fatal("Expected connection to be closed by the server, timed out waiting")
+	}
+	if elapsed := time.Since(start); elapsed > 4*headerReadTimeout {
+		t.Fatalf("Expected connection to be closed within %s, took %s", 4*headerReadTimeout, elapsed)
+	}
+}
+
+// Tests keep-alive connections idle for longer than the idle timeout
+// are closed, independently of the header read timeout.
+func TestServerListenAndServeIdleConnTimeout(t *testing.T) {
+	addr := net.JoinHostPort("127.0.0.1", getFreePort())
+	errc := make(chan error)
+
+	// Initialize done channel specifically for each tests.
+	globalServiceDoneCh = make(chan struct{}, 1)
+
+	m := NewServerMux(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	idleConnTimeout := 500 * time.Millisecond
+	m.idleConnTimeout = idleConnTimeout
+	m.headerReadTimeout = time.Minute
+	go func() { errc <- m.ListenAndServe("", "") }()
+	defer m.Close()
+
+	// Keep trying the server until it's accepting connections
+	var conn net.Conn
+	var err error
+	for {
+		conn, err = net.Dial("tcp", addr)
+		if err == nil {
+			break
+		}
+		select {
+		case err = <-errc:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	defer conn.Close()
+
+	connReader := bufio.NewReader(conn)
+	sendRequest := func() {
+		if _, err = fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: %s\r\n\r\n", addr); err != nil {
+			t.Fatal(err)
+		}
+		res, rerr := http.ReadResponse(connReader, nil)
+		if rerr != nil {
+			t.Fatal(rerr)
+		}
+		ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status %d, got %d", http.StatusOK, res.StatusCode)
+		}
+	}
+
+	// Connections are reused within the idle timeout.
+	sendRequest()
+	time.Sleep(idleConnTimeout / 2)
+	sendRequest()
+
+	// Idle connection is closed once the idle timeout expires.
+	start := time.Now()
+	conn.SetReadDeadline(time.Now().Add(10 * idleConnTimeout))
+	n, err := connReader.Read(make([]byte, 1))
+	if err == nil || n != 0 {
+		t.Fatal("Expected connection to be closed")
+	}
+	if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+		t.Fatal("Expected connection to be closed by the server, timed out waiting")
+	}
+	elapsed := time.Since(start)
+	if elapsed < idleConnTimeout/2 || elapsed > 4*idleConnTimeout {
+		t.Fatalf("Expected connection to be closed after %s, took %s", idleConnTimeout, elapsed)
+	}
+}
+
+// Tests the clients pipelining more requests than the maximum on a
+// connection are throttled, unlike the keep-alive requests sent after
+// their previous response.
+func TestServerListenAndServePipelinedRequests(t *testing.T) {
+	addr := net.JoinHostPort("127.0.0.1", getFreePort())
+	errc := make(chan error)
+
+	// Initialize done channel specifically for each tests.
+	globalServiceDoneCh = make(chan struct{}, 1)
+
+	defer func(delay time.Duration) { pipelineThrottleDelay = delay }(pipelineThrottleDelay)
+	pipelineThrottleDelay = 200 * time.Millisecond
+
+	m := NewServerMux(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "hello")
+	}))
+	m.maxPipelinedRequests = 2
+	go func() { errc <- m.ListenAndServe("", "") }()
+	defer m.Close()
+
+	// Keep trying the server until it's accepting connections
+	var conn net.Conn
+	var err error
+	for {
+		conn, err = net.Dial("tcp", addr)
+		if err == nil {
+			break
+		}
+		select {
+		case err = <-errc:
+			t.Fatal(err)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	defer conn.Close()
+
+	connReader := bufio.NewReader(conn)
+	readResponses := func(count int) {
+		for i := 0; i < count; i++ {
+			res, rerr := http.ReadResponse(connReader, nil)
+			if rerr != nil {
+				t.Fatal(rerr)
+			}
+			ioutil.ReadAll(res.Body)
+			res.Body.Close()
+			if res.StatusCode != http.StatusOK {
+				t.Fatalf("Expected status %d, got %d", http.StatusOK, res.StatusCode)
+			}
+		}
+	}
+	sendRequests := func(count int) {
+		var requests bytes.Buffer
+		for i := 0; i < count; i++ {
+			fmt.Fprintf(&requests, "GET / HTTP/1.1\r\nHost: %s\r\n\r\n", addr)
+		}
+		if _, err = conn.Write(requests.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Keep-alive requests are not throttled.
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		sendRequests(1)
+		readResponses(1)
+	}
+	if elapsed := time.Since(start); elapsed >= pipelineThrottleDelay {
+		t.Fatalf("Expected keep-alive requests not to be throttled, took %s", elapsed)
+	}
+
+	// Requests pipelined past the maximum are throttled, the first
+	// one is read off the wire and two are pipelined behind it.
+	start = time.Now()
+	sendRequests(6)
+	readResponses(3)
+	if elapsed := time.Since(start); elapsed >= pipelineThrottleDelay {
+		t.Fatalf("Expected the requests within the limit not to be throttled, took %s", elapsed)
+	}
+	readResponses(3)
+	if elapsed := time.Since(start); elapsed < 3*pipelineThrottleDelay {
+		t.Fatalf("Expected the requests past the limit to be throttled, took %s", elapsed)
+	}
+}
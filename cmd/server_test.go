@@ -927,6 +927,44 @@ func (s *TestSuiteCommon) TestNotImplemented(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
 }
 
+// TestNotImplementedAPIs - validates that unsupported S3 APIs reply with
+// a well formed 'NotImplemented' error response.
+func (s *TestSuiteCommon) TestNotImplementedAPIs(c *C) {
+	// Generate a random bucket name.
+	bucketName := getRandomBucketName()
+	testCases := []struct {
+		method string
+		url    string
+	}{
+		// GetBucketTagging.
+		{"GET", s.endPoint + "/" + bucketName + "?tagging"},
+		// PutBucketVersioning.
+		{"PUT", s.endPoint + "/" + bucketName + "?versioning"},
+		// DeleteBucketCors.
+		{"DELETE", s.endPoint + "/" + bucketName + "?cors"},
+		// GetObjectTorrent.
+		{"GET", s.endPoint + "/" + bucketName + "/object?torrent"},
+		// PutObjectACL.
+		{"PUT", s.endPoint + "/" + bucketName + "/object?acl"},
+		// Other methods on the not implemented sub-resources.
+		{"DELETE", s.endPoint + "/" + bucketName + "?versioning"},
+		{"POST", s.endPoint + "/" + bucketName + "?acl"},
+		{"DELETE", s.endPoint + "/" + bucketName + "/object?acl"},
+		{"POST", s.endPoint + "/" + bucketName + "/object?policy"},
+	}
+	client := http.Client{Transport: s.transport}
+	for _, testCase := range testCases {
+		request, err := newTestSignedRequest(testCase.method, testCase.url,
+			0, nil, s.accessKey, s.secretKey, s.signer)
+		c.Assert(err, IsNil)
+
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.Header.Get(responseRequestIDKey), Not(Equals), "")
+		verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented", http.StatusNotImplemented)
+	}
+}
+
 // TestHeader - Validates the error response for an attempt to fetch non-existent object.
 func (s *TestSuiteCommon) TestHeader(c *C) {
 	// generate a random bucket name.
@@ -1643,14 +1681,15 @@ func (s *TestSuiteCommon) TestPutBucketErrors(c *C) {
 		http.StatusConflict)
 
 	// request for ACL.
-	// Since Minio server doesn't support ACL's the request is expected to fail with  "NotImplemented" error message.
+	// The bucket ACL is set from a canned ACL header or an ACL document,
+	// the request with neither is expected to fail with "MissingContentLength".
 	request, err = newTestSignedRequest("PUT", s.endPoint+"/"+bucketName+"?acl",
 		0, nil, s.accessKey, s.secretKey, s.signer)
 	c.Assert(err, IsNil)
 
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented", http.StatusNotImplemented)
+	verifyError(c, response, "MissingContentLength", "You must provide the Content-Length HTTP header.", http.StatusLengthRequired)
 }
 
 func (s *TestSuiteCommon) TestGetObjectLarge10MiB(c *C) {
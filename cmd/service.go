@@ -19,6 +19,7 @@ package cmd
 import (
 	"os"
 	"os/exec"
+	"os/signal"
 	"syscall"
 )
 
@@ -29,6 +30,7 @@ const (
 	serviceStatus  = iota // Gets status about the service.
 	serviceRestart        // Restarts the service.
 	serviceStop           // Stops the server.
+	serviceReload         // Reloads the server config.
 	// Add new service requests here.
 )
 
@@ -87,6 +89,15 @@ func (m *ServerMux) handleServiceSignals() error {
 		globalServiceSignalCh <- serviceStop
 	}(trapCh)
 
+	// Reload the server config on every SIGHUP in a go-routine.
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	go func(<-chan os.Signal) {
+		for range hupCh {
+			globalServiceSignalCh <- serviceReload
+		}
+	}(hupCh)
+
 	// Start listening on service signal. Monitor signals.
 	for {
 		signal := <-globalServiceSignalCh
@@ -101,6 +112,8 @@ func (m *ServerMux) handleServiceSignals() error {
 				errorIf(err, "Unable to restart the server.")
 			}
 			runExitFn(nil)
+		case serviceReload:
+			errorIf(reloadConfig(), "Unable to reload the server config.")
 		case serviceStop:
 			if err := m.Close(); err != nil {
 				errorIf(err, "Unable to close server gracefully")
@@ -40,6 +40,7 @@ const (
 // Whitelist resource list that will be used in query string for signature-V2 calculation.
 // The list should be alphabetically sorted
 var resourceList = []string{
+	"accelerate",
 	"acl",
 	"delete",
 	"lifecycle",
@@ -20,10 +20,12 @@ import (
 	"crypto/hmac"
 	"encoding/hex"
 	"net/http"
+	"os"
 	"regexp"
 	"strings"
 	"unicode/utf8"
 
+	"github.com/minio/minio-go/pkg/set"
 	"github.com/minio/sha256-simd"
 )
 
@@ -59,6 +61,37 @@ func isValidRegion(reqRegion string, confRegion string) bool {
 	return reqRegion == confRegion
 }
 
+// Sets the regions accepted in the signatures besides the server region.
+func setAlternateSigningRegions() {
+	regions := os.Getenv("MINIO_SIGNING_ALTERNATE_REGIONS")
+	if regions == "" {
+		return
+	}
+	alternateRegions := set.NewStringSet()
+	for _, region := range strings.Split(regions, ",") {
+		region = strings.TrimSpace(region)
+		if region == "" {
+			fatalIf(errInvalidArgument, "Invalid MINIO_SIGNING_ALTERNATE_REGIONS value %s.", regions)
+		}
+		alternateRegions.Add(region)
+	}
+	globalAlternateSigningRegions = alternateRegions
+}
+
+// getSigningRegion - returns the region the signature is verified with,
+// the configured region if the region of the request is valid, else the
+// region of the request if it is one of the alternate signing regions.
+// Returns false if the region of the request is not accepted.
+func getSigningRegion(reqRegion string, confRegion string) (string, bool) {
+	if isValidRegion(reqRegion, confRegion) {
+		return confRegion, true
+	}
+	if globalAlternateSigningRegions.Contains(reqRegion) {
+		return reqRegion, true
+	}
+	return "", false
+}
+
 // sumHMAC calculate hmac between two input byte array.
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
@@ -178,7 +178,8 @@ func doesPolicySignatureV4Match(formValues map[string]string) APIErrorCode {
 
 	// Verify if the region is valid.
 	sRegion := credHeader.scope.region
-	if !isValidRegion(sRegion, region) {
+	region, ok := getSigningRegion(sRegion, region)
+	if !ok {
 		return ErrInvalidRegion
 	}
 
@@ -234,7 +235,8 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, region s
 	if region == "" {
 		region = sRegion
 	}
-	if !isValidRegion(sRegion, region) {
+	region, ok := getSigningRegion(sRegion, region)
+	if !ok {
 		return ErrInvalidRegion
 	}
 
@@ -384,7 +386,8 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, region string) AP
 		region = sRegion
 	}
 	// Should validate region, only if region is set.
-	if !isValidRegion(sRegion, region) {
+	region, ok := getSigningRegion(sRegion, region)
+	if !ok {
 		return ErrInvalidRegion
 	}
 
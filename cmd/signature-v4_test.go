@@ -22,6 +22,9 @@ import (
 	"net/url"
 	"testing"
 	"time"
+
+	"github.com/minio/minio-go/pkg/s3signer"
+	"github.com/minio/minio-go/pkg/set"
 )
 
 func niceError(code APIErrorCode) string {
@@ -332,3 +335,63 @@ func TestDoesPresignedSignatureMatch(t *testing.T) {
 		}
 	}
 }
+
+// Tests the signatures for an alternate signing region are accepted,
+// the other regions are still rejected.
+func TestDoesSignatureMatchAlternateRegion(t *testing.T) {
+	root, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(root)
+
+	defer func(regions set.StringSet) {
+		globalAlternateSigningRegions = regions
+	}(globalAlternateSigningRegions)
+
+	cred := serverConfig.GetCredential()
+	newSignedRequest := func(region string) *http.Request {
+		req, rErr := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+		if rErr != nil {
+			t.Fatal(rErr)
+		}
+		req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+		return s3signer.SignV4(*req, cred.AccessKey, cred.SecretKey, region)
+	}
+
+	// Strict by default.
+	if code := doesSignatureMatch(unsignedPayload, newSignedRequest("eu-west-1"), serverConfig.GetRegion()); code != ErrInvalidRegion {
+		t.Fatalf("expected to get %s, instead got %s", niceError(ErrInvalidRegion), niceError(code))
+	}
+
+	globalAlternateSigningRegions = set.CreateStringSet("eu-west-1")
+	testCases := []struct {
+		region   string
+		expected APIErrorCode
+	}{
+		// (0) Server region is still accepted.
+		{globalMinioDefaultRegion, ErrNone},
+		// (1) Alternate region is accepted.
+		{"eu-west-1", ErrNone},
+		// (2) Unknown region is rejected.
+		{"ap-south-1", ErrInvalidRegion},
+	}
+	for i, testCase := range testCases {
+		code := doesSignatureMatch(unsignedPayload, newSignedRequest(testCase.region), serverConfig.GetRegion())
+		if code != testCase.expected {
+			t.Errorf("(%d) expected to get %s, instead got %s", i, niceError(testCase.expected), niceError(code))
+		}
+	}
+
+	// Policy signatures are verified with the alternate region as well.
+	now := time.Now().UTC()
+	form := map[string]string{
+		"X-Amz-Credential": fmt.Sprintf("%s/%s/%s/s3/aws4_request", cred.AccessKey, now.Format(yyyymmdd), "eu-west-1"),
+		"X-Amz-Date":       now.Format(iso8601Format),
+		"X-Amz-Signature":  getSignature(getSigningKey(cred.SecretKey, now, "eu-west-1"), "policy"),
+		"Policy":           "policy",
+	}
+	if code := doesPolicySignatureMatch(form); code != ErrNone {
+		t.Fatalf("expected to get %s, instead got %s", niceError(ErrNone), niceError(code))
+	}
+}
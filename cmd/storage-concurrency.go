@@ -0,0 +1,187 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/minio/minio/pkg/disk"
+)
+
+// Sets the maximum number of concurrent operations on each drive.
+func setDriveMaxConcurrency() {
+	if maxConcurrency := os.Getenv("MINIO_DRIVE_MAX_CONCURRENCY"); maxConcurrency != "" {
+		n, err := strconv.Atoi(maxConcurrency)
+		if err != nil || n < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_DRIVE_MAX_CONCURRENCY value %s.", maxConcurrency)
+		}
+		globalDriveMaxConcurrency = n
+	}
+}
+
+// Concurrency storage is an instance of StorageAPI which limits the
+// number of concurrent in-flight operations on the underlying disk,
+// the operations beyond the limit queue until a slot is released.
+type concurrencyStorage struct {
+	storage StorageAPI
+
+	// Slots of the in-flight operations, the operations block on
+	// sending when all the slots are taken.
+	slots chan struct{}
+}
+
+// newConcurrencyStorage - limits the concurrent operations on the disk,
+// the disk is returned as is if maxConcurrency is 0.
+func newConcurrencyStorage(storage StorageAPI, maxConcurrency int) StorageAPI {
+	if maxConcurrency <= 0 {
+		return storage
+	}
+	return &concurrencyStorage{
+		storage: storage,
+		slots:   make(chan struct{}, maxConcurrency),
+	}
+}
+
+// acquire - waits for a free slot.
+func (c *concurrencyStorage) acquire() {
+	c.slots <- struct{}{}
+}
+
+// release - frees the slot taken by acquire.
+func (c *concurrencyStorage) release() {
+	<-c.slots
+}
+
+// isBusy - returns true if all the slots are taken, a new operation
+// would queue.
+func (c *concurrencyStorage) isBusy() bool {
+	return len(c.slots) >= cap(c.slots)
+}
+
+// isDiskBusy - returns true if the disk is running its maximum number
+// of concurrent operations.
+func isDiskBusy(storage StorageAPI) bool {
+	c, ok := storage.(*concurrencyStorage)
+	return ok && c.isBusy()
+}
+
+// String representation of the underlying storage.
+func (c *concurrencyStorage) String() string {
+	return c.storage.String()
+}
+
+// Init - initializes the underlying storage.
+func (c *concurrencyStorage) Init() error {
+	return c.storage.Init()
+}
+
+// Close - closes the underlying storage.
+func (c *concurrencyStorage) Close() error {
+	return c.storage.Close()
+}
+
+// DiskInfo - a concurrency limited implementation of disk info.
+func (c *concurrencyStorage) DiskInfo() (disk.Info, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.DiskInfo()
+}
+
+// MakeVol - a concurrency limited implementation of creating a volume.
+func (c *concurrencyStorage) MakeVol(volume string) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.MakeVol(volume)
+}
+
+// ListVols - a concurrency limited implementation of listing all the volumes.
+func (c *concurrencyStorage) ListVols() ([]VolInfo, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.ListVols()
+}
+
+// StatVol - a concurrency limited implementation of stating a volume.
+func (c *concurrencyStorage) StatVol(volume string) (VolInfo, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.StatVol(volume)
+}
+
+// DeleteVol - a concurrency limited implementation of deleting a volume.
+func (c *concurrencyStorage) DeleteVol(volume string) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.DeleteVol(volume)
+}
+
+// ListDir - a concurrency limited implementation of listing a directory.
+func (c *concurrencyStorage) ListDir(volume, dirPath string) ([]string, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.ListDir(volume, dirPath)
+}
+
+// ReadFile - a concurrency limited implementation of reading a file.
+func (c *concurrencyStorage) ReadFile(volume, path string, offset int64, buf []byte) (int64, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.ReadFile(volume, path, offset, buf)
+}
+
+// PrepareFile - a concurrency limited implementation of preparing a file.
+func (c *concurrencyStorage) PrepareFile(volume, path string, length int64) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.PrepareFile(volume, path, length)
+}
+
+// AppendFile - a concurrency limited implementation of appending to a file.
+func (c *concurrencyStorage) AppendFile(volume, path string, buf []byte) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.AppendFile(volume, path, buf)
+}
+
+// RenameFile - a concurrency limited implementation of renaming a file.
+func (c *concurrencyStorage) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.RenameFile(srcVolume, srcPath, dstVolume, dstPath)
+}
+
+// StatFile - a concurrency limited implementation of stating a file.
+func (c *concurrencyStorage) StatFile(volume, path string) (FileInfo, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.StatFile(volume, path)
+}
+
+// DeleteFile - a concurrency limited implementation of deleting a file.
+func (c *concurrencyStorage) DeleteFile(volume, path string) error {
+	c.acquire()
+	defer c.release()
+	return c.storage.DeleteFile(volume, path)
+}
+
+// ReadAll - a concurrency limited implementation of reading a whole file.
+func (c *concurrencyStorage) ReadAll(volume, path string) ([]byte, error) {
+	c.acquire()
+	defer c.release()
+	return c.storage.ReadAll(volume, path)
+}
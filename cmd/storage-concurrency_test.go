@@ -0,0 +1,189 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/bpool"
+)
+
+// Simulates a disk counting and optionally blocking its ReadFile() calls.
+type countingReadDisk struct {
+	StorageAPI
+	reads   int32
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (c *countingReadDisk) ReadFile(volume string, path string, offset int64, buf []byte) (n int64, err error) {
+	atomic.AddInt32(&c.reads, 1)
+	if c.started != nil {
+		c.started <- struct{}{}
+		<-c.unblock
+	}
+	return c.StorageAPI.ReadFile(volume, path, offset, buf)
+}
+
+// Tests the operations beyond the limit queue until a slot is released.
+func TestConcurrencyStorageQueuing(t *testing.T) {
+	posixDisk, diskPath, err := newPosixTestSetup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	if newConcurrencyStorage(posixDisk, 0) != posixDisk {
+		t.Fatal("Expected the disk not to be limited")
+	}
+
+	if err = posixDisk.MakeVol("testvolume"); err != nil {
+		t.Fatal(err)
+	}
+	if err = posixDisk.AppendFile("testvolume", "testfile", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	blockingDisk := &countingReadDisk{
+		StorageAPI: posixDisk,
+		started:    make(chan struct{}),
+		unblock:    make(chan struct{}),
+	}
+	disk := newConcurrencyStorage(blockingDisk, 1)
+	if isDiskBusy(disk) {
+		t.Fatal("Expected the disk not to be busy")
+	}
+
+	done := make(chan error, 2)
+	readFile := func() {
+		_, rErr := disk.ReadFile("testvolume", "testfile", 0, make([]byte, 5))
+		done <- rErr
+	}
+
+	// First read takes the only slot.
+	go readFile()
+	<-blockingDisk.started
+	if !isDiskBusy(disk) {
+		t.Fatal("Expected the disk to be busy")
+	}
+
+	// Second read queues until the first one completes.
+	go readFile()
+	select {
+	case <-blockingDisk.started:
+		t.Fatal("Expected the second read to queue")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	blockingDisk.unblock <- struct{}{}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	<-blockingDisk.started
+	blockingDisk.unblock <- struct{}{}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if reads := atomic.LoadInt32(&blockingDisk.reads); reads != 2 {
+		t.Fatalf("Expected 2 reads, got %d", reads)
+	}
+	if isDiskBusy(disk) {
+		t.Fatal("Expected the disk not to be busy")
+	}
+}
+
+// Tests the erasure coded reads are routed around a saturated disk.
+func TestErasureReadFileBusyDisk(t *testing.T) {
+	dataBlocks := 7
+	parityBlocks := 7
+	blockSize := int64(blockSizeV1)
+	setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Remove()
+
+	data := make([]byte, 3*blockSize/2)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	length := int64(len(data))
+	_, checkSums, err := erasureCreateFile(setup.disks, "testbucket", "testobject", bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, bitRotAlgo, dataBlocks+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	countingDisks := make([]*countingReadDisk, len(setup.disks))
+	disks := make([]StorageAPI, len(setup.disks))
+	for i, disk := range setup.disks {
+		countingDisks[i] = &countingReadDisk{StorageAPI: disk}
+		disks[i] = newConcurrencyStorage(countingDisks[i], 1)
+	}
+
+	// Saturate the first data disk.
+	busyDisk := disks[0].(*concurrencyStorage)
+	busyDisk.acquire()
+
+	pool := bpool.NewBytePool(getChunkSize(blockSize, dataBlocks), len(disks))
+	buf := &bytes.Buffer{}
+	done := make(chan error, 1)
+	go func() {
+		_, rErr := erasureReadFile(buf, disks, "testbucket", "testobject", 0, length, length, blockSize, dataBlocks, parityBlocks, checkSums, bitRotAlgo, pool)
+		done <- rErr
+	}()
+	select {
+	case err = <-done:
+	case <-time.After(10 * time.Second):
+		busyDisk.release()
+		t.Fatal("Expected the read to be routed around the busy disk")
+	}
+	busyDisk.release()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Contents of the erasure coded file differs")
+	}
+	if reads := atomic.LoadInt32(&countingDisks[0].reads); reads != 0 {
+		t.Fatalf("Expected no reads on the busy disk, got %d", reads)
+	}
+	// A parity disk is read instead.
+	if reads := atomic.LoadInt32(&countingDisks[dataBlocks].reads); reads == 0 {
+		t.Fatal("Expected reads on the first parity disk")
+	}
+
+	// Without busy disks only the data disks are read.
+	for _, disk := range countingDisks {
+		atomic.StoreInt32(&disk.reads, 0)
+	}
+	buf.Reset()
+	if _, err = erasureReadFile(buf, disks, "testbucket", "testobject", 0, length, length, blockSize, dataBlocks, parityBlocks, checkSums, bitRotAlgo, pool); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Contents of the erasure coded file differs")
+	}
+	for i := dataBlocks; i < len(countingDisks); i++ {
+		if reads := atomic.LoadInt32(&countingDisks[i].reads); reads != 0 {
+			t.Fatalf("Expected no reads on the parity disk %d, got %d", i, reads)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"math"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// Weight of a new latency sample in the score of a node.
+	nodeLatencySampleWeight = 0.2
+
+	// Latency sample recorded for a read failing with a disk error.
+	nodeLatencyFailurePenalty = time.Second
+
+	// A node is slow when its score is more than nodeSlowFactor times
+	// and more than nodeSlowMinGap above the score of the fastest node.
+	nodeSlowFactor = 2
+	nodeSlowMinGap = 10 * time.Millisecond
+)
+
+// setNodeLatencyHalfLife - sets the half-life of the latency scores of
+// the nodes from MINIO_NODE_LATENCY_HALF_LIFE env.
+func setNodeLatencyHalfLife() {
+	if halfLife := os.Getenv("MINIO_NODE_LATENCY_HALF_LIFE"); halfLife != "" {
+		duration, err := time.ParseDuration(halfLife)
+		fatalIf(err, "Invalid MINIO_NODE_LATENCY_HALF_LIFE value %s.", halfLife)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_NODE_LATENCY_HALF_LIFE value %s.", halfLife)
+		}
+		globalNodeLatencyHalfLife = duration
+	}
+}
+
+// NodeLatency - latency score of the reads from the drives of a node.
+type NodeLatency struct {
+	Node    string        `json:"node"`
+	Latency time.Duration `json:"latency"`
+	Samples uint64        `json:"samples"`
+	Slow    bool          `json:"slow"`
+}
+
+// nodeLatencyScore - moving average of the read latency of a node,
+// decayed toward zero while no reads are made so that a slow node is
+// read again once it had time to recover.
+type nodeLatencyScore struct {
+	latency float64
+	samples uint64
+	updated time.Time
+}
+
+// decayed - returns the latency decayed by the time elapsed since the
+// last sample.
+func (s *nodeLatencyScore) decayed(now time.Time) float64 {
+	if globalNodeLatencyHalfLife <= 0 {
+		return s.latency
+	}
+	elapsed := now.Sub(s.updated)
+	if elapsed <= 0 {
+		return s.latency
+	}
+	return s.latency * math.Exp2(-float64(elapsed)/float64(globalNodeLatencyHalfLife))
+}
+
+// nodeLatencyScores - latency scores of the nodes, safe to be updated
+// concurrently.
+type nodeLatencyScores struct {
+	mutex  sync.Mutex
+	scores map[string]*nodeLatencyScore
+}
+
+func newNodeLatencyScores() *nodeLatencyScores {
+	return &nodeLatencyScores{
+		scores: make(map[string]*nodeLatencyScore),
+	}
+}
+
+// Observe - adds a latency sample of the node.
+func (n *nodeLatencyScores) Observe(node string, latency time.Duration, now time.Time) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	score, ok := n.scores[node]
+	if !ok {
+		n.scores[node] = &nodeLatencyScore{latency: float64(latency), samples: 1, updated: now}
+		return
+	}
+	decayed := score.decayed(now)
+	score.latency = decayed + nodeLatencySampleWeight*(float64(latency)-decayed)
+	score.samples++
+	score.updated = now
+}
+
+// Score - returns the current latency score of the node, zero if no
+// reads were made.
+func (n *nodeLatencyScores) Score(node string, now time.Time) time.Duration {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	score, ok := n.scores[node]
+	if !ok {
+		return 0
+	}
+	return time.Duration(score.decayed(now))
+}
+
+// Stats - returns the latency scores of all the nodes sorted by node,
+// the nodes slow compared to the fastest node are marked.
+func (n *nodeLatencyScores) Stats(now time.Time) []NodeLatency {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+
+	var nodes []string
+	for node := range n.scores {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+
+	stats := make([]NodeLatency, len(nodes))
+	fastest := time.Duration(-1)
+	for i, node := range nodes {
+		score := n.scores[node]
+		stats[i] = NodeLatency{
+			Node:    node,
+			Latency: time.Duration(score.decayed(now)),
+			Samples: score.samples,
+		}
+		if fastest < 0 || stats[i].Latency < fastest {
+			fastest = stats[i].Latency
+		}
+	}
+	for i := range stats {
+		stats[i].Slow = isNodeSlow(stats[i].Latency, fastest)
+	}
+	return stats
+}
+
+// isNodeSlow - returns true if the latency is well above the latency
+// of the fastest node.
+func isNodeSlow(latency, fastest time.Duration) bool {
+	return latency > nodeSlowFactor*fastest && latency-fastest > nodeSlowMinGap
+}
+
+// Latency storage is an instance of StorageAPI which records the
+// latency of the reads of the underlying disk in the score of its node,
+// reads of the erasure coded files are routed away from the slow nodes.
+type latencyStorage struct {
+	StorageAPI
+
+	node   string
+	scores *nodeLatencyScores
+}
+
+// newLatencyStorage - records the read latency of the disk under the
+// node, the disk is returned as is if the scores are disabled.
+func newLatencyStorage(storage StorageAPI, node string, scores *nodeLatencyScores) StorageAPI {
+	if globalNodeLatencyHalfLife <= 0 {
+		return storage
+	}
+	return &latencyStorage{
+		StorageAPI: storage,
+		node:       node,
+		scores:     scores,
+	}
+}
+
+// ReadFile - reads the file recording its latency, failures of the
+// disk are recorded as a high latency.
+func (l *latencyStorage) ReadFile(volume, path string, offset int64, buf []byte) (int64, error) {
+	start := time.Now().UTC()
+	n, err := l.StorageAPI.ReadFile(volume, path, offset, buf)
+	now := time.Now().UTC()
+	switch err {
+	case nil:
+		l.scores.Observe(l.node, now.Sub(start), now)
+	case errDiskNotFound, errFaultyDisk, errFaultyRemoteDisk:
+		l.scores.Observe(l.node, nodeLatencyFailurePenalty, now)
+	}
+	return n, err
+}
+
+// getDiskLatencyStorage - returns the latency storage of the disk,
+// looking through the concurrency limits.
+func getDiskLatencyStorage(storage StorageAPI) (*latencyStorage, bool) {
+	if c, ok := storage.(*concurrencyStorage); ok {
+		storage = c.storage
+	}
+	l, ok := storage.(*latencyStorage)
+	return l, ok
+}
+
+// getSlowDisks - returns which of the disks belong to a node slow
+// compared to the fastest node of the disks.
+func getSlowDisks(disks []StorageAPI) []bool {
+	now := time.Now().UTC()
+	latencies := make([]time.Duration, len(disks))
+	fastest := time.Duration(-1)
+	for i, disk := range disks {
+		l, ok := getDiskLatencyStorage(disk)
+		if !ok {
+			continue
+		}
+		latencies[i] = l.scores.Score(l.node, now)
+		if fastest < 0 || latencies[i] < fastest {
+			fastest = latencies[i]
+		}
+	}
+
+	slowDisks := make([]bool, len(disks))
+	for i := range disks {
+		slowDisks[i] = fastest >= 0 && isNodeSlow(latencies[i], fastest)
+	}
+	return slowDisks
+}
@@ -0,0 +1,149 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/rand"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/bpool"
+)
+
+// Tests the latency scores average the samples and decay over time.
+func TestNodeLatencyScores(t *testing.T) {
+	defer func(halfLife time.Duration) {
+		globalNodeLatencyHalfLife = halfLife
+	}(globalNodeLatencyHalfLife)
+	globalNodeLatencyHalfLife = time.Minute
+
+	scores := newNodeLatencyScores()
+	now := time.Now().UTC()
+	if score := scores.Score("node1", now); score != 0 {
+		t.Fatalf("Expected no score, got %s", score)
+	}
+
+	scores.Observe("node1", 100*time.Millisecond, now)
+	scores.Observe("node1", 200*time.Millisecond, now)
+	scores.Observe("node2", time.Millisecond, now)
+	if score := scores.Score("node1", now); score != 120*time.Millisecond {
+		t.Fatalf("Expected score %s, got %s", 120*time.Millisecond, score)
+	}
+
+	// Scores are halved after each half-life without samples.
+	if score := scores.Score("node1", now.Add(2*time.Minute)); score != 30*time.Millisecond {
+		t.Fatalf("Expected score %s, got %s", 30*time.Millisecond, score)
+	}
+
+	stats := scores.Stats(now)
+	expected := []NodeLatency{
+		{Node: "node1", Latency: 120 * time.Millisecond, Samples: 2, Slow: true},
+		{Node: "node2", Latency: time.Millisecond, Samples: 1, Slow: false},
+	}
+	if len(stats) != len(expected) {
+		t.Fatalf("Expected stats %v, got %v", expected, stats)
+	}
+	for i := range expected {
+		if stats[i] != expected[i] {
+			t.Fatalf("Expected stats %v, got %v", expected, stats)
+		}
+	}
+
+	// Slow node recovers once its score decayed.
+	stats = scores.Stats(now.Add(10 * time.Minute))
+	if stats[0].Slow {
+		t.Fatalf("Expected node1 not to be slow after decay, got %v", stats)
+	}
+}
+
+// Tests the erasure coded reads prefer the disks of the faster nodes.
+func TestErasureReadFileSlowNode(t *testing.T) {
+	dataBlocks := 7
+	parityBlocks := 7
+	blockSize := int64(blockSizeV1)
+	setup, err := newErasureTestSetup(dataBlocks, parityBlocks, blockSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer setup.Remove()
+
+	data := make([]byte, 3*blockSize/2)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	length := int64(len(data))
+	_, checkSums, err := erasureCreateFile(setup.disks, "testbucket", "testobject", bytes.NewReader(data), true, blockSize, dataBlocks, parityBlocks, bitRotAlgo, dataBlocks+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Data disks are on the first node, parity disks on the second.
+	scores := newNodeLatencyScores()
+	nodes := []string{"node1:9000", "node2:9000"}
+	countingDisks := make([]*countingReadDisk, len(setup.disks))
+	disks := make([]StorageAPI, len(setup.disks))
+	for i, disk := range setup.disks {
+		countingDisks[i] = &countingReadDisk{StorageAPI: disk}
+		disks[i] = newConcurrencyStorage(newLatencyStorage(countingDisks[i], nodes[i/dataBlocks], scores), 1)
+	}
+	readFile := func() {
+		for _, disk := range countingDisks {
+			atomic.StoreInt32(&disk.reads, 0)
+		}
+		pool := bpool.NewBytePool(getChunkSize(blockSize, dataBlocks), len(disks))
+		buf := &bytes.Buffer{}
+		if _, rErr := erasureReadFile(buf, disks, "testbucket", "testobject", 0, length, length, blockSize, dataBlocks, parityBlocks, checkSums, bitRotAlgo, pool); rErr != nil {
+			t.Fatal(rErr)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Fatal("Contents of the erasure coded file differs")
+		}
+	}
+
+	// Mark the first node slow, the parity disks are read instead.
+	now := time.Now().UTC()
+	scores.Observe(nodes[0], 500*time.Millisecond, now)
+	scores.Observe(nodes[1], time.Millisecond, now)
+	readFile()
+	for i, disk := range countingDisks {
+		reads := atomic.LoadInt32(&disk.reads)
+		if i < dataBlocks && reads != 0 {
+			t.Fatalf("Expected no reads on the disk %d of the slow node, got %d", i, reads)
+		}
+		if i >= dataBlocks && reads == 0 {
+			t.Fatalf("Expected reads on the disk %d of the fast node", i)
+		}
+	}
+	if stats := scores.Stats(time.Now().UTC()); !stats[0].Slow || stats[1].Slow {
+		t.Fatalf("Expected only %s to be slow, got %v", nodes[0], stats)
+	}
+
+	// Once the score of the slow node decayed the data disks are read again.
+	scores = newNodeLatencyScores()
+	for i := range disks {
+		disks[i] = newConcurrencyStorage(newLatencyStorage(countingDisks[i], nodes[i/dataBlocks], scores), 1)
+	}
+	scores.Observe(nodes[0], 500*time.Millisecond, now.Add(-10*globalNodeLatencyHalfLife))
+	readFile()
+	for i := dataBlocks; i < len(countingDisks); i++ {
+		if reads := atomic.LoadInt32(&countingDisks[i].reads); reads != 0 {
+			t.Fatalf("Expected no reads on the parity disk %d, got %d", i, reads)
+		}
+	}
+}
@@ -21,11 +21,14 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
 	"encoding/hex"
 	"errors"
 	"hash"
 	"io"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	humanize "github.com/dustin/go-humanize"
@@ -37,16 +40,46 @@ const (
 	emptySHA256            = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
 	streamingContentSHA256 = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD"
 	signV4ChunkedAlgorithm = "AWS4-HMAC-SHA256-PAYLOAD"
+
+	// Streaming payloads followed by a trailing checksum, with signed
+	// or unsigned chunks.
+	streamingContentSHA256Trailer         = "STREAMING-AWS4-HMAC-SHA256-PAYLOAD-TRAILER"
+	streamingUnsignedContentSHA256Trailer = "STREAMING-UNSIGNED-PAYLOAD-TRAILER"
+	signV4TrailerAlgorithm                = "AWS4-HMAC-SHA256-TRAILER"
+
+	// Trailer header holding the signature of the trailer.
+	trailerSignatureKey = "x-amz-trailer-signature"
 )
 
-// getChunkSignature - get chunk signature.
-func getChunkSignature(seedSignature string, date time.Time, hashedChunk string) string {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
+// isStreamingContentSHA256 - returns if the x-amz-content-sha256 value
+// is one of the streaming payloads.
+func isStreamingContentSHA256(contentSHA256 string) bool {
+	switch contentSHA256 {
+	case streamingContentSHA256, streamingContentSHA256Trailer, streamingUnsignedContentSHA256Trailer:
+		return true
+	}
+	return false
+}
 
-	// Server region.
-	region := serverConfig.GetRegion()
+// setStreamingTrailer - enables the streaming uploads followed by a
+// trailing checksum unless MINIO_STREAMING_TRAILER env is set to "off".
+func setStreamingTrailer() {
+	if trailer := os.Getenv("MINIO_STREAMING_TRAILER"); trailer != "" {
+		switch strings.ToLower(trailer) {
+		case "on":
+			globalIsStreamingTrailer = true
+		case "off":
+			globalIsStreamingTrailer = false
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_STREAMING_TRAILER value %s.", trailer)
+		}
+	}
+}
 
+// getChunkSignature - get chunk signature, the region is the one the
+// seed signature was verified with. The signing key is the same for all
+// the chunks of a stream, it is derived once by the caller.
+func getChunkSignature(signingKey []byte, seedSignature string, region string, date time.Time, hashedChunk string) string {
 	// Calculate string to sign.
 	stringToSign := signV4ChunkedAlgorithm + "\n" +
 		date.Format(iso8601Format) + "\n" +
@@ -55,25 +88,41 @@ func getChunkSignature(seedSignature string, date time.Time, hashedChunk string)
 		emptySHA256 + "\n" +
 		hashedChunk
 
-	// Get hmac signing key.
-	signingKey := getSigningKey(cred.SecretKey, date, region)
-
 	// Calculate signature.
 	newSignature := getSignature(signingKey, stringToSign)
 
 	return newSignature
 }
 
+// getTrailerSignature - get the signature of the trailer, chained to
+// the signature of the final chunk.
+func getTrailerSignature(seedSignature string, region string, date time.Time, hashedTrailer string) string {
+	// Access credentials.
+	cred := serverConfig.GetCredential()
+
+	// Calculate string to sign.
+	stringToSign := signV4TrailerAlgorithm + "\n" +
+		date.Format(iso8601Format) + "\n" +
+		getScope(date, region) + "\n" +
+		seedSignature + "\n" +
+		hashedTrailer
+
+	// Get hmac signing key.
+	signingKey := getSigningKey(cred.SecretKey, date, region)
+
+	return getSignature(signingKey, stringToSign)
+}
+
 // calculateSeedSignature - Calculate seed signature in accordance with
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-streaming.html
-// returns signature, error otherwise if the signature mismatches or any other
-// error while parsing and validating.
-func calculateSeedSignature(r *http.Request) (signature string, date time.Time, errCode APIErrorCode) {
+// returns signature along with the region it was verified with, error otherwise
+// if the signature mismatches or any other error while parsing and validating.
+func calculateSeedSignature(r *http.Request) (signature string, region string, date time.Time, errCode APIErrorCode) {
 	// Access credentials.
 	cred := serverConfig.GetCredential()
 
 	// Server region.
-	region := serverConfig.GetRegion()
+	region = serverConfig.GetRegion()
 
 	// Copy request.
 	req := *r
@@ -84,40 +133,42 @@ func calculateSeedSignature(r *http.Request) (signature string, date time.Time,
 	// Parse signature version '4' header.
 	signV4Values, errCode := parseSignV4(v4Auth)
 	if errCode != ErrNone {
-		return "", time.Time{}, errCode
+		return "", "", time.Time{}, errCode
 	}
 
 	// Payload streaming.
-	payload := streamingContentSHA256
+	payload := req.Header.Get("X-Amz-Content-Sha256")
 
-	// Payload for STREAMING signature should be 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD'
-	if payload != req.Header.Get("X-Amz-Content-Sha256") {
-		return "", time.Time{}, ErrContentSHA256Mismatch
+	// Payload for STREAMING signature should be 'STREAMING-AWS4-HMAC-SHA256-PAYLOAD',
+	// or one of its variants followed by a trailer.
+	if !isStreamingContentSHA256(payload) {
+		return "", "", time.Time{}, ErrContentSHA256Mismatch
 	}
 
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders, errCode := extractSignedHeaders(signV4Values.SignedHeaders, req.Header)
 	if errCode != ErrNone {
-		return "", time.Time{}, errCode
+		return "", "", time.Time{}, errCode
 	}
 	// Verify if the access key id matches.
 	if signV4Values.Credential.accessKey != cred.AccessKey {
-		return "", time.Time{}, ErrInvalidAccessKeyID
+		return "", "", time.Time{}, ErrInvalidAccessKeyID
 	}
 
 	// Verify if region is valid.
 	sRegion := signV4Values.Credential.scope.region
 	// Should validate region, only if region is set. Some operations
 	// do not need region validated for example GetBucketLocation.
-	if !isValidRegion(sRegion, region) {
-		return "", time.Time{}, ErrInvalidRegion
+	region, ok := getSigningRegion(sRegion, region)
+	if !ok {
+		return "", "", time.Time{}, ErrInvalidRegion
 	}
 
 	// Extract date, if not present throw error.
 	var dateStr string
 	if dateStr = req.Header.Get(http.CanonicalHeaderKey("x-amz-date")); dateStr == "" {
 		if dateStr = r.Header.Get("Date"); dateStr == "" {
-			return "", time.Time{}, ErrMissingDateHeader
+			return "", "", time.Time{}, ErrMissingDateHeader
 		}
 	}
 	// Parse date header.
@@ -125,7 +176,7 @@ func calculateSeedSignature(r *http.Request) (signature string, date time.Time,
 	date, err = time.Parse(iso8601Format, dateStr)
 	if err != nil {
 		errorIf(err, "Unable to parse date", dateStr)
-		return "", time.Time{}, ErrMalformedDate
+		return "", "", time.Time{}, ErrMalformedDate
 	}
 
 	// Query string.
@@ -145,14 +196,20 @@ func calculateSeedSignature(r *http.Request) (signature string, date time.Time,
 
 	// Verify if signature match.
 	if newSignature != signV4Values.Signature {
-		return "", time.Time{}, ErrSignatureDoesNotMatch
+		return "", "", time.Time{}, ErrSignatureDoesNotMatch
 	}
 
 	// Return caculated signature.
-	return newSignature, date, ErrNone
+	return newSignature, region, date, ErrNone
 }
 
-const maxLineLength = 4 * humanize.KiByte // assumed <= bufio.defaultBufSize 4KiB
+const maxLineLength = 4 * humanize.KiByte // assumed <= s3ChunkedReaderBufferSize
+
+// Size of the buffer the chunked payloads are read through. Streams of
+// many small chunks are read from the network in batches of this size
+// rather than a few chunks at a time, their headers and signatures
+// being parsed from memory.
+const s3ChunkedReaderBufferSize = 64 * humanize.KiByte
 
 // lineTooLong is generated as chunk header is bigger than 4KiB.
 var errLineTooLong = errors.New("header line too long")
@@ -167,24 +224,45 @@ var errMalformedEncoding = errors.New("malformed chunked encoding")
 // NewChunkedReader is not needed by normal applications. The http package
 // automatically decodes chunking when reading response bodies.
 func newSignV4ChunkedReader(req *http.Request) (io.Reader, APIErrorCode) {
-	seedSignature, seedDate, errCode := calculateSeedSignature(req)
+	seedSignature, seedRegion, seedDate, errCode := calculateSeedSignature(req)
 	if errCode != ErrNone {
 		return nil, errCode
 	}
-	return &s3ChunkedReader{
-		reader:            bufio.NewReader(req.Body),
+	cr := &s3ChunkedReader{
+		reader:            bufio.NewReaderSize(req.Body, s3ChunkedReaderBufferSize),
+		signingKey:        getSigningKey(serverConfig.GetCredential().SecretKey, seedDate, seedRegion),
 		seedSignature:     seedSignature,
+		seedRegion:        seedRegion,
 		seedDate:          seedDate,
 		chunkSHA256Writer: sha256.New(),
 		state:             readChunkHeader,
-	}, ErrNone
+	}
+
+	// The trailing checksum declared by x-amz-trailer is verified
+	// against the decoded data once the final chunk is read.
+	contentSHA256 := req.Header.Get("X-Amz-Content-Sha256")
+	if contentSHA256 == streamingContentSHA256Trailer || contentSHA256 == streamingUnsignedContentSHA256Trailer {
+		if !globalIsStreamingTrailer {
+			return nil, ErrNotImplemented
+		}
+		algorithm, s3Error := getTrailerChecksumAlgorithm(req.Header)
+		if s3Error != ErrNone {
+			return nil, s3Error
+		}
+		cr.trailer = strings.ToLower(checksumMetadataKey(algorithm))
+		cr.checksumHash = checksumHashers[algorithm]()
+		cr.unsignedChunks = contentSHA256 == streamingUnsignedContentSHA256Trailer
+	}
+	return cr, ErrNone
 }
 
 // Represents the overall state that is required for decoding a
 // AWS Signature V4 chunked reader.
 type s3ChunkedReader struct {
 	reader            *bufio.Reader
+	signingKey        []byte // Signing key of the chunk signatures.
 	seedSignature     string
+	seedRegion        string
 	seedDate          time.Time
 	state             chunkState
 	lastChunk         bool
@@ -192,6 +270,13 @@ type s3ChunkedReader struct {
 	chunkSHA256Writer hash.Hash // Calculates sha256 of chunk data.
 	n                 uint64    // Unread bytes in chunk
 	err               error
+
+	// Trailer holding the checksum of the decoded data, empty if none,
+	// and the hash calculating it.
+	trailer      string
+	checksumHash hash.Hash
+	// Chunks of the unsigned payloads carry no signatures.
+	unsignedChunks bool
 }
 
 // Read chunk reads the chunk token signature portion.
@@ -221,6 +306,7 @@ const (
 	readChunkTrailer
 	readChunk
 	verifyChunk
+	readTrailer
 )
 
 func (cs chunkState) String() string {
@@ -234,6 +320,8 @@ func (cs chunkState) String() string {
 		stateString = "readChunk"
 	case verifyChunk:
 		stateString = "verifyChunk"
+	case readTrailer:
+		stateString = "readTrailer"
 	}
 	return stateString
 }
@@ -241,14 +329,27 @@ func (cs chunkState) String() string {
 // Read - implements `io.Reader`, which transparently decodes
 // the incoming AWS Signature V4 streaming signature.
 func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
+	// Nothing follows the final chunk and its trailer.
+	if cr.lastChunk && cr.state == readChunkHeader {
+		return 0, io.EOF
+	}
 	for {
 		switch cr.state {
 		case readChunkHeader:
+			// Return the data of the chunks verified so far rather
+			// than wait for the next chunk to arrive.
+			if n > 0 && cr.reader.Buffered() == 0 {
+				return n, nil
+			}
 			cr.readS3ChunkHeader()
 			// If we're at the end of a chunk.
 			if cr.n == 0 && cr.err == io.EOF {
 				cr.state = readChunkTrailer
 				cr.lastChunk = true
+				// The trailer follows the final chunk directly.
+				if cr.trailer != "" {
+					cr.state = verifyChunk
+				}
 				continue
 			}
 			if cr.err != nil {
@@ -284,6 +385,9 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 
 			// Calculate sha256.
 			cr.chunkSHA256Writer.Write(rbuf[:n0])
+			if cr.checksumHash != nil {
+				cr.checksumHash.Write(rbuf[:n0])
+			}
 			// Update the bytes read into request buffer so far.
 			n += n0
 			buf = buf[n0:]
@@ -296,30 +400,113 @@ func (cr *s3ChunkedReader) Read(buf []byte) (n int, err error) {
 				continue
 			}
 		case verifyChunk:
-			// Calculate the hashed chunk.
-			hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
-			// Calculate the chunk signature.
-			newSignature := getChunkSignature(cr.seedSignature, cr.seedDate, hashedChunk)
-			if cr.chunkSignature != newSignature {
-				// Chunk signature doesn't match we return signature does not match.
-				cr.err = errSignatureMismatch
-				return 0, cr.err
+			if !cr.unsignedChunks {
+				// Calculate the hashed chunk.
+				hashedChunk := hex.EncodeToString(cr.chunkSHA256Writer.Sum(nil))
+				// Calculate the chunk signature.
+				newSignature := getChunkSignature(cr.signingKey, cr.seedSignature, cr.seedRegion, cr.seedDate, hashedChunk)
+				if cr.chunkSignature != newSignature {
+					// Chunk signature doesn't match we return signature does not match.
+					cr.err = errSignatureMismatch
+					return 0, cr.err
+				}
+				// Newly calculated signature becomes the seed for the next chunk
+				// this follows the chaining.
+				cr.seedSignature = newSignature
 			}
-			// Newly calculated signature becomes the seed for the next chunk
-			// this follows the chaining.
-			cr.seedSignature = newSignature
 			cr.chunkSHA256Writer.Reset()
 			cr.state = readChunkHeader
+			if cr.lastChunk && cr.trailer != "" {
+				cr.state = readTrailer
+				continue
+			}
 			if cr.lastChunk {
 				return n, nil
 			}
+		case readTrailer:
+			// The data is only returned once its checksum is verified.
+			if cr.err = cr.readS3ChunkTrailer(); cr.err != nil {
+				return 0, cr.err
+			}
+			cr.state = readChunkHeader
+			return n, nil
+		}
+	}
+}
+
+// readS3ChunkTrailer - reads the trailer following the final chunk,
+// made of "name:value" lines ended by an empty line, verifies its
+// signature for the signed payloads and the checksum of the data.
+func (cr *s3ChunkedReader) readS3ChunkTrailer() error {
+	var trailer bytes.Buffer
+	var checksum, signature string
+	for {
+		line, err := cr.reader.ReadSlice('\n')
+		if err == io.EOF && len(line) == 0 {
+			// Tolerate a body ending without the empty line.
+			break
+		}
+		if err != nil {
+			if err == bufio.ErrBufferFull {
+				return errLineTooLong
+			}
+			return errMalformedEncoding
+		}
+		line = trimTrailingWhitespace(line)
+		if len(line) == 0 {
+			break
+		}
+		colon := bytes.IndexByte(line, ':')
+		if colon == -1 {
+			return errMalformedEncoding
+		}
+		name := strings.ToLower(strings.TrimSpace(string(line[:colon])))
+		value := strings.TrimSpace(string(line[colon+1:]))
+		switch name {
+		case cr.trailer:
+			checksum = value
+			trailer.WriteString(name + ":" + value + "\n")
+		case trailerSignatureKey:
+			signature = value
+		default:
+			// Only the declared trailer is accepted.
+			return errMalformedEncoding
 		}
 	}
+	if checksum == "" {
+		return errMalformedEncoding
+	}
+
+	if !cr.unsignedChunks {
+		hashedTrailer := getSHA256Hash(trailer.Bytes())
+		if signature != getTrailerSignature(cr.seedSignature, cr.seedRegion, cr.seedDate, hashedTrailer) {
+			return errSignatureMismatch
+		}
+	}
+	if checksum != base64.StdEncoding.EncodeToString(cr.checksumHash.Sum(nil)) {
+		return errChecksumMismatch
+	}
+	return nil
 }
 
 // readCRLF - check if reader only has '\r\n' CRLF character.
 // returns malformed encoding if it doesn't.
 func readCRLF(reader io.Reader) error {
+	// Buffered readers are checked in place, without allocating.
+	if b, ok := reader.(*bufio.Reader); ok {
+		crlf, err := b.Peek(2)
+		if err != nil {
+			if err == io.EOF && len(crlf) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		}
+		if crlf[0] != '\r' || crlf[1] != '\n' {
+			return errMalformedEncoding
+		}
+		_, err = b.Discard(2)
+		return err
+	}
 	buf := make([]byte, 2)
 	_, err := io.ReadFull(reader, buf[:2])
 	if err != nil {
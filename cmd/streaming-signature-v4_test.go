@@ -19,8 +19,10 @@ package cmd
 import (
 	"bufio"
 	"bytes"
+	"encoding/base64"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strings"
 	"testing"
 )
@@ -194,3 +196,145 @@ func TestParseHexUint(t *testing.T) {
 		}
 	}
 }
+
+// readCallCounter - counts the reads of the underlying reader.
+type readCallCounter struct {
+	io.Reader
+	calls int
+}
+
+func (r *readCallCounter) Read(p []byte) (int, error) {
+	r.calls++
+	return r.Reader.Read(p)
+}
+
+// newTinyChunksReader - returns the data streamed in chunks of 16 bytes
+// and the chunked reader decoding it, reading the request body through
+// the counter.
+func newTinyChunksReader(data []byte, counter *readCallCounter) (io.Reader, error) {
+	cred := serverConfig.GetCredential()
+	req, err := newTestStreamingSignedRequest("PUT", "http://127.0.0.1:9000/bucket/object",
+		int64(len(data)), 16, bytes.NewReader(data), cred.AccessKey, cred.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	counter.Reader = req.Body
+	req.Body = ioutil.NopCloser(counter)
+	reader, s3Error := newSignV4ChunkedReader(req)
+	if s3Error != ErrNone {
+		return nil, fmt.Errorf("Unable to read the stream: %s", getAPIError(s3Error).Description)
+	}
+	return reader, nil
+}
+
+// Tests streams of many tiny chunks are decoded and verified, their
+// body being read in batches rather than a few chunks at a time.
+func TestS3ChunkedReaderTinyChunks(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	data := bytes.Repeat([]byte("0123456789abcdefghijklmnopqrstuvwxyz"), 10000)
+	counter := &readCallCounter{}
+	reader, err := newTinyChunksReader(data, counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(decoded, data) {
+		t.Fatalf("Expected the decoded data to match the data streamed")
+	}
+
+	// Each read fills the buffer, the last ones returning the end of the
+	// stream.
+	streamLength := calculateStreamContentLength(int64(len(data)), 16)
+	if maxCalls := int(streamLength/s3ChunkedReaderBufferSize) + 3; counter.calls > maxCalls {
+		t.Fatalf("Expected at most %d reads of the %d bytes stream, got %d", maxCalls, streamLength, counter.calls)
+	}
+}
+
+// Tests the unsigned chunks followed by a trailing checksum, the data
+// is decoded when the checksum matches and the stream fails otherwise.
+func TestS3ChunkedReaderUnsignedTrailer(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+	defer func(trailer bool) { globalIsStreamingTrailer = trailer }(globalIsStreamingTrailer)
+	globalIsStreamingTrailer = true
+
+	data := bytes.Repeat([]byte("unsigned trailer "), 1000)
+	checksumOf := func(algorithm string, data []byte) string {
+		hash := checksumHashers[algorithm]()
+		hash.Write(data)
+		return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	}
+	badData := append([]byte("x"), data[1:]...)
+
+	testCases := []struct {
+		trailer     string
+		checksum    string
+		chunkSize   int64
+		expectedErr error
+	}{
+		// Correct checksums.
+		{"x-amz-checksum-sha256", checksumOf(checksumSHA256, data), 1024, nil},
+		{"x-amz-checksum-crc32", checksumOf(checksumCRC32, data), 64 * 1024, nil},
+		// Checksum of other data.
+		{"x-amz-checksum-sha256", checksumOf(checksumSHA256, badData), 1024, errChecksumMismatch},
+		// Checksum of another algorithm.
+		{"x-amz-checksum-crc32c", checksumOf(checksumCRC32, data), 1024, errChecksumMismatch},
+	}
+	cred := serverConfig.GetCredential()
+	for i, testCase := range testCases {
+		req, err := newTestStreamingTrailerRequest("PUT", "http://127.0.0.1:9000/bucket/object", data, testCase.chunkSize,
+			testCase.trailer, testCase.checksum, false, cred.AccessKey, cred.SecretKey)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if contentSHA256 := req.Header.Get("X-Amz-Content-Sha256"); contentSHA256 != streamingUnsignedContentSHA256Trailer {
+			t.Fatalf("Test %d: Expected an unsigned payload, got %s", i+1, contentSHA256)
+		}
+		reader, s3Error := newSignV4ChunkedReader(req)
+		if s3Error != ErrNone {
+			t.Fatalf("Test %d: Unable to read the stream: %s", i+1, getAPIError(s3Error).Description)
+		}
+		decoded, err := ioutil.ReadAll(reader)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && !bytes.Equal(decoded, data) {
+			t.Fatalf("Test %d: Expected the decoded data to match the data streamed", i+1)
+		}
+	}
+}
+
+// Benchmarks decoding streams of many tiny chunks.
+func BenchmarkS3ChunkedReaderTinyChunks(b *testing.B) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	data := bytes.Repeat([]byte("a"), 64*1024)
+	b.SetBytes(int64(len(data)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		reader, err := newTinyChunksReader(data, &readCallCounter{})
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.StartTimer()
+		if _, err = io.Copy(ioutil.Discard, reader); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -67,6 +67,10 @@ func init() {
 
 	// Enable caching.
 	setMaxMemory()
+
+	// Disable quorum checks, tests take disks offline and bring them
+	// back at will which the cached quorum state would not notice.
+	globalQuorumCheckInterval = 0
 }
 
 func prepareFS() (ObjectLayer, string, error) {
@@ -850,6 +854,80 @@ func newTestStreamingSignedRequest(method, urlStr string, contentLength, chunkSi
 	return req, err
 }
 
+// Returns new HTTP request object streaming the body in chunks followed
+// by a trailer holding the checksum of the body, with signed chunks
+// and trailer or unsigned ones.
+func newTestStreamingTrailerRequest(method, urlStr string, data []byte, chunkSize int64, trailer, checksum string, signed bool, accessKey, secretKey string) (*http.Request, error) {
+	req, err := newTestStreamingRequest(method, urlStr, int64(len(data)), chunkSize, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if signed {
+		req.Header.Set("x-amz-content-sha256", streamingContentSHA256Trailer)
+	} else {
+		req.Header.Set("x-amz-content-sha256", streamingUnsignedContentSHA256Trailer)
+	}
+	req.Header.Set("x-amz-trailer", trailer)
+
+	currTime := time.Now().UTC()
+	regionStr := serverConfig.GetRegion()
+	scope := strings.Join([]string{currTime.Format(yyyymmdd), regionStr, "s3", "aws4_request"}, "/")
+	date := sumHMAC([]byte("AWS4"+secretKey), []byte(currTime.Format(yyyymmdd)))
+	region := sumHMAC(date, []byte(regionStr))
+	service := sumHMAC(region, []byte("s3"))
+	signingKey := sumHMAC(service, []byte("aws4_request"))
+	sign := func(algorithm, signature string, hashes ...string) string {
+		stringToSign := algorithm + "\n" + currTime.Format(iso8601Format) + "\n" + scope + "\n" + signature
+		for _, hash := range hashes {
+			stringToSign += "\n" + hash
+		}
+		return hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
+	}
+
+	// Assembles the chunks and the trailer chained to the seed signature.
+	assemble := func(signature string) []byte {
+		var stream []byte
+		remaining := data
+		for {
+			chunk := remaining
+			if int64(len(chunk)) > chunkSize {
+				chunk = chunk[:chunkSize]
+			}
+			remaining = remaining[len(chunk):]
+			if signed {
+				signature = sign("AWS4-HMAC-SHA256-PAYLOAD", signature, emptySHA256, getSHA256Hash(chunk))
+				stream = append(stream, []byte(fmt.Sprintf("%x", len(chunk))+";chunk-signature="+signature+"\r\n")...)
+			} else {
+				stream = append(stream, []byte(fmt.Sprintf("%x", len(chunk))+"\r\n")...)
+			}
+			if len(chunk) == 0 {
+				break
+			}
+			stream = append(stream, chunk...)
+			stream = append(stream, []byte("\r\n")...)
+		}
+		trailerLine := trailer + ":" + checksum
+		stream = append(stream, []byte(trailerLine+"\r\n")...)
+		if signed {
+			trailerSignature := sign("AWS4-HMAC-SHA256-TRAILER", signature, getSHA256Hash([]byte(trailerLine+"\n")))
+			stream = append(stream, []byte("x-amz-trailer-signature:"+trailerSignature+"\r\n")...)
+		}
+		return append(stream, []byte("\r\n")...)
+	}
+
+	// The signed content length does not depend on the signatures.
+	contentLength := int64(len(assemble(emptySHA256)))
+	req.ContentLength = contentLength
+	req.Header.Set("content-length", strconv.FormatInt(contentLength, 10))
+
+	seedSignature, err := signStreamingRequest(req, accessKey, secretKey, currTime)
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(assemble(seedSignature)))
+	return req, nil
+}
+
 // Replaces any occurring '/' in string, into its encoded
 // representation.
 func percentEncodeSlash(s string) string {
@@ -1431,6 +1509,13 @@ func getGetObjectURL(endPoint, bucketName, objectName string) string {
 	return makeTestTargetURL(endPoint, bucketName, objectName, url.Values{})
 }
 
+// return URL for getting the torrent of the object.
+func getObjectTorrentURL(endPoint, bucketName, objectName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("torrent", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
 // return URL for deleting the object from the bucket.
 func getDeleteObjectURL(endPoint, bucketName, objectName string) string {
 	return makeTestTargetURL(endPoint, bucketName, objectName, url.Values{})
@@ -1482,6 +1567,13 @@ func getGetPolicyURL(endPoint, bucketName string) string {
 	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
 }
 
+// return URL for fetching bucket policy status.
+func getGetPolicyStatusURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("policyStatus", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
 // return URL for deleting bucket policy.
 func getDeletePolicyURL(endPoint, bucketName string) string {
 	queryValue := url.Values{}
@@ -1516,6 +1608,14 @@ func getDeleteMultipleObjectsURL(endPoint, bucketName string) string {
 	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
 }
 
+// return URL for storing an upload under the fan-out keys.
+func getFanOutPutObjectURL(endPoint, bucketName string, keys []string) string {
+	queryValue := url.Values{}
+	queryValue.Set(fanOutQueryParam, "")
+	queryValue[fanOutKeyQueryParam] = keys
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
 // return URL For fetching location of the bucket.
 func getBucketLocationURL(endPoint, bucketName string) string {
 	queryValue := url.Values{}
@@ -1532,6 +1632,23 @@ func getListObjectsV1URL(endPoint, bucketName string, maxKeys string) string {
 	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
 }
 
+// return URL for searching the objects of the bucket by their metadata.
+func getMetadataSearchURL(endPoint, bucketName, prefix, marker, maxKeys string, metas []string) string {
+	queryValue := url.Values{}
+	queryValue.Set(metadataSearchQueryParam, "")
+	if prefix != "" {
+		queryValue.Set("prefix", prefix)
+	}
+	if marker != "" {
+		queryValue.Set("marker", marker)
+	}
+	if maxKeys != "" {
+		queryValue.Set("max-keys", maxKeys)
+	}
+	queryValue[metadataSearchMetaQueryParam] = metas
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
 // return URL for listing objects in the bucket with V2 API.
 func getListObjectsV2URL(endPoint, bucketName string, maxKeys string, fetchOwner string) string {
 	queryValue := url.Values{}
@@ -1617,6 +1734,144 @@ func getGetBucketNotificationURL(endPoint, bucketName string) string {
 	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
 }
 
+// return URL for bucket logging.
+func getBucketLoggingURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("logging", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket lifecycle operations.
+func getBucketLifecycleURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("lifecycle", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket inventory operations, all the configs are
+// listed without an ID.
+func getBucketInventoryURL(endPoint, bucketName, id string) string {
+	queryValue := url.Values{}
+	queryValue.Set("inventory", "")
+	if id != "" {
+		queryValue.Set("id", id)
+	}
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket replication operations.
+func getBucketReplicationURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("replication", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket content addressing operations.
+func getBucketContentAddressingURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("contentAddressing", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket ownership controls operations.
+func getBucketOwnershipControlsURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("ownershipControls", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket content sniffing operations.
+func getBucketContentSniffingURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("contentSniffing", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket ACL operations.
+func getBucketACLURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("acl", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket request payment operations.
+func getBucketRequestPaymentURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("requestPayment", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket accelerate operations.
+func getBucketAccelerateURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("accelerate", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket object lock operations.
+func getBucketObjectLockURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("object-lock", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket trash operations.
+func getBucketTrashURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("trash", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket object size limits operations.
+func getBucketObjectSizeLimitsURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("objectSizeLimits", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket public access block operations.
+func getBucketPublicAccessBlockURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("publicAccessBlock", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for bucket client certificate operations.
+func getBucketClientCertURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("clientCert", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
+// return URL for restoring an object from the trash.
+func getRestoreObjectFromTrashURL(endPoint, bucketName, objectName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("trashRestore", "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
+// return URL for truncating the object to the given length.
+func getTruncateObjectURL(endPoint, bucketName, objectName, length string) string {
+	queryValue := url.Values{}
+	queryValue.Set(truncateQueryParam, "")
+	queryValue.Set(truncateLengthQueryParam, length)
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
+// return URL for moving an object to the given object.
+func getMoveObjectURL(endPoint, bucketName, objectName string) string {
+	queryValue := url.Values{}
+	queryValue.Set(moveQueryParam, "")
+	return makeTestTargetURL(endPoint, bucketName, objectName, queryValue)
+}
+
+// return URL for bucket website operations.
+func getBucketWebsiteURL(endPoint, bucketName string) string {
+	queryValue := url.Values{}
+	queryValue.Set("website", "")
+	return makeTestTargetURL(endPoint, bucketName, "", queryValue)
+}
+
 // return URL for listen bucket notification.
 func getListenBucketNotificationURL(endPoint, bucketName string, prefixes, suffixes, events []string) string {
 	queryValue := url.Values{}
@@ -2068,6 +2323,9 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "HeadObject":
 			// Register HeadObject handler.
 			bucket.Methods("Head").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
+		case "GetObjectTorrent":
+			// Register GetObjectTorrent handler.
+			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectTorrentHandler).Queries("torrent", "")
 		case "GetObject":
 			// Register GetObject handler.
 			bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectHandler)
@@ -2095,6 +2353,16 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "HeadBucket":
 			// Register HeadBucket handler.
 			bucket.Methods("HEAD").HandlerFunc(api.HeadBucketHandler)
+		case "DeleteBucket":
+			// Register DeleteBucket handler, it matches every DELETE
+			// on the bucket so it must be listed last.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketHandler)
+		case "MetadataSearch":
+			// Register MetadataSearch handler.
+			bucket.Methods("GET").HandlerFunc(api.MetadataSearchHandler).Queries(metadataSearchQueryParam, "")
+		case "FanOutPutObject":
+			// Register FanOutPutObject handler.
+			bucket.Methods("POST").HandlerFunc(api.FanOutPutObjectHandler).Queries(fanOutQueryParam, "")
 		case "DeleteMultipleObjects":
 			// Register DeleteMultipleObjects handler.
 			bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler).Queries("delete", "")
@@ -2128,6 +2396,150 @@ func registerBucketLevelFunc(bucket *router.Router, api objectAPIHandlers, apiFu
 		case "ListenBucketNotification":
 			// Register ListenBucketNotification Handler.
 			bucket.Methods("GET").HandlerFunc(api.ListenBucketNotificationHandler).Queries("events", "{events:.*}")
+		case "GetBucketLogging":
+			// Register GetBucketLogging Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketLoggingHandler).Queries("logging", "")
+		case "PutBucketLogging":
+			// Register PutBucketLogging Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketLoggingHandler).Queries("logging", "")
+		case "GetBucketLifecycle":
+			// Register GetBucketLifecycle Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketLifecycleHandler).Queries("lifecycle", "")
+		case "PutBucketLifecycle":
+			// Register PutBucketLifecycle Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketLifecycleHandler).Queries("lifecycle", "")
+		case "DeleteBucketLifecycle":
+			// Register DeleteBucketLifecycle Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketLifecycleHandler).Queries("lifecycle", "")
+		case "GetBucketInventory":
+			// Register GetBucketInventory Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketInventoryHandler).Queries("inventory", "")
+		case "PutBucketInventory":
+			// Register PutBucketInventory Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketInventoryHandler).Queries("inventory", "")
+		case "DeleteBucketInventory":
+			// Register DeleteBucketInventory Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketInventoryHandler).Queries("inventory", "")
+		case "GetBucketPolicyStatus":
+			// Register GetBucketPolicyStatus Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketPolicyStatusHandler).Queries("policyStatus", "")
+		case "GetBucketReplication":
+			// Register GetBucketReplication Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketReplicationHandler).Queries("replication", "")
+		case "PutBucketReplication":
+			// Register PutBucketReplication Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketReplicationHandler).Queries("replication", "")
+		case "DeleteBucketReplication":
+			// Register DeleteBucketReplication Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketReplicationHandler).Queries("replication", "")
+		case "GetBucketContentSniffing":
+			// Register GetBucketContentSniffing Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketContentSniffingHandler).Queries("contentSniffing", "")
+		case "GetBucketContentAddressing":
+			// Register GetBucketContentAddressing Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketContentAddressingHandler).Queries("contentAddressing", "")
+		case "GetBucketOwnershipControls":
+			// Register GetBucketOwnershipControls Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+		case "GetBucketWebsite":
+			// Register GetBucketWebsite Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketWebsiteHandler).Queries("website", "")
+		case "GetBucketACL":
+			// Register GetBucketACL Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketACLHandler).Queries("acl", "")
+		case "PutBucketACL":
+			// Register PutBucketACL Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketACLHandler).Queries("acl", "")
+		case "GetBucketRequestPayment":
+			// Register GetBucketRequestPayment Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketRequestPaymentHandler).Queries("requestPayment", "")
+		case "PutBucketRequestPayment":
+			// Register PutBucketRequestPayment Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketRequestPaymentHandler).Queries("requestPayment", "")
+		case "GetBucketObjectLockConfig":
+			// Register GetBucketObjectLockConfig Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketObjectLockConfigHandler).Queries("object-lock", "")
+		case "PutBucketObjectLockConfig":
+			// Register PutBucketObjectLockConfig Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectLockConfigHandler).Queries("object-lock", "")
+		case "GetBucketTrash":
+			// Register GetBucketTrash Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketTrashHandler).Queries("trash", "")
+		case "PutBucketTrash":
+			// Register PutBucketTrash Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketTrashHandler).Queries("trash", "")
+		case "GetBucketObjectSizeLimits":
+			// Register GetBucketObjectSizeLimits Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+		case "PutBucketObjectSizeLimits":
+			// Register PutBucketObjectSizeLimits Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+		case "DeleteBucketObjectSizeLimits":
+			// Register DeleteBucketObjectSizeLimits Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketObjectSizeLimitsHandler).Queries("objectSizeLimits", "")
+		case "GetBucketPublicAccessBlock":
+			// Register GetBucketPublicAccessBlock Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+		case "PutBucketPublicAccessBlock":
+			// Register PutBucketPublicAccessBlock Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+		case "DeleteBucketPublicAccessBlock":
+			// Register DeleteBucketPublicAccessBlock Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPublicAccessBlockHandler).Queries("publicAccessBlock", "")
+		case "GetBucketAccelerate":
+			// Register GetBucketAccelerate Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketAccelerateHandler).Queries("accelerate", "")
+		case "PutBucketAccelerate":
+			// Register PutBucketAccelerate Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketAccelerateHandler).Queries("accelerate", "")
+		case "GetBucketClientCert":
+			// Register GetBucketClientCert Handler.
+			bucket.Methods("GET").HandlerFunc(api.GetBucketClientCertHandler).Queries("clientCert", "")
+		case "PutBucketClientCert":
+			// Register PutBucketClientCert Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketClientCertHandler).Queries("clientCert", "")
+		case "DeleteBucketClientCert":
+			// Register DeleteBucketClientCert Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketClientCertHandler).Queries("clientCert", "")
+		case "RestoreObjectFromTrash":
+			// Register RestoreObjectFromTrash Handler.
+			bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.RestoreObjectFromTrashHandler).Queries("trashRestore", "")
+		case "TruncateObject":
+			// Register TruncateObject Handler.
+			bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.TruncateObjectHandler).Queries(truncateQueryParam, "")
+		case "MoveObject":
+			// Register MoveObject Handler.
+			bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.MoveObjectHandler).Queries(moveQueryParam, "")
+		case "PutBucketContentSniffing":
+			// Register PutBucketContentSniffing Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketContentSniffingHandler).Queries("contentSniffing", "")
+		case "PutBucketContentAddressing":
+			// Register PutBucketContentAddressing Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketContentAddressingHandler).Queries("contentAddressing", "")
+		case "PutBucketOwnershipControls":
+			// Register PutBucketOwnershipControls Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+		case "PutBucketWebsite":
+			// Register PutBucketWebsite Handler.
+			bucket.Methods("PUT").HandlerFunc(api.PutBucketWebsiteHandler).Queries("website", "")
+		case "DeleteBucketContentSniffing":
+			// Register DeleteBucketContentSniffing Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketContentSniffingHandler).Queries("contentSniffing", "")
+		case "DeleteBucketContentAddressing":
+			// Register DeleteBucketContentAddressing Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketContentAddressingHandler).Queries("contentAddressing", "")
+		case "DeleteBucketOwnershipControls":
+			// Register DeleteBucketOwnershipControls Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketOwnershipControlsHandler).Queries("ownershipControls", "")
+		case "DeleteBucketWebsite":
+			// Register DeleteBucketWebsite Handler.
+			bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketWebsiteHandler).Queries("website", "")
+		case "ListObjectsV2":
+			// Register ListObjectsV2 Handler.
+			bucket.Methods("GET").HandlerFunc(api.ListObjectsV2Handler).Queries("list-type", "2")
+		case "ListObjectsV1":
+			// Register ListObjectsV1 Handler.
+			bucket.Methods("GET").HandlerFunc(api.ListObjectsV1Handler)
 		}
 	}
 }
@@ -0,0 +1,93 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Requests accepted in TLS early data.
+const (
+	// Only the reads, which are safe to replay.
+	tlsEarlyDataReads = "reads"
+	// No request.
+	tlsEarlyDataOff = "off"
+)
+
+// Header marking the requests received in TLS early data by the TLS
+// terminating proxies, as per RFC 8470.
+const earlyDataHeader = "Early-Data"
+
+// setTLSEarlyData - sets the requests accepted in TLS early data from
+// MINIO_TLS_EARLY_DATA env.
+func setTLSEarlyData() {
+	if earlyData := os.Getenv("MINIO_TLS_EARLY_DATA"); earlyData != "" {
+		switch strings.ToLower(earlyData) {
+		case tlsEarlyDataReads:
+			globalTLSEarlyData = tlsEarlyDataReads
+		case tlsEarlyDataOff:
+			globalTLSEarlyData = tlsEarlyDataOff
+		default:
+			fatalIf(errInvalidArgument, "Invalid MINIO_TLS_EARLY_DATA value %s.", earlyData)
+		}
+	}
+}
+
+// isEarlyDataRequest - returns true if the request was received in TLS
+// early data, before the handshake completed. The TLS server of the Go
+// runtime never accepts early data, these requests are forwarded by the
+// TLS terminating proxies in front of the server.
+func isEarlyDataRequest(r *http.Request) bool {
+	return r.Header.Get(earlyDataHeader) == "1" || (r.TLS != nil && !r.TLS.HandshakeComplete)
+}
+
+// isEarlyDataAllowed - returns true if the request may be served from
+// TLS early data. An attacker may replay early data, only the requests
+// without side effects are allowed.
+func isEarlyDataAllowed(r *http.Request) bool {
+	if globalTLSEarlyData == tlsEarlyDataOff {
+		return false
+	}
+	switch r.Method {
+	case httpGET, httpHEAD, "OPTIONS":
+		return true
+	}
+	return false
+}
+
+// earlyDataHandler - rejects the requests received in TLS early data
+// which are not allowed.
+type earlyDataHandler struct {
+	handler http.Handler
+}
+
+// setEarlyDataHandler - rejects the requests received in TLS early data
+// which could be replayed with side effects with 425 Too Early, the
+// clients retry them once the handshake completed.
+func setEarlyDataHandler(h http.Handler) http.Handler {
+	return earlyDataHandler{h}
+}
+
+func (h earlyDataHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isEarlyDataRequest(r) && !isEarlyDataAllowed(r) {
+		writeErrorResponse(w, ErrTooEarly, r.URL)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,83 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests the requests received in TLS early data are served only if
+// they can be replayed without side effects, PUT never is.
+func TestEarlyDataHandler(t *testing.T) {
+	defer func() { globalTLSEarlyData = tlsEarlyDataReads }()
+
+	handler := setEarlyDataHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	testCases := []struct {
+		earlyData    string
+		method       string
+		earlyDataTLS bool
+		expectedCode int
+	}{
+		// Requests after the handshake are always served.
+		{tlsEarlyDataReads, "PUT", false, http.StatusOK},
+		{tlsEarlyDataOff, "PUT", false, http.StatusOK},
+		{tlsEarlyDataOff, "GET", false, http.StatusOK},
+		// Only the reads are served from early data by default.
+		{tlsEarlyDataReads, "GET", true, http.StatusOK},
+		{tlsEarlyDataReads, "HEAD", true, http.StatusOK},
+		{tlsEarlyDataReads, "PUT", true, http.StatusTooEarly},
+		{tlsEarlyDataReads, "POST", true, http.StatusTooEarly},
+		{tlsEarlyDataReads, "DELETE", true, http.StatusTooEarly},
+		// No request is served from early data once disabled.
+		{tlsEarlyDataOff, "GET", true, http.StatusTooEarly},
+		{tlsEarlyDataOff, "PUT", true, http.StatusTooEarly},
+	}
+	for i, testCase := range testCases {
+		globalTLSEarlyData = testCase.earlyData
+		// Early data is marked by the proxies or seen on the
+		// connection of a handshake not completed yet.
+		for _, fromProxy := range []bool{true, false} {
+			rec := httptest.NewRecorder()
+			req, err := http.NewRequest(testCase.method, "https://127.0.0.1:9000/bucket/object", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.TLS = &tls.ConnectionState{HandshakeComplete: true}
+			if testCase.earlyDataTLS {
+				if fromProxy {
+					req.Header.Set("Early-Data", "1")
+				} else {
+					req.TLS.HandshakeComplete = false
+				}
+			}
+			handler.ServeHTTP(rec, req)
+			if rec.Code != testCase.expectedCode {
+				t.Fatalf("Test %d: %s: Expected status %d, got %d", i+1, testCase.method, testCase.expectedCode, rec.Code)
+			}
+			if rec.Code != http.StatusOK && !bytes.Contains(rec.Body.Bytes(), []byte("<Code>TooEarly</Code>")) {
+				t.Fatalf("Test %d: Expected TooEarly, got %s", i+1, rec.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// setSessionTicketRotation - sets the interval between two rotations of
+// the TLS session ticket keys from MINIO_TLS_SESSION_TICKET_ROTATION env
+// and the number of previous keys kept from
+// MINIO_TLS_SESSION_TICKET_PREVIOUS_KEYS env.
+func setSessionTicketRotation() {
+	if interval := os.Getenv("MINIO_TLS_SESSION_TICKET_ROTATION"); interval != "" {
+		duration, err := time.ParseDuration(interval)
+		if err != nil || duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_TLS_SESSION_TICKET_ROTATION value %s.", interval)
+		}
+		globalSessionTicketRotation = duration
+	}
+	if previousKeys := os.Getenv("MINIO_TLS_SESSION_TICKET_PREVIOUS_KEYS"); previousKeys != "" {
+		n, err := strconv.Atoi(previousKeys)
+		if err != nil || n < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_TLS_SESSION_TICKET_PREVIOUS_KEYS value %s.", previousKeys)
+		}
+		globalSessionTicketPreviousKeys = n
+	}
+}
+
+// sessionTicketKeys - keys encrypting the TLS session tickets of a
+// server. The newest key encrypts the new tickets, the previous keys
+// only decrypt the tickets issued before the last rotations so that
+// their sessions are still resumed. The tickets of the keys dropped
+// can not be decrypted anymore, even if the keys leak later.
+type sessionTicketKeys struct {
+	mu sync.Mutex
+
+	config       *tls.Config
+	previousKeys int
+
+	// Newest key first.
+	keys [][32]byte
+}
+
+// newSessionTicketKeys - returns the session ticket keys of the TLS
+// config, rotated once to replace the keys generated by crypto/tls.
+func newSessionTicketKeys(config *tls.Config, previousKeys int) (*sessionTicketKeys, error) {
+	s := &sessionTicketKeys{config: config, previousKeys: previousKeys}
+	if err := s.Rotate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Rotate - encrypts the new tickets with a new random key, the oldest
+// key is dropped once more than the previous keys kept.
+func (s *sessionTicketKeys) Rotate() error {
+	var key [32]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append([][32]byte{key}, s.keys...)
+	if len(s.keys) > s.previousKeys+1 {
+		s.keys = s.keys[:s.previousKeys+1]
+	}
+	s.config.SetSessionTicketKeys(s.keys)
+	return nil
+}
+
+// startSessionTicketRotation - rotates the session ticket keys of the
+// TLS config periodically until the server stops. A zero interval
+// leaves the keys to crypto/tls.
+func startSessionTicketRotation(config *tls.Config) error {
+	if globalSessionTicketRotation == 0 {
+		return nil
+	}
+	keys, err := newSessionTicketKeys(config, globalSessionTicketPreviousKeys)
+	if err != nil {
+		return err
+	}
+	go func() {
+		ticker := time.NewTicker(globalSessionTicketRotation)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				errorIf(keys.Rotate(), "Unable to rotate the TLS session ticket keys.")
+			case <-globalServiceDoneCh:
+				return
+			}
+		}
+	}()
+	return nil
+}
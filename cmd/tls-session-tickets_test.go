@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// newTestTLSCertificate - returns a self-signed certificate of 127.0.0.1.
+func newTestTLSCertificate(t *testing.T) tls.Certificate {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Minio Test Cert"}},
+		NotBefore:    time.Now().UTC().Add(-time.Minute),
+		NotAfter:     time.Now().UTC().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{derBytes}, PrivateKey: priv}
+}
+
+// Tests the session ticket keys are rotated, the sessions of the
+// tickets issued before a rotation are still resumed until their key
+// is dropped.
+func TestSessionTicketKeysRotate(t *testing.T) {
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{newTestTLSCertificate(t)}}
+	keys, err := newSessionTicketKeys(serverConfig, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, aErr := listener.Accept()
+			if aErr != nil {
+				return
+			}
+			// Complete the handshake and send a byte after the
+			// session tickets.
+			conn.Write([]byte{0})
+			conn.Close()
+		}
+	}()
+
+	rootCAs := x509.NewCertPool()
+	cert, _ := x509.ParseCertificate(serverConfig.Certificates[0].Certificate[0])
+	rootCAs.AddCert(cert)
+	clientConfig := &tls.Config{RootCAs: rootCAs, ClientSessionCache: tls.NewLRUClientSessionCache(1)}
+	// dial - connects and returns true if the session was resumed. The
+	// byte read makes sure the client received the new session ticket.
+	dial := func() bool {
+		conn, dErr := tls.Dial("tcp", listener.Addr().String(), clientConfig)
+		if dErr != nil {
+			t.Fatal(dErr)
+		}
+		defer conn.Close()
+		if _, dErr = conn.Read(make([]byte, 1)); dErr != nil {
+			t.Fatal(dErr)
+		}
+		return conn.ConnectionState().DidResume
+	}
+
+	if dial() {
+		t.Fatal("Expected the first session not to be resumed")
+	}
+	if !dial() {
+		t.Fatal("Expected the session to be resumed")
+	}
+
+	// Sessions of the previous key are still resumed.
+	firstKey := keys.keys[0]
+	if err = keys.Rotate(); err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.keys) != 2 || keys.keys[1] != firstKey || keys.keys[0] == firstKey {
+		t.Fatal("Expected a new key to be used, keeping the previous one")
+	}
+	if !dial() {
+		t.Fatal("Expected the session to be resumed across a rotation")
+	}
+
+	// Keys older than the previous keys kept are dropped, their
+	// sessions can not be resumed anymore.
+	for i := 0; i < 2; i++ {
+		if err = keys.Rotate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(keys.keys) != 2 {
+		t.Fatalf("Expected 2 keys, got %d", len(keys.keys))
+	}
+	if dial() {
+		t.Fatal("Expected the session of a dropped key not to be resumed")
+	}
+	if !dial() {
+		t.Fatal("Expected the new session to be resumed")
+	}
+}
@@ -30,6 +30,9 @@ var errInvalidToken = errors.New("Invalid token")
 // If x-amz-content-sha256 header value mismatches with what we calculate.
 var errContentSHA256Mismatch = errors.New("Content checksum SHA256 mismatch")
 
+// If the trailing checksum of a streaming upload mismatches with what we calculate.
+var errChecksumMismatch = errors.New("Trailing checksum mismatch")
+
 // used when we deal with data larger than expected
 var errSizeUnexpected = errors.New("Data size larger than expected")
 
@@ -45,6 +48,9 @@ var errDataTooSmall = errors.New("Object size smaller than expected")
 // errServerNotInitialized - server not initialized.
 var errServerNotInitialized = errors.New("Server not initialized, please try again")
 
+// errServerReadOnly - server is in read-only mode.
+var errServerReadOnly = errors.New("Server is in read-only mode, write operations are not allowed")
+
 // errServerVersionMismatch - server versions do not match.
 var errServerVersionMismatch = errors.New("Server versions do not match")
 
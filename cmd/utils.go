@@ -23,6 +23,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strconv"
 	"strings"
 
 	"encoding/json"
@@ -170,6 +172,8 @@ func checkValidMD5(md5 string) ([]byte, error) {
 const (
 	// maximum object size per PUT request is 5GiB
 	maxObjectSize = 5 * humanize.GiByte
+	// maximum size of an object completed from its parts is 5TiB
+	maxMultipartObjectSize = 5 * humanize.TiByte
 	// minimum Part size for multipart upload is 5MiB
 	minPartSize = 5 * humanize.MiByte
 	// maximum Part ID for multipart upload is 10000 (Acceptable values range from 1 to 10000 inclusive)
@@ -178,7 +182,7 @@ const (
 
 // isMaxObjectSize - verify if max object size
 func isMaxObjectSize(size int64) bool {
-	return size > maxObjectSize
+	return size > globalMaxObjectSize
 }
 
 // Check if part size is more than or equal to minimum allowed size.
@@ -188,7 +192,51 @@ func isMinAllowedPartSize(size int64) bool {
 
 // isMaxPartNumber - Check if part ID is greater than the maximum allowed ID.
 func isMaxPartID(partID int) bool {
-	return partID > maxPartID
+	return partID > globalMaxPartID
+}
+
+// isValidPartID - Check if part ID is within 1 and the maximum allowed ID.
+func isValidPartID(partID int) bool {
+	return partID >= 1 && !isMaxPartID(partID)
+}
+
+// setMaxPartID - sets the maximum part ID of the multipart uploads from
+// MINIO_MAX_PARTS env, it can only be lowered from the S3 limit of 10000.
+func setMaxPartID() {
+	if maxParts := os.Getenv("MINIO_MAX_PARTS"); maxParts != "" {
+		partID, err := strconv.Atoi(maxParts)
+		if err != nil || partID < 1 || partID > maxPartID {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_PARTS value %s.", maxParts)
+		}
+		globalMaxPartID = partID
+	}
+}
+
+// setMaxObjectSize - sets the maximum size of the objects uploaded by a
+// single operation and of the parts of the multipart uploads from
+// MINIO_MAX_OBJECT_SIZE env, it can only be lowered from the S3 limit
+// of 5GiB.
+func setMaxObjectSize() {
+	if maxSize := os.Getenv("MINIO_MAX_OBJECT_SIZE"); maxSize != "" {
+		size, err := humanize.ParseBytes(maxSize)
+		if err != nil || size == 0 || size > maxObjectSize {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_OBJECT_SIZE value %s.", maxSize)
+		}
+		globalMaxObjectSize = int64(size)
+	}
+}
+
+// setMaxMultipartObjectSize - sets the maximum size of the objects
+// completed from their parts from MINIO_MAX_MULTIPART_OBJECT_SIZE env,
+// it can only be lowered from the S3 limit of 5TiB.
+func setMaxMultipartObjectSize() {
+	if maxSize := os.Getenv("MINIO_MAX_MULTIPART_OBJECT_SIZE"); maxSize != "" {
+		size, err := humanize.ParseBytes(maxSize)
+		if err != nil || size == 0 || size > maxMultipartObjectSize {
+			fatalIf(errInvalidArgument, "Invalid MINIO_MAX_MULTIPART_OBJECT_SIZE value %s.", maxSize)
+		}
+		globalMaxMultipartObjectSize = int64(size)
+	}
 }
 
 func contains(stringList []string, element string) bool {
@@ -24,6 +24,8 @@ import (
 	"reflect"
 	"runtime"
 	"testing"
+
+	humanize "github.com/dustin/go-humanize"
 )
 
 // Tests http.Header clone.
@@ -101,24 +103,44 @@ func TestCheckDuplicates(t *testing.T) {
 	}
 }
 
-// Tests maximum object size.
+// Tests maximum object size, with the default and a lowered maximum.
 func TestMaxObjectSize(t *testing.T) {
+	defer func(maxSize int64) {
+		globalMaxObjectSize = maxSize
+	}(globalMaxObjectSize)
+
 	sizes := []struct {
-		isMax bool
-		size  int64
+		maxSize int64
+		isMax   bool
+		size    int64
 	}{
 		// Test - 1 - maximum object size.
 		{
+			maxObjectSize,
 			true,
 			maxObjectSize + 1,
 		},
 		// Test - 2 - not maximum object size.
 		{
+			maxObjectSize,
 			false,
 			maxObjectSize - 1,
 		},
+		// Test - 3 - lowered maximum object size.
+		{
+			humanize.MiByte,
+			true,
+			humanize.MiByte + 1,
+		},
+		// Test - 4 - within the lowered maximum object size.
+		{
+			humanize.MiByte,
+			false,
+			humanize.MiByte,
+		},
 	}
 	for i, s := range sizes {
+		globalMaxObjectSize = s.maxSize
 		isMax := isMaxObjectSize(s.size)
 		if isMax != s.isMax {
 			t.Errorf("Test %d: Expected %t, got %t", i+1, s.isMax, isMax)
@@ -178,6 +200,34 @@ func TestMaxPartID(t *testing.T) {
 	}
 }
 
+// Tests validating the part IDs with the default and a lowered maximum
+// part ID.
+func TestIsValidPartID(t *testing.T) {
+	defer func(maxID int) {
+		globalMaxPartID = maxID
+	}(globalMaxPartID)
+
+	testCases := []struct {
+		maxPartID int
+		partID    int
+		isValid   bool
+	}{
+		{maxPartID, 0, false},
+		{maxPartID, -1, false},
+		{maxPartID, 1, true},
+		{maxPartID, maxPartID, true},
+		{maxPartID, maxPartID + 1, false},
+		{100, 100, true},
+		{100, 101, false},
+	}
+	for i, testCase := range testCases {
+		globalMaxPartID = testCase.maxPartID
+		if isValid := isValidPartID(testCase.partID); isValid != testCase.isValid {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.isValid, isValid)
+		}
+	}
+}
+
 // Tests extracting bucket and objectname from various types of URL paths.
 func TestURL2BucketObjectName(t *testing.T) {
 	testCases := []struct {
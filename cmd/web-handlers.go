@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -121,10 +122,13 @@ func (web *webAPIHandlers) MakeBucket(r *http.Request, args *MakeBucketArgs, rep
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if globalReadOnlyMode.IsEnabled() {
+		return toJSONError(errServerReadOnly)
+	}
 	bucketLock := globalNSMutex.NewNSLock(args.BucketName, "")
 	bucketLock.Lock()
 	defer bucketLock.Unlock()
-	if err := objectAPI.MakeBucket(args.BucketName); err != nil {
+	if err := makeBucketWithinLimit(objectAPI, args.BucketName); err != nil {
 		return toJSONError(err, args.BucketName)
 	}
 	reply.UIVersion = miniobrowser.UIVersion
@@ -268,12 +272,20 @@ func (web *webAPIHandlers) RemoveObject(r *http.Request, args *RemoveObjectArgs,
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if globalReadOnlyMode.IsEnabled() {
+		return toJSONError(errServerReadOnly)
+	}
 
 	objectLock := globalNSMutex.NewNSLock(args.BucketName, args.ObjectName)
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
-	if err := objectAPI.DeleteObject(args.BucketName, args.ObjectName); err != nil {
+	// Objects under retention can not be removed.
+	if err := enforceObjectRetention(objectAPI, args.BucketName, args.ObjectName, r); err != nil {
+		return toJSONError(err, args.BucketName, args.ObjectName)
+	}
+
+	if err := deleteObjectOrTrash(objectAPI, args.BucketName, args.ObjectName); err != nil {
 		if isErrObjectNotFound(err) {
 			// Ignore object not found error.
 			reply.UIVersion = miniobrowser.UIVersion
@@ -455,6 +467,10 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		writeWebErrorResponse(w, errAuthentication)
 		return
 	}
+	if globalReadOnlyMode.IsEnabled() {
+		writeWebErrorResponse(w, errServerReadOnly)
+		return
+	}
 
 	// Require Content-Length to be set in the request
 	size := r.ContentLength
@@ -463,16 +479,49 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Object size must be within the limits of the bucket, if any.
+	if err := checkObjectSizeLimits(bucket, size); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
 	// Extract incoming metadata if any.
 	metadata := extractMetadataFromHeader(r.Header)
 
+	// Save the default retention of the bucket, if any.
+	if err := setObjectRetentionMetadata(bucket, nil, metadata); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
+	// Objects of content-addressed buckets are named after their
+	// SHA256, content not hashing to its key is never stored.
+	sha256sum := ""
+	var reader io.Reader = r.Body
+	if globalBucketContentAddressing.IsEnabled(bucket) {
+		var err error
+		if err = checkContentAddress(bucket, object); err == nil {
+			setContentAddressMetadata(metadata, object)
+			reader, err = newContentAddressReader(r.Body, size, object, sha256sum)
+		}
+		if err != nil {
+			writeWebErrorResponse(w, err)
+			return
+		}
+	}
+
 	// Lock the object.
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.Lock()
 	defer objectLock.Unlock()
 
-	sha256sum := ""
-	objInfo, err := objectAPI.PutObject(bucket, object, size, r.Body, metadata, sha256sum)
+	// Objects under retention can not be overwritten.
+	if err := enforceObjectRetention(objectAPI, bucket, object, r); err != nil {
+		writeWebErrorResponse(w, err)
+		return
+	}
+
+	objInfo, err := objectAPI.PutObject(bucket, object, size, reader, metadata, sha256sum)
 	if err != nil {
 		writeWebErrorResponse(w, err)
 		return
@@ -507,14 +556,20 @@ func (web *webAPIHandlers) Download(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Add content disposition.
-	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(object)))
-
 	// Lock the object before reading.
 	objectLock := globalNSMutex.NewNSLock(bucket, object)
 	objectLock.RLock()
 	defer objectLock.RUnlock()
 
+	// The browser does not carry the customer keys of encrypted objects.
+	if objInfo, err := objectAPI.GetObjectInfo(bucket, object); err == nil && isEncryptedObject(objInfo.UserDefined) {
+		writeWebErrorResponse(w, errEncryptedObjectUnsupported)
+		return
+	}
+
+	// Add content disposition.
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", path.Base(object)))
+
 	if err := objectAPI.GetObject(bucket, object, 0, -1, w); err != nil {
 		/// No need to print error, response writer already written to.
 		return
@@ -639,6 +694,9 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 	if !isHTTPRequestValid(r) {
 		return toJSONError(errAuthentication)
 	}
+	if globalReadOnlyMode.IsEnabled() {
+		return toJSONError(errServerReadOnly)
+	}
 
 	bucketP := policy.BucketPolicy(args.Policy)
 	if !bucketP.IsValidBucketPolicy() {
@@ -665,6 +723,11 @@ func (web *webAPIHandlers) SetBucketPolicy(r *http.Request, args *SetBucketPolic
 		return toJSONError(err)
 	}
 
+	// Public policies are rejected if blocked on the bucket.
+	if err = checkPublicPolicyAllowed(args.BucketName, data); err != nil {
+		return toJSONError(err, args.BucketName)
+	}
+
 	// Parse validate and save bucket policy.
 	if s3Error := parseAndPersistBucketPolicy(args.BucketName, data, objectAPI); s3Error != ErrNone {
 		apiErr := getAPIError(s3Error)
@@ -805,6 +868,12 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusServiceUnavailable,
 			Description:    err.Error(),
 		}
+	} else if err == errServerReadOnly {
+		return APIError{
+			Code:           "XMinioServerReadOnly",
+			HTTPStatusCode: http.StatusServiceUnavailable,
+			Description:    err.Error(),
+		}
 	} else if err == errInvalidAccessKeyLength {
 		return APIError{
 			Code:           "AccessDenied",
@@ -829,6 +898,18 @@ func toWebAPIError(err error) APIError {
 			HTTPStatusCode: http.StatusBadRequest,
 			Description:    err.Error(),
 		}
+	} else if err == errInvalidContentAddress || err == errContentAddressMismatch {
+		return APIError{
+			Code:           "InvalidRequest",
+			HTTPStatusCode: http.StatusBadRequest,
+			Description:    err.Error(),
+		}
+	} else if err == errObjectLocked {
+		return getAPIError(ErrObjectLocked)
+	} else if err == errDataTooSmall {
+		return getAPIError(ErrEntityTooSmall)
+	} else if err == errDataTooLarge {
+		return getAPIError(ErrEntityTooLarge)
 	}
 
 	// Convert error type to api error code.
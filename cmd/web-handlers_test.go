@@ -27,6 +27,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	humanize "github.com/dustin/go-humanize"
 	"github.com/minio/minio-go/pkg/policy"
@@ -555,6 +556,30 @@ func testRemoveObjectWebHandler(obj ObjectLayer, instanceType string, t TestErrH
 	if err != nil {
 		t.Fatalf("Failed, %v", err)
 	}
+
+	// Objects removed from a bucket with the trash enabled are moved to
+	// its trash.
+	globalBucketTrash.Set(bucketName, &TrashConfiguration{Status: trashStatusEnabled, RetentionDays: 1})
+	defer globalBucketTrash.Set(bucketName, nil)
+	_, err = obj.PutObject(bucketName, objectName, int64(len(data)), bytes.NewReader(data), nil, "")
+	if err != nil {
+		t.Fatalf("Was not able to upload an object, %v", err)
+	}
+	rec = httptest.NewRecorder()
+	req, err = newTestWebRPCRequest("Web.RemoveObject", authorization, removeObjectRequest)
+	if err != nil {
+		t.Fatalf("Failed to create HTTP request: <ERROR> %v", err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if err = getTestWebRPCResponse(rec, &removeObjectReply); err != nil {
+		t.Fatalf("Failed, %v", err)
+	}
+	if _, err = obj.GetObjectInfo(bucketName, objectName); !isErrObjectNotFound(err) {
+		t.Fatalf("%s: Expected the object to be removed, got %v", instanceType, err)
+	}
+	if _, err = readTrashEntry(obj, getTrashEntryPath(bucketName, objectName)); err != nil {
+		t.Fatalf("%s: Expected the object to be in the trash, got %v", instanceType, err)
+	}
 }
 
 // Wrapper for calling Generate Auth Handler
@@ -811,6 +836,156 @@ func testUploadWebHandler(obj ObjectLayer, instanceType string, t TestErrHandler
 	}
 }
 
+// Tests the objects uploaded through the browser are within the size
+// limits of their bucket.
+func TestWebHandlerUploadSizeLimits(t *testing.T) {
+	ExecObjectLayerTest(t, testWebHandlerUploadSizeLimits)
+}
+
+func testWebHandlerUploadSizeLimits(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	credentials := serverConfig.GetCredential()
+	authorization, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal("Cannot authenticate")
+	}
+
+	bucketName := getRandomBucketName()
+	if err = obj.MakeBucket(bucketName); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	globalBucketObjectSizeLimits.Set(bucketName, &ObjectSizeLimitsConfiguration{MinSize: 10, MaxSize: 100})
+	defer globalBucketObjectSizeLimits.Set(bucketName, nil)
+
+	testCases := []struct {
+		size         int
+		expectedCode int
+	}{
+		{5, http.StatusBadRequest},
+		{10, http.StatusOK},
+		{100, http.StatusOK},
+		{101, http.StatusBadRequest},
+	}
+	for i, testCase := range testCases {
+		objectName := fmt.Sprintf("object-%d", i+1)
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("PUT", "/minio/upload/"+bucketName+"/"+objectName,
+			bytes.NewReader(bytes.Repeat([]byte("a"), testCase.size)))
+		if err != nil {
+			t.Fatalf("Cannot create upload request, %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+authorization)
+		apiRouter.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("%s: Test %d: Expected the response status to be %d, but instead found `%d`", instanceType, i+1, testCase.expectedCode, rec.Code)
+		}
+		_, err = obj.GetObjectInfo(bucketName, objectName)
+		if testCase.expectedCode == http.StatusOK && err != nil {
+			t.Fatalf("%s: Test %d: Expected the object to be uploaded, got %v", instanceType, i+1, err)
+		}
+		if testCase.expectedCode != http.StatusOK && !isErrObjectNotFound(err) {
+			t.Fatalf("%s: Test %d: Expected no object uploaded, got %v", instanceType, i+1, err)
+		}
+	}
+}
+
+// Tests objects under retention can neither be removed nor overwritten
+// through the browser.
+func TestWebHandlerObjectRetention(t *testing.T) {
+	ExecObjectLayerTest(t, testWebHandlerObjectRetention)
+}
+
+func testWebHandlerObjectRetention(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	apiRouter := initTestWebRPCEndPoint(obj)
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatalf("Init Test config failed")
+	}
+	defer removeAll(rootPath)
+
+	credentials := serverConfig.GetCredential()
+	authorization, err := getWebRPCToken(apiRouter, credentials.AccessKey, credentials.SecretKey)
+	if err != nil {
+		t.Fatal("Cannot authenticate")
+	}
+
+	bucketName := getRandomBucketName()
+	objectName := "object"
+	if err = obj.MakeBucket(bucketName); err != nil {
+		t.Fatalf("%s : %s", instanceType, err)
+	}
+	globalBucketObjectLock.Set(bucketName, &ObjectLockConfiguration{ObjectLockEnabled: "Enabled"})
+	defer globalBucketObjectLock.Set(bucketName, nil)
+
+	data := []byte("retained")
+	metadata := map[string]string{
+		amzObjectLockMode:            retentionModeCompliance,
+		amzObjectLockRetainUntilDate: time.Now().UTC().Add(time.Hour).Format(time.RFC3339),
+	}
+	if _, err = obj.PutObject(bucketName, objectName, int64(len(data)), bytes.NewReader(data), metadata, ""); err != nil {
+		t.Fatalf("Was not able to upload an object, %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	req, err := newTestWebRPCRequest("Web.RemoveObject", authorization, RemoveObjectArgs{BucketName: bucketName, ObjectName: objectName})
+	if err != nil {
+		t.Fatalf("Failed to create HTTP request: <ERROR> %v", err)
+	}
+	apiRouter.ServeHTTP(rec, req)
+	if err = getTestWebRPCResponse(rec, &WebGenericRep{}); err == nil {
+		t.Fatalf("%s: Expected the removal of an object under retention to fail", instanceType)
+	}
+
+	content := []byte("overwritten")
+	rec = httptest.NewRecorder()
+	req, err = http.NewRequest("PUT", "/minio/upload/"+bucketName+"/"+objectName, bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Cannot create upload request, %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+authorization)
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected the response status to be 403, but instead found `%d`", instanceType, rec.Code)
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucketName, objectName, 0, int64(len(data)), &buffer); err != nil {
+		t.Fatalf("%s: Expected the object to be kept, got %v", instanceType, err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatalf("%s: Expected content %q, got %q", instanceType, data, buffer.Bytes())
+	}
+
+	// New uploads inherit the default retention of the bucket.
+	globalBucketObjectLock.Set(bucketName, &ObjectLockConfiguration{
+		ObjectLockEnabled: "Enabled",
+		Rule:              &ObjectLockRule{DefaultRetention: DefaultRetention{Mode: retentionModeGovernance, Days: 1}},
+	})
+	rec = httptest.NewRecorder()
+	req, err = http.NewRequest("PUT", "/minio/upload/"+bucketName+"/new-object", bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("Cannot create upload request, %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+authorization)
+	apiRouter.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected the response status to be 200, but instead found `%d`", instanceType, rec.Code)
+	}
+	objInfo, err := obj.GetObjectInfo(bucketName, "new-object")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if objInfo.UserDefined[amzObjectLockMode] != retentionModeGovernance || objInfo.UserDefined[amzObjectLockRetainUntilDate] == "" {
+		t.Fatalf("%s: Expected the default retention of the bucket, got %#v", instanceType, objInfo.UserDefined)
+	}
+}
+
 // Wrapper for calling Download Handler
 func TestWebHandlerDownload(t *testing.T) {
 	ExecObjectLayerTest(t, testDownloadWebHandler)
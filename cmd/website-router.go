@@ -0,0 +1,145 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"net/http"
+
+	router "github.com/gorilla/mux"
+)
+
+const websitePath = "/website"
+
+// registerWebsiteRouter - add handler functions serving the buckets
+// with a website configuration as static websites.
+func registerWebsiteRouter(mux *router.Router) {
+	websiteRouter := mux.NewRoute().PathPrefix(reservedBucket + websitePath).Subrouter()
+
+	websiteRouter.Methods("GET", "HEAD").Path("/{bucket}/{object:.*}").HandlerFunc(WebsiteHandler)
+	websiteRouter.Methods("GET", "HEAD").Path("/{bucket}").HandlerFunc(WebsiteHandler)
+}
+
+// getWebsiteURLPath - returns the path of the object on the website
+// endpoint.
+func getWebsiteURLPath(bucket, object string) string {
+	return reservedBucket + websitePath + slashSeparator + bucket + slashSeparator + object
+}
+
+// writeWebsiteErrorResponse - writes the error, without a body for
+// HEAD requests.
+func writeWebsiteErrorResponse(w http.ResponseWriter, r *http.Request, errorCode APIErrorCode) {
+	if r.Method == httpHEAD {
+		writeErrorResponseHeadersOnly(w, errorCode)
+		return
+	}
+	writeErrorResponse(w, errorCode, r.URL)
+}
+
+// WebsiteHandler - GET, HEAD /minio/website/{bucket}/{object}
+// ----------
+// Serves the objects of a bucket with a website configuration to
+// anonymous clients, the bucket policy must allow reading the objects.
+// Requests of the website root and its subdirectories are served with
+// the index document, missing objects with the error document if any.
+// Objects with a website redirect location are answered with a
+// redirect instead of their data.
+func WebsiteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := router.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	objectAPI := newObjectLayerFn()
+	if objectAPI == nil {
+		writeWebsiteErrorResponse(w, r, ErrServerNotInitialized)
+		return
+	}
+
+	config, ok := globalBucketWebsites.Get(bucket)
+	if !ok {
+		if err := checkBucketExist(bucket, objectAPI); err != nil {
+			writeWebsiteErrorResponse(w, r, toAPIErrorCode(err))
+			return
+		}
+		writeWebsiteErrorResponse(w, r, ErrNoSuchWebsiteConfiguration)
+		return
+	}
+
+	if object == "" || hasSuffix(object, slashSeparator) {
+		object += config.IndexDocument.Suffix
+	}
+
+	s3Error := serveWebsiteObject(w, r, objectAPI, bucket, object, http.StatusOK)
+	if s3Error == ErrNoSuchKey && config.ErrorDocument != nil {
+		// Missing objects are served with the error document.
+		s3Error = serveWebsiteObject(w, r, objectAPI, bucket, config.ErrorDocument.Key, http.StatusNotFound)
+	}
+	if s3Error != ErrNone {
+		writeWebsiteErrorResponse(w, r, s3Error)
+	}
+}
+
+// serveWebsiteObject - writes the object readable by anonymous clients
+// with the status, returns the error without writing any response if
+// the object cannot be served.
+func serveWebsiteObject(w http.ResponseWriter, r *http.Request, objectAPI ObjectLayer, bucket, object string, status int) APIErrorCode {
+	if s3Error := enforceBucketPolicy(bucket, "s3:GetObject", slashSeparator+bucket+slashSeparator+object,
+		r.Referer(), nil); s3Error != ErrNone {
+		return s3Error
+	}
+
+	// Lock the object before reading.
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	defer objectLock.RUnlock()
+
+	objInfo, err := objectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return toAPIErrorCode(err)
+	}
+	// Websites do not carry the customer keys of encrypted objects.
+	if isEncryptedObject(objInfo.UserDefined) {
+		return ErrEncryptedObjectNotSupported
+	}
+
+	if location := objInfo.UserDefined[websiteRedirectLocationKey]; location != "" && status == http.StatusOK {
+		// Paths are relative to the website root.
+		if hasPrefix(location, slashSeparator) {
+			location = getWebsiteURLPath(bucket, location[1:])
+		}
+		http.Redirect(w, r, location, http.StatusMovedPermanently)
+		return ErrNone
+	}
+
+	// Validate pre-conditions if any.
+	if status == http.StatusOK && checkPreconditions(w, r, objInfo) {
+		return ErrNone
+	}
+
+	setObjectHeaders(w, objInfo, nil)
+	w.WriteHeader(status)
+	if r.Method == httpHEAD {
+		return ErrNone
+	}
+
+	// Object data is written within the bandwidth limit.
+	dataWriter, releaseThrottle := throttleResponseWriter(w, r)
+	defer releaseThrottle()
+	if err = objectAPI.GetObject(bucket, object, 0, objInfo.Size, dataWriter); err != nil {
+		errorIf(err, "Unable to write to client.")
+	}
+	return ErrNone
+}
@@ -0,0 +1,257 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests the bucket website configuration and the objects served on
+// the website endpoint, including the website redirects of objects.
+func TestWebsiteHandler(t *testing.T) {
+	ExecObjectLayerAPITest(t, testWebsiteHandler, []string{
+		"GetBucketWebsite",
+		"PutBucketWebsite",
+		"DeleteBucketWebsite",
+		"PutObject",
+		"GetObject",
+	})
+}
+
+func testWebsiteHandler(obj ObjectLayer, instanceType, bucketName string, apiRouter http.Handler,
+	credentials credential, t *testing.T) {
+	// Website configs are applied in-memory through the local peer.
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+	initGlobalS3Peers(nil)
+
+	websiteRouter := router.NewRouter()
+	registerWebsiteRouter(websiteRouter)
+
+	serveRequest := func(method, urlStr string, body []byte, header http.Header) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := newTestRequest(method, urlStr, int64(len(body)), bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		if err = signRequestV4(req, credentials.AccessKey, credentials.SecretKey); err != nil {
+			t.Fatalf("%s: Failed to sign HTTP request: <ERROR> %v", instanceType, err)
+		}
+		apiRouter.ServeHTTP(rec, req)
+		return rec
+	}
+	serveWebsiteRequest := func(method, object string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest(method, "http://127.0.0.1:9000"+reservedBucket+websitePath+"/"+bucketName+object, nil)
+		if err != nil {
+			t.Fatalf("%s: Failed to create HTTP request: <ERROR> %v", instanceType, err)
+		}
+		websiteRouter.ServeHTTP(rec, req)
+		return rec
+	}
+
+	objects := []struct {
+		name     string
+		data     string
+		location string
+	}{
+		{"index.html", "<html>index</html>", ""},
+		{"docs/index.html", "<html>docs</html>", ""},
+		{"error.html", "<html>error</html>", ""},
+		{"old.html", "<html>old</html>", "/new.html"},
+		{"external.html", "<html>external</html>", "https://example.com/page"},
+		// Objects stored purely as redirects have no data.
+		{"moved.html", "", "/docs/"},
+		{"blog/index.html", "", "https://blog.example.com/"},
+	}
+	for _, object := range objects {
+		header := http.Header{}
+		if object.location != "" {
+			header.Set("X-Amz-Website-Redirect-Location", object.location)
+		}
+		if rec := serveRequest("PUT", getPutObjectURL("", bucketName, object.name), []byte(object.data), header); rec.Code != http.StatusOK {
+			t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+		}
+	}
+
+	// Redirect objects are accepted below the minimum object size of
+	// the bucket, unlike the other empty objects.
+	globalBucketObjectSizeLimits.Set(bucketName, &ObjectSizeLimitsConfiguration{MinSize: 10})
+	header := http.Header{}
+	header.Set("X-Amz-Website-Redirect-Location", "/index.html")
+	if rec := serveRequest("PUT", getPutObjectURL("", bucketName, "home.html"), nil, header); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if rec := serveRequest("PUT", getPutObjectURL("", bucketName, "empty.html"), nil, nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+	globalBucketObjectSizeLimits.Set(bucketName, nil)
+
+	// Redirect location must be a path or a http(s) URL.
+	header = http.Header{}
+	header.Set("X-Amz-Website-Redirect-Location", "ftp://example.com/page")
+	if rec := serveRequest("PUT", getPutObjectURL("", bucketName, "invalid.html"), []byte("data"), header); rec.Code != http.StatusBadRequest {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusBadRequest, rec.Code)
+	}
+
+	// Redirect location is returned as is on direct GET.
+	rec := serveRequest("GET", getGetObjectURL("", bucketName, "old.html"), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	if location := rec.Header().Get("X-Amz-Website-Redirect-Location"); location != "/new.html" {
+		t.Errorf("%s: Expected redirect location /new.html, got %s", instanceType, location)
+	}
+	if rec.Body.String() != "<html>old</html>" {
+		t.Errorf("%s: Unexpected object data %s", instanceType, rec.Body.String())
+	}
+	rec = serveRequest("GET", getGetObjectURL("", bucketName, "moved.html"), nil, nil)
+	if rec.Code != http.StatusOK || rec.Body.Len() != 0 {
+		t.Fatalf("%s: Expected status %d without data, got %d with %d bytes", instanceType, http.StatusOK, rec.Code, rec.Body.Len())
+	}
+	if location := rec.Header().Get("X-Amz-Website-Redirect-Location"); location != "/docs/" {
+		t.Errorf("%s: Expected redirect location /docs/, got %s", instanceType, location)
+	}
+
+	// Website is not configured by default.
+	if rec = serveRequest("GET", getBucketWebsiteURL("", bucketName), nil, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+	if rec = serveWebsiteRequest("GET", "/"); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+
+	// Invalid website configs.
+	invalidConfigs := []struct {
+		config         string
+		expectedStatus int
+	}{
+		{`<WebsiteConfiguration><IndexDocument>`, http.StatusBadRequest},
+		{`<WebsiteConfiguration></WebsiteConfiguration>`, http.StatusBadRequest},
+		{`<WebsiteConfiguration><IndexDocument><Suffix>a/index.html</Suffix></IndexDocument></WebsiteConfiguration>`, http.StatusBadRequest},
+		{`<WebsiteConfiguration><RedirectAllRequestsTo><HostName>example.com</HostName></RedirectAllRequestsTo></WebsiteConfiguration>`, http.StatusNotImplemented},
+	}
+	for i, testCase := range invalidConfigs {
+		if rec = serveRequest("PUT", getBucketWebsiteURL("", bucketName), []byte(testCase.config), nil); rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+
+	config := `<WebsiteConfiguration xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>error.html</Key></ErrorDocument></WebsiteConfiguration>`
+	if rec = serveRequest("PUT", getBucketWebsiteURL("", bucketName), []byte(config), nil); rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	rec = serveRequest("GET", getBucketWebsiteURL("", bucketName), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusOK, rec.Code)
+	}
+	var website WebsiteConfiguration
+	if err := xml.Unmarshal(rec.Body.Bytes(), &website); err != nil {
+		t.Fatalf("%s: Unexpected XML received %s", instanceType, err)
+	}
+	if website.IndexDocument == nil || website.IndexDocument.Suffix != "index.html" ||
+		website.ErrorDocument == nil || website.ErrorDocument.Key != "error.html" {
+		t.Fatalf("%s: Unexpected website config %#v", instanceType, website)
+	}
+
+	// Objects are not served unless the bucket policy allows it.
+	if rec = serveWebsiteRequest("GET", "/index.html"); rec.Code != http.StatusForbidden {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusForbidden, rec.Code)
+	}
+
+	policy := bucketPolicy{
+		Version:    "1.0",
+		Statements: []policyStatement{getReadOnlyObjectStatement(bucketName, "")},
+	}
+	globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{false, &policy})
+	defer globalBucketPolicies.SetBucketPolicy(bucketName, policyChange{true, nil})
+
+	testCases := []struct {
+		method           string
+		object           string
+		expectedStatus   int
+		expectedLocation string
+		expectedBody     string
+	}{
+		// Test case - 1.
+		// Website root is served with the index document.
+		{"GET", "", http.StatusOK, "", "<html>index</html>"},
+		// Test case - 2.
+		{"GET", "/", http.StatusOK, "", "<html>index</html>"},
+		// Test case - 3.
+		// Subdirectory is served with its index document.
+		{"GET", "/docs/", http.StatusOK, "", "<html>docs</html>"},
+		// Test case - 4.
+		{"GET", "/index.html", http.StatusOK, "", "<html>index</html>"},
+		// Test case - 5.
+		// Redirect path is relative to the website root.
+		{"GET", "/old.html", http.StatusMovedPermanently, reservedBucket + websitePath + "/" + bucketName + "/new.html", ""},
+		// Test case - 6.
+		{"HEAD", "/old.html", http.StatusMovedPermanently, reservedBucket + websitePath + "/" + bucketName + "/new.html", ""},
+		// Test case - 7.
+		{"GET", "/external.html", http.StatusMovedPermanently, "https://example.com/page", ""},
+		// Test case - 8.
+		// Zero-byte redirect objects.
+		{"GET", "/moved.html", http.StatusMovedPermanently, reservedBucket + websitePath + "/" + bucketName + "/docs/", ""},
+		// Test case - 9.
+		{"HEAD", "/moved.html", http.StatusMovedPermanently, reservedBucket + websitePath + "/" + bucketName + "/docs/", ""},
+		// Test case - 10.
+		// Index document stored as a redirect.
+		{"GET", "/blog/", http.StatusMovedPermanently, "https://blog.example.com/", ""},
+		// Test case - 11.
+		// Missing object is served with the error document.
+		{"GET", "/missing.html", http.StatusNotFound, "", "<html>error</html>"},
+	}
+	for i, testCase := range testCases {
+		rec = serveWebsiteRequest(testCase.method, testCase.object)
+		if rec.Code != testCase.expectedStatus {
+			t.Fatalf("%s: Test %d: Expected status %d, got %d", instanceType, i+1, testCase.expectedStatus, rec.Code)
+		}
+		if location := rec.Header().Get("Location"); location != testCase.expectedLocation {
+			t.Errorf("%s: Test %d: Expected location %s, got %s", instanceType, i+1, testCase.expectedLocation, location)
+		}
+		if testCase.expectedBody != "" && rec.Body.String() != testCase.expectedBody {
+			t.Errorf("%s: Test %d: Expected body %s, got %s", instanceType, i+1, testCase.expectedBody, rec.Body.String())
+		}
+		if testCase.expectedStatus == http.StatusMovedPermanently && bytes.Contains(rec.Body.Bytes(), []byte("<html>old</html>")) {
+			t.Errorf("%s: Test %d: Expected the redirect without the object data", instanceType, i+1)
+		}
+	}
+
+	// Delete website.
+	if rec = serveRequest("DELETE", getBucketWebsiteURL("", bucketName), nil, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNoContent, rec.Code)
+	}
+	if rec = serveWebsiteRequest("GET", "/index.html"); rec.Code != http.StatusNotFound {
+		t.Fatalf("%s: Expected status %d, got %d", instanceType, http.StatusNotFound, rec.Code)
+	}
+}
@@ -36,6 +36,11 @@ func (xl xlObjects) MakeBucket(bucket string) error {
 		return traceError(BucketNameInvalid{Bucket: bucket})
 	}
 
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return toObjectErr(err, bucket)
+	}
+
 	// Initialize sync waitgroup.
 	var wg = &sync.WaitGroup{}
 
@@ -143,6 +148,11 @@ func (xl xlObjects) GetBucketInfo(bucket string) (BucketInfo, error) {
 		return BucketInfo{}, BucketNameInvalid{Bucket: bucket}
 	}
 
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return BucketInfo{}, toObjectErr(err, bucket)
+	}
+
 	bucketInfo, err := xl.getBucketInfo(bucket)
 	if err != nil {
 		return BucketInfo{}, toObjectErr(err, bucket)
@@ -197,6 +207,10 @@ func (xl xlObjects) listBuckets() (bucketsInfo []BucketInfo, err error) {
 
 // ListBuckets - lists all the buckets, sorted by its name.
 func (xl xlObjects) ListBuckets() ([]BucketInfo, error) {
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return nil, toObjectErr(err)
+	}
 	bucketInfos, err := xl.listBuckets()
 	if err != nil {
 		return nil, toObjectErr(err)
@@ -213,6 +227,11 @@ func (xl xlObjects) DeleteBucket(bucket string) error {
 		return BucketNameInvalid{Bucket: bucket}
 	}
 
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return toObjectErr(err, bucket)
+	}
+
 	// Collect if all disks report volume not found.
 	var wg = &sync.WaitGroup{}
 	var dErrs = make([]error, len(xl.storageDisks))
@@ -0,0 +1,184 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"io"
+	"path"
+	"strconv"
+)
+
+// isFragmentedObject - an object is fragmented when it has at least the
+// minimum number of parts, and more parts than the same object written
+// by a PutObject.
+func isFragmentedObject(xlMeta xlMetaV1) bool {
+	size := xlMeta.Stat.Size
+	if size <= 0 || len(xlMeta.Parts) < globalCompactionMinParts {
+		return false
+	}
+	partsCount := (size + globalPutPartSize - 1) / globalPutPartSize
+	return int64(len(xlMeta.Parts)) > partsCount
+}
+
+// readCompactionMeta - reads the `xl.json` of the object, ok is false
+// unless all the disks hold the same version of the object. Objects
+// being healed are left alone, the heals do not tell the compacted
+// parts apart from the old ones as the modification time is kept.
+func (xl xlObjects) readCompactionMeta(bucket, object string) (xlMeta xlMetaV1, ok bool, err error) {
+	metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
+		return xlMetaV1{}, false, toObjectErr(reducedErr, bucket, object)
+	}
+	for _, err = range errs {
+		if err != nil {
+			return xlMetaV1{}, false, nil
+		}
+	}
+	onlineDisks, modTime := listOnlineDisks(xl.storageDisks, metaArr, errs)
+	for _, disk := range onlineDisks {
+		if disk == nil {
+			return xlMetaV1{}, false, nil
+		}
+	}
+	xlMeta, err = pickValidXLMeta(metaArr, modTime)
+	if err != nil {
+		return xlMetaV1{}, false, err
+	}
+	return xlMeta, true, nil
+}
+
+// compactObject - rewrites a fragmented object into parts of the maximum
+// size. The ETag, size, modification time and metadata of the object are
+// kept so that the clients do not see the compaction. The new parts are
+// written while the object is read locked, they replace the old ones
+// under the write lock only if the object was not changed meanwhile.
+// Returns true if the object was compacted.
+func (xl xlObjects) compactObject(bucket, object string, limiter *healBandwidthLimiter) (bool, error) {
+	objectLock := globalNSMutex.NewNSLock(bucket, object)
+	objectLock.RLock()
+	xlMeta, ok, err := xl.readCompactionMeta(bucket, object)
+	if err != nil || !ok || !isFragmentedObject(xlMeta) {
+		objectLock.RUnlock()
+		return false, err
+	}
+
+	// Delete the temporary object in the event of failure.
+	tempObj := mustGetUUID()
+	defer xl.deleteObject(minioMetaTmpBucket, tempObj)
+
+	partsMetadata, onlineDisks, err := xl.writeCompactedParts(bucket, object, xlMeta, tempObj, limiter)
+	objectLock.RUnlock()
+	if err != nil {
+		return false, err
+	}
+
+	objectLock.Lock()
+	defer objectLock.Unlock()
+
+	// The new parts are dropped if the object was changed meanwhile.
+	currentMeta, ok, err := xl.readCompactionMeta(bucket, object)
+	if err != nil || !ok {
+		return false, err
+	}
+	if !currentMeta.Stat.ModTime.Equal(xlMeta.Stat.ModTime) || currentMeta.Stat.Size != xlMeta.Stat.Size ||
+		currentMeta.Meta["md5Sum"] != xlMeta.Meta["md5Sum"] || len(currentMeta.Parts) != len(xlMeta.Parts) {
+		return false, nil
+	}
+
+	// All the disks are written, a disk left with the old parts would
+	// not be healed.
+	quorum := len(xl.storageDisks)
+	if err = writeUniqueXLMetadata(onlineDisks, minioMetaTmpBucket, tempObj, partsMetadata, quorum); err != nil {
+		return false, toObjectErr(err, bucket, object)
+	}
+
+	// Move the old parts aside, they are put back if the new ones fail
+	// to be moved in place.
+	oldObj := mustGetUUID()
+	defer xl.deleteObject(minioMetaTmpBucket, oldObj)
+	if err = renameObject(xl.storageDisks, bucket, object, minioMetaTmpBucket, oldObj, quorum); err != nil {
+		return false, toObjectErr(err, bucket, object)
+	}
+	if err = renameObject(onlineDisks, minioMetaTmpBucket, tempObj, bucket, object, quorum); err != nil {
+		errorIf(renameObject(xl.storageDisks, minioMetaTmpBucket, oldObj, bucket, object, quorum),
+			"Unable to restore %s/%s after a failed compaction.", bucket, object)
+		return false, toObjectErr(err, bucket, object)
+	}
+	return true, nil
+}
+
+// writeCompactedParts - writes the data of the object read back into
+// parts of the maximum size under the temporary object, the reads are
+// throttled by the limiter. Returns the `xl.json` of each disk along
+// with the disks, both in the order of the erasure distribution.
+func (xl xlObjects) writeCompactedParts(bucket, object string, xlMeta xlMetaV1, tempObj string, limiter *healBandwidthLimiter) ([]xlMetaV1, []StorageAPI, error) {
+	newMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	newMeta.Stat = xlMeta.Stat
+	newMeta.Meta = xlMeta.Meta
+
+	partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
+	for index := range partsMetadata {
+		partsMetadata[index] = newMeta
+	}
+	onlineDisks := getOrderedDisks(newMeta.Erasure.Distribution, xl.storageDisks)
+
+	pipeReader, pipeWriter := io.Pipe()
+	defer pipeReader.Close()
+	go func() {
+		pipeWriter.CloseWithError(xl.GetObject(bucket, object, 0, xlMeta.Stat.Size, pipeWriter))
+	}()
+	reader := compactionReader{pipeReader, limiter}
+
+	size := xlMeta.Stat.Size
+	for partIdx := 1; ; partIdx++ {
+		partSize, err := getPartSizeFromIdx(size, globalPutPartSize, partIdx)
+		if err != nil {
+			return nil, nil, toObjectErr(err, bucket, object)
+		}
+		if partSize == 0 {
+			break
+		}
+		partName := "part." + strconv.Itoa(partIdx)
+		tempErasureObj := path.Join(tempObj, partName)
+
+		// Prepare file for eventual optimization in the disk
+		actualSize := xl.sizeOnDisk(partSize, newMeta.Erasure.BlockSize, newMeta.Erasure.DataBlocks)
+		for _, disk := range onlineDisks {
+			if disk != nil {
+				disk.PrepareFile(minioMetaTmpBucket, tempErasureObj, actualSize)
+			}
+		}
+
+		partSizeWritten, checkSums, err := erasureCreateFile(onlineDisks, minioMetaTmpBucket, tempErasureObj, io.LimitReader(reader, partSize), false, newMeta.Erasure.BlockSize, newMeta.Erasure.DataBlocks, newMeta.Erasure.ParityBlocks, bitRotAlgo, len(xl.storageDisks))
+		if err != nil {
+			return nil, nil, toObjectErr(err, minioMetaTmpBucket, tempErasureObj)
+		}
+		if partSizeWritten < partSize {
+			return nil, nil, traceError(IncompleteBody{})
+		}
+
+		for index := range partsMetadata {
+			partsMetadata[index].AddObjectPart(partIdx, partName, "", partSizeWritten)
+			partsMetadata[index].Erasure.AddCheckSumInfo(checkSumInfo{
+				Name:      partName,
+				Hash:      checkSums[index],
+				Algorithm: bitRotAlgo,
+			})
+		}
+	}
+	return partsMetadata, onlineDisks, nil
+}
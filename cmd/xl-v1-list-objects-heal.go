@@ -58,9 +58,9 @@ func listDirHealFactory(isLeaf isLeafFunc, disks ...StorageAPI) listDirFunc {
 			// find elements in entries which are not in mergedentries
 			for _, entry := range entries {
 				idx := sort.SearchStrings(mergedEntries, entry)
-				// idx different from len(mergedEntries) means entry is not found
-				// in mergedEntries
-				if idx < len(mergedEntries) {
+				// idx is where entry would be inserted, entry is only
+				// found in mergedEntries if it is already there.
+				if idx < len(mergedEntries) && mergedEntries[idx] == entry {
 					continue
 				}
 				newEntries = append(newEntries, entry)
@@ -177,6 +177,13 @@ func (xl xlObjects) ListObjectsHeal(bucket, prefix, marker, delimiter string, ma
 		return ListObjectsInfo{}, err
 	}
 
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != slashSeparator {
+		return ListObjectsInfo{}, traceError(UnsupportedDelimiter{
+			Delimiter: delimiter,
+		})
+	}
+
 	// With max keys of zero we have reached eof, return right here.
 	if maxKeys == 0 {
 		return ListObjectsInfo{}, nil
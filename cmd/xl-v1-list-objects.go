@@ -126,8 +126,13 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 	return result, nil
 }
 
-// ListObjects - list all objects at prefix, delimited by '/'.
+// ListObjects - list all objects at prefix, optionally delimited by delimiter.
 func (xl xlObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return ListObjectsInfo{}, toObjectErr(err, bucket)
+	}
+
 	if err := checkListObjsArgs(bucket, prefix, marker, delimiter, xl); err != nil {
 		return ListObjectsInfo{}, err
 	}
@@ -150,6 +155,18 @@ func (xl xlObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKey
 		maxKeys = maxObjectList
 	}
 
+	// Delimiters other than '/' are rolled up over a recursive listing.
+	if delimiter != "" && delimiter != slashSeparator {
+		listFn := func(marker string, maxKeys int) (ListObjectsInfo, error) {
+			return xl.listObjects(bucket, prefix, marker, "", maxKeys)
+		}
+		listObjInfo, err := listObjectsWithDelimiter(listFn, prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			return ListObjectsInfo{}, toObjectErr(err, bucket, prefix)
+		}
+		return listObjInfo, nil
+	}
+
 	// Initiate a list operation, if successful filter and return quickly.
 	listObjInfo, err := xl.listObjects(bucket, prefix, marker, delimiter, maxKeys)
 	if err == nil {
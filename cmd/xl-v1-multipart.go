@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"hash"
@@ -448,6 +447,11 @@ func (xl xlObjects) listMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 // ListMultipartsInfo structure is unmarshalled directly into XML and
 // replied back to the client.
 func (xl xlObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return ListMultipartsInfo{}, toObjectErr(err, bucket)
+	}
+
 	if err := checkListMultipartArgs(bucket, prefix, keyMarker, uploadIDMarker, delimiter, xl); err != nil {
 		return ListMultipartsInfo{}, err
 	}
@@ -520,6 +524,11 @@ func (xl xlObjects) newMultipartUpload(bucket string, object string, meta map[st
 //
 // Implements S3 compatible initiate multipart API.
 func (xl xlObjects) NewMultipartUpload(bucket, object string, meta map[string]string) (string, error) {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
 	if err := checkNewMultipartArgs(bucket, object, xl); err != nil {
 		return "", err
 	}
@@ -536,6 +545,11 @@ func (xl xlObjects) NewMultipartUpload(bucket, object string, meta map[string]st
 //
 // Implements S3 compatible Upload Part Copy API.
 func (xl xlObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, uploadID string, partID int, startOffset int64, length int64) (PartInfo, error) {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return PartInfo{}, toObjectErr(err, dstBucket, dstObject)
+	}
+
 	if err := checkNewMultipartArgs(srcBucket, srcObject, xl); err != nil {
 		return PartInfo{}, err
 	}
@@ -571,6 +585,11 @@ func (xl xlObjects) CopyObjectPart(srcBucket, srcObject, dstBucket, dstObject, u
 //
 // Implements S3 compatible Upload Part API.
 func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string, sha256sum string) (PartInfo, error) {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return PartInfo{}, toObjectErr(err, bucket, object)
+	}
+
 	if err := checkPutObjectPartArgs(bucket, object, xl); err != nil {
 		return PartInfo{}, err
 	}
@@ -617,10 +636,13 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	tmpPart := mustGetUUID()
 	tmpPartPath := path.Join(tmpPart, partSuffix)
 
-	// Initialize md5 writer.
-	md5Writer := md5.New()
+	// Initialize ETag writer.
+	etagWriter := newETagWriter(md5Hex != "")
+
+	// Validate the content while it is written.
+	validation := newContentValidation(bucket, object)
 
-	writers := []io.Writer{md5Writer}
+	writers := []io.Writer{etagWriter, validation}
 
 	var sha256Writer hash.Hash
 	if sha256sum != "" {
@@ -677,14 +699,14 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 		size = sizeWritten
 	}
 
-	// Calculate new md5sum.
-	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
+	// Verify the md5sum sent by the client.
 	if md5Hex != "" {
-		if newMD5Hex != md5Hex {
+		if newMD5Hex := etagWriter.MD5Hex(); newMD5Hex != md5Hex {
 			// Returns md5 mismatch.
 			return PartInfo{}, traceError(BadDigest{md5Hex, newMD5Hex})
 		}
 	}
+	newETag := etagWriter.ETag()
 
 	if sha256sum != "" {
 		newSHA256sum := hex.EncodeToString(sha256Writer.Sum(nil))
@@ -693,6 +715,10 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 		}
 	}
 
+	if err = validation.Validate(); err != nil {
+		return PartInfo{}, err
+	}
+
 	// post-upload check (write) lock
 	postUploadIDLock := globalNSMutex.NewNSLock(minioMetaMultipartBucket, uploadIDPath)
 	postUploadIDLock.Lock()
@@ -730,7 +756,7 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	xlMeta.Stat.ModTime = time.Now().UTC()
 
 	// Add the current part.
-	xlMeta.AddObjectPart(partID, partSuffix, newMD5Hex, size)
+	xlMeta.AddObjectPart(partID, partSuffix, newETag, size)
 
 	for index, disk := range onlineDisks {
 		if disk == nil {
@@ -766,7 +792,7 @@ func (xl xlObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	return PartInfo{
 		PartNumber:   partID,
 		LastModified: fi.ModTime,
-		ETag:         newMD5Hex,
+		ETag:         newETag,
 		Size:         fi.Size,
 	}, nil
 }
@@ -842,6 +868,11 @@ func (xl xlObjects) listObjectParts(bucket, object, uploadID string, partNumberM
 // ListPartsInfo structure is unmarshalled directly into XML and
 // replied back to the client.
 func (xl xlObjects) ListObjectParts(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, error) {
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return ListPartsInfo{}, toObjectErr(err, bucket, object)
+	}
+
 	if err := checkListPartsArgs(bucket, object, xl); err != nil {
 		return ListPartsInfo{}, err
 	}
@@ -867,6 +898,11 @@ func (xl xlObjects) ListObjectParts(bucket, object, uploadID string, partNumberM
 //
 // Implements S3 compatible Complete multipart API.
 func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, uploadID string, parts []completePart) (ObjectInfo, error) {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
 	if err := checkCompleteMultipartArgs(bucket, object, xl); err != nil {
 		return ObjectInfo{}, err
 	}
@@ -1139,6 +1175,11 @@ func (xl xlObjects) abortMultipartUpload(bucket, object, uploadID string) (err e
 // that this is an atomic idempotent operation. Subsequent calls have
 // no affect and further requests to the same uploadID would not be honored.
 func (xl xlObjects) AbortMultipartUpload(bucket, object, uploadID string) error {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
 	if err := checkAbortMultipartArgs(bucket, object, xl); err != nil {
 		return err
 	}
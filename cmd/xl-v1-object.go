@@ -17,7 +17,6 @@
 package cmd
 
 import (
-	"crypto/md5"
 	"encoding/hex"
 	"hash"
 	"io"
@@ -42,6 +41,11 @@ var objectOpIgnoredErrs = append(baseIgnoredErrs, errDiskAccessDenied)
 // if source object and destination object are same we only
 // update metadata.
 func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (ObjectInfo, error) {
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return ObjectInfo{}, toObjectErr(err, dstBucket, dstObject)
+	}
+
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := readAllXLMetadata(xl.storageDisks, srcBucket, srcObject)
 	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
@@ -67,11 +71,12 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 	cpMetadataOnly := strings.EqualFold(pathJoin(srcBucket, srcObject), pathJoin(dstBucket, dstObject))
 	if cpMetadataOnly {
 		xlMeta.Meta = metadata
-		partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
-		// Update `xl.json` content on each disks.
-		for index := range partsMetadata {
-			partsMetadata[index] = xlMeta
+		// Update `xl.json` content on each disks, every disk keeps its
+		// own erasure index and checksums.
+		for index := range metaArr {
+			metaArr[index].Meta = metadata
 		}
+		partsMetadata := getOrderedPartsMetadata(xlMeta.Erasure.Distribution, metaArr)
 
 		tempObj := mustGetUUID()
 
@@ -102,27 +107,24 @@ func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string
 		return objInfo, nil
 	}
 
-	// Initialize pipe.
-	pipeReader, pipeWriter := io.Pipe()
-
-	go func() {
-		startOffset := int64(0) // Read the whole file.
-		if gerr := xl.GetObject(srcBucket, srcObject, startOffset, length, pipeWriter); gerr != nil {
-			errorIf(gerr, "Unable to read %s of the object `%s/%s`.", srcBucket, srcObject)
-			pipeWriter.CloseWithError(toObjectErr(gerr, srcBucket, srcObject))
-			return
+	// Large objects are read in chunks concurrently, all of them from
+	// the metadata read above.
+	partsMetadata := getOrderedPartsMetadata(xlMeta.Erasure.Distribution, metaArr)
+	reader := newCopyReader(func(startOffset, length int64, writer io.Writer) error {
+		if gerr := xl.getObject(srcBucket, srcObject, xlMeta, partsMetadata, onlineDisks, startOffset, length, writer); gerr != nil {
+			errorIf(gerr, "Unable to read the object `%s/%s`.", srcBucket, srcObject)
+			return toObjectErr(gerr, srcBucket, srcObject)
 		}
-		pipeWriter.Close() // Close writer explicitly signalling we wrote all data.
-	}()
+		return nil
+	}, length)
 
-	objInfo, err := xl.PutObject(dstBucket, dstObject, length, pipeReader, metadata, "")
+	objInfo, err := xl.PutObject(dstBucket, dstObject, length, reader, metadata, "")
+	// Explicitly close the reader.
+	reader.Close()
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, dstBucket, dstObject)
 	}
 
-	// Explicitly close the reader.
-	pipeReader.Close()
-
 	return objInfo, nil
 }
 
@@ -147,6 +149,11 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 		return traceError(errUnexpected)
 	}
 
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
 	if reducedErr := reduceReadQuorumErrs(errs, objectOpIgnoredErrs, xl.readQuorum); reducedErr != nil {
@@ -168,6 +175,12 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	// Reorder parts metadata based on erasure distribution order.
 	metaArr = getOrderedPartsMetadata(xlMeta.Erasure.Distribution, metaArr)
 
+	return xl.getObject(bucket, object, xlMeta, metaArr, onlineDisks, startOffset, length, writer)
+}
+
+// getObject - reads an object from its metadata already read, metaArr
+// and onlineDisks being ordered by the erasure distribution of xlMeta.
+func (xl xlObjects) getObject(bucket, object string, xlMeta xlMetaV1, metaArr []xlMetaV1, onlineDisks []StorageAPI, startOffset int64, length int64, writer io.Writer) (err error) {
 	// For negative length read everything.
 	if length < 0 {
 		length = xlMeta.Stat.Size - startOffset
@@ -203,7 +216,7 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	if xlMeta.Stat.Size > 0 && xl.objCacheEnabled {
 		// Validate if we have previous cache.
 		var cachedBuffer io.ReadSeeker
-		cachedBuffer, err = xl.objCache.Open(path.Join(bucket, object), modTime)
+		cachedBuffer, err = xl.objCache.Open(path.Join(bucket, object), xlMeta.Stat.ModTime)
 		if err == nil { // Cache hit.
 			// Advance the buffer to offset as if it was read.
 			if _, err = cachedBuffer.Seek(startOffset, 0); err != nil { // Seek to the offset.
@@ -242,9 +255,9 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	totalBytesRead := int64(0)
 
 	chunkSize := getChunkSize(xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks)
-	pool := bpool.NewBytePool(chunkSize, len(onlineDisks))
 
-	// Read from all parts.
+	// Collect all the part reads needed to serve the requested range.
+	var partReads []partReadFn
 	for ; partIndex <= lastPartIndex; partIndex++ {
 		if length == totalBytesRead {
 			break
@@ -278,20 +291,41 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 			}
 		}
 
-		// Start erasure decoding and writing to the client.
-		n, err := erasureReadFile(mw, onlineDisks, bucket, pathJoin(object, partName), partOffset, readSize, partSize, xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, checkSums, ckSumAlgo, pool)
-		if err != nil {
-			errorIf(err, "Unable to read %s of the object `%s/%s`.", partName, bucket, object)
-			return toObjectErr(err, bucket, object)
-		}
+		readOffset := partOffset
+		// Each part read uses its own copy of the disks, erasure reads
+		// drop the disks which failed from it while parts may be read
+		// concurrently.
+		partDisks := append([]StorageAPI(nil), onlineDisks...)
+		partReads = append(partReads, func(w io.Writer) error {
+			// Each part read uses its own pool, since parts may be read concurrently.
+			pool := bpool.NewBytePool(chunkSize, len(partDisks))
+
+			// Start erasure decoding and writing to the client.
+			_, rerr := erasureReadFile(w, partDisks, bucket, pathJoin(object, partName), readOffset, readSize, partSize, xlMeta.Erasure.BlockSize, xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks, checkSums, ckSumAlgo, pool)
+			// Parts read ahead are aborted once the client went away.
+			if rerr != nil && errorCause(rerr) != errReadAheadAborted {
+				errorIf(rerr, "Unable to read %s of the object `%s/%s`.", partName, bucket, object)
+			}
+			return rerr
+		})
 
-		// Track total bytes read from disk and written to the client.
-		totalBytesRead += n
+		// Track total bytes to be read from disk and written to the client.
+		totalBytesRead += readSize
 
 		// partOffset will be valid only for the first part, hence reset it to 0 for
 		// the remaining parts.
 		partOffset = 0
-	} // End of read all parts loop.
+	} // End of collect all part reads loop.
+
+	// Reading a single part needs no read ahead, write directly to the client.
+	if len(partReads) == 1 {
+		err = partReads[0](mw)
+	} else {
+		err = readPartsAhead(mw, partReads, xlReadAheadParts)
+	}
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
 
 	// Return success.
 	return nil
@@ -303,6 +337,11 @@ func (xl xlObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 		return ObjectInfo{}, err
 	}
 
+	// Fail fast when read quorum is lost.
+	if err := xl.checkReadQuorum(); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
 	info, err := xl.getObjectInfo(bucket, object)
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
@@ -438,9 +477,15 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	if isObjectDir(object, size) {
 		return dirObjectInfo(bucket, object, size, metadata), nil
 	}
+	// Fail fast when write quorum is lost.
+	if err = xl.checkWriteQuorum(); err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+
 	if err = checkPutObjectArgs(bucket, object, xl); err != nil {
 		return ObjectInfo{}, err
 	}
+
 	// No metadata is set, allocate a new one.
 	if metadata == nil {
 		metadata = make(map[string]string)
@@ -449,10 +494,13 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	uniqueID := mustGetUUID()
 	tempObj := uniqueID
 
-	// Initialize md5 writer.
-	md5Writer := md5.New()
+	// Initialize ETag writer.
+	etagWriter := newETagWriter(metadata["md5Sum"] != "")
+
+	// Validate the content while it is written.
+	validation := newContentValidation(bucket, object)
 
-	writers := []io.Writer{md5Writer}
+	writers := []io.Writer{etagWriter, validation}
 
 	var sha256Writer hash.Hash
 	if sha256sum != "" {
@@ -611,12 +659,6 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	// Save additional erasureMetadata.
 	modTime := time.Now().UTC()
 
-	newMD5Hex := hex.EncodeToString(md5Writer.Sum(nil))
-	// Update the md5sum if not set with the newly calculated one.
-	if len(metadata["md5Sum"]) == 0 {
-		metadata["md5Sum"] = newMD5Hex
-	}
-
 	// Guess content-type from the extension if possible.
 	if metadata["content-type"] == "" {
 		if objectExt := path.Ext(object); objectExt != "" {
@@ -629,11 +671,13 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	// md5Hex representation.
 	md5Hex := metadata["md5Sum"]
 	if md5Hex != "" {
-		if newMD5Hex != md5Hex {
+		if newMD5Hex := etagWriter.MD5Hex(); newMD5Hex != md5Hex {
 			// Returns md5 mismatch.
 			return ObjectInfo{}, traceError(BadDigest{md5Hex, newMD5Hex})
 		}
 	}
+	// Save the newly calculated ETag.
+	metadata["md5Sum"] = etagWriter.ETag()
 
 	if sha256sum != "" {
 		newSHA256sum := hex.EncodeToString(sha256Writer.Sum(nil))
@@ -642,6 +686,10 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		}
 	}
 
+	if err = validation.Validate(); err != nil {
+		return ObjectInfo{}, err
+	}
+
 	// Check if an object is present as one of the parent dir.
 	// -- FIXME. (needs a new kind of lock).
 	if xl.parentDirIsObject(bucket, path.Dir(object)) {
@@ -739,6 +787,57 @@ func (xl xlObjects) deleteObject(bucket, object string) error {
 	return reduceWriteQuorumErrs(dErrs, objectOpIgnoredErrs, xl.writeQuorum)
 }
 
+// asyncDeleteObject - moves the object to the temporary bucket on all
+// disks, the object is gone once moved and its parts are removed later
+// by the async deleter.
+func (xl xlObjects) asyncDeleteObject(bucket, object string) error {
+	tmpObj := mustGetUUID()
+	if err := renameObject(xl.storageDisks, bucket, object, minioMetaTmpBucket, tmpObj, xl.writeQuorum); err != nil {
+		return err
+	}
+	globalAsyncDeleter.Delete(func() error {
+		return xl.deleteObject(minioMetaTmpBucket, tmpObj)
+	})
+	return nil
+}
+
+// RenameObject - moves an object along with its metadata to another
+// bucket or name on all disks, its data is not copied. The destination
+// object must not exist.
+func (xl xlObjects) RenameObject(srcBucket, srcObject, dstBucket, dstObject string) (ObjectInfo, error) {
+	if err := checkDelObjArgs(srcBucket, srcObject); err != nil {
+		return ObjectInfo{}, err
+	}
+	if err := checkBucketAndObjectNames(dstBucket, dstObject); err != nil {
+		return ObjectInfo{}, err
+	}
+
+	// Fail fast when write quorum is lost.
+	if err := xl.checkWriteQuorum(); err != nil {
+		return ObjectInfo{}, toObjectErr(err, srcBucket, srcObject)
+	}
+
+	// Validate object exists.
+	if !xl.isObject(srcBucket, srcObject) {
+		return ObjectInfo{}, traceError(ObjectNotFound{srcBucket, srcObject})
+	}
+
+	if err := renameObject(xl.storageDisks, srcBucket, srcObject, dstBucket, dstObject, xl.writeQuorum); err != nil {
+		return ObjectInfo{}, toObjectErr(err, dstBucket, dstObject)
+	}
+
+	if xl.objCacheEnabled {
+		// Delete from the cache.
+		xl.objCache.Delete(pathJoin(srcBucket, srcObject))
+	}
+
+	objInfo, err := xl.getObjectInfo(dstBucket, dstObject)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, dstBucket, dstObject)
+	}
+	return objInfo, nil
+}
+
 // DeleteObject - deletes an object, this call doesn't necessary reply
 // any error as it is not necessary for the handler to reply back a
 // response to the client request.
@@ -747,13 +846,28 @@ func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
 		return err
 	}
 
+	// Fail fast when write quorum is lost.
+	if err = xl.checkWriteQuorum(); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
 	// Validate object exists.
 	if !xl.isObject(bucket, object) {
 		return traceError(ObjectNotFound{bucket, object})
 	} // else proceed to delete the object.
 
-	// Delete the object on all disks.
-	err = xl.deleteObject(bucket, object)
+	// Delete the object on all disks, large objects are moved to the
+	// temporary bucket and removed asynchronously.
+	var objInfo ObjectInfo
+	if globalAsyncDeleteMinSize > 0 {
+		// Unreadable metadata deletes the object synchronously.
+		objInfo, _ = xl.getObjectInfo(bucket, object)
+	}
+	if isAsyncDelete(bucket, objInfo.Size) {
+		err = xl.asyncDeleteObject(bucket, object)
+	} else {
+		err = xl.deleteObject(bucket, object)
+	}
 	if err != nil {
 		return toObjectErr(err, bucket, object)
 	}
@@ -362,3 +362,59 @@ func TestHealing(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// Tests metadata-only copies keep the erasure index and checksums of
+// every disk, the object stays readable with its new metadata.
+func TestXLCopyObjectMetadataOnly(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(*xlObjects)
+
+	bucket := "bucket"
+	object := "object"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 1*humanize.MiByte)
+	if _, err = rand.Read(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+	metaArr, errs := readAllXLMetadata(xl.storageDisks, bucket, object)
+
+	metadata := map[string]string{"content-type": "text/plain", "X-Amz-Meta-Color": "blue"}
+	if _, err = obj.CopyObject(bucket, object, bucket, object, metadata); err != nil {
+		t.Fatal(err)
+	}
+	newMetaArr, newErrs := readAllXLMetadata(xl.storageDisks, bucket, object)
+	for i := range metaArr {
+		if errs[i] != nil || newErrs[i] != nil {
+			t.Fatalf("Disk %d: Unable to read xl.json: %v %v", i+1, errs[i], newErrs[i])
+		}
+		if !reflect.DeepEqual(newMetaArr[i].Erasure, metaArr[i].Erasure) {
+			t.Fatalf("Disk %d: Expected the erasure info %#v, got %#v", i+1, metaArr[i].Erasure, newMetaArr[i].Erasure)
+		}
+		if newMetaArr[i].Meta["X-Amz-Meta-Color"] != "blue" {
+			t.Fatalf("Disk %d: Expected the new metadata, got %#v", i+1, newMetaArr[i].Meta)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, int64(len(data)), &buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Fatal("Contents of the object changed by the copy")
+	}
+}
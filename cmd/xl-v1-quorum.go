@@ -0,0 +1,184 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// QuorumStatus - reports if the backend currently has enough disks
+// online to serve reads and writes, always true for FS.
+type QuorumStatus struct {
+	ReadQuorum  bool `json:"readQuorum"`
+	WriteQuorum bool `json:"writeQuorum"`
+}
+
+// quorumMonitor - caches the number of online disks of an XL backend,
+// so that operations can fail fast once quorum is lost instead of
+// waiting on offline disks. The disks are probed in background at each
+// interval, the operations only read the count of the last probe.
+type quorumMonitor struct {
+	disks    []StorageAPI
+	interval time.Duration
+
+	// Number of online disks found by the last probe, accessed
+	// atomically.
+	onlineDisks int64
+
+	closeOnce sync.Once
+	doneCh    chan struct{}
+}
+
+// newQuorumMonitor - initializes a new quorum monitor for disks, probed
+// once right away and then in background at each interval. The disks
+// are only probed on demand when interval is not positive.
+func newQuorumMonitor(disks []StorageAPI, interval time.Duration) *quorumMonitor {
+	m := &quorumMonitor{
+		disks:    disks,
+		interval: interval,
+		doneCh:   make(chan struct{}),
+	}
+	m.Probe()
+	if interval > 0 {
+		go m.run()
+	}
+	return m
+}
+
+// run - probes the disks at each interval until the monitor is closed.
+func (m *quorumMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.Probe()
+		case <-m.doneCh:
+			return
+		}
+	}
+}
+
+// Probe - probes the disks and caches the number of online disks.
+func (m *quorumMonitor) Probe() {
+	atomic.StoreInt64(&m.onlineDisks, int64(countOnlineDisks(m.disks)))
+}
+
+// OnlineDisks - returns the number of online disks found by the last
+// probe, the disks are probed right away when they are not probed in
+// background.
+func (m *quorumMonitor) OnlineDisks() int {
+	if m.interval <= 0 {
+		m.Probe()
+	}
+	return int(atomic.LoadInt64(&m.onlineDisks))
+}
+
+// Close - stops probing the disks in background.
+func (m *quorumMonitor) Close() {
+	m.closeOnce.Do(func() {
+		close(m.doneCh)
+	})
+}
+
+// countOnlineDisks - probes all the disks in parallel and returns the
+// number of disks which are online.
+func countOnlineDisks(disks []StorageAPI) int {
+	var wg sync.WaitGroup
+	online := make([]bool, len(disks))
+	for index, disk := range disks {
+		if disk == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			_, err := disk.DiskInfo()
+			online[index] = err == nil || !isErr(err, baseErrs...)
+		}(index, disk)
+	}
+	wg.Wait()
+
+	onlineDisks := 0
+	for _, ok := range online {
+		if ok {
+			onlineDisks++
+		}
+	}
+	return onlineDisks
+}
+
+// setQuorumCheckInterval - sets the interval at which the quorum of the
+// XL backend is verified from MINIO_QUORUM_CHECK_INTERVAL env.
+func setQuorumCheckInterval() {
+	if interval := os.Getenv("MINIO_QUORUM_CHECK_INTERVAL"); interval != "" {
+		duration, err := time.ParseDuration(interval)
+		fatalIf(err, "Invalid MINIO_QUORUM_CHECK_INTERVAL value %s.", interval)
+		if duration < 0 {
+			fatalIf(errInvalidArgument, "Invalid MINIO_QUORUM_CHECK_INTERVAL value %s.", interval)
+		}
+		globalQuorumCheckInterval = duration
+	}
+}
+
+// QuorumStatus - returns the current read and write quorum availability,
+// disks are probed when quorum checks are disabled.
+func (xl xlObjects) QuorumStatus() QuorumStatus {
+	if xl.quorum == nil {
+		return QuorumStatus{ReadQuorum: true, WriteQuorum: true}
+	}
+	onlineDisks := xl.quorum.OnlineDisks()
+	return QuorumStatus{
+		ReadQuorum:  onlineDisks >= xl.readQuorum,
+		WriteQuorum: onlineDisks >= xl.writeQuorum,
+	}
+}
+
+// checkReadQuorum - returns errXLReadQuorum right away when not enough
+// disks are online to serve reads.
+func (xl xlObjects) checkReadQuorum() error {
+	if globalQuorumCheckInterval <= 0 {
+		return nil
+	}
+	if !xl.QuorumStatus().ReadQuorum {
+		return traceError(errXLReadQuorum)
+	}
+	return nil
+}
+
+// checkWriteQuorum - returns errXLWriteQuorum right away when not enough
+// disks are online to serve writes.
+func (xl xlObjects) checkWriteQuorum() error {
+	if globalQuorumCheckInterval <= 0 {
+		return nil
+	}
+	if !xl.QuorumStatus().WriteQuorum {
+		return traceError(errXLWriteQuorum)
+	}
+	return nil
+}
+
+// getQuorumStatus - returns the quorum status of the object layer.
+func getQuorumStatus(objLayer ObjectLayer) QuorumStatus {
+	if xl := getXLObjects(objLayer); xl != nil {
+		return xl.QuorumStatus()
+	}
+	return QuorumStatus{ReadQuorum: true, WriteQuorum: true}
+}
@@ -0,0 +1,266 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/minio/minio/pkg/disk"
+)
+
+// offlineDisk - reports the disk as offline while it keeps serving all
+// the other calls, any data written to it shows that the operation did
+// not fail fast.
+type offlineDisk struct {
+	StorageAPI
+}
+
+func (d offlineDisk) DiskInfo() (disk.Info, error) {
+	return disk.Info{}, errDiskNotFound
+}
+
+// failingDisk - reports the disk as offline once failing is set,
+// accessed atomically.
+type failingDisk struct {
+	StorageAPI
+	failing *int32
+}
+
+func (d failingDisk) DiskInfo() (disk.Info, error) {
+	if atomic.LoadInt32(d.failing) == 1 {
+		return disk.Info{}, errDiskNotFound
+	}
+	return d.StorageAPI.DiskInfo()
+}
+
+// Tests that operations fail fast as disks are lost across the write
+// and read quorum thresholds.
+func TestXLQuorumDegradation(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	// Verify quorum on every operation, the disks are probed by the
+	// test.
+	globalQuorumCheckInterval = time.Hour
+	defer func() { globalQuorumCheckInterval = 0 }()
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	defer obj.Shutdown()
+
+	globalObjLayerMutex.Lock()
+	globalObjectAPI = obj
+	globalObjLayerMutex.Unlock()
+	defer func() {
+		globalObjLayerMutex.Lock()
+		globalObjectAPI = nil
+		globalObjLayerMutex.Unlock()
+	}()
+
+	xl := obj.(*xlObjects)
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello")
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Takes disks offline till only onlineDisks are left.
+	setOnlineDisks := func(onlineDisks int) {
+		for i := onlineDisks; i < len(xl.storageDisks); i++ {
+			if _, ok := xl.storageDisks[i].(offlineDisk); !ok {
+				xl.storageDisks[i] = offlineDisk{xl.storageDisks[i]}
+			}
+		}
+		xl.quorum.Probe()
+	}
+
+	testCases := []struct {
+		onlineDisks  int
+		readQuorum   bool
+		writeQuorum  bool
+		expectedCode int
+	}{
+		// Test case - 1.
+		// Write quorum holds.
+		{xl.writeQuorum, true, true, http.StatusOK},
+		// Test case - 2.
+		// Write quorum is lost, read quorum holds.
+		{xl.readQuorum, true, false, http.StatusOK},
+		// Test case - 3.
+		// Read quorum is lost.
+		{xl.readQuorum - 1, false, false, http.StatusServiceUnavailable},
+	}
+	for i, testCase := range testCases {
+		setOnlineDisks(testCase.onlineDisks)
+
+		object := "new-object"
+		_, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil, "")
+		if testCase.writeQuorum {
+			if err != nil {
+				t.Fatalf("Test %d: Expected write to succeed, got %s", i+1, err)
+			}
+			if err = obj.DeleteObject(bucket, object); err != nil {
+				t.Fatalf("Test %d: Expected delete to succeed, got %s", i+1, err)
+			}
+		} else {
+			if _, ok := errorCause(err).(InsufficientWriteQuorum); !ok {
+				t.Fatalf("Test %d: Expected InsufficientWriteQuorum, got %v", i+1, err)
+			}
+			if err = obj.MakeBucket("new-bucket"); err == nil {
+				t.Fatalf("Test %d: Expected MakeBucket to fail", i+1)
+			}
+			if _, err = obj.NewMultipartUpload(bucket, object, nil); err == nil {
+				t.Fatalf("Test %d: Expected NewMultipartUpload to fail", i+1)
+			}
+			// Nothing should be written to the disks.
+			if xl.isObject(bucket, object) {
+				t.Fatalf("Test %d: Expected object not to be written", i+1)
+			}
+		}
+
+		var buf bytes.Buffer
+		err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf)
+		if testCase.readQuorum {
+			if err != nil {
+				t.Fatalf("Test %d: Expected read to succeed, got %s", i+1, err)
+			}
+			if !bytes.Equal(buf.Bytes(), data) {
+				t.Fatalf("Test %d: Object content mismatch", i+1)
+			}
+			if _, err = obj.ListObjects(bucket, "", "", "", 10); err != nil {
+				t.Fatalf("Test %d: Expected listing to succeed, got %s", i+1, err)
+			}
+		} else {
+			if _, ok := errorCause(err).(InsufficientReadQuorum); !ok {
+				t.Fatalf("Test %d: Expected InsufficientReadQuorum, got %v", i+1, err)
+			}
+			if _, err = obj.GetObjectInfo(bucket, "object"); err == nil {
+				t.Fatalf("Test %d: Expected GetObjectInfo to fail", i+1)
+			}
+		}
+
+		// Verify the quorum status reported by server info.
+		info, err := getLocalServerInfoData()
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if info.Quorum.ReadQuorum != testCase.readQuorum || info.Quorum.WriteQuorum != testCase.writeQuorum {
+			t.Fatalf("Test %d: Unexpected quorum status in server info %+v", i+1, info.Quorum)
+		}
+
+		// Verify the readiness probe.
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", reservedBucket+healthCheckPath+healthCheckReadyPath, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ReadinessCheckHandler(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Fatalf("Test %d: Expected readiness status %d, got %d", i+1, testCase.expectedCode, rec.Code)
+		}
+		var quorumStatus QuorumStatus
+		body, _ := ioutil.ReadAll(rec.Body)
+		if err = json.Unmarshal(body, &quorumStatus); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if quorumStatus != info.Quorum {
+			t.Fatalf("Test %d: Expected readiness quorum status %+v, got %+v", i+1, info.Quorum, quorumStatus)
+		}
+	}
+}
+
+// Tests that quorum state is cached between the probes.
+func TestQuorumMonitorInterval(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	xl := obj.(*xlObjects)
+	monitor := newQuorumMonitor(xl.storageDisks, time.Hour)
+	defer monitor.Close()
+	if onlineDisks := monitor.OnlineDisks(); onlineDisks != len(xl.storageDisks) {
+		t.Fatalf("Expected %d online disks, got %d", len(xl.storageDisks), onlineDisks)
+	}
+
+	xl.storageDisks[0] = nil
+	xl.storageDisks[1] = offlineDisk{xl.storageDisks[1]}
+	if onlineDisks := monitor.OnlineDisks(); onlineDisks != len(xl.storageDisks) {
+		t.Fatalf("Expected cached count %d, got %d", len(xl.storageDisks), onlineDisks)
+	}
+	monitor.Probe()
+	if onlineDisks := monitor.OnlineDisks(); onlineDisks != len(xl.storageDisks)-2 {
+		t.Fatalf("Expected %d online disks, got %d", len(xl.storageDisks)-2, onlineDisks)
+	}
+}
+
+// Tests that the disks are probed in background at each interval.
+func TestQuorumMonitorBackgroundProbe(t *testing.T) {
+	rootPath, err := newTestConfig(globalMinioDefaultRegion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(rootPath)
+
+	obj, fsDirs, err := prepareXL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+
+	xl := obj.(*xlObjects)
+	var failing int32
+	disks := append([]StorageAPI(nil), xl.storageDisks...)
+	disks[0] = failingDisk{disks[0], &failing}
+	monitor := newQuorumMonitor(disks, 10*time.Millisecond)
+	defer monitor.Close()
+	if onlineDisks := monitor.OnlineDisks(); onlineDisks != len(disks) {
+		t.Fatalf("Expected %d online disks, got %d", len(disks), onlineDisks)
+	}
+
+	atomic.StoreInt32(&failing, 1)
+	deadline := time.Now().Add(5 * time.Second)
+	for monitor.OnlineDisks() != len(disks)-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d online disks, got %d", len(disks)-1, monitor.OnlineDisks())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+const (
+	// Number of parts of a multipart object read concurrently,
+	// this includes the part currently being written to the client.
+	xlReadAheadParts = 3
+
+	// Maximum number of bytes buffered in memory for each part
+	// being read ahead of the client.
+	xlReadAheadBufferSize = blockSizeV1
+)
+
+// errReadAheadAborted - returned to part readers when the consumer has
+// stopped reading, for example when the client went away.
+var errReadAheadAborted = errors.New("Read ahead aborted")
+
+// partReadFn - reads a single part and writes its content to the writer.
+type partReadFn func(writer io.Writer) error
+
+// readAheadBuffer - bounded in-memory buffer between a part reader and
+// the consumer writing to the client. Writes block once `limit` bytes
+// are buffered, until the consumer drains them.
+type readAheadBuffer struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	bufs    [][]byte
+	size    int64
+	limit   int64
+	err     error
+	closed  bool
+	aborted bool
+}
+
+// newReadAheadBuffer - initializes a new read ahead buffer which holds
+// at most limit bytes, plus the size of a single write.
+func newReadAheadBuffer(limit int64) *readAheadBuffer {
+	b := &readAheadBuffer{limit: limit}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// Write - buffers a copy of p, blocks while the buffer is full.
+func (b *readAheadBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for b.size >= b.limit && !b.aborted {
+		b.cond.Wait()
+	}
+	if b.aborted {
+		return 0, errReadAheadAborted
+	}
+	// Callers re-use their buffers, save a copy.
+	b.bufs = append(b.bufs, append([]byte(nil), p...))
+	b.size += int64(len(p))
+	b.cond.Broadcast()
+	return len(p), nil
+}
+
+// CloseWithError - marks the end of the part, err is returned to the
+// consumer once all the buffered data is drained.
+func (b *readAheadBuffer) CloseWithError(err error) {
+	b.mu.Lock()
+	b.closed = true
+	b.err = err
+	b.cond.Broadcast()
+	b.mu.Unlock()
+}
+
+// Abort - unblocks the part reader, all its subsequent writes fail.
+func (b *readAheadBuffer) Abort() {
+	b.mu.Lock()
+	b.aborted = true
+	b.bufs = nil
+	b.size = 0
+	b.cond.Broadcast()
+	b.mu.Unlock()
+}
+
+// drainTo - writes all the buffered data to writer as it arrives, till
+// the part reader closes the buffer.
+func (b *readAheadBuffer) drainTo(writer io.Writer) error {
+	for {
+		b.mu.Lock()
+		for len(b.bufs) == 0 && !b.closed {
+			b.cond.Wait()
+		}
+		if len(b.bufs) == 0 {
+			err := b.err
+			b.mu.Unlock()
+			return err
+		}
+		buf := b.bufs[0]
+		b.bufs[0] = nil
+		b.bufs = b.bufs[1:]
+		b.size -= int64(len(buf))
+		b.cond.Broadcast()
+		b.mu.Unlock()
+
+		if _, err := writer.Write(buf); err != nil {
+			return traceError(err)
+		}
+	}
+}
+
+// readPartsAhead - reads upto `window` parts concurrently while writing
+// them to the writer strictly in part order. Memory usage is bounded
+// by window * xlReadAheadBufferSize. Returns only once all the part
+// readers returned, so that none of them reads the object after its
+// lock is released.
+func readPartsAhead(writer io.Writer, partReads []partReadFn, window int) error {
+	if window < 1 {
+		window = 1
+	}
+
+	var wg sync.WaitGroup
+	buffers := make([]*readAheadBuffer, len(partReads))
+	// Abort all part readers which might still be in progress, this
+	// is a no-op for parts which are already fully drained, and wait
+	// for them to return.
+	defer func() {
+		for _, buf := range buffers {
+			if buf != nil {
+				buf.Abort()
+			}
+		}
+		wg.Wait()
+	}()
+
+	for index := range partReads {
+		// Start reading parts ahead of the current part, upto window.
+		for next := index; next < len(partReads) && next < index+window; next++ {
+			if buffers[next] != nil {
+				continue
+			}
+			buf := newReadAheadBuffer(xlReadAheadBufferSize)
+			buffers[next] = buf
+			wg.Add(1)
+			go func(readPart partReadFn) {
+				defer wg.Done()
+				buf.CloseWithError(readPart(buf))
+			}(partReads[next])
+		}
+
+		// Write the current part to the client.
+		if err := buffers[index].drainTo(writer); err != nil {
+			return err
+		}
+	}
+
+	// Success.
+	return nil
+}
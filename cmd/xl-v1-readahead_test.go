@@ -0,0 +1,209 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// Returns synthetic part reads, each part writes its content in small
+// writes after the given delay.
+func newTestPartReads(parts [][]byte, delay func(index int) time.Duration) []partReadFn {
+	partReads := make([]partReadFn, len(parts))
+	for i := range parts {
+		index := i
+		partReads[i] = func(w io.Writer) error {
+			time.Sleep(delay(index))
+			data := parts[index]
+			for len(data) > 0 {
+				n := 1024
+				if n > len(data) {
+					n = len(data)
+				}
+				if _, err := w.Write(data[:n]); err != nil {
+					return err
+				}
+				data = data[n:]
+			}
+			return nil
+		}
+	}
+	return partReads
+}
+
+// Tests that parts read ahead are written in order.
+func TestReadPartsAheadOrder(t *testing.T) {
+	var parts [][]byte
+	var expected []byte
+	for i := 0; i < 10; i++ {
+		part := bytes.Repeat([]byte{byte('a' + i)}, 4096+i*512)
+		parts = append(parts, part)
+		expected = append(expected, part...)
+	}
+
+	// Later parts finish earlier than the former ones.
+	delay := func(index int) time.Duration {
+		return time.Duration(len(parts)-index) * time.Millisecond
+	}
+	for _, window := range []int{0, 1, 2, xlReadAheadParts, 20} {
+		var buf bytes.Buffer
+		if err := readPartsAhead(&buf, newTestPartReads(parts, delay), window); err != nil {
+			t.Fatalf("Window %d: Unexpected error %s", window, err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Fatalf("Window %d: Parts are not written in order", window)
+		}
+	}
+
+	// Random delays.
+	delay = func(index int) time.Duration {
+		return time.Duration(rand.Intn(5)) * time.Millisecond
+	}
+	var buf bytes.Buffer
+	if err := readPartsAhead(&buf, newTestPartReads(parts, delay), xlReadAheadParts); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Fatal("Parts are not written in order")
+	}
+}
+
+// Tests that errors of part reads are returned, and the data of parts
+// before the failed part are written.
+func TestReadPartsAheadError(t *testing.T) {
+	parts := [][]byte{[]byte("hello"), []byte("world"), []byte("!")}
+	partReads := newTestPartReads(parts, func(int) time.Duration { return 0 })
+	errPart := errors.New("part read failed")
+	partReads[1] = func(w io.Writer) error {
+		return errPart
+	}
+	var buf bytes.Buffer
+	if err := readPartsAhead(&buf, partReads, xlReadAheadParts); err != errPart {
+		t.Fatalf("Expected %s, got %s", errPart, err)
+	}
+	if buf.String() != "hello" {
+		t.Fatalf("Expected \"hello\", got %q", buf.String())
+	}
+
+	// Writer errors should abort all the part reads, which returned
+	// before readPartsAhead does.
+	aborted := make(chan error, len(parts))
+	for i := range partReads {
+		partReads[i] = func(w io.Writer) error {
+			var err error
+			for err == nil {
+				_, err = w.Write(make([]byte, 1024))
+			}
+			aborted <- err
+			return err
+		}
+	}
+	if err := readPartsAhead(NewEOFWriter(&buf, 0), partReads, xlReadAheadParts); err == nil {
+		t.Fatal("Expected error for a failed writer")
+	}
+	for i := 0; i < xlReadAheadParts; i++ {
+		select {
+		case err := <-aborted:
+			if err != errReadAheadAborted {
+				t.Fatalf("Expected %s, got %s", errReadAheadAborted, err)
+			}
+		default:
+			t.Fatal("Part reads were not aborted")
+		}
+	}
+}
+
+// Tests that reading parts ahead is faster than reading them serially.
+func TestReadPartsAheadThroughput(t *testing.T) {
+	parts := make([][]byte, 8)
+	for i := range parts {
+		parts[i] = bytes.Repeat([]byte{byte(i)}, 64*1024)
+	}
+	partDelay := 50 * time.Millisecond
+	delay := func(int) time.Duration { return partDelay }
+
+	startTime := time.Now()
+	if err := readPartsAhead(ioutil.Discard, newTestPartReads(parts, delay), 4); err != nil {
+		t.Fatalf("Unexpected error %s", err)
+	}
+	elapsed := time.Since(startTime)
+
+	// Serial read takes atleast len(parts) * partDelay.
+	serial := time.Duration(len(parts)) * partDelay
+	if elapsed >= serial/2 {
+		t.Fatalf("Expected parts to be read in less than %s, took %s", serial/2, elapsed)
+	}
+}
+
+// Tests reading a multipart object, with more parts than read ahead window.
+func TestXLGetObjectMultipartReadAhead(t *testing.T) {
+	ExecObjectLayerTest(t, testXLGetObjectMultipartReadAhead)
+}
+
+func testXLGetObjectMultipartReadAhead(obj ObjectLayer, instanceType string, t TestErrHandler) {
+	bucket, object := "bucket", "multipart-object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	partSize := int64(5 * 1024 * 1024)
+	var expected []byte
+	var parts []completePart
+	for i := 1; i <= xlReadAheadParts+2; i++ {
+		data := bytes.Repeat([]byte{byte('a' + i)}, int(partSize))
+		// Last part can be smaller than the minimum part size.
+		if i == xlReadAheadParts+2 {
+			data = data[:1024]
+		}
+		info, perr := obj.PutObjectPart(bucket, object, uploadID, i, int64(len(data)), bytes.NewReader(data), "", "")
+		if perr != nil {
+			t.Fatalf("%s: %s", instanceType, perr)
+		}
+		parts = append(parts, completePart{PartNumber: i, ETag: info.ETag})
+		expected = append(expected, data...)
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, object, uploadID, parts); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		offset, length int64
+	}{
+		{0, int64(len(expected))},
+		{partSize - 10, 2*partSize + 20},
+		{1, int64(len(expected)) - 1},
+	}
+	for i, testCase := range testCases {
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, object, testCase.offset, testCase.length, &buf); err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), expected[testCase.offset:testCase.offset+testCase.length]) {
+			t.Fatalf("%s: Test %d: Object content mismatch", instanceType, i+1)
+		}
+	}
+}
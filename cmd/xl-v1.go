@@ -71,6 +71,9 @@ type xlObjects struct {
 
 	// Object cache enabled.
 	objCacheEnabled bool
+
+	// Tracks the number of online disks to verify quorum.
+	quorum *quorumMonitor
 }
 
 // list of all errors that can be ignored in tree walk operation in XL
@@ -90,6 +93,66 @@ func newXLObjectLayer(storageDisks []StorageAPI) (ObjectLayer, error) {
 	err = initEventNotifier(objAPI)
 	fatalIf(err, "Unable to initialize event notification.")
 
+	// Initialize and load bucket logging configs.
+	err = initBucketLogging(objAPI)
+	fatalIf(err, "Unable to load all bucket logging configs.")
+
+	// Initialize and load bucket lifecycle configs.
+	err = initBucketLifecycle(objAPI)
+	fatalIf(err, "Unable to load all bucket lifecycle configs.")
+
+	// Initialize and load bucket replication configs.
+	err = initBucketReplication(objAPI)
+	fatalIf(err, "Unable to load all bucket replication configs.")
+
+	// Initialize and load bucket content sniffing configs.
+	err = initBucketContentSniffing(objAPI)
+	fatalIf(err, "Unable to load all bucket content sniffing configs.")
+
+	// Initialize and load bucket website configs.
+	err = initBucketWebsite(objAPI)
+	fatalIf(err, "Unable to load all bucket website configs.")
+
+	// Initialize and load bucket request payment configs.
+	err = initBucketRequestPayment(objAPI)
+	fatalIf(err, "Unable to load all bucket request payment configs.")
+
+	// Initialize and load bucket object lock configs.
+	err = initBucketObjectLock(objAPI)
+	fatalIf(err, "Unable to load all bucket object lock configs.")
+
+	// Initialize and load bucket trash configs.
+	err = initBucketTrash(objAPI)
+	fatalIf(err, "Unable to load all bucket trash configs.")
+
+	// Initialize and load bucket object size limits configs.
+	err = initBucketObjectSizeLimits(objAPI)
+	fatalIf(err, "Unable to load all bucket object size limits configs.")
+
+	// Initialize and load bucket public access block configs.
+	err = initBucketPublicAccessBlock(objAPI)
+	fatalIf(err, "Unable to load all bucket public access block configs.")
+
+	// Initialize and load bucket accelerate configs.
+	err = initBucketAccelerate(objAPI)
+	fatalIf(err, "Unable to load all bucket accelerate configs.")
+
+	// Initialize and load bucket client certificate configs.
+	err = initBucketClientCert(objAPI)
+	fatalIf(err, "Unable to load all bucket client certificate configs.")
+
+	// Initialize and load bucket content addressing configs.
+	err = initBucketContentAddressing(objAPI)
+	fatalIf(err, "Unable to load all bucket content addressing configs.")
+
+	// Initialize and load bucket ownership controls configs.
+	err = initBucketOwnershipControls(objAPI)
+	fatalIf(err, "Unable to load all bucket ownership controls configs.")
+
+	// Initialize bucket inventory.
+	err = initBucketInventory(objAPI)
+	fatalIf(err, "Unable to initialize bucket inventory.")
+
 	// Success.
 	return objAPI, nil
 }
@@ -125,6 +188,7 @@ func newXLObjects(storageDisks []StorageAPI) (ObjectLayer, error) {
 		dataBlocks:   dataBlocks,
 		parityBlocks: parityBlocks,
 		listPool:     listPool,
+		quorum:       newQuorumMonitor(newStorageDisks, globalQuorumCheckInterval),
 	}
 
 	// Object cache is enabled when _MINIO_CACHE env is missing.
@@ -163,6 +227,9 @@ func newXLObjects(storageDisks []StorageAPI) (ObjectLayer, error) {
 // Shutdown function for object storage interface.
 func (xl xlObjects) Shutdown() error {
 	// Add any object layer shutdown activities here.
+	if xl.quorum != nil {
+		xl.quorum.Close()
+	}
 	for _, disk := range xl.storageDisks {
 		// This closes storage rpc client connections if any.
 		// Otherwise this is a no-op.
@@ -0,0 +1,53 @@
+// +build ignore
+
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package main
+
+import (
+	"log"
+
+	"github.com/minio/minio/pkg/madmin"
+)
+
+func main() {
+	// Note: YOUR-ACCESSKEYID, YOUR-SECRETACCESSKEY and my-bucketname are
+	// dummy values, please replace them with original values.
+
+	// API requests are secure (HTTPS) if secure=true and insecure (HTTPS) otherwise.
+	// New returns an Minio Admin client object.
+	madmClnt, err := madmin.New("your-minio.example.com:9000", "YOUR-ACCESSKEYID", "YOUR-SECRETACCESSKEY", true)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	doneCh := make(chan struct{})
+	defer close(doneCh)
+
+	// Print the traces of the failed GET object requests.
+	traceCh, err := madmClnt.Trace([]string{"REST.GET.OBJECT"}, []string{"4xx", "5xx"}, doneCh)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for trace := range traceCh {
+		if trace.Err != nil {
+			log.Fatalln(trace.Err)
+		}
+		log.Println(trace.Method, trace.Path, trace.StatusCode, trace.ErrorCode, trace.Duration)
+	}
+}
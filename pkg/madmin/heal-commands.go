@@ -18,6 +18,8 @@
 package madmin
 
 import (
+	"bytes"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"net/http"
@@ -435,3 +437,98 @@ func (adm *AdminClient) HealFormat(dryrun bool) error {
 
 	return nil
 }
+
+// HealObjectsResult - result of the heal of the objects of a bucket.
+type HealObjectsResult struct {
+	Healed int               `json:"healed"`
+	Failed []HealObjectError `json:"failed,omitempty"`
+}
+
+// HealObjectError - object which could not be healed and the reason.
+type HealObjectError struct {
+	Object string `json:"object"`
+	Error  string `json:"error"`
+}
+
+// HealObjects - Heal all the objects needing heal in the given bucket
+// matching the given prefix.
+func (adm *AdminClient) HealObjects(bucket, prefix string, dryrun bool) (HealObjectsResult, error) {
+	// Construct query params.
+	queryVal := url.Values{}
+	queryVal.Set("heal", "")
+	queryVal.Set(string(healBucket), bucket)
+	queryVal.Set(string(healPrefix), prefix)
+	if dryrun {
+		queryVal.Set(string(healDryRun), "")
+	}
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, "objects")
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+
+	// Execute POST on /?heal&bucket=mybucket&prefix=myprefix to heal objects.
+	resp, err := adm.executeMethod("POST", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return HealObjectsResult{}, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return HealObjectsResult{}, httpRespToErrorResponse(resp)
+	}
+
+	// A dry run replies without any result.
+	var result HealObjectsResult
+	if dryrun {
+		return result, nil
+	}
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return HealObjectsResult{}, err
+	}
+	return result, nil
+}
+
+// setHealBandwidthReq - xml to send to the server to set the heal bandwidth
+type setHealBandwidthReq struct {
+	Bandwidth uint64 `xml:"bandwidth"`
+}
+
+// SetHealBandwidth - Set the rate in bytes per second at which the
+// healed data is written to the drives, 0 is unlimited.
+func (adm *AdminClient) SetHealBandwidth(bandwidth uint64) error {
+	// Setup new request
+	reqData := requestData{}
+	reqData.queryValues = make(url.Values)
+	reqData.queryValues.Set("heal", "")
+	reqData.customHeaders = make(http.Header)
+	reqData.customHeaders.Set(minioAdminOpHeader, "set-bandwidth")
+
+	// Setup request's body
+	body, err := xml.Marshal(setHealBandwidthReq{Bandwidth: bandwidth})
+	if err != nil {
+		return err
+	}
+	reqData.contentBody = bytes.NewReader(body)
+	reqData.contentLength = int64(len(body))
+	reqData.contentMD5Bytes = sumMD5(body)
+	reqData.contentSHA256Bytes = sum256(body)
+
+	// Execute POST on /?heal to set the heal bandwidth.
+	resp, err := adm.executeMethod("POST", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	// Return error to the caller if http response code is different from 200
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ConnStats - connections statistics of a server.
+type ConnStats struct {
+	Accepted       uint64 `json:"accepted"`
+	Rejected       uint64 `json:"rejected"`
+	Queued         int64  `json:"queued"`
+	Open           int64  `json:"open"`
+	MaxConnections int    `json:"maxConnections"`
+}
+
+// ServerMuxStats - requests statistics of a server.
+type ServerMuxStats struct {
+	ActiveRequests int64     `json:"activeRequests"`
+	TotalRequests  uint64    `json:"totalRequests"`
+	Connections    ConnStats `json:"connections"`
+}
+
+// ServerProperties - holds the version, uptime and region of a server.
+type ServerProperties struct {
+	Uptime        time.Duration `json:"uptime"`
+	Version       string        `json:"version"`
+	CommitID      string        `json:"commitID"`
+	Region        string        `json:"region"`
+	ReadOnly      bool          `json:"readOnly"`
+	HealBandwidth uint64        `json:"healBandwidth"`
+}
+
+// QuorumStatus - reports if the backend currently has enough disks
+// online to serve reads and writes.
+type QuorumStatus struct {
+	ReadQuorum  bool `json:"readQuorum"`
+	WriteQuorum bool `json:"writeQuorum"`
+}
+
+// RPCRetryStats - counters of the retried inter-node calls of a server.
+type RPCRetryStats struct {
+	Retries   uint64 `json:"retries"`
+	Recovered uint64 `json:"recovered"`
+	Exhausted uint64 `json:"exhausted"`
+}
+
+// ServerInfoData - holds the storage, requests and properties of a server.
+type ServerInfoData struct {
+	StorageInfo StorageInfo      `json:"storage"`
+	MuxStats    ServerMuxStats   `json:"mux"`
+	Properties  ServerProperties `json:"server"`
+	Quorum      QuorumStatus     `json:"quorum"`
+	RPCRetry    RPCRetryStats    `json:"rpcRetry"`
+}
+
+// ServerInfo - holds the server info of a node, Error is set when the
+// node could not be reached.
+type ServerInfo struct {
+	Error string          `json:"error"`
+	Addr  string          `json:"addr"`
+	Data  *ServerInfoData `json:"data"`
+}
+
+// ServerInfo - Connect to a minio server and call Server Info Management API
+// to fetch server's information represented by ServerInfo structure
+func (adm *AdminClient) ServerInfo() ([]ServerInfo, error) {
+	// Prepare web service request
+	reqData := requestData{}
+	reqData.queryValues = make(url.Values)
+	reqData.queryValues.Set("info", "")
+	reqData.customHeaders = make(http.Header)
+	reqData.customHeaders.Set(minioAdminOpHeader, "server")
+
+	resp, err := adm.executeMethod("GET", reqData)
+	defer closeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check response http status code
+	if resp.StatusCode != http.StatusOK {
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	// Unmarshal the server's json response
+	var serversInfo []ServerInfo
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(respBytes, &serversInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return serversInfo, nil
+}
@@ -179,3 +179,43 @@ func (adm *AdminClient) ServiceSetCredentials(access, secret string) error {
 	}
 	return nil
 }
+
+// setReadOnlyReq - xml to send to the server to set read-only mode
+type setReadOnlyReq struct {
+	ReadOnly bool `xml:"readOnly"`
+}
+
+// ServiceSetReadOnly - Call Service Set Read-Only API to enable or
+// disable read-only mode of the specified Minio server.
+func (adm *AdminClient) ServiceSetReadOnly(readOnly bool) error {
+	// Setup new request
+	reqData := requestData{}
+	reqData.queryValues = make(url.Values)
+	reqData.queryValues.Set("service", "")
+	reqData.customHeaders = make(http.Header)
+	reqData.customHeaders.Set(minioAdminOpHeader, "set-read-only")
+
+	// Setup request's body
+	body, err := xml.Marshal(setReadOnlyReq{ReadOnly: readOnly})
+	if err != nil {
+		return err
+	}
+	reqData.contentBody = bytes.NewReader(body)
+	reqData.contentLength = int64(len(body))
+	reqData.contentMD5Bytes = sumMD5(body)
+	reqData.contentSHA256Bytes = sum256(body)
+
+	// Execute POST on /?service to set read-only mode.
+	resp, err := adm.executeMethod("POST", reqData)
+
+	defer closeResponse(resp)
+	if err != nil {
+		return err
+	}
+
+	// Return error to the caller if http response code is different from 200
+	if resp.StatusCode != http.StatusOK {
+		return httpRespToErrorResponse(resp)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2017 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package madmin
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TraceInfo - trace of a request served by the server.
+type TraceInfo struct {
+	Time          time.Time     `json:"time"`
+	API           string        `json:"api"`
+	Method        string        `json:"method"`
+	Path          string        `json:"path"`
+	StatusCode    int           `json:"statusCode"`
+	ErrorCode     string        `json:"errorCode,omitempty"`
+	Duration      time.Duration `json:"duration"`
+	BytesReceived int64         `json:"bytesReceived"`
+	BytesSent     int64         `json:"bytesSent"`
+	RemoteAddr    string        `json:"remoteAddr"`
+	RequestID     string        `json:"requestID,omitempty"`
+	// Number of traces dropped by the server before this one as the
+	// client did not keep up.
+	Dropped uint64 `json:"dropped,omitempty"`
+
+	// Error which ended the stream of traces.
+	Err error `json:"-"`
+}
+
+// Trace - streams the traces of the requests served by the server
+// until doneCh is closed. Traces are filtered by the APIs like
+// REST.GET.OBJECT and the statuses like 404 or 5xx if any.
+func (adm *AdminClient) Trace(apis, statuses []string, doneCh <-chan struct{}) (<-chan TraceInfo, error) {
+	queryVal := make(url.Values)
+	queryVal.Set("trace", "")
+	for _, api := range apis {
+		queryVal.Add("api", api)
+	}
+	for _, status := range statuses {
+		queryVal.Add("status", status)
+	}
+
+	hdrs := make(http.Header)
+	hdrs.Set(minioAdminOpHeader, "trace")
+
+	reqData := requestData{
+		queryValues:   queryVal,
+		customHeaders: hdrs,
+	}
+
+	// Execute GET on /?trace to stream the traces.
+	resp, err := adm.executeMethod("GET", reqData)
+	if err != nil {
+		closeResponse(resp)
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer closeResponse(resp)
+		return nil, httpRespToErrorResponse(resp)
+	}
+
+	traceCh := make(chan TraceInfo)
+	go func() {
+		defer close(traceCh)
+		defer closeResponse(resp)
+
+		// Stop reading once done.
+		go func() {
+			<-doneCh
+			resp.Body.Close()
+		}()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			// Empty lines keep the connection active.
+			if len(scanner.Bytes()) == 0 {
+				continue
+			}
+			var info TraceInfo
+			if err := json.Unmarshal(scanner.Bytes(), &info); err != nil {
+				info = TraceInfo{Err: err}
+			}
+			select {
+			case traceCh <- info:
+			case <-doneCh:
+				return
+			}
+			if info.Err != nil {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			select {
+			case traceCh <- TraceInfo{Err: err}:
+			case <-doneCh:
+			}
+		}
+	}()
+	return traceCh, nil
+}